@@ -8,8 +8,8 @@ import (
 	"github.com/kubernetes-incubator/kube-aws/pkg/api"
 )
 
-// NewSessionFromRegion creates an AWS session from AWS region and a debug flag
-func NewSessionFromRegion(region api.Region, debug bool) (*session.Session, error) {
+// NewSessionFromRegion creates an AWS session from AWS region, a debug flag and the retry settings shared by all the AWS service clients
+func NewSessionFromRegion(region api.Region, debug bool, retry api.AWSAPIRetry) (*session.Session, error) {
 	awsConfig := aws.NewConfig().
 		WithRegion(region.String()).
 		WithCredentialsChainVerboseErrors(true)
@@ -22,7 +22,7 @@ func NewSessionFromRegion(region api.Region, debug bool) (*session.Session, erro
 	if err != nil {
 		return nil, fmt.Errorf("failed to establish aws session: %v", err)
 	}
-	return session, nil
+	return withRetryer(session, retry), nil
 }
 
 // newSession returns an AWS session which supports source_profile and assume role with MFA
@@ -36,3 +36,17 @@ func newSession(config *aws.Config) (*session.Session, error) {
 		AssumeRoleTokenProvider: stscreds.StdinTokenProvider,
 	})
 }
+
+// WithAssumedRole returns a copy of the session whose credentials are of the role assumed via STS with the base session.
+// The base session is returned as-is when no role is specified.
+func WithAssumedRole(base *session.Session, role api.AssumeRole) *session.Session {
+	if !role.Enabled() {
+		return base
+	}
+	creds := stscreds.NewCredentials(base, role.RoleARN, func(p *stscreds.AssumeRoleProvider) {
+		if role.ExternalID != "" {
+			p.ExternalID = aws.String(role.ExternalID)
+		}
+	})
+	return base.Copy(&aws.Config{Credentials: creds})
+}
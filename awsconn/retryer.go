@@ -0,0 +1,129 @@
+package awsconn
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/kubernetes-incubator/kube-aws/pkg/api"
+)
+
+const (
+	retryBaseDelay         = 30 * time.Millisecond
+	retryThrottleBaseDelay = 500 * time.Millisecond
+)
+
+// retryer retries failed requests like client.DefaultRetryer does, but with the exponential backoff capped by the configured max backoff.
+// Each delay is jittered between the half and the whole of the exponential backoff so that concurrent retries spread out
+type retryer struct {
+	client.DefaultRetryer
+	maxBackoff time.Duration
+}
+
+func newRetryer(c api.AWSAPIRetry) retryer {
+	return retryer{
+		DefaultRetryer: client.DefaultRetryer{NumMaxRetries: c.MaxRetriesOrDefault()},
+		maxBackoff:     c.MaxBackoffOrDefault(),
+	}
+}
+
+func (r retryer) RetryRules(req *request.Request) time.Duration {
+	base := retryBaseDelay
+	if throttled(req) {
+		base = retryThrottleBaseDelay
+	}
+	backoff := r.maxBackoff
+	// Beyond 2^20 times the base delay exceeds the upper limit of the max backoff anyway
+	if req.RetryCount < 20 {
+		if d := base << uint(req.RetryCount); d < backoff {
+			backoff = d
+		}
+	}
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+func throttled(req *request.Request) bool {
+	if req.HTTPResponse != nil {
+		switch req.HTTPResponse.StatusCode {
+		case 429, 502, 503, 504:
+			return true
+		}
+	}
+	return req.IsErrorThrottle()
+}
+
+// throttleLimiter spaces out the requests of all the clients sharing it while AWS is throttling them.
+// The spacing doubles on each throttled attempt up to the max backoff and halves on each succeeded request
+type throttleLimiter struct {
+	mu       sync.Mutex
+	spacing  time.Duration
+	next     time.Time
+	min, max time.Duration
+}
+
+func (l *throttleLimiter) wait(*request.Request) {
+	l.mu.Lock()
+	now := time.Now()
+	start := l.next
+	if start.Before(now) {
+		start = now
+	}
+	l.next = start.Add(l.spacing)
+	l.mu.Unlock()
+
+	time.Sleep(start.Sub(now))
+}
+
+func (l *throttleLimiter) observeRetry(req *request.Request) {
+	if throttled(req) {
+		l.adjust(true)
+	}
+}
+
+func (l *throttleLimiter) observeComplete(req *request.Request) {
+	if req.Error == nil {
+		l.adjust(false)
+	}
+}
+
+func (l *throttleLimiter) adjust(throttled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	switch {
+	case !throttled:
+		l.spacing /= 2
+		if l.spacing < l.min {
+			l.spacing = 0
+		}
+	case l.spacing == 0:
+		l.spacing = l.min
+	default:
+		l.spacing *= 2
+		if l.spacing > l.max {
+			l.spacing = l.max
+		}
+	}
+}
+
+// withRetryer makes all the clients created from the session retry failed requests as configured.
+// In the adaptive mode, the clients share a throttleLimiter
+func withRetryer(s *session.Session, c api.AWSAPIRetry) *session.Session {
+	if !c.Enabled() {
+		return s
+	}
+
+	s = s.Copy(request.WithRetryer(aws.NewConfig(), newRetryer(c)))
+
+	if c.Adaptive() {
+		l := &throttleLimiter{min: retryBaseDelay, max: c.MaxBackoffOrDefault()}
+		s.Handlers.Send.PushFrontNamed(request.NamedHandler{Name: "kube-aws.throttleLimiter.wait", Fn: l.wait})
+		s.Handlers.Retry.PushFrontNamed(request.NamedHandler{Name: "kube-aws.throttleLimiter.observeRetry", Fn: l.observeRetry})
+		s.Handlers.Complete.PushBackNamed(request.NamedHandler{Name: "kube-aws.throttleLimiter.observeComplete", Fn: l.observeComplete})
+	}
+
+	return s
+}
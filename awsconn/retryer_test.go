@@ -0,0 +1,63 @@
+package awsconn
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/kubernetes-incubator/kube-aws/pkg/api"
+)
+
+func TestRetryerRetryRules(t *testing.T) {
+	r := newRetryer(api.AWSAPIRetry{Mode: "adaptive", MaxBackoff: "5s"})
+
+	testCases := []struct {
+		statusCode int
+		retryCount int
+		min, max   time.Duration
+	}{
+		{statusCode: 500, retryCount: 0, min: 15 * time.Millisecond, max: 30 * time.Millisecond},
+		{statusCode: 500, retryCount: 3, min: 120 * time.Millisecond, max: 240 * time.Millisecond},
+		{statusCode: 400, retryCount: 2, min: time.Second, max: 2 * time.Second},
+		{statusCode: 500, retryCount: 10, min: 2500 * time.Millisecond, max: 5 * time.Second},
+		{statusCode: 400, retryCount: 100, min: 2500 * time.Millisecond, max: 5 * time.Second},
+	}
+
+	for i, testCase := range testCases {
+		req := &request.Request{
+			HTTPResponse: &http.Response{StatusCode: testCase.statusCode},
+			RetryCount:   testCase.retryCount,
+		}
+		if testCase.statusCode == 400 {
+			req.Error = awserr.New("Throttling", "Rate exceeded", nil)
+		}
+		for j := 0; j < 10; j++ {
+			if d := r.RetryRules(req); d < testCase.min || d > testCase.max {
+				t.Errorf("case %d: expected the delay to be between %v and %v, but was %v", i, testCase.min, testCase.max, d)
+			}
+		}
+	}
+
+	if actual := r.MaxRetries(); actual != 10 {
+		t.Errorf("unexpected max retries: expected 10, got %d", actual)
+	}
+}
+
+func TestThrottleLimiterAdjust(t *testing.T) {
+	l := &throttleLimiter{min: 30 * time.Millisecond, max: 100 * time.Millisecond}
+
+	for i, expected := range []time.Duration{30, 60, 100, 100} {
+		l.adjust(true)
+		if l.spacing != expected*time.Millisecond {
+			t.Errorf("throttled %d times: expected the spacing to be %v, got %v", i+1, expected*time.Millisecond, l.spacing)
+		}
+	}
+	for i, expected := range []time.Duration{50, 0} {
+		l.adjust(false)
+		if l.spacing != expected*time.Millisecond {
+			t.Errorf("succeeded %d times: expected the spacing to be %v, got %v", i+1, expected*time.Millisecond, l.spacing)
+		}
+	}
+}
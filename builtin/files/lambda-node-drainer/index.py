@@ -0,0 +1,73 @@
+# Drains the node being terminated by the auto scaling group and then completes the lifecycle action.
+# Embedded into the node pool stack template as is, this must be kept smaller than 4096 bytes
+import base64, json, os, ssl, time, urllib.error, urllib.request
+import boto3
+
+ssm = boto3.client('ssm')
+autoscaling = boto3.client('autoscaling')
+
+
+def handler(event, context):
+    d = event['detail']
+    try:
+        drain(d['EC2InstanceId'], context)
+    finally:
+        autoscaling.complete_lifecycle_action(
+            LifecycleHookName=d['LifecycleHookName'],
+            AutoScalingGroupName=d['AutoScalingGroupName'],
+            LifecycleActionToken=d['LifecycleActionToken'],
+            LifecycleActionResult='CONTINUE')
+
+
+def drain(instance_id, context):
+    cred = json.loads(ssm.get_parameter(Name=os.environ['CREDENTIALS_PARAMETER'], WithDecryption=True)['Parameter']['Value'])
+    api = client(base64.b64decode(cred['token']).decode(), ssl.create_default_context(cadata=base64.b64decode(cred['ca']).decode()))
+
+    nodes = [n['metadata']['name'] for n in api('GET', '/api/v1/nodes')['items']
+             if n['spec'].get('providerID', '').endswith('/' + instance_id)]
+    if not nodes:
+        print('no node found for %s' % instance_id)
+        return
+    node = nodes[0]
+
+    print('cordoning %s' % node)
+    api('PATCH', '/api/v1/nodes/' + node, {'spec': {'unschedulable': True}}, 'application/strategic-merge-patch+json')
+
+    # Keep retrying evictions rejected by PodDisruptionBudgets until the function is about to time out
+    while context.get_remaining_time_in_millis() > 15000:
+        pods = [p for p in api('GET', '/api/v1/pods?fieldSelector=spec.nodeName%3D' + node)['items'] if evictable(p)]
+        if not pods:
+            print('drained %s' % node)
+            return
+        for p in pods:
+            m = p['metadata']
+            try:
+                api('POST', '/api/v1/namespaces/%s/pods/%s/eviction' % (m['namespace'], m['name']), {
+                    'apiVersion': os.environ['EVICTION_API_VERSION'],
+                    'kind': 'Eviction',
+                    'metadata': {'name': m['name'], 'namespace': m['namespace']}})
+            except urllib.error.HTTPError as e:
+                if e.code not in (404, 429):
+                    raise
+        time.sleep(5)
+    print('timed out draining %s' % node)
+
+
+def evictable(pod):
+    m = pod['metadata']
+    if 'kubernetes.io/config.mirror' in m.get('annotations', {}):
+        return False
+    if any(o['kind'] == 'DaemonSet' for o in m.get('ownerReferences', [])):
+        return False
+    return pod['status'].get('phase') not in ('Succeeded', 'Failed')
+
+
+def client(token, ctx):
+    def api(method, path, body=None, content_type='application/json'):
+        req = urllib.request.Request(
+            os.environ['API_ENDPOINT'] + path, method=method,
+            data=json.dumps(body).encode() if body is not None else None,
+            headers={'Authorization': 'Bearer ' + token, 'Content-Type': content_type})
+        with urllib.request.urlopen(req, context=ctx, timeout=10) as r:
+            return json.load(r)
+    return api
@@ -180,3 +180,64 @@ func (p AssetLocationProvider) Locate(filename string) (*api.AssetLocation, erro
 func (p AssetLocationProvider) S3Prefix() string {
 	return fmt.Sprintf("%s/%s", p.s3URI.BucketAndKey(), p.stackName)
 }
+
+// ExcludeAssets returns the assets except the ones named any of the file names, in any stack
+func ExcludeAssets(assets Assets, filenames ...string) Assets {
+	excluded := map[string]bool{}
+	for _, f := range filenames {
+		excluded[f] = true
+	}
+
+	remaining := map[api.AssetID]api.Asset{}
+	for id, a := range assets.AsMap() {
+		if !excluded[id.Filename] {
+			remaining[id] = a
+		}
+	}
+
+	return assetsImpl{
+		s3Prefix:   assets.S3Prefix(),
+		underlying: remaining,
+	}
+}
+
+// RelocateAssets returns the copies of the assets located under the S3 URI `from`, which are located under the S3 URI `to` in the region
+// with the same keys relative to the URIs. It is used to replicate the assets to the bucket in another region
+func RelocateAssets(assets Assets, from string, to string, region api.Region) (Assets, error) {
+	src, err := S3URIFromString(from)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := S3URIFromString(to)
+	if err != nil {
+		return nil, err
+	}
+	srcPrefix := strings.Join(src.KeyComponents(), "/")
+	dstPrefix := strings.Join(dst.KeyComponents(), "/")
+
+	relocated := map[api.AssetID]api.Asset{}
+	for id, a := range assets.AsMap() {
+		if a.Bucket != src.Bucket() {
+			return nil, fmt.Errorf("[bug] the asset %s/%s is not located in the bucket of %s", id.StackName, id.Filename, from)
+		}
+		key := a.Key
+		if srcPrefix != "" {
+			if !strings.HasPrefix(key, srcPrefix+"/") {
+				return nil, fmt.Errorf("[bug] the asset %s/%s is not located under %s", id.StackName, id.Filename, from)
+			}
+			key = strings.TrimPrefix(key, srcPrefix+"/")
+		}
+		if dstPrefix != "" {
+			key = dstPrefix + "/" + key
+		}
+		a.Bucket = dst.Bucket()
+		a.Key = key
+		a.Region = region
+		relocated[id] = a
+	}
+
+	return assetsImpl{
+		s3Prefix:   strings.Replace(assets.S3Prefix(), src.BucketAndKey(), dst.BucketAndKey(), 1),
+		underlying: relocated,
+	}, nil
+}
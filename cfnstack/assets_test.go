@@ -0,0 +1,69 @@
+package cfnstack
+
+import (
+	"testing"
+
+	"github.com/kubernetes-incubator/kube-aws/pkg/api"
+)
+
+func TestRelocateAssets(t *testing.T) {
+	testCases := []struct {
+		to          string
+		expectedKey string
+	}{
+		{
+			to:          "s3://my-dr-bucket/dr/dir",
+			expectedKey: "dr/dir/kube-aws/clusters/mycluster/exported/stacks/control-plane/stack.json",
+		},
+		{
+			to:          "s3://my-dr-bucket",
+			expectedKey: "kube-aws/clusters/mycluster/exported/stacks/control-plane/stack.json",
+		},
+	}
+
+	for i, testCase := range testCases {
+		builder, err := NewAssetsBuilder("control-plane", "s3://mybucket/mydir/kube-aws/clusters/mycluster/exported/stacks", api.RegionForName("us-west-1"))
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if _, err := builder.Add("stack.json", "{}"); err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+
+		relocated, err := RelocateAssets(builder.Build(), "s3://mybucket/mydir", testCase.to, api.RegionForName("us-east-1"))
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		a, err := relocated.FindAssetByStackAndFileName("control-plane", "stack.json")
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if a.Bucket != "my-dr-bucket" || a.Key != testCase.expectedKey || a.Region.Name != "us-east-1" || a.Content != "{}" {
+			t.Errorf("case %d: unexpected relocated asset: %+v", i, a)
+		}
+	}
+
+	builder, _ := NewAssetsBuilder("control-plane", "s3://otherbucket/mydir", api.RegionForName("us-west-1"))
+	builder.Add("stack.json", "{}")
+	if _, err := RelocateAssets(builder.Build(), "s3://mybucket/mydir", "s3://my-dr-bucket", api.RegionForName("us-east-1")); err == nil {
+		t.Error("expected relocating the assets in another bucket to fail, but it didn't")
+	}
+}
+
+func TestExcludeAssets(t *testing.T) {
+	builder, _ := NewAssetsBuilder("control-plane", "s3://mybucket/mydir", api.RegionForName("us-west-1"))
+	builder.Add("stack.json", "{}")
+	builder.Add("oidc-ca.pem", "ca")
+	builder.Add("apiserver.pem", "cert")
+
+	remaining := ExcludeAssets(builder.Build(), "oidc-ca.pem", "apiserver.pem")
+	if len(remaining.AsMap()) != 1 {
+		t.Errorf("expected the assets but the excluded ones to remain, but got: %+v", remaining.AsMap())
+	}
+	if _, err := remaining.FindAssetByStackAndFileName("control-plane", "stack.json"); err != nil {
+		t.Errorf("expected the stack template to remain, but it didn't: %v", err)
+	}
+	if remaining.S3Prefix() != "mybucket/mydir/control-plane" {
+		t.Errorf("unexpected s3 prefix: %s", remaining.S3Prefix())
+	}
+}
@@ -3,6 +3,7 @@ package cfnstack
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
@@ -26,6 +27,21 @@ type CRUDService interface {
 	DescribeStacks(input *cloudformation.DescribeStacksInput) (*cloudformation.DescribeStacksOutput, error)
 	DescribeStackEvents(input *cloudformation.DescribeStackEventsInput) (*cloudformation.DescribeStackEventsOutput, error)
 	EstimateTemplateCost(input *cloudformation.EstimateTemplateCostInput) (*cloudformation.EstimateTemplateCostOutput, error)
+	UpdateTerminationProtection(input *cloudformation.UpdateTerminationProtectionInput) (*cloudformation.UpdateTerminationProtectionOutput, error)
+}
+
+type StackEventsDescriber interface {
+	DescribeStackEvents(input *cloudformation.DescribeStackEventsInput) (*cloudformation.DescribeStackEventsOutput, error)
+}
+
+type TerminationProtectionService interface {
+	DescribeStacks(input *cloudformation.DescribeStacksInput) (*cloudformation.DescribeStacksOutput, error)
+	UpdateTerminationProtection(input *cloudformation.UpdateTerminationProtectionInput) (*cloudformation.UpdateTerminationProtectionOutput, error)
+}
+
+type ContinueUpdateRollbackService interface {
+	DescribeStacks(input *cloudformation.DescribeStacksInput) (*cloudformation.DescribeStacksOutput, error)
+	ContinueUpdateRollback(input *cloudformation.ContinueUpdateRollbackInput) (*cloudformation.ContinueUpdateRollbackOutput, error)
 }
 
 type S3ObjectPutterService interface {
@@ -60,6 +76,63 @@ func StackEventErrMsgs(events []*cloudformation.StackEvent) []string {
 	return errMsgs
 }
 
+// StackUpdateErrMsgs returns the failures happened in the stack and its nested stacks since the update started, in the order they happened.
+// Each failure of a nested stack is followed by the failures inside it, which are usually the actual causes like missing resource signals
+func StackUpdateErrMsgs(cf StackEventsDescriber, stackId string, since time.Time) ([]string, error) {
+	var events []*cloudformation.StackEvent
+	input := &cloudformation.DescribeStackEventsInput{StackName: aws.String(stackId)}
+	for {
+		output, err := cf.DescribeStackEvents(input)
+		if err != nil {
+			return nil, err
+		}
+		done := false
+		// Events are returned in reverse chronological order
+		for _, e := range output.StackEvents {
+			if aws.TimeValue(e.Timestamp).Before(since) {
+				done = true
+				break
+			}
+			events = append(events, e)
+		}
+		if done || output.NextToken == nil {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+
+	var errMsgs []string
+	for i := len(events) - 1; i >= 0; i-- {
+		e := events[i]
+		status := aws.StringValue(e.ResourceStatus)
+		if status != cloudformation.ResourceStatusUpdateFailed && status != cloudformation.ResourceStatusCreateFailed {
+			continue
+		}
+		// Only show actual failures, not cancelled dependent resources.
+		if reason := aws.StringValue(e.ResourceStatusReason); reason == "Resource update cancelled" || reason == "Resource creation cancelled" {
+			continue
+		}
+		errMsgs = append(errMsgs,
+			strings.TrimSpace(
+				strings.Join([]string{
+					aws.StringValue(e.StackName) + ":",
+					status,
+					aws.StringValue(e.ResourceType),
+					aws.StringValue(e.LogicalResourceId),
+					aws.StringValue(e.ResourceStatusReason),
+				}, " ")))
+		if aws.StringValue(e.ResourceType) == "AWS::CloudFormation::Stack" && aws.StringValue(e.PhysicalResourceId) != "" && aws.StringValue(e.PhysicalResourceId) != aws.StringValue(e.StackId) {
+			nested, err := StackUpdateErrMsgs(cf, aws.StringValue(e.PhysicalResourceId), since)
+			if err != nil {
+				return nil, err
+			}
+			errMsgs = append(errMsgs, nested...)
+		}
+	}
+
+	return errMsgs, nil
+}
+
 func NestedStackExists(cf CFInterrogator, parentStackName, stackName string) (bool, error) {
 	logger.Debugf("testing whether nested stack '%s' is present in parent stack '%s'", stackName, parentStackName)
 	parentExists, err := StackExists(cf, parentStackName)
@@ -0,0 +1,215 @@
+package cfnstack
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/kubernetes-incubator/kube-aws/logger"
+)
+
+// ChangeSetService is the subset of the CloudFormation API to preview the changes to a stack via a change set
+type ChangeSetService interface {
+	DescribeStacks(input *cloudformation.DescribeStacksInput) (*cloudformation.DescribeStacksOutput, error)
+	CreateChangeSet(input *cloudformation.CreateChangeSetInput) (*cloudformation.CreateChangeSetOutput, error)
+	DescribeChangeSet(input *cloudformation.DescribeChangeSetInput) (*cloudformation.DescribeChangeSetOutput, error)
+	DeleteChangeSet(input *cloudformation.DeleteChangeSetInput) (*cloudformation.DeleteChangeSetOutput, error)
+}
+
+// ResourceChange is a change CloudFormation would make to a resource of a stack on update
+type ResourceChange struct {
+	LogicalID    string
+	ResourceType string
+	// Action is either `Add`, `Modify` or `Remove`
+	Action string
+	// Replacement is either `True`, `False` or `Conditional` for a modified resource, which is `True` when the resource is recreated
+	Replacement string
+	// Properties are the names of the properties and attributes which would change
+	Properties []string
+}
+
+// instanceResourceTypes are the types of the resources recreating EC2 instances on replacement
+var instanceResourceTypes = []string{
+	"AWS::EC2::Instance",
+	"AWS::AutoScaling::AutoScalingGroup",
+	"AWS::AutoScaling::LaunchConfiguration",
+	"AWS::EC2::LaunchTemplate",
+	"AWS::EC2::SpotFleet",
+}
+
+// launchProperties are the properties of auto scaling groups and spot fleets whose changes roll the instances
+var launchProperties = []string{
+	"LaunchConfigurationName",
+	"LaunchTemplate",
+	"MixedInstancesPolicy",
+	"SpotFleetRequestConfigData",
+}
+
+// Replaced returns true when the resource would be recreated, or may be depending on the values resolved on update
+func (c ResourceChange) Replaced() bool {
+	return c.Action == cloudformation.ChangeActionModify && c.Replacement != cloudformation.ReplacementFalse
+}
+
+// ChurnsInstances returns true when the change would replace EC2 instances, either by recreating the instances or the launch configurations
+// they are launched from, or by rolling the instances of an auto scaling group or a spot fleet
+func (c ResourceChange) ChurnsInstances() bool {
+	if c.Action != cloudformation.ChangeActionModify || !containsString(instanceResourceTypes, c.ResourceType) {
+		return false
+	}
+	if c.Replaced() {
+		return true
+	}
+	for _, p := range c.Properties {
+		if containsString(launchProperties, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// PreviewChangeSet creates a change set updating the stack with the template at the URL, returns the changes in it and then deletes it.
+// The change set is deleted on failures and interruptions too, so that it is never left to be executed by mistake.
+// The parameters of the stack are kept as they are, as the ones of nested stacks are passed from the parent stack
+func (c *Provisioner) PreviewChangeSet(cfSvc ChangeSetService, templateURL string) ([]ResourceChange, error) {
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupted)
+
+	changes, err := c.previewChangeSet(cfSvc, templateURL, interrupted)
+	if err != nil {
+		return nil, err
+	}
+	// The interruption while creating the change set is reported once the change set is deleted
+	select {
+	case sig := <-interrupted:
+		return nil, fmt.Errorf("interrupted by %v while previewing changes to stack %s", sig, c.stackName)
+	default:
+	}
+	return changes, nil
+}
+
+func (c *Provisioner) previewChangeSet(cfSvc ChangeSetService, templateURL string, interrupted <-chan os.Signal) ([]ResourceChange, error) {
+	stacks, err := cfSvc.DescribeStacks(&cloudformation.DescribeStacksInput{StackName: aws.String(c.stackName)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe stack %s: %v", c.stackName, err)
+	}
+	if len(stacks.Stacks) == 0 {
+		return nil, fmt.Errorf("stack %s not found", c.stackName)
+	}
+	var params []*cloudformation.Parameter
+	for _, p := range stacks.Stacks[0].Parameters {
+		params = append(params, &cloudformation.Parameter{ParameterKey: p.ParameterKey, UsePreviousValue: aws.Bool(true)})
+	}
+
+	var tags []*cloudformation.Tag
+	for k, v := range c.stackTags {
+		key := k
+		value := v
+		tags = append(tags, &cloudformation.Tag{Key: &key, Value: &value})
+	}
+
+	changeSetName := fmt.Sprintf("kube-aws-diff-%d", time.Now().Unix())
+	input := &cloudformation.CreateChangeSetInput{
+		ChangeSetName: aws.String(changeSetName),
+		ChangeSetType: aws.String(cloudformation.ChangeSetTypeUpdate),
+		StackName:     aws.String(c.stackName),
+		TemplateURL:   aws.String(templateURL),
+		Parameters:    params,
+		Capabilities:  c.capabilities(),
+		Tags:          tags,
+		Description:   aws.String("Created by kube-aws diff to preview the changes. Never executed"),
+	}
+	if c.roleARN != "" {
+		input = input.SetRoleARN(c.roleARN)
+	}
+	// The change set is deleted by its name even when the creation fails, as it may have been created before e.g. the request timed out
+	defer func() {
+		_, err := cfSvc.DeleteChangeSet(&cloudformation.DeleteChangeSetInput{ChangeSetName: aws.String(changeSetName), StackName: aws.String(c.stackName)})
+		if err != nil && !isChangeSetNotFoundError(err) {
+			logger.Warnf("failed to delete change set %s of stack %s: %v", changeSetName, c.stackName, err)
+		}
+	}()
+	created, err := cfSvc.CreateChangeSet(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create change set for stack %s: %v", c.stackName, err)
+	}
+
+	return c.waitUntilChangeSetGetsCreated(cfSvc, created.Id, 3*time.Second, interrupted)
+}
+
+func isChangeSetNotFoundError(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == cloudformation.ErrCodeChangeSetNotFoundException
+}
+
+func (c *Provisioner) waitUntilChangeSetGetsCreated(cfSvc ChangeSetService, changeSetID *string, interval time.Duration, interrupted <-chan os.Signal) ([]ResourceChange, error) {
+	changes := []ResourceChange{}
+	var nextToken *string
+	for {
+		resp, err := cfSvc.DescribeChangeSet(&cloudformation.DescribeChangeSetInput{ChangeSetName: changeSetID, NextToken: nextToken})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe change set of stack %s: %v", c.stackName, err)
+		}
+
+		status := aws.StringValue(resp.Status)
+		switch status {
+		case cloudformation.ChangeSetStatusCreateComplete:
+		case cloudformation.ChangeSetStatusFailed:
+			reason := aws.StringValue(resp.StatusReason)
+			// CloudFormation refuses to create an empty change set
+			if strings.Contains(reason, "didn't contain changes") || strings.Contains(reason, "No updates are to be performed") {
+				return changes, nil
+			}
+			return nil, fmt.Errorf("failed to create change set of stack %s: %s", c.stackName, reason)
+		case cloudformation.ChangeSetStatusCreatePending, cloudformation.ChangeSetStatusCreateInProgress:
+			select {
+			case sig := <-interrupted:
+				return nil, fmt.Errorf("interrupted by %v while waiting for change set of stack %s to be created", sig, c.stackName)
+			case <-time.After(interval):
+			}
+			continue
+		default:
+			return nil, fmt.Errorf("unexpected change set status: %s", status)
+		}
+
+		for _, ch := range resp.Changes {
+			rc := ch.ResourceChange
+			if rc == nil {
+				continue
+			}
+			change := ResourceChange{
+				LogicalID:    aws.StringValue(rc.LogicalResourceId),
+				ResourceType: aws.StringValue(rc.ResourceType),
+				Action:       aws.StringValue(rc.Action),
+				Replacement:  aws.StringValue(rc.Replacement),
+			}
+			for _, d := range rc.Details {
+				if d.Target == nil || d.Target.Name == nil || containsString(change.Properties, *d.Target.Name) {
+					continue
+				}
+				change.Properties = append(change.Properties, *d.Target.Name)
+			}
+			changes = append(changes, change)
+		}
+
+		if resp.NextToken == nil {
+			return changes, nil
+		}
+		nextToken = resp.NextToken
+	}
+}
+
+func containsString(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,232 @@
+package cfnstack
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/kubernetes-incubator/kube-aws/pkg/api"
+)
+
+type dummyChangeSetService struct {
+	// Pages are returned in order once the change set got created
+	Pages        []*cloudformation.DescribeChangeSetOutput
+	Pending      int
+	CreateErr    error
+	Created      *cloudformation.CreateChangeSetInput
+	DeletedNames []string
+}
+
+func (s *dummyChangeSetService) DescribeStacks(input *cloudformation.DescribeStacksInput) (*cloudformation.DescribeStacksOutput, error) {
+	return &cloudformation.DescribeStacksOutput{
+		Stacks: []*cloudformation.Stack{
+			{
+				StackName: input.StackName,
+				Parameters: []*cloudformation.Parameter{
+					{ParameterKey: aws.String("ControlPlaneStackTemplateURL"), ParameterValue: aws.String("https://example.com/control-plane.json")},
+				},
+			},
+		},
+	}, nil
+}
+
+func (s *dummyChangeSetService) CreateChangeSet(input *cloudformation.CreateChangeSetInput) (*cloudformation.CreateChangeSetOutput, error) {
+	s.Created = input
+	if s.CreateErr != nil {
+		return nil, s.CreateErr
+	}
+	return &cloudformation.CreateChangeSetOutput{Id: aws.String("arn:aws:cloudformation:us-west-1:123456789012:changeSet/" + *input.ChangeSetName)}, nil
+}
+
+func (s *dummyChangeSetService) DescribeChangeSet(input *cloudformation.DescribeChangeSetInput) (*cloudformation.DescribeChangeSetOutput, error) {
+	if s.Pending > 0 {
+		s.Pending--
+		return &cloudformation.DescribeChangeSetOutput{Status: aws.String(cloudformation.ChangeSetStatusCreateInProgress)}, nil
+	}
+	page := 0
+	if input.NextToken != nil {
+		page = len(*input.NextToken)
+	}
+	return s.Pages[page], nil
+}
+
+func (s *dummyChangeSetService) DeleteChangeSet(input *cloudformation.DeleteChangeSetInput) (*cloudformation.DeleteChangeSetOutput, error) {
+	s.DeletedNames = append(s.DeletedNames, aws.StringValue(input.ChangeSetName))
+	return &cloudformation.DeleteChangeSetOutput{}, nil
+}
+
+func resourceChange(logicalID, resourceType, action, replacement string, properties ...string) *cloudformation.Change {
+	rc := &cloudformation.ResourceChange{
+		LogicalResourceId: aws.String(logicalID),
+		ResourceType:      aws.String(resourceType),
+		Action:            aws.String(action),
+	}
+	if replacement != "" {
+		rc.Replacement = aws.String(replacement)
+	}
+	for _, p := range properties {
+		rc.Details = append(rc.Details, &cloudformation.ResourceChangeDetail{Target: &cloudformation.ResourceTargetDefinition{Name: aws.String(p)}})
+	}
+	return &cloudformation.Change{ResourceChange: rc}
+}
+
+func TestPreviewChangeSet(t *testing.T) {
+	svc := &dummyChangeSetService{
+		Pages: []*cloudformation.DescribeChangeSetOutput{
+			{
+				Status:    aws.String(cloudformation.ChangeSetStatusCreateComplete),
+				NextToken: aws.String("1"),
+				Changes: []*cloudformation.Change{
+					resourceChange("Controllers", "AWS::AutoScaling::AutoScalingGroup", cloudformation.ChangeActionModify, cloudformation.ReplacementFalse, "LaunchConfigurationName", "LaunchConfigurationName"),
+				},
+			},
+			{
+				Status: aws.String(cloudformation.ChangeSetStatusCreateComplete),
+				Changes: []*cloudformation.Change{
+					resourceChange("IAMRoleController", "AWS::IAM::Role", cloudformation.ChangeActionModify, cloudformation.ReplacementFalse, "Policies"),
+					resourceChange("SecurityGroupExtra", "AWS::EC2::SecurityGroup", cloudformation.ChangeActionAdd, ""),
+				},
+			},
+		},
+	}
+	p := NewProvisioner("mycluster", map[string]string{"owner": "me"}, "s3://mybucket/mydir", api.RegionForName("us-west-1"), "{}", nil, "arn:aws:iam::123456789012:role/cfn")
+
+	changes, err := p.PreviewChangeSet(svc, "https://mybucket.s3.amazonaws.com/mydir/stack.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(changes) != 3 {
+		t.Fatalf("expected the changes in both pages to be returned, but got: %+v", changes)
+	}
+	if changes[0].LogicalID != "Controllers" || len(changes[0].Properties) != 1 || !changes[0].ChurnsInstances() {
+		t.Errorf("expected the change of the launch configuration name to churn instances, but got: %+v", changes[0])
+	}
+	if changes[1].ChurnsInstances() || changes[2].ChurnsInstances() {
+		t.Errorf("expected the changes of the other resources not to churn instances, but got: %+v", changes[1:])
+	}
+
+	in := svc.Created
+	if aws.StringValue(in.ChangeSetType) != cloudformation.ChangeSetTypeUpdate || aws.StringValue(in.RoleARN) != "arn:aws:iam::123456789012:role/cfn" {
+		t.Errorf("unexpected change set: %+v", in)
+	}
+	if len(in.Parameters) != 1 || !aws.BoolValue(in.Parameters[0].UsePreviousValue) || in.Parameters[0].ParameterValue != nil {
+		t.Errorf("expected the previous values of the parameters to be kept, but got: %+v", in.Parameters)
+	}
+	if len(in.Tags) != 1 || aws.StringValue(in.Tags[0].Key) != "owner" {
+		t.Errorf("expected the stack tags to be passed, but got: %+v", in.Tags)
+	}
+	if len(svc.DeletedNames) != 1 || !strings.Contains(svc.DeletedNames[0], "kube-aws-diff-") {
+		t.Errorf("expected the change set to be deleted, but deleted: %v", svc.DeletedNames)
+	}
+}
+
+func TestPreviewChangeSetDeletesChangeSetOnFailures(t *testing.T) {
+	p := NewProvisioner("mycluster", map[string]string{}, "s3://mybucket/mydir", api.RegionForName("us-west-1"), "{}", nil)
+
+	timedOut := &dummyChangeSetService{CreateErr: errors.New("RequestError: send request failed")}
+	if _, err := p.previewChangeSet(timedOut, "https://mybucket.s3.amazonaws.com/mydir/stack.json", nil); err == nil {
+		t.Errorf("expected an error for the failed creation but got none")
+	}
+	if len(timedOut.DeletedNames) != 1 || !strings.HasPrefix(timedOut.DeletedNames[0], "kube-aws-diff-") {
+		t.Errorf("expected the change set possibly created to be deleted, but deleted: %v", timedOut.DeletedNames)
+	}
+
+	interrupted := make(chan os.Signal, 1)
+	interrupted <- os.Interrupt
+	pending := &dummyChangeSetService{Pending: 1}
+	if _, err := p.previewChangeSet(pending, "https://mybucket.s3.amazonaws.com/mydir/stack.json", interrupted); err == nil || !strings.Contains(err.Error(), "interrupted") {
+		t.Errorf("expected an error for the interruption but got: %v", err)
+	}
+	if len(pending.DeletedNames) != 1 {
+		t.Errorf("expected the change set being created to be deleted, but deleted: %v", pending.DeletedNames)
+	}
+}
+
+func TestWaitUntilChangeSetGetsCreated(t *testing.T) {
+	p := NewProvisioner("mycluster", map[string]string{}, "s3://mybucket/mydir", api.RegionForName("us-west-1"), "{}", nil)
+
+	pending := &dummyChangeSetService{
+		Pending: 2,
+		Pages: []*cloudformation.DescribeChangeSetOutput{
+			{
+				Status: aws.String(cloudformation.ChangeSetStatusCreateComplete),
+				Changes: []*cloudformation.Change{
+					resourceChange("Etcd0", "AWS::EC2::Instance", cloudformation.ChangeActionModify, cloudformation.ReplacementTrue, "UserData"),
+				},
+			},
+		},
+	}
+	changes, err := p.waitUntilChangeSetGetsCreated(pending, aws.String("id"), 0, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(changes) != 1 || !changes[0].Replaced() || !changes[0].ChurnsInstances() {
+		t.Errorf("expected the replaced instance to churn, but got: %+v", changes)
+	}
+
+	empty := &dummyChangeSetService{
+		Pages: []*cloudformation.DescribeChangeSetOutput{
+			{
+				Status:       aws.String(cloudformation.ChangeSetStatusFailed),
+				StatusReason: aws.String("The submitted information didn't contain changes. Submit different information to create a change set."),
+			},
+		},
+	}
+	changes, err = p.waitUntilChangeSetGetsCreated(empty, aws.String("id"), 0, nil)
+	if err != nil {
+		t.Errorf("expected an empty change set not to be an error but got: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("expected no changes but got: %+v", changes)
+	}
+
+	failed := &dummyChangeSetService{
+		Pages: []*cloudformation.DescribeChangeSetOutput{
+			{
+				Status:       aws.String(cloudformation.ChangeSetStatusFailed),
+				StatusReason: aws.String("Template format error"),
+			},
+		},
+	}
+	if _, err := p.waitUntilChangeSetGetsCreated(failed, aws.String("id"), 0, nil); err == nil || !strings.Contains(err.Error(), "Template format error") {
+		t.Errorf("expected the failure reason to be returned, but got: %v", err)
+	}
+}
+
+func TestResourceChangeChurnsInstances(t *testing.T) {
+	testCases := []struct {
+		change ResourceChange
+		churns bool
+	}{
+		{
+			change: ResourceChange{ResourceType: "AWS::AutoScaling::LaunchConfiguration", Action: cloudformation.ChangeActionModify, Replacement: cloudformation.ReplacementConditional},
+			churns: true,
+		},
+		{
+			change: ResourceChange{ResourceType: "AWS::EC2::SpotFleet", Action: cloudformation.ChangeActionModify, Replacement: cloudformation.ReplacementFalse, Properties: []string{"SpotFleetRequestConfigData"}},
+			churns: true,
+		},
+		{
+			change: ResourceChange{ResourceType: "AWS::AutoScaling::AutoScalingGroup", Action: cloudformation.ChangeActionModify, Replacement: cloudformation.ReplacementFalse, Properties: []string{"MaxSize"}},
+			churns: false,
+		},
+		{
+			change: ResourceChange{ResourceType: "AWS::AutoScaling::AutoScalingGroup", Action: cloudformation.ChangeActionAdd},
+			churns: false,
+		},
+		{
+			change: ResourceChange{ResourceType: "AWS::IAM::Role", Action: cloudformation.ChangeActionModify, Replacement: cloudformation.ReplacementTrue},
+			churns: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		if churns := testCase.change.ChurnsInstances(); churns != testCase.churns {
+			t.Errorf("case %d: expected %+v to churn instances to be %v but was %v", i, testCase.change, testCase.churns, churns)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/aws/aws-sdk-go/service/s3"
@@ -22,8 +23,14 @@ type Provisioner struct {
 	s3URI           string
 	roleARN         string
 	region          api.Region
+
+	terminationProtection bool
+	autoExpand            bool
 }
 
+// capabilityAutoExpand is the capability required to create and update stacks containing macros, which the vendored SDK lacks the constant for
+const capabilityAutoExpand = "CAPABILITY_AUTO_EXPAND"
+
 func NewProvisioner(name string, stackTags map[string]string, s3URI string, region api.Region, stackPolicyBody string, session *session.Session, options ...string) *Provisioner {
 	p := &Provisioner{
 		stackName:       name,
@@ -42,6 +49,26 @@ func NewProvisioner(name string, stackTags map[string]string, s3URI string, regi
 	return p
 }
 
+// WithTerminationProtection makes the provisioner enable CloudFormation termination protection on the stack when it is created or updated
+func (c *Provisioner) WithTerminationProtection(enabled bool) *Provisioner {
+	c.terminationProtection = enabled
+	return c
+}
+
+// WithAutoExpand makes the provisioner acknowledge that the stack template contains macros expanded by CloudFormation
+func (c *Provisioner) WithAutoExpand(enabled bool) *Provisioner {
+	c.autoExpand = enabled
+	return c
+}
+
+func (c *Provisioner) capabilities() []*string {
+	capabilities := []*string{aws.String(cloudformation.CapabilityCapabilityIam), aws.String(cloudformation.CapabilityCapabilityNamedIam)}
+	if c.autoExpand {
+		capabilities = append(capabilities, aws.String(capabilityAutoExpand))
+	}
+	return capabilities
+}
+
 func (c *Provisioner) uploadAsset(s3Svc S3ObjectPutterService, asset api.Asset) error {
 	bucket := asset.Bucket
 	key := asset.Key
@@ -142,7 +169,7 @@ func (c *Provisioner) baseCreateStackInput() *cloudformation.CreateStackInput {
 	input := &cloudformation.CreateStackInput{
 		StackName:       aws.String(c.stackName),
 		OnFailure:       aws.String(cloudformation.OnFailureDoNothing),
-		Capabilities:    []*string{aws.String(cloudformation.CapabilityCapabilityIam), aws.String(cloudformation.CapabilityCapabilityNamedIam)},
+		Capabilities:    c.capabilities(),
 		Tags:            tags,
 		StackPolicyBody: aws.String(c.stackPolicyBody),
 	}
@@ -151,6 +178,10 @@ func (c *Provisioner) baseCreateStackInput() *cloudformation.CreateStackInput {
 		input = input.SetRoleARN(c.roleARN)
 	}
 
+	if c.terminationProtection {
+		input = input.SetEnableTerminationProtection(true)
+	}
+
 	return input
 }
 
@@ -169,7 +200,7 @@ func (c *Provisioner) baseUpdateStackInput() *cloudformation.UpdateStackInput {
 	}
 
 	input := &cloudformation.UpdateStackInput{
-		Capabilities: []*string{aws.String(cloudformation.CapabilityCapabilityIam), aws.String(cloudformation.CapabilityCapabilityNamedIam)},
+		Capabilities: c.capabilities(),
 		StackName:    aws.String(c.stackName),
 		Tags:         tags,
 	}
@@ -186,17 +217,57 @@ func (c *Provisioner) updateStackWithTemplateURL(cfSvc UpdateService, templateUR
 }
 
 func (c *Provisioner) UpdateStackAtURLAndWait(cfSvc CRUDService, templateURL string) (string, error) {
+	// Termination protection isn't a part of UpdateStackInput hence we have to enable it with the dedicated API.
+	// We never disable it here so that a protection enabled out of kube-aws is kept as-is.
+	if c.terminationProtection {
+		if err := c.enableTerminationProtection(cfSvc); err != nil {
+			return "", err
+		}
+	}
+
+	startTime := time.Now()
 	updateOutput, err := c.updateStackWithTemplateURL(cfSvc, templateURL)
 	if err != nil {
+		// Nothing but the assets fetched by nodes at runtime, like the OIDC CA bundle, may have changed
+		if isNoUpdatesError(err) {
+			logger.Infof("stack %s is already up-to-date", c.stackName)
+			return "", nil
+		}
 		return "", fmt.Errorf("error updating cloudformation stack: %v", err)
 	}
-	return c.waitUntilStackGetsUpdated(cfSvc, updateOutput)
+	return c.waitUntilStackGetsUpdated(cfSvc, updateOutput, startTime, 3*time.Second)
+}
+
+func isNoUpdatesError(err error) bool {
+	if aerr, ok := err.(awserr.Error); ok {
+		return aerr.Code() == "ValidationError" && strings.Contains(aerr.Message(), "No updates are to be performed")
+	}
+	return false
 }
 
-func (c *Provisioner) waitUntilStackGetsUpdated(cfSvc CRUDService, updateOutput *cloudformation.UpdateStackOutput) (string, error) {
+func isStackNotFoundError(err error) bool {
+	if aerr, ok := err.(awserr.Error); ok {
+		return aerr.Code() == "ValidationError" && strings.Contains(aerr.Message(), "does not exist")
+	}
+	return false
+}
+
+func (c *Provisioner) enableTerminationProtection(cfSvc TerminationProtectionService) error {
+	_, err := cfSvc.UpdateTerminationProtection(&cloudformation.UpdateTerminationProtectionInput{
+		StackName:                   aws.String(c.stackName),
+		EnableTerminationProtection: aws.Bool(true),
+	})
+	if err != nil {
+		return fmt.Errorf("error enabling termination protection on cloudformation stack %s: %v", c.stackName, err)
+	}
+	return nil
+}
+
+func (c *Provisioner) waitUntilStackGetsUpdated(cfSvc CRUDService, updateOutput *cloudformation.UpdateStackOutput, since time.Time, interval time.Duration) (string, error) {
 	req := cloudformation.DescribeStacksInput{
 		StackName: updateOutput.StackId,
 	}
+	rollingBack := false
 	for {
 		resp, err := cfSvc.DescribeStacks(&req)
 		if err != nil {
@@ -209,11 +280,32 @@ func (c *Provisioner) waitUntilStackGetsUpdated(cfSvc CRUDService, updateOutput
 		switch statusString {
 		case cloudformation.ResourceStatusUpdateComplete:
 			return updateOutput.String(), nil
-		case cloudformation.ResourceStatusUpdateFailed, cloudformation.StackStatusUpdateRollbackComplete, cloudformation.StackStatusUpdateRollbackFailed:
+		case cloudformation.ResourceStatusUpdateFailed:
 			errMsg := fmt.Sprintf("Stack status: %s : %s", statusString, aws.StringValue(resp.Stacks[0].StackStatusReason))
 			return "", errors.New(errMsg)
+		case cloudformation.StackStatusUpdateRollbackComplete:
+			errMsg := fmt.Sprintf("Stack status: %s : %s", statusString, aws.StringValue(resp.Stacks[0].StackStatusReason))
+			// The reason of the root stack only names the nested stacks failed to update, e.g. a node pool whose new nodes didn't become Ready
+			failures, err := StackUpdateErrMsgs(cfSvc, aws.StringValue(updateOutput.StackId), since)
+			if err != nil {
+				return "", fmt.Errorf("%s\n\nfailed to describe the failed stack events: %v", errMsg, err)
+			}
+			if len(failures) > 0 {
+				errMsg = errMsg + "\n\nThe update has been rolled back due to the failed stack events:\n" + strings.Join(failures, "\n")
+			}
+			return "", errors.New(errMsg)
+		case cloudformation.StackStatusUpdateRollbackInProgress, cloudformation.StackStatusUpdateRollbackCompleteCleanupInProgress:
+			if !rollingBack {
+				logger.Warnf("stack %s failed to update and is rolling back: %s", c.stackName, aws.StringValue(resp.Stacks[0].StackStatusReason))
+				rollingBack = true
+			}
+			time.Sleep(interval)
+			continue
+		case cloudformation.StackStatusUpdateRollbackFailed:
+			errMsg := fmt.Sprintf("Stack status: %s : %s\n\nFix the resources which failed to roll back, or run `kube-aws continue-rollback --skip-resources <logical ids>` to skip them, to resume the rollback", statusString, aws.StringValue(resp.Stacks[0].StackStatusReason))
+			return "", errors.New(errMsg)
 		case cloudformation.ResourceStatusUpdateInProgress, cloudformation.StackStatusUpdateCompleteCleanupInProgress:
-			time.Sleep(3 * time.Second)
+			time.Sleep(interval)
 			continue
 		default:
 			return "", fmt.Errorf("unexpected stack status: %s", statusString)
@@ -243,18 +335,24 @@ type Destroyer struct {
 	roleARN   string
 	stackName string
 	session   *session.Session
+
+	disableTerminationProtection bool
 }
 
-func NewDestroyer(stackName string, session *session.Session, roleARN string) *Destroyer {
+func NewDestroyer(stackName string, session *session.Session, roleARN string, disableTerminationProtection bool) *Destroyer {
 	return &Destroyer{
-		stackName: stackName,
-		session:   session,
-		roleARN:   roleARN,
+		stackName:                    stackName,
+		session:                      session,
+		roleARN:                      roleARN,
+		disableTerminationProtection: disableTerminationProtection,
 	}
 }
 
 func (c *Destroyer) Destroy() error {
 	cfSvc := cloudformation.New(c.session)
+	if err := c.ensureTerminationProtectionDisabled(cfSvc); err != nil {
+		return err
+	}
 	dreq := &cloudformation.DeleteStackInput{
 		StackName: aws.String(c.stackName),
 	}
@@ -265,6 +363,111 @@ func (c *Destroyer) Destroy() error {
 	return err
 }
 
+// ensureTerminationProtectionDisabled refuses to proceed when the stack is protected from termination,
+// unless the destroyer is explicitly told to disable the protection beforehand
+func (c *Destroyer) ensureTerminationProtectionDisabled(cfSvc TerminationProtectionService) error {
+	resp, err := cfSvc.DescribeStacks(&cloudformation.DescribeStacksInput{
+		StackName: aws.String(c.stackName),
+	})
+	// DeleteStack succeeds for the stack already deleted or never created, which has nothing to protect
+	if isStackNotFoundError(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(resp.Stacks) == 0 || !aws.BoolValue(resp.Stacks[0].EnableTerminationProtection) {
+		return nil
+	}
+	if !c.disableTerminationProtection {
+		return fmt.Errorf("stack %s has termination protection enabled. Run `kube-aws destroy` with `--disable-termination-protection` to disable it and proceed", c.stackName)
+	}
+	_, err = cfSvc.UpdateTerminationProtection(&cloudformation.UpdateTerminationProtectionInput{
+		StackName:                   aws.String(c.stackName),
+		EnableTerminationProtection: aws.Bool(false),
+	})
+	if err != nil {
+		return fmt.Errorf("error disabling termination protection on cloudformation stack %s: %v", c.stackName, err)
+	}
+	return nil
+}
+
+type RollbackContinuer struct {
+	roleARN   string
+	stackName string
+	session   *session.Session
+
+	resourcesToSkip []string
+}
+
+func NewRollbackContinuer(stackName string, session *session.Session, roleARN string, resourcesToSkip []string) *RollbackContinuer {
+	return &RollbackContinuer{
+		stackName:       stackName,
+		session:         session,
+		roleARN:         roleARN,
+		resourcesToSkip: resourcesToSkip,
+	}
+}
+
+// ContinueRollback resumes the rollback of a stack left in UPDATE_ROLLBACK_FAILED after a failed update and waits for it to complete
+func (c *RollbackContinuer) ContinueRollback() error {
+	return c.continueRollback(cloudformation.New(c.session), 3*time.Second)
+}
+
+func (c *RollbackContinuer) continueRollback(cfSvc ContinueUpdateRollbackService, interval time.Duration) error {
+	status, _, err := c.stackStatus(cfSvc)
+	if err != nil {
+		return err
+	}
+	if status != cloudformation.StackStatusUpdateRollbackFailed {
+		return fmt.Errorf("stack %s can't continue rolling back: its status must be %s but was %s", c.stackName, cloudformation.StackStatusUpdateRollbackFailed, status)
+	}
+
+	input := &cloudformation.ContinueUpdateRollbackInput{
+		StackName: aws.String(c.stackName),
+	}
+	if c.roleARN != "" {
+		input = input.SetRoleARN(c.roleARN)
+	}
+	if len(c.resourcesToSkip) > 0 {
+		input = input.SetResourcesToSkip(aws.StringSlice(c.resourcesToSkip))
+	}
+	if _, err := cfSvc.ContinueUpdateRollback(input); err != nil {
+		return fmt.Errorf("error continuing the rollback of cloudformation stack %s: %v", c.stackName, err)
+	}
+
+	for {
+		status, reason, err := c.stackStatus(cfSvc)
+		if err != nil {
+			return err
+		}
+		switch status {
+		case cloudformation.StackStatusUpdateRollbackComplete:
+			return nil
+		case cloudformation.StackStatusUpdateRollbackFailed:
+			return fmt.Errorf("Stack status: %s : %s", status, reason)
+		case cloudformation.StackStatusUpdateRollbackInProgress, cloudformation.StackStatusUpdateRollbackCompleteCleanupInProgress:
+			time.Sleep(interval)
+			continue
+		default:
+			return fmt.Errorf("unexpected stack status: %s", status)
+		}
+	}
+}
+
+func (c *RollbackContinuer) stackStatus(cfSvc ContinueUpdateRollbackService) (string, string, error) {
+	resp, err := cfSvc.DescribeStacks(&cloudformation.DescribeStacksInput{
+		StackName: aws.String(c.stackName),
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if len(resp.Stacks) == 0 {
+		return "", "", fmt.Errorf("stack not found")
+	}
+	return aws.StringValue(resp.Stacks[0].StackStatus), aws.StringValue(resp.Stacks[0].StackStatusReason), nil
+}
+
 func (c *Provisioner) StreamEventsNested(q chan struct{}, f *cloudformation.CloudFormation, stackId string, headStackName string, t time.Time) error {
 	nestedStacks := make(map[string]bool)
 	nestedQuit := make(chan struct{}, 1)
@@ -3,7 +3,15 @@ package cfnstack
 import (
 	"bytes"
 	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/kubernetes-incubator/kube-aws/pkg/api"
 )
 
 type dummyS3ObjectPutterService struct {
@@ -64,3 +72,275 @@ func (s3Svc dummyS3ObjectPutterService) PutObject(input *s3.PutObjectInput) (*s3
 
 	return resp, nil
 }
+
+type dummyTerminationProtectionService struct {
+	EnableTerminationProtection bool
+	Updates                     []bool
+	NotFound                    bool
+}
+
+func (s *dummyTerminationProtectionService) DescribeStacks(input *cloudformation.DescribeStacksInput) (*cloudformation.DescribeStacksOutput, error) {
+	if s.NotFound {
+		return nil, awserr.New("ValidationError", fmt.Sprintf("Stack with id %s does not exist", aws.StringValue(input.StackName)), nil)
+	}
+	return &cloudformation.DescribeStacksOutput{
+		Stacks: []*cloudformation.Stack{
+			{
+				StackName:                   input.StackName,
+				EnableTerminationProtection: aws.Bool(s.EnableTerminationProtection),
+			},
+		},
+	}, nil
+}
+
+func (s *dummyTerminationProtectionService) UpdateTerminationProtection(input *cloudformation.UpdateTerminationProtectionInput) (*cloudformation.UpdateTerminationProtectionOutput, error) {
+	s.EnableTerminationProtection = aws.BoolValue(input.EnableTerminationProtection)
+	s.Updates = append(s.Updates, s.EnableTerminationProtection)
+	return &cloudformation.UpdateTerminationProtectionOutput{StackId: input.StackName}, nil
+}
+
+type dummyUpToDateStackService struct {
+	CRUDService
+}
+
+func (s dummyUpToDateStackService) UpdateStack(input *cloudformation.UpdateStackInput) (*cloudformation.UpdateStackOutput, error) {
+	return nil, awserr.New("ValidationError", "No updates are to be performed.", nil)
+}
+
+func TestUpdateStackAtURLAndWaitWithoutUpdates(t *testing.T) {
+	p := NewProvisioner("mycluster", map[string]string{}, "s3://mybucket/mydir", api.RegionForName("us-west-1"), "{}", nil)
+
+	report, err := p.UpdateStackAtURLAndWait(dummyUpToDateStackService{}, "https://mybucket.s3.amazonaws.com/mydir/stack.json")
+	if err != nil {
+		t.Errorf("expected an up-to-date stack not to be an error but got: %v", err)
+	}
+	if report != "" {
+		t.Errorf("expected no report for an up-to-date stack but got: %s", report)
+	}
+
+	if isNoUpdatesError(awserr.New("ValidationError", "Template format error", nil)) {
+		t.Errorf("expected other validation errors not to be treated as no updates")
+	}
+}
+
+func TestBaseCreateStackInputWithTerminationProtection(t *testing.T) {
+	p := NewProvisioner("mycluster", map[string]string{}, "s3://mybucket/mydir", api.RegionForName("us-west-1"), "{}", nil)
+	if p.baseCreateStackInput().EnableTerminationProtection != nil {
+		t.Errorf("termination protection must not be enabled by default")
+	}
+
+	p = p.WithTerminationProtection(true)
+	if !aws.BoolValue(p.baseCreateStackInput().EnableTerminationProtection) {
+		t.Errorf("expected termination protection to be enabled")
+	}
+}
+
+func TestProvisionerCapabilitiesWithAutoExpand(t *testing.T) {
+	p := NewProvisioner("mycluster", map[string]string{}, "s3://mybucket/mydir", api.RegionForName("us-west-1"), "{}", nil)
+	for _, c := range append(p.baseCreateStackInput().Capabilities, p.baseUpdateStackInput().Capabilities...) {
+		if aws.StringValue(c) == "CAPABILITY_AUTO_EXPAND" {
+			t.Errorf("CAPABILITY_AUTO_EXPAND must not be acknowledged by default")
+		}
+	}
+
+	p = p.WithAutoExpand(true)
+	for _, capabilities := range [][]*string{p.baseCreateStackInput().Capabilities, p.baseUpdateStackInput().Capabilities} {
+		if len(capabilities) != 3 || aws.StringValue(capabilities[2]) != "CAPABILITY_AUTO_EXPAND" {
+			t.Errorf("expected CAPABILITY_AUTO_EXPAND to be acknowledged, but the capabilities were %v", aws.StringValueSlice(capabilities))
+		}
+	}
+}
+
+func TestDestroyerTerminationProtection(t *testing.T) {
+	unprotected := &dummyTerminationProtectionService{}
+	if err := NewDestroyer("mycluster", nil, "", false).ensureTerminationProtectionDisabled(unprotected); err != nil {
+		t.Errorf("unexpected error for an unprotected stack: %v", err)
+	}
+	if len(unprotected.Updates) != 0 {
+		t.Errorf("termination protection of an unprotected stack must not be updated: %v", unprotected.Updates)
+	}
+
+	protected := &dummyTerminationProtectionService{EnableTerminationProtection: true}
+	err := NewDestroyer("mycluster", nil, "", false).ensureTerminationProtectionDisabled(protected)
+	if err == nil || !strings.Contains(err.Error(), "--disable-termination-protection") {
+		t.Errorf("expected an error suggesting --disable-termination-protection, but got: %v", err)
+	}
+	if !protected.EnableTerminationProtection {
+		t.Errorf("termination protection must not be disabled without --disable-termination-protection")
+	}
+
+	if err := NewDestroyer("mycluster", nil, "", true).ensureTerminationProtectionDisabled(protected); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if protected.EnableTerminationProtection {
+		t.Errorf("expected termination protection to be disabled")
+	}
+}
+
+func TestDestroyerTerminationProtectionOfMissingStack(t *testing.T) {
+	missing := &dummyTerminationProtectionService{NotFound: true}
+	if err := NewDestroyer("mycluster", nil, "", false).ensureTerminationProtectionDisabled(missing); err != nil {
+		t.Errorf("expected a missing stack to be destroyable but got: %v", err)
+	}
+	if len(missing.Updates) != 0 {
+		t.Errorf("termination protection of a missing stack must not be updated: %v", missing.Updates)
+	}
+
+	if isStackNotFoundError(awserr.New("ValidationError", "Template format error", nil)) {
+		t.Errorf("expected other validation errors not to be treated as a missing stack")
+	}
+}
+
+type dummyContinueUpdateRollbackService struct {
+	Statuses []string
+	Input    *cloudformation.ContinueUpdateRollbackInput
+}
+
+func (s *dummyContinueUpdateRollbackService) DescribeStacks(input *cloudformation.DescribeStacksInput) (*cloudformation.DescribeStacksOutput, error) {
+	status := s.Statuses[0]
+	if len(s.Statuses) > 1 {
+		s.Statuses = s.Statuses[1:]
+	}
+	return &cloudformation.DescribeStacksOutput{
+		Stacks: []*cloudformation.Stack{
+			{
+				StackName:   input.StackName,
+				StackStatus: aws.String(status),
+			},
+		},
+	}, nil
+}
+
+func (s *dummyContinueUpdateRollbackService) ContinueUpdateRollback(input *cloudformation.ContinueUpdateRollbackInput) (*cloudformation.ContinueUpdateRollbackOutput, error) {
+	s.Input = input
+	return &cloudformation.ContinueUpdateRollbackOutput{}, nil
+}
+
+func TestRollbackContinuer(t *testing.T) {
+	failed := &dummyContinueUpdateRollbackService{
+		Statuses: []string{
+			cloudformation.StackStatusUpdateRollbackFailed,
+			cloudformation.StackStatusUpdateRollbackInProgress,
+			cloudformation.StackStatusUpdateRollbackComplete,
+		},
+	}
+	c := NewRollbackContinuer("mycluster", nil, "arn:aws:iam::123456789012:role/kube-aws", []string{"Controlplane.Controllers"})
+	if err := c.continueRollback(failed, 0); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if failed.Input == nil {
+		t.Fatalf("expected the rollback to be continued")
+	}
+	if skipped := aws.StringValueSlice(failed.Input.ResourcesToSkip); len(skipped) != 1 || skipped[0] != "Controlplane.Controllers" {
+		t.Errorf("unexpected resources to skip: %v", skipped)
+	}
+	if aws.StringValue(failed.Input.RoleARN) != "arn:aws:iam::123456789012:role/kube-aws" {
+		t.Errorf("unexpected role arn: %v", aws.StringValue(failed.Input.RoleARN))
+	}
+
+	stillFailing := &dummyContinueUpdateRollbackService{
+		Statuses: []string{
+			cloudformation.StackStatusUpdateRollbackFailed,
+			cloudformation.StackStatusUpdateRollbackInProgress,
+			cloudformation.StackStatusUpdateRollbackFailed,
+		},
+	}
+	if err := NewRollbackContinuer("mycluster", nil, "", nil).continueRollback(stillFailing, 0); err == nil {
+		t.Errorf("expected an error for the rollback failed again, but got none")
+	}
+	if stillFailing.Input.ResourcesToSkip != nil {
+		t.Errorf("no resources should be skipped by default: %v", stillFailing.Input.ResourcesToSkip)
+	}
+
+	complete := &dummyContinueUpdateRollbackService{Statuses: []string{cloudformation.StackStatusUpdateComplete}}
+	err := NewRollbackContinuer("mycluster", nil, "", nil).continueRollback(complete, 0)
+	if err == nil || !strings.Contains(err.Error(), cloudformation.StackStatusUpdateRollbackFailed) {
+		t.Errorf("expected an error for the stack not in %s, but got: %v", cloudformation.StackStatusUpdateRollbackFailed, err)
+	}
+	if complete.Input != nil {
+		t.Errorf("the rollback must not be continued for the stack not in %s", cloudformation.StackStatusUpdateRollbackFailed)
+	}
+}
+
+type dummyRollingBackStackService struct {
+	CRUDService
+	Statuses []string
+	Events   map[string][]*cloudformation.StackEvent
+}
+
+func (s *dummyRollingBackStackService) DescribeStacks(input *cloudformation.DescribeStacksInput) (*cloudformation.DescribeStacksOutput, error) {
+	status := s.Statuses[0]
+	if len(s.Statuses) > 1 {
+		s.Statuses = s.Statuses[1:]
+	}
+	return &cloudformation.DescribeStacksOutput{
+		Stacks: []*cloudformation.Stack{
+			{
+				StackName:         input.StackName,
+				StackStatus:       aws.String(status),
+				StackStatusReason: aws.String("The following resource(s) failed to update: [Pool1]."),
+			},
+		},
+	}, nil
+}
+
+func (s *dummyRollingBackStackService) DescribeStackEvents(input *cloudformation.DescribeStackEventsInput) (*cloudformation.DescribeStackEventsOutput, error) {
+	return &cloudformation.DescribeStackEventsOutput{StackEvents: s.Events[aws.StringValue(input.StackName)]}, nil
+}
+
+func TestWaitUntilStackGetsUpdatedRollingBack(t *testing.T) {
+	since := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(minutes int) *time.Time {
+		t := since.Add(time.Duration(minutes) * time.Minute)
+		return &t
+	}
+	event := func(stack, status, typ, logicalId, physicalId, reason string, minutes int) *cloudformation.StackEvent {
+		return &cloudformation.StackEvent{
+			StackId:              aws.String(stack),
+			StackName:            aws.String(stack),
+			ResourceStatus:       aws.String(status),
+			ResourceType:         aws.String(typ),
+			LogicalResourceId:    aws.String(logicalId),
+			PhysicalResourceId:   aws.String(physicalId),
+			ResourceStatusReason: aws.String(reason),
+			Timestamp:            at(minutes),
+		}
+	}
+
+	svc := &dummyRollingBackStackService{
+		Statuses: []string{
+			cloudformation.StackStatusUpdateInProgress,
+			cloudformation.StackStatusUpdateRollbackInProgress,
+			cloudformation.StackStatusUpdateRollbackCompleteCleanupInProgress,
+			cloudformation.StackStatusUpdateRollbackComplete,
+		},
+		Events: map[string][]*cloudformation.StackEvent{
+			"mycluster": {
+				event("mycluster", "UPDATE_ROLLBACK_IN_PROGRESS", "AWS::CloudFormation::Stack", "mycluster", "mycluster", "The following resource(s) failed to update: [Pool1].", 30),
+				event("mycluster", "UPDATE_FAILED", "AWS::CloudFormation::Stack", "Pool1", "mycluster-Pool1", "Embedded stack mycluster-Pool1 was not successfully updated.", 29),
+				event("mycluster", "UPDATE_FAILED", "AWS::CloudFormation::Stack", "Pool2", "mycluster-Pool2", "Resource update cancelled", 29),
+				event("mycluster", "UPDATE_IN_PROGRESS", "AWS::CloudFormation::Stack", "Pool1", "mycluster-Pool1", "", 1),
+				// Failed in the previous update
+				event("mycluster", "UPDATE_FAILED", "AWS::CloudFormation::Stack", "Pool1", "mycluster-Pool1", "Embedded stack mycluster-Pool1 was not successfully updated.", -10),
+			},
+			"mycluster-Pool1": {
+				event("mycluster-Pool1", "UPDATE_FAILED", "AWS::AutoScaling::AutoScalingGroup", "Workers", "mycluster-Pool1-Workers", "Received 0 SUCCESS signal(s) out of 1.", 28),
+				event("mycluster-Pool1", "UPDATE_IN_PROGRESS", "AWS::AutoScaling::AutoScalingGroup", "Workers", "mycluster-Pool1-Workers", "", 2),
+			},
+		},
+	}
+
+	p := NewProvisioner("mycluster", map[string]string{}, "s3://mybucket/mydir", api.RegionForName("us-west-1"), "{}", nil)
+	_, err := p.waitUntilStackGetsUpdated(svc, &cloudformation.UpdateStackOutput{StackId: aws.String("mycluster")}, since, 0)
+	if err == nil {
+		t.Fatalf("expected an error for the rolled back update, but got none")
+	}
+	expected := `Stack status: UPDATE_ROLLBACK_COMPLETE : The following resource(s) failed to update: [Pool1].
+
+The update has been rolled back due to the failed stack events:
+mycluster: UPDATE_FAILED AWS::CloudFormation::Stack Pool1 Embedded stack mycluster-Pool1 was not successfully updated.
+mycluster-Pool1: UPDATE_FAILED AWS::AutoScaling::AutoScalingGroup Workers Received 0 SUCCESS signal(s) out of 1.`
+	if err.Error() != expected {
+		t.Errorf("unexpected error message: expected:\n%s\n\nactual:\n%s", expected, err.Error())
+	}
+}
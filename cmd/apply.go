@@ -34,7 +34,7 @@ func init() {
 	cmdApply.Flags().BoolVar(&applyOpts.awsDebug, "aws-debug", false, "Log debug information from aws-sdk-go library")
 	cmdApply.Flags().BoolVar(&applyOpts.prettyPrint, "pretty-print", false, "Pretty print the resulting CloudFormation")
 	cmdApply.Flags().BoolVar(&applyOpts.skipWait, "skip-wait", false, "Don't wait the resources finish")
-	cmdApply.Flags().BoolVar(&applyOpts.force, "force", false, "Don't ask for confirmation")
+	cmdApply.Flags().BoolVar(&applyOpts.force, "force", false, "Don't ask for confirmation, and update the cluster even outside the maintenance window")
 	cmdApply.Flags().StringSliceVar(&applyOpts.targets, "targets", root.AllOperationTargetsAsStringSlice(), "Update nothing but specified sub-stacks.  Specify `all` or any combination of `etcd`, `control-plane`, and node pool names. Defaults to `all`")
 }
 
@@ -64,6 +64,19 @@ func runCmdApply(_ *cobra.Command, _ []string) error {
 		return nil
 	}
 
+	if cluster.Cfg.MaintenanceWindow.Enabled() {
+		exists, err := cluster.Exists()
+		if err != nil {
+			return err
+		}
+		// Creating a cluster doesn't affect anything running
+		if exists {
+			if err := ensureWithinMaintenanceWindow(cluster, applyOpts.force); err != nil {
+				return err
+			}
+		}
+	}
+
 	err = cluster.Apply(targets)
 	if err != nil {
 		return fmt.Errorf("error updating cluster: %v", err)
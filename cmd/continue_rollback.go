@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kubernetes-incubator/kube-aws/core/root"
+	"github.com/kubernetes-incubator/kube-aws/logger"
+)
+
+var (
+	cmdContinueRollback = &cobra.Command{
+		Use:          "continue-rollback",
+		Short:        "Continue rolling back the cluster's CloudFormation stack after the rollback of a failed update failed",
+		Long:         `Resumes the rollback of the cluster's CloudFormation stack left in UPDATE_ROLLBACK_FAILED. Fix the resources failed to roll back beforehand, or skip them with --skip-resources.`,
+		RunE:         runCmdContinueRollback,
+		SilenceUsage: true,
+	}
+	continueRollbackOpts = root.ContinueRollbackOptions{}
+)
+
+func init() {
+	RootCmd.AddCommand(cmdContinueRollback)
+	cmdContinueRollback.Flags().BoolVar(&continueRollbackOpts.AwsDebug, "aws-debug", false, "Log debug information from aws-sdk-go library")
+	cmdContinueRollback.Flags().StringSliceVar(&continueRollbackOpts.ResourcesToSkip, "skip-resources", []string{}, "Logical ids of the resources failed to roll back to leave as-is. Specify resources in nested stacks as <nested stack logical id>.<resource logical id>")
+}
+
+func runCmdContinueRollback(_ *cobra.Command, _ []string) error {
+	c, err := root.ClusterRollbackContinuerFromFile(configPath, continueRollbackOpts)
+	if err != nil {
+		return fmt.Errorf("error parsing config: %v", err)
+	}
+
+	if err := c.ContinueRollback(); err != nil {
+		return fmt.Errorf("failed continuing the rollback of the cluster: %v", err)
+	}
+
+	logger.Info("CloudFormation stack has been rolled back")
+	return nil
+}
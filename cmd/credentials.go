@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kubernetes-incubator/kube-aws/core/root"
+	"github.com/kubernetes-incubator/kube-aws/credential"
+	"github.com/kubernetes-incubator/kube-aws/logger"
+	"github.com/kubernetes-incubator/kube-aws/pki"
+)
+
+var (
+	cmdCredentials = &cobra.Command{
+		Use:   "credentials",
+		Short: "Manage the lifecycle of the TLS certificates in the credentials directory",
+		Long:  ``,
+	}
+
+	cmdCredentialsStatus = &cobra.Command{
+		Use:          "status",
+		Short:        "Show the number of days until each certificate expires",
+		Long:         ``,
+		RunE:         runCmdCredentialsStatus,
+		SilenceUsage: true,
+	}
+
+	cmdCredentialsRotate = &cobra.Command{
+		Use:   "rotate",
+		Short: "Re-issue the certificates in the credentials directory",
+		Long: `--leaf re-issues all the certificates signed by the cluster CA with the existing keys.
+--ca rotates the cluster CA in three phases, each of which must be rolled out by "kube-aws apply" before running "kube-aws credentials rotate --ca" again:
+  1. The new CA is trusted alongside the current CA
+  2. All the certificates are re-issued by the new CA, while the previous CA is still trusted
+  3. The previous CA is no longer trusted
+The updated certificates are encrypted with KMS and replaced on the nodes by the rolling update of controller and worker nodes triggered by "kube-aws apply".`,
+		RunE:         runCmdCredentialsRotate,
+		SilenceUsage: true,
+	}
+
+	credentialsStatusOpts = struct {
+		warnDays int
+	}{}
+
+	credentialsRotateOpts = root.CredentialsRotateOptions{}
+)
+
+func init() {
+	RootCmd.AddCommand(cmdCredentials)
+	cmdCredentials.AddCommand(cmdCredentialsStatus)
+	cmdCredentials.AddCommand(cmdCredentialsRotate)
+
+	cmdCredentialsStatus.Flags().IntVar(&credentialsStatusOpts.warnDays, "warn-days", 30, "Report certificates expiring within this number of days as EXPIRING")
+
+	cmdCredentialsRotate.Flags().BoolVar(&credentialsRotateOpts.AwsDebug, "aws-debug", false, "Log debug information from aws-sdk-go library")
+	cmdCredentialsRotate.Flags().BoolVar(&credentialsRotateOpts.CA, "ca", false, "Advance the rotation of the cluster CA by one phase")
+	cmdCredentialsRotate.Flags().BoolVar(&credentialsRotateOpts.Leaf, "leaf", false, "Re-issue all the certificates signed by the cluster CA with the existing keys")
+}
+
+func runCmdCredentialsStatus(_ *cobra.Command, _ []string) error {
+	statuses, err := root.CredentialsStatus(configPath)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "FILE\tCOMMON NAME\tNOT AFTER\tDAYS LEFT\tSTATUS")
+	for _, s := range statuses {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", s.File, s.CommonName, s.NotAfter.Format(pki.ValidityFormat), s.DaysLeft, s.State(credentialsStatusOpts.warnDays))
+	}
+	w.Flush()
+	logger.Info(buf.String())
+	return nil
+}
+
+func runCmdCredentialsRotate(_ *cobra.Command, _ []string) error {
+	phase, err := root.RotateCredentials(configPath, credentialsRotateOpts)
+	if err != nil {
+		return fmt.Errorf("failed rotating credentials: %v", err)
+	}
+
+	switch phase {
+	case 0:
+		logger.Info("The certificates have been re-issued. Run `kube-aws apply` to roll them out to the nodes")
+	case credential.CARotationCompleted:
+		logger.Infof("CA rotation phase %d of 3 done: %s. Run `kube-aws apply` to roll it out to the nodes, which completes the rotation", phase, phase)
+	default:
+		logger.Infof("CA rotation phase %d of 3 done: %s. Run `kube-aws apply` to roll it out to the nodes, and then `kube-aws credentials rotate --ca` again", phase, phase)
+	}
+	return nil
+}
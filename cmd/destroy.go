@@ -27,6 +27,7 @@ func init() {
 	RootCmd.AddCommand(cmdDestroy)
 	cmdDestroy.Flags().BoolVar(&destroyOpts.AwsDebug, "aws-debug", false, "Log debug information from aws-sdk-go library")
 	cmdDestroy.Flags().BoolVar(&destroyOpts.Force, "force", false, "Don't ask for confirmation")
+	cmdDestroy.Flags().BoolVar(&destroyOpts.DisableTerminationProtection, "disable-termination-protection", false, "Disable termination protection on the cluster's CloudFormation stack before destroying it")
 }
 
 func runCmdDestroy(_ *cobra.Command, _ []string) error {
@@ -19,9 +19,9 @@ var (
 	}
 
 	diffOpts = struct {
-		awsDebug, prettyPrint, skipWait, export bool
-		context                                 int
-		targets                                 []string
+		awsDebug, prettyPrint, skipWait, export, changeSets bool
+		context                                             int
+		targets                                             []string
 	}{}
 )
 
@@ -39,6 +39,7 @@ func init() {
 	cmdDiff.Flags().BoolVar(&diffOpts.awsDebug, "aws-debug", false, "Log debug information from aws-sdk-go library")
 	cmdDiff.Flags().StringSliceVar(&diffOpts.targets, "targets", root.AllOperationTargetsAsStringSlice(), "Diff nothing but specified sub-stacks.  Specify `all` or any combination of `etcd`, `control-plane`, and node pool names. Defaults to `all`")
 	cmdDiff.Flags().IntVarP(&diffOpts.context, "context", "C", -1, "output NUM lines of context around changes")
+	cmdDiff.Flags().BoolVar(&diffOpts.changeSets, "changesets", false, "Preview the changes to the resources of the live stacks via CloudFormation change sets, which are deleted without being executed. Uploads the assets referenced by the stack templates, like userdata, to S3 just as kube-aws apply does, except the OIDC CA and the apiserver certificates controller nodes periodically fetch")
 }
 
 func runCmdDiff(c *cobra.Command, _ []string) error {
@@ -69,6 +70,24 @@ func runCmdDiff(c *cobra.Command, _ []string) error {
 		names[i] = diffs[i].Target
 	}
 
+	if diffOpts.changeSets {
+		results, err := cluster.PreviewChangeSets(targets)
+		if err != nil {
+			return fmt.Errorf("error previewing change sets: %v", err)
+		}
+
+		churns := []string{}
+		for _, r := range results {
+			logger.Infof("Changes to be made by CloudFormation in: %s\n%s", r.Target, r.String())
+			for _, c := range r.InstanceChurns() {
+				churns = append(churns, fmt.Sprintf("%s/%s", r.Target, c.LogicalID))
+			}
+		}
+		if len(churns) > 0 {
+			logger.Warnf("Updating the cluster will replace EC2 instances via: %s", strings.Join(churns, ", "))
+		}
+	}
+
 	if len(diffs) > 0 {
 		c.SilenceErrors = true
 		return &ExitError{fmt.Sprintf("Detected changes in: %s", strings.Join(names, ", ")), 2}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kubernetes-incubator/kube-aws/core/root"
+	"github.com/kubernetes-incubator/kube-aws/logger"
+)
+
+var (
+	cmdEtcd = &cobra.Command{
+		Use:   "etcd",
+		Short: "Operate the etcd cluster of an existing Kubernetes cluster",
+		Long:  ``,
+	}
+
+	cmdEtcdRestore = &cobra.Command{
+		Use:   "restore",
+		Short: "Restore the etcd cluster from a snapshot",
+		Long: `Replaces all the etcd nodes with the ones restored from the etcd snapshot in S3, and waits until all the members are restored.
+Every write to etcd since the snapshot was taken is lost, and the cluster is unavailable until a quorum of the members is restored.`,
+		RunE:         runCmdEtcdRestore,
+		SilenceUsage: true,
+	}
+
+	etcdRestoreOpts = struct {
+		awsDebug, force bool
+		snapshot        string
+		timeout         time.Duration
+	}{}
+)
+
+func init() {
+	RootCmd.AddCommand(cmdEtcd)
+	cmdEtcd.AddCommand(cmdEtcdRestore)
+	cmdEtcdRestore.Flags().BoolVar(&etcdRestoreOpts.awsDebug, "aws-debug", false, "Log debug information from aws-sdk-go library")
+	cmdEtcdRestore.Flags().BoolVar(&etcdRestoreOpts.force, "force", false, "Don't ask for confirmation")
+	cmdEtcdRestore.Flags().StringVar(&etcdRestoreOpts.snapshot, "snapshot", "", "The S3 URI of the etcd snapshot to restore, like the latest one s3://<bucket>/<cluster prefix>/instances/<etcd stack id>/etcd-snapshots/snapshot.db or an archived one s3://<etcd snapshots history bucket>/history/snapshot-<UTC timestamp>.db")
+	cmdEtcdRestore.Flags().DurationVar(&etcdRestoreOpts.timeout, "timeout", 30*time.Minute, "How long to wait for all the etcd members to be restored")
+}
+
+func runCmdEtcdRestore(_ *cobra.Command, _ []string) error {
+	if etcdRestoreOpts.snapshot == "" {
+		return fmt.Errorf("--snapshot is required")
+	}
+
+	if !etcdRestoreOpts.force && !etcdRestoreConfirmation() {
+		logger.Info("Operation Cancelled")
+		return nil
+	}
+
+	opts := root.NewOptions(false, false)
+	cluster, err := root.LoadClusterFromFile(configPath, opts, etcdRestoreOpts.awsDebug)
+	if err != nil {
+		return fmt.Errorf("failed to read cluster config: %v", err)
+	}
+
+	if err := cluster.RestoreEtcd(root.EtcdRestoreOptions{Snapshot: etcdRestoreOpts.snapshot, Timeout: etcdRestoreOpts.timeout}); err != nil {
+		return fmt.Errorf("failed restoring etcd: %v", err)
+	}
+
+	logger.Infof("etcd has been restored from %s. Restart kube-apiserver on controller nodes, as it may keep caching the objects written after the snapshot was taken", etcdRestoreOpts.snapshot)
+	return nil
+}
+
+func etcdRestoreConfirmation() bool {
+	reader := bufio.NewReader(os.Stdin)
+	fmt.Print("This operation will replace all the etcd nodes and discard the data written since the snapshot was taken. Are you sure? [y,n]: ")
+	text, _ := reader.ReadString('\n')
+	text = strings.TrimSuffix(strings.ToLower(text), "\n")
+
+	return text == "y" || text == "yes"
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubernetes-incubator/kube-aws/core/root"
+	"github.com/kubernetes-incubator/kube-aws/logger"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cmdExport = &cobra.Command{
+		Use:   "export",
+		Short: "Export the cluster to be provisioned by another tool",
+		Long:  ``,
+	}
+
+	cmdExportTerraform = &cobra.Command{
+		Use:   "terraform",
+		Short: "Export the cluster as Terraform configuration wrapping its CloudFormation stacks",
+		Long: `Translates the root stack into the Terraform configuration which manages the network, etcd, control-plane and node pool stacks
+as aws_cloudformation_stack resources with the same parameters, and uploads their templates and userdata to S3 as aws_s3_bucket_object resources.
+Resources added to the root stack, like the ones of plugins, are wrapped in an aws_cloudformation_stack of their own.
+The resources inside the stacks are NOT translated into native Terraform resources. They are still managed by CloudFormation and never appear in the Terraform state.
+For an existing cluster, the stacks are named after the live nested stacks, and ` + root.TerraformImportScriptFilename + ` is written to import them into the Terraform state before "terraform apply".
+The configuration and the assets are written to ` + root.TerraformExportDir + `. Run "terraform plan" and "terraform apply" in that directory instead of "kube-aws apply".`,
+		RunE:         runCmdExportTerraform,
+		SilenceUsage: true,
+	}
+
+	exportOpts = struct {
+		awsDebug, prettyPrint bool
+	}{}
+)
+
+func init() {
+	RootCmd.AddCommand(cmdExport)
+	cmdExport.AddCommand(cmdExportTerraform)
+	cmdExportTerraform.Flags().BoolVar(&exportOpts.awsDebug, "aws-debug", false, "Log debug information from aws-sdk-go library")
+	cmdExportTerraform.Flags().BoolVar(&exportOpts.prettyPrint, "pretty-print", false, "Pretty print the exported CloudFormation templates")
+}
+
+func runCmdExportTerraform(_ *cobra.Command, _ []string) error {
+	opts := root.NewOptions(exportOpts.prettyPrint, false)
+
+	cluster, err := root.LoadClusterFromFile(configPath, opts, exportOpts.awsDebug)
+	if err != nil {
+		return fmt.Errorf("failed to read cluster config: %v", err)
+	}
+
+	if err := cluster.ExportTerraform(); err != nil {
+		return err
+	}
+
+	logger.Infof("Success! Terraform configuration exported to %s\n", filepath.Join(root.TerraformExportDir, root.TerraformConfigFilename))
+	return nil
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kubernetes-incubator/kube-aws/core/root"
+	"github.com/kubernetes-incubator/kube-aws/logger"
+)
+
+// ensureWithinMaintenanceWindow refuses to update the cluster outside the maintenance window specified in cluster.yaml unless forced
+func ensureWithinMaintenanceWindow(cluster *root.Cluster, force bool) error {
+	w := cluster.Cfg.MaintenanceWindow
+	within, err := w.Contains(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to check the maintenance window: %v", err)
+	}
+	if within {
+		return nil
+	}
+	if force {
+		logger.Warnf("Updating the cluster outside the maintenance window %s as --force is specified", w)
+		return nil
+	}
+	return fmt.Errorf("refusing to update the cluster outside the maintenance window %s. Specify --force to proceed anyway", w)
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/kubernetes-incubator/kube-aws/core/root"
 	"github.com/kubernetes-incubator/kube-aws/credential"
@@ -45,9 +46,9 @@ func init() {
 	cmdRender.AddCommand(cmdRenderStack)
 
 	cmdRenderCredentials.Flags().BoolVar(&renderCredentialsOpts.GenerateCA, "generate-ca", false, "if generating credentials, generate root CA key and cert. NOT RECOMMENDED FOR PRODUCTION USE- use '-ca-key-path' and '-ca-cert-path' options to provide your own certificate authority assets")
-	cmdRenderCredentials.Flags().StringVar(&renderCredentialsOpts.CaKeyPath, "ca-key-path", "./credentials/ca-key.pem", "path to pem-encoded CA RSA key")
+	cmdRenderCredentials.Flags().StringVar(&renderCredentialsOpts.CaKeyPath, "ca-key-path", "", "path to pem-encoded CA RSA key. Defaults to ca-key.pem in the credentials directory")
 	cmdRenderCredentials.Flags().StringVar(&renderCredentialsOpts.CommonName, "cn", "kube-ca", "FQDN for CN in the self-generate CA certificate")
-	cmdRenderCredentials.Flags().StringVar(&renderCredentialsOpts.CaCertPath, "ca-cert-path", "./credentials/ca.pem", "path to pem-encoded CA x509 certificate")
+	cmdRenderCredentials.Flags().StringVar(&renderCredentialsOpts.CaCertPath, "ca-cert-path", "", "path to pem-encoded CA x509 certificate. Defaults to ca.pem in the credentials directory")
 	cmdRenderCredentials.Flags().StringVar(&renderCredentialsOpts.AdminKeyPath, "admin-key-path", "", "path to pem-encoded CA RSA key")
 	cmdRenderCredentials.Flags().StringVar(&renderCredentialsOpts.ApiServerAggregatorKeyPath, "", "", "path to pem-encoded apiserver aggregator RSA key")
 	cmdRenderCredentials.Flags().StringVar(&renderCredentialsOpts.ApiServerKeyPath, "apiserver-key-path", "", "path to pem-encoded apiserver RSA key")
@@ -60,6 +61,7 @@ func init() {
 	cmdRenderCredentials.Flags().StringVar(&renderCredentialsOpts.ServiceAccountKeyPath, "service-account-key-path", "", "path to pem-encoded service account RSA key")
 	cmdRenderCredentials.Flags().StringVar(&renderCredentialsOpts.WorkerKeyPath, "worker-key-path", "", "path to pem-encoded worker RSA key")
 	cmdRenderCredentials.Flags().BoolVar(&renderCredentialsOpts.KIAM, "kiam", true, "generate TLS assets for kiam")
+	cmdRenderCredentials.Flags().BoolVar(&renderCredentialsOpts.FrontProxy, "front-proxy", true, "generate the front-proxy CA and client certificate for the apiserver aggregation")
 	cmdRenderCredentials.Flags().BoolVar(&renderCredentialsOpts.AwsDebug, "aws-debug", false, "Log debug information from aws-sdk-go library")
 
 }
@@ -70,7 +72,15 @@ func runCmdRender(_ *cobra.Command, args []string) error {
 		return fmt.Errorf("render takes no arguments\n")
 	}
 
-	if _, err := os.Stat(renderCredentialsOpts.CaKeyPath); os.IsNotExist(err) {
+	caKeyPath := renderCredentialsOpts.CaKeyPath
+	if caKeyPath == "" {
+		layout, err := root.LoadAssetLayout(configPath)
+		if err != nil {
+			return err
+		}
+		caKeyPath = filepath.Join(layout.CredentialsPath(), "ca-key.pem")
+	}
+	if _, err := os.Stat(caKeyPath); os.IsNotExist(err) {
 		renderCredentialsOpts.GenerateCA = true
 	}
 	if err := runCmdRenderCredentials(cmdRenderCredentials, args); err != nil {
@@ -89,16 +99,21 @@ func runCmdRenderStack(_ *cobra.Command, _ []string) error {
 		return err
 	}
 
+	layout, err := root.LoadAssetLayout(configPath)
+	if err != nil {
+		return err
+	}
+
 	successMsg :=
-		`Success! Stack rendered to ./stack-templates.
+		`Success! Stack rendered to %s.
 
 Next steps:
 1. (Optional) Validate your changes to %s with "kube-aws validate"
-2. (Optional) Further customize the cluster by modifying templates in ./stack-templates or cloud-configs in ./userdata.
+2. (Optional) Further customize the cluster by modifying templates in %s or cloud-configs in %s.
 3. Start the cluster with "kube-aws apply".
 `
 
-	logger.Infof(successMsg, configPath)
+	logger.Infof(successMsg, layout.StackTemplatesPath(), configPath, layout.StackTemplatesPath(), layout.UserDataPath())
 	return nil
 }
 
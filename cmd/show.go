@@ -32,7 +32,7 @@ func init() {
 }
 
 func runCmdShowCertificates(_ *cobra.Command, _ []string) error {
-	certs, err := root.LoadCertificates()
+	certs, err := root.LoadCertificates(configPath)
 	if err != nil {
 		return err
 	}
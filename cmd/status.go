@@ -10,29 +10,53 @@ import (
 
 var (
 	cmdStatus = &cobra.Command{
-		Use:          "status",
-		Short:        "Describe an existing Kubernetes cluster",
-		Long:         ``,
+		Use:   "status",
+		Short: "Describe an existing Kubernetes cluster",
+		Long: `Reports the states of the CloudFormation stacks, the number of nodes in each auto scaling group including the ones yet to be replaced by a rolling update,
+the health of the instances behind the load balancers of the API endpoints, and the health of the etcd members.
+The etcd members are checked with the etcd client certificate in the credentials directory, and reported unhealthy when they're unreachable from where kube-aws runs.`,
 		RunE:         runCmdStatus,
 		SilenceUsage: true,
 	}
+
+	statusOpts = struct {
+		awsDebug bool
+		output   string
+	}{}
 )
 
 func init() {
 	RootCmd.AddCommand(cmdStatus)
+	cmdStatus.Flags().BoolVar(&statusOpts.awsDebug, "aws-debug", false, "Log debug information from aws-sdk-go library")
+	cmdStatus.Flags().StringVarP(&statusOpts.output, "output", "o", "table", "Output format. One of: table, json")
 }
 
 func runCmdStatus(_ *cobra.Command, _ []string) error {
-	describer, err := root.ClusterDescriberFromFile(configPath)
+	if statusOpts.output != "table" && statusOpts.output != "json" {
+		return fmt.Errorf("--output must be either table or json, but was %s", statusOpts.output)
+	}
+
+	opts := root.NewOptions(false, false)
+	cluster, err := root.LoadClusterFromFile(configPath, opts, statusOpts.awsDebug)
 	if err != nil {
 		return fmt.Errorf("failed to read cluster config: %v", err)
 	}
 
-	info, err := describer.Info()
+	status, err := cluster.Status()
 	if err != nil {
-		return fmt.Errorf("failed fetching cluster info: %v", err)
+		return fmt.Errorf("failed fetching cluster status: %v", err)
+	}
+
+	if statusOpts.output == "json" {
+		out, err := status.JSON()
+		if err != nil {
+			return err
+		}
+		// Printed to stdout as is, so that it can be piped to other tools
+		fmt.Println(out)
+		return nil
 	}
 
-	logger.Info(info)
+	logger.Info(status)
 	return nil
 }
@@ -33,7 +33,7 @@ func init() {
 	cmdUpdate.Flags().BoolVar(&updateOpts.awsDebug, "aws-debug", false, "Log debug information from aws-sdk-go library")
 	cmdUpdate.Flags().BoolVar(&updateOpts.prettyPrint, "pretty-print", false, "Pretty print the resulting CloudFormation")
 	cmdUpdate.Flags().BoolVar(&updateOpts.skipWait, "skip-wait", false, "Don't wait the resources finish")
-	cmdUpdate.Flags().BoolVar(&updateOpts.force, "force", false, "Don't ask for confirmation")
+	cmdUpdate.Flags().BoolVar(&updateOpts.force, "force", false, "Don't ask for confirmation, and update the cluster even outside the maintenance window")
 	cmdUpdate.Flags().StringSliceVar(&updateOpts.targets, "targets", root.AllOperationTargetsAsStringSlice(), "Update nothing but specified sub-stacks.  Specify `all` or any combination of `etcd`, `control-plane`, and node pool names. Defaults to `all`")
 }
 
@@ -59,6 +59,10 @@ func runCmdUpdate(_ *cobra.Command, _ []string) error {
 		return err
 	}
 
+	if err := ensureWithinMaintenanceWindow(cluster, updateOpts.force); err != nil {
+		return err
+	}
+
 	report, err := cluster.LegacyUpdate(targets)
 	if err != nil {
 		return fmt.Errorf("error updating cluster: %v", err)
@@ -0,0 +1,172 @@
+package root
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/kubernetes-incubator/kube-aws/awsconn"
+	"github.com/kubernetes-incubator/kube-aws/cfnstack"
+	"github.com/kubernetes-incubator/kube-aws/logger"
+	"github.com/kubernetes-incubator/kube-aws/pkg/api"
+	"github.com/kubernetes-incubator/kube-aws/pkg/model"
+)
+
+type recordSetService interface {
+	ChangeResourceRecordSets(input *route53.ChangeResourceRecordSetsInput) (*route53.ChangeResourceRecordSetsOutput, error)
+	ListResourceRecordSets(input *route53.ListResourceRecordSetsInput) (*route53.ListResourceRecordSetsOutput, error)
+}
+
+// apiEndpointRecordSet is the CNAME record pointing the DNS name of an API endpoint to its load balancer,
+// which kube-aws manages via `assumeRoles.route53` as CloudFormation can't create record sets in hosted zones owned by other AWS accounts
+type apiEndpointRecordSet struct {
+	HostedZoneID string
+	Name         string
+	TTL          int
+	Target       string
+}
+
+// apiEndpointRecordSets returns the record sets for the API endpoints whose load balancers' DNS names are exported from the control-plane stack
+func apiEndpointRecordSets(endpoints model.APIEndpoints, controlPlaneOutputs map[string]string) ([]apiEndpointRecordSet, error) {
+	names := []string{}
+	for name := range endpoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sets := []apiEndpointRecordSet{}
+	for _, name := range names {
+		lb := endpoints[name].LoadBalancer
+		if !lb.ManageELB() || !lb.ManageELBRecordSet() {
+			continue
+		}
+		target, ok := controlPlaneOutputs[lb.DNSNameOutputLogicalName()]
+		if !ok {
+			return nil, fmt.Errorf("the control-plane stack has no output named %s for the record set of the API endpoint \"%s\"", lb.DNSNameOutputLogicalName(), name)
+		}
+		sets = append(sets, apiEndpointRecordSet{
+			HostedZoneID: lb.HostedZoneRef(),
+			Name:         endpoints[name].DNSName,
+			TTL:          lb.RecordSetTTL(),
+			Target:       target,
+		})
+	}
+	return sets, nil
+}
+
+func (s apiEndpointRecordSet) change(action string) *route53.ChangeResourceRecordSetsInput {
+	return &route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String(s.HostedZoneID),
+		ChangeBatch: &route53.ChangeBatch{
+			Changes: []*route53.Change{
+				{
+					Action: aws.String(action),
+					ResourceRecordSet: &route53.ResourceRecordSet{
+						Name:            aws.String(s.Name),
+						Type:            aws.String(route53.RRTypeCname),
+						TTL:             aws.Int64(int64(s.TTL)),
+						ResourceRecords: []*route53.ResourceRecord{{Value: aws.String(s.Target)}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func upsertAPIEndpointRecordSets(r53 recordSetService, sets []apiEndpointRecordSet) error {
+	for _, s := range sets {
+		logger.Infof("pointing the record set for %s in the hosted zone %s to %s", s.Name, s.HostedZoneID, s.Target)
+		if _, err := r53.ChangeResourceRecordSets(s.change(route53.ChangeActionUpsert)); err != nil {
+			return fmt.Errorf("failed to upsert the record set for %s: %v", s.Name, err)
+		}
+	}
+	return nil
+}
+
+// deleteAPIEndpointRecordSets deletes the record sets still pointing to the load balancers of the cluster.
+// Those already pointed to another cluster, like the stable DNS names switched for blue-green deployments of clusters, are left as-is
+func deleteAPIEndpointRecordSets(r53 recordSetService, sets []apiEndpointRecordSet) error {
+	for _, s := range sets {
+		resp, err := r53.ListResourceRecordSets(&route53.ListResourceRecordSetsInput{
+			HostedZoneId:    aws.String(s.HostedZoneID),
+			StartRecordName: aws.String(s.Name),
+			StartRecordType: aws.String(route53.RRTypeCname),
+			MaxItems:        aws.String("1"),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list the record sets for %s: %v", s.Name, err)
+		}
+		if len(resp.ResourceRecordSets) == 0 {
+			continue
+		}
+		existing := resp.ResourceRecordSets[0]
+		if aws.StringValue(existing.Name) != model.WithTrailingDot(s.Name) || aws.StringValue(existing.Type) != route53.RRTypeCname ||
+			len(existing.ResourceRecords) != 1 || aws.StringValue(existing.ResourceRecords[0].Value) != s.Target {
+			logger.Infof("skipped deleting the record set for %s, which doesn't point to %s", s.Name, s.Target)
+			continue
+		}
+		s.TTL = int(aws.Int64Value(existing.TTL))
+		logger.Infof("deleting the record set for %s in the hosted zone %s", s.Name, s.HostedZoneID)
+		if _, err := r53.ChangeResourceRecordSets(s.change(route53.ChangeActionDelete)); err != nil {
+			return fmt.Errorf("failed to delete the record set for %s: %v", s.Name, err)
+		}
+	}
+	return nil
+}
+
+// stackOutputs returns the outputs of the stack, or nil when the stack doesn't exist
+func stackOutputs(cf cfnstack.CFInterrogator, stackName string) (map[string]string, error) {
+	exists, err := cfnstack.StackExists(cf, stackName)
+	if err != nil || !exists {
+		return nil, err
+	}
+	resp, err := cf.DescribeStacks(&cloudformation.DescribeStacksInput{StackName: aws.String(stackName)})
+	if err != nil {
+		return nil, fmt.Errorf("error describing stack %s: %v", stackName, err)
+	}
+	outputs := map[string]string{}
+	for _, o := range resp.Stacks[0].Outputs {
+		outputs[aws.StringValue(o.OutputKey)] = aws.StringValue(o.OutputValue)
+	}
+	return outputs, nil
+}
+
+// controlPlaneStackOutputs returns the outputs of the control-plane stack nested in the root stack, or nil when the cluster doesn't exist
+func controlPlaneStackOutputs(cf cfnstack.CFInterrogator, rootStackName string) (map[string]string, error) {
+	rootOutputs, err := stackOutputs(cf, rootStackName)
+	if err != nil || rootOutputs == nil {
+		return nil, err
+	}
+	cpStackName, ok := rootOutputs["ControlPlaneStackName"]
+	if !ok {
+		return nil, fmt.Errorf("the stack %s has no output named ControlPlaneStackName", rootStackName)
+	}
+	return stackOutputs(cf, cpStackName)
+}
+
+func route53RecordSetService(base *session.Session, role api.AssumeRole) recordSetService {
+	return route53.New(awsconn.WithAssumedRole(base, role))
+}
+
+// ensureAPIEndpointRecordSets points the record sets for API endpoints to the load balancers via `assumeRoles.route53`
+// once the control-plane stack is created or updated
+func (cl *Cluster) ensureAPIEndpointRecordSets() error {
+	if !cl.Cfg.AssumeRoles.Route53.Enabled() {
+		return nil
+	}
+	outputs, err := controlPlaneStackOutputs(cl.context().ProvidedCFInterrogator, cl.Cfg.RootStackName())
+	if err != nil {
+		return err
+	}
+	if outputs == nil {
+		return fmt.Errorf("the stack %s doesn't exist", cl.Cfg.RootStackName())
+	}
+	sets, err := apiEndpointRecordSets(cl.Cfg.APIEndpoints, outputs)
+	if err != nil {
+		return err
+	}
+	return upsertAPIEndpointRecordSets(route53RecordSetService(cl.session, cl.Cfg.AssumeRoles.Route53), sets)
+}
@@ -0,0 +1,105 @@
+package root
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/kubernetes-incubator/kube-aws/pkg/api"
+	"github.com/kubernetes-incubator/kube-aws/pkg/model"
+)
+
+type dummyRecordSetService struct {
+	RecordSets []*route53.ResourceRecordSet
+	Changes    []*route53.Change
+}
+
+func (s *dummyRecordSetService) ChangeResourceRecordSets(input *route53.ChangeResourceRecordSetsInput) (*route53.ChangeResourceRecordSetsOutput, error) {
+	s.Changes = append(s.Changes, input.ChangeBatch.Changes...)
+	return &route53.ChangeResourceRecordSetsOutput{}, nil
+}
+
+func (s *dummyRecordSetService) ListResourceRecordSets(input *route53.ListResourceRecordSetsInput) (*route53.ListResourceRecordSetsOutput, error) {
+	return &route53.ListResourceRecordSetsOutput{ResourceRecordSets: s.RecordSets}, nil
+}
+
+func testAPIEndpoints() model.APIEndpoints {
+	endpoint := func(name, dnsName string, lb api.APIEndpointLB) model.APIEndpoint {
+		c := api.APIEndpoint{Name: name, DNSName: dnsName, LoadBalancer: lb}
+		return model.APIEndpoint{
+			APIEndpoint:  c,
+			LoadBalancer: model.APIEndpointLB{APIEndpointLB: lb, APIEndpoint: c},
+		}
+	}
+	recordSetNotManaged := false
+	return model.APIEndpoints{
+		"public":    endpoint("public", "api.example.com", api.APIEndpointLB{HostedZone: api.HostedZone{Identifier: api.Identifier{ID: "hostedzone-xxxx"}}}),
+		"unmanaged": endpoint("unmanaged", "api-alt.example.com", api.APIEndpointLB{RecordSetManaged: &recordSetNotManaged}),
+	}
+}
+
+func TestAPIEndpointRecordSets(t *testing.T) {
+	sets, err := apiEndpointRecordSets(testAPIEndpoints(), map[string]string{"APIEndpointPublicELBDNSName": "elb.us-west-1.elb.amazonaws.com"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := apiEndpointRecordSet{HostedZoneID: "hostedzone-xxxx", Name: "api.example.com", TTL: api.DefaultRecordSetTTL, Target: "elb.us-west-1.elb.amazonaws.com"}
+	if len(sets) != 1 || sets[0] != expected {
+		t.Errorf("expected only the record set %+v but got %+v", expected, sets)
+	}
+
+	if _, err := apiEndpointRecordSets(testAPIEndpoints(), map[string]string{}); err == nil {
+		t.Errorf("expected an error for the missing stack output but got none")
+	}
+}
+
+func TestUpsertAPIEndpointRecordSets(t *testing.T) {
+	r53 := &dummyRecordSetService{}
+	sets := []apiEndpointRecordSet{{HostedZoneID: "hostedzone-xxxx", Name: "api.example.com", TTL: 300, Target: "elb.us-west-1.elb.amazonaws.com"}}
+	if err := upsertAPIEndpointRecordSets(r53, sets); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(r53.Changes) != 1 || aws.StringValue(r53.Changes[0].Action) != route53.ChangeActionUpsert {
+		t.Fatalf("expected the record set to be upserted but the changes were %v", r53.Changes)
+	}
+	rs := r53.Changes[0].ResourceRecordSet
+	if aws.StringValue(rs.Type) != "CNAME" || aws.StringValue(rs.ResourceRecords[0].Value) != "elb.us-west-1.elb.amazonaws.com" {
+		t.Errorf("unexpected record set: %v", rs)
+	}
+}
+
+func TestDeleteAPIEndpointRecordSets(t *testing.T) {
+	sets := []apiEndpointRecordSet{{HostedZoneID: "hostedzone-xxxx", Name: "api.example.com", TTL: 300, Target: "elb.us-west-1.elb.amazonaws.com"}}
+	recordSet := func(target string) *route53.ResourceRecordSet {
+		return &route53.ResourceRecordSet{
+			Name:            aws.String("api.example.com."),
+			Type:            aws.String("CNAME"),
+			TTL:             aws.Int64(60),
+			ResourceRecords: []*route53.ResourceRecord{{Value: aws.String(target)}},
+		}
+	}
+
+	pointingToCluster := &dummyRecordSetService{RecordSets: []*route53.ResourceRecordSet{recordSet("elb.us-west-1.elb.amazonaws.com")}}
+	if err := deleteAPIEndpointRecordSets(pointingToCluster, sets); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pointingToCluster.Changes) != 1 || aws.StringValue(pointingToCluster.Changes[0].Action) != route53.ChangeActionDelete {
+		t.Fatalf("expected the record set to be deleted but the changes were %v", pointingToCluster.Changes)
+	}
+	if ttl := aws.Int64Value(pointingToCluster.Changes[0].ResourceRecordSet.TTL); ttl != 60 {
+		t.Errorf("expected the record set to be deleted with the current TTL 60 but was %d", ttl)
+	}
+
+	pointingToAnotherCluster := &dummyRecordSetService{RecordSets: []*route53.ResourceRecordSet{recordSet("another.us-west-1.elb.amazonaws.com")}}
+	if err := deleteAPIEndpointRecordSets(pointingToAnotherCluster, sets); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pointingToAnotherCluster.Changes) != 0 {
+		t.Errorf("expected the record set pointing to another cluster to be kept but the changes were %v", pointingToAnotherCluster.Changes)
+	}
+
+	missing := &dummyRecordSetService{}
+	if err := deleteAPIEndpointRecordSets(missing, sets); err != nil || len(missing.Changes) != 0 {
+		t.Errorf("expected nothing to be deleted for the missing record set but got the changes %v and the error %v", missing.Changes, err)
+	}
+}
@@ -0,0 +1,47 @@
+package root
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/kubernetes-incubator/kube-aws/cfnstack"
+	"github.com/kubernetes-incubator/kube-aws/logger"
+)
+
+// replicateAssets uploads the assets already uploaded to `s3URI` to `assetReplication.s3URI` in the secondary region with the same keys,
+// after ensuring that the replica of the KMS key exists in the region to decrypt the credentials encrypted in the assets
+func (cl *Cluster) replicateAssets(assets cfnstack.Assets) error {
+	r := cl.Cfg.AssetReplication
+	if !r.Enabled() {
+		return nil
+	}
+
+	if cl.Cfg.AssetsEncryptionEnabled() {
+		keyARN, err := r.ReplicaKMSKeyARN(cl.Cfg.KMSKeyARN)
+		if err != nil {
+			return err
+		}
+		kmsSvc := kms.New(cl.session, aws.NewConfig().WithRegion(r.Region.Name))
+		out, err := kmsSvc.DescribeKey(&kms.DescribeKeyInput{KeyId: aws.String(keyARN)})
+		if err != nil {
+			return fmt.Errorf("failed to describe the replica %s of the KMS key in the region %s of `assetReplication`: %v", keyARN, r.Region, err)
+		}
+		if !aws.BoolValue(out.KeyMetadata.Enabled) {
+			return fmt.Errorf("the replica %s of the KMS key in the region %s of `assetReplication` must be enabled, but was %s", keyARN, r.Region, aws.StringValue(out.KeyMetadata.KeyState))
+		}
+	}
+
+	replicated, err := cfnstack.RelocateAssets(assets, cl.s3URI(), r.S3URI, r.Region)
+	if err != nil {
+		return fmt.Errorf("failed to locate the replicas of the assets: %v", err)
+	}
+
+	logger.Infof("replicating %d assets to %s in %s", len(replicated.AsMap()), r.S3URI, r.Region)
+	s3Svc := s3.New(cl.s3Session(), aws.NewConfig().WithRegion(r.Region.Name))
+	if err := cl.stackProvisioner().UploadAssets(s3Svc, replicated); err != nil {
+		return fmt.Errorf("failed to replicate assets to %s: %v", r.S3URI, err)
+	}
+	return nil
+}
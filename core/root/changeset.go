@@ -0,0 +1,156 @@
+package root
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/kubernetes-incubator/kube-aws/cfnstack"
+	"github.com/kubernetes-incubator/kube-aws/pkg/model"
+)
+
+// ChangeSetResult is the summary of the changes CloudFormation would make to a stack of the cluster on update
+type ChangeSetResult struct {
+	Target  string
+	Changes []cfnstack.ResourceChange
+}
+
+// InstanceChurns returns the changes which would replace EC2 instances of the stack
+func (r *ChangeSetResult) InstanceChurns() []cfnstack.ResourceChange {
+	churns := []cfnstack.ResourceChange{}
+	for _, c := range r.Changes {
+		if c.ChurnsInstances() {
+			churns = append(churns, c)
+		}
+	}
+	return churns
+}
+
+func (r *ChangeSetResult) String() string {
+	if len(r.Changes) == 0 {
+		return "  no changes\n"
+	}
+
+	var buf bytes.Buffer
+	for _, c := range r.Changes {
+		mark, action := "~", "modify"
+		switch {
+		case c.Action == cloudformation.ChangeActionAdd:
+			mark, action = "+", "add"
+		case c.Action == cloudformation.ChangeActionRemove:
+			mark, action = "-", "remove"
+		case c.Replacement == cloudformation.ReplacementTrue:
+			mark, action = "!", "replace"
+		case c.Replacement == cloudformation.ReplacementConditional:
+			mark, action = "?", "may replace"
+		}
+		fmt.Fprintf(&buf, "  %s %-11s %s (%s)", mark, action, c.LogicalID, c.ResourceType)
+		if len(c.Properties) > 0 {
+			fmt.Fprintf(&buf, " [%s]", strings.Join(c.Properties, ", "))
+		}
+		if c.ChurnsInstances() {
+			buf.WriteString(" <- REPLACES INSTANCES")
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+// PreviewChangeSets creates CloudFormation change sets updating the live stacks with the stack templates rendered from the current cluster.yaml,
+// and summarizes the changes in them. The change sets are deleted without being executed, hence the stacks are never updated.
+// It uploads the assets the stack templates refer to in the same way as `kube-aws apply` does, except the ones controller nodes periodically
+// fetch from the same S3 keys like the OIDC CA and the apiserver certificates, so that previewing never rotates them.
+// The assets aren't replicated either, as no node launches with the previewed stack templates
+func (cl *Cluster) PreviewChangeSets(opts OperationTargets) ([]*ChangeSetResult, error) {
+	if err := cl.ensureNestedStacksLoaded(); err != nil {
+		return nil, err
+	}
+
+	assets, err := cl.generateAssets(cl.operationTargetsFromUserInput([]OperationTargets{opts}))
+	if err != nil {
+		return nil, err
+	}
+	s3Svc := s3.New(cl.s3Session())
+	if err := cl.stackProvisioner().UploadAssets(s3Svc, cfnstack.ExcludeAssets(assets, model.LiveAssetFilenames...)); err != nil {
+		return nil, fmt.Errorf("failed to upload assets: %v", err)
+	}
+
+	cfnSvc := cloudformation.New(cl.session)
+	roleARN := cl.controlPlaneStack.Config.CloudFormation.RoleARN
+	autoExpand := len(cl.controlPlaneStack.Config.CloudFormation.Transforms) > 0
+
+	provisioners := map[string]*cfnstack.Provisioner{}
+	templateURLs := map[string]string{}
+
+	isAll := opts.IsAll()
+	includeAll := opts.IncludeAll(cl)
+	for _, np := range cl.nodePoolStacks {
+		includeAll = includeAll && opts.IncludeWorker(np.StackName)
+	}
+	if isAll || includeAll {
+		url, err := cl.extractRootStackTemplateURL(assets)
+		if err != nil {
+			return nil, err
+		}
+		provisioners["root"] = cl.stackProvisioner()
+		templateURLs["root"] = url
+	}
+
+	type nestedStack struct {
+		id       string
+		stack    *model.Stack
+		included bool
+	}
+	nested := []nestedStack{
+		{"network", cl.networkStack, isAll || opts.IncludeNetwork(cl.networkStack.Config.NetworkStackName())},
+		{"etcd", cl.etcdStack, isAll || opts.IncludeEtcd(cl.etcdStack.Config.EtcdStackName())},
+		{"controller", cl.controlPlaneStack, isAll || opts.IncludeControlPlane(cl.controlPlaneStack.Config.ControlPlaneStackName())},
+	}
+	for _, np := range cl.nodePoolStacks {
+		nested = append(nested, nestedStack{fmt.Sprintf("worker-%s", np.StackName), np, opts.IncludeWorker(np.StackName)})
+	}
+	for _, n := range nested {
+		if !n.included {
+			continue
+		}
+		physicalName, err := getNestedStackName(cfnSvc, cl.stackName(), n.stack.NestedStackName())
+		if err != nil {
+			// The stack of a node pool added to cluster.yaml is created by the change set of the root stack
+			if strings.HasPrefix(n.id, "worker-") {
+				continue
+			}
+			return nil, err
+		}
+		a, err := assets.FindAssetByStackAndFileName(n.stack.StackName, REMOTE_STACK_TEMPLATE_FILENAME)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find assets for stack %s: %v", n.stack.StackName, err)
+		}
+		url, err := a.URL()
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate %s stack template url: %v", n.stack.StackName, err)
+		}
+		// No tags are passed to the nested stacks, so that the tags propagated from the root stack are kept as they are
+		provisioners[n.id] = cfnstack.NewProvisioner(physicalName, nil, cl.s3URI(), cl.controlPlaneStack.Region, "", cl.session, roleARN).
+			WithAutoExpand(autoExpand)
+		templateURLs[n.id] = url
+	}
+
+	ids := make([]string, 0, len(provisioners))
+	for id := range provisioners {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	results := []*ChangeSetResult{}
+	for _, id := range ids {
+		changes, err := provisioners[id].PreviewChangeSet(cfnSvc, templateURLs[id])
+		if err != nil {
+			return nil, fmt.Errorf("failed to preview changes to %s stack: %v", id, err)
+		}
+		results = append(results, &ChangeSetResult{fmt.Sprintf("%s-stack", id), changes})
+	}
+	return results, nil
+}
@@ -118,6 +118,9 @@ type Cluster struct {
 
 	ExtraCfnResources map[string]interface{}
 
+	// stackDependencies are the logical names of the resources each nested stack depends on in addition to the implicit ones
+	stackDependencies map[string][]string
+
 	opts     options
 	session  *session.Session
 	Context  *model.Context
@@ -144,12 +147,12 @@ func CompileClusterFromFile(configPath string, opts options, awsDebug bool) (*Cl
 }
 
 func CompileClusterFromConfig(cfg *config.Config, opts options, awsDebug bool) (*Cluster, error) {
-	session, err := awsconn.NewSessionFromRegion(cfg.Region, awsDebug)
+	session, err := awsconn.NewSessionFromRegion(cfg.Region, awsDebug, cfg.AWSAPIRetry)
 	if err != nil {
 		return nil, fmt.Errorf("failed to establish aws session: %v", err)
 	}
 
-	return &Cluster{Cfg: cfg, opts: opts, awsDebug: awsDebug, extras: *cfg.Extras, session: session}, nil
+	return &Cluster{Cfg: cfg, opts: opts.withAssetLayout(cfg.AssetLayout), awsDebug: awsDebug, extras: *cfg.Extras, session: session}, nil
 }
 
 func (cl *Cluster) context() *model.Context {
@@ -164,6 +167,11 @@ func (cl *Cluster) context() *model.Context {
 	return cl.Context
 }
 
+// s3Session returns the session to access the S3 bucket for assets, which may be owned by another AWS account
+func (cl *Cluster) s3Session() *session.Session {
+	return awsconn.WithAssumedRole(cl.session, cl.Cfg.AssumeRoles.S3)
+}
+
 func (cl *Cluster) ensureNestedStacksLoaded() error {
 	if cl.loaded {
 		return nil
@@ -188,6 +196,14 @@ func (cl *Cluster) ensureNestedStacksLoaded() error {
 
 	cfg := cl.Cfg.Config
 
+	if cfg.AmiSsmParameter != "" {
+		ami, err := cl.context().ResolveAMIFromSSMParameter(cfg.AmiSsmParameter)
+		if err != nil {
+			return fmt.Errorf("failed to resolve the AMI: %v", err)
+		}
+		cfg.AMI = ami
+	}
+
 	assetsConfig, err := cl.context().LoadCredentials(cfg, stackTemplateOpts)
 	if err != nil {
 		return fmt.Errorf("failed initializing credentials: %v", err)
@@ -213,17 +229,24 @@ func (cl *Cluster) ensureNestedStacksLoaded() error {
 	nodePools := []*model.Stack{}
 	for i, c := range cfg.NodePools {
 		npOpts := api.StackTemplateOptions{
-			AssetsDir:             opts.AssetsDir,
-			WorkerTmplFile:        opts.WorkerTmplFile,
-			StackTemplateTmplFile: opts.NodePoolStackTemplateTmplFile,
-			PrettyPrint:           opts.PrettyPrint,
-			S3URI:                 cfg.DeploymentSettings.S3URI,
-			SkipWait:              opts.SkipWait,
+			AssetsDir:                  opts.AssetsDir,
+			WorkerTmplFile:             opts.WorkerTmplFile,
+			BottlerocketWorkerTmplFile: opts.BottlerocketWorkerTmplFile,
+			WindowsWorkerTmplFile:      opts.WindowsWorkerTmplFile,
+			StackTemplateTmplFile:      opts.NodePoolStackTemplateTmplFile,
+			PrettyPrint:                opts.PrettyPrint,
+			S3URI:                      cfg.DeploymentSettings.S3URI,
+			SkipWait:                   opts.SkipWait,
 		}
 		npCfg, err := model.NodePoolCompile(c, cfg)
 		if err != nil {
 			return fmt.Errorf("failed initializing worker node pool: %v", err)
 		}
+		if npCfg.AmiSsmParameter != "" {
+			if npCfg.AMI, err = cl.context().ResolveAMIFromSSMParameter(npCfg.AmiSsmParameter); err != nil {
+				return fmt.Errorf("failed to resolve the AMI for worker node pool \"%s\": %v", npCfg.NodePoolName, err)
+			}
+		}
 		npExtras := extras
 		npExtras.Configs = npCfg.Plugins
 		np, err := model.NewWorkerStack(cfg, npCfg, npOpts, npExtras, assetsConfig)
@@ -250,6 +273,10 @@ func (cl *Cluster) ensureNestedStacksLoaded() error {
 
 	cl.ExtraCfnResources = extra.Resources
 
+	if err := cl.loadStackDependencies(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -331,7 +358,7 @@ func (cl *Cluster) Diff(opts OperationTargets, context int) ([]*DiffResult, erro
 	}
 
 	cfnSvc := cloudformation.New(cl.session)
-	s3Svc := s3.New(cl.session)
+	s3Svc := s3.New(cl.s3Session())
 
 	mappings := map[string]diffSetting{}
 
@@ -376,7 +403,13 @@ func (cl *Cluster) Diff(opts OperationTargets, context int) ([]*DiffResult, erro
 				return nil, err
 			}
 			id := fmt.Sprintf("worker-%s", np.StackName)
-			mappings[id] = diffSetting{stackName, np, np.GetUserData("Worker"), np.NodePoolConfig.WorkerNodePool.LaunchConfigurationLogicalName()}
+			userdata := np.GetUserData("Worker")
+			// The settings of Bottlerocket nodes are embedded into the stack template as a whole, which the stack diff covers.
+			// Windows nodes lack the instance script diffed below, and the changes to their PowerShell script in S3 show up as the changed fingerprint
+			if np.NodePoolConfig.IsBottlerocket() || np.NodePoolConfig.IsWindows() {
+				userdata = nil
+			}
+			mappings[id] = diffSetting{stackName, np, userdata, np.NodePoolConfig.WorkerNodePool.LaunchConfigurationLogicalName()}
 		}
 	}
 
@@ -466,6 +499,10 @@ func (cl *Cluster) create(cfSvc *cloudformation.CloudFormation) error {
 		return err
 	}
 
+	if err := cl.validateServiceAccountKey(); err != nil {
+		return err
+	}
+
 	assets, err := cl.EnsureAllAssetsGenerated()
 
 	err = cl.uploadAssets(assets)
@@ -473,6 +510,10 @@ func (cl *Cluster) create(cfSvc *cloudformation.CloudFormation) error {
 		return err
 	}
 
+	if err := cl.publishServiceAccountIssuerDiscovery(); err != nil {
+		return err
+	}
+
 	stackTemplateURL, err := cl.extractRootStackTemplateURL(assets)
 	if err != nil {
 		return err
@@ -489,7 +530,11 @@ func (cl *Cluster) create(cfSvc *cloudformation.CloudFormation) error {
 		go streamStackEvents(cl, cfSvc, q)
 	}
 
-	return cl.stackProvisioner().CreateStackAtURLAndWait(cfSvc, stackTemplateURL)
+	if err := cl.stackProvisioner().CreateStackAtURLAndWait(cfSvc, stackTemplateURL); err != nil {
+		return err
+	}
+
+	return cl.ensureAPIEndpointRecordSets()
 }
 
 func (cl *Cluster) Info() (*Info, error) {
@@ -536,10 +581,7 @@ func (cl *Cluster) generateAssets(targets OperationTargets) (cfnstack.Assets, er
 		Merge(etcdAssets).
 		Merge(wAssets)
 
-	s3URI := fmt.Sprintf("%s/kube-aws/clusters/%s/exported/stacks",
-		strings.TrimSuffix(cl.s3URI(), "/"),
-		cl.controlPlaneStack.ClusterName,
-	)
+	s3URI := cl.controlPlaneStack.ClusterExportedStacksS3URI()
 	rootStackAssetsBuilder, err := cfnstack.NewAssetsBuilder(cl.stackName(), s3URI, cl.controlPlaneStack.Region)
 	if err != nil {
 		return nil, err
@@ -605,12 +647,12 @@ func (cl *Cluster) getCurrentRootStackTemplate() (string, error) {
 }
 
 func (cl *Cluster) uploadAssets(assets cfnstack.Assets) error {
-	s3Svc := s3.New(cl.session)
+	s3Svc := s3.New(cl.s3Session())
 	err := cl.stackProvisioner().UploadAssets(s3Svc, assets)
 	if err != nil {
 		return fmt.Errorf("failed to upload assets: %v", err)
 	}
-	return nil
+	return cl.replicateAssets(assets)
 }
 
 func (cl *Cluster) extractRootStackTemplateURL(assets cfnstack.Assets) (string, error) {
@@ -671,7 +713,8 @@ func (cl *Cluster) stackProvisioner() *cfnstack.Provisioner {
 		stackPolicyBody,
 		cl.session,
 		cl.controlPlaneStack.Config.CloudFormation.RoleARN,
-	)
+	).WithTerminationProtection(cl.controlPlaneStack.Config.CloudFormation.TerminationProtection).
+		WithAutoExpand(len(cl.controlPlaneStack.Config.CloudFormation.Transforms) > 0)
 }
 
 func (cl Cluster) stackName() string {
@@ -687,13 +730,22 @@ func (cl Cluster) tags() map[string]string {
 	return cptags
 }
 
+// Exists returns true when the root stack of the cluster exists
+func (cl *Cluster) Exists() (bool, error) {
+	exists, err := cfnstack.StackExists(cl.context().ProvidedCFInterrogator, cl.controlPlaneStack.ClusterName)
+	if err != nil {
+		logger.Errorf("please check your AWS credentials/permissions")
+		return false, fmt.Errorf("can't lookup AWS CloudFormation stacks: %s", err)
+	}
+	return exists, nil
+}
+
 func (cl *Cluster) Apply(targets OperationTargets) error {
 	cfSvc := cloudformation.New(cl.session)
 
-	exists, err := cfnstack.StackExists(cl.context().ProvidedCFInterrogator, cl.controlPlaneStack.ClusterName)
+	exists, err := cl.Exists()
 	if err != nil {
-		logger.Errorf("please check your AWS credentials/permissions")
-		return fmt.Errorf("can't lookup AWS CloudFormation stacks: %s", err)
+		return err
 	}
 
 	if exists {
@@ -716,6 +768,9 @@ func (cl *Cluster) LegacyUpdate(targets OperationTargets) (string, error) {
 }
 
 func (cl *Cluster) update(cfSvc *cloudformation.CloudFormation, targets OperationTargets) (string, error) {
+	if err := cl.validateServiceAccountKey(); err != nil {
+		return "", err
+	}
 
 	assets, err := cl.generateAssets(cl.operationTargetsFromUserInput([]OperationTargets{targets}))
 	if err != nil {
@@ -727,6 +782,10 @@ func (cl *Cluster) update(cfSvc *cloudformation.CloudFormation, targets Operatio
 		return "", err
 	}
 
+	if err := cl.publishServiceAccountIssuerDiscovery(); err != nil {
+		return "", err
+	}
+
 	templateUrl, err := cl.extractRootStackTemplateURL(assets)
 	if err != nil {
 		return "", err
@@ -743,7 +802,16 @@ func (cl *Cluster) update(cfSvc *cloudformation.CloudFormation, targets Operatio
 		go streamStackEvents(cl, cfSvc, q)
 	}
 
-	return cl.stackProvisioner().UpdateStackAtURLAndWait(cfSvc, templateUrl)
+	report, err := cl.stackProvisioner().UpdateStackAtURLAndWait(cfSvc, templateUrl)
+	if err != nil {
+		return "", err
+	}
+
+	if err := cl.ensureAPIEndpointRecordSets(); err != nil {
+		return "", err
+	}
+
+	return report, nil
 }
 
 func (cl *Cluster) ValidateTemplates() error {
@@ -774,6 +842,10 @@ func (cl *Cluster) ValidateStack(opts ...OperationTargets) (string, error) {
 		return "", err
 	}
 
+	if err := cl.validateServiceAccountKey(); err != nil {
+		return "", err
+	}
+
 	reports := []string{}
 
 	targets := cl.operationTargetsFromUserInput(opts)
@@ -815,6 +887,10 @@ func (cl *Cluster) ValidateStack(opts ...OperationTargets) (string, error) {
 	}
 	reports = append(reports, cpReport)
 
+	if err := ctx.ValidateInjectedKubernetesObjects(cl.controlPlaneStack.Config); err != nil {
+		return "", fmt.Errorf("failed to validate kubernetes secrets and configmaps: %v", err)
+	}
+
 	etcdReport, err := ctx.ValidateStack(cl.etcdStack)
 	if err != nil {
 		return "", fmt.Errorf("failed to validate etcd plane: %v", err)
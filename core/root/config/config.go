@@ -75,6 +75,15 @@ func ConfigFromBytes(data []byte, plugins []*api.Plugin) (*Config, error) {
 		return nil, err
 	}
 
+	if err := c.PluginConfigs.Validate(plugins, "kubeAwsPlugins"); err != nil {
+		return nil, err
+	}
+	for i, np := range c.NodePools {
+		if err := np.Plugins.Validate(plugins, fmt.Sprintf("worker.nodePools[%d].kubeAwsPlugins", i)); err != nil {
+			return nil, err
+		}
+	}
+
 	extras := clusterextension.NewExtrasFromPlugins(plugins, c.PluginConfigs)
 
 	opts := api.ClusterOptions{
@@ -2,14 +2,18 @@ package root
 
 import (
 	"fmt"
-	"github.com/kubernetes-incubator/kube-aws/core/root/defaults"
 	"github.com/kubernetes-incubator/kube-aws/credential"
 	"github.com/kubernetes-incubator/kube-aws/logger"
+	"github.com/kubernetes-incubator/kube-aws/pkg/api"
+	"github.com/kubernetes-incubator/kube-aws/pkg/model"
 	"github.com/kubernetes-incubator/kube-aws/pki"
 	"io/ioutil"
+	"math"
 	"os"
-	"path"
+	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 )
 
 func RenderCredentials(configPath string, renderCredentialsOpts credential.GeneratorOptions) error {
@@ -19,26 +23,48 @@ func RenderCredentials(configPath string, renderCredentialsOpts credential.Gener
 		return err
 	}
 
-	if err := os.MkdirAll(defaults.AssetsDir, 0700); err != nil {
+	assetsDir := cluster.opts.AssetsDir
+	if err := ensureWritableDir(assetsDir, 0700); err != nil {
 		return err
 	}
 
-	if _, err = cluster.GenerateAssetsOnDisk(defaults.AssetsDir, renderCredentialsOpts); err != nil {
+	if renderCredentialsOpts.CaKeyPath == "" {
+		renderCredentialsOpts.CaKeyPath = filepath.Join(assetsDir, "ca-key.pem")
+	}
+	if renderCredentialsOpts.CaCertPath == "" {
+		renderCredentialsOpts.CaCertPath = filepath.Join(assetsDir, "ca.pem")
+	}
+
+	if _, err = cluster.GenerateAssetsOnDisk(assetsDir, renderCredentialsOpts); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func LoadCertificates() (map[string]pki.Certificates, error) {
+// LoadAssetLayout returns the directory structure assets are rendered into, according to `assetLayout` in the cluster.yaml
+func LoadAssetLayout(configPath string) (api.AssetLayout, error) {
+	c, err := model.ClusterFromFile(configPath)
+	if err != nil {
+		return api.AssetLayout{}, err
+	}
+	return c.AssetLayout, nil
+}
 
-	if _, err := os.Stat(defaults.AssetsDir); os.IsNotExist(err) {
-		return nil, fmt.Errorf("%s does not exist, run 'render credentials' first", defaults.AssetsDir)
+func LoadCertificates(configPath string) (map[string]pki.Certificates, error) {
+	layout, err := LoadAssetLayout(configPath)
+	if err != nil {
+		return nil, err
+	}
+	assetsDir := layout.CredentialsPath()
+
+	if _, err := os.Stat(assetsDir); os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s does not exist, run 'render credentials' first", assetsDir)
 	}
 
-	files, err := ioutil.ReadDir(defaults.AssetsDir)
+	files, err := ioutil.ReadDir(assetsDir)
 	if err != nil {
-		return nil, fmt.Errorf("cannot read files from %s: %v", defaults.AssetsDir, err)
+		return nil, fmt.Errorf("cannot read files from %s: %v", assetsDir, err)
 	}
 
 	certs := make(map[string]pki.Certificates)
@@ -46,7 +72,7 @@ func LoadCertificates() (map[string]pki.Certificates, error) {
 		if f.IsDir() || !strings.HasSuffix(f.Name(), ".pem") {
 			continue
 		}
-		b, err := ioutil.ReadFile(path.Join(defaults.AssetsDir, f.Name()))
+		b, err := ioutil.ReadFile(filepath.Join(assetsDir, f.Name()))
 		if err != nil {
 			logger.Warnf("cannot read %q file: %v", f.Name(), err)
 			continue
@@ -63,3 +89,103 @@ func LoadCertificates() (map[string]pki.Certificates, error) {
 	}
 	return certs, nil
 }
+
+// ensureWritableDir creates the directory if missing and fails early unless files can be written into it
+func ensureWritableDir(dir string, perm os.FileMode) error {
+	if err := os.MkdirAll(dir, perm); err != nil {
+		return fmt.Errorf("failed to create directory %s: %v", dir, err)
+	}
+	f, err := ioutil.TempFile(dir, ".kube-aws-")
+	if err != nil {
+		return fmt.Errorf("directory %s is not writable: %v", dir, err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// CertificateStatus is the expiry of a certificate in the credentials directory
+type CertificateStatus struct {
+	File       string
+	CommonName string
+	NotAfter   time.Time
+	DaysLeft   int
+}
+
+// State is either EXPIRED, EXPIRING when the certificate expires within the warnDays, or OK
+func (s CertificateStatus) State(warnDays int) string {
+	switch {
+	case s.DaysLeft < 0:
+		return "EXPIRED"
+	case s.DaysLeft < warnDays:
+		return "EXPIRING"
+	}
+	return "OK"
+}
+
+// CredentialsStatus returns the expiry of all the certificates in the credentials directory, ordered by the file name
+func CredentialsStatus(configPath string) ([]CertificateStatus, error) {
+	certs, err := LoadCertificates(configPath)
+	if err != nil {
+		return nil, err
+	}
+	return certificateStatuses(certs, time.Now()), nil
+}
+
+func certificateStatuses(certs map[string]pki.Certificates, now time.Time) []CertificateStatus {
+	statuses := []CertificateStatus{}
+	for f, cs := range certs {
+		for _, c := range cs {
+			statuses = append(statuses, CertificateStatus{
+				File:       f,
+				CommonName: c.Subject.CommonName,
+				NotAfter:   c.NotAfter,
+				DaysLeft:   int(math.Floor(c.NotAfter.Sub(now).Hours() / 24)),
+			})
+		}
+	}
+	sort.SliceStable(statuses, func(i, j int) bool {
+		if statuses[i].File != statuses[j].File {
+			return statuses[i].File < statuses[j].File
+		}
+		return statuses[i].NotAfter.Before(statuses[j].NotAfter)
+	})
+	return statuses
+}
+
+// CredentialsRotateOptions selects the certificates rotated by RotateCredentials
+type CredentialsRotateOptions struct {
+	AwsDebug bool
+	// CA advances the rotation of the cluster CA by one phase
+	CA bool
+	// Leaf re-issues all the certificates signed by the cluster CA with the existing keys
+	Leaf bool
+}
+
+// RotateCredentials re-issues the certificates in the credentials directory. Certificates are re-encrypted with KMS and
+// rolled out to the nodes by replacing them in the rolling update triggered by the next `kube-aws apply`.
+// It returns the phase of the CA rotation completed when opts.CA is true, or zero otherwise.
+func RotateCredentials(configPath string, opts CredentialsRotateOptions) (credential.CARotationPhase, error) {
+	if opts.CA == opts.Leaf {
+		return 0, fmt.Errorf("either --ca or --leaf must be specified")
+	}
+
+	cluster, err := CompileClusterFromFile(configPath, NewOptions(false, false), opts.AwsDebug)
+	if err != nil {
+		return 0, err
+	}
+
+	assetsDir := cluster.opts.AssetsDir
+	if err := ensureWritableDir(assetsDir, 0700); err != nil {
+		return 0, err
+	}
+
+	r := credential.CertificateRotator{
+		Generator: *model.NewCredentialGenerator(cluster.Cfg.Config),
+		Dir:       assetsDir,
+	}
+
+	if opts.Leaf {
+		return 0, r.RotateLeafCertificates()
+	}
+	return r.RotateCA()
+}
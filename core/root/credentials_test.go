@@ -0,0 +1,42 @@
+package root
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kubernetes-incubator/kube-aws/pki"
+)
+
+func TestCertificateStatuses(t *testing.T) {
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	cert := func(cn string, days int) pki.Certificate {
+		return pki.Certificate{Subject: pki.DN{CommonName: cn}, NotAfter: now.Add(time.Duration(days)*24*time.Hour + time.Hour)}
+	}
+	certs := map[string]pki.Certificates{
+		"worker.pem":    {cert("kube-worker", 100)},
+		"ca.pem":        {cert("kube-ca", 3000), cert("kube-ca", 10)},
+		"apiserver.pem": {cert("kube-apiserver", -2)},
+	}
+
+	statuses := certificateStatuses(certs, now)
+
+	expected := []struct {
+		file     string
+		daysLeft int
+		state    string
+	}{
+		{"apiserver.pem", -2, "EXPIRED"},
+		{"ca.pem", 10, "EXPIRING"},
+		{"ca.pem", 3000, "OK"},
+		{"worker.pem", 100, "OK"},
+	}
+	if len(statuses) != len(expected) {
+		t.Fatalf("expected %d statuses, but got %d: %+v", len(expected), len(statuses), statuses)
+	}
+	for i, e := range expected {
+		s := statuses[i]
+		if s.File != e.file || s.DaysLeft != e.daysLeft || s.State(30) != e.state {
+			t.Errorf("case %d: expected %s expiring in %d days to be %s, but got %s expiring in %d days to be %s", i, e.file, e.daysLeft, e.state, s.File, s.DaysLeft, s.State(30))
+		}
+	}
+}
@@ -4,6 +4,8 @@ const (
 	AssetsDir                         = "credentials"
 	ControllerTmplFile                = "userdata/cloud-config-controller"
 	WorkerTmplFile                    = "userdata/cloud-config-worker"
+	BottlerocketWorkerTmplFile        = "userdata/bottlerocket-worker"
+	WindowsWorkerTmplFile             = "userdata/windows-worker"
 	EtcdTmplFile                      = "userdata/cloud-config-etcd"
 	ControlPlaneStackTemplateTmplFile = "stack-templates/control-plane.json.tmpl"
 	NetworkStackTemplateTmplFile      = "stack-templates/network.json.tmpl"
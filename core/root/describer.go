@@ -35,7 +35,7 @@ func ClusterDescriberFromFile(configPath string) (ClusterDescriber, error) {
 		return nil, err
 	}
 
-	session, err := awsconn.NewSessionFromRegion(config.Region, false)
+	session, err := awsconn.NewSessionFromRegion(config.Region, false, config.AWSAPIRetry)
 	if err != nil {
 		return nil, fmt.Errorf("failed to establish aws session: %v", err)
 	}
@@ -3,14 +3,18 @@ package root
 import (
 	"fmt"
 
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/kubernetes-incubator/kube-aws/awsconn"
 	"github.com/kubernetes-incubator/kube-aws/cfnstack"
 	"github.com/kubernetes-incubator/kube-aws/core/root/config"
+	"github.com/kubernetes-incubator/kube-aws/logger"
 )
 
 type DestroyOptions struct {
-	AwsDebug bool
-	Force    bool
+	AwsDebug                     bool
+	Force                        bool
+	DisableTerminationProtection bool
 }
 
 type ClusterDestroyer interface {
@@ -19,6 +23,8 @@ type ClusterDestroyer interface {
 
 type clusterDestroyerImpl struct {
 	underlying *cfnstack.Destroyer
+	cfg        *config.Config
+	session    *session.Session
 }
 
 func ClusterDestroyerFromFile(configPath string, opts DestroyOptions) (ClusterDestroyer, error) {
@@ -27,17 +33,40 @@ func ClusterDestroyerFromFile(configPath string, opts DestroyOptions) (ClusterDe
 		return nil, err
 	}
 
-	session, err := awsconn.NewSessionFromRegion(cfg.Region, opts.AwsDebug)
+	session, err := awsconn.NewSessionFromRegion(cfg.Region, opts.AwsDebug, cfg.AWSAPIRetry)
 	if err != nil {
 		return nil, fmt.Errorf("failed to establish aws session: %v", err)
 	}
 
-	cfnDestroyer := cfnstack.NewDestroyer(cfg.RootStackName(), session, cfg.CloudFormation.RoleARN)
+	cfnDestroyer := cfnstack.NewDestroyer(cfg.RootStackName(), session, cfg.CloudFormation.RoleARN, opts.DisableTerminationProtection)
 	return clusterDestroyerImpl{
 		underlying: cfnDestroyer,
+		cfg:        cfg,
+		session:    session,
 	}, nil
 }
 
 func (d clusterDestroyerImpl) Destroy() error {
+	if err := d.deleteAPIEndpointRecordSets(); err != nil {
+		return err
+	}
 	return d.underlying.Destroy()
 }
+
+// deleteAPIEndpointRecordSets deletes the record sets kube-aws created via `assumeRoles.route53`, which CloudFormation doesn't delete along with the stacks
+func (d clusterDestroyerImpl) deleteAPIEndpointRecordSets() error {
+	if !d.cfg.AssumeRoles.Route53.Enabled() {
+		return nil
+	}
+	outputs, err := controlPlaneStackOutputs(cloudformation.New(d.session), d.cfg.RootStackName())
+	if err != nil || outputs == nil {
+		return err
+	}
+	sets, err := apiEndpointRecordSets(d.cfg.APIEndpoints, outputs)
+	if err != nil {
+		// The cluster may have been created before `assumeRoles.route53` was specified, hence without the record sets
+		logger.Warnf("skipped deleting the record sets for API endpoints: %v", err)
+		return nil
+	}
+	return deleteAPIEndpointRecordSets(route53RecordSetService(d.session, d.cfg.AssumeRoles.Route53), sets)
+}
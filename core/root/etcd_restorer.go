@@ -0,0 +1,216 @@
+package root
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/kubernetes-incubator/kube-aws/cfnstack"
+	"github.com/kubernetes-incubator/kube-aws/logger"
+)
+
+// The keys under the etcd snapshots prefix the restore is requested with, which are read by `etcdadm reconfigure` on boot.
+// The snapshot is copied to a key of its own so that `etcdadm save` on a still running member never overwrites it
+const (
+	etcdRestoreSnapshotKey     = "restore/snapshot.db"
+	etcdRestoreMemberKeyPrefix = "restore/member-"
+	etcdRestoreMemberKeySuffix = ".request"
+)
+
+type EtcdRestoreOptions struct {
+	// Snapshot is the S3 URI of the etcd snapshot to restore, like `s3://mybucket/mycluster/instances/<id>/etcd-snapshots/snapshot.db`
+	Snapshot string
+	// Timeout is how long to wait for all the members to be restored
+	Timeout time.Duration
+}
+
+type etcdRestoreS3Service interface {
+	HeadObject(*s3.HeadObjectInput) (*s3.HeadObjectOutput, error)
+	CopyObject(*s3.CopyObjectInput) (*s3.CopyObjectOutput, error)
+	PutObject(*s3.PutObjectInput) (*s3.PutObjectOutput, error)
+	ListObjectsV2(*s3.ListObjectsV2Input) (*s3.ListObjectsV2Output, error)
+	DeleteObject(*s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error)
+}
+
+type etcdRestoreASGService interface {
+	DescribeAutoScalingGroups(*autoscaling.DescribeAutoScalingGroupsInput) (*autoscaling.DescribeAutoScalingGroupsOutput, error)
+	TerminateInstanceInAutoScalingGroup(*autoscaling.TerminateInstanceInAutoScalingGroupInput) (*autoscaling.TerminateInstanceInAutoScalingGroupOutput, error)
+}
+
+// etcdRestorer restores every etcd member from a single snapshot, so that the members form a new cluster with the data in it.
+// It requests every member to restore via S3 and then replaces all the etcd nodes at once.
+// On boot, `etcdadm reconfigure` finds the request for the member, restores the data dir from the snapshot and deletes the request
+type etcdRestorer struct {
+	s3Svc  etcdRestoreS3Service
+	asgSvc etcdRestoreASGService
+
+	// bucket and prefix locate the etcd snapshots of the cluster
+	bucket      string
+	prefix      string
+	memberCount int
+	asgNames    []string
+
+	interval time.Duration
+	timeout  time.Duration
+}
+
+// RestoreEtcd replaces all the etcd members with the ones restored from the snapshot.
+// Every write to etcd since the snapshot was taken is lost, and etcd is unavailable until a quorum of the members is restored
+func (cl *Cluster) RestoreEtcd(opts EtcdRestoreOptions) error {
+	if err := cl.ensureNestedStacksLoaded(); err != nil {
+		return err
+	}
+
+	etcd := cl.etcdStack.Config.Etcd
+	if !etcd.Version().Is3() {
+		return fmt.Errorf("restoring etcd from a snapshot requires etcd 3, but the version was %s", etcd.Version())
+	}
+
+	cfnSvc := cloudformation.New(cl.session)
+	// The physical id of a nested stack is its stack id
+	stackID, err := getNestedStackName(cfnSvc, cl.stackName(), cl.etcdStack.NestedStackName())
+	if err != nil {
+		return err
+	}
+	stackIDComponents := strings.Split(stackID, "/")
+	if len(stackIDComponents) != 3 {
+		return fmt.Errorf("unexpected etcd stack id: %s", stackID)
+	}
+
+	resources, err := cfnSvc.DescribeStackResources(&cloudformation.DescribeStackResourcesInput{StackName: aws.String(stackID)})
+	if err != nil {
+		return fmt.Errorf("failed to describe resources of etcd stack: %v", err)
+	}
+	asgNames := []string{}
+	for _, r := range resources.StackResources {
+		if aws.StringValue(r.ResourceType) == "AWS::AutoScaling::AutoScalingGroup" {
+			asgNames = append(asgNames, aws.StringValue(r.PhysicalResourceId))
+		}
+	}
+	sort.Strings(asgNames)
+
+	bucket, err := cl.etcdStack.EtcdSnapshotsS3Bucket()
+	if err != nil {
+		return err
+	}
+	clusterPrefix, err := cl.etcdStack.ClusterS3Prefix()
+	if err != nil {
+		return err
+	}
+
+	r := etcdRestorer{
+		s3Svc:       s3.New(cl.s3Session()),
+		asgSvc:      autoscaling.New(cl.session),
+		bucket:      bucket,
+		prefix:      fmt.Sprintf("%s/instances/%s/etcd-snapshots", clusterPrefix, stackIDComponents[2]),
+		memberCount: etcd.Count,
+		asgNames:    asgNames,
+		interval:    10 * time.Second,
+		timeout:     opts.Timeout,
+	}
+	return r.restore(opts.Snapshot)
+}
+
+func (r etcdRestorer) key(name string) string {
+	return fmt.Sprintf("%s/%s", r.prefix, name)
+}
+
+func (r etcdRestorer) memberKey(index int) string {
+	return r.key(fmt.Sprintf("%s%d%s", etcdRestoreMemberKeyPrefix, index, etcdRestoreMemberKeySuffix))
+}
+
+func (r etcdRestorer) restore(snapshot string) error {
+	src, err := cfnstack.S3URIFromString(snapshot)
+	if err != nil {
+		return fmt.Errorf("invalid snapshot: %v", err)
+	}
+	if len(src.KeyComponents()) == 0 {
+		return fmt.Errorf("invalid snapshot: %s is not an S3 object", snapshot)
+	}
+	srcKey := strings.Join(src.KeyComponents(), "/")
+	if _, err := r.s3Svc.HeadObject(&s3.HeadObjectInput{Bucket: aws.String(src.Bucket()), Key: aws.String(srcKey)}); err != nil {
+		return fmt.Errorf("failed to find snapshot %s: %v", snapshot, err)
+	}
+	if len(r.asgNames) != r.memberCount {
+		return fmt.Errorf("expected %d auto scaling groups for etcd members but found %d: %v", r.memberCount, len(r.asgNames), r.asgNames)
+	}
+
+	logger.Infof("copying %s to s3://%s/%s", snapshot, r.bucket, r.key(etcdRestoreSnapshotKey))
+	if _, err := r.s3Svc.CopyObject(&s3.CopyObjectInput{
+		Bucket:     aws.String(r.bucket),
+		Key:        aws.String(r.key(etcdRestoreSnapshotKey)),
+		CopySource: aws.String(src.BucketAndKey()),
+	}); err != nil {
+		return fmt.Errorf("failed to copy snapshot %s: %v", snapshot, err)
+	}
+
+	for i := 0; i < r.memberCount; i++ {
+		if _, err := r.s3Svc.PutObject(&s3.PutObjectInput{
+			Bucket: aws.String(r.bucket),
+			Key:    aws.String(r.memberKey(i)),
+			Body:   strings.NewReader(snapshot),
+		}); err != nil {
+			return fmt.Errorf("failed to request etcd member %d to restore: %v", i, err)
+		}
+	}
+
+	groups, err := r.asgSvc.DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{AutoScalingGroupNames: aws.StringSlice(r.asgNames)})
+	if err != nil {
+		return fmt.Errorf("failed to describe auto scaling groups of etcd members: %v", err)
+	}
+	// All the members are stopped at once, so that no member keeps serving the data diverged from the snapshot
+	for _, g := range groups.AutoScalingGroups {
+		for _, i := range g.Instances {
+			logger.Infof("terminating etcd node %s in %s", aws.StringValue(i.InstanceId), aws.StringValue(g.AutoScalingGroupName))
+			if _, err := r.asgSvc.TerminateInstanceInAutoScalingGroup(&autoscaling.TerminateInstanceInAutoScalingGroupInput{
+				InstanceId:                     i.InstanceId,
+				ShouldDecrementDesiredCapacity: aws.Bool(false),
+			}); err != nil {
+				return fmt.Errorf("failed to terminate etcd node %s: %v", aws.StringValue(i.InstanceId), err)
+			}
+		}
+	}
+
+	if err := r.waitUntilMembersGetRestored(); err != nil {
+		return err
+	}
+
+	if _, err := r.s3Svc.DeleteObject(&s3.DeleteObjectInput{Bucket: aws.String(r.bucket), Key: aws.String(r.key(etcdRestoreSnapshotKey))}); err != nil {
+		logger.Warnf("failed to delete s3://%s/%s: %v", r.bucket, r.key(etcdRestoreSnapshotKey), err)
+	}
+	return nil
+}
+
+// waitUntilMembersGetRestored waits until every member deletes the request to restore it
+func (r etcdRestorer) waitUntilMembersGetRestored() error {
+	deadline := time.Now().Add(r.timeout)
+	for {
+		resp, err := r.s3Svc.ListObjectsV2(&s3.ListObjectsV2Input{
+			Bucket: aws.String(r.bucket),
+			Prefix: aws.String(r.key(etcdRestoreMemberKeyPrefix)),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list etcd members to be restored: %v", err)
+		}
+		if len(resp.Contents) == 0 {
+			logger.Info("all the etcd members have been restored")
+			return nil
+		}
+
+		// The indices of the members yet to be restored
+		remaining := make([]string, len(resp.Contents))
+		for i, o := range resp.Contents {
+			remaining[i] = strings.TrimSuffix(strings.TrimPrefix(aws.StringValue(o.Key), r.key(etcdRestoreMemberKeyPrefix)), etcdRestoreMemberKeySuffix)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for etcd members to be restored: members %s remaining", strings.Join(remaining, ", "))
+		}
+		logger.Infof("waiting for etcd members to be restored: members %s remaining", strings.Join(remaining, ", "))
+		time.Sleep(r.interval)
+	}
+}
@@ -0,0 +1,144 @@
+package root
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+type dummyEtcdRestoreS3Service struct {
+	Objects map[string]bool
+	Copied  []*s3.CopyObjectInput
+	// RestoreOnList deletes a restore request whenever the objects are listed, as `etcdadm reconfigure` on an etcd node does
+	RestoreOnList bool
+}
+
+func (s *dummyEtcdRestoreS3Service) HeadObject(input *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+	if !s.Objects[*input.Bucket+"/"+*input.Key] {
+		return nil, errors.New("NotFound")
+	}
+	return &s3.HeadObjectOutput{}, nil
+}
+
+func (s *dummyEtcdRestoreS3Service) CopyObject(input *s3.CopyObjectInput) (*s3.CopyObjectOutput, error) {
+	s.Copied = append(s.Copied, input)
+	s.Objects[*input.Bucket+"/"+*input.Key] = true
+	return &s3.CopyObjectOutput{}, nil
+}
+
+func (s *dummyEtcdRestoreS3Service) PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	s.Objects[*input.Bucket+"/"+*input.Key] = true
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (s *dummyEtcdRestoreS3Service) ListObjectsV2(input *s3.ListObjectsV2Input) (*s3.ListObjectsV2Output, error) {
+	keys := []string{}
+	for k := range s.Objects {
+		if key := strings.TrimPrefix(k, *input.Bucket+"/"); key != k && strings.HasPrefix(key, *input.Prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	out := &s3.ListObjectsV2Output{}
+	for _, k := range keys {
+		out.Contents = append(out.Contents, &s3.Object{Key: aws.String(k)})
+	}
+	if s.RestoreOnList && len(keys) > 0 {
+		delete(s.Objects, *input.Bucket+"/"+keys[0])
+	}
+	return out, nil
+}
+
+func (s *dummyEtcdRestoreS3Service) DeleteObject(input *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
+	delete(s.Objects, *input.Bucket+"/"+*input.Key)
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+type dummyEtcdRestoreASGService struct {
+	Terminated []string
+}
+
+func (s *dummyEtcdRestoreASGService) DescribeAutoScalingGroups(input *autoscaling.DescribeAutoScalingGroupsInput) (*autoscaling.DescribeAutoScalingGroupsOutput, error) {
+	out := &autoscaling.DescribeAutoScalingGroupsOutput{}
+	for _, n := range input.AutoScalingGroupNames {
+		out.AutoScalingGroups = append(out.AutoScalingGroups, &autoscaling.Group{
+			AutoScalingGroupName: n,
+			Instances:            []*autoscaling.Instance{{InstanceId: aws.String("i-" + *n)}},
+		})
+	}
+	return out, nil
+}
+
+func (s *dummyEtcdRestoreASGService) TerminateInstanceInAutoScalingGroup(input *autoscaling.TerminateInstanceInAutoScalingGroupInput) (*autoscaling.TerminateInstanceInAutoScalingGroupOutput, error) {
+	if aws.BoolValue(input.ShouldDecrementDesiredCapacity) {
+		return nil, errors.New("etcd nodes must be replaced")
+	}
+	s.Terminated = append(s.Terminated, *input.InstanceId)
+	return &autoscaling.TerminateInstanceInAutoScalingGroupOutput{}, nil
+}
+
+func TestEtcdRestorer(t *testing.T) {
+	snapshot := "s3://backups/mycluster/etcd-snapshots/history/snapshot-20180101T000000Z.db"
+	newRestorer := func(s3Svc *dummyEtcdRestoreS3Service, asgSvc *dummyEtcdRestoreASGService) etcdRestorer {
+		return etcdRestorer{
+			s3Svc:       s3Svc,
+			asgSvc:      asgSvc,
+			bucket:      "mybucket",
+			prefix:      "mycluster/instances/1234/etcd-snapshots",
+			memberCount: 3,
+			asgNames:    []string{"etcd0", "etcd1", "etcd2"},
+			timeout:     time.Minute,
+		}
+	}
+
+	s3Svc := &dummyEtcdRestoreS3Service{Objects: map[string]bool{"backups/mycluster/etcd-snapshots/history/snapshot-20180101T000000Z.db": true}, RestoreOnList: true}
+	asgSvc := &dummyEtcdRestoreASGService{}
+	if err := newRestorer(s3Svc, asgSvc).restore(snapshot); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(s3Svc.Copied) != 1 || *s3Svc.Copied[0].CopySource != "backups/mycluster/etcd-snapshots/history/snapshot-20180101T000000Z.db" || *s3Svc.Copied[0].Key != "mycluster/instances/1234/etcd-snapshots/restore/snapshot.db" {
+		t.Errorf("expected the snapshot to be copied for etcd nodes to restore from, but copied: %+v", s3Svc.Copied)
+	}
+	if len(asgSvc.Terminated) != 3 {
+		t.Errorf("expected all the etcd nodes to be replaced, but terminated: %v", asgSvc.Terminated)
+	}
+	for k := range s3Svc.Objects {
+		if strings.HasPrefix(k, "mybucket/") {
+			t.Errorf("expected the restore requests and the copied snapshot to be deleted, but %s remained", k)
+		}
+	}
+
+	missing := &dummyEtcdRestoreS3Service{Objects: map[string]bool{}}
+	asgSvc = &dummyEtcdRestoreASGService{}
+	if err := newRestorer(missing, asgSvc).restore(snapshot); err == nil || !strings.Contains(err.Error(), "failed to find snapshot") {
+		t.Errorf("expected an error for the missing snapshot, but got: %v", err)
+	}
+	if len(asgSvc.Terminated) != 0 {
+		t.Errorf("expected no etcd node to be replaced for the missing snapshot, but terminated: %v", asgSvc.Terminated)
+	}
+
+	if err := newRestorer(s3Svc, asgSvc).restore("s3://backups"); err == nil {
+		t.Errorf("expected an error for the snapshot not being an S3 object, but got none")
+	}
+}
+
+func TestEtcdRestorerTimeout(t *testing.T) {
+	s3Svc := &dummyEtcdRestoreS3Service{Objects: map[string]bool{"mybucket/mycluster/instances/1234/etcd-snapshots/restore/member-0.request": true}}
+	r := etcdRestorer{
+		s3Svc:  s3Svc,
+		bucket: "mybucket",
+		prefix: "mycluster/instances/1234/etcd-snapshots",
+	}
+	err := r.waitUntilMembersGetRestored()
+	if err == nil || !strings.Contains(err.Error(), "members 0 remaining") {
+		t.Errorf("expected a timeout waiting for member 0, but got: %v", err)
+	}
+}
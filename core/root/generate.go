@@ -26,6 +26,13 @@ func RenderStack(configPath string) error {
 		return err
 	}
 
+	layout := c.AssetLayout
+	for _, dir := range []string{layout.UserDataPath(), layout.StackTemplatesPath()} {
+		if err := ensureWritableDir(dir, 0755); err != nil {
+			return err
+		}
+	}
+
 	ignoredWords := []string{
 		"etcdadm",
 		"kubeconfig.tmpl",
@@ -43,14 +50,14 @@ func RenderStack(configPath string) error {
 		if err != nil {
 			return err
 		}
-		gen := filegen.File(path, content, 0644)
+		gen := filegen.File(layout.RelocatePath(path), content, 0644)
 		return filegen.Render(gen)
 	}); err != nil {
 		return err
 	}
 
 	if err := filegen.Render(
-		filegen.File("kubeconfig", kubeconfig, 0600),
+		filegen.File(layout.KubeconfigPath(), kubeconfig, 0600),
 	); err != nil {
 		return err
 	}
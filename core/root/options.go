@@ -1,11 +1,16 @@
 package root
 
-import "github.com/kubernetes-incubator/kube-aws/core/root/defaults"
+import (
+	"github.com/kubernetes-incubator/kube-aws/core/root/defaults"
+	"github.com/kubernetes-incubator/kube-aws/pkg/api"
+)
 
 type options struct {
 	AssetsDir                         string
 	ControllerTmplFile                string
 	WorkerTmplFile                    string
+	BottlerocketWorkerTmplFile        string
+	WindowsWorkerTmplFile             string
 	EtcdTmplFile                      string
 	RootStackTemplateTmplFile         string
 	ControlPlaneStackTemplateTmplFile string
@@ -21,6 +26,8 @@ func NewOptions(prettyPrint bool, skipWait bool) options {
 		AssetsDir:                         defaults.AssetsDir,
 		ControllerTmplFile:                defaults.ControllerTmplFile,
 		WorkerTmplFile:                    defaults.WorkerTmplFile,
+		BottlerocketWorkerTmplFile:        defaults.BottlerocketWorkerTmplFile,
+		WindowsWorkerTmplFile:             defaults.WindowsWorkerTmplFile,
 		EtcdTmplFile:                      defaults.EtcdTmplFile,
 		ControlPlaneStackTemplateTmplFile: defaults.ControlPlaneStackTemplateTmplFile,
 		NetworkStackTemplateTmplFile:      defaults.NetworkStackTemplateTmplFile,
@@ -31,3 +38,19 @@ func NewOptions(prettyPrint bool, skipWait bool) options {
 		PrettyPrint:                       prettyPrint,
 	}
 }
+
+// withAssetLayout relocates the credentials, the cloud-configs and the stack templates according to `assetLayout` in cluster.yaml
+func (o options) withAssetLayout(l api.AssetLayout) options {
+	o.AssetsDir = l.RelocatePath(o.AssetsDir)
+	o.ControllerTmplFile = l.RelocatePath(o.ControllerTmplFile)
+	o.WorkerTmplFile = l.RelocatePath(o.WorkerTmplFile)
+	o.BottlerocketWorkerTmplFile = l.RelocatePath(o.BottlerocketWorkerTmplFile)
+	o.WindowsWorkerTmplFile = l.RelocatePath(o.WindowsWorkerTmplFile)
+	o.EtcdTmplFile = l.RelocatePath(o.EtcdTmplFile)
+	o.RootStackTemplateTmplFile = l.RelocatePath(o.RootStackTemplateTmplFile)
+	o.ControlPlaneStackTemplateTmplFile = l.RelocatePath(o.ControlPlaneStackTemplateTmplFile)
+	o.NetworkStackTemplateTmplFile = l.RelocatePath(o.NetworkStackTemplateTmplFile)
+	o.EtcdStackTemplateTmplFile = l.RelocatePath(o.EtcdStackTemplateTmplFile)
+	o.NodePoolStackTemplateTmplFile = l.RelocatePath(o.NodePoolStackTemplateTmplFile)
+	return o
+}
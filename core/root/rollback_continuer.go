@@ -0,0 +1,45 @@
+package root
+
+import (
+	"fmt"
+
+	"github.com/kubernetes-incubator/kube-aws/awsconn"
+	"github.com/kubernetes-incubator/kube-aws/cfnstack"
+	"github.com/kubernetes-incubator/kube-aws/core/root/config"
+)
+
+type ContinueRollbackOptions struct {
+	AwsDebug bool
+	// ResourcesToSkip are the logical ids of the resources CloudFormation failed to roll back and should leave as-is.
+	// Resources in nested stacks are specified as `<nested stack logical id>.<resource logical id>`
+	ResourcesToSkip []string
+}
+
+type ClusterRollbackContinuer interface {
+	ContinueRollback() error
+}
+
+type clusterRollbackContinuerImpl struct {
+	underlying *cfnstack.RollbackContinuer
+}
+
+func ClusterRollbackContinuerFromFile(configPath string, opts ContinueRollbackOptions) (ClusterRollbackContinuer, error) {
+	cfg, err := config.ConfigFromFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := awsconn.NewSessionFromRegion(cfg.Region, opts.AwsDebug, cfg.AWSAPIRetry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to establish aws session: %v", err)
+	}
+
+	continuer := cfnstack.NewRollbackContinuer(cfg.RootStackName(), session, cfg.CloudFormation.RoleARN, opts.ResourcesToSkip)
+	return clusterRollbackContinuerImpl{
+		underlying: continuer,
+	}, nil
+}
+
+func (c clusterRollbackContinuerImpl) ContinueRollback() error {
+	return c.underlying.ContinueRollback()
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/kubernetes-incubator/kube-aws/logger"
 	"github.com/kubernetes-incubator/kube-aws/pkg/api"
+	"github.com/kubernetes-incubator/kube-aws/pkg/model"
 )
 
 // returns NodePoolRolling strategy string to be used in stack-template
@@ -19,12 +20,20 @@ func (p nodePool) NodePoolRollingStrategy() string {
 // Only nodepools containing a single AZ can use this strategy!
 // Returns a comma separated quoted list, e.g. "pool1","pool2","pool3"
 func (c Cluster) nodePoolAvailabilityZoneDependencies(pool nodePool, subnets api.Subnets) (string, error) {
-	poolConfig := pool.nodePool.NodePoolConfig
+	names, err := c.nodePoolAvailabilityZoneDependencyNames(pool.nodePool.NodePoolConfig)
+	if err != nil || len(names) == 0 {
+		return "", err
+	}
+	return `"` + strings.Join(names, `","`) + `"`, nil
+}
 
+// nodePoolAvailabilityZoneDependencyNames returns the logical names of the nodepools the nodepool depends upon
+// when using the 'AvailabilityZone' NodePoolRollingStrategy
+func (c Cluster) nodePoolAvailabilityZoneDependencyNames(poolConfig *model.NodePoolConfig) ([]string, error) {
 	var order []string
 	order, err := c.azOrder()
 	if err != nil {
-		return "", fmt.Errorf("can't resolve nodepool availability zone ordering dependencies for %s: %v", poolConfig.NodePoolName, err)
+		return nil, fmt.Errorf("can't resolve nodepool availability zone ordering dependencies for %s: %v", poolConfig.NodePoolName, err)
 	}
 	logger.Debugf("AZ Rollout order: %v", order)
 
@@ -32,15 +41,15 @@ func (c Cluster) nodePoolAvailabilityZoneDependencies(pool nodePool, subnets api
 	position, err := azPosition(order, poolConfig.Subnets[0].AvailabilityZone)
 	if err != nil {
 		logger.Debugf("There was the following error looking up nodepool azPosition: %v", err)
-		return "", err
+		return nil, err
 	}
 	if position == 0 {
 		// a nodePool with position 0 doesn't have any other nodepool dependencies
 		logger.Debugf("The AZ for nodepool %s is first in the list, so it does not have any dependencies", poolConfig.NodePoolName)
-		return "", nil
+		return nil, nil
 	}
 
-	return `"` + strings.Join(c.allNodePoolLogicalNamesinAZ(order[position-1]), `","`) + `"`, nil
+	return c.allNodePoolLogicalNamesinAZ(order[position-1]), nil
 }
 
 // rolloutAZOrder works out an order for availability zones from the order of the nodepool stacks were loaded from the cluster.yaml.
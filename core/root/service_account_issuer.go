@@ -0,0 +1,88 @@
+package root
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/kubernetes-incubator/kube-aws/credential"
+	"github.com/kubernetes-incubator/kube-aws/logger"
+	"github.com/kubernetes-incubator/kube-aws/pkg/api"
+)
+
+// publishServiceAccountIssuerDiscovery uploads the OIDC discovery documents of the issuer of service account tokens to
+// `controller.apiServer.serviceAccountIssuer.discovery.s3Bucket`, so that the IAM OIDC provider can verify the tokens via the issuer URL
+func (cl *Cluster) publishServiceAccountIssuerDiscovery() error {
+	issuer := cl.Cfg.Controller.APIServer.ServiceAccountIssuer
+	if !issuer.DiscoveryEnabled() {
+		return nil
+	}
+
+	key, err := cl.readServiceAccountKey()
+	if err != nil {
+		return err
+	}
+
+	openIDConfiguration, err := issuer.OpenIDConfiguration()
+	if err != nil {
+		return fmt.Errorf("failed to generate the OpenID configuration: %v", err)
+	}
+	jwks, err := issuer.JWKS(key.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to generate the JWKS: %v", err)
+	}
+
+	s3Svc := s3.New(cl.s3Session())
+	docs := []struct {
+		path    string
+		content string
+	}{
+		{path: api.OpenIDConfigurationPath, content: openIDConfiguration},
+		{path: api.JWKSPath, content: jwks},
+	}
+	for _, d := range docs {
+		objectKey, err := issuer.DiscoveryObjectKey(d.path)
+		if err != nil {
+			return err
+		}
+		logger.Infof("publishing /%s of the service account issuer to s3://%s/%s", d.path, issuer.Discovery.S3Bucket, objectKey)
+		_, err = s3Svc.PutObject(&s3.PutObjectInput{
+			Bucket:        aws.String(issuer.Discovery.S3Bucket),
+			Key:           aws.String(objectKey),
+			Body:          strings.NewReader(d.content),
+			ContentLength: aws.Int64(int64(len(d.content))),
+			ContentType:   aws.String("application/json"),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to publish /%s of the service account issuer: %v", d.path, err)
+		}
+	}
+	return nil
+}
+
+// validateServiceAccountKey ensures that the service account key, which defaults to the apiserver key, can sign the bound service account tokens
+// before kube-aws rolls out the apiserver issuing the tokens
+func (cl *Cluster) validateServiceAccountKey() error {
+	if !cl.Cfg.Controller.ServiceAccount.BoundTokens {
+		return nil
+	}
+	key, err := cl.readServiceAccountKey()
+	if err != nil {
+		return err
+	}
+	if _, err := cl.Cfg.Controller.APIServer.ServiceAccountIssuer.JWKS(key.Bytes()); err != nil {
+		return fmt.Errorf("the service account key can't sign the bound service account tokens, please regenerate or resolve: %v", err)
+	}
+	return nil
+}
+
+func (cl *Cluster) readServiceAccountKey() (*credential.PlaintextFile, error) {
+	defaultKey := "<<<" + filepath.Join(cl.opts.AssetsDir, "apiserver-key.pem")
+	key, err := credential.RawCredentialFileFromPath(filepath.Join(cl.opts.AssetsDir, "service-account-key.pem"), &defaultKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the service account key: %v", err)
+	}
+	return key, nil
+}
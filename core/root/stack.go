@@ -7,11 +7,25 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/kubernetes-incubator/kube-aws/gzipcompressor"
 	"github.com/kubernetes-incubator/kube-aws/pkg/model"
 	"io/ioutil"
+	"regexp"
 	"strings"
 )
 
+// compressedInstanceScriptPattern matches the instance script embedded into the user-data with `instanceScript.compression: gzip`
+var compressedInstanceScriptPattern = regexp.MustCompile(`echo '([A-Za-z0-9+/=]+)' \| base64 -d \| gunzip`)
+
+// decompressInstanceScript returns the instance script in the user-data decompressed, or the user-data as-is when it isn't compressed
+func decompressInstanceScript(userdata string) (string, error) {
+	m := compressedInstanceScriptPattern.FindStringSubmatch(userdata)
+	if m == nil {
+		return userdata, nil
+	}
+	return gzipcompressor.GzippedBase64StringToString(m[1])
+}
+
 func getStackTemplate(cfnSvc model.StackTemplateGetter, stackName string) (string, error) {
 	byRootStackName := &cloudformation.GetTemplateInput{StackName: aws.String(stackName)}
 	output, err := cfnSvc.GetTemplate(byRootStackName)
@@ -48,7 +62,7 @@ func getInstanceScriptUserdata(stackJson string, nestedStackLogicalName string)
 	fnJoin := fnBase64["Fn::Join"].([]interface{})
 	joinedItems := fnJoin[1].([]interface{})
 	instanceScript := joinedItems[3].(string)
-	return instanceScript, nil
+	return decompressInstanceScript(instanceScript)
 }
 
 func getInstanceUserdataJson(stackJson string, nestedStackLogicalName string) (string, error) {
@@ -75,6 +89,10 @@ func getInstanceUserdataJson(stackJson string, nestedStackLogicalName string) (s
 }
 
 func getS3Userdata(s3Svc *s3.S3, instanceUserdata string) (string, error) {
+	instanceUserdata, err := decompressInstanceScript(instanceUserdata)
+	if err != nil {
+		return "", err
+	}
 	a := strings.Split(instanceUserdata, " cp ")
 	b := strings.Split(a[1], " ")[0]
 	s3uri := b
@@ -0,0 +1,215 @@
+package root
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// loadStackDependencies resolves `cloudformation.stackDependencies` into the logical names of the resources of the root stack
+// each nested stack additionally depends on, and ensures that they don't form a dependency cycle with the dependencies kube-aws
+// puts among the nested stacks and the ones of the resources added by plugins
+func (cl *Cluster) loadStackDependencies() error {
+	deps := cl.Cfg.CloudFormation.StackDependencies
+	if len(deps) == 0 {
+		return nil
+	}
+
+	stacks := map[string]string{
+		cl.controlPlaneStack.Config.ControlPlaneStackName(): cl.controlPlaneStack.NestedStackName(),
+		cl.etcdStack.Config.EtcdStackName():                 cl.etcdStack.NestedStackName(),
+		cl.networkStack.Config.NetworkStackName():           cl.networkStack.NestedStackName(),
+	}
+	for _, p := range cl.nodePoolStacks {
+		stacks[p.NodePoolConfig.NodePoolName] = p.NestedStackName()
+	}
+
+	graph, err := cl.implicitDependencyGraph()
+	if err != nil {
+		return err
+	}
+
+	extra := map[string][]string{}
+	for i, d := range deps {
+		dependent, ok := stacks[d.Stack]
+		if !ok {
+			return fmt.Errorf("invalid `cloudformation.stackDependencies[%d]`: unknown stack \"%s\": it must be one of %s", i, d.Stack, strings.Join(sortedKeys(stacks), ", "))
+		}
+		for _, n := range d.DependsOn {
+			dependency, isStack := stacks[n]
+			if !isStack {
+				if _, isResource := cl.ExtraCfnResources[n]; !isResource {
+					return fmt.Errorf("invalid `cloudformation.stackDependencies[%d]`: \"%s\" is neither a stack nor a resource added to the root stack by plugins", i, n)
+				}
+				dependency = n
+			}
+			if containsName(graph[dependent], dependency) {
+				continue
+			}
+			graph[dependent] = append(graph[dependent], dependency)
+			extra[dependent] = append(extra[dependent], dependency)
+		}
+	}
+
+	if cycle := findDependencyCycle(graph); len(cycle) > 0 {
+		return fmt.Errorf("`cloudformation.stackDependencies` form a dependency cycle: %s", strings.Join(cycle, " -> "))
+	}
+
+	cl.stackDependencies = extra
+
+	return nil
+}
+
+// implicitDependencyGraph returns the logical names of the resources each resource of the root stack depends on without
+// `cloudformation.stackDependencies`
+func (cl *Cluster) implicitDependencyGraph() (map[string][]string, error) {
+	network := cl.networkStack.NestedStackName()
+	etcd := cl.etcdStack.NestedStackName()
+	controlPlane := cl.controlPlaneStack.NestedStackName()
+
+	graph := map[string][]string{
+		network:      {},
+		etcd:         {network},
+		controlPlane: {etcd, network},
+	}
+	for i, p := range cl.nodePoolStacks {
+		name := p.NestedStackName()
+		graph[name] = []string{controlPlane, etcd, network}
+		switch p.NodePoolConfig.NodePoolRollingStrategy {
+		case "Sequential":
+			if i > 0 {
+				graph[name] = append(graph[name], cl.nodePoolStacks[i-1].NestedStackName())
+			}
+		case "AvailabilityZone":
+			names, err := cl.nodePoolAvailabilityZoneDependencyNames(p.NodePoolConfig)
+			if err != nil {
+				return nil, err
+			}
+			graph[name] = append(graph[name], names...)
+		}
+	}
+	for n, r := range cl.ExtraCfnResources {
+		graph[n] = cfnResourceReferences(r)
+	}
+	return graph, nil
+}
+
+// cfnResourceReferences returns the logical names of the resources the resource refers to with `DependsOn`, `Ref` and `Fn::GetAtt`
+func cfnResourceReferences(resource interface{}) []string {
+	refs := []string{}
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			for k, e := range t {
+				switch k {
+				case "DependsOn":
+					switch d := e.(type) {
+					case string:
+						refs = append(refs, d)
+					case []interface{}:
+						for _, n := range d {
+							if s, ok := n.(string); ok {
+								refs = append(refs, s)
+							}
+						}
+					}
+				case "Ref":
+					if s, ok := e.(string); ok {
+						refs = append(refs, s)
+					}
+				case "Fn::GetAtt":
+					if a, ok := e.([]interface{}); ok && len(a) > 0 {
+						if s, ok := a[0].(string); ok {
+							refs = append(refs, s)
+						}
+					}
+				default:
+					walk(e)
+				}
+			}
+		case map[interface{}]interface{}:
+			m := map[string]interface{}{}
+			for k, e := range t {
+				m[fmt.Sprintf("%v", k)] = e
+			}
+			walk(m)
+		case []interface{}:
+			for _, e := range t {
+				walk(e)
+			}
+		}
+	}
+	walk(resource)
+	return refs
+}
+
+// findDependencyCycle returns the names forming a dependency cycle like [a b a], or nil when the graph is acyclic.
+// Names not in the graph like parameters and pseudo parameters are ignored
+func findDependencyCycle(graph map[string][]string) []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	states := map[string]int{}
+	path := []string{}
+
+	var visit func(n string) []string
+	visit = func(n string) []string {
+		states[n] = visiting
+		path = append(path, n)
+		for _, d := range graph[n] {
+			if _, ok := graph[d]; !ok {
+				continue
+			}
+			switch states[d] {
+			case visiting:
+				for i, p := range path {
+					if p == d {
+						return append(append([]string{}, path[i:]...), d)
+					}
+				}
+			case unvisited:
+				if cycle := visit(d); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		states[n] = visited
+		return nil
+	}
+
+	names := []string{}
+	for n := range graph {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		if states[n] == unvisited {
+			if cycle := visit(n); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package root
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindDependencyCycle(t *testing.T) {
+	testCases := []struct {
+		graph    map[string][]string
+		expected []string
+	}{
+		// Acyclic, with a reference to a parameter not in the graph
+		{
+			graph: map[string][]string{
+				"Network":      {},
+				"Etcd":         {"Network"},
+				"Controlplane": {"Etcd", "Network", "AWS::Region"},
+				"Pool1":        {"Controlplane", "Pool2"},
+				"Pool2":        {"Controlplane"},
+			},
+			expected: nil,
+		},
+		// A cycle among node pools
+		{
+			graph: map[string][]string{
+				"Controlplane": {},
+				"Pool1":        {"Controlplane", "Pool2"},
+				"Pool2":        {"Controlplane", "Pool1"},
+			},
+			expected: []string{"Pool1", "Pool2", "Pool1"},
+		},
+		// A cycle through a resource added by a plugin
+		{
+			graph: map[string][]string{
+				"Network":      {"PluginBucket"},
+				"PluginBucket": {"Pool1"},
+				"Pool1":        {"Network"},
+			},
+			expected: []string{"Network", "PluginBucket", "Pool1", "Network"},
+		},
+	}
+
+	for i, testCase := range testCases {
+		if actual := findDependencyCycle(testCase.graph); !reflect.DeepEqual(testCase.expected, actual) {
+			t.Errorf("case %d: expected cycle %v, but was %v", i, testCase.expected, actual)
+		}
+	}
+}
+
+func TestCfnResourceReferences(t *testing.T) {
+	resource := map[string]interface{}{
+		"Type":      "AWS::S3::Bucket",
+		"DependsOn": []interface{}{"Network"},
+		"Properties": map[string]interface{}{
+			"BucketName": map[string]interface{}{
+				"Fn::Join": []interface{}{"-", []interface{}{
+					map[string]interface{}{"Ref": "AWS::StackName"},
+					map[string]interface{}{"Fn::GetAtt": []interface{}{"Controlplane", "Outputs.StackName"}},
+				}},
+			},
+		},
+	}
+	expected := []string{"Network", "AWS::StackName", "Controlplane"}
+	actual := cfnResourceReferences(resource)
+	if len(actual) != len(expected) {
+		t.Fatalf("unexpected references: expected %v, but was %v", expected, actual)
+	}
+	for _, e := range expected {
+		if !containsName(actual, e) {
+			t.Errorf("expected references %v to contain %s", actual, e)
+		}
+	}
+}
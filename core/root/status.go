@@ -0,0 +1,528 @@
+package root
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/kubernetes-incubator/kube-aws/pkg/api"
+)
+
+// etcdHealthCheckTimeout is how long `kube-aws status` waits for each etcd member to respond.
+// etcd members are usually in private subnets, which may not be reachable from where kube-aws runs
+const etcdHealthCheckTimeout = 5 * time.Second
+
+// ClusterStatus is the live state of the stacks and the nodes of the cluster, reported by `kube-aws status`
+type ClusterStatus struct {
+	Name              string                   `json:"name"`
+	Stacks            []StackStatus            `json:"stacks"`
+	AutoScalingGroups []AutoScalingGroupStatus `json:"autoScalingGroups"`
+	LoadBalancers     []LoadBalancerStatus     `json:"loadBalancers"`
+	EtcdMembers       []EtcdMemberStatus       `json:"etcdMembers"`
+}
+
+type StackStatus struct {
+	// Name is the logical name of the nested stack in the root stack, or the name of the root stack
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type AutoScalingGroupStatus struct {
+	Name        string `json:"name"`
+	Stack       string `json:"stack"`
+	Desired     int    `json:"desired"`
+	Min         int    `json:"min"`
+	Max         int    `json:"max"`
+	InService   int    `json:"inService"`
+	Pending     int    `json:"pending"`
+	Terminating int    `json:"terminating"`
+	// Outdated is the number of instances launched from a launch configuration or a launch template version other than
+	// the current one of the group, which are yet to be replaced by the rolling update
+	Outdated int `json:"outdated"`
+}
+
+type LoadBalancerStatus struct {
+	Name    string `json:"name"`
+	DNSName string `json:"dnsName"`
+	Healthy int    `json:"healthy"`
+	Total   int    `json:"total"`
+}
+
+type EtcdMemberStatus struct {
+	InstanceID string `json:"instanceId"`
+	Endpoint   string `json:"endpoint"`
+	Healthy    bool   `json:"healthy"`
+	Error      string `json:"error,omitempty"`
+}
+
+// OutdatedInstances returns the number of instances yet to be replaced and the number of all the instances in the cluster
+func (s *ClusterStatus) OutdatedInstances() (int, int) {
+	outdated, total := 0, 0
+	for _, g := range s.AutoScalingGroups {
+		outdated += g.Outdated
+		total += g.InService + g.Pending + g.Terminating
+	}
+	return outdated, total
+}
+
+func (s *ClusterStatus) JSON() (string, error) {
+	bytes, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal cluster status: %v", err)
+	}
+	return string(bytes), nil
+}
+
+func (s *ClusterStatus) String() string {
+	buf := new(bytes.Buffer)
+	w := new(tabwriter.Writer)
+	w.Init(buf, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintf(w, "Cluster Name:\t%s\n", s.Name)
+	if outdated, total := s.OutdatedInstances(); outdated > 0 {
+		fmt.Fprintf(w, "Rolling Update:\t%d of %d instances are yet to be replaced\n", outdated, total)
+	}
+
+	fmt.Fprintf(w, "\nSTACK\tSTATUS\tREASON\n")
+	for _, st := range s.Stacks {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", st.Name, st.Status, st.Reason)
+	}
+
+	fmt.Fprintf(w, "\nAUTO SCALING GROUP\tSTACK\tDESIRED\tMIN\tMAX\tIN SERVICE\tPENDING\tTERMINATING\tOUTDATED\n")
+	for _, g := range s.AutoScalingGroups {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\n", g.Name, g.Stack, g.Desired, g.Min, g.Max, g.InService, g.Pending, g.Terminating, g.Outdated)
+	}
+
+	fmt.Fprintf(w, "\nLOAD BALANCER\tDNS NAME\tHEALTHY\n")
+	for _, lb := range s.LoadBalancers {
+		fmt.Fprintf(w, "%s\t%s\t%d/%d\n", lb.Name, lb.DNSName, lb.Healthy, lb.Total)
+	}
+
+	fmt.Fprintf(w, "\nETCD MEMBER\tENDPOINT\tHEALTH\n")
+	for _, m := range s.EtcdMembers {
+		health := "healthy"
+		if !m.Healthy {
+			health = "unhealthy: " + m.Error
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", m.InstanceID, m.Endpoint, health)
+	}
+
+	w.Flush()
+	return buf.String()
+}
+
+type statusCFNService interface {
+	DescribeStacks(*cloudformation.DescribeStacksInput) (*cloudformation.DescribeStacksOutput, error)
+	ListStackResources(*cloudformation.ListStackResourcesInput) (*cloudformation.ListStackResourcesOutput, error)
+}
+
+type statusASGService interface {
+	DescribeAutoScalingGroups(*autoscaling.DescribeAutoScalingGroupsInput) (*autoscaling.DescribeAutoScalingGroupsOutput, error)
+}
+
+type statusELBService interface {
+	DescribeLoadBalancers(*elb.DescribeLoadBalancersInput) (*elb.DescribeLoadBalancersOutput, error)
+	DescribeInstanceHealth(*elb.DescribeInstanceHealthInput) (*elb.DescribeInstanceHealthOutput, error)
+}
+
+type statusELBV2Service interface {
+	DescribeLoadBalancers(*elbv2.DescribeLoadBalancersInput) (*elbv2.DescribeLoadBalancersOutput, error)
+	DescribeTargetGroups(*elbv2.DescribeTargetGroupsInput) (*elbv2.DescribeTargetGroupsOutput, error)
+	DescribeTargetHealth(*elbv2.DescribeTargetHealthInput) (*elbv2.DescribeTargetHealthOutput, error)
+}
+
+type statusEC2Service interface {
+	DescribeInstances(*ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error)
+}
+
+// clusterStatusCollector collects the status of the cluster only by reading the live stacks and the resources in them,
+// so that the status is available even while a stack is being updated
+type clusterStatusCollector struct {
+	cfnSvc   statusCFNService
+	asgSvc   statusASGService
+	elbSvc   statusELBService
+	elbv2Svc statusELBV2Service
+	ec2Svc   statusEC2Service
+
+	clusterName string
+	stackName   string
+	// etcdStackName is the logical name of the etcd stack in the root stack
+	etcdStackName string
+	// etcdPublicEndpoints is true when etcd members are identified by their EIPs
+	etcdPublicEndpoints bool
+	// checkEtcdHealth returns an error when the etcd member at the host is unhealthy
+	checkEtcdHealth func(host string) error
+}
+
+// Status reports the states of the stacks, the auto scaling groups, the load balancers of the API endpoints and the etcd members
+func (cl *Cluster) Status() (*ClusterStatus, error) {
+	if err := cl.ensureNestedStacksLoaded(); err != nil {
+		return nil, err
+	}
+
+	etcdCluster := cl.etcdStack.Config.EtcdCluster()
+	c := clusterStatusCollector{
+		cfnSvc:              cloudformation.New(cl.session),
+		asgSvc:              autoscaling.New(cl.session),
+		elbSvc:              elb.New(cl.session),
+		elbv2Svc:            elbv2.New(cl.session),
+		ec2Svc:              ec2.New(cl.session),
+		clusterName:         cl.controlPlaneStack.ClusterName,
+		stackName:           cl.stackName(),
+		etcdStackName:       cl.etcdStack.NestedStackName(),
+		etcdPublicEndpoints: etcdCluster.GetMemberIdentityProvider() == api.MemberIdentityProviderEIP,
+		checkEtcdHealth:     newEtcdHealthChecker(cl.opts.AssetsDir, etcdCluster.DNSNames()),
+	}
+	return c.collect()
+}
+
+func (c clusterStatusCollector) collect() (*ClusterStatus, error) {
+	status := &ClusterStatus{
+		Name:              c.clusterName,
+		Stacks:            []StackStatus{},
+		AutoScalingGroups: []AutoScalingGroupStatus{},
+		LoadBalancers:     []LoadBalancerStatus{},
+		EtcdMembers:       []EtcdMemberStatus{},
+	}
+
+	root, err := c.describeStack(c.stackName, c.stackName)
+	if err != nil {
+		return nil, err
+	}
+	status.Stacks = append(status.Stacks, root)
+
+	rootResources, err := c.listStackResources(c.stackName)
+	if err != nil {
+		return nil, err
+	}
+	for _, nested := range rootResources {
+		if aws.StringValue(nested.ResourceType) != "AWS::CloudFormation::Stack" || nested.PhysicalResourceId == nil {
+			continue
+		}
+		name := aws.StringValue(nested.LogicalResourceId)
+		st, err := c.describeStack(name, aws.StringValue(nested.PhysicalResourceId))
+		if err != nil {
+			return nil, err
+		}
+		status.Stacks = append(status.Stacks, st)
+
+		resources, err := c.listStackResources(aws.StringValue(nested.PhysicalResourceId))
+		if err != nil {
+			return nil, err
+		}
+		asgNames := []string{}
+		for _, r := range resources {
+			if r.PhysicalResourceId == nil {
+				continue
+			}
+			switch aws.StringValue(r.ResourceType) {
+			case "AWS::AutoScaling::AutoScalingGroup":
+				asgNames = append(asgNames, aws.StringValue(r.PhysicalResourceId))
+			case "AWS::ElasticLoadBalancing::LoadBalancer":
+				lb, err := c.classicLoadBalancerStatus(aws.StringValue(r.PhysicalResourceId))
+				if err != nil {
+					return nil, err
+				}
+				status.LoadBalancers = append(status.LoadBalancers, *lb)
+			case "AWS::ElasticLoadBalancingV2::LoadBalancer":
+				lb, err := c.loadBalancerV2Status(aws.StringValue(r.PhysicalResourceId))
+				if err != nil {
+					return nil, err
+				}
+				status.LoadBalancers = append(status.LoadBalancers, *lb)
+			}
+		}
+		if len(asgNames) == 0 {
+			continue
+		}
+
+		groups, err := c.describeAutoScalingGroups(asgNames)
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range groups {
+			status.AutoScalingGroups = append(status.AutoScalingGroups, autoScalingGroupStatus(name, g))
+		}
+		if name == c.etcdStackName {
+			members, err := c.etcdMemberStatuses(groups)
+			if err != nil {
+				return nil, err
+			}
+			status.EtcdMembers = members
+		}
+	}
+
+	return status, nil
+}
+
+func (c clusterStatusCollector) describeStack(name, stackID string) (StackStatus, error) {
+	resp, err := c.cfnSvc.DescribeStacks(&cloudformation.DescribeStacksInput{StackName: aws.String(stackID)})
+	if err != nil {
+		return StackStatus{}, fmt.Errorf("failed to describe stack %s: %v", name, err)
+	}
+	if len(resp.Stacks) == 0 {
+		return StackStatus{}, fmt.Errorf("could not find a stack with name %s", stackID)
+	}
+	s := resp.Stacks[0]
+	return StackStatus{
+		Name:   name,
+		Status: aws.StringValue(s.StackStatus),
+		Reason: aws.StringValue(s.StackStatusReason),
+	}, nil
+}
+
+func (c clusterStatusCollector) listStackResources(stackID string) ([]*cloudformation.StackResourceSummary, error) {
+	resources := []*cloudformation.StackResourceSummary{}
+	input := &cloudformation.ListStackResourcesInput{StackName: aws.String(stackID)}
+	for {
+		resp, err := c.cfnSvc.ListStackResources(input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list resources of stack %s: %v", stackID, err)
+		}
+		resources = append(resources, resp.StackResourceSummaries...)
+		if resp.NextToken == nil {
+			return resources, nil
+		}
+		input.NextToken = resp.NextToken
+	}
+}
+
+func (c clusterStatusCollector) describeAutoScalingGroups(names []string) ([]*autoscaling.Group, error) {
+	groups := []*autoscaling.Group{}
+	input := &autoscaling.DescribeAutoScalingGroupsInput{AutoScalingGroupNames: aws.StringSlice(names)}
+	for {
+		resp, err := c.asgSvc.DescribeAutoScalingGroups(input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe auto scaling groups %v: %v", names, err)
+		}
+		groups = append(groups, resp.AutoScalingGroups...)
+		if resp.NextToken == nil {
+			break
+		}
+		input.NextToken = resp.NextToken
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return aws.StringValue(groups[i].AutoScalingGroupName) < aws.StringValue(groups[j].AutoScalingGroupName)
+	})
+	return groups, nil
+}
+
+func autoScalingGroupStatus(stack string, g *autoscaling.Group) AutoScalingGroupStatus {
+	s := AutoScalingGroupStatus{
+		Name:    aws.StringValue(g.AutoScalingGroupName),
+		Stack:   stack,
+		Desired: int(aws.Int64Value(g.DesiredCapacity)),
+		Min:     int(aws.Int64Value(g.MinSize)),
+		Max:     int(aws.Int64Value(g.MaxSize)),
+	}
+
+	for _, i := range g.Instances {
+		state := aws.StringValue(i.LifecycleState)
+		switch {
+		case state == autoscaling.LifecycleStateInService:
+			s.InService++
+		case strings.HasPrefix(state, "Pending"):
+			s.Pending++
+		case strings.HasPrefix(state, "Terminating"):
+			s.Terminating++
+			// A terminating instance is being replaced already
+			continue
+		}
+		if instanceOutdated(g, i) {
+			s.Outdated++
+		}
+	}
+	return s
+}
+
+// instanceOutdated returns true when the instance is launched from other than the current launch configuration or launch template version of the group.
+// The version can't be compared when the group refers to the latest or the default version, or when the launch template is in
+// the mixed instances policy of the group, in which case the instance is never considered outdated
+func instanceOutdated(g *autoscaling.Group, i *autoscaling.Instance) bool {
+	if g.LaunchConfigurationName != nil {
+		return aws.StringValue(i.LaunchConfigurationName) != aws.StringValue(g.LaunchConfigurationName)
+	}
+	if g.LaunchTemplate == nil {
+		return false
+	}
+	if _, err := strconv.Atoi(aws.StringValue(g.LaunchTemplate.Version)); err != nil {
+		return false
+	}
+	return i.LaunchTemplate == nil || aws.StringValue(i.LaunchTemplate.Version) != aws.StringValue(g.LaunchTemplate.Version)
+}
+
+func (c clusterStatusCollector) classicLoadBalancerStatus(name string) (*LoadBalancerStatus, error) {
+	resp, err := c.elbSvc.DescribeLoadBalancers(&elb.DescribeLoadBalancersInput{LoadBalancerNames: aws.StringSlice([]string{name})})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe load balancer %s: %v", name, err)
+	}
+	if len(resp.LoadBalancerDescriptions) == 0 {
+		return nil, fmt.Errorf("could not find load balancer with name %s", name)
+	}
+	health, err := c.elbSvc.DescribeInstanceHealth(&elb.DescribeInstanceHealthInput{LoadBalancerName: aws.String(name)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe the health of instances behind load balancer %s: %v", name, err)
+	}
+	s := &LoadBalancerStatus{
+		Name:    name,
+		DNSName: aws.StringValue(resp.LoadBalancerDescriptions[0].DNSName),
+		Total:   len(health.InstanceStates),
+	}
+	for _, i := range health.InstanceStates {
+		if aws.StringValue(i.State) == "InService" {
+			s.Healthy++
+		}
+	}
+	return s, nil
+}
+
+func (c clusterStatusCollector) loadBalancerV2Status(arn string) (*LoadBalancerStatus, error) {
+	resp, err := c.elbv2Svc.DescribeLoadBalancers(&elbv2.DescribeLoadBalancersInput{LoadBalancerArns: aws.StringSlice([]string{arn})})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe load balancer %s: %v", arn, err)
+	}
+	if len(resp.LoadBalancers) == 0 {
+		return nil, fmt.Errorf("could not find load balancer with arn %s", arn)
+	}
+	s := &LoadBalancerStatus{
+		Name:    aws.StringValue(resp.LoadBalancers[0].LoadBalancerName),
+		DNSName: aws.StringValue(resp.LoadBalancers[0].DNSName),
+	}
+
+	tgs, err := c.elbv2Svc.DescribeTargetGroups(&elbv2.DescribeTargetGroupsInput{LoadBalancerArn: aws.String(arn)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe target groups of load balancer %s: %v", s.Name, err)
+	}
+	for _, tg := range tgs.TargetGroups {
+		health, err := c.elbv2Svc.DescribeTargetHealth(&elbv2.DescribeTargetHealthInput{TargetGroupArn: tg.TargetGroupArn})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe the health of targets in %s: %v", aws.StringValue(tg.TargetGroupName), err)
+		}
+		for _, t := range health.TargetHealthDescriptions {
+			s.Total++
+			if t.TargetHealth != nil && aws.StringValue(t.TargetHealth.State) == elbv2.TargetHealthStateEnumHealthy {
+				s.Healthy++
+			}
+		}
+	}
+	return s, nil
+}
+
+func (c clusterStatusCollector) etcdMemberStatuses(groups []*autoscaling.Group) ([]EtcdMemberStatus, error) {
+	ids := []string{}
+	for _, g := range groups {
+		for _, i := range g.Instances {
+			ids = append(ids, aws.StringValue(i.InstanceId))
+		}
+	}
+	if len(ids) == 0 {
+		return []EtcdMemberStatus{}, nil
+	}
+
+	resp, err := c.ec2Svc.DescribeInstances(&ec2.DescribeInstancesInput{InstanceIds: aws.StringSlice(ids)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe etcd nodes: %v", err)
+	}
+	members := []EtcdMemberStatus{}
+	for _, r := range resp.Reservations {
+		for _, i := range r.Instances {
+			host := aws.StringValue(i.PrivateIpAddress)
+			if c.etcdPublicEndpoints {
+				host = aws.StringValue(i.PublicIpAddress)
+			}
+			m := EtcdMemberStatus{
+				InstanceID: aws.StringValue(i.InstanceId),
+				Endpoint:   fmt.Sprintf("https://%s", net.JoinHostPort(host, strconv.Itoa(api.EtcdClientPort))),
+			}
+			if host == "" {
+				m.Error = "no endpoint"
+			} else if err := c.checkEtcdHealth(host); err != nil {
+				m.Error = err.Error()
+			} else {
+				m.Healthy = true
+			}
+			members = append(members, m)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].InstanceID < members[j].InstanceID })
+	return members, nil
+}
+
+// newEtcdHealthChecker returns the function querying the health endpoint of an etcd member with the etcd client certificate in the assets dir.
+// etcd members are connected by their IP addresses and verified against the wildcard DNS name in the etcd server certificate
+func newEtcdHealthChecker(assetsDir string, etcdDNSNames []string) func(host string) error {
+	return func(host string) error {
+		tlsConfig, err := etcdClientTLSConfig(assetsDir)
+		if err != nil {
+			return err
+		}
+		if len(etcdDNSNames) > 0 && etcdDNSNames[0] != "" {
+			tlsConfig.ServerName = strings.Replace(etcdDNSNames[0], "*", "etcd", 1)
+		}
+		client := &http.Client{
+			Timeout:   etcdHealthCheckTimeout,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		}
+
+		resp, err := client.Get(fmt.Sprintf("https://%s/health", net.JoinHostPort(host, strconv.Itoa(api.EtcdClientPort))))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		var health struct {
+			Health string `json:"health"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+			return fmt.Errorf("unexpected response with status %s: %v", resp.Status, err)
+		}
+		if health.Health != "true" {
+			return fmt.Errorf("health is %s", health.Health)
+		}
+		return nil
+	}
+}
+
+func etcdClientTLSConfig(assetsDir string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(filepath.Join(assetsDir, "etcd-client.pem"), filepath.Join(assetsDir, "etcd-client-key.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load etcd client certificate: %v", err)
+	}
+
+	caPath := filepath.Join(assetsDir, "etcd-trusted-ca.pem")
+	if _, err := os.Stat(caPath); os.IsNotExist(err) {
+		caPath = filepath.Join(assetsDir, "ca.pem")
+	}
+	ca, err := ioutil.ReadFile(caPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read etcd trusted ca: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificate found in %s", caPath)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+	}, nil
+}
@@ -0,0 +1,279 @@
+package root
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+)
+
+type dummyStatusCFNService struct {
+	Statuses map[string]string
+	// Resources are the types of the resources keyed by the physical ids, in each stack
+	Resources map[string]map[string][]string
+}
+
+func (s dummyStatusCFNService) DescribeStacks(input *cloudformation.DescribeStacksInput) (*cloudformation.DescribeStacksOutput, error) {
+	status, ok := s.Statuses[*input.StackName]
+	if !ok {
+		return nil, errors.New("stack not found")
+	}
+	return &cloudformation.DescribeStacksOutput{
+		Stacks: []*cloudformation.Stack{{StackName: input.StackName, StackStatus: aws.String(status)}},
+	}, nil
+}
+
+func (s dummyStatusCFNService) ListStackResources(input *cloudformation.ListStackResourcesInput) (*cloudformation.ListStackResourcesOutput, error) {
+	out := &cloudformation.ListStackResourcesOutput{}
+	for resourceType, ids := range s.Resources[*input.StackName] {
+		for _, id := range ids {
+			logicalID := id
+			if resourceType == "AWS::CloudFormation::Stack" {
+				logicalID = strings.Split(id, "/")[1]
+			}
+			out.StackResourceSummaries = append(out.StackResourceSummaries, &cloudformation.StackResourceSummary{
+				LogicalResourceId:  aws.String(logicalID),
+				PhysicalResourceId: aws.String(id),
+				ResourceType:       aws.String(resourceType),
+			})
+		}
+	}
+	return out, nil
+}
+
+type dummyStatusASGService struct {
+	Groups map[string]*autoscaling.Group
+}
+
+func (s dummyStatusASGService) DescribeAutoScalingGroups(input *autoscaling.DescribeAutoScalingGroupsInput) (*autoscaling.DescribeAutoScalingGroupsOutput, error) {
+	out := &autoscaling.DescribeAutoScalingGroupsOutput{}
+	for _, n := range input.AutoScalingGroupNames {
+		out.AutoScalingGroups = append(out.AutoScalingGroups, s.Groups[*n])
+	}
+	return out, nil
+}
+
+type dummyStatusELBService struct{}
+
+func (s dummyStatusELBService) DescribeLoadBalancers(input *elb.DescribeLoadBalancersInput) (*elb.DescribeLoadBalancersOutput, error) {
+	return &elb.DescribeLoadBalancersOutput{
+		LoadBalancerDescriptions: []*elb.LoadBalancerDescription{{DNSName: aws.String(*input.LoadBalancerNames[0] + ".elb.amazonaws.com")}},
+	}, nil
+}
+
+func (s dummyStatusELBService) DescribeInstanceHealth(input *elb.DescribeInstanceHealthInput) (*elb.DescribeInstanceHealthOutput, error) {
+	return &elb.DescribeInstanceHealthOutput{
+		InstanceStates: []*elb.InstanceState{{State: aws.String("InService")}, {State: aws.String("OutOfService")}},
+	}, nil
+}
+
+type dummyStatusELBV2Service struct{}
+
+func (s dummyStatusELBV2Service) DescribeLoadBalancers(input *elbv2.DescribeLoadBalancersInput) (*elbv2.DescribeLoadBalancersOutput, error) {
+	return &elbv2.DescribeLoadBalancersOutput{
+		LoadBalancers: []*elbv2.LoadBalancer{{LoadBalancerName: aws.String("nlb"), DNSName: aws.String("nlb.elb.amazonaws.com")}},
+	}, nil
+}
+
+func (s dummyStatusELBV2Service) DescribeTargetGroups(input *elbv2.DescribeTargetGroupsInput) (*elbv2.DescribeTargetGroupsOutput, error) {
+	return &elbv2.DescribeTargetGroupsOutput{
+		TargetGroups: []*elbv2.TargetGroup{{TargetGroupArn: aws.String("tg"), TargetGroupName: aws.String("tg")}},
+	}, nil
+}
+
+func (s dummyStatusELBV2Service) DescribeTargetHealth(input *elbv2.DescribeTargetHealthInput) (*elbv2.DescribeTargetHealthOutput, error) {
+	return &elbv2.DescribeTargetHealthOutput{
+		TargetHealthDescriptions: []*elbv2.TargetHealthDescription{
+			{TargetHealth: &elbv2.TargetHealth{State: aws.String(elbv2.TargetHealthStateEnumHealthy)}},
+			{TargetHealth: &elbv2.TargetHealth{State: aws.String(elbv2.TargetHealthStateEnumHealthy)}},
+		},
+	}, nil
+}
+
+type dummyStatusEC2Service struct{}
+
+func (s dummyStatusEC2Service) DescribeInstances(input *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+	r := &ec2.Reservation{}
+	for i, id := range input.InstanceIds {
+		r.Instances = append(r.Instances, &ec2.Instance{InstanceId: id, PrivateIpAddress: aws.String(fmt.Sprintf("10.0.0.%d", i+1))})
+	}
+	return &ec2.DescribeInstancesOutput{Reservations: []*ec2.Reservation{r}}, nil
+}
+
+func instances(states ...string) []*autoscaling.Instance {
+	is := []*autoscaling.Instance{}
+	for i, s := range states {
+		is = append(is, &autoscaling.Instance{InstanceId: aws.String(fmt.Sprintf("i-%d", i)), LifecycleState: aws.String(s), LaunchConfigurationName: aws.String("lc1")})
+	}
+	return is
+}
+
+func TestClusterStatusCollector(t *testing.T) {
+	workers := &autoscaling.Group{
+		AutoScalingGroupName:    aws.String("workers"),
+		DesiredCapacity:         aws.Int64(3),
+		MinSize:                 aws.Int64(1),
+		MaxSize:                 aws.Int64(5),
+		LaunchConfigurationName: aws.String("lc2"),
+		Instances:               instances("InService", "InService", "Pending:Wait", "Terminating:Wait"),
+	}
+	workers.Instances[1].LaunchConfigurationName = aws.String("lc2")
+
+	c := clusterStatusCollector{
+		cfnSvc: dummyStatusCFNService{
+			Statuses: map[string]string{
+				"mycluster":        "UPDATE_IN_PROGRESS",
+				"s/Etcd/1":         "UPDATE_COMPLETE",
+				"s/Controlplane/2": "UPDATE_COMPLETE",
+				"s/Pool1/3":        "UPDATE_IN_PROGRESS",
+			},
+			Resources: map[string]map[string][]string{
+				"mycluster":        {"AWS::CloudFormation::Stack": {"s/Etcd/1", "s/Controlplane/2", "s/Pool1/3"}, "AWS::IAM::Role": {"role"}},
+				"s/Etcd/1":         {"AWS::AutoScaling::AutoScalingGroup": {"etcd0"}},
+				"s/Controlplane/2": {"AWS::ElasticLoadBalancing::LoadBalancer": {"elb"}, "AWS::ElasticLoadBalancingV2::LoadBalancer": {"arn:nlb"}},
+				"s/Pool1/3":        {"AWS::AutoScaling::AutoScalingGroup": {"workers"}},
+			},
+		},
+		asgSvc: dummyStatusASGService{Groups: map[string]*autoscaling.Group{
+			"etcd0": {
+				AutoScalingGroupName:    aws.String("etcd0"),
+				DesiredCapacity:         aws.Int64(1),
+				MinSize:                 aws.Int64(1),
+				MaxSize:                 aws.Int64(1),
+				LaunchConfigurationName: aws.String("lc1"),
+				Instances:               instances("InService"),
+			},
+			"workers": workers,
+		}},
+		elbSvc:        dummyStatusELBService{},
+		elbv2Svc:      dummyStatusELBV2Service{},
+		ec2Svc:        dummyStatusEC2Service{},
+		clusterName:   "mycluster",
+		stackName:     "mycluster",
+		etcdStackName: "Etcd",
+		checkEtcdHealth: func(host string) error {
+			return nil
+		},
+	}
+
+	status, err := c.collect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(status.Stacks) != 4 || status.Stacks[0].Name != "mycluster" || status.Stacks[0].Status != "UPDATE_IN_PROGRESS" {
+		t.Errorf("expected the root and the nested stacks to be reported, but got: %+v", status.Stacks)
+	}
+
+	if len(status.AutoScalingGroups) != 2 {
+		t.Fatalf("expected the auto scaling groups in the nested stacks to be reported, but got: %+v", status.AutoScalingGroups)
+	}
+	expected := AutoScalingGroupStatus{Name: "workers", Stack: "Pool1", Desired: 3, Min: 1, Max: 5, InService: 2, Pending: 1, Terminating: 1, Outdated: 2}
+	for _, g := range status.AutoScalingGroups {
+		if g.Name == "workers" && g != expected {
+			t.Errorf("expected %+v but got %+v", expected, g)
+		}
+	}
+	if outdated, total := status.OutdatedInstances(); outdated != 2 || total != 5 {
+		t.Errorf("expected 2 of 5 instances to be outdated, but got %d of %d", outdated, total)
+	}
+
+	if len(status.LoadBalancers) != 2 {
+		t.Fatalf("expected both the classic and the network load balancers to be reported, but got: %+v", status.LoadBalancers)
+	}
+	for _, lb := range status.LoadBalancers {
+		if lb.Name == "elb" && (lb.Healthy != 1 || lb.Total != 2 || lb.DNSName != "elb.elb.amazonaws.com") {
+			t.Errorf("unexpected status of the classic load balancer: %+v", lb)
+		}
+		if lb.Name == "nlb" && (lb.Healthy != 2 || lb.Total != 2) {
+			t.Errorf("unexpected status of the network load balancer: %+v", lb)
+		}
+	}
+
+	if len(status.EtcdMembers) != 1 || !status.EtcdMembers[0].Healthy || status.EtcdMembers[0].Endpoint != "https://10.0.0.1:2379" {
+		t.Errorf("expected the etcd member to be healthy, but got: %+v", status.EtcdMembers)
+	}
+
+	c.checkEtcdHealth = func(host string) error {
+		return errors.New("connection refused")
+	}
+	status, err = c.collect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(status.EtcdMembers) != 1 || status.EtcdMembers[0].Healthy || status.EtcdMembers[0].Error != "connection refused" {
+		t.Errorf("expected the etcd member to be unhealthy, but got: %+v", status.EtcdMembers)
+	}
+
+	out := status.String()
+	for _, e := range []string{"Rolling Update:  2 of 5 instances are yet to be replaced", "unhealthy: connection refused", "elb.elb.amazonaws.com"} {
+		if !strings.Contains(out, e) {
+			t.Errorf("expected \"%s\" to be contained in the output, but it wasn't: %s", e, out)
+		}
+	}
+	js, err := status.JSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(js, `"outdated": 2`) {
+		t.Errorf("expected the outdated instances to be contained in the json, but they weren't: %s", js)
+	}
+}
+
+func TestInstanceOutdated(t *testing.T) {
+	lt := func(version string) *autoscaling.LaunchTemplateSpecification {
+		return &autoscaling.LaunchTemplateSpecification{LaunchTemplateId: aws.String("lt-1"), Version: aws.String(version)}
+	}
+
+	testCases := []struct {
+		group    *autoscaling.Group
+		instance *autoscaling.Instance
+		outdated bool
+	}{
+		{
+			group:    &autoscaling.Group{LaunchConfigurationName: aws.String("lc2")},
+			instance: &autoscaling.Instance{LaunchConfigurationName: aws.String("lc1")},
+			outdated: true,
+		},
+		{
+			group:    &autoscaling.Group{LaunchConfigurationName: aws.String("lc2")},
+			instance: &autoscaling.Instance{LaunchConfigurationName: aws.String("lc2")},
+			outdated: false,
+		},
+		{
+			group:    &autoscaling.Group{LaunchTemplate: lt("3")},
+			instance: &autoscaling.Instance{LaunchTemplate: lt("2")},
+			outdated: true,
+		},
+		{
+			group:    &autoscaling.Group{LaunchTemplate: lt("3")},
+			instance: &autoscaling.Instance{LaunchTemplate: lt("3")},
+			outdated: false,
+		},
+		// The version of the instance can't be compared with $Latest
+		{
+			group:    &autoscaling.Group{LaunchTemplate: lt("$Latest")},
+			instance: &autoscaling.Instance{LaunchTemplate: lt("2")},
+			outdated: false,
+		},
+		// The launch template is in the mixed instances policy
+		{
+			group:    &autoscaling.Group{},
+			instance: &autoscaling.Instance{LaunchTemplate: lt("2")},
+			outdated: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		if outdated := instanceOutdated(testCase.group, testCase.instance); outdated != testCase.outdated {
+			t.Errorf("case %d: expected the instance to be outdated to be %v but was %v", i, testCase.outdated, outdated)
+		}
+	}
+}
@@ -16,10 +16,19 @@ func (p TemplateParams) ExtraCfnResources() map[string]interface{} {
 	return p.cluster.ExtraCfnResources
 }
 
+// ExtraDependsOn returns the logical names of the resources the nested stack depends on according to `cloudformation.stackDependencies`
+func (p TemplateParams) ExtraDependsOn(name string) []string {
+	return p.cluster.stackDependencies[name]
+}
+
 func (p TemplateParams) ClusterName() string {
 	return p.cluster.controlPlaneStack.ClusterName
 }
 
+func (p TemplateParams) CloudFormation() api.CloudFormation {
+	return p.cluster.controlPlaneStack.Config.CloudFormation
+}
+
 func (p TemplateParams) KubeAwsVersion() string {
 	return model.VERSION
 }
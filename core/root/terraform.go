@@ -0,0 +1,628 @@
+package root
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/kubernetes-incubator/kube-aws/cfnstack"
+	"github.com/kubernetes-incubator/kube-aws/fingerprint"
+	"github.com/kubernetes-incubator/kube-aws/logger"
+	"github.com/kubernetes-incubator/kube-aws/naming"
+	"github.com/kubernetes-incubator/kube-aws/pkg/api"
+)
+
+const (
+	TerraformExportDir      = "exported/terraform"
+	TerraformConfigFilename = "kube-aws.tf.json"
+	// TerraformImportScriptFilename is the script importing the stacks and the log group of an existing cluster into the Terraform state
+	TerraformImportScriptFilename = "import.sh"
+
+	// terraformRootResourcesStackName is the logical name of the stack wrapping the resources added to the root stack by plugins
+	terraformRootResourcesStackName = "RootResources"
+)
+
+// terraformExport translates the root stack of the cluster into Terraform configuration wrapping the CloudFormation stacks.
+// Each nested stack becomes an `aws_cloudformation_stack` managed by Terraform with the same parameters, dependencies and tags
+// as in the root stack, so that Terraform replaces the root stack while the resources of the nested stacks are kept intact.
+// The resources in the stacks are never translated into native Terraform resources, hence still managed by CloudFormation.
+// When the cluster already exists, the stacks are named after the live nested stacks so that Terraform adopts them once imported
+type terraformExport struct {
+	clusterName  string
+	region       api.Region
+	roleARN      string
+	capabilities []string
+	tags         map[string]string
+	// assets are the stack templates and userdata of the nested stacks, which are uploaded to S3 by Terraform
+	assets []api.Asset
+	// liveResources are the physical IDs of the resources of the live root stack by their logical names, which is empty for a new cluster
+	liveResources map[string]terraformLiveResource
+}
+
+// terraformLiveResource is a resource of the live root stack
+type terraformLiveResource struct {
+	Type       string
+	PhysicalID string
+}
+
+// terraformCfnResource is a resource of the root stack
+type terraformCfnResource struct {
+	Type       string
+	Properties map[string]interface{}
+	DependsOn  interface{}
+}
+
+type terraformConfig struct {
+	Provider map[string]interface{}                       `json:"provider"`
+	Resource map[string]map[string]map[string]interface{} `json:"resource"`
+	Output   map[string]map[string]interface{}            `json:"output,omitempty"`
+}
+
+// ExportTerraform writes the Terraform configuration of the cluster and the assets it uploads to `exported/terraform`
+func (cl Cluster) ExportTerraform() error {
+	assets, err := cl.EnsureAllAssetsGenerated()
+	if err != nil {
+		return err
+	}
+
+	rootStackTemplate, err := cl.renderTemplateAsString()
+	if err != nil {
+		return fmt.Errorf("failed to render template : %v", err)
+	}
+
+	capabilities := []string{"CAPABILITY_IAM", "CAPABILITY_NAMED_IAM"}
+	if len(cl.controlPlaneStack.Config.CloudFormation.Transforms) > 0 {
+		capabilities = append(capabilities, "CAPABILITY_AUTO_EXPAND")
+	}
+	e := terraformExport{
+		clusterName:  cl.stackName(),
+		region:       cl.controlPlaneStack.Region,
+		roleARN:      cl.controlPlaneStack.Config.CloudFormation.RoleARN,
+		capabilities: capabilities,
+		tags:         cl.tags(),
+	}
+	for _, a := range assets.AsMap() {
+		// The root stack is what the Terraform configuration replaces
+		if a.ID.StackName != cl.stackName() {
+			e.assets = append(e.assets, a)
+		}
+	}
+
+	e.liveResources, err = listLiveRootResources(cloudformation.New(cl.session), cl.stackName())
+	if err != nil {
+		return err
+	}
+
+	config, err := e.render(rootStackTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to translate the root stack into terraform configuration: %v", err)
+	}
+
+	files := map[string]string{TerraformConfigFilename: config}
+	if script := e.renderImportScript(); script != "" {
+		files[TerraformImportScriptFilename] = script
+	}
+	for _, a := range e.assets {
+		files[e.assetSource(a)] = a.Content
+	}
+	for f, content := range files {
+		path := filepath.Join(TerraformExportDir, f)
+		logger.Infof("Exporting %s\n", path)
+		dir := filepath.Dir(path)
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return fmt.Errorf("failed to create directory \"%s\": %v", dir, err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			return fmt.Errorf("Error writing %s : %v", path, err)
+		}
+	}
+	return nil
+}
+
+// listLiveRootResources returns the physical IDs of the resources of the root stack by their logical names, or nothing when the stack doesn't exist yet
+func listLiveRootResources(cfSvc cfnstack.CFInterrogator, stackName string) (map[string]terraformLiveResource, error) {
+	resources := map[string]terraformLiveResource{}
+	exists, err := cfnstack.StackExists(cfSvc, stackName)
+	if err != nil || !exists {
+		return resources, err
+	}
+	input := &cloudformation.ListStackResourcesInput{StackName: aws.String(stackName)}
+	for {
+		resp, err := cfSvc.ListStackResources(input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list resources of stack %s: %v", stackName, err)
+		}
+		for _, r := range resp.StackResourceSummaries {
+			if r.PhysicalResourceId != nil {
+				resources[aws.StringValue(r.LogicalResourceId)] = terraformLiveResource{
+					Type:       aws.StringValue(r.ResourceType),
+					PhysicalID: aws.StringValue(r.PhysicalResourceId),
+				}
+			}
+		}
+		if resp.NextToken == nil {
+			return resources, nil
+		}
+		input.NextToken = resp.NextToken
+	}
+}
+
+// liveStackName returns the name of the live nested stack for the logical name, which CloudFormation suffixes with a random string
+func (e terraformExport) liveStackName(logicalName string) (string, bool) {
+	r, ok := e.liveResources[logicalName]
+	if !ok || r.Type != "AWS::CloudFormation::Stack" {
+		return "", false
+	}
+	// The physical ID of a nested stack is its ARN like `arn:aws:cloudformation:<region>:<account>:stack/<name>/<uuid>`
+	if ss := strings.Split(r.PhysicalID, "/"); len(ss) == 3 {
+		return ss[1], true
+	}
+	return r.PhysicalID, true
+}
+
+// renderImportScript renders the `terraform import` commands adopting the nested stacks and the log group of the live root stack
+func (e terraformExport) renderImportScript() string {
+	names := []string{}
+	for n := range e.liveResources {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	commands := []string{}
+	for _, n := range names {
+		switch r := e.liveResources[n]; r.Type {
+		case "AWS::CloudFormation::Stack":
+			s, _ := e.liveStackName(n)
+			commands = append(commands, fmt.Sprintf("terraform import aws_cloudformation_stack.%s %s", terraformName(n), s))
+		case "AWS::Logs::LogGroup":
+			commands = append(commands, fmt.Sprintf("terraform import aws_cloudwatch_log_group.%s %s", terraformName(n), r.PhysicalID))
+		}
+	}
+	if len(commands) == 0 {
+		return ""
+	}
+	return "#!/bin/sh\n\nset -e\n\n" + strings.Join(commands, "\n") + "\n"
+}
+
+func (e terraformExport) render(rootStackTemplate string) (string, error) {
+	var root struct {
+		Resources map[string]terraformCfnResource
+		Outputs   map[string]struct {
+			Description string
+			Value       interface{}
+		}
+	}
+	if err := json.Unmarshal([]byte(rootStackTemplate), &root); err != nil {
+		return "", fmt.Errorf("failed to parse the root stack template: %v", err)
+	}
+
+	types := map[string]string{}
+	for n, r := range root.Resources {
+		types[n] = r.Type
+	}
+
+	stacks := map[string]map[string]interface{}{}
+	logGroups := map[string]map[string]interface{}{}
+	objects := map[string]map[string]interface{}{}
+	// The resources added to the root stack by plugins, which are wrapped in a stack of their own
+	extras := map[string]terraformCfnResource{}
+
+	for _, a := range e.assets {
+		loc := e.assetLocation(a)
+		objects[terraformName(a.Path)] = map[string]interface{}{
+			"bucket": loc.Bucket,
+			"key":    loc.Key,
+			"source": fmt.Sprintf("${path.module}/%s", e.assetSource(a)),
+			"etag":   fmt.Sprintf(`${filemd5("${path.module}/%s")}`, e.assetSource(a)),
+		}
+	}
+
+	for n, r := range root.Resources {
+		switch r.Type {
+		case "AWS::Logs::LogGroup":
+			group := map[string]interface{}{
+				"name": r.Properties["LogGroupName"],
+			}
+			if days, ok := r.Properties["RetentionInDays"]; ok {
+				group["retention_in_days"] = days
+			}
+			logGroups[terraformName(n)] = group
+		case "AWS::CloudFormation::Stack":
+			stack, err := e.renderStack(n, r.Properties, types)
+			if err != nil {
+				return "", err
+			}
+			deps, err := terraformDependsOn(r.DependsOn, types)
+			if err != nil {
+				return "", fmt.Errorf("resource %s: %v", n, err)
+			}
+			for _, a := range e.assets {
+				if naming.FromStackToCfnResource(a.ID.StackName) == n {
+					deps = append(deps, "aws_s3_bucket_object."+terraformName(a.Path))
+				}
+			}
+			if len(deps) > 0 {
+				sort.Strings(deps)
+				stack["depends_on"] = uniqueStrings(deps)
+			}
+			stacks[terraformName(n)] = stack
+		default:
+			extras[n] = r
+		}
+	}
+	if len(extras) > 0 {
+		// Wrapping the live resources in another stack would create them twice, or fail for the ones with fixed names
+		live := []string{}
+		for n := range extras {
+			if _, ok := e.liveResources[n]; ok {
+				live = append(live, n)
+			}
+		}
+		if len(live) > 0 {
+			sort.Strings(live)
+			return "", fmt.Errorf("resources %v of the live root stack can't be exported to terraform, because they would be created again in the stack wrapping them instead of being moved into it", live)
+		}
+		stack, err := e.renderRootResourcesStack(extras, types)
+		if err != nil {
+			return "", err
+		}
+		stacks[terraformRootResourcesStackName] = stack
+	}
+
+	outputs := map[string]map[string]interface{}{}
+	for n, o := range root.Outputs {
+		v, err := terraformValue(o.Value, types)
+		if err != nil {
+			return "", fmt.Errorf("output %s: %v", n, err)
+		}
+		outputs[terraformName(n)] = map[string]interface{}{
+			"description": o.Description,
+			"value":       v,
+		}
+	}
+
+	config := terraformConfig{
+		Provider: map[string]interface{}{
+			"aws": map[string]interface{}{"region": e.region.Name},
+		},
+		Resource: map[string]map[string]map[string]interface{}{},
+		Output:   outputs,
+	}
+	for t, rs := range map[string]map[string]map[string]interface{}{
+		"aws_cloudformation_stack": stacks,
+		"aws_cloudwatch_log_group": logGroups,
+		"aws_s3_bucket_object":     objects,
+	} {
+		if len(rs) > 0 {
+			config.Resource[t] = rs
+		}
+	}
+
+	b, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b) + "\n", nil
+}
+
+func (e terraformExport) renderStack(name string, props map[string]interface{}, types map[string]string) (map[string]interface{}, error) {
+	var template *api.Asset
+	for i, a := range e.assets {
+		if naming.FromStackToCfnResource(a.ID.StackName) == name && a.ID.Filename == REMOTE_STACK_TEMPLATE_FILENAME {
+			template = &e.assets[i]
+		}
+	}
+	if template == nil {
+		return nil, fmt.Errorf("[bug] failed to find the stack template of the nested stack %s", name)
+	}
+	url, err := e.assetLocation(*template).URL()
+	if err != nil {
+		return nil, err
+	}
+
+	stackName, ok := e.liveStackName(name)
+	if !ok {
+		stackName = fmt.Sprintf("%s-%s", e.clusterName, name)
+	}
+	stack := map[string]interface{}{
+		"name":         stackName,
+		"template_url": url,
+		"capabilities": e.capabilities,
+	}
+	if e.roleARN != "" {
+		stack["iam_role_arn"] = e.roleARN
+	}
+
+	if ps, ok := props["Parameters"].(map[string]interface{}); ok && len(ps) > 0 {
+		params := map[string]interface{}{}
+		for k, v := range ps {
+			p, err := terraformValue(v, types)
+			if err != nil {
+				return nil, fmt.Errorf("parameter %s of the nested stack %s: %v", k, name, err)
+			}
+			params[k] = p
+		}
+		stack["parameters"] = params
+	}
+
+	// Tags of the root stack are propagated to the nested stacks by CloudFormation, which Terraform doesn't do
+	tags := map[string]interface{}{}
+	for k, v := range e.tags {
+		tags[k] = v
+	}
+	if ts, ok := props["Tags"].([]interface{}); ok {
+		for _, t := range ts {
+			tag, ok := t.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("unexpected tag of the nested stack %s: %v", name, t)
+			}
+			k, _ := tag["Key"].(string)
+			tags[k] = tag["Value"]
+		}
+	}
+	stack["tags"] = tags
+
+	return stack, nil
+}
+
+// renderRootResourcesStack wraps the resources added to the root stack, like the ones of plugins, in a stack whose template is embedded.
+// The references from the resources to the nested stacks and the CloudWatch log group are passed as the parameters of the stack
+func (e terraformExport) renderRootResourcesStack(resources map[string]terraformCfnResource, types map[string]string) (map[string]interface{}, error) {
+	params := map[string]interface{}{}
+	paramDefs := map[string]interface{}{}
+	deps := []string{}
+	templateResources := map[string]interface{}{}
+	for n, r := range resources {
+		resource := map[string]interface{}{"Type": r.Type}
+		props, err := parameterizeReferences(r.Properties, types, func(name string, value interface{}) (interface{}, error) {
+			v, err := terraformValue(value, types)
+			if err != nil {
+				return nil, fmt.Errorf("resource %s: %v", n, err)
+			}
+			params[name] = v
+			paramDefs[name] = map[string]interface{}{"Type": "String"}
+			return map[string]interface{}{"Ref": name}, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if r.Properties != nil {
+			resource["Properties"] = props
+		}
+		// Dependencies among the wrapped resources are kept in the template, whereas the ones to the others are moved to Terraform
+		inner := []string{}
+		outer := []interface{}{}
+		names, err := dependencyNames(r.DependsOn)
+		if err != nil {
+			return nil, fmt.Errorf("resource %s: %v", n, err)
+		}
+		for _, d := range names {
+			if _, ok := resources[d]; ok {
+				inner = append(inner, d)
+			} else {
+				outer = append(outer, d)
+			}
+		}
+		if len(inner) > 0 {
+			resource["DependsOn"] = inner
+		}
+		outerDeps, err := terraformDependsOn(outer, types)
+		if err != nil {
+			return nil, fmt.Errorf("resource %s: %v", n, err)
+		}
+		deps = append(deps, outerDeps...)
+		templateResources[n] = resource
+	}
+
+	template := map[string]interface{}{"Resources": templateResources}
+	if len(paramDefs) > 0 {
+		template["Parameters"] = paramDefs
+	}
+	body, err := json.Marshal(template)
+	if err != nil {
+		return nil, err
+	}
+
+	stack := map[string]interface{}{
+		"name":          fmt.Sprintf("%s-%s", e.clusterName, terraformRootResourcesStackName),
+		"template_body": terraformEscape(string(body)),
+		"capabilities":  e.capabilities,
+	}
+	if e.roleARN != "" {
+		stack["iam_role_arn"] = e.roleARN
+	}
+	if len(params) > 0 {
+		stack["parameters"] = params
+	}
+	if len(deps) > 0 {
+		sort.Strings(deps)
+		stack["depends_on"] = uniqueStrings(deps)
+	}
+	tags := map[string]interface{}{}
+	for k, v := range e.tags {
+		tags[k] = v
+	}
+	stack["tags"] = tags
+	return stack, nil
+}
+
+// parameterizeReferences replaces the `Ref`s and `Fn::GetAtt`s to the nested stacks and the CloudWatch log group in the value with
+// the references to the parameters returned by param, which is called with the name of the parameter and the reference replaced
+func parameterizeReferences(v interface{}, types map[string]string, param func(string, interface{}) (interface{}, error)) (interface{}, error) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if len(t) == 1 {
+			if n, ok := t["Ref"].(string); ok && isTerraformManaged(types[n]) {
+				return param(terraformParameterName(n), t)
+			}
+			if attr, ok := t["Fn::GetAtt"].([]interface{}); ok && len(attr) == 2 {
+				n, _ := attr[0].(string)
+				a, _ := attr[1].(string)
+				if isTerraformManaged(types[n]) {
+					return param(terraformParameterName(n+a), t)
+				}
+			}
+		}
+		m := map[string]interface{}{}
+		for k, x := range t {
+			p, err := parameterizeReferences(x, types, param)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = p
+		}
+		return m, nil
+	case []interface{}:
+		s := make([]interface{}, len(t))
+		for i, x := range t {
+			p, err := parameterizeReferences(x, types, param)
+			if err != nil {
+				return nil, err
+			}
+			s[i] = p
+		}
+		return s, nil
+	}
+	return v, nil
+}
+
+// isTerraformManaged returns true for the types of the resources of the root stack translated into Terraform resources of their own
+func isTerraformManaged(resourceType string) bool {
+	return resourceType == "AWS::CloudFormation::Stack" || resourceType == "AWS::Logs::LogGroup"
+}
+
+// terraformParameterName converts a reference into the name of a parameter, which must be alphanumeric
+func terraformParameterName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+func uniqueStrings(ss []string) []string {
+	unique := []string{}
+	for i, s := range ss {
+		if i == 0 || ss[i-1] != s {
+			unique = append(unique, s)
+		}
+	}
+	return unique
+}
+
+// assetLocation returns the location of the asset Terraform uploads it to.
+// Stack templates are located by their fingerprints like userdata are, because Terraform updates a stack only when its template url changes
+func (e terraformExport) assetLocation(a api.Asset) api.AssetLocation {
+	loc := a.AssetLocation
+	if a.ID.Filename == REMOTE_STACK_TEMPLATE_FILENAME {
+		ext := filepath.Ext(loc.Key)
+		loc.Key = fmt.Sprintf("%s-%s%s", strings.TrimSuffix(loc.Key, ext), fingerprint.SHA256(a.Content), ext)
+	}
+	return loc
+}
+
+// assetSource returns the path to the asset relative to the Terraform configuration
+func (e terraformExport) assetSource(a api.Asset) string {
+	return filepath.Join("stacks", a.Path)
+}
+
+// terraformEscape escapes the Terraform interpolations and directives in the string
+func terraformEscape(s string) string {
+	return strings.NewReplacer("${", "$${", "%{", "%%{").Replace(s)
+}
+
+// terraformValue translates the value of a parameter or an output of the root stack into a Terraform expression
+func terraformValue(v interface{}, types map[string]string) (interface{}, error) {
+	switch t := v.(type) {
+	case string:
+		return terraformEscape(t), nil
+	case map[string]interface{}:
+		if len(t) != 1 {
+			break
+		}
+		if attr, ok := t["Fn::GetAtt"].([]interface{}); ok && len(attr) == 2 {
+			n, _ := attr[0].(string)
+			a, _ := attr[1].(string)
+			switch {
+			case types[n] == "AWS::CloudFormation::Stack" && strings.HasPrefix(a, "Outputs."):
+				return fmt.Sprintf(`${aws_cloudformation_stack.%s.outputs["%s"]}`, terraformName(n), strings.TrimPrefix(a, "Outputs.")), nil
+			case types[n] == "AWS::Logs::LogGroup" && a == "Arn":
+				// CloudFormation returns the ARN with the trailing `:*` the nested stacks rely on, whereas Terraform doesn't
+				return fmt.Sprintf("${aws_cloudwatch_log_group.%s.arn}:*", terraformName(n)), nil
+			}
+		}
+		if n, ok := t["Ref"].(string); ok {
+			switch types[n] {
+			case "AWS::CloudFormation::Stack":
+				return fmt.Sprintf("${aws_cloudformation_stack.%s.id}", terraformName(n)), nil
+			case "AWS::Logs::LogGroup":
+				return fmt.Sprintf("${aws_cloudwatch_log_group.%s.name}", terraformName(n)), nil
+			}
+		}
+	default:
+		return t, nil
+	}
+	b, _ := json.Marshal(v)
+	return nil, fmt.Errorf("unsupported value %s: only strings, `Ref` and `Fn::GetAtt` to nested stacks and the CloudWatch log group can be exported to terraform. Resources added to the root stack can't be referred from outside of it", string(b))
+}
+
+// dependencyNames returns the names of the resources in the DependsOn attribute
+func dependencyNames(v interface{}) ([]string, error) {
+	names := []string{}
+	switch t := v.(type) {
+	case nil:
+	case string:
+		names = append(names, t)
+	case []interface{}:
+		for _, n := range t {
+			s, ok := n.(string)
+			if !ok {
+				return nil, fmt.Errorf("unexpected DependsOn: %v", v)
+			}
+			names = append(names, s)
+		}
+	default:
+		return nil, fmt.Errorf("unexpected DependsOn: %v", v)
+	}
+	return names, nil
+}
+
+func terraformDependsOn(v interface{}, types map[string]string) ([]string, error) {
+	names, err := dependencyNames(v)
+	if err != nil {
+		return nil, err
+	}
+
+	deps := []string{}
+	for _, n := range names {
+		switch types[n] {
+		case "AWS::CloudFormation::Stack":
+			deps = append(deps, "aws_cloudformation_stack."+terraformName(n))
+		case "AWS::Logs::LogGroup":
+			deps = append(deps, "aws_cloudwatch_log_group."+terraformName(n))
+		case "":
+			return nil, fmt.Errorf("dependency on %s can't be exported to terraform", n)
+		default:
+			// Resources added to the root stack are wrapped in a stack of their own
+			deps = append(deps, "aws_cloudformation_stack."+terraformRootResourcesStackName)
+		}
+	}
+	return deps, nil
+}
+
+// terraformName converts the logical name of a resource or the path to an asset into a valid Terraform resource name
+func terraformName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,288 @@
+package root
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubernetes-incubator/kube-aws/pkg/api"
+)
+
+func TestTerraformExportRender(t *testing.T) {
+	region := api.RegionForName("us-west-1")
+	asset := func(stack, file, content string) api.Asset {
+		return api.Asset{
+			AssetLocation: api.AssetLocation{
+				ID:     api.NewAssetID(stack, file),
+				Key:    "prefix/" + stack + "/" + file,
+				Bucket: "my-bucket",
+				Path:   stack + "/" + file,
+				Region: region,
+			},
+			Content: content,
+		}
+	}
+	e := terraformExport{
+		clusterName:  "mycluster",
+		region:       region,
+		capabilities: []string{"CAPABILITY_IAM", "CAPABILITY_NAMED_IAM"},
+		tags:         map[string]string{"kube-aws:version": "v0.0.0"},
+		assets: []api.Asset{
+			asset("network", "stack.json", "{}"),
+			asset("control-plane", "stack.json", "{}"),
+			asset("control-plane", "userdata-controller-abc", "#cloud-config"),
+		},
+	}
+
+	rootStackTemplate := `{
+  "Resources": {
+    "CloudWatchLogGroup": {"Type": "AWS::Logs::LogGroup", "Properties": {"LogGroupName": "mycluster", "RetentionInDays": 7}},
+    "Network": {"Type": "AWS::CloudFormation::Stack", "Properties": {"TemplateURL": "https://example.com/network/stack.json"}},
+    "Controlplane": {
+      "Type": "AWS::CloudFormation::Stack",
+      "Properties": {
+        "Parameters": {
+          "NetworkStackName": {"Fn::GetAtt": ["Network", "Outputs.StackName"]},
+          "CloudWatchLogGroupARN": {"Fn::GetAtt": ["CloudWatchLogGroup", "Arn"]}
+        },
+        "Tags": [{"Key": "kubernetes.io/cluster/mycluster", "Value": "true"}],
+        "TemplateURL": "https://example.com/control-plane/stack.json"
+      },
+      "DependsOn": "Network"
+    }
+  },
+  "Outputs": {
+    "ControlPlaneStackName": {"Description": "The name of the control plane stack", "Value": {"Fn::GetAtt": ["Controlplane", "Outputs.StackName"]}}
+  }
+}`
+
+	rendered, err := e.render(rootStackTemplate)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var config struct {
+		Provider map[string]map[string]string
+		Resource map[string]map[string]map[string]interface{}
+		Output   map[string]map[string]interface{}
+	}
+	if err := json.Unmarshal([]byte(rendered), &config); err != nil {
+		t.Fatalf("failed to parse the rendered configuration: %v\n%s", err, rendered)
+	}
+
+	if config.Provider["aws"]["region"] != "us-west-1" {
+		t.Errorf("expected the aws provider for us-west-1, but got: %v", config.Provider)
+	}
+
+	cp := config.Resource["aws_cloudformation_stack"]["Controlplane"]
+	if cp["name"] != "mycluster-Controlplane" {
+		t.Errorf("unexpected stack name: %v", cp["name"])
+	}
+	url, _ := cp["template_url"].(string)
+	if !strings.HasPrefix(url, "https://s3.amazonaws.com/my-bucket/prefix/control-plane/stack-") || !strings.HasSuffix(url, ".json") {
+		t.Errorf("expected the template url to be located by the fingerprint of the template, but got: %s", url)
+	}
+	expectedParams := map[string]interface{}{
+		"NetworkStackName":      `${aws_cloudformation_stack.Network.outputs["StackName"]}`,
+		"CloudWatchLogGroupARN": "${aws_cloudwatch_log_group.CloudWatchLogGroup.arn}:*",
+	}
+	if !reflect.DeepEqual(cp["parameters"], expectedParams) {
+		t.Errorf("unexpected parameters: expected=%v actual=%v", expectedParams, cp["parameters"])
+	}
+	expectedTags := map[string]interface{}{"kube-aws:version": "v0.0.0", "kubernetes.io/cluster/mycluster": "true"}
+	if !reflect.DeepEqual(cp["tags"], expectedTags) {
+		t.Errorf("unexpected tags: expected=%v actual=%v", expectedTags, cp["tags"])
+	}
+	expectedDeps := []interface{}{
+		"aws_cloudformation_stack.Network",
+		"aws_s3_bucket_object.control-plane_stack_json",
+		"aws_s3_bucket_object.control-plane_userdata-controller-abc",
+	}
+	if !reflect.DeepEqual(cp["depends_on"], expectedDeps) {
+		t.Errorf("unexpected dependencies: expected=%v actual=%v", expectedDeps, cp["depends_on"])
+	}
+
+	if _, ok := config.Resource["aws_cloudformation_stack"]["Network"]["parameters"]; ok {
+		t.Errorf("expected no parameters for the network stack")
+	}
+
+	obj := config.Resource["aws_s3_bucket_object"]["control-plane_userdata-controller-abc"]
+	if obj["bucket"] != "my-bucket" || obj["key"] != "prefix/control-plane/userdata-controller-abc" || obj["source"] != "${path.module}/stacks/control-plane/userdata-controller-abc" {
+		t.Errorf("unexpected s3 object: %v", obj)
+	}
+
+	if lg := config.Resource["aws_cloudwatch_log_group"]["CloudWatchLogGroup"]; lg["name"] != "mycluster" || lg["retention_in_days"] != float64(7) {
+		t.Errorf("unexpected log group: %v", lg)
+	}
+
+	if v := config.Output["ControlPlaneStackName"]["value"]; v != `${aws_cloudformation_stack.Controlplane.outputs["StackName"]}` {
+		t.Errorf("unexpected output: %v", v)
+	}
+}
+
+func TestTerraformExportRenderUnsupported(t *testing.T) {
+	e := terraformExport{clusterName: "mycluster", region: api.RegionForName("us-west-1")}
+
+	testCases := []string{
+		// A reference to a resource added by a plugin from outside of the stack wrapping it
+		`{"Resources": {"PluginBucket": {"Type": "AWS::S3::Bucket"}}, "Outputs": {"Bucket": {"Value": {"Ref": "PluginBucket"}}}}`,
+		// An intrinsic function other than Ref and Fn::GetAtt
+		`{"Outputs": {"Name": {"Value": {"Fn::Sub": "${AWS::StackName}-foo"}}}}`,
+	}
+
+	for i, tc := range testCases {
+		if _, err := e.render(tc); err == nil {
+			t.Errorf("case %d: expected error, but got none", i)
+		}
+	}
+}
+
+func TestTerraformExportRenderRootResources(t *testing.T) {
+	region := api.RegionForName("us-west-1")
+	network := api.Asset{
+		AssetLocation: api.AssetLocation{ID: api.NewAssetID("network", "stack.json"), Key: "prefix/network/stack.json", Bucket: "my-bucket", Path: "network/stack.json", Region: region},
+		Content:       "{}",
+	}
+	e := terraformExport{clusterName: "mycluster", region: region, tags: map[string]string{"kube-aws:version": "v0.0.0"}, assets: []api.Asset{network}}
+
+	rootStackTemplate := `{
+  "Resources": {
+    "Network": {"Type": "AWS::CloudFormation::Stack", "Properties": {}},
+    "PluginRole": {"Type": "AWS::IAM::Role", "Properties": {"RoleName": "${AWS::StackName}-plugin"}},
+    "PluginQueue": {
+      "Type": "AWS::SQS::Queue",
+      "Properties": {"Tags": [{"Key": "network", "Value": {"Fn::GetAtt": ["Network", "Outputs.StackName"]}}]},
+      "DependsOn": ["Network", "PluginRole"]
+    }
+  }
+}`
+
+	rendered, err := e.render(rootStackTemplate)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var config struct {
+		Resource map[string]map[string]map[string]interface{}
+	}
+	if err := json.Unmarshal([]byte(rendered), &config); err != nil {
+		t.Fatalf("failed to parse the rendered configuration: %v\n%s", err, rendered)
+	}
+
+	stack := config.Resource["aws_cloudformation_stack"]["RootResources"]
+	if stack["name"] != "mycluster-RootResources" {
+		t.Errorf("unexpected stack name: %v", stack["name"])
+	}
+	expectedParams := map[string]interface{}{"NetworkOutputsStackName": `${aws_cloudformation_stack.Network.outputs["StackName"]}`}
+	if !reflect.DeepEqual(stack["parameters"], expectedParams) {
+		t.Errorf("unexpected parameters: expected=%v actual=%v", expectedParams, stack["parameters"])
+	}
+	if expectedDeps := []interface{}{"aws_cloudformation_stack.Network"}; !reflect.DeepEqual(stack["depends_on"], expectedDeps) {
+		t.Errorf("unexpected dependencies: expected=%v actual=%v", expectedDeps, stack["depends_on"])
+	}
+
+	body, _ := stack["template_body"].(string)
+	var template struct {
+		Parameters map[string]map[string]string
+		Resources  map[string]map[string]interface{}
+	}
+	if err := json.Unmarshal([]byte(strings.Replace(body, "$${", "${", -1)), &template); err != nil {
+		t.Fatalf("failed to parse the template body: %v\n%s", err, body)
+	}
+	if template.Parameters["NetworkOutputsStackName"]["Type"] != "String" || len(template.Resources) != 2 {
+		t.Errorf("unexpected template body: %s", body)
+	}
+	tag := template.Resources["PluginQueue"]["Properties"].(map[string]interface{})["Tags"].([]interface{})[0].(map[string]interface{})
+	if !reflect.DeepEqual(tag["Value"], map[string]interface{}{"Ref": "NetworkOutputsStackName"}) {
+		t.Errorf("expected the reference to the nested stack to be passed as the parameter, but got: %v", tag["Value"])
+	}
+	if !reflect.DeepEqual(template.Resources["PluginQueue"]["DependsOn"], []interface{}{"PluginRole"}) {
+		t.Errorf("expected only the dependency among the wrapped resources to be kept, but got: %v", template.Resources["PluginQueue"]["DependsOn"])
+	}
+	if !strings.Contains(body, `"$${AWS::StackName}-plugin"`) {
+		t.Errorf("expected the interpolation syntax of terraform to be escaped in the template body: %s", body)
+	}
+}
+
+func TestTerraformValue(t *testing.T) {
+	testCases := []struct {
+		value    interface{}
+		expected interface{}
+	}{
+		{value: "plain", expected: "plain"},
+		{value: "${notinterpolated}", expected: "$${notinterpolated}"},
+		{value: float64(3), expected: float64(3)},
+		{value: map[string]interface{}{"Ref": "Network"}, expected: "${aws_cloudformation_stack.Network.id}"},
+	}
+
+	for i, tc := range testCases {
+		actual, err := terraformValue(tc.value, map[string]string{"Network": "AWS::CloudFormation::Stack"})
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if actual != tc.expected {
+			t.Errorf("case %d: expected %v, but got %v", i, tc.expected, actual)
+		}
+	}
+}
+
+func TestTerraformExportRenderExistingCluster(t *testing.T) {
+	region := api.RegionForName("us-west-1")
+	network := api.Asset{
+		AssetLocation: api.AssetLocation{ID: api.NewAssetID("network", "stack.json"), Key: "prefix/network/stack.json", Bucket: "my-bucket", Path: "network/stack.json", Region: region},
+		Content:       "{}",
+	}
+	e := terraformExport{
+		clusterName: "mycluster",
+		region:      region,
+		assets:      []api.Asset{network},
+		liveResources: map[string]terraformLiveResource{
+			"CloudWatchLogGroup": {Type: "AWS::Logs::LogGroup", PhysicalID: "mycluster"},
+			"Network":            {Type: "AWS::CloudFormation::Stack", PhysicalID: "arn:aws:cloudformation:us-west-1:123456789012:stack/mycluster-Network-1A2B3C4D5E6F/0a1b2c3d-0000-1111-2222-333344445555"},
+		},
+	}
+
+	rootStackTemplate := `{
+  "Resources": {
+    "CloudWatchLogGroup": {"Type": "AWS::Logs::LogGroup", "Properties": {"LogGroupName": "mycluster"}},
+    "Network": {"Type": "AWS::CloudFormation::Stack", "Properties": {}}
+  }
+}`
+
+	rendered, err := e.render(rootStackTemplate)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var config struct {
+		Resource map[string]map[string]map[string]interface{}
+	}
+	if err := json.Unmarshal([]byte(rendered), &config); err != nil {
+		t.Fatalf("failed to parse the rendered configuration: %v\n%s", err, rendered)
+	}
+	if name := config.Resource["aws_cloudformation_stack"]["Network"]["name"]; name != "mycluster-Network-1A2B3C4D5E6F" {
+		t.Errorf("expected the stack to be named after the live nested stack, but got: %v", name)
+	}
+
+	expectedScript := `#!/bin/sh
+
+set -e
+
+terraform import aws_cloudwatch_log_group.CloudWatchLogGroup mycluster
+terraform import aws_cloudformation_stack.Network mycluster-Network-1A2B3C4D5E6F
+`
+	if script := e.renderImportScript(); script != expectedScript {
+		t.Errorf("unexpected import script: expected=%s actual=%s", expectedScript, script)
+	}
+
+	e.liveResources["PluginRole"] = terraformLiveResource{Type: "AWS::IAM::Role", PhysicalID: "mycluster-plugin"}
+	withPlugin := `{"Resources": {"Network": {"Type": "AWS::CloudFormation::Stack", "Properties": {}}, "PluginRole": {"Type": "AWS::IAM::Role"}}}`
+	if _, err := e.render(withPlugin); err == nil || !strings.Contains(err.Error(), "PluginRole") {
+		t.Errorf("expected an error for the resource added to the live root stack, but got: %v", err)
+	}
+
+	if script := (terraformExport{clusterName: "mycluster"}).renderImportScript(); script != "" {
+		t.Errorf("expected no import script for a new cluster, but got: %s", script)
+	}
+}
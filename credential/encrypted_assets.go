@@ -43,7 +43,12 @@ type RawAssetsOnMemory struct {
 	KIAMAgentCert             []byte
 	KIAMAgentKey              []byte
 	KIAMCACert                []byte
+	FrontProxyCACert          []byte
+	FrontProxyCAKey           []byte
+	FrontProxyClientCert      []byte
+	FrontProxyClientKey       []byte
 	ServiceAccountKey         []byte
+	OidcCACert                []byte
 
 	// Other assets.
 	AuthTokens        []byte
@@ -79,7 +84,11 @@ type RawAssetsOnDisk struct {
 	KIAMAgentCert             PlaintextFile
 	KIAMAgentKey              PlaintextFile
 	KIAMCACert                PlaintextFile
+	FrontProxyCACert          PlaintextFile
+	FrontProxyClientCert      PlaintextFile
+	FrontProxyClientKey       PlaintextFile
 	ServiceAccountKey         PlaintextFile
+	OidcCACert                PlaintextFile
 
 	// Other assets.
 	AuthTokens        PlaintextFile
@@ -115,7 +124,11 @@ type EncryptedAssetsOnDisk struct {
 	KIAMAgentCert             EncryptedFile
 	KIAMAgentKey              EncryptedFile
 	KIAMCACert                EncryptedFile
+	FrontProxyCACert          EncryptedFile
+	FrontProxyClientCert      EncryptedFile
+	FrontProxyClientKey       EncryptedFile
 	ServiceAccountKey         EncryptedFile
+	OidcCACert                EncryptedFile
 
 	// Other encrypted assets.
 	AuthTokens        EncryptedFile
@@ -151,7 +164,11 @@ type CompactAssets struct {
 	KIAMAgentCert             string
 	KIAMAgentKey              string
 	KIAMCACert                string
+	FrontProxyCACert          string
+	FrontProxyClientCert      string
+	FrontProxyClientKey       string
 	ServiceAccountKey         string
+	OidcCACert                string
 
 	// Encrypted -> gzip -> base64 encoded assets.
 	AuthTokens        string
@@ -161,7 +178,7 @@ type CompactAssets struct {
 	EncryptionConfig string
 }
 
-func ReadRawAssets(dirname string, manageCertificates bool, caKeyRequiredOnController bool, kiamEnabled bool) (*RawAssetsOnDisk, error) {
+func ReadRawAssets(dirname string, manageCertificates bool, caKeyRequiredOnController bool, kiamEnabled bool, frontProxyEnabled bool) (*RawAssetsOnDisk, error) {
 	defaultTokensFile := ""
 	defaultServiceAccountKey := "<<<" + filepath.Join(dirname, "apiserver-key.pem")
 	defaultTLSBootstrapToken, err := RandomTokenString()
@@ -227,6 +244,17 @@ func ReadRawAssets(dirname string, manageCertificates bool, caKeyRequiredOnContr
 			files = append(files, entry{name: "kiam-agent.pem", data: &r.KIAMAgentCert, defaultValue: nil, expiryCheck: true})
 			files = append(files, entry{name: "kiam-ca.pem", data: &r.KIAMCACert, defaultValue: nil, expiryCheck: true})
 		}
+
+		if frontProxyEnabled {
+			files = append(files, entry{name: "front-proxy-ca.pem", data: &r.FrontProxyCACert, defaultValue: nil, expiryCheck: true})
+			files = append(files, entry{name: "front-proxy-client-key.pem", data: &r.FrontProxyClientKey, defaultValue: nil, expiryCheck: false})
+			files = append(files, entry{name: "front-proxy-client.pem", data: &r.FrontProxyClientCert, defaultValue: nil, expiryCheck: true})
+		}
+	}
+
+	// Unlike tokens.csv, the CA bundle of the OIDC provider isn't created when missing
+	if fileExists(filepath.Join(dirname, "oidc-ca.pem")) {
+		files = append(files, entry{name: "oidc-ca.pem", data: &r.OidcCACert, defaultValue: nil, expiryCheck: true})
 	}
 
 	for _, file := range files {
@@ -253,7 +281,7 @@ func ReadRawAssets(dirname string, manageCertificates bool, caKeyRequiredOnContr
 	return r, nil
 }
 
-func ReadOrEncryptAssets(dirname string, manageCertificates bool, caKeyRequiredOnController bool, kiamEnabled bool, store Store) (*EncryptedAssetsOnDisk, error) {
+func ReadOrEncryptAssets(dirname string, manageCertificates bool, caKeyRequiredOnController bool, kiamEnabled bool, frontProxyEnabled bool, store Store) (*EncryptedAssetsOnDisk, error) {
 	defaultTokensFile := ""
 	defaultServiceAccountKey := "<<<" + filepath.Join(dirname, "apiserver-key.pem")
 	defaultTLSBootstrapToken, err := RandomTokenString()
@@ -316,6 +344,19 @@ func ReadOrEncryptAssets(dirname string, manageCertificates bool, caKeyRequiredO
 			files = append(files, entry{name: "kiam-agent.pem", data: &r.KIAMAgentCert, defaultValue: nil, readEncrypted: false, expiryCheck: true})
 			files = append(files, entry{name: "kiam-ca.pem", data: &r.KIAMCACert, defaultValue: nil, readEncrypted: false, expiryCheck: true})
 		}
+
+		if frontProxyEnabled {
+			files = append(files, entry{name: "front-proxy-ca.pem", data: &r.FrontProxyCACert, defaultValue: nil, readEncrypted: false, expiryCheck: true})
+			files = append(files, entry{name: "front-proxy-client-key.pem", data: &r.FrontProxyClientKey, defaultValue: nil, readEncrypted: true, expiryCheck: false})
+			files = append(files, entry{name: "front-proxy-client.pem", data: &r.FrontProxyClientCert, defaultValue: nil, readEncrypted: false, expiryCheck: true})
+		}
+	}
+
+	// Unlike tokens.csv, the CA bundle of the OIDC provider isn't created when missing.
+	// It is encrypted even though it isn't a secret, so that it is handled like the other credentials on controller nodes
+	oidcCACertPath := filepath.Join(dirname, "oidc-ca.pem")
+	if fileExists(oidcCACertPath) || fileExists(cacheFilePath(oidcCACertPath)) {
+		files = append(files, entry{name: "oidc-ca.pem", data: &r.OidcCACert, defaultValue: nil, readEncrypted: true, expiryCheck: false})
 	}
 
 	for _, file := range files {
@@ -353,7 +394,7 @@ func ReadOrEncryptAssets(dirname string, manageCertificates bool, caKeyRequiredO
 	return r, nil
 }
 
-func (r *RawAssetsOnMemory) WriteToDir(dirname string, includeCAKey bool, kiamEnabled bool) error {
+func (r *RawAssetsOnMemory) WriteToDir(dirname string, includeCAKey bool, kiamEnabled bool, frontProxyEnabled bool) error {
 	type asset struct {
 		name             string
 		data             []byte
@@ -407,6 +448,15 @@ func (r *RawAssetsOnMemory) WriteToDir(dirname string, includeCAKey bool, kiamEn
 		)
 	}
 
+	if frontProxyEnabled {
+		assets = append(assets,
+			asset{"front-proxy-ca.pem", r.FrontProxyCACert, true, ""},
+			asset{"front-proxy-ca-key.pem", r.FrontProxyCAKey, true, ""},
+			asset{"front-proxy-client.pem", r.FrontProxyClientCert, true, ""},
+			asset{"front-proxy-client-key.pem", r.FrontProxyClientKey, true, ""},
+		)
+	}
+
 	for _, asset := range assets {
 		path := filepath.Join(dirname, asset.name)
 
@@ -482,7 +532,7 @@ func (r *RawAssetsOnMemory) WriteToDir(dirname string, includeCAKey bool, kiamEn
 	return nil
 }
 
-func (r *EncryptedAssetsOnDisk) WriteToDir(dirname string, kiamEnabled bool) error {
+func (r *EncryptedAssetsOnDisk) WriteToDir(dirname string, kiamEnabled bool, frontProxyEnabled bool) error {
 	type asset struct {
 		name string
 		data EncryptedFile
@@ -524,6 +574,13 @@ func (r *EncryptedAssetsOnDisk) WriteToDir(dirname string, kiamEnabled bool) err
 			asset{"kiam-ca.pem", r.KIAMCACert},
 		)
 	}
+	if frontProxyEnabled {
+		assets = append(assets,
+			asset{"front-proxy-ca.pem", r.FrontProxyCACert},
+			asset{"front-proxy-client.pem", r.FrontProxyClientCert},
+			asset{"front-proxy-client-key.pem", r.FrontProxyClientKey},
+		)
+	}
 
 	for _, asset := range assets {
 		if asset.name != "ca-key.pem" {
@@ -579,7 +636,11 @@ func (r *RawAssetsOnDisk) Compact() (*CompactAssets, error) {
 		KIAMServerCert:            compact(r.KIAMServerCert),
 		KIAMServerKey:             compact(r.KIAMServerKey),
 		KIAMCACert:                compact(r.KIAMCACert),
+		FrontProxyCACert:          compact(r.FrontProxyCACert),
+		FrontProxyClientCert:      compact(r.FrontProxyClientCert),
+		FrontProxyClientKey:       compact(r.FrontProxyClientKey),
 		ServiceAccountKey:         compact(r.ServiceAccountKey),
+		OidcCACert:                compact(r.OidcCACert),
 
 		AuthTokens:        compact(r.AuthTokens),
 		TLSBootstrapToken: compact(r.TLSBootstrapToken),
@@ -636,7 +697,11 @@ func (r *EncryptedAssetsOnDisk) Compact() (*CompactAssets, error) {
 		KIAMServerKey:             compact(r.KIAMServerKey),
 		KIAMServerCert:            compact(r.KIAMServerCert),
 		KIAMCACert:                compact(r.KIAMCACert),
+		FrontProxyCACert:          compact(r.FrontProxyCACert),
+		FrontProxyClientCert:      compact(r.FrontProxyClientCert),
+		FrontProxyClientKey:       compact(r.FrontProxyClientKey),
 		ServiceAccountKey:         compact(r.ServiceAccountKey),
+		OidcCACert:                compact(r.OidcCACert),
 
 		AuthTokens:        compact(r.AuthTokens),
 		TLSBootstrapToken: compact(r.TLSBootstrapToken),
@@ -679,14 +744,14 @@ func NewKMSConfig(kmsKeyARN string, encSvc KMSEncryptionService, session *sessio
 	}
 }
 
-func ReadOrCreateEncryptedAssets(tlsAssetsDir string, manageCertificates bool, caKeyRequiredOnController bool, kiamEnabled bool, kmsConfig KMSConfig) (*EncryptedAssetsOnDisk, error) {
+func ReadOrCreateEncryptedAssets(tlsAssetsDir string, manageCertificates bool, caKeyRequiredOnController bool, kiamEnabled bool, frontProxyEnabled bool, kmsConfig KMSConfig) (*EncryptedAssetsOnDisk, error) {
 	store := kmsConfig.Store()
 
-	return ReadOrEncryptAssets(tlsAssetsDir, manageCertificates, caKeyRequiredOnController, kiamEnabled, store)
+	return ReadOrEncryptAssets(tlsAssetsDir, manageCertificates, caKeyRequiredOnController, kiamEnabled, frontProxyEnabled, store)
 }
 
-func ReadOrCreateCompactAssets(assetsDir string, manageCertificates bool, caKeyRequiredOnController bool, kiamEnabled bool, kmsConfig KMSConfig) (*CompactAssets, error) {
-	encryptedAssets, err := ReadOrCreateEncryptedAssets(assetsDir, manageCertificates, caKeyRequiredOnController, kiamEnabled, kmsConfig)
+func ReadOrCreateCompactAssets(assetsDir string, manageCertificates bool, caKeyRequiredOnController bool, kiamEnabled bool, frontProxyEnabled bool, kmsConfig KMSConfig) (*CompactAssets, error) {
+	encryptedAssets, err := ReadOrCreateEncryptedAssets(assetsDir, manageCertificates, caKeyRequiredOnController, kiamEnabled, frontProxyEnabled, kmsConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read/create encrypted assets: %v", err)
 	}
@@ -699,8 +764,8 @@ func ReadOrCreateCompactAssets(assetsDir string, manageCertificates bool, caKeyR
 	return compactAssets, nil
 }
 
-func ReadOrCreateUnencryptedCompactAssets(assetsDir string, manageCertificates bool, caKeyRequiredOnController bool, kiamEnabled bool) (*CompactAssets, error) {
-	unencryptedAssets, err := ReadRawAssets(assetsDir, manageCertificates, caKeyRequiredOnController, kiamEnabled)
+func ReadOrCreateUnencryptedCompactAssets(assetsDir string, manageCertificates bool, caKeyRequiredOnController bool, kiamEnabled bool, frontProxyEnabled bool) (*CompactAssets, error) {
+	unencryptedAssets, err := ReadRawAssets(assetsDir, manageCertificates, caKeyRequiredOnController, kiamEnabled, frontProxyEnabled)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read/create encrypted assets: %v", err)
 	}
@@ -750,3 +815,12 @@ func (a *CompactAssets) HasAuthTokens() bool {
 func (a *CompactAssets) HasTLSBootstrapToken() bool {
 	return len(a.TLSBootstrapToken) > 0
 }
+
+func (a *CompactAssets) HasOidcCACert() bool {
+	return len(a.OidcCACert) > 0
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"encoding/base64"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -64,7 +65,7 @@ func TestReadOrCreateCompactAssets(t *testing.T) {
 
 		// See https://github.com/kubernetes-incubator/kube-aws/issues/107
 		t.Run("CachedToPreventUnnecessaryNodeReplacement", func(t *testing.T) {
-			created, err := ReadOrCreateCompactAssets(dir, true, true, true, kmsConfig)
+			created, err := ReadOrCreateCompactAssets(dir, true, true, true, true, kmsConfig)
 
 			if err != nil {
 				t.Errorf("failed to read or update compact assets in %s : %v", dir, err)
@@ -74,7 +75,7 @@ func TestReadOrCreateCompactAssets(t *testing.T) {
 			// This depends on TestDummyEncryptService which ensures dummy encrypt service to produce different ciphertext for each encryption
 			// created == read means that encrypted assets were loaded from cached files named *.pem.enc, instead of re-encrypting raw assets named *.pem files
 			// TODO Use some kind of mocking framework for tests like this
-			read, err := ReadOrCreateCompactAssets(dir, true, true, true, kmsConfig)
+			read, err := ReadOrCreateCompactAssets(dir, true, true, true, true, kmsConfig)
 
 			if err != nil {
 				t.Errorf("failed to read or update compact assets in %s : %v", dir, err)
@@ -90,7 +91,7 @@ func TestReadOrCreateCompactAssets(t *testing.T) {
 		})
 
 		t.Run("RemoveFilesToRegenerate", func(t *testing.T) {
-			original, err := ReadOrCreateCompactAssets(dir, true, true, true, kmsConfig)
+			original, err := ReadOrCreateCompactAssets(dir, true, true, true, true, kmsConfig)
 
 			if err != nil {
 				t.Errorf("failed to read the original encrypted assets : %v", err)
@@ -102,6 +103,7 @@ func TestReadOrCreateCompactAssets(t *testing.T) {
 				"etcd-key.pem.enc", "etcd-client-key.pem.enc", "worker-ca-key.pem.enc",
 				"kube-controller-manager-key.pem.enc", "kube-scheduler-key.pem.enc",
 				"kiam-agent-key.pem.enc", "kiam-server-key.pem.enc", "apiserver-aggregator-key.pem.enc",
+				"front-proxy-client-key.pem.enc",
 			}
 
 			for _, filename := range files {
@@ -111,7 +113,7 @@ func TestReadOrCreateCompactAssets(t *testing.T) {
 				}
 			}
 
-			regenerated, err := ReadOrCreateCompactAssets(dir, true, true, true, kmsConfig)
+			regenerated, err := ReadOrCreateCompactAssets(dir, true, true, true, true, kmsConfig)
 
 			if err != nil {
 				t.Errorf("failed to read the regenerated encrypted assets : %v", err)
@@ -131,6 +133,8 @@ func TestReadOrCreateCompactAssets(t *testing.T) {
 				{"KIAMServerCert", original.KIAMServerCert, regenerated.KIAMServerCert},
 				{"KIAMCACert", original.KIAMCACert, regenerated.KIAMCACert},
 				{"APIServerAggregatorCert", original.APIServerAggregatorCert, regenerated.APIServerAggregatorCert},
+				{"FrontProxyCACert", original.FrontProxyCACert, regenerated.FrontProxyCACert},
+				{"FrontProxyClientCert", original.FrontProxyClientCert, regenerated.FrontProxyClientCert},
 			} {
 				if v[1] != v[2] {
 					t.Errorf("%s must NOT change but it did : original = %v, regenrated = %v ", v[0], v[1], v[2])
@@ -149,6 +153,7 @@ func TestReadOrCreateCompactAssets(t *testing.T) {
 				{"KIAMAgentKey", original.KIAMAgentKey, regenerated.KIAMAgentKey},
 				{"KIAMServerKey", original.KIAMServerKey, regenerated.KIAMServerKey},
 				{"APIServerAggregatorKey", original.APIServerAggregatorKey, regenerated.APIServerAggregatorKey},
+				{"FrontProxyClientKey", original.FrontProxyClientKey, regenerated.FrontProxyClientKey},
 			} {
 				if v[1] == v[2] {
 					t.Errorf("%s must change but it didn't : original = %v, regenrated = %v ", v[0], v[1], v[2])
@@ -167,13 +172,13 @@ func TestReadOrCreateCompactAssets(t *testing.T) {
 func TestReadOrCreateUnEncryptedCompactAssets(t *testing.T) {
 	run := func(dir string, caKeyRequiredOnController bool, t *testing.T) {
 		t.Run("CachedToPreventUnnecessaryNodeReplacementOnUnencrypted", func(t *testing.T) {
-			created, err := ReadOrCreateUnencryptedCompactAssets(dir, true, caKeyRequiredOnController, true)
+			created, err := ReadOrCreateUnencryptedCompactAssets(dir, true, caKeyRequiredOnController, true, true)
 
 			if err != nil {
 				t.Errorf("failed to read or update compact assets in %s : %v", dir, err)
 			}
 
-			read, err := ReadOrCreateUnencryptedCompactAssets(dir, true, caKeyRequiredOnController, true)
+			read, err := ReadOrCreateUnencryptedCompactAssets(dir, true, caKeyRequiredOnController, true, true)
 
 			if err != nil {
 				t.Errorf("failed to read or update compact assets in %s : %v", dir, err)
@@ -277,3 +282,86 @@ func TestHasTLSBootstrapToken(t *testing.T) {
 		}
 	}
 }
+
+func TestHasOidcCACert(t *testing.T) {
+	testCases := []struct {
+		oidcCACert string
+		expected   bool
+	}{
+		// Without OIDC CA bundle
+		{
+			oidcCACert: "",
+			expected:   false,
+		},
+
+		// With OIDC CA bundle
+		{
+			oidcCACert: "contents",
+			expected:   true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		asset := &CompactAssets{
+			OidcCACert: testCase.oidcCACert,
+		}
+
+		actual := asset.HasOidcCACert()
+		if actual != testCase.expected {
+			t.Errorf("Expected HasOidcCACert to be %v, but was %v", testCase.expected, actual)
+		}
+	}
+}
+
+func TestReadOidcCACert(t *testing.T) {
+	helper.WithDummyCredentials(func(dir string) {
+		kmsConfig := NewKMSConfig("keyarn", &dummyEncryptService{}, nil)
+		oidcCACertPath := filepath.Join(dir, "oidc-ca.pem")
+
+		t.Run("NotCreatedWhenMissing", func(t *testing.T) {
+			unencrypted, err := ReadOrCreateUnencryptedCompactAssets(dir, true, true, false, false)
+			if err != nil {
+				t.Fatalf("failed to read unencrypted compact assets in %s : %v", dir, err)
+			}
+			encrypted, err := ReadOrCreateCompactAssets(dir, true, true, false, false, kmsConfig)
+			if err != nil {
+				t.Fatalf("failed to read encrypted compact assets in %s : %v", dir, err)
+			}
+			if unencrypted.HasOidcCACert() || encrypted.HasOidcCACert() {
+				t.Errorf("expected no OIDC CA bundle to be read but it was")
+			}
+			if _, err := os.Stat(oidcCACertPath); !os.IsNotExist(err) {
+				t.Errorf("expected %s not to be created but it was", oidcCACertPath)
+			}
+		})
+
+		t.Run("ReadWhenPresent", func(t *testing.T) {
+			ca, err := ioutil.ReadFile(filepath.Join(dir, "ca.pem"))
+			if err != nil {
+				t.Fatalf("failed to read ca.pem : %v", err)
+			}
+			if err := ioutil.WriteFile(oidcCACertPath, ca, 0600); err != nil {
+				t.Fatalf("failed to write %s : %v", oidcCACertPath, err)
+			}
+
+			unencrypted, err := ReadOrCreateUnencryptedCompactAssets(dir, true, true, false, false)
+			if err != nil {
+				t.Fatalf("failed to read unencrypted compact assets in %s : %v", dir, err)
+			}
+			if unencrypted.OidcCACert != unencrypted.CACert {
+				t.Errorf("expected the OIDC CA bundle to be read as-is but it wasn't: %s", unencrypted.OidcCACert)
+			}
+
+			encrypted, err := ReadOrCreateCompactAssets(dir, true, true, false, false, kmsConfig)
+			if err != nil {
+				t.Fatalf("failed to read encrypted compact assets in %s : %v", dir, err)
+			}
+			if !encrypted.HasOidcCACert() || encrypted.OidcCACert == unencrypted.OidcCACert {
+				t.Errorf("expected the OIDC CA bundle to be encrypted but it wasn't: %s", encrypted.OidcCACert)
+			}
+			if _, err := os.Stat(oidcCACertPath + ".enc"); err != nil {
+				t.Errorf("expected the encrypted OIDC CA bundle to be cached but it wasn't: %v", err)
+			}
+		})
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/kubernetes-incubator/kube-aws/logger"
 	"github.com/kubernetes-incubator/kube-aws/netutil"
+	"github.com/kubernetes-incubator/kube-aws/pkg/api"
 	"github.com/kubernetes-incubator/kube-aws/pki"
 	"io/ioutil"
 	"net"
@@ -30,6 +31,8 @@ type GeneratorOptions struct {
 	CommonName string
 	// KIAM is set to true when you want kube-aws to render TLS assets for uswitch/kiam
 	KIAM bool
+	// FrontProxy is set to true when you want kube-aws to render the front-proxy CA and client certificate dedicated to the apiserver aggregation
+	FrontProxy bool
 	// Paths for private certificate keys.
 	AdminKeyPath                 string
 	ApiServerAggregatorKeyPath   string
@@ -88,13 +91,13 @@ func (c Generator) GenerateAssetsOnDisk(dir string, o GeneratorOptions) (*RawAss
 
 	logger.Info("--> Writing to the storage")
 	alsoWriteCAKey := o.GenerateCA || caKeyRequiredOnController
-	if err := assets.WriteToDir(dir, alsoWriteCAKey, o.KIAM); err != nil {
+	if err := assets.WriteToDir(dir, alsoWriteCAKey, o.KIAM, o.FrontProxy); err != nil {
 		return nil, fmt.Errorf("Error creating assets: %v", err)
 	}
 
 	{
 		logger.Info("--> Verifying the result")
-		verified, err := ReadRawAssets(dir, certsManagedByKubeAws, tlsBootstrappingEnabled, o.KIAM)
+		verified, err := ReadRawAssets(dir, certsManagedByKubeAws, tlsBootstrappingEnabled, o.KIAM, o.FrontProxy)
 
 		if err != nil {
 			return nil, fmt.Errorf("failed verifying the result: %v", err)
@@ -321,5 +324,31 @@ func (c Generator) GenerateAssetsOnMemory(caKey *rsa.PrivateKey, caCert *x509.Ce
 		r.KIAMServerKey = pki.EncodePrivateKeyPEM(privateKeys[generatorOptions.KiamServerKeyPath])
 	}
 
+	if generatorOptions.FrontProxy {
+		// The front-proxy CA must be distinct from the cluster CA, so that no client certificate signed by the cluster CA
+		// is accepted by aggregated apiservers as the trusted proxy passing the request headers
+		frontProxyCAKey, frontProxyCACert, err := pki.NewCA(c.TLSCADurationDays, "front-proxy-ca")
+		if err != nil {
+			return nil, fmt.Errorf("failed generating front-proxy CA: %v", err)
+		}
+		frontProxyClientKey, err := pki.NewPrivateKey()
+		if err != nil {
+			return nil, err
+		}
+		frontProxyClientConfig := pki.ClientCertConfig{
+			CommonName: api.FrontProxyClientCommonName,
+			Duration:   certDuration,
+		}
+		frontProxyClientCert, err := pki.NewSignedClientCertificate(frontProxyClientConfig, frontProxyClientKey, frontProxyCACert, frontProxyCAKey)
+		if err != nil {
+			return nil, err
+		}
+
+		r.FrontProxyCACert = pki.EncodeCertificatePEM(frontProxyCACert)
+		r.FrontProxyCAKey = pki.EncodePrivateKeyPEM(frontProxyCAKey)
+		r.FrontProxyClientCert = pki.EncodeCertificatePEM(frontProxyClientCert)
+		r.FrontProxyClientKey = pki.EncodePrivateKeyPEM(frontProxyClientKey)
+	}
+
 	return r, nil
 }
@@ -0,0 +1,355 @@
+package credential
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kubernetes-incubator/kube-aws/logger"
+	"github.com/kubernetes-incubator/kube-aws/pkg/api"
+	"github.com/kubernetes-incubator/kube-aws/pki"
+)
+
+const (
+	// nextCACertFile and nextCAKeyFile hold the CA that is trusted but not yet used for signing while the rotation of the CA is in progress
+	nextCACertFile = "ca-next.pem"
+	nextCAKeyFile  = "ca-next-key.pem"
+	// previousCACertFile holds the CA that is no longer used for signing but still trusted while the rotation of the CA is in progress
+	previousCACertFile = "ca-previous.pem"
+)
+
+// CARotationPhase is the step of the CA rotation completed by CertificateRotator.RotateCA.
+// Each step must be rolled out to all the nodes by `kube-aws apply` before proceeding to the next one,
+// so that every node trusts the CA that signed the certificates presented by its peers all the time.
+type CARotationPhase int
+
+const (
+	// CARotationStarted means that the new CA is trusted alongside the current CA, which still signs all the certificates
+	CARotationStarted CARotationPhase = iota + 1
+	// CARotationSwitched means that all the certificates are re-issued by the new CA, while the previous CA is still trusted
+	CARotationSwitched
+	// CARotationCompleted means that the previous CA is no longer trusted
+	CARotationCompleted
+)
+
+func (p CARotationPhase) String() string {
+	switch p {
+	case CARotationStarted:
+		return "the new CA is trusted alongside the current CA"
+	case CARotationSwitched:
+		return "the certificates are re-issued by the new CA, while the previous CA is still trusted"
+	case CARotationCompleted:
+		return "the previous CA is no longer trusted"
+	}
+	return "unknown"
+}
+
+// CertificateRotator re-issues the TLS certificates in the credentials directory rendered by `kube-aws render credentials`.
+// The private keys, the service account key, the TLS bootstrap token and the encryption config are kept as they are.
+type CertificateRotator struct {
+	Generator
+	Dir string
+}
+
+func (r CertificateRotator) path(name string) string {
+	return filepath.Join(r.Dir, name)
+}
+
+func (r CertificateRotator) exists(name string) bool {
+	_, err := os.Stat(r.path(name))
+	return err == nil
+}
+
+func (r CertificateRotator) write(name string, data []byte) error {
+	logger.Infof("Writing %d bytes to %s\n", len(data), r.path(name))
+	return ioutil.WriteFile(r.path(name), data, 0600)
+}
+
+func (r CertificateRotator) readCAKey(name string) (*rsa.PrivateKey, error) {
+	b, err := ioutil.ReadFile(r.path(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed reading ca key file %s, which is required to rotate certificates: %v", r.path(name), err)
+	}
+	key, err := pki.DecodePrivateKeyPEM(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing ca key %s: %v", r.path(name), err)
+	}
+	return key, nil
+}
+
+// signingCA returns the CA key in ca-key.pem and the certificate for it in the ca.pem bundle
+func (r CertificateRotator) signingCA() (*rsa.PrivateKey, *x509.Certificate, error) {
+	key, err := r.readCAKey("ca-key.pem")
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := ioutil.ReadFile(r.path("ca.pem"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed reading ca cert file %s: %v", r.path("ca.pem"), err)
+	}
+	certs, err := pki.DecodeCertificatesPEM(b)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed parsing ca cert %s: %v", r.path("ca.pem"), err)
+	}
+	for _, c := range certs {
+		if pub, ok := c.PublicKey.(*rsa.PublicKey); ok && pub.N.Cmp(key.N) == 0 && pub.E == key.E {
+			return key, c, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("none of the certificates in %s matches the ca key in %s", r.path("ca.pem"), r.path("ca-key.pem"))
+}
+
+// RotateLeafCertificates re-issues all the certificates signed by the CA in ca-key.pem with the existing private keys,
+// so that they become valid for another TLSCertDurationDays
+func (r CertificateRotator) RotateLeafCertificates() error {
+	caKey, caCert, err := r.signingCA()
+	if err != nil {
+		return err
+	}
+	return r.rotateLeafCertificates(caKey, caCert)
+}
+
+func (r CertificateRotator) rotateLeafCertificates(caKey *rsa.PrivateKey, caCert *x509.Certificate) error {
+	kiam := r.exists("kiam-agent-key.pem") && r.exists("kiam-server-key.pem")
+
+	opts := GeneratorOptions{
+		KIAM:                         kiam,
+		AdminKeyPath:                 r.path("admin-key.pem"),
+		ApiServerAggregatorKeyPath:   r.path("apiserver-aggregator-key.pem"),
+		ApiServerKeyPath:             r.path("apiserver-key.pem"),
+		EtcdClientKeyPath:            r.path("etcd-client-key.pem"),
+		EtcdKeyPath:                  r.path("etcd-key.pem"),
+		KubeControllerManagerKeyPath: r.path("kube-controller-manager-key.pem"),
+		KubeSchedulerKeyPath:         r.path("kube-scheduler-key.pem"),
+		ServiceAccountKeyPath:        r.path("service-account-key.pem"),
+		WorkerKeyPath:                r.path("worker-key.pem"),
+	}
+	if kiam {
+		opts.KiamAgentKeyPath = r.path("kiam-agent-key.pem")
+		opts.KiamServerKeyPath = r.path("kiam-server-key.pem")
+	}
+
+	logger.Info("-> Re-issuing certificates with the existing keys")
+	assets, err := r.Generator.GenerateAssetsOnMemory(caKey, caCert, opts)
+	if err != nil {
+		return fmt.Errorf("failed re-issuing certificates: %v", err)
+	}
+
+	type certFile struct {
+		name string
+		data []byte
+	}
+	certs := []certFile{
+		{"apiserver.pem", assets.APIServerCert},
+		{"kube-controller-manager.pem", assets.KubeControllerManagerCert},
+		{"kube-scheduler.pem", assets.KubeSchedulerCert},
+		{"worker.pem", assets.WorkerCert},
+		{"admin.pem", assets.AdminCert},
+		{"etcd.pem", assets.EtcdCert},
+		{"etcd-client.pem", assets.EtcdClientCert},
+		{"apiserver-aggregator.pem", assets.APIServerAggregatorCert},
+	}
+	if kiam {
+		certs = append(certs,
+			certFile{"kiam-agent.pem", assets.KIAMAgentCert},
+			certFile{"kiam-server.pem", assets.KIAMServerCert},
+		)
+	}
+	for _, c := range certs {
+		if err := r.write(c.name, c.data); err != nil {
+			return err
+		}
+	}
+
+	if r.exists("front-proxy-ca-key.pem") {
+		if err := r.rotateFrontProxyClientCertificate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rotateFrontProxyClientCertificate re-issues the front-proxy client certificate with the front-proxy CA, which is distinct from the cluster CA
+func (r CertificateRotator) rotateFrontProxyClientCertificate() error {
+	caKey, err := r.readCAKey("front-proxy-ca-key.pem")
+	if err != nil {
+		return err
+	}
+	caCertBytes, err := ioutil.ReadFile(r.path("front-proxy-ca.pem"))
+	if err != nil {
+		return fmt.Errorf("failed reading front-proxy ca cert: %v", err)
+	}
+	caCert, err := pki.DecodeCertificatePEM(caCertBytes)
+	if err != nil {
+		return fmt.Errorf("failed parsing front-proxy ca cert: %v", err)
+	}
+	keyBytes, err := ioutil.ReadFile(r.path("front-proxy-client-key.pem"))
+	if err != nil {
+		return fmt.Errorf("failed reading front-proxy client key: %v", err)
+	}
+	key, err := pki.DecodePrivateKeyPEM(keyBytes)
+	if err != nil {
+		return fmt.Errorf("failed parsing front-proxy client key: %v", err)
+	}
+	cert, err := pki.NewSignedClientCertificate(pki.ClientCertConfig{
+		CommonName: api.FrontProxyClientCommonName,
+		Duration:   time.Duration(r.TLSCertDurationDays) * 24 * time.Hour,
+	}, key, caCert, caKey)
+	if err != nil {
+		return err
+	}
+	return r.write("front-proxy-client.pem", pki.EncodeCertificatePEM(cert))
+}
+
+// RotateCA advances the rotation of the cluster CA by one phase and returns the phase it completed:
+//
+//  1. Generates the new CA and appends it to the trust bundle in ca.pem
+//  2. Signs all the certificates with the new CA, while keeping the previous CA in the trust bundle
+//  3. Removes the previous CA from the trust bundle
+//
+// The state of the rotation is kept in the credentials directory, so that each phase can be rolled out by `kube-aws apply` before the next one.
+func (r CertificateRotator) RotateCA() (CARotationPhase, error) {
+	switch {
+	case r.exists(previousCACertFile):
+		return CARotationCompleted, r.completeCARotation()
+	case r.exists(nextCACertFile):
+		return CARotationSwitched, r.switchCA()
+	default:
+		return CARotationStarted, r.startCARotation()
+	}
+}
+
+func (r CertificateRotator) startCARotation() error {
+	caKey, caCert, err := r.signingCA()
+	if err != nil {
+		return err
+	}
+	logger.Info("-> Generating the new CA")
+	nextKey, nextCert, err := pki.NewCA(r.TLSCADurationDays, caCert.Subject.CommonName)
+	if err != nil {
+		return fmt.Errorf("failed generating the new CA: %v", err)
+	}
+	if err := r.write(nextCAKeyFile, pki.EncodePrivateKeyPEM(nextKey)); err != nil {
+		return err
+	}
+	if err := r.write(nextCACertFile, pki.EncodeCertificatePEM(nextCert)); err != nil {
+		return err
+	}
+	// The CA signing the certificates comes first in the bundle
+	if err := r.updateTrustBundle(caCert, nextCert); err != nil {
+		return err
+	}
+	return r.updateSigningCA(caKey, caCert, caKey, caCert)
+}
+
+func (r CertificateRotator) switchCA() error {
+	prevKey, prevCert, err := r.signingCA()
+	if err != nil {
+		return err
+	}
+	nextKey, err := r.readCAKey(nextCAKeyFile)
+	if err != nil {
+		return err
+	}
+	nextCertBytes, err := ioutil.ReadFile(r.path(nextCACertFile))
+	if err != nil {
+		return fmt.Errorf("failed reading the new ca cert: %v", err)
+	}
+	nextCert, err := pki.DecodeCertificatePEM(nextCertBytes)
+	if err != nil {
+		return fmt.Errorf("failed parsing the new ca cert: %v", err)
+	}
+
+	if err := r.rotateLeafCertificates(nextKey, nextCert); err != nil {
+		return err
+	}
+	if err := r.updateTrustBundle(nextCert, prevCert); err != nil {
+		return err
+	}
+	if err := r.updateSigningCA(prevKey, prevCert, nextKey, nextCert); err != nil {
+		return err
+	}
+	if err := r.write(previousCACertFile, pki.EncodeCertificatePEM(prevCert)); err != nil {
+		return err
+	}
+	for _, f := range []string{nextCACertFile, nextCAKeyFile} {
+		if err := os.Remove(r.path(f)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r CertificateRotator) completeCARotation() error {
+	_, caCert, err := r.signingCA()
+	if err != nil {
+		return err
+	}
+	if err := r.updateTrustBundle(caCert); err != nil {
+		return err
+	}
+	return os.Remove(r.path(previousCACertFile))
+}
+
+// updateTrustBundle rewrites ca.pem along with etcd-trusted-ca.pem and kiam-ca.pem unless they are distinct CAs managed outside of kube-aws
+func (r CertificateRotator) updateTrustBundle(certs ...*x509.Certificate) error {
+	current, err := ioutil.ReadFile(r.path("ca.pem"))
+	if err != nil {
+		return err
+	}
+	bundle := []byte{}
+	for _, c := range certs {
+		bundle = append(bundle, pki.EncodeCertificatePEM(c)...)
+	}
+	for _, f := range []string{"etcd-trusted-ca.pem", "kiam-ca.pem"} {
+		if err := r.replaceCopy(f, current, bundle); err != nil {
+			return err
+		}
+	}
+	return r.write("ca.pem", bundle)
+}
+
+// updateSigningCA rewrites the CA used for signing in ca-key.pem, and in worker-ca.pem and worker-ca-key.pem for kube-controller-manager signing kubelet certificates.
+// worker-ca.pem is replaced with a file containing the single certificate when it is a symlink to the bundle in ca.pem, as kube-controller-manager can't sign with a bundle.
+func (r CertificateRotator) updateSigningCA(prevKey *rsa.PrivateKey, prevCert *x509.Certificate, key *rsa.PrivateKey, cert *x509.Certificate) error {
+	if err := r.replaceCopy("worker-ca-key.pem", pki.EncodePrivateKeyPEM(prevKey), pki.EncodePrivateKeyPEM(key)); err != nil {
+		return err
+	}
+	if info, err := os.Lstat(r.path("worker-ca.pem")); err == nil && info.Mode()&os.ModeSymlink == os.ModeSymlink {
+		if err := os.Remove(r.path("worker-ca.pem")); err != nil {
+			return err
+		}
+		if err := r.write("worker-ca.pem", pki.EncodeCertificatePEM(cert)); err != nil {
+			return err
+		}
+	} else if err := r.replaceCopy("worker-ca.pem", pki.EncodeCertificatePEM(prevCert), pki.EncodeCertificatePEM(cert)); err != nil {
+		return err
+	}
+	if prevKey == key {
+		return nil
+	}
+	return r.write("ca-key.pem", pki.EncodePrivateKeyPEM(key))
+}
+
+// replaceCopy writes the data to the regular file only when it had the same content as the file being replaced.
+// Symlinks are left as they are, as they follow the file they point to.
+func (r CertificateRotator) replaceCopy(name string, prev, data []byte) error {
+	info, err := os.Lstat(r.path(name))
+	if err != nil || info.Mode()&os.ModeSymlink == os.ModeSymlink {
+		return nil
+	}
+	current, err := ioutil.ReadFile(r.path(name))
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(current, prev) {
+		return nil
+	}
+	return r.write(name, data)
+}
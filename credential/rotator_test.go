@@ -0,0 +1,162 @@
+package credential
+
+import (
+	"bytes"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubernetes-incubator/kube-aws/pki"
+)
+
+func newTestCertificateRotator(t *testing.T) (CertificateRotator, func()) {
+	dir, err := ioutil.TempDir("", "rotator-test")
+	if err != nil {
+		t.Fatalf("failed creating a temporary directory: %v", err)
+	}
+	g := Generator{
+		TLSCADurationDays:   3650,
+		TLSCertDurationDays: 365,
+		TLSBootstrapEnabled: true,
+		ManageCertificates:  true,
+		Region:              "us-west-1",
+		ServiceCIDR:         "10.3.0.0/24",
+	}
+	if _, err := g.GenerateAssetsOnDisk(dir, GeneratorOptions{GenerateCA: true, CommonName: "kube-ca", FrontProxy: true}); err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("failed generating credentials: %v", err)
+	}
+	return CertificateRotator{Generator: g, Dir: dir}, func() { os.RemoveAll(dir) }
+}
+
+func readTestFile(t *testing.T, dir, name string) []byte {
+	b, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("failed reading %s: %v", name, err)
+	}
+	return b
+}
+
+func verifyTestCertificate(t *testing.T, dir, name, caName string) error {
+	cert, err := pki.DecodeCertificatePEM(readTestFile(t, dir, name))
+	if err != nil {
+		t.Fatalf("failed parsing %s: %v", name, err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(readTestFile(t, dir, caName))
+	_, err = cert.Verify(x509.VerifyOptions{Roots: pool, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+	return err
+}
+
+func countTestCertificates(t *testing.T, dir, name string) int {
+	certs, err := pki.DecodeCertificatesPEM(readTestFile(t, dir, name))
+	if err != nil {
+		t.Fatalf("failed parsing %s: %v", name, err)
+	}
+	return len(certs)
+}
+
+func TestRotateLeafCertificates(t *testing.T) {
+	r, cleanup := newTestCertificateRotator(t)
+	defer cleanup()
+
+	preserved := []string{"ca.pem", "ca-key.pem", "apiserver-key.pem", "kubelet-tls-bootstrap-token", "encryption-config.yaml", "front-proxy-ca.pem", "front-proxy-client-key.pem"}
+	before := map[string][]byte{}
+	for _, f := range append(preserved, "apiserver.pem", "admin.pem", "front-proxy-client.pem") {
+		before[f] = readTestFile(t, r.Dir, f)
+	}
+
+	if err := r.RotateLeafCertificates(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, f := range preserved {
+		if !bytes.Equal(before[f], readTestFile(t, r.Dir, f)) {
+			t.Errorf("expected %s to be kept as it is, but it was changed", f)
+		}
+	}
+	for _, f := range []string{"apiserver.pem", "admin.pem", "front-proxy-client.pem"} {
+		if bytes.Equal(before[f], readTestFile(t, r.Dir, f)) {
+			t.Errorf("expected %s to be re-issued, but it was not changed", f)
+		}
+	}
+	if err := verifyTestCertificate(t, r.Dir, "apiserver.pem", "ca.pem"); err != nil {
+		t.Errorf("expected the re-issued apiserver.pem to be signed by the CA: %v", err)
+	}
+	if err := verifyTestCertificate(t, r.Dir, "front-proxy-client.pem", "front-proxy-ca.pem"); err != nil {
+		t.Errorf("expected the re-issued front-proxy-client.pem to be signed by the front-proxy CA: %v", err)
+	}
+	if _, err := ReadRawAssets(r.Dir, true, true, false, true); err != nil {
+		t.Errorf("expected the rotated credentials to be readable: %v", err)
+	}
+}
+
+func TestRotateCA(t *testing.T) {
+	r, cleanup := newTestCertificateRotator(t)
+	defer cleanup()
+
+	oldCA := readTestFile(t, r.Dir, "ca.pem")
+	oldAPIServerCert := readTestFile(t, r.Dir, "apiserver.pem")
+
+	// Phase 1: the new CA is trusted alongside the current CA
+	if phase, err := r.RotateCA(); err != nil || phase != CARotationStarted {
+		t.Fatalf("expected the CA rotation to be started, but got phase %d: %v", phase, err)
+	}
+	if n := countTestCertificates(t, r.Dir, "ca.pem"); n != 2 {
+		t.Errorf("expected ca.pem to be a bundle of the current and the new CAs, but it contained %d certificates", n)
+	}
+	if !bytes.HasPrefix(readTestFile(t, r.Dir, "ca.pem"), oldCA) {
+		t.Errorf("expected the current CA to come first in ca.pem while it still signs certificates")
+	}
+	if !bytes.Equal(oldAPIServerCert, readTestFile(t, r.Dir, "apiserver.pem")) {
+		t.Errorf("expected apiserver.pem not to be re-issued until the new CA is trusted by all the nodes")
+	}
+	if info, err := os.Lstat(filepath.Join(r.Dir, "worker-ca.pem")); err != nil || info.Mode()&os.ModeSymlink != 0 || countTestCertificates(t, r.Dir, "worker-ca.pem") != 1 {
+		t.Errorf("expected worker-ca.pem to be replaced with the single CA certificate kube-controller-manager signs with")
+	}
+	if n := countTestCertificates(t, r.Dir, "etcd-trusted-ca.pem"); n != 2 {
+		t.Errorf("expected etcd-trusted-ca.pem to keep following ca.pem, but it contained %d certificates", n)
+	}
+
+	// Phase 2: the certificates are re-issued by the new CA
+	newCA := readTestFile(t, r.Dir, nextCACertFile)
+	if phase, err := r.RotateCA(); err != nil || phase != CARotationSwitched {
+		t.Fatalf("expected the CA to be switched, but got phase %d: %v", phase, err)
+	}
+	if !bytes.HasPrefix(readTestFile(t, r.Dir, "ca.pem"), newCA) || countTestCertificates(t, r.Dir, "ca.pem") != 2 {
+		t.Errorf("expected ca.pem to be a bundle of the new CA followed by the previous CA")
+	}
+	if !bytes.Equal(newCA, readTestFile(t, r.Dir, "worker-ca.pem")) {
+		t.Errorf("expected worker-ca.pem to be the new CA")
+	}
+	if err := verifyTestCertificate(t, r.Dir, "apiserver.pem", previousCACertFile); err == nil {
+		t.Errorf("expected apiserver.pem to be re-issued by the new CA, but it was signed by the previous CA")
+	}
+	if err := verifyTestCertificate(t, r.Dir, "apiserver.pem", "ca.pem"); err != nil {
+		t.Errorf("expected apiserver.pem to be trusted by the bundle: %v", err)
+	}
+	if r.exists(nextCACertFile) || r.exists(nextCAKeyFile) {
+		t.Errorf("expected the new CA to be removed from the rotation state once it signs certificates")
+	}
+
+	// Phase 3: the previous CA is no longer trusted
+	if phase, err := r.RotateCA(); err != nil || phase != CARotationCompleted {
+		t.Fatalf("expected the CA rotation to be completed, but got phase %d: %v", phase, err)
+	}
+	if !bytes.Equal(newCA, readTestFile(t, r.Dir, "ca.pem")) {
+		t.Errorf("expected ca.pem to contain only the new CA")
+	}
+	if r.exists(previousCACertFile) {
+		t.Errorf("expected the previous CA to be removed from the rotation state")
+	}
+	if _, err := ReadRawAssets(r.Dir, true, true, false, true); err != nil {
+		t.Errorf("expected the rotated credentials to be readable: %v", err)
+	}
+
+	// Starts another rotation
+	if phase, err := r.RotateCA(); err != nil || phase != CARotationStarted {
+		t.Errorf("expected another CA rotation to be started, but got phase %d: %v", phase, err)
+	}
+}
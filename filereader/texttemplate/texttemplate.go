@@ -12,6 +12,8 @@ import (
 	"github.com/Masterminds/semver"
 	"github.com/Masterminds/sprig"
 	"github.com/kubernetes-incubator/kube-aws/fingerprint"
+	"github.com/kubernetes-incubator/kube-aws/gzipcompressor"
+	"github.com/kubernetes-incubator/kube-aws/logger"
 	"github.com/kubernetes-incubator/kube-aws/tmpl"
 )
 
@@ -24,6 +26,12 @@ func ParseFile(filename string, funcs template.FuncMap) (*template.Template, err
 	return Parse(filename, string(raw), funcs)
 }
 
+// The size limit of EC2 user-data, and the size from which kube-aws warns that user-data is approaching the limit
+const (
+	userDataMaxSize     = 16384
+	userDataWarningSize = 14336
+)
+
 var funcs2 = template.FuncMap{
 	"checkSizeLessThan": func(size int, content string) (string, error) {
 		if len(content) >= size {
@@ -31,6 +39,18 @@ var funcs2 = template.FuncMap{
 		}
 		return content, nil
 	},
+	"checkUserDataSize": func(role string, content string) (string, error) {
+		if len(content) >= userDataMaxSize {
+			return "", fmt.Errorf("the user-data of %s nodes is %d bytes, which exceeds the maximum size %d of EC2 user-data. "+
+				"Set `instanceScript.compression` to `gzip` to compress the instance script, or `instanceScript.storage` to `s3` to store it in S3 instead", role, len(content), userDataMaxSize)
+		}
+		if len(content) >= userDataWarningSize {
+			logger.Warnf("the user-data of %s nodes is %d bytes, which is approaching the maximum size %d of EC2 user-data. "+
+				"Consider setting `instanceScript.compression` to `gzip` to compress the instance script, or `instanceScript.storage` to `s3` to store it in S3 instead", role, len(content), userDataMaxSize)
+		}
+		return content, nil
+	},
+	"gzipBase64": gzipcompressor.StringToGzippedBase64String,
 	"toJSON": func(v interface{}) (string, error) {
 		data, err := json.Marshal(v)
 		return string(data), err
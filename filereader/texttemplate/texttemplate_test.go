@@ -1,6 +1,7 @@
 package texttemplate
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -8,6 +9,8 @@ import (
 
 var tolabelFunc = funcs2["toLabel"].(func(string) string)
 
+var checkUserDataSizeFunc = funcs2["checkUserDataSize"].(func(string, string) (string, error))
+
 func TestCIDRToLabel(t *testing.T) {
 
 	data := "192.168.0.0/16"
@@ -23,3 +26,13 @@ func TestMultipleSymbolsToReplace(t *testing.T) {
 
 	assert.Equal(t, "https___kubernetes.io_docs_admin_authorization_rbac__referring-to-subjects", label)
 }
+
+func TestCheckUserDataSize(t *testing.T) {
+	small := strings.Repeat("a", userDataWarningSize)
+	content, err := checkUserDataSizeFunc("worker", small)
+	assert.NoError(t, err)
+	assert.Equal(t, small, content)
+
+	_, err = checkUserDataSizeFunc("worker", strings.Repeat("a", userDataMaxSize))
+	assert.Error(t, err)
+}
@@ -1,19 +1,48 @@
 package api
 
 type Addons struct {
-	Rescheduler         Rescheduler              `yaml:"rescheduler"`
-	ClusterAutoscaler   ClusterAutoscalerSupport `yaml:"clusterAutoscaler,omitempty"`
-	MetricsServer       MetricsServer            `yaml:"metricsServer,omitempty"`
-	Prometheus          Prometheus               `yaml:"prometheus"`
-	APIServerAggregator APIServerAggregator      `yaml:"apiserverAggregator"`
-	UnknownKeys         `yaml:",inline"`
+	Rescheduler               Rescheduler               `yaml:"rescheduler"`
+	ClusterAutoscaler         ClusterAutoscalerSupport  `yaml:"clusterAutoscaler,omitempty"`
+	MetricsServer             MetricsServer             `yaml:"metricsServer,omitempty"`
+	Prometheus                Prometheus                `yaml:"prometheus"`
+	APIServerAggregator       APIServerAggregator       `yaml:"apiserverAggregator"`
+	AWSLoadBalancerController AWSLoadBalancerController `yaml:"awsLoadBalancerController,omitempty"`
+	EFSCSIDriver              EFSCSIDriver              `yaml:"efsCsiDriver,omitempty"`
+	UnknownKeys               `yaml:",inline"`
 }
 
 type ClusterAutoscalerSupport struct {
 	Enabled          bool              `yaml:"enabled"`
 	Options          map[string]string `yaml:"options"`
 	ComputeResources ComputeResources  `yaml:"resources"`
-	UnknownKeys      `yaml:",inline"`
+	// Expander is the strategy cluster-autoscaler selects the node pool to scale out with, passed to `--expander`.
+	// Either `random`, `most-pods`, `least-waste` or `priority`. Defaults to `least-waste`
+	Expander string `yaml:"expander,omitempty"`
+	// SafeToEvictAddons overrides the `cluster-autoscaler.kubernetes.io/safe-to-evict` annotation of the pods of kube-aws add-ons,
+	// keyed by the add-on name like `kube-dns`. `false` prevents cluster-autoscaler from scaling down the nodes running the pods
+	SafeToEvictAddons map[string]bool `yaml:"safeToEvict,omitempty"`
+	UnknownKeys       `yaml:",inline"`
+
+	// disabledExplicitly is true when `enabled: false` is specified, which node pools scaled by cluster-autoscaler never override
+	disabledExplicitly bool
+}
+
+func (c *ClusterAutoscalerSupport) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type t ClusterAutoscalerSupport
+	work := t(*c)
+	if err := unmarshal(&work); err != nil {
+		return err
+	}
+	*c = ClusterAutoscalerSupport(work)
+
+	var specified struct {
+		Enabled *bool `yaml:"enabled"`
+	}
+	if err := unmarshal(&specified); err != nil {
+		return err
+	}
+	c.disabledExplicitly = specified.Enabled != nil && !*specified.Enabled
+	return nil
 }
 
 type Rescheduler struct {
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// FrontProxyClientCommonName is the common name of the client certificate kube-aws generates for the apiserver to proxy requests to aggregated apiservers
+	FrontProxyClientCommonName = "front-proxy-client"
+)
+
+// Aggregation is the set of settings for the request header authentication between the apiserver and aggregated apiservers like metrics-server.
+// When enabled, kube-aws generates a front-proxy CA dedicated to the request header authentication and a client certificate signed by it,
+// so that no client certificate signed by the cluster CA is able to impersonate users against aggregated apiservers
+type Aggregation struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// AllowedNames is the list of common names of the client certificates allowed to pass the request headers. Defaults to `front-proxy-client`
+	AllowedNames []string `yaml:"allowedNames,omitempty"`
+	// ExtraHeadersPrefixes defaults to `X-Remote-Extra-`
+	ExtraHeadersPrefixes []string `yaml:"extraHeadersPrefixes,omitempty"`
+	// GroupHeaders defaults to `X-Remote-Group`
+	GroupHeaders []string `yaml:"groupHeaders,omitempty"`
+	// UsernameHeaders defaults to `X-Remote-User`
+	UsernameHeaders []string `yaml:"usernameHeaders,omitempty"`
+}
+
+func (a Aggregation) RequestHeaderAllowedNames() string {
+	return joinOrDefault(a.AllowedNames, FrontProxyClientCommonName)
+}
+
+func (a Aggregation) RequestHeaderExtraHeadersPrefix() string {
+	return joinOrDefault(a.ExtraHeadersPrefixes, "X-Remote-Extra-")
+}
+
+func (a Aggregation) RequestHeaderGroupHeaders() string {
+	return joinOrDefault(a.GroupHeaders, "X-Remote-Group")
+}
+
+func (a Aggregation) RequestHeaderUsernameHeaders() string {
+	return joinOrDefault(a.UsernameHeaders, "X-Remote-User")
+}
+
+func joinOrDefault(values []string, defaultValue string) string {
+	if len(values) == 0 {
+		return defaultValue
+	}
+	return strings.Join(values, ",")
+}
+
+func (a Aggregation) Validate() error {
+	if !a.Enabled {
+		if len(a.AllowedNames) > 0 || len(a.ExtraHeadersPrefixes) > 0 || len(a.GroupHeaders) > 0 || len(a.UsernameHeaders) > 0 {
+			return errors.New("`controller.aggregation.enabled` must be true to customize the request header authentication")
+		}
+		return nil
+	}
+
+	settings := []struct {
+		key    string
+		values []string
+	}{
+		{"allowedNames", a.AllowedNames},
+		{"extraHeadersPrefixes", a.ExtraHeadersPrefixes},
+		{"groupHeaders", a.GroupHeaders},
+		{"usernameHeaders", a.UsernameHeaders},
+	}
+	for _, s := range settings {
+		for _, v := range s.values {
+			if v == "" || strings.ContainsAny(v, ", \t") {
+				return fmt.Errorf("invalid `controller.aggregation.%s`: \"%s\" must be non-empty and must not contain commas or whitespaces", s.key, v)
+			}
+		}
+	}
+
+	return nil
+}
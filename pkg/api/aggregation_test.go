@@ -0,0 +1,96 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestAggregationValidate(t *testing.T) {
+	testCases := []struct {
+		aggregation Aggregation
+		isValid     bool
+	}{
+		// Valid, not configured
+		{
+			aggregation: Aggregation{},
+			isValid:     true,
+		},
+		// Valid, defaults
+		{
+			aggregation: Aggregation{Enabled: true},
+			isValid:     true,
+		},
+		// Valid, customized
+		{
+			aggregation: Aggregation{
+				Enabled:              true,
+				AllowedNames:         []string{"front-proxy-client", "my-proxy"},
+				ExtraHeadersPrefixes: []string{"X-Remote-Extra-", "X-Custom-Extra-"},
+				GroupHeaders:         []string{"X-Remote-Group"},
+				UsernameHeaders:      []string{"X-Remote-User"},
+			},
+			isValid: true,
+		},
+		// Invalid, customized while disabled
+		{
+			aggregation: Aggregation{AllowedNames: []string{"my-proxy"}},
+			isValid:     false,
+		},
+		// Invalid, empty header
+		{
+			aggregation: Aggregation{Enabled: true, GroupHeaders: []string{""}},
+			isValid:     false,
+		},
+		// Invalid, comma separated values in a single item
+		{
+			aggregation: Aggregation{Enabled: true, UsernameHeaders: []string{"X-Remote-User,X-User"}},
+			isValid:     false,
+		},
+		// Invalid, whitespace
+		{
+			aggregation: Aggregation{Enabled: true, AllowedNames: []string{"my proxy"}},
+			isValid:     false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.aggregation.Validate()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.aggregation, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but it was not", i, testCase.aggregation)
+		}
+	}
+}
+
+func TestAggregationRequestHeaderFlags(t *testing.T) {
+	defaults := Aggregation{Enabled: true}
+	customized := Aggregation{
+		Enabled:              true,
+		AllowedNames:         []string{"front-proxy-client", "my-proxy"},
+		ExtraHeadersPrefixes: []string{"X-Custom-Extra-"},
+		GroupHeaders:         []string{"X-Custom-Group", "X-Remote-Group"},
+		UsernameHeaders:      []string{"X-Custom-User"},
+	}
+
+	testCases := []struct {
+		name     string
+		actual   string
+		expected string
+	}{
+		{"default allowed names", defaults.RequestHeaderAllowedNames(), "front-proxy-client"},
+		{"default extra headers prefix", defaults.RequestHeaderExtraHeadersPrefix(), "X-Remote-Extra-"},
+		{"default group headers", defaults.RequestHeaderGroupHeaders(), "X-Remote-Group"},
+		{"default username headers", defaults.RequestHeaderUsernameHeaders(), "X-Remote-User"},
+		{"customized allowed names", customized.RequestHeaderAllowedNames(), "front-proxy-client,my-proxy"},
+		{"customized extra headers prefix", customized.RequestHeaderExtraHeadersPrefix(), "X-Custom-Extra-"},
+		{"customized group headers", customized.RequestHeaderGroupHeaders(), "X-Custom-Group,X-Remote-Group"},
+		{"customized username headers", customized.RequestHeaderUsernameHeaders(), "X-Custom-User"},
+	}
+
+	for _, testCase := range testCases {
+		if testCase.actual != testCase.expected {
+			t.Errorf("%s: expected \"%s\" but was \"%s\"", testCase.name, testCase.expected, testCase.actual)
+		}
+	}
+}
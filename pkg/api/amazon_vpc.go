@@ -1,13 +1,112 @@
 package api
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"strings"
+
 	"github.com/aws/amazon-vpc-cni-k8s/pkg/awsutils"
+	"github.com/kubernetes-incubator/kube-aws/netutil"
 	"github.com/kubernetes-incubator/kube-aws/provisioner"
 )
 
+// ENIConfigAnnotation is the node annotation read by the VPC CNI to select the ENIConfig used for pod ENIs
+const ENIConfigAnnotation = "k8s.amazonaws.com/eniConfig"
+
 type AmazonVPC struct {
 	Enabled bool `yaml:"enabled"`
+	// SecondaryCIDR is a secondary CIDR block of the VPC from which pods get their IPs via the CNI custom networking.
+	// kube-aws associates it with the VPC only when the VPC is managed by kube-aws
+	SecondaryCIDR string `yaml:"secondaryCIDR,omitempty"`
+	// PodSubnets are the subnets created in the secondary CIDR block, one per availability zone
+	PodSubnets []AmazonVPCPodSubnet `yaml:"podSubnets,omitempty"`
+}
+
+type AmazonVPCPodSubnet struct {
+	AvailabilityZone string `yaml:"availabilityZone"`
+	InstanceCIDR     string `yaml:"instanceCIDR"`
+}
+
+// LogicalName is the name of the subnet resource in the network stack, e.g. PodSubnetUsWest1a for us-west-1a
+func (s AmazonVPCPodSubnet) LogicalName() string {
+	return "PodSubnet" + strings.Replace(strings.Title(s.AvailabilityZone), "-", "", -1)
+}
+
+// ENIConfigName is the name of the ENIConfig for the subnet.
+// Every node is annotated with the ENIConfig named after its availability zone
+func (s AmazonVPCPodSubnet) ENIConfigName() string {
+	return s.AvailabilityZone
+}
+
+func (a AmazonVPC) ENIConfigAnnotation() string {
+	return ENIConfigAnnotation
+}
+
+func (a AmazonVPC) CustomNetworkingEnabled() bool {
+	return a.Enabled && a.SecondaryCIDR != ""
+}
+
+func (a AmazonVPC) Validate(vpcNet, podNet, serviceNet *net.IPNet, availabilityZones []string) error {
+	if a.SecondaryCIDR == "" {
+		if len(a.PodSubnets) > 0 {
+			return errors.New("`kubernetes.networking.amazonVPC.podSubnets` requires `kubernetes.networking.amazonVPC.secondaryCIDR` to be set")
+		}
+		return nil
+	}
+
+	if !a.Enabled {
+		return errors.New("`kubernetes.networking.amazonVPC.secondaryCIDR` requires `kubernetes.networking.amazonVPC.enabled` to be true")
+	}
+
+	_, secondaryNet, err := net.ParseCIDR(a.SecondaryCIDR)
+	if err != nil {
+		return fmt.Errorf("invalid `kubernetes.networking.amazonVPC.secondaryCIDR`: %v", err)
+	}
+	if netutil.CidrOverlap(secondaryNet, vpcNet) {
+		return fmt.Errorf("secondaryCIDR (%s) overlaps with vpcCIDR (%s)", secondaryNet, vpcNet)
+	}
+	if netutil.CidrOverlap(secondaryNet, podNet) {
+		return fmt.Errorf("secondaryCIDR (%s) overlaps with podCIDR (%s)", secondaryNet, podNet)
+	}
+	if netutil.CidrOverlap(secondaryNet, serviceNet) {
+		return fmt.Errorf("secondaryCIDR (%s) overlaps with serviceCIDR (%s)", secondaryNet, serviceNet)
+	}
+
+	secondaryOnes, _ := secondaryNet.Mask.Size()
+	podSubnetNets := make([]*net.IPNet, len(a.PodSubnets))
+	podSubnetAZs := map[string]bool{}
+	for i, s := range a.PodSubnets {
+		if s.AvailabilityZone == "" {
+			return fmt.Errorf("availabilityZone must be set for pod subnet #%d", i)
+		}
+		if podSubnetAZs[s.AvailabilityZone] {
+			return fmt.Errorf("only one pod subnet can be defined per availability zone, but there were two or more for %s", s.AvailabilityZone)
+		}
+		podSubnetAZs[s.AvailabilityZone] = true
+
+		_, instanceNet, err := net.ParseCIDR(s.InstanceCIDR)
+		if err != nil {
+			return fmt.Errorf("invalid instanceCIDR for pod subnet #%d: %v", i, err)
+		}
+		if ones, _ := instanceNet.Mask.Size(); ones < secondaryOnes || !secondaryNet.Contains(instanceNet.IP) {
+			return fmt.Errorf("secondaryCIDR (%s) does not contain instanceCIDR (%s) for pod subnet #%d", secondaryNet, instanceNet, i)
+		}
+		for j := 0; j < i; j++ {
+			if netutil.CidrOverlap(podSubnetNets[j], instanceNet) {
+				return fmt.Errorf("CIDR of pod subnet %d (%s) overlaps with CIDR of pod subnet %d (%s)", j, podSubnetNets[j], i, instanceNet)
+			}
+		}
+		podSubnetNets[i] = instanceNet
+	}
+
+	for _, az := range availabilityZones {
+		if !podSubnetAZs[az] {
+			return fmt.Errorf("`kubernetes.networking.amazonVPC.podSubnets` must contain a pod subnet for every availability zone nodes are launched in, but there was none for %s", az)
+		}
+	}
+
+	return nil
 }
 
 func (a AmazonVPC) MaxPodsScript() provisioner.Content {
@@ -50,8 +149,18 @@ if [ "" == "$ips_per_eni" ]; then
   exit 1
 fi
 
+`
+	// With custom networking, pods get IPs from the ENIs in the pod subnets alone, as the primary ENI stays in the subnet of the node
+	if a.CustomNetworkingEnabled() {
+		script = script + `
+max_pods=$(( ((enis - 1) * (ips_per_eni - 1)) + 2 ))
+`
+	} else {
+		script = script + `
 max_pods=$(( (enis * (ips_per_eni - 1)) + 2 ))
-
+`
+	}
+	script = script + `
 printf $max_pods
 `
 	return provisioner.NewBinaryContent([]byte(script))
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestAmazonVPCValidate(t *testing.T) {
+	_, vpcNet, _ := net.ParseCIDR("10.0.0.0/16")
+	_, podNet, _ := net.ParseCIDR("10.2.0.0/16")
+	_, serviceNet, _ := net.ParseCIDR("10.3.0.0/24")
+	azs := []string{"us-west-1a", "us-west-1b"}
+
+	testCases := []struct {
+		amazonVPC AmazonVPC
+		isValid   bool
+	}{
+		// Valid, without custom networking
+		{
+			amazonVPC: AmazonVPC{Enabled: true},
+			isValid:   true,
+		},
+		// Valid, a pod subnet per availability zone
+		{
+			amazonVPC: AmazonVPC{
+				Enabled:       true,
+				SecondaryCIDR: "100.64.0.0/16",
+				PodSubnets: []AmazonVPCPodSubnet{
+					{AvailabilityZone: "us-west-1a", InstanceCIDR: "100.64.0.0/17"},
+					{AvailabilityZone: "us-west-1b", InstanceCIDR: "100.64.128.0/17"},
+				},
+			},
+			isValid: true,
+		},
+		// Invalid, the VPC CNI is disabled
+		{
+			amazonVPC: AmazonVPC{
+				SecondaryCIDR: "100.64.0.0/16",
+				PodSubnets: []AmazonVPCPodSubnet{
+					{AvailabilityZone: "us-west-1a", InstanceCIDR: "100.64.0.0/17"},
+					{AvailabilityZone: "us-west-1b", InstanceCIDR: "100.64.128.0/17"},
+				},
+			},
+			isValid: false,
+		},
+		// Invalid, pod subnets without the secondary CIDR
+		{
+			amazonVPC: AmazonVPC{
+				Enabled: true,
+				PodSubnets: []AmazonVPCPodSubnet{
+					{AvailabilityZone: "us-west-1a", InstanceCIDR: "100.64.0.0/17"},
+				},
+			},
+			isValid: false,
+		},
+		// Invalid, overlaps with the vpc CIDR
+		{
+			amazonVPC: AmazonVPC{
+				Enabled:       true,
+				SecondaryCIDR: "10.0.0.0/8",
+				PodSubnets: []AmazonVPCPodSubnet{
+					{AvailabilityZone: "us-west-1a", InstanceCIDR: "10.128.0.0/17"},
+					{AvailabilityZone: "us-west-1b", InstanceCIDR: "10.128.128.0/17"},
+				},
+			},
+			isValid: false,
+		},
+		// Invalid, overlaps with the pod CIDR
+		{
+			amazonVPC: AmazonVPC{
+				Enabled:       true,
+				SecondaryCIDR: "10.2.0.0/16",
+				PodSubnets: []AmazonVPCPodSubnet{
+					{AvailabilityZone: "us-west-1a", InstanceCIDR: "10.2.0.0/17"},
+					{AvailabilityZone: "us-west-1b", InstanceCIDR: "10.2.128.0/17"},
+				},
+			},
+			isValid: false,
+		},
+		// Invalid, a pod subnet outside of the secondary CIDR
+		{
+			amazonVPC: AmazonVPC{
+				Enabled:       true,
+				SecondaryCIDR: "100.64.0.0/17",
+				PodSubnets: []AmazonVPCPodSubnet{
+					{AvailabilityZone: "us-west-1a", InstanceCIDR: "100.64.0.0/16"},
+					{AvailabilityZone: "us-west-1b", InstanceCIDR: "100.64.128.0/17"},
+				},
+			},
+			isValid: false,
+		},
+		// Invalid, overlapping pod subnets
+		{
+			amazonVPC: AmazonVPC{
+				Enabled:       true,
+				SecondaryCIDR: "100.64.0.0/16",
+				PodSubnets: []AmazonVPCPodSubnet{
+					{AvailabilityZone: "us-west-1a", InstanceCIDR: "100.64.0.0/17"},
+					{AvailabilityZone: "us-west-1b", InstanceCIDR: "100.64.0.0/18"},
+				},
+			},
+			isValid: false,
+		},
+		// Invalid, two pod subnets in the same availability zone
+		{
+			amazonVPC: AmazonVPC{
+				Enabled:       true,
+				SecondaryCIDR: "100.64.0.0/16",
+				PodSubnets: []AmazonVPCPodSubnet{
+					{AvailabilityZone: "us-west-1a", InstanceCIDR: "100.64.0.0/17"},
+					{AvailabilityZone: "us-west-1a", InstanceCIDR: "100.64.128.0/17"},
+				},
+			},
+			isValid: false,
+		},
+		// Invalid, no pod subnet for us-west-1b
+		{
+			amazonVPC: AmazonVPC{
+				Enabled:       true,
+				SecondaryCIDR: "100.64.0.0/16",
+				PodSubnets: []AmazonVPCPodSubnet{
+					{AvailabilityZone: "us-west-1a", InstanceCIDR: "100.64.0.0/17"},
+				},
+			},
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.amazonVPC.Validate(vpcNet, podNet, serviceNet, azs)
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.amazonVPC, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.amazonVPC)
+		}
+	}
+}
+
+func TestAmazonVPCPodSubnetLogicalName(t *testing.T) {
+	s := AmazonVPCPodSubnet{AvailabilityZone: "us-west-1a"}
+	if n := s.LogicalName(); n != "PodSubnetUsWest1a" {
+		t.Errorf("unexpected logical name: %s", n)
+	}
+}
+
+func TestAmazonVPCMaxPodsScript(t *testing.T) {
+	testCases := []struct {
+		amazonVPC AmazonVPC
+		expected  string
+	}{
+		{
+			amazonVPC: AmazonVPC{Enabled: true},
+			expected:  "max_pods=$(( (enis * (ips_per_eni - 1)) + 2 ))",
+		},
+		// The primary ENI hosts no pods with custom networking
+		{
+			amazonVPC: AmazonVPC{Enabled: true, SecondaryCIDR: "100.64.0.0/16"},
+			expected:  "max_pods=$(( ((enis - 1) * (ips_per_eni - 1)) + 2 ))",
+		},
+	}
+
+	for i, testCase := range testCases {
+		script := testCase.amazonVPC.MaxPodsScript().String()
+		if !strings.Contains(script, testCase.expected) {
+			t.Errorf("case %d: expected the max pods script to contain %s, but it didn't: %s", i, testCase.expected, script)
+		}
+		if c := strings.Count(script, "max_pods=$(("); c != 1 {
+			t.Errorf("case %d: expected max pods to be computed once, but was computed %d times", i, c)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+)
+
+// maxSSMParameterNameLength is the maximum length of a fully qualified SSM parameter name
+const maxSSMParameterNameLength = 2048
+
+// validateAMISource returns an error when the AMI is given by both `amiId` and `amiSsmParameter`, or `amiSsmParameter` isn't a valid SSM parameter name
+func validateAMISource(amiID, ssmParameter string) error {
+	if ssmParameter == "" {
+		return nil
+	}
+	if amiID != "" {
+		return errors.New("`amiId` and `amiSsmParameter` can't be specified at once")
+	}
+	if len(ssmParameter) > maxSSMParameterNameLength || !ssmParameterNamePattern.MatchString(ssmParameter) {
+		return fmt.Errorf("invalid `amiSsmParameter` \"%s\": it must be the name of an SSM parameter like /aws/service/eks/optimized-ami/1.14/amazon-linux-2/recommended/image_id", ssmParameter)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateAMISource(t *testing.T) {
+	testCases := []struct {
+		amiID        string
+		ssmParameter string
+		isValid      bool
+	}{
+		// Valid, not configured
+		{isValid: true},
+		// Valid, AMI ID
+		{amiID: "ami-12345678", isValid: true},
+		// Valid, SSM parameter
+		{ssmParameter: "/aws/service/eks/optimized-ami/1.14/amazon-linux-2/recommended/image_id", isValid: true},
+		// Invalid, both
+		{amiID: "ami-12345678", ssmParameter: "/aws/service/eks/optimized-ami/1.14/amazon-linux-2/recommended/image_id", isValid: false},
+		// Invalid, malformed name
+		{ssmParameter: "/aws/service/my ami", isValid: false},
+		// Invalid, too long name
+		{ssmParameter: "/" + strings.Repeat("a", 2048), isValid: false},
+	}
+
+	for i, testCase := range testCases {
+		err := validateAMISource(testCase.amiID, testCase.ssmParameter)
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase)
+		}
+	}
+}
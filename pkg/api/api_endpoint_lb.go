@@ -8,6 +8,13 @@ import (
 // DefaultRecordSetTTL is the default value for the loadBalancer.recordSetTTL key
 const DefaultRecordSetTTL = 300
 
+const (
+	// DefaultELBIdleTimeout is the idle timeout in seconds of managed classic ELBs, long enough for watch requests from kubectl
+	DefaultELBIdleTimeout = 3600
+	// DefaultNLBIdleTimeout is the AWS default idle timeout in seconds of the TCP listeners of network load balancers
+	DefaultNLBIdleTimeout = 350
+)
+
 // APIEndpointLB is a set of an ELB and relevant settings and resources to serve a Kubernetes API hosted by controller nodes
 type APIEndpointLB struct {
 	// APIAccessAllowedSourceCIDRs is network ranges of sources you'd like Kubernetes API accesses to be allowed from, in CIDR notation
@@ -33,6 +40,11 @@ type APIEndpointLB struct {
 	SecurityGroupIds []string `yaml:"securityGroupIds"`
 	// Load balancer type. It is 'classic' by default, but can be changed to 'network'
 	Type *string `yaml:"type,omitempty"`
+	// HealthCheck is the health check of the classic ELB pinging controller nodes
+	HealthCheck ELBHealthCheck `yaml:"healthCheck,omitempty"`
+	// IdleTimeout is the number of seconds the load balancer keeps connections without any data sent or received open for.
+	// Defaults to 3600 for classic ELBs and to 350, which is the AWS default, for network load balancers
+	IdleTimeout int `yaml:"idleTimeout,omitempty"`
 }
 
 // UnmarshalYAML unmarshals YAML data to an APIEndpointLB object with defaults
@@ -90,6 +102,14 @@ func (e APIEndpointLB) Validate() error {
 			return errors.New("type, private, subnets, hostedZone must be omitted when id is specified to reuse an existing ELB")
 		}
 
+		if !e.HealthCheck.IsEmpty() {
+			return errors.New("healthCheck must be omitted when id is specified to reuse an existing ELB")
+		}
+
+		if e.IdleTimeout != 0 {
+			return errors.New("idleTimeout must be omitted when id is specified to reuse an existing ELB")
+		}
+
 		return nil
 	}
 
@@ -106,6 +126,14 @@ func (e APIEndpointLB) Validate() error {
 			return errors.New("type should not be specified when an API endpoint LB is not managed by kube-aws")
 		}
 
+		if !e.HealthCheck.IsEmpty() {
+			return errors.New("healthCheck should not be specified when an API endpoint LB is not managed by kube-aws")
+		}
+
+		if e.IdleTimeout != 0 {
+			return errors.New("idleTimeout should not be specified when an API endpoint LB is not managed by kube-aws")
+		}
+
 		return nil
 	}
 
@@ -141,11 +169,56 @@ func (e APIEndpointLB) Validate() error {
 		if len(e.SecurityGroupIds) > 0 {
 			return errors.New("cannot specify security group IDs for a network load balancer")
 		}
+		if !e.HealthCheck.IsEmpty() {
+			return errors.New("healthCheck can only be specified for a classic load balancer")
+		}
+	}
+
+	if err := e.HealthCheck.Validate(); err != nil {
+		return err
+	}
+
+	if err := e.validateIdleTimeout(); err != nil {
+		return err
 	}
 
 	return nil
 }
 
+// IdleTimeoutOrDefault returns the idle timeout of the load balancer in seconds
+func (e APIEndpointLB) IdleTimeoutOrDefault() int {
+	switch {
+	case e.IdleTimeout != 0:
+		return e.IdleTimeout
+	case e.NetworkLoadBalancer():
+		return DefaultNLBIdleTimeout
+	}
+	return DefaultELBIdleTimeout
+}
+
+// See https://docs.aws.amazon.com/elasticloadbalancing/latest/classic/config-idle-timeout.html
+// and https://docs.aws.amazon.com/elasticloadbalancing/latest/network/update-idle-timeout.html for the ranges
+func (e APIEndpointLB) validateIdleTimeout() error {
+	if e.IdleTimeout == 0 {
+		return nil
+	}
+	min, max := 1, 4000
+	if e.NetworkLoadBalancer() {
+		min, max = 60, 6000
+	}
+	if e.IdleTimeout < min || e.IdleTimeout > max {
+		return fmt.Errorf("idleTimeout (%d) must be in range %d-%d seconds for the %s load balancer", e.IdleTimeout, min, max, e.typeName())
+	}
+	return nil
+}
+
+func (e APIEndpointLB) typeName() string {
+	if e.Type == nil {
+		return "classic"
+	}
+	return *e.Type
+}
+
 func (e APIEndpointLB) managedELBImplied() bool {
 	return len(e.SubnetReferences) > 0 ||
 		e.explicitlyPrivate() ||
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestAPIEndpointLBIdleTimeout(t *testing.T) {
+	classic := "classic"
+	network := "network"
+
+	testCases := []struct {
+		lb       APIEndpointLB
+		expected int
+		isValid  bool
+	}{
+		// Valid, the default of classic ELBs
+		{
+			lb:       APIEndpointLB{},
+			expected: 3600,
+			isValid:  true,
+		},
+		// Valid, the default of NLBs
+		{
+			lb:       APIEndpointLB{Type: &network},
+			expected: 350,
+			isValid:  true,
+		},
+		// Valid, the maximum of classic ELBs
+		{
+			lb:       APIEndpointLB{Type: &classic, IdleTimeout: 4000},
+			expected: 4000,
+			isValid:  true,
+		},
+		// Valid, the maximum of NLBs
+		{
+			lb:       APIEndpointLB{Type: &network, IdleTimeout: 6000},
+			expected: 6000,
+			isValid:  true,
+		},
+		// Invalid, longer than classic ELBs support
+		{
+			lb:       APIEndpointLB{IdleTimeout: 6000},
+			expected: 6000,
+			isValid:  false,
+		},
+		// Invalid, shorter than NLBs support
+		{
+			lb:       APIEndpointLB{Type: &network, IdleTimeout: 30},
+			expected: 30,
+			isValid:  false,
+		},
+		// Invalid, negative
+		{
+			lb:       APIEndpointLB{IdleTimeout: -1},
+			expected: -1,
+			isValid:  false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		if actual := testCase.lb.IdleTimeoutOrDefault(); actual != testCase.expected {
+			t.Errorf("case %d: expected %d but was %d", i, testCase.expected, actual)
+		}
+		err := testCase.lb.validateIdleTimeout()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but was not: %v", i, testCase.lb, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.lb)
+		}
+	}
+}
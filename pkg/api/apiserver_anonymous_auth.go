@@ -0,0 +1,90 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kubernetes-incubator/kube-aws/logger"
+)
+
+const (
+	// APIServerAnonymousAuthDisabled rejects every anonymous request via the apiserver's `--anonymous-auth=false`
+	APIServerAnonymousAuthDisabled = "disabled"
+	// APIServerAnonymousAuthHealthEndpoints allows anonymous requests only to the health endpoints, so that load balancers can observe the health of apiservers
+	APIServerAnonymousAuthHealthEndpoints = "healthEndpoints"
+)
+
+// APIServerReadinessHealthCheckTarget is the default target of the ELB health checks when the health endpoints are served to anonymous requests
+const APIServerReadinessHealthCheckTarget = "HTTPS:443/readyz"
+
+// apiServerHealthEndpoints are the paths anonymous requests are allowed to when `controller.apiServer.anonymousAuth` is `healthEndpoints`
+var apiServerHealthEndpoints = []string{"/healthz", "/livez", "/readyz"}
+
+// AnonymousHealthEndpointsEnabled returns true when anonymous requests are allowed to the health endpoints of the apiserver
+func (s ControllerAPIServer) AnonymousHealthEndpointsEnabled() bool {
+	return s.AnonymousAuth == APIServerAnonymousAuthHealthEndpoints
+}
+
+// AnonymousHealthEndpoints returns the paths anonymous requests are allowed to
+func (s ControllerAPIServer) AnonymousHealthEndpoints() []string {
+	return apiServerHealthEndpoints
+}
+
+// AuthenticationConfigAPIVersion returns the apiVersion of the AuthenticationConfiguration supported by the specified version of Kubernetes
+func (s ControllerAPIServer) AuthenticationConfigAPIVersion(k8sVer string) (string, error) {
+	v1, err := k8sVersionSatisfies(">= 1.34", k8sVer)
+	if err != nil {
+		return "", err
+	}
+	if v1 {
+		return "apiserver.config.k8s.io/v1", nil
+	}
+	return "apiserver.config.k8s.io/v1beta1", nil
+}
+
+func (s ControllerAPIServer) validateAnonymousAuth() error {
+	switch s.AnonymousAuth {
+	case "", APIServerAnonymousAuthDisabled, APIServerAnonymousAuthHealthEndpoints:
+		return nil
+	default:
+		return fmt.Errorf("invalid `controller.apiServer.anonymousAuth` \"%s\": it must be either \"%s\" or \"%s\"",
+			s.AnonymousAuth, APIServerAnonymousAuthDisabled, APIServerAnonymousAuthHealthEndpoints)
+	}
+}
+
+// defaultHealthCheckTargetsToReadiness lets the ELBs observe the readiness of apiservers unless their health check targets are customized,
+// when the apiservers serve their health endpoints to the anonymous health checks
+func (c *Cluster) defaultHealthCheckTargetsToReadiness() {
+	if !c.Controller.APIServer.AnonymousHealthEndpointsEnabled() {
+		return
+	}
+	for i, e := range c.APIEndpointConfigs {
+		if e.LoadBalancer.ClassicLoadBalancer() && e.LoadBalancer.HealthCheck.Target == "" {
+			c.APIEndpointConfigs[i].LoadBalancer.HealthCheck.Target = APIServerReadinessHealthCheckTarget
+		}
+	}
+}
+
+func (c Cluster) validateAnonymousAuth() error {
+	if c.Controller.APIServer.AnonymousHealthEndpointsEnabled() && c.Experimental.Oidc.Enabled {
+		return fmt.Errorf("`controller.apiServer.anonymousAuth: %s` can't be used with `experimental.oidc`, as the apiserver rejects the authentication config file along with the oidc flags", APIServerAnonymousAuthHealthEndpoints)
+	}
+	return nil
+}
+
+// warnHealthChecksRejectedByAnonymousAuth warns about the ELB health checks sending HTTP requests, which are anonymous and
+// therefore rejected with 401 unless the health endpoints are served to anonymous requests
+func (c Cluster) warnHealthChecksRejectedByAnonymousAuth() {
+	if c.Controller.APIServer.AnonymousHealthEndpointsEnabled() {
+		return
+	}
+	for _, e := range c.APIEndpointConfigs {
+		if !e.LoadBalancer.ManageELB() || !e.LoadBalancer.ClassicLoadBalancer() {
+			continue
+		}
+		if target := e.LoadBalancer.HealthCheck.TargetOrDefault(); strings.HasPrefix(target, "HTTP:") || strings.HasPrefix(target, "HTTPS:") {
+			logger.Warnf("the health check target \"%s\" of the API endpoint \"%s\" is going to be rejected with 401 by apiservers disabling anonymous auth, which marks every controller node unhealthy. "+
+				"Please consider setting `controller.apiServer.anonymousAuth` to \"%s\", or `healthCheck.target` to \"%s\"", target, e.Name, APIServerAnonymousAuthHealthEndpoints, DefaultELBHealthCheckTarget)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const defaultAPIServerCertificateReloadInterval = 5 * time.Minute
+
+// APIServerCertificateReload makes controller nodes fetch the serving certificate and the client CAs of the apiserver from S3 on boot and
+// periodically afterwards, instead of embedding them into the userdata. The files are updated in place and reloaded by the apiserver
+// without restarting, so that rotating them doesn't replace controller nodes
+type APIServerCertificateReload struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// Interval is how often controller nodes fetch the files like `5m`. Defaults to `5m`
+	Interval string `yaml:"interval,omitempty"`
+}
+
+func (r APIServerCertificateReload) IntervalOrDefault() time.Duration {
+	if d, err := time.ParseDuration(r.Interval); err == nil && r.Interval != "" {
+		return d
+	}
+	return defaultAPIServerCertificateReloadInterval
+}
+
+// IntervalSeconds returns the interval in seconds for the systemd timer fetching the files
+func (r APIServerCertificateReload) IntervalSeconds() int {
+	return int(r.IntervalOrDefault() / time.Second)
+}
+
+func (c Cluster) validateAPIServerCertificateReload() error {
+	r := c.Controller.APIServer.CertificateReload
+	if !r.Enabled {
+		if r.Interval != "" {
+			return errors.New("`controller.apiServer.certificateReload.interval` requires `controller.apiServer.certificateReload.enabled` to be true")
+		}
+		return nil
+	}
+
+	if !c.ManageCertificates {
+		return errors.New("`controller.apiServer.certificateReload` requires `manageCertificates` to be true, as kube-aws uploads the certificates in `credentials/` for controller nodes to fetch")
+	}
+	// The apiserver reloads the serving certificate and the client CA file on a change since 1.17
+	supported, err := k8sVersionSatisfies(">= 1.17", c.K8sVer)
+	if err != nil {
+		return err
+	}
+	if !supported {
+		return fmt.Errorf("`controller.apiServer.certificateReload` requires kubernetesVersion 1.17 or greater, but was %s", c.K8sVer)
+	}
+	if r.Interval != "" {
+		d, err := time.ParseDuration(r.Interval)
+		if err != nil {
+			return fmt.Errorf("invalid `controller.apiServer.certificateReload.interval` \"%s\": %v", r.Interval, err)
+		}
+		if d < time.Minute {
+			return fmt.Errorf("`controller.apiServer.certificateReload.interval` must be 1m or longer, but was \"%s\"", r.Interval)
+		}
+	}
+	return nil
+}
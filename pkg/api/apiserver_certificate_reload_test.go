@@ -0,0 +1,79 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestValidateAPIServerCertificateReload(t *testing.T) {
+	cluster := func(k8sVer string, r APIServerCertificateReload) Cluster {
+		c := Cluster{}
+		c.K8sVer = k8sVer
+		c.ManageCertificates = true
+		c.Controller.APIServer.CertificateReload = r
+		return c
+	}
+
+	testCases := []struct {
+		cluster Cluster
+		isValid bool
+	}{
+		// Valid, not configured
+		{
+			cluster: Cluster{},
+			isValid: true,
+		},
+		// Valid, the defaults
+		{
+			cluster: cluster("v1.17.0", APIServerCertificateReload{Enabled: true}),
+			isValid: true,
+		},
+		// Valid, the interval
+		{
+			cluster: cluster("v1.20.2", APIServerCertificateReload{Enabled: true, Interval: "1h"}),
+			isValid: true,
+		},
+		// Invalid, the interval without enabled
+		{
+			cluster: cluster("v1.20.2", APIServerCertificateReload{Interval: "10m"}),
+			isValid: false,
+		},
+		// Invalid, kubernetes older than 1.17
+		{
+			cluster: cluster("v1.16.8", APIServerCertificateReload{Enabled: true}),
+			isValid: false,
+		},
+		// Invalid, malformed interval
+		{
+			cluster: cluster("v1.20.2", APIServerCertificateReload{Enabled: true, Interval: "10"}),
+			isValid: false,
+		},
+		// Invalid, too short interval
+		{
+			cluster: cluster("v1.20.2", APIServerCertificateReload{Enabled: true, Interval: "59s"}),
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.cluster.validateAPIServerCertificateReload()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.cluster.Controller.APIServer.CertificateReload, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.cluster.Controller.APIServer.CertificateReload)
+		}
+	}
+
+	unmanaged := cluster("v1.20.2", APIServerCertificateReload{Enabled: true})
+	unmanaged.ManageCertificates = false
+	if err := unmanaged.validateAPIServerCertificateReload(); err == nil {
+		t.Error("expected the certificate reload without `manageCertificates` to be invalid but was not")
+	}
+
+	if s := (APIServerCertificateReload{Enabled: true, Interval: "10m"}).IntervalSeconds(); s != 600 {
+		t.Errorf("expected the interval to be 600 seconds but was %d", s)
+	}
+	if s := (APIServerCertificateReload{Enabled: true}).IntervalSeconds(); s != 300 {
+		t.Errorf("expected the default interval to be 300 seconds but was %d", s)
+	}
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/Masterminds/semver"
+)
+
+const (
+	EgressProxyProtocolDirect      = "Direct"
+	EgressProxyProtocolHTTPConnect = "HTTPConnect"
+	EgressProxyProtocolGRPC        = "GRPC"
+)
+
+// APIServerEgressSelector configures how the apiserver routes its egress traffic, e.g. to admission/conversion webhooks and aggregated apiservers,
+// via the EgressSelectorConfiguration passed to the apiserver via `--egress-selector-config-file`
+type APIServerEgressSelector struct {
+	Selections []EgressSelection `yaml:"selections,omitempty"`
+}
+
+// EgressSelection is the set of settings for one type of the apiserver egress traffic
+type EgressSelection struct {
+	// Name is one of `cluster`(webhooks, aggregated apiservers, nodes and pods), `controlplane`, `master`(the former name of `controlplane`) or `etcd`
+	Name string `yaml:"name,omitempty"`
+	// ProxyProtocol is one of `Direct`, `HTTPConnect` or `GRPC`
+	ProxyProtocol string `yaml:"proxyProtocol,omitempty"`
+	// URL is the URL of the proxy connected over TCP. Either `url` or `udsName` is required unless the proxy protocol is `Direct`
+	URL string `yaml:"url,omitempty"`
+	// UDSName is the path to the unix domain socket of the proxy. `GRPC` is supported only over a unix domain socket
+	UDSName string             `yaml:"udsName,omitempty"`
+	TLS     EgressSelectionTLS `yaml:"tls,omitempty"`
+}
+
+// EgressSelectionTLS is the paths to the files on controller nodes used for connecting the proxy over HTTPS
+type EgressSelectionTLS struct {
+	CABundle   string `yaml:"caBundle,omitempty"`
+	ClientCert string `yaml:"clientCert,omitempty"`
+	ClientKey  string `yaml:"clientKey,omitempty"`
+}
+
+var supportedEgressSelectionNames = map[string]bool{
+	"cluster":      true,
+	"controlplane": true,
+	"master":       true,
+	"etcd":         true,
+}
+
+func (s APIServerEgressSelector) Enabled() bool {
+	return len(s.Selections) > 0
+}
+
+// APIVersion returns the apiVersion of EgressSelectorConfiguration supported by the specified version of Kubernetes
+func (s APIServerEgressSelector) APIVersion(k8sVer string) (string, error) {
+	v1beta1, err := k8sVersionSatisfies(">= 1.20", k8sVer)
+	if err != nil {
+		return "", err
+	}
+	if v1beta1 {
+		return "apiserver.k8s.io/v1beta1", nil
+	}
+	return "apiserver.k8s.io/v1alpha1", nil
+}
+
+// k8sVersionSatisfies returns true when the version of Kubernetes satisfies the constraint like ">= 1.16"
+func k8sVersionSatisfies(constraint string, k8sVer string) (bool, error) {
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return false, fmt.Errorf("[bug] invalid version constraint %s: %v", constraint, err)
+	}
+	v, err := semver.NewVersion(k8sVer)
+	if err != nil {
+		return false, fmt.Errorf("kubernetesVersion must be a valid version: %v", err)
+	}
+	return c.Check(v), nil
+}
+
+func (s APIServerEgressSelector) Validate(k8sVer string) error {
+	if !s.Enabled() {
+		return nil
+	}
+
+	// The `transport` and `proxyProtocol` of the selections rendered into EgressSelectorConfiguration replaced `connection` in 1.18
+	supported, err := k8sVersionSatisfies(">= 1.18", k8sVer)
+	if err != nil {
+		return err
+	}
+	if !supported {
+		return fmt.Errorf("`kubernetes.apiserver.egressSelector` requires kubernetesVersion 1.18 or greater, but was %s", k8sVer)
+	}
+
+	names := map[string]bool{}
+	for i, sel := range s.Selections {
+		if err := sel.Validate(); err != nil {
+			return fmt.Errorf("invalid `kubernetes.apiserver.egressSelector.selections[%d]`: %v", i, err)
+		}
+		if names[sel.Name] {
+			return fmt.Errorf("invalid `kubernetes.apiserver.egressSelector.selections[%d]`: duplicate name \"%s\"", i, sel.Name)
+		}
+		names[sel.Name] = true
+	}
+
+	return nil
+}
+
+func (s EgressSelection) Validate() error {
+	if !supportedEgressSelectionNames[s.Name] {
+		return fmt.Errorf("`name` must be one of \"cluster\", \"controlplane\", \"master\" or \"etcd\", but was \"%s\"", s.Name)
+	}
+
+	switch s.ProxyProtocol {
+	case EgressProxyProtocolDirect:
+		if s.URL != "" || s.UDSName != "" {
+			return fmt.Errorf("`url` and `udsName` must be omitted for the proxy protocol \"%s\"", s.ProxyProtocol)
+		}
+		return nil
+	case EgressProxyProtocolHTTPConnect:
+		if (s.URL == "") == (s.UDSName == "") {
+			return fmt.Errorf("either `url` or `udsName` must be specified for the proxy protocol \"%s\"", s.ProxyProtocol)
+		}
+	case EgressProxyProtocolGRPC:
+		if s.UDSName == "" || s.URL != "" {
+			return fmt.Errorf("the proxy protocol \"%s\" is supported only over a unix domain socket. Specify `udsName` instead of `url`", s.ProxyProtocol)
+		}
+	default:
+		return fmt.Errorf("`proxyProtocol` must be one of \"%s\", \"%s\" or \"%s\", but was \"%s\"",
+			EgressProxyProtocolDirect, EgressProxyProtocolHTTPConnect, EgressProxyProtocolGRPC, s.ProxyProtocol)
+	}
+
+	if s.URL != "" {
+		u, err := url.Parse(s.URL)
+		if err != nil {
+			return fmt.Errorf("invalid `url`: %v", err)
+		}
+		if u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("`url` must be an http or https URL with a host, but was \"%s\"", s.URL)
+		}
+		if u.Scheme == "https" && s.TLS.CABundle == "" {
+			return errors.New("`tls.caBundle` must be specified for an https `url`")
+		}
+		if u.Scheme == "http" && !s.TLS.IsEmpty() {
+			return errors.New("`tls` can only be specified for an https `url`")
+		}
+	} else if !s.TLS.IsEmpty() {
+		return errors.New("`tls` can only be specified for an https `url`")
+	}
+
+	if (s.TLS.ClientCert == "") != (s.TLS.ClientKey == "") {
+		return errors.New("`tls.clientCert` and `tls.clientKey` must be specified together")
+	}
+
+	return nil
+}
+
+// UsesTCP returns true when the proxy is connected over TCP rather than a unix domain socket
+func (s EgressSelection) UsesTCP() bool {
+	return s.URL != ""
+}
+
+func (t EgressSelectionTLS) IsEmpty() bool {
+	return t.CABundle == "" && t.ClientCert == "" && t.ClientKey == ""
+}
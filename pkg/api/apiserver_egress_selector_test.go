@@ -0,0 +1,175 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestAPIServerEgressSelectorValidate(t *testing.T) {
+	testCases := []struct {
+		k8sVer     string
+		selections []EgressSelection
+		isValid    bool
+	}{
+		// Valid, disabled
+		{
+			k8sVer:  "v1.11.3",
+			isValid: true,
+		},
+		// Invalid, unsupported k8s version
+		{
+			k8sVer:     "v1.15.0",
+			selections: []EgressSelection{{Name: "cluster", ProxyProtocol: "Direct"}},
+			isValid:    false,
+		},
+		// Invalid, k8s version with the `connection` schema of EgressSelectorConfiguration
+		{
+			k8sVer:     "v1.17.3",
+			selections: []EgressSelection{{Name: "cluster", ProxyProtocol: "Direct"}},
+			isValid:    false,
+		},
+		// Valid, direct
+		{
+			k8sVer:     "v1.18.0",
+			selections: []EgressSelection{{Name: "etcd", ProxyProtocol: "Direct"}},
+			isValid:    true,
+		},
+		// Valid, HTTPConnect over https
+		{
+			k8sVer: "v1.20.2",
+			selections: []EgressSelection{{
+				Name:          "cluster",
+				ProxyProtocol: "HTTPConnect",
+				URL:           "https://proxy.example.com:8131",
+				TLS:           EgressSelectionTLS{CABundle: "/ca.pem", ClientCert: "/cert.pem", ClientKey: "/key.pem"},
+			}},
+			isValid: true,
+		},
+		// Valid, HTTPConnect over a unix domain socket
+		{
+			k8sVer:     "v1.20.2",
+			selections: []EgressSelection{{Name: "cluster", ProxyProtocol: "HTTPConnect", UDSName: "/proxy.sock"}},
+			isValid:    true,
+		},
+		// Valid, GRPC over a unix domain socket
+		{
+			k8sVer:     "v1.20.2",
+			selections: []EgressSelection{{Name: "controlplane", ProxyProtocol: "GRPC", UDSName: "/proxy.sock"}},
+			isValid:    true,
+		},
+		// Invalid, unknown name
+		{
+			k8sVer:     "v1.20.2",
+			selections: []EgressSelection{{Name: "webhooks", ProxyProtocol: "Direct"}},
+			isValid:    false,
+		},
+		// Invalid, duplicate names
+		{
+			k8sVer: "v1.20.2",
+			selections: []EgressSelection{
+				{Name: "cluster", ProxyProtocol: "Direct"},
+				{Name: "cluster", ProxyProtocol: "HTTPConnect", UDSName: "/proxy.sock"},
+			},
+			isValid: false,
+		},
+		// Invalid, unknown proxy protocol
+		{
+			k8sVer:     "v1.20.2",
+			selections: []EgressSelection{{Name: "cluster", ProxyProtocol: "SOCKS5", URL: "http://proxy.example.com"}},
+			isValid:    false,
+		},
+		// Invalid, direct with a proxy
+		{
+			k8sVer:     "v1.20.2",
+			selections: []EgressSelection{{Name: "cluster", ProxyProtocol: "Direct", URL: "http://proxy.example.com"}},
+			isValid:    false,
+		},
+		// Invalid, HTTPConnect without a proxy
+		{
+			k8sVer:     "v1.20.2",
+			selections: []EgressSelection{{Name: "cluster", ProxyProtocol: "HTTPConnect"}},
+			isValid:    false,
+		},
+		// Invalid, GRPC over TCP
+		{
+			k8sVer:     "v1.20.2",
+			selections: []EgressSelection{{Name: "cluster", ProxyProtocol: "GRPC", URL: "http://proxy.example.com"}},
+			isValid:    false,
+		},
+		// Invalid, malformed URL
+		{
+			k8sVer:     "v1.20.2",
+			selections: []EgressSelection{{Name: "cluster", ProxyProtocol: "HTTPConnect", URL: "proxy.example.com:8131"}},
+			isValid:    false,
+		},
+		// Invalid, https without a CA bundle
+		{
+			k8sVer:     "v1.20.2",
+			selections: []EgressSelection{{Name: "cluster", ProxyProtocol: "HTTPConnect", URL: "https://proxy.example.com"}},
+			isValid:    false,
+		},
+		// Invalid, TLS for http
+		{
+			k8sVer: "v1.20.2",
+			selections: []EgressSelection{{
+				Name:          "cluster",
+				ProxyProtocol: "HTTPConnect",
+				URL:           "http://proxy.example.com",
+				TLS:           EgressSelectionTLS{CABundle: "/ca.pem"},
+			}},
+			isValid: false,
+		},
+		// Invalid, client cert without a key
+		{
+			k8sVer: "v1.20.2",
+			selections: []EgressSelection{{
+				Name:          "cluster",
+				ProxyProtocol: "HTTPConnect",
+				URL:           "https://proxy.example.com",
+				TLS:           EgressSelectionTLS{CABundle: "/ca.pem", ClientCert: "/cert.pem"},
+			}},
+			isValid: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		s := APIServerEgressSelector{Selections: testCase.selections}
+		err := s.Validate(testCase.k8sVer)
+		if testCase.isValid && err != nil {
+			t.Errorf("Expected %+v to be valid for %s, but got error: %v", testCase.selections, testCase.k8sVer, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("Expected %+v to be invalid for %s, but it was valid", testCase.selections, testCase.k8sVer)
+		}
+	}
+}
+
+func TestAPIServerEgressSelectorAPIVersion(t *testing.T) {
+	testCases := []struct {
+		k8sVer     string
+		apiVersion string
+	}{
+		{
+			k8sVer:     "v1.18.0",
+			apiVersion: "apiserver.k8s.io/v1alpha1",
+		},
+		{
+			k8sVer:     "v1.19.7",
+			apiVersion: "apiserver.k8s.io/v1alpha1",
+		},
+		{
+			k8sVer:     "v1.20.2",
+			apiVersion: "apiserver.k8s.io/v1beta1",
+		},
+	}
+
+	for _, testCase := range testCases {
+		actual, err := APIServerEgressSelector{}.APIVersion(testCase.k8sVer)
+		if err != nil {
+			t.Errorf("Unexpected error for %s: %v", testCase.k8sVer, err)
+			continue
+		}
+		if actual != testCase.apiVersion {
+			t.Errorf("Expected apiVersion for %s to be %s, but was %s", testCase.k8sVer, testCase.apiVersion, actual)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-yaml/yaml"
+)
+
+// maxFlowSchemaMatchingPrecedence is the upper bound of FlowSchema's `matchingPrecedence`
+const maxFlowSchemaMatchingPrecedence = 10000
+
+// The mandatory objects are maintained by the apiserver, which overwrites any changes to them
+// See https://kubernetes.io/docs/concepts/cluster-administration/flow-control/#defaults
+var mandatoryPriorityLevelConfigurations = []string{"exempt", "catch-all"}
+var mandatoryFlowSchemas = []string{"exempt", "catch-all"}
+
+// The suggested objects are created by the apiserver and can be referenced by custom FlowSchemas
+var suggestedPriorityLevelConfigurations = []string{"leader-election", "node-high", "system", "workload-high", "workload-low", "global-default"}
+
+// APIServerPriorityAndFairness is the set of settings for API Priority and Fairness, which classifies requests to the apiserver
+// into priority levels and shares the concurrency of each level fairly among flows of requests, so that a few noisy clients
+// don't starve others
+// See https://kubernetes.io/docs/concepts/cluster-administration/flow-control/
+type APIServerPriorityAndFairness struct {
+	// Enabled is passed to the apiserver's `--enable-priority-and-fairness`. Defaults to the apiserver's default, which is true since 1.20
+	Enabled *bool `yaml:"enabled,omitempty"`
+	// MaxRequestsInflight and MaxMutatingRequestsInflight are passed to the apiserver's flags of the same names.
+	// Their sum is the total concurrency shared among priority levels while API Priority and Fairness is enabled
+	MaxRequestsInflight         int `yaml:"maxRequestsInflight,omitempty"`
+	MaxMutatingRequestsInflight int `yaml:"maxMutatingRequestsInflight,omitempty"`
+	// PriorityLevelConfigurations and FlowSchemas are the custom objects kube-aws deploys while bootstrapping controller nodes
+	PriorityLevelConfigurations []APFObject `yaml:"priorityLevelConfigurations,omitempty"`
+	FlowSchemas                 []APFObject `yaml:"flowSchemas,omitempty"`
+}
+
+// APFObject is a FlowSchema or a PriorityLevelConfiguration
+type APFObject struct {
+	Name string `yaml:"name"`
+	// Spec is rendered as is into the spec of the object
+	Spec map[string]interface{} `yaml:"spec"`
+}
+
+// APFEnabled returns true unless API Priority and Fairness is explicitly disabled
+func (p APIServerPriorityAndFairness) APFEnabled() bool {
+	return p.Enabled == nil || *p.Enabled
+}
+
+// HasObjects returns true when any custom FlowSchema or PriorityLevelConfiguration is specified
+func (p APIServerPriorityAndFairness) HasObjects() bool {
+	return len(p.PriorityLevelConfigurations) > 0 || len(p.FlowSchemas) > 0
+}
+
+// APIVersion returns the apiVersion of the flowcontrol API served by the specified version of Kubernetes
+func (p APIServerPriorityAndFairness) APIVersion(k8sVer string) (string, error) {
+	versions := []struct {
+		constraint string
+		apiVersion string
+	}{
+		{">= 1.29", "flowcontrol.apiserver.k8s.io/v1"},
+		{">= 1.26", "flowcontrol.apiserver.k8s.io/v1beta3"},
+		{">= 1.23", "flowcontrol.apiserver.k8s.io/v1beta2"},
+	}
+	for _, v := range versions {
+		ok, err := k8sVersionSatisfies(v.constraint, k8sVer)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return v.apiVersion, nil
+		}
+	}
+	return "flowcontrol.apiserver.k8s.io/v1beta1", nil
+}
+
+// Manifest returns the custom PriorityLevelConfigurations followed by the custom FlowSchemas as a multi-document YAML
+func (p APIServerPriorityAndFairness) Manifest(k8sVer string) (string, error) {
+	apiVersion, err := p.APIVersion(k8sVer)
+	if err != nil {
+		return "", err
+	}
+
+	docs := []string{}
+	render := func(kind string, objects []APFObject) error {
+		for _, o := range objects {
+			obj := map[string]interface{}{
+				"apiVersion": apiVersion,
+				"kind":       kind,
+				"metadata":   map[string]interface{}{"name": o.Name},
+				"spec":       normalizeYAMLValue(o.Spec),
+			}
+			b, err := yaml.Marshal(obj)
+			if err != nil {
+				return fmt.Errorf("failed to render %s \"%s\": %v", kind, o.Name, err)
+			}
+			docs = append(docs, string(b))
+		}
+		return nil
+	}
+	if err := render("PriorityLevelConfiguration", p.PriorityLevelConfigurations); err != nil {
+		return "", err
+	}
+	if err := render("FlowSchema", p.FlowSchemas); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(strings.Join(docs, "---\n"), "\n"), nil
+}
+
+func (p APIServerPriorityAndFairness) Validate() error {
+	if p.MaxRequestsInflight < 0 {
+		return fmt.Errorf("`controller.apiServer.priorityAndFairness.maxRequestsInflight` must be a positive integer, but was %d", p.MaxRequestsInflight)
+	}
+	if p.MaxMutatingRequestsInflight < 0 {
+		return fmt.Errorf("`controller.apiServer.priorityAndFairness.maxMutatingRequestsInflight` must be a positive integer, but was %d", p.MaxMutatingRequestsInflight)
+	}
+
+	if p.HasObjects() && !p.APFEnabled() {
+		return errors.New("`controller.apiServer.priorityAndFairness.enabled` must not be false to deploy custom FlowSchemas and PriorityLevelConfigurations")
+	}
+
+	levels := map[string]bool{}
+	for _, n := range append(mandatoryPriorityLevelConfigurations, suggestedPriorityLevelConfigurations...) {
+		levels[n] = true
+	}
+	names := map[string]bool{}
+	for i, o := range p.PriorityLevelConfigurations {
+		if err := o.validateName(names, mandatoryPriorityLevelConfigurations); err != nil {
+			return fmt.Errorf("invalid `controller.apiServer.priorityAndFairness.priorityLevelConfigurations[%d]`: %v", i, err)
+		}
+		if err := o.validatePriorityLevelConfigurationSpec(); err != nil {
+			return fmt.Errorf("invalid `controller.apiServer.priorityAndFairness.priorityLevelConfigurations[%d]`: %v", i, err)
+		}
+		levels[o.Name] = true
+	}
+
+	names = map[string]bool{}
+	for i, o := range p.FlowSchemas {
+		if err := o.validateName(names, mandatoryFlowSchemas); err != nil {
+			return fmt.Errorf("invalid `controller.apiServer.priorityAndFairness.flowSchemas[%d]`: %v", i, err)
+		}
+		if err := o.validateFlowSchemaSpec(levels); err != nil {
+			return fmt.Errorf("invalid `controller.apiServer.priorityAndFairness.flowSchemas[%d]`: %v", i, err)
+		}
+	}
+
+	return nil
+}
+
+// ValidateKubernetesVersion returns an error when API Priority and Fairness is configured for the apiserver of a kubernetes version
+// in which it isn't enabled by default
+func (p APIServerPriorityAndFairness) ValidateKubernetesVersion(k8sVer string) error {
+	if p.Enabled == nil && p.MaxRequestsInflight == 0 && p.MaxMutatingRequestsInflight == 0 && !p.HasObjects() {
+		return nil
+	}
+	supported, err := k8sVersionSatisfies(">= 1.20", k8sVer)
+	if err != nil {
+		return err
+	}
+	if !supported {
+		return fmt.Errorf("`controller.apiServer.priorityAndFairness` requires kubernetesVersion 1.20 or greater, but was %s", k8sVer)
+	}
+	return nil
+}
+
+func (o APFObject) validateName(names map[string]bool, mandatory []string) error {
+	if len(o.Name) > 253 || !kubernetesObjectNamePattern.MatchString(o.Name) {
+		return fmt.Errorf("`name` must be a valid DNS subdomain, but was \"%s\"", o.Name)
+	}
+	if containsString(mandatory, o.Name) {
+		return fmt.Errorf("\"%s\" is maintained by the apiserver and can not be customized", o.Name)
+	}
+	if names[o.Name] {
+		return fmt.Errorf("duplicate name \"%s\"", o.Name)
+	}
+	names[o.Name] = true
+	return nil
+}
+
+func (o APFObject) validatePriorityLevelConfigurationSpec() error {
+	if t, _ := o.Spec["type"].(string); t != "Limited" {
+		return fmt.Errorf("`spec.type` must be \"Limited\", but was \"%v\"", o.Spec["type"])
+	}
+	if _, ok := normalizeYAMLValue(o.Spec["limited"]).(map[string]interface{}); !ok {
+		return errors.New("`spec.limited` must be specified for the type \"Limited\"")
+	}
+	return nil
+}
+
+func (o APFObject) validateFlowSchemaSpec(levels map[string]bool) error {
+	level, _ := normalizeYAMLValue(o.Spec["priorityLevelConfiguration"]).(map[string]interface{})
+	name, _ := level["name"].(string)
+	if name == "" {
+		return errors.New("`spec.priorityLevelConfiguration.name` must be specified")
+	}
+	if !levels[name] {
+		return fmt.Errorf("`spec.priorityLevelConfiguration.name` refers to the unknown PriorityLevelConfiguration \"%s\"", name)
+	}
+
+	if p, ok := o.Spec["matchingPrecedence"]; ok {
+		precedence, isInt := p.(int)
+		if !isInt || precedence < 1 || precedence > maxFlowSchemaMatchingPrecedence {
+			return fmt.Errorf("`spec.matchingPrecedence` must be an integer between 1 and %d, but was %v", maxFlowSchemaMatchingPrecedence, p)
+		}
+	}
+
+	if rules, _ := o.Spec["rules"].([]interface{}); len(rules) == 0 {
+		return errors.New("`spec.rules` must contain at least one rule")
+	}
+
+	return nil
+}
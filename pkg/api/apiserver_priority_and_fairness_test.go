@@ -0,0 +1,165 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestAPIServerPriorityAndFairnessValidate(t *testing.T) {
+	disabled := false
+	level := APFObject{
+		Name: "noisy-clients",
+		Spec: map[string]interface{}{
+			"type":    "Limited",
+			"limited": map[interface{}]interface{}{"assuredConcurrencyShares": 5},
+		},
+	}
+	flowSchema := func(level string, precedence interface{}) APFObject {
+		return APFObject{
+			Name: "noisy-clients",
+			Spec: map[string]interface{}{
+				"priorityLevelConfiguration": map[interface{}]interface{}{"name": level},
+				"matchingPrecedence":         precedence,
+				"rules":                      []interface{}{map[interface{}]interface{}{"subjects": []interface{}{}}},
+			},
+		}
+	}
+
+	testCases := []struct {
+		apf     APIServerPriorityAndFairness
+		isValid bool
+	}{
+		// Valid, the apiserver's defaults
+		{
+			apf:     APIServerPriorityAndFairness{},
+			isValid: true,
+		},
+		// Valid, disabled
+		{
+			apf:     APIServerPriorityAndFairness{Enabled: &disabled, MaxRequestsInflight: 800},
+			isValid: true,
+		},
+		// Valid, a custom priority level and a flow schema referring to it
+		{
+			apf: APIServerPriorityAndFairness{
+				PriorityLevelConfigurations: []APFObject{level},
+				FlowSchemas:                 []APFObject{flowSchema("noisy-clients", 1000)},
+			},
+			isValid: true,
+		},
+		// Valid, a flow schema referring to a priority level created by the apiserver
+		{
+			apf:     APIServerPriorityAndFairness{FlowSchemas: []APFObject{flowSchema("workload-low", 1000)}},
+			isValid: true,
+		},
+		// Invalid, negative max requests inflight
+		{
+			apf:     APIServerPriorityAndFairness{MaxMutatingRequestsInflight: -1},
+			isValid: false,
+		},
+		// Invalid, custom objects while disabled
+		{
+			apf:     APIServerPriorityAndFairness{Enabled: &disabled, PriorityLevelConfigurations: []APFObject{level}},
+			isValid: false,
+		},
+		// Invalid, a mandatory priority level
+		{
+			apf:     APIServerPriorityAndFairness{PriorityLevelConfigurations: []APFObject{{Name: "catch-all", Spec: level.Spec}}},
+			isValid: false,
+		},
+		// Invalid, duplicate priority levels
+		{
+			apf:     APIServerPriorityAndFairness{PriorityLevelConfigurations: []APFObject{level, level}},
+			isValid: false,
+		},
+		// Invalid, name
+		{
+			apf:     APIServerPriorityAndFairness{PriorityLevelConfigurations: []APFObject{{Name: "Noisy", Spec: level.Spec}}},
+			isValid: false,
+		},
+		// Invalid, priority level type
+		{
+			apf:     APIServerPriorityAndFairness{PriorityLevelConfigurations: []APFObject{{Name: "noisy", Spec: map[string]interface{}{"type": "Exempt"}}}},
+			isValid: false,
+		},
+		// Invalid, a flow schema referring to an unknown priority level
+		{
+			apf:     APIServerPriorityAndFairness{FlowSchemas: []APFObject{flowSchema("noisy-clients", 1000)}},
+			isValid: false,
+		},
+		// Invalid, matching precedence out of range
+		{
+			apf:     APIServerPriorityAndFairness{FlowSchemas: []APFObject{flowSchema("workload-low", 10001)}},
+			isValid: false,
+		},
+		// Invalid, a flow schema without rules
+		{
+			apf: APIServerPriorityAndFairness{FlowSchemas: []APFObject{{
+				Name: "noisy-clients",
+				Spec: map[string]interface{}{"priorityLevelConfiguration": map[interface{}]interface{}{"name": "workload-low"}},
+			}}},
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.apf.Validate()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.apf, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.apf)
+		}
+	}
+}
+
+func TestAPIServerPriorityAndFairnessManifest(t *testing.T) {
+	apf := APIServerPriorityAndFairness{
+		PriorityLevelConfigurations: []APFObject{
+			{Name: "noisy-clients", Spec: map[string]interface{}{
+				"type":    "Limited",
+				"limited": map[interface{}]interface{}{"assuredConcurrencyShares": 5},
+			}},
+		},
+		FlowSchemas: []APFObject{
+			{Name: "noisy-clients", Spec: map[string]interface{}{
+				"priorityLevelConfiguration": map[interface{}]interface{}{"name": "noisy-clients"},
+				"matchingPrecedence":         1000,
+			}},
+		},
+	}
+	expected := `apiVersion: flowcontrol.apiserver.k8s.io/v1beta2
+kind: PriorityLevelConfiguration
+metadata:
+  name: noisy-clients
+spec:
+  limited:
+    assuredConcurrencyShares: 5
+  type: Limited
+---
+apiVersion: flowcontrol.apiserver.k8s.io/v1beta2
+kind: FlowSchema
+metadata:
+  name: noisy-clients
+spec:
+  matchingPrecedence: 1000
+  priorityLevelConfiguration:
+    name: noisy-clients`
+
+	actual, err := apf.Manifest("v1.23.4")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if actual != expected {
+		t.Errorf("unexpected manifest:\nexpected:\n%s\nactual:\n%s", expected, actual)
+	}
+
+	for k8sVer, expected := range map[string]string{
+		"v1.20.2":  "flowcontrol.apiserver.k8s.io/v1beta1",
+		"v1.26.0":  "flowcontrol.apiserver.k8s.io/v1beta3",
+		"v1.29.10": "flowcontrol.apiserver.k8s.io/v1",
+	} {
+		if v, err := apf.APIVersion(k8sVer); err != nil || v != expected {
+			t.Errorf("expected %s for %s but got %s: %v", expected, k8sVer, v, err)
+		}
+	}
+}
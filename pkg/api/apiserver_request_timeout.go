@@ -0,0 +1,67 @@
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultAPIServerRequestTimeout is the default of the apiserver's `--request-timeout`
+	DefaultAPIServerRequestTimeout = time.Minute
+	// DefaultAPIServerMinRequestTimeout is the default of the apiserver's `--min-request-timeout`
+	DefaultAPIServerMinRequestTimeout = 30 * time.Minute
+)
+
+// RequestTimeoutOrDefault returns the timeout of requests other than the long-running ones like watch, exec, attach, port-forward, proxy and log
+func (s ControllerAPIServer) RequestTimeoutOrDefault() time.Duration {
+	if d, err := time.ParseDuration(s.RequestTimeout); err == nil {
+		return d
+	}
+	return DefaultAPIServerRequestTimeout
+}
+
+// MinRequestTimeoutSeconds returns the `--min-request-timeout`, which the apiserver randomizes the timeout of each watch request
+// between it and its double with
+func (s ControllerAPIServer) MinRequestTimeoutSeconds() int {
+	if d, err := time.ParseDuration(s.MinRequestTimeout); err == nil {
+		return int(d.Seconds())
+	}
+	return int(DefaultAPIServerMinRequestTimeout.Seconds())
+}
+
+func (s ControllerAPIServer) validateRequestTimeouts() error {
+	if s.RequestTimeout != "" {
+		d, err := time.ParseDuration(s.RequestTimeout)
+		if err != nil {
+			return fmt.Errorf("invalid `controller.apiServer.requestTimeout` \"%s\": %v", s.RequestTimeout, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("`controller.apiServer.requestTimeout` must be a positive duration like 1m, but was \"%s\"", s.RequestTimeout)
+		}
+	}
+	if s.MinRequestTimeout != "" {
+		d, err := time.ParseDuration(s.MinRequestTimeout)
+		if err != nil {
+			return fmt.Errorf("invalid `controller.apiServer.minRequestTimeout` \"%s\": %v", s.MinRequestTimeout, err)
+		}
+		if d < time.Second || d%time.Second != 0 {
+			return fmt.Errorf("`controller.apiServer.minRequestTimeout` must be a duration of whole seconds like 30m, but was \"%s\"", s.MinRequestTimeout)
+		}
+	}
+	return nil
+}
+
+// validateAPIEndpointIdleTimeouts ensures that the managed load balancers of API endpoints don't cut off requests the apiserver is still processing
+func (c Cluster) validateAPIEndpointIdleTimeouts() error {
+	timeout := c.Controller.APIServer.RequestTimeoutOrDefault()
+	for _, e := range c.APIEndpointConfigs {
+		if !e.LoadBalancer.ManageELB() {
+			continue
+		}
+		idle := time.Duration(e.LoadBalancer.IdleTimeoutOrDefault()) * time.Second
+		if idle < timeout {
+			return fmt.Errorf("the idle timeout %v of the load balancer of the API endpoint \"%s\" must be equal to or longer than `controller.apiServer.requestTimeout` %v, or the load balancer cuts off requests still being processed by apiservers", idle, e.Name, timeout)
+		}
+	}
+	return nil
+}
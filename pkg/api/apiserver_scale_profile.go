@@ -0,0 +1,147 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The bounds of `controller.apiServer.etcdCompactionInterval`. Compacting more often than the lower bound makes etcd spend
+// most of its time compacting, while compacting less often than the upper bound lets the etcd database grow towards its quota
+const (
+	minEtcdCompactionInterval = time.Minute
+	maxEtcdCompactionInterval = time.Hour
+)
+
+// watchCacheSizePattern matches an entry of the apiserver's `--watch-cache-sizes` like `pods#5000` or `deployments.apps#1000`
+var watchCacheSizePattern = regexp.MustCompile(`^([a-z0-9]+(\.[a-z0-9-]+)*)#([0-9]+)$`)
+
+var apiServerScaleProfileNames = []string{"small", "medium", "large"}
+
+// apiServerScaleProfiles are the presets of `controller.apiServer.scaleProfile`, each a coherent bundle of flags tuned for a cluster size.
+// Larger clusters keep more history in etcd so that their many watchers rarely need to relist, serve more lists and watches from
+// bigger watch caches, and wait longer before evicting pods from unreachable nodes so that a partitioned availability zone doesn't cause
+// an eviction storm
+var apiServerScaleProfiles = map[string]APIServerScaleSettings{
+	"small": {
+		EtcdCompactionInterval:              "5m",
+		DefaultWatchCacheSize:               intPtr(100),
+		DefaultNotReadyTolerationSeconds:    intPtr(300),
+		DefaultUnreachableTolerationSeconds: intPtr(300),
+	},
+	"medium": {
+		EtcdCompactionInterval:              "5m",
+		DefaultWatchCacheSize:               intPtr(500),
+		DefaultNotReadyTolerationSeconds:    intPtr(300),
+		DefaultUnreachableTolerationSeconds: intPtr(300),
+	},
+	"large": {
+		EtcdCompactionInterval:              "10m",
+		DefaultWatchCacheSize:               intPtr(1000),
+		WatchCacheSizes:                     []string{"pods#5000", "nodes#2000", "endpoints#2000"},
+		DefaultNotReadyTolerationSeconds:    intPtr(300),
+		DefaultUnreachableTolerationSeconds: intPtr(600),
+	},
+}
+
+// APIServerScaleSettings is the set of the apiserver's flags tuned together for the size of the cluster.
+// Each setting overrides the one of `controller.apiServer.scaleProfile` when specified
+type APIServerScaleSettings struct {
+	// EtcdCompactionInterval is passed to the apiserver's `--etcd-compaction-interval`
+	EtcdCompactionInterval string `yaml:"etcdCompactionInterval,omitempty"`
+	// DefaultWatchCacheSize is passed to the apiserver's `--default-watch-cache-size`. 0 disables watch caches of resources
+	// not listed in WatchCacheSizes
+	DefaultWatchCacheSize *int `yaml:"defaultWatchCacheSize,omitempty"`
+	// WatchCacheSizes are passed to the apiserver's `--watch-cache-sizes`, each like `pods#5000`
+	WatchCacheSizes []string `yaml:"watchCacheSizes,omitempty"`
+	// DefaultNotReadyTolerationSeconds and DefaultUnreachableTolerationSeconds are passed to the apiserver's flags of the same names,
+	// which are the tolerationSeconds given to pods not tolerating the not-ready and unreachable taints of nodes
+	DefaultNotReadyTolerationSeconds    *int `yaml:"defaultNotReadyTolerationSeconds,omitempty"`
+	DefaultUnreachableTolerationSeconds *int `yaml:"defaultUnreachableTolerationSeconds,omitempty"`
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+// ScaleSettings returns the settings of `controller.apiServer.scaleProfile` overridden by the ones specified individually
+func (s ControllerAPIServer) ScaleSettings() APIServerScaleSettings {
+	merged := apiServerScaleProfiles[s.ScaleProfile]
+	o := s.APIServerScaleSettings
+	if o.EtcdCompactionInterval != "" {
+		merged.EtcdCompactionInterval = o.EtcdCompactionInterval
+	}
+	if o.DefaultWatchCacheSize != nil {
+		merged.DefaultWatchCacheSize = o.DefaultWatchCacheSize
+	}
+	if len(o.WatchCacheSizes) > 0 {
+		merged.WatchCacheSizes = o.WatchCacheSizes
+	}
+	if o.DefaultNotReadyTolerationSeconds != nil {
+		merged.DefaultNotReadyTolerationSeconds = o.DefaultNotReadyTolerationSeconds
+	}
+	if o.DefaultUnreachableTolerationSeconds != nil {
+		merged.DefaultUnreachableTolerationSeconds = o.DefaultUnreachableTolerationSeconds
+	}
+	return merged
+}
+
+func (s APIServerScaleSettings) WatchCacheSizesString() string {
+	return strings.Join(s.WatchCacheSizes, ",")
+}
+
+func (s ControllerAPIServer) validateScaleSettings() error {
+	if s.ScaleProfile != "" {
+		if _, ok := apiServerScaleProfiles[s.ScaleProfile]; !ok {
+			return fmt.Errorf("invalid `controller.apiServer.scaleProfile` \"%s\": it must be one of %s", s.ScaleProfile, strings.Join(apiServerScaleProfileNames, ", "))
+		}
+	}
+
+	settings := s.ScaleSettings()
+
+	if settings.EtcdCompactionInterval != "" {
+		d, err := time.ParseDuration(settings.EtcdCompactionInterval)
+		if err != nil {
+			return fmt.Errorf("invalid `controller.apiServer.etcdCompactionInterval` \"%s\": %v", settings.EtcdCompactionInterval, err)
+		}
+		if d < minEtcdCompactionInterval || d > maxEtcdCompactionInterval {
+			return fmt.Errorf("`controller.apiServer.etcdCompactionInterval` must be between %v and %v, but was \"%s\"", minEtcdCompactionInterval, maxEtcdCompactionInterval, settings.EtcdCompactionInterval)
+		}
+	}
+
+	if settings.DefaultWatchCacheSize != nil {
+		size := *settings.DefaultWatchCacheSize
+		if size < 0 {
+			return fmt.Errorf("`controller.apiServer.defaultWatchCacheSize` must not be negative, but was %d", size)
+		}
+		if size == 0 && (s.ScaleProfile == "medium" || s.ScaleProfile == "large") {
+			return fmt.Errorf("`controller.apiServer.defaultWatchCacheSize` must not be 0 with the scale profile \"%s\": lists and watches from every node would hit etcd directly", s.ScaleProfile)
+		}
+	}
+
+	resources := map[string]bool{}
+	for _, e := range settings.WatchCacheSizes {
+		m := watchCacheSizePattern.FindStringSubmatch(e)
+		if m == nil {
+			return fmt.Errorf("invalid `controller.apiServer.watchCacheSizes` entry \"%s\": it must be like pods#5000", e)
+		}
+		if _, err := strconv.Atoi(m[3]); err != nil {
+			return fmt.Errorf("invalid `controller.apiServer.watchCacheSizes` entry \"%s\": %v", e, err)
+		}
+		if resources[m[1]] {
+			return fmt.Errorf("duplicate resource \"%s\" in `controller.apiServer.watchCacheSizes`", m[1])
+		}
+		resources[m[1]] = true
+	}
+
+	if t := settings.DefaultNotReadyTolerationSeconds; t != nil && *t < 0 {
+		return fmt.Errorf("`controller.apiServer.defaultNotReadyTolerationSeconds` must not be negative, but was %d", *t)
+	}
+	if t := settings.DefaultUnreachableTolerationSeconds; t != nil && *t < 0 {
+		return fmt.Errorf("`controller.apiServer.defaultUnreachableTolerationSeconds` must not be negative, but was %d", *t)
+	}
+
+	return nil
+}
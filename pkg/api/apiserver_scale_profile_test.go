@@ -0,0 +1,109 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAPIServerScaleSettingsValidate(t *testing.T) {
+	testCases := []struct {
+		apiServer ControllerAPIServer
+		isValid   bool
+	}{
+		// Valid, not configured
+		{
+			apiServer: ControllerAPIServer{},
+			isValid:   true,
+		},
+		// Valid, a preset
+		{
+			apiServer: ControllerAPIServer{ScaleProfile: "large"},
+			isValid:   true,
+		},
+		// Valid, a preset with overrides
+		{
+			apiServer: ControllerAPIServer{
+				ScaleProfile: "medium",
+				APIServerScaleSettings: APIServerScaleSettings{
+					EtcdCompactionInterval: "15m",
+					WatchCacheSizes:        []string{"pods#3000", "deployments.apps#500"},
+				},
+			},
+			isValid: true,
+		},
+		// Valid, watch caches disabled without a preset
+		{
+			apiServer: ControllerAPIServer{APIServerScaleSettings: APIServerScaleSettings{DefaultWatchCacheSize: intPtr(0)}},
+			isValid:   true,
+		},
+		// Invalid, unknown preset
+		{
+			apiServer: ControllerAPIServer{ScaleProfile: "huge"},
+			isValid:   false,
+		},
+		// Invalid, compaction disabled
+		{
+			apiServer: ControllerAPIServer{APIServerScaleSettings: APIServerScaleSettings{EtcdCompactionInterval: "0s"}},
+			isValid:   false,
+		},
+		// Invalid, too long compaction interval
+		{
+			apiServer: ControllerAPIServer{ScaleProfile: "large", APIServerScaleSettings: APIServerScaleSettings{EtcdCompactionInterval: "2h"}},
+			isValid:   false,
+		},
+		// Invalid, watch caches disabled with the large preset
+		{
+			apiServer: ControllerAPIServer{ScaleProfile: "large", APIServerScaleSettings: APIServerScaleSettings{DefaultWatchCacheSize: intPtr(0)}},
+			isValid:   false,
+		},
+		// Invalid, malformed watch cache size
+		{
+			apiServer: ControllerAPIServer{APIServerScaleSettings: APIServerScaleSettings{WatchCacheSizes: []string{"pods=5000"}}},
+			isValid:   false,
+		},
+		// Invalid, duplicate watch cache size
+		{
+			apiServer: ControllerAPIServer{APIServerScaleSettings: APIServerScaleSettings{WatchCacheSizes: []string{"pods#5000", "pods#100"}}},
+			isValid:   false,
+		},
+		// Invalid, negative toleration
+		{
+			apiServer: ControllerAPIServer{APIServerScaleSettings: APIServerScaleSettings{DefaultUnreachableTolerationSeconds: intPtr(-1)}},
+			isValid:   false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.apiServer.Validate()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.apiServer, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.apiServer)
+		}
+	}
+}
+
+func TestControllerAPIServerScaleSettings(t *testing.T) {
+	s := ControllerAPIServer{
+		ScaleProfile: "large",
+		APIServerScaleSettings: APIServerScaleSettings{
+			DefaultWatchCacheSize:               intPtr(2000),
+			DefaultUnreachableTolerationSeconds: intPtr(900),
+		},
+	}
+	expected := APIServerScaleSettings{
+		EtcdCompactionInterval:              "10m",
+		DefaultWatchCacheSize:               intPtr(2000),
+		WatchCacheSizes:                     []string{"pods#5000", "nodes#2000", "endpoints#2000"},
+		DefaultNotReadyTolerationSeconds:    intPtr(300),
+		DefaultUnreachableTolerationSeconds: intPtr(900),
+	}
+	if actual := s.ScaleSettings(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("unexpected scale settings: expected %+v, but was %+v", expected, actual)
+	}
+
+	if actual := (ControllerAPIServer{}).ScaleSettings(); !reflect.DeepEqual(APIServerScaleSettings{}, actual) {
+		t.Errorf("expected no scale settings without a preset, but was %+v", actual)
+	}
+}
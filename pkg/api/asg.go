@@ -10,7 +10,10 @@ type AutoScalingGroup struct {
 	MaxSize                            int            `yaml:"maxSize,omitempty"`
 	RollingUpdateMinInstancesInService *int           `yaml:"rollingUpdateMinInstancesInService,omitempty"`
 	MixedInstances                     MixedInstances `yaml:"mixedInstances,omitempty"`
-	UnknownKeys                        `yaml:",inline"`
+	// SubnetWeights splits a node pool into one auto scaling group per subnet, keyed by the subnet name,
+	// and distributes minSize and maxSize among them in proportion to the weights
+	SubnetWeights map[string]int `yaml:"subnetWeights,omitempty"`
+	UnknownKeys   `yaml:",inline"`
 }
 
 func (asg AutoScalingGroup) Validate() error {
@@ -27,6 +30,11 @@ func (asg AutoScalingGroup) Validate() error {
 	if asg.RollingUpdateMinInstancesInService != nil && *asg.RollingUpdateMinInstancesInService < 0 {
 		return fmt.Errorf("`autoScalingGroup.rollingUpdateMinInstancesInService` must be greater than or equal to 0 but was %d", *asg.RollingUpdateMinInstancesInService)
 	}
+	for name, weight := range asg.SubnetWeights {
+		if weight < 1 {
+			return fmt.Errorf("the weight of the subnet \"%s\" in `autoScalingGroup.subnetWeights` must be 1 or greater but was %d", name, weight)
+		}
+	}
 	if asg.MixedInstances.Enabled {
 		return asg.MixedInstances.Validate()
 	}
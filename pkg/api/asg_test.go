@@ -39,6 +39,12 @@ func TestValidateAsgSizes(t *testing.T) {
 	err = a.Validate()
 	require.EqualError(t, err, "`autoScalingGroup.rollingUpdateMinInstancesInService` must be greater than or equal to 0 but was -1")
 	rolMinInst = 1
+
+	// Expect error if a subnet weight is not positive
+	a.SubnetWeights = map[string]int{"public1": 0}
+	err = a.Validate()
+	require.EqualError(t, err, "the weight of the subnet \"public1\" in `autoScalingGroup.subnetWeights` must be 1 or greater but was 0")
+	a.SubnetWeights = nil
 }
 
 func TestValidateAsgMixedInstances(t *testing.T) {
@@ -60,11 +66,11 @@ func TestValidateAsgMixedInstances(t *testing.T) {
 	// Expect error if string fields set to incorrect values
 	a.MixedInstances.OnDemandAllocationStrategy = "invalid-value"
 	err = a.Validate()
-	require.EqualError(t, err, "`mixedInstances.onDemandAllocationStrategy` must be equal to 'prioritized' if specified")
+	require.EqualError(t, err, "`mixedInstances.onDemandAllocationStrategy` must be one of 'prioritized', 'lowest-price' if specified, but was 'invalid-value'")
 	a.MixedInstances.OnDemandAllocationStrategy = "prioritized"
 	a.MixedInstances.SpotAllocationStrategy = "invalid-value"
 	err = a.Validate()
-	require.EqualError(t, err, "`mixedInstances.spotAllocationStrategy` must be equal to 'lowest-price' if specified")
+	require.EqualError(t, err, "`mixedInstances.spotAllocationStrategy` must be one of 'lowest-price', 'capacity-optimized', 'capacity-optimized-prioritized', 'price-capacity-optimized' if specified, but was 'invalid-value'")
 
 	// Expect no error if string fields set to correct values
 	a.MixedInstances.SpotAllocationStrategy = "lowest-price"
@@ -106,8 +112,82 @@ func TestValidateAsgMixedInstances(t *testing.T) {
 	err = a.Validate()
 	require.EqualError(t, err, "`mixedInstances.spotMaxPrice` can have a maximum length of 255")
 
+	a.MixedInstances.SpotMaxPrice = "two dollars"
+	err = a.Validate()
+	require.EqualError(t, err, "`mixedInstances.spotMaxPrice` must be a positive decimal number of the maximum hourly price in USD if specified, but was 'two dollars'")
+
 	// Every field filled with a valid value, no error expected
 	a.MixedInstances.SpotMaxPrice = "2"
 	err = a.Validate()
 	require.NoError(t, err)
 }
+
+func TestValidateAsgMixedInstancesSpotAllocation(t *testing.T) {
+	testCases := []struct {
+		mixedInstances MixedInstances
+		expectedError  string
+	}{
+		// Spot instance pools with the default allocation strategy
+		{
+			mixedInstances: MixedInstances{Enabled: true, SpotInstancePools: 4},
+		},
+		// Spot instance pools with the lowest-price allocation strategy
+		{
+			mixedInstances: MixedInstances{Enabled: true, SpotAllocationStrategy: "lowest-price", SpotInstancePools: 4},
+		},
+		// Capacity optimized allocation without spot instance pools
+		{
+			mixedInstances: MixedInstances{Enabled: true, SpotAllocationStrategy: "capacity-optimized", SpotMaxPrice: "0.25"},
+		},
+		// Capacity optimized prioritized allocation with instance types in the order of priority
+		{
+			mixedInstances: MixedInstances{Enabled: true, SpotAllocationStrategy: "capacity-optimized-prioritized", InstanceTypes: []string{"m5.large", "m5a.large", "m4.large"}},
+		},
+		// On-demand only
+		{
+			mixedInstances: MixedInstances{Enabled: true, OnDemandAllocationStrategy: "lowest-price", OnDemandPercentageAboveBaseCapacity: 100},
+		},
+		// Spot instance pools are ignored by allocation strategies other than lowest-price
+		{
+			mixedInstances: MixedInstances{Enabled: true, SpotAllocationStrategy: "capacity-optimized", SpotInstancePools: 4},
+			expectedError:  "`mixedInstances.spotInstancePools` can only be specified with the 'lowest-price' spot allocation strategy, but `mixedInstances.spotAllocationStrategy` was 'capacity-optimized'",
+		},
+		// No spot instances are launched
+		{
+			mixedInstances: MixedInstances{Enabled: true, OnDemandPercentageAboveBaseCapacity: 100, SpotMaxPrice: "0.25"},
+			expectedError:  "`mixedInstances.spotAllocationStrategy`, `mixedInstances.spotInstancePools` and `mixedInstances.spotMaxPrice` must not be specified when `mixedInstances.onDemandPercentageAboveBaseCapacity` is 100, as no spot instances would be launched",
+		},
+		// Capacity optimized prioritized allocation without the order of instance types
+		{
+			mixedInstances: MixedInstances{Enabled: true, SpotAllocationStrategy: "capacity-optimized-prioritized"},
+			expectedError:  "`mixedInstances.instanceTypes` must list 2 or more instance types in the order of priority for the 'capacity-optimized-prioritized' spot allocation strategy, but was []",
+		},
+		{
+			mixedInstances: MixedInstances{Enabled: true, SpotAllocationStrategy: "capacity-optimized-prioritized", InstanceTypes: []string{"m5.large"}},
+			expectedError:  "`mixedInstances.instanceTypes` must list 2 or more instance types in the order of priority for the 'capacity-optimized-prioritized' spot allocation strategy, but was [m5.large]",
+		},
+		// Capacity optimized prioritized allocation with an ambiguous order of instance types
+		{
+			mixedInstances: MixedInstances{Enabled: true, SpotAllocationStrategy: "capacity-optimized-prioritized", InstanceTypes: []string{"m5.large", "m5a.large", "m5.large"}},
+			expectedError:  "`mixedInstances.instanceTypes` must not contain the duplicate instance type 'm5.large', as the order of instance types is the priority for the 'capacity-optimized-prioritized' spot allocation strategy",
+		},
+		{
+			mixedInstances: MixedInstances{Enabled: true, SpotAllocationStrategy: "capacity-optimized-prioritized", InstanceTypes: []string{"m5.large", ""}},
+			expectedError:  "`mixedInstances.instanceTypes[1]` must not be empty",
+		},
+		// Negative spot max price
+		{
+			mixedInstances: MixedInstances{Enabled: true, SpotMaxPrice: "-0.25"},
+			expectedError:  "`mixedInstances.spotMaxPrice` must be a positive decimal number of the maximum hourly price in USD if specified, but was '-0.25'",
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := AutoScalingGroup{MixedInstances: testCase.mixedInstances}.Validate()
+		if testCase.expectedError == "" {
+			require.NoError(t, err, "case %d", i)
+		} else {
+			require.EqualError(t, err, testCase.expectedError, "case %d", i)
+		}
+	}
+}
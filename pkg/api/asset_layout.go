@@ -0,0 +1,128 @@
+package api
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	DefaultCredentialsDir    = "credentials"
+	DefaultUserDataDir       = "userdata"
+	DefaultStackTemplatesDir = "stack-templates"
+	KubeconfigFileName       = "kubeconfig"
+	// PluginsDir is where kube-aws loads plugins from. It isn't relocated by the asset layout
+	PluginsDir = "plugins"
+)
+
+// AssetLayout is the directory structure kube-aws renders assets into and reads them back from,
+// so that the outputs can be kept within an existing repository structure
+type AssetLayout struct {
+	// BaseDir is the directory containing all the rendered assets and the kubeconfig. Defaults to the current directory
+	BaseDir string `yaml:"baseDir,omitempty"`
+	// CredentialsDir is the name of the directory for TLS assets and tokens, relative to BaseDir. Defaults to `credentials`
+	CredentialsDir string `yaml:"credentialsDir,omitempty"`
+	// UserDataDir is the name of the directory for cloud-configs, relative to BaseDir. Defaults to `userdata`
+	UserDataDir string `yaml:"userDataDir,omitempty"`
+	// StackTemplatesDir is the name of the directory for CloudFormation stack templates, relative to BaseDir. Defaults to `stack-templates`
+	StackTemplatesDir string `yaml:"stackTemplatesDir,omitempty"`
+}
+
+func (l AssetLayout) credentialsDir() string {
+	if l.CredentialsDir == "" {
+		return DefaultCredentialsDir
+	}
+	return l.CredentialsDir
+}
+
+func (l AssetLayout) userDataDir() string {
+	if l.UserDataDir == "" {
+		return DefaultUserDataDir
+	}
+	return l.UserDataDir
+}
+
+func (l AssetLayout) stackTemplatesDir() string {
+	if l.StackTemplatesDir == "" {
+		return DefaultStackTemplatesDir
+	}
+	return l.StackTemplatesDir
+}
+
+func (l AssetLayout) path(name string) string {
+	return filepath.Join(l.BaseDir, name)
+}
+
+func (l AssetLayout) CredentialsPath() string {
+	return l.path(l.credentialsDir())
+}
+
+func (l AssetLayout) UserDataPath() string {
+	return l.path(l.userDataDir())
+}
+
+func (l AssetLayout) StackTemplatesPath() string {
+	return l.path(l.stackTemplatesDir())
+}
+
+func (l AssetLayout) KubeconfigPath() string {
+	return l.path(KubeconfigFileName)
+}
+
+// KubeconfigCredentialsDir is the credentials directory relative to the kubeconfig, which is how kubectl resolves the paths in it
+func (l AssetLayout) KubeconfigCredentialsDir() string {
+	return filepath.ToSlash(filepath.Clean(l.credentialsDir()))
+}
+
+// RelocatePath maps the default path of an asset or its directory like `userdata/cloud-config-worker` to the one within this layout.
+// Paths outside the default directories are returned as-is
+func (l AssetLayout) RelocatePath(p string) string {
+	for dir, relocated := range map[string]string{
+		DefaultCredentialsDir:    l.CredentialsPath(),
+		DefaultUserDataDir:       l.UserDataPath(),
+		DefaultStackTemplatesDir: l.StackTemplatesPath(),
+	} {
+		if p == dir {
+			return relocated
+		}
+		if strings.HasPrefix(p, dir+"/") {
+			return filepath.Join(relocated, strings.TrimPrefix(p, dir+"/"))
+		}
+	}
+	return p
+}
+
+func (l AssetLayout) Validate() error {
+	dirs := []struct {
+		key  string
+		name string
+	}{
+		{"credentialsDir", l.credentialsDir()},
+		{"userDataDir", l.userDataDir()},
+		{"stackTemplatesDir", l.stackTemplatesDir()},
+	}
+
+	for _, d := range dirs {
+		cleaned := filepath.Clean(d.name)
+		if filepath.IsAbs(d.name) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("invalid `assetLayout.%s` \"%s\": it must be a directory name relative to `assetLayout.baseDir`", d.key, d.name)
+		}
+		if cleaned == KubeconfigFileName {
+			return fmt.Errorf("`assetLayout.%s` \"%s\" collides with the kubeconfig rendered into `assetLayout.baseDir`", d.key, d.name)
+		}
+		if filepath.Clean(filepath.Join(l.BaseDir, d.name)) == PluginsDir {
+			return fmt.Errorf("`assetLayout.%s` \"%s\" collides with the %s directory kube-aws loads plugins from", d.key, d.name, PluginsDir)
+		}
+	}
+
+	for i, a := range dirs {
+		for _, b := range dirs[i+1:] {
+			x, y := filepath.Clean(a.name), filepath.Clean(b.name)
+			if x == y || strings.HasPrefix(x, y+string(filepath.Separator)) || strings.HasPrefix(y, x+string(filepath.Separator)) {
+				return fmt.Errorf("`assetLayout.%s` \"%s\" and `assetLayout.%s` \"%s\" collide: they must be distinct and not nested in each other", a.key, a.name, b.key, b.name)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestAssetLayoutPaths(t *testing.T) {
+	testCases := []struct {
+		layout             AssetLayout
+		credentialsPath    string
+		userDataPath       string
+		stackTemplatesPath string
+		kubeconfigPath     string
+	}{
+		// The default layout
+		{
+			layout:             AssetLayout{},
+			credentialsPath:    "credentials",
+			userDataPath:       "userdata",
+			stackTemplatesPath: "stack-templates",
+			kubeconfigPath:     "kubeconfig",
+		},
+		// The base directory only
+		{
+			layout:             AssetLayout{BaseDir: "clusters/prod"},
+			credentialsPath:    "clusters/prod/credentials",
+			userDataPath:       "clusters/prod/userdata",
+			stackTemplatesPath: "clusters/prod/stack-templates",
+			kubeconfigPath:     "clusters/prod/kubeconfig",
+		},
+		// Customized directory names
+		{
+			layout:             AssetLayout{BaseDir: "/srv/gitops", CredentialsDir: "secrets/tls", UserDataDir: "cloud-configs", StackTemplatesDir: "cfn"},
+			credentialsPath:    "/srv/gitops/secrets/tls",
+			userDataPath:       "/srv/gitops/cloud-configs",
+			stackTemplatesPath: "/srv/gitops/cfn",
+			kubeconfigPath:     "/srv/gitops/kubeconfig",
+		},
+	}
+
+	for i, testCase := range testCases {
+		l := testCase.layout
+		for _, p := range []struct{ actual, expected string }{
+			{l.CredentialsPath(), testCase.credentialsPath},
+			{l.UserDataPath(), testCase.userDataPath},
+			{l.StackTemplatesPath(), testCase.stackTemplatesPath},
+			{l.KubeconfigPath(), testCase.kubeconfigPath},
+		} {
+			if p.actual != p.expected {
+				t.Errorf("case %d: expected path to be %s but was %s", i, p.expected, p.actual)
+			}
+		}
+	}
+}
+
+func TestAssetLayoutRelocatePath(t *testing.T) {
+	l := AssetLayout{BaseDir: "kube-aws", UserDataDir: "cloud-configs", StackTemplatesDir: "cfn"}
+
+	testCases := []struct {
+		path     string
+		expected string
+	}{
+		{"userdata/cloud-config-worker", "kube-aws/cloud-configs/cloud-config-worker"},
+		{"stack-templates/root.json.tmpl", "kube-aws/cfn/root.json.tmpl"},
+		{"credentials/ca.pem", "kube-aws/credentials/ca.pem"},
+		{"credentials", "kube-aws/credentials"},
+		{"plugins/aws-iam-authenticator/plugin.yaml", "plugins/aws-iam-authenticator/plugin.yaml"},
+		{"userdata-backup/cloud-config-worker", "userdata-backup/cloud-config-worker"},
+	}
+
+	for i, testCase := range testCases {
+		if actual := l.RelocatePath(testCase.path); actual != testCase.expected {
+			t.Errorf("case %d: expected %s to be relocated to %s but was %s", i, testCase.path, testCase.expected, actual)
+		}
+	}
+}
+
+func TestAssetLayoutValidate(t *testing.T) {
+	testCases := []struct {
+		layout  AssetLayout
+		isValid bool
+	}{
+		// Valid, the default layout
+		{
+			layout:  AssetLayout{},
+			isValid: true,
+		},
+		// Valid, customized
+		{
+			layout:  AssetLayout{BaseDir: "/srv/gitops", CredentialsDir: "secrets/tls", UserDataDir: "cloud-configs", StackTemplatesDir: "cfn"},
+			isValid: true,
+		},
+		// Valid, sharing a parent directory
+		{
+			layout:  AssetLayout{UserDataDir: "rendered/userdata", StackTemplatesDir: "rendered/stack-templates"},
+			isValid: true,
+		},
+		// Invalid, absolute directory name
+		{
+			layout:  AssetLayout{CredentialsDir: "/etc/kube-aws/credentials"},
+			isValid: false,
+		},
+		// Invalid, escaping the base directory
+		{
+			layout:  AssetLayout{UserDataDir: "../userdata"},
+			isValid: false,
+		},
+		// Invalid, the base directory itself
+		{
+			layout:  AssetLayout{StackTemplatesDir: "."},
+			isValid: false,
+		},
+		// Invalid, colliding with each other
+		{
+			layout:  AssetLayout{UserDataDir: "assets", StackTemplatesDir: "assets"},
+			isValid: false,
+		},
+		// Invalid, nested in another
+		{
+			layout:  AssetLayout{CredentialsDir: "assets", UserDataDir: "assets/userdata"},
+			isValid: false,
+		},
+		// Invalid, colliding with the kubeconfig
+		{
+			layout:  AssetLayout{CredentialsDir: "kubeconfig"},
+			isValid: false,
+		},
+		// Invalid, colliding with the plugins directory
+		{
+			layout:  AssetLayout{UserDataDir: "plugins"},
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.layout.Validate()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.layout, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.layout)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const assetPrefixMaxLength = 512
+
+// assetPrefixSegmentPattern excludes the characters S3 recommends for object keys but have special meanings in IAM policies like `*`
+// and in the shell scripts of nodes downloading assets like `(`
+var assetPrefixSegmentPattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// S3Folders returns the folders of the cluster's assets, which are namespaced under either the `assetPrefix` or `kube-aws/clusters/<clusterName>`
+func (c DeploymentSettings) S3Folders() S3Folders {
+	return NewS3Folders(c.S3URI, c.ClusterName, c.AssetPrefix)
+}
+
+// validateAssetPrefix ensures that the `assetPrefix` namespaces the assets of the cluster apart from the ones of the other clusters sharing the bucket.
+// As the cluster name is also the name of the root stack, requiring it as the last segment makes the prefixes of clusters unique and never nested in each other
+func (c DeploymentSettings) validateAssetPrefix() error {
+	if c.AssetPrefix == "" {
+		return nil
+	}
+	if len(c.AssetPrefix) > assetPrefixMaxLength {
+		return fmt.Errorf("`assetPrefix` must be %d characters or less, but was %d characters", assetPrefixMaxLength, len(c.AssetPrefix))
+	}
+	segments := strings.Split(c.AssetPrefix, "/")
+	for _, s := range segments {
+		if s == "" || s == "." || s == ".." || !assetPrefixSegmentPattern.MatchString(s) {
+			return fmt.Errorf("invalid `assetPrefix` \"%s\": it must be slash-separated segments of alphanumeric characters, `_`, `.` and `-` like `kube-aws/team-a/%s`, without leading or trailing slashes", c.AssetPrefix, c.ClusterName)
+		}
+	}
+	if segments[len(segments)-1] != c.ClusterName {
+		return fmt.Errorf("invalid `assetPrefix` \"%s\": it must end with the cluster name like `kube-aws/team-a/%s` so that it is unique to the cluster", c.AssetPrefix, c.ClusterName)
+	}
+	return nil
+}
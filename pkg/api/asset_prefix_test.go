@@ -0,0 +1,107 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateAssetPrefix(t *testing.T) {
+	testCases := []struct {
+		assetPrefix string
+		isValid     bool
+	}{
+		// Valid, defaulted
+		{
+			assetPrefix: "",
+			isValid:     true,
+		},
+		// Valid, only the cluster name
+		{
+			assetPrefix: "mycluster",
+			isValid:     true,
+		},
+		// Valid, nested
+		{
+			assetPrefix: "kube-aws/team_a.prod/mycluster",
+			isValid:     true,
+		},
+		// Invalid, not ending with the cluster name
+		{
+			assetPrefix: "kube-aws/team-a",
+			isValid:     false,
+		},
+		// Invalid, ending with a cluster name containing the cluster name
+		{
+			assetPrefix: "kube-aws/mycluster2",
+			isValid:     false,
+		},
+		// Invalid, leading slash
+		{
+			assetPrefix: "/kube-aws/mycluster",
+			isValid:     false,
+		},
+		// Invalid, trailing slash
+		{
+			assetPrefix: "kube-aws/mycluster/",
+			isValid:     false,
+		},
+		// Invalid, empty segment
+		{
+			assetPrefix: "kube-aws//mycluster",
+			isValid:     false,
+		},
+		// Invalid, relative segment
+		{
+			assetPrefix: "kube-aws/../mycluster",
+			isValid:     false,
+		},
+		// Invalid, wildcard broadening the IAM policies
+		{
+			assetPrefix: "kube-aws/*/mycluster",
+			isValid:     false,
+		},
+		// Invalid, too long
+		{
+			assetPrefix: strings.Repeat("a/", 256) + "mycluster",
+			isValid:     false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		settings := DeploymentSettings{ClusterName: "mycluster", AssetPrefix: testCase.assetPrefix}
+		err := settings.validateAssetPrefix()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %q to be valid but got an error: %v", i, testCase.assetPrefix, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %q to be invalid but was not", i, testCase.assetPrefix)
+		}
+	}
+}
+
+func TestS3FoldersWithAssetPrefix(t *testing.T) {
+	testCases := []struct {
+		assetPrefix     string
+		expectedCluster string
+	}{
+		{
+			assetPrefix:     "",
+			expectedCluster: "s3://mybucket/mydir/kube-aws/clusters/mycluster",
+		},
+		{
+			assetPrefix:     "team-a/mycluster",
+			expectedCluster: "s3://mybucket/mydir/team-a/mycluster",
+		},
+	}
+
+	for i, testCase := range testCases {
+		settings := DeploymentSettings{S3URI: "s3://mybucket/mydir", ClusterName: "mycluster", AssetPrefix: testCase.assetPrefix}
+		folders := settings.S3Folders()
+		if actual := folders.Cluster().URI(); actual != testCase.expectedCluster {
+			t.Errorf("case %d: expected the cluster folder to be %s but was %s", i, testCase.expectedCluster, actual)
+		}
+		if expected := testCase.expectedCluster + "/exported/stacks"; folders.ClusterExportedStacks().URI() != expected {
+			t.Errorf("case %d: expected the exported stacks folder to be %s but was %s", i, expected, folders.ClusterExportedStacks().URI())
+		}
+	}
+}
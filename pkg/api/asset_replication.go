@@ -0,0 +1,76 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	assetReplicationS3URIPattern = regexp.MustCompile(`^s3://([^/]+)(/.*)?$`)
+	kmsKeyARNPattern             = regexp.MustCompile(`^arn:([^:]+):kms:([^:]+):([0-9]+):key/(.+)$`)
+)
+
+// AssetReplication configures the S3 bucket in a secondary region kube-aws uploads the assets of the cluster like the stack templates and
+// the userdata containing the encrypted credentials to, in addition to `s3URI`, so that the cluster can be recreated from them in the region
+type AssetReplication struct {
+	// S3URI is the URI of the bucket in the secondary region like `s3://my-dr-bucket/mydir`.
+	// The assets are uploaded under it with the same keys as the ones under `s3URI`
+	S3URI string `yaml:"s3URI,omitempty"`
+	// Region is the region of the bucket, which must differ from the region of the cluster
+	Region Region `yaml:",inline"`
+}
+
+func (r AssetReplication) Enabled() bool {
+	return r.S3URI != ""
+}
+
+// ReplicaKMSKeyARN returns the ARN of the replica of the multi-region KMS key in the secondary region,
+// which decrypts the credentials encrypted with the primary key in the region
+func (r AssetReplication) ReplicaKMSKeyARN(kmsKeyARN string) (string, error) {
+	m := kmsKeyARNPattern.FindStringSubmatch(kmsKeyARN)
+	if m == nil || !strings.HasPrefix(m[4], "mrk-") {
+		return "", fmt.Errorf("`kmsKeyArn` must be the ARN of a multi-region key like `arn:aws:kms:us-west-1:123456789012:key/mrk-1234abcd12ab34cd56ef1234567890ab` "+
+			"replicated to `assetReplication.region` %s, so that the credentials encrypted in the assets can be decrypted in the region, but was \"%s\"", r.Region, kmsKeyARN)
+	}
+	return fmt.Sprintf("arn:%s:kms:%s:%s:key/%s", m[1], r.Region, m[3], m[4]), nil
+}
+
+// ReplicaS3Folders returns the folders of the assets of the cluster in the bucket of the secondary region
+func (c DeploymentSettings) ReplicaS3Folders() S3Folders {
+	return NewS3Folders(c.AssetReplication.S3URI, c.ClusterName, c.AssetPrefix)
+}
+
+func (c DeploymentSettings) validateAssetReplication() error {
+	r := c.AssetReplication
+	if !r.Enabled() {
+		if !r.Region.IsEmpty() {
+			return errors.New("`assetReplication.region` requires `assetReplication.s3URI`")
+		}
+		return nil
+	}
+
+	m := assetReplicationS3URIPattern.FindStringSubmatch(r.S3URI)
+	if m == nil {
+		return fmt.Errorf("invalid `assetReplication.s3URI` \"%s\": it must be like s3://mybucket/mydir or s3://mybucket", r.S3URI)
+	}
+	if primary := assetReplicationS3URIPattern.FindStringSubmatch(c.S3URI); primary != nil && primary[1] == m[1] {
+		return fmt.Errorf("the bucket of `assetReplication.s3URI` \"%s\" must differ from the one of `s3URI` \"%s\"", r.S3URI, c.S3URI)
+	}
+	if r.Region.IsEmpty() {
+		return errors.New("`assetReplication.region` must be set with `assetReplication.s3URI`")
+	}
+	if r.Region.Name == c.Region.Name {
+		return fmt.Errorf("`assetReplication.region` must differ from the region of the cluster %s", c.Region)
+	}
+	if r.Region.Partition() != c.Region.Partition() {
+		return fmt.Errorf("`assetReplication.region` %s must be in the same partition as the region of the cluster %s", r.Region, c.Region)
+	}
+	if c.AssetsEncryptionEnabled() {
+		if _, err := r.ReplicaKMSKeyARN(c.KMSKeyARN); err != nil {
+			return err
+		}
+	}
+	return nil
+}
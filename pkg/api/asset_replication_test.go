@@ -0,0 +1,106 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestValidateAssetReplication(t *testing.T) {
+	mrk := "arn:aws:kms:us-west-1:123456789012:key/mrk-1234abcd12ab34cd56ef1234567890ab"
+	testCases := []struct {
+		replication        AssetReplication
+		kmsKeyARN          string
+		manageCertificates bool
+		isValid            bool
+	}{
+		// Valid, disabled
+		{
+			replication: AssetReplication{},
+			isValid:     true,
+		},
+		// Valid, with a multi-region key
+		{
+			replication:        AssetReplication{S3URI: "s3://my-dr-bucket/mydir", Region: RegionForName("us-east-1")},
+			kmsKeyARN:          mrk,
+			manageCertificates: true,
+			isValid:            true,
+		},
+		// Valid, without encrypting assets
+		{
+			replication: AssetReplication{S3URI: "s3://my-dr-bucket", Region: RegionForName("us-east-1")},
+			isValid:     true,
+		},
+		// Invalid, region without s3URI
+		{
+			replication: AssetReplication{Region: RegionForName("us-east-1")},
+			isValid:     false,
+		},
+		// Invalid, malformed s3URI
+		{
+			replication: AssetReplication{S3URI: "my-dr-bucket/mydir", Region: RegionForName("us-east-1")},
+			isValid:     false,
+		},
+		// Invalid, the same bucket as s3URI
+		{
+			replication: AssetReplication{S3URI: "s3://mybucket/dr", Region: RegionForName("us-east-1")},
+			isValid:     false,
+		},
+		// Invalid, s3URI without region
+		{
+			replication: AssetReplication{S3URI: "s3://my-dr-bucket/mydir"},
+			isValid:     false,
+		},
+		// Invalid, the same region as the cluster
+		{
+			replication: AssetReplication{S3URI: "s3://my-dr-bucket/mydir", Region: RegionForName("us-west-1")},
+			isValid:     false,
+		},
+		// Invalid, another partition
+		{
+			replication: AssetReplication{S3URI: "s3://my-dr-bucket/mydir", Region: RegionForName("cn-north-1")},
+			isValid:     false,
+		},
+		// Invalid, single-region key
+		{
+			replication:        AssetReplication{S3URI: "s3://my-dr-bucket/mydir", Region: RegionForName("us-east-1")},
+			kmsKeyARN:          "arn:aws:kms:us-west-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+			manageCertificates: true,
+			isValid:            false,
+		},
+		// Invalid, alias of a multi-region key
+		{
+			replication:        AssetReplication{S3URI: "s3://my-dr-bucket/mydir", Region: RegionForName("us-east-1")},
+			kmsKeyARN:          "arn:aws:kms:us-west-1:123456789012:alias/mrk-kube-aws",
+			manageCertificates: true,
+			isValid:            false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		settings := DeploymentSettings{
+			S3URI:            "s3://mybucket/mydir",
+			ClusterName:      "mycluster",
+			Region:           RegionForName("us-west-1"),
+			AssetReplication: testCase.replication,
+		}
+		settings.KMSKeyARN = testCase.kmsKeyARN
+		settings.ManageCertificates = testCase.manageCertificates
+		err := settings.validateAssetReplication()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.replication, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.replication)
+		}
+	}
+}
+
+func TestAssetReplicationReplicaKMSKeyARN(t *testing.T) {
+	r := AssetReplication{S3URI: "s3://my-dr-bucket", Region: RegionForName("us-east-1")}
+	actual, err := r.ReplicaKMSKeyARN("arn:aws:kms:us-west-1:123456789012:key/mrk-1234abcd12ab34cd56ef1234567890ab")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "arn:aws:kms:us-east-1:123456789012:key/mrk-1234abcd12ab34cd56ef1234567890ab"; actual != expected {
+		t.Errorf("expected the replica key to be %s but was %s", expected, actual)
+	}
+}
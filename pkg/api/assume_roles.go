@@ -0,0 +1,51 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var iamRoleARNRegexp = regexp.MustCompile(`^arn:aws(-cn|-us-gov)?:iam::\d{12}:role/[a-zA-Z0-9+=,.@_/-]{1,512}$`)
+
+// AssumeRoles is the set of IAM roles kube-aws assumes when making AWS API calls for specific operations,
+// so that resources like the S3 bucket and the Route 53 hosted zone can be owned by AWS accounts other than the one the cluster runs in
+type AssumeRoles struct {
+	// S3 is assumed to upload and download assets to and from the bucket specified by `s3URI`
+	S3 AssumeRole `yaml:"s3,omitempty"`
+	// Route53 is assumed to look up the hosted zones and the record sets for API endpoints
+	Route53 AssumeRole `yaml:"route53,omitempty"`
+}
+
+// AssumeRole is an IAM role, possibly in another AWS account, assumed via STS
+type AssumeRole struct {
+	RoleARN string `yaml:"roleARN,omitempty"`
+	// ExternalID is required when the trust policy of the role has the `sts:ExternalId` condition
+	ExternalID string `yaml:"externalID,omitempty"`
+}
+
+func (r AssumeRole) Enabled() bool {
+	return r.RoleARN != ""
+}
+
+func (r AssumeRole) Validate(name string) error {
+	if !r.Enabled() {
+		if r.ExternalID != "" {
+			return fmt.Errorf("`assumeRoles.%s.roleARN` must be set to specify `assumeRoles.%s.externalID`", name, name)
+		}
+		return nil
+	}
+	if !iamRoleARNRegexp.MatchString(r.RoleARN) {
+		return fmt.Errorf("invalid `assumeRoles.%s.roleARN` \"%s\": it must be an IAM role ARN like arn:aws:iam::123456789012:role/name", name, r.RoleARN)
+	}
+	return nil
+}
+
+func (r AssumeRoles) Validate() error {
+	if err := r.S3.Validate("s3"); err != nil {
+		return err
+	}
+	if err := r.Route53.Validate("route53"); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestAssumeRolesValidate(t *testing.T) {
+	testCases := []struct {
+		assumeRoles AssumeRoles
+		isValid     bool
+	}{
+		// Valid, not configured
+		{
+			assumeRoles: AssumeRoles{},
+			isValid:     true,
+		},
+		// Valid, roles in other accounts
+		{
+			assumeRoles: AssumeRoles{
+				S3:      AssumeRole{RoleARN: "arn:aws:iam::123456789012:role/kube-aws-assets"},
+				Route53: AssumeRole{RoleARN: "arn:aws:iam::123456789012:role/dns/kube-aws-dns", ExternalID: "mycluster"},
+			},
+			isValid: true,
+		},
+		// Valid, china partition
+		{
+			assumeRoles: AssumeRoles{S3: AssumeRole{RoleARN: "arn:aws-cn:iam::123456789012:role/kube-aws-assets"}},
+			isValid:     true,
+		},
+		// Invalid, not a role
+		{
+			assumeRoles: AssumeRoles{S3: AssumeRole{RoleARN: "arn:aws:iam::123456789012:user/admin"}},
+			isValid:     false,
+		},
+		// Invalid, malformed account id
+		{
+			assumeRoles: AssumeRoles{Route53: AssumeRole{RoleARN: "arn:aws:iam::1234:role/kube-aws-dns"}},
+			isValid:     false,
+		},
+		// Invalid, external id without a role
+		{
+			assumeRoles: AssumeRoles{Route53: AssumeRole{ExternalID: "mycluster"}},
+			isValid:     false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.assumeRoles.Validate()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.assumeRoles, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.assumeRoles)
+		}
+	}
+}
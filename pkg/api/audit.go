@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// auditWebhookModes are the strategies of `--audit-webhook-mode` for sending audit events to the webhook
+var auditWebhookModes = []string{"batch", "blocking", "blocking-strict"}
+
+// AuditWebhook configures the apiserver to send audit events to a remote backend, e.g. a SIEM, in addition to or instead of the audit log.
+// Both backends record the events according to the same audit policy
+type AuditWebhook struct {
+	Enabled bool `yaml:"enabled"`
+	// Config is the base64-encoded kubeconfig file specifying the remote backend, passed to `--audit-webhook-config-file`
+	Config string `yaml:"configBase64"`
+	// Mode is the strategy for sending audit events, either `batch`, `blocking` or `blocking-strict`. Defaults to the apiserver's default, `batch`
+	Mode string `yaml:"mode,omitempty"`
+	// InitialBackoff is the duration like 10s to wait before retrying the first failed request to the backend
+	InitialBackoff string `yaml:"initialBackoff,omitempty"`
+}
+
+// AuditEnabled returns true when the apiserver records audit events to any backend, which requires the audit policy file
+func (c Experimental) AuditEnabled() bool {
+	return c.AuditLog.Enabled || c.AuditWebhook.Enabled
+}
+
+func (l AuditLog) Validate() error {
+	if !l.Enabled {
+		return nil
+	}
+	if !filepath.IsAbs(l.LogPath) {
+		return fmt.Errorf("`experimental.auditLog.logPath` must be an absolute path when the audit log is enabled, but was \"%s\"", l.LogPath)
+	}
+	for _, v := range []struct {
+		name  string
+		value int
+	}{
+		{"maxAge", l.MaxAge},
+		{"maxBackup", l.MaxBackup},
+		{"maxSize", l.MaxSize},
+	} {
+		if v.value < 0 {
+			return fmt.Errorf("`experimental.auditLog.%s` must not be negative, but was %d", v.name, v.value)
+		}
+	}
+	return nil
+}
+
+func (w AuditWebhook) Validate() error {
+	if !w.Enabled {
+		return nil
+	}
+	if w.Config == "" {
+		return errors.New("`experimental.auditWebhook.configBase64` must be a base64-encoded kubeconfig file of the audit backend when the audit webhook is enabled")
+	}
+	if _, err := base64.StdEncoding.DecodeString(w.Config); err != nil {
+		return fmt.Errorf("`experimental.auditWebhook.configBase64` must be base64-encoded: %v", err)
+	}
+	if w.Mode != "" && !containsString(auditWebhookModes, w.Mode) {
+		return fmt.Errorf("invalid `experimental.auditWebhook.mode` \"%s\": it must be one of %s", w.Mode, strings.Join(auditWebhookModes, ", "))
+	}
+	if w.InitialBackoff != "" {
+		d, err := time.ParseDuration(w.InitialBackoff)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("`experimental.auditWebhook.initialBackoff` must be a positive duration like 10s, but was \"%s\"", w.InitialBackoff)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestAuditValidate(t *testing.T) {
+	testCases := []struct {
+		auditLog     AuditLog
+		auditWebhook AuditWebhook
+		isValid      bool
+	}{
+		// Valid, disabled
+		{
+			isValid: true,
+		},
+		// Valid, the audit log only
+		{
+			auditLog: AuditLog{Enabled: true, LogPath: "/var/log/kube-apiserver-audit.log", MaxAge: 30, MaxBackup: 1, MaxSize: 100},
+			isValid:  true,
+		},
+		// Valid, the audit webhook only
+		{
+			auditWebhook: AuditWebhook{Enabled: true, Config: "YXBpVmVyc2lvbjogdjE=", Mode: "blocking", InitialBackoff: "10s"},
+			isValid:      true,
+		},
+		// Valid, both backends
+		{
+			auditLog:     AuditLog{Enabled: true, LogPath: "/var/log/kube-apiserver-audit.log"},
+			auditWebhook: AuditWebhook{Enabled: true, Config: "YXBpVmVyc2lvbjogdjE="},
+			isValid:      true,
+		},
+		// Valid, the disabled webhook isn't validated
+		{
+			auditWebhook: AuditWebhook{Mode: "async"},
+			isValid:      true,
+		},
+		// Invalid, relative log path
+		{
+			auditLog: AuditLog{Enabled: true, LogPath: "audit.log"},
+			isValid:  false,
+		},
+		// Invalid, negative max age
+		{
+			auditLog: AuditLog{Enabled: true, LogPath: "/var/log/kube-apiserver-audit.log", MaxAge: -1},
+			isValid:  false,
+		},
+		// Invalid, the webhook without the config
+		{
+			auditWebhook: AuditWebhook{Enabled: true},
+			isValid:      false,
+		},
+		// Invalid, the config isn't base64-encoded
+		{
+			auditWebhook: AuditWebhook{Enabled: true, Config: "apiVersion: v1"},
+			isValid:      false,
+		},
+		// Invalid, unknown mode
+		{
+			auditWebhook: AuditWebhook{Enabled: true, Config: "YXBpVmVyc2lvbjogdjE=", Mode: "async"},
+			isValid:      false,
+		},
+		// Invalid, non-positive initial backoff
+		{
+			auditWebhook: AuditWebhook{Enabled: true, Config: "YXBpVmVyc2lvbjogdjE=", InitialBackoff: "0s"},
+			isValid:      false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		experimental := Experimental{AuditLog: testCase.auditLog, AuditWebhook: testCase.auditWebhook}
+		err := experimental.AuditLog.Validate()
+		if err == nil {
+			err = experimental.AuditWebhook.Validate()
+		}
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, experimental, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, experimental)
+		}
+	}
+}
+
+func TestExperimentalAuditEnabled(t *testing.T) {
+	if (Experimental{}).AuditEnabled() {
+		t.Error("auditing must not be enabled by default")
+	}
+	if !(Experimental{AuditLog: AuditLog{Enabled: true}}).AuditEnabled() {
+		t.Error("expected auditing to be enabled with the audit log")
+	}
+	if !(Experimental{AuditWebhook: AuditWebhook{Enabled: true}}).AuditEnabled() {
+		t.Error("expected auditing to be enabled with the audit webhook")
+	}
+}
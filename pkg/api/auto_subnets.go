@@ -0,0 +1,151 @@
+package api
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+)
+
+const (
+	// AWS allows subnets between /16 and /28
+	autoSubnetsMinPrefixLength = 16
+	autoSubnetsMaxPrefixLength = 28
+)
+
+// AutoSubnets carves a public and a private subnet per availability zone out of `vpcCIDR`, instead of the subnets listed one by one.
+// The public subnets come first in the order of the availability zones, followed by the private ones
+type AutoSubnets struct {
+	// AvailabilityZones are the availability zones each of which is given a public and a private subnet
+	AvailabilityZones []string `yaml:"availabilityZones"`
+	// PrefixLength is the size of every subnet like `24` for a /24, between 16 and 28
+	PrefixLength int `yaml:"prefixLength"`
+}
+
+// UnmarshalYAML reads either a list of subnets or `auto`, which is kept aside the subnets until they are carved
+func (ss *Subnets) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw interface{}
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+	if _, ok := raw.(map[interface{}]interface{}); !ok {
+		var subnets []Subnet
+		if err := unmarshal(&subnets); err != nil {
+			return err
+		}
+		*ss = subnets
+		return nil
+	}
+
+	var config struct {
+		Auto        *AutoSubnets `yaml:"auto"`
+		UnknownKeys `yaml:",inline"`
+	}
+	if err := unmarshal(&config); err != nil {
+		return fmt.Errorf("failed to parse `subnets.auto`: %v", err)
+	}
+	if err := config.FailWhenUnknownKeysFound("subnets"); err != nil {
+		return err
+	}
+	if config.Auto == nil {
+		return errors.New("`subnets` must be either a list of subnets or `auto`")
+	}
+	*ss = Subnets{{Auto: config.Auto}}
+	return nil
+}
+
+// Auto returns the settings of the subnets to be carved, or nil for the subnets listed one by one
+func (ss Subnets) Auto() *AutoSubnets {
+	if len(ss) == 1 {
+		return ss[0].Auto
+	}
+	return nil
+}
+
+// Carve returns the public subnets followed by the private subnets carved in order from the beginning of the VPC CIDR.
+// Each private subnet gets a NAT gateway in the public subnet in the same availability zone
+func (a AutoSubnets) Carve(vpcCIDR string) (Subnets, error) {
+	_, vpcNet, err := net.ParseCIDR(vpcCIDR)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vpcCIDR: %v", err)
+	}
+	vpcIP := vpcNet.IP.To4()
+	if vpcIP == nil {
+		return nil, fmt.Errorf("`subnets.auto` requires an IPv4 vpcCIDR, but was %s", vpcCIDR)
+	}
+
+	if len(a.AvailabilityZones) == 0 {
+		return nil, errors.New("`subnets.auto.availabilityZones` must contain one or more availability zones")
+	}
+	for i, az := range a.AvailabilityZones {
+		if az == "" {
+			return nil, fmt.Errorf("`subnets.auto.availabilityZones[%d]` must not be empty", i)
+		}
+		if containsString(a.AvailabilityZones[:i], az) {
+			return nil, fmt.Errorf("`subnets.auto.availabilityZones[%d]` \"%s\" is duplicated", i, az)
+		}
+	}
+
+	vpcPrefixLength, _ := vpcNet.Mask.Size()
+	if a.PrefixLength < autoSubnetsMinPrefixLength || a.PrefixLength > autoSubnetsMaxPrefixLength {
+		return nil, fmt.Errorf("`subnets.auto.prefixLength` must be between %d and %d, but was %d", autoSubnetsMinPrefixLength, autoSubnetsMaxPrefixLength, a.PrefixLength)
+	}
+	if a.PrefixLength < vpcPrefixLength {
+		return nil, fmt.Errorf("`subnets.auto.prefixLength` %d must be equal to or greater than the prefix length %d of vpcCIDR %s", a.PrefixLength, vpcPrefixLength, vpcCIDR)
+	}
+	count := 2 * len(a.AvailabilityZones)
+	capacity := 1 << uint(a.PrefixLength-vpcPrefixLength)
+	if count > capacity {
+		return nil, fmt.Errorf("vpcCIDR %s is too small for `subnets.auto`: it can hold only %d subnets of /%d, but %d subnets are required for the public and private subnets in %d availability zones",
+			vpcCIDR, capacity, a.PrefixLength, count, len(a.AvailabilityZones))
+	}
+
+	base := binary.BigEndian.Uint32(vpcIP)
+	size := uint32(1) << uint(32-a.PrefixLength)
+	cidr := func(i int) string {
+		ip := make(net.IP, net.IPv4len)
+		binary.BigEndian.PutUint32(ip, base+uint32(i)*size)
+		return fmt.Sprintf("%s/%d", ip, a.PrefixLength)
+	}
+
+	subnets := make(Subnets, 0, count)
+	for i, az := range a.AvailabilityZones {
+		s := NewPublicSubnet(az, cidr(i))
+		s.Name = "public-" + az
+		subnets = append(subnets, s)
+	}
+	for i, az := range a.AvailabilityZones {
+		s := NewPrivateSubnet(az, cidr(len(a.AvailabilityZones)+i))
+		s.Name = "private-" + az
+		subnets = append(subnets, s)
+	}
+	return subnets, nil
+}
+
+// carveSubnets replaces `subnets.auto` with the subnets carved out of the VPC CIDR, so that the subnets are validated and referenced
+// by their names like `public-us-west-2a` and `private-us-west-2a` just like the ones listed one by one
+func (c *Cluster) carveSubnets() error {
+	for _, s := range []struct {
+		key     string
+		subnets Subnets
+	}{
+		{"controller.subnets", c.Controller.Subnets},
+		{"controller.loadBalancer.subnets", c.Controller.LoadBalancer.Subnets},
+		{"etcd.subnets", c.Etcd.Subnets},
+	} {
+		if s.subnets.Auto() != nil {
+			return fmt.Errorf("`%s` doesn't support `auto`. Specify `auto` in the top-level `subnets` and refer to the carved subnets by their names", s.key)
+		}
+	}
+
+	auto := c.Subnets.Auto()
+	if auto == nil {
+		return nil
+	}
+	subnets, err := auto.Carve(c.VPCCIDR)
+	if err != nil {
+		return err
+	}
+	c.Subnets = subnets
+	return nil
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-yaml/yaml"
+)
+
+func TestAutoSubnetsCarve(t *testing.T) {
+	type carved struct {
+		name, az, cidr string
+		private        bool
+	}
+
+	testCases := []struct {
+		vpcCIDR  string
+		auto     AutoSubnets
+		expected []carved
+	}{
+		// Valid, three availability zones
+		{
+			vpcCIDR: "10.0.0.0/16",
+			auto:    AutoSubnets{AvailabilityZones: []string{"us-west-1a", "us-west-1b", "us-west-1c"}, PrefixLength: 24},
+			expected: []carved{
+				{"public-us-west-1a", "us-west-1a", "10.0.0.0/24", false},
+				{"public-us-west-1b", "us-west-1b", "10.0.1.0/24", false},
+				{"public-us-west-1c", "us-west-1c", "10.0.2.0/24", false},
+				{"private-us-west-1a", "us-west-1a", "10.0.3.0/24", true},
+				{"private-us-west-1b", "us-west-1b", "10.0.4.0/24", true},
+				{"private-us-west-1c", "us-west-1c", "10.0.5.0/24", true},
+			},
+		},
+		// Valid, the subnets exactly filling the VPC CIDR up to its last address
+		{
+			vpcCIDR: "10.255.255.192/26",
+			auto:    AutoSubnets{AvailabilityZones: []string{"a", "b"}, PrefixLength: 28},
+			expected: []carved{
+				{"public-a", "a", "10.255.255.192/28", false},
+				{"public-b", "b", "10.255.255.208/28", false},
+				{"private-a", "a", "10.255.255.224/28", true},
+				{"private-b", "b", "10.255.255.240/28", true},
+			},
+		},
+		// Valid, the host bits of the VPC CIDR are ignored
+		{
+			vpcCIDR: "172.16.5.10/16",
+			auto:    AutoSubnets{AvailabilityZones: []string{"a"}, PrefixLength: 17},
+			expected: []carved{
+				{"public-a", "a", "172.16.0.0/17", false},
+				{"private-a", "a", "172.16.128.0/17", true},
+			},
+		},
+		// Invalid, too small VPC CIDR
+		{
+			vpcCIDR: "10.0.0.0/23",
+			auto:    AutoSubnets{AvailabilityZones: []string{"a", "b"}, PrefixLength: 24},
+		},
+		// Invalid, the prefix length same as the VPC CIDR's, which can hold only one subnet
+		{
+			vpcCIDR: "10.0.0.0/24",
+			auto:    AutoSubnets{AvailabilityZones: []string{"a"}, PrefixLength: 24},
+		},
+		// Invalid, the prefix length shorter than the VPC CIDR's
+		{
+			vpcCIDR: "10.0.0.0/24",
+			auto:    AutoSubnets{AvailabilityZones: []string{"a"}, PrefixLength: 20},
+		},
+		// Invalid, the prefix length larger than AWS allows
+		{
+			vpcCIDR: "10.0.0.0/16",
+			auto:    AutoSubnets{AvailabilityZones: []string{"a"}, PrefixLength: 29},
+		},
+		// Invalid, the prefix length smaller than AWS allows
+		{
+			vpcCIDR: "10.0.0.0/8",
+			auto:    AutoSubnets{AvailabilityZones: []string{"a"}, PrefixLength: 15},
+		},
+		// Invalid, no prefix length
+		{
+			vpcCIDR: "10.0.0.0/16",
+			auto:    AutoSubnets{AvailabilityZones: []string{"a"}},
+		},
+		// Invalid, no availability zones
+		{
+			vpcCIDR: "10.0.0.0/16",
+			auto:    AutoSubnets{PrefixLength: 24},
+		},
+		// Invalid, duplicated availability zones
+		{
+			vpcCIDR: "10.0.0.0/16",
+			auto:    AutoSubnets{AvailabilityZones: []string{"a", "b", "a"}, PrefixLength: 24},
+		},
+		// Invalid, IPv6 VPC CIDR
+		{
+			vpcCIDR: "2001:db8::/56",
+			auto:    AutoSubnets{AvailabilityZones: []string{"a"}, PrefixLength: 24},
+		},
+		// Invalid, malformed VPC CIDR
+		{
+			vpcCIDR: "10.0.0.0",
+			auto:    AutoSubnets{AvailabilityZones: []string{"a"}, PrefixLength: 24},
+		},
+	}
+
+	for i, testCase := range testCases {
+		subnets, err := testCase.auto.Carve(testCase.vpcCIDR)
+		if testCase.expected == nil {
+			if err == nil {
+				t.Errorf("case %d: expected %+v to be invalid for %s but carved %+v", i, testCase.auto, testCase.vpcCIDR, subnets)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %d: expected %+v to be valid for %s but got an error: %v", i, testCase.auto, testCase.vpcCIDR, err)
+			continue
+		}
+		actual := make([]carved, len(subnets))
+		for j, s := range subnets {
+			actual[j] = carved{s.Name, s.AvailabilityZone, s.InstanceCIDR, s.Private}
+		}
+		if !reflect.DeepEqual(actual, testCase.expected) {
+			t.Errorf("case %d: expected %+v to be carved out of %s into %+v but was %+v", i, testCase.auto, testCase.vpcCIDR, testCase.expected, actual)
+		}
+	}
+}
+
+func TestSubnetsUnmarshalYAML(t *testing.T) {
+	var listed struct {
+		Subnets Subnets `yaml:"subnets"`
+	}
+	if err := yaml.Unmarshal([]byte("subnets:\n- name: public1\n  availabilityZone: us-west-1a\n  instanceCIDR: 10.0.0.0/24\n"), &listed); err != nil {
+		t.Fatalf("failed to parse listed subnets: %v", err)
+	}
+	if len(listed.Subnets) != 1 || listed.Subnets[0].Name != "public1" || listed.Subnets.Auto() != nil {
+		t.Errorf("unexpected listed subnets: %+v", listed.Subnets)
+	}
+
+	var auto struct {
+		Subnets Subnets `yaml:"subnets"`
+	}
+	if err := yaml.Unmarshal([]byte("subnets:\n  auto:\n    availabilityZones: [us-west-1a, us-west-1b]\n    prefixLength: 20\n"), &auto); err != nil {
+		t.Fatalf("failed to parse auto subnets: %v", err)
+	}
+	expected := &AutoSubnets{AvailabilityZones: []string{"us-west-1a", "us-west-1b"}, PrefixLength: 20}
+	if !reflect.DeepEqual(auto.Subnets.Auto(), expected) {
+		t.Errorf("expected auto subnets %+v but was %+v", expected, auto.Subnets.Auto())
+	}
+
+	var unknown struct {
+		Subnets Subnets `yaml:"subnets"`
+	}
+	if err := yaml.Unmarshal([]byte("subnets:\n  carve:\n    prefixLength: 20\n"), &unknown); err == nil {
+		t.Errorf("expected an unknown key in subnets to be an error, but was parsed into %+v", unknown.Subnets)
+	}
+}
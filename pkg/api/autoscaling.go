@@ -1,5 +1,52 @@
 package api
 
+import (
+	"fmt"
+	"strconv"
+)
+
+const (
+	LaunchTemplateVersionLatest  = "$Latest"
+	LaunchTemplateVersionDefault = "$Default"
+)
+
 type Autoscaling struct {
 	ClusterAutoscaler ClusterAutoscaler `yaml:"clusterAutoscaler,omitempty"`
+	// LaunchTemplateVersion is the version of the node pool's launch template used by the auto scaling groups.
+	// One of `$Latest`, `$Default` or a version number like `3` pinning the version. Defaults to `$Latest`
+	LaunchTemplateVersion string `yaml:"launchTemplateVersion,omitempty"`
+	// WarmPool is the pool of pre-initialized instances the auto scaling groups scale out with
+	WarmPool WarmPool `yaml:"warmPool,omitempty"`
+}
+
+// LaunchTemplateVersionPinned returns true when the auto scaling groups use a specific version of the launch template
+func (a Autoscaling) LaunchTemplateVersionPinned() bool {
+	return a.LaunchTemplateVersion != "" && a.LaunchTemplateVersion != LaunchTemplateVersionLatest && a.LaunchTemplateVersion != LaunchTemplateVersionDefault
+}
+
+// LaunchTemplateVersionAttribute returns the attribute of the launch template resolved to the version number via `Fn::GetAtt`,
+// as CloudFormation doesn't accept `$Latest` and `$Default` as the version of the launch template used by an auto scaling group
+func (a Autoscaling) LaunchTemplateVersionAttribute() string {
+	if a.LaunchTemplateVersion == LaunchTemplateVersionDefault {
+		return "DefaultVersionNumber"
+	}
+	return "LatestVersionNumber"
+}
+
+func (a Autoscaling) Validate() error {
+	if err := a.ClusterAutoscaler.Validate(); err != nil {
+		return err
+	}
+
+	if a.LaunchTemplateVersionPinned() {
+		if v, err := strconv.Atoi(a.LaunchTemplateVersion); err != nil || v < 1 {
+			return fmt.Errorf("invalid `autoscaling.launchTemplateVersion` \"%s\": it must be one of %s, %s or a version number", a.LaunchTemplateVersion, LaunchTemplateVersionLatest, LaunchTemplateVersionDefault)
+		}
+	}
+
+	if err := a.WarmPool.Validate(); err != nil {
+		return err
+	}
+
+	return nil
 }
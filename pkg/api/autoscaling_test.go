@@ -0,0 +1,53 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestAutoscalingValidate(t *testing.T) {
+	testCases := []struct {
+		autoscaling Autoscaling
+		isValid     bool
+	}{
+		// Valid, not configured
+		{
+			autoscaling: Autoscaling{},
+			isValid:     true,
+		},
+		// Valid, the latest version
+		{
+			autoscaling: Autoscaling{LaunchTemplateVersion: "$Latest"},
+			isValid:     true,
+		},
+		// Valid, the default version
+		{
+			autoscaling: Autoscaling{LaunchTemplateVersion: "$Default"},
+			isValid:     true,
+		},
+		// Valid, a pinned version
+		{
+			autoscaling: Autoscaling{LaunchTemplateVersion: "12"},
+			isValid:     true,
+		},
+		// Invalid, unknown strategy
+		{
+			autoscaling: Autoscaling{LaunchTemplateVersion: "Latest"},
+			isValid:     false,
+		},
+		// Invalid, version zero
+		{
+			autoscaling: Autoscaling{LaunchTemplateVersion: "0"},
+			isValid:     false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.autoscaling.Validate()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.autoscaling, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.autoscaling)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// AWSAPIRetryModeStandard retries failed AWS API requests with the jittered exponential backoff
+	AWSAPIRetryModeStandard = "standard"
+	// AWSAPIRetryModeAdaptive additionally slows down the requests of all the AWS service clients while AWS is throttling any of them
+	AWSAPIRetryModeAdaptive = "adaptive"
+
+	awsAPIRetryMaxRetriesLimit      = 50
+	awsAPIRetryDefaultMaxBackoff    = 20 * time.Second
+	awsAPIRetryMaxBackoffLowerLimit = time.Second
+	awsAPIRetryMaxBackoffUpperLimit = 5 * time.Minute
+)
+
+// AWSAPIRetry configures how kube-aws retries the AWS API requests failed e.g. due to throttling.
+// The AWS SDK defaults are used as-is unless any of the settings is specified
+type AWSAPIRetry struct {
+	// Mode is either `standard` or `adaptive`. Defaults to `standard`
+	Mode string `yaml:"mode,omitempty"`
+	// MaxRetries is the number of retries for each request. Defaults to 3 in the standard mode and 10 in the adaptive mode
+	MaxRetries int `yaml:"maxRetries,omitempty"`
+	// MaxBackoff is the upper limit of the delay before each retry, like `30s`. Defaults to 20s
+	MaxBackoff string `yaml:"maxBackoff,omitempty"`
+}
+
+func (r AWSAPIRetry) Enabled() bool {
+	return r.Mode != "" || r.MaxRetries != 0 || r.MaxBackoff != ""
+}
+
+func (r AWSAPIRetry) Adaptive() bool {
+	return r.Mode == AWSAPIRetryModeAdaptive
+}
+
+func (r AWSAPIRetry) MaxRetriesOrDefault() int {
+	if r.MaxRetries != 0 {
+		return r.MaxRetries
+	}
+	if r.Adaptive() {
+		return 10
+	}
+	return 3
+}
+
+func (r AWSAPIRetry) MaxBackoffOrDefault() time.Duration {
+	if d, err := time.ParseDuration(r.MaxBackoff); err == nil {
+		return d
+	}
+	return awsAPIRetryDefaultMaxBackoff
+}
+
+func (r AWSAPIRetry) Validate() error {
+	switch r.Mode {
+	case "", AWSAPIRetryModeStandard, AWSAPIRetryModeAdaptive:
+	default:
+		return fmt.Errorf("invalid `awsApiRetry.mode` \"%s\": it must be either %s or %s", r.Mode, AWSAPIRetryModeStandard, AWSAPIRetryModeAdaptive)
+	}
+
+	if r.MaxRetries < 0 || r.MaxRetries > awsAPIRetryMaxRetriesLimit {
+		return fmt.Errorf("invalid `awsApiRetry.maxRetries` %d: it must be between 1 and %d", r.MaxRetries, awsAPIRetryMaxRetriesLimit)
+	}
+
+	if r.MaxBackoff != "" {
+		d, err := time.ParseDuration(r.MaxBackoff)
+		if err != nil {
+			return fmt.Errorf("invalid `awsApiRetry.maxBackoff` \"%s\": %v", r.MaxBackoff, err)
+		}
+		if d < awsAPIRetryMaxBackoffLowerLimit || d > awsAPIRetryMaxBackoffUpperLimit {
+			return fmt.Errorf("invalid `awsApiRetry.maxBackoff` \"%s\": it must be between %v and %v", r.MaxBackoff, awsAPIRetryMaxBackoffLowerLimit, awsAPIRetryMaxBackoffUpperLimit)
+		}
+	}
+
+	return nil
+}
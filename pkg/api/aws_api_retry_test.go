@@ -0,0 +1,102 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAWSAPIRetryValidate(t *testing.T) {
+	testCases := []struct {
+		retry   AWSAPIRetry
+		isValid bool
+	}{
+		// Valid, SDK defaults
+		{
+			retry:   AWSAPIRetry{},
+			isValid: true,
+		},
+		// Valid, adaptive
+		{
+			retry:   AWSAPIRetry{Mode: "adaptive", MaxRetries: 15, MaxBackoff: "30s"},
+			isValid: true,
+		},
+		// Valid, standard with more retries
+		{
+			retry:   AWSAPIRetry{Mode: "standard", MaxRetries: 50},
+			isValid: true,
+		},
+		// Invalid, mode
+		{
+			retry:   AWSAPIRetry{Mode: "legacy"},
+			isValid: false,
+		},
+		// Invalid, negative retries
+		{
+			retry:   AWSAPIRetry{MaxRetries: -1},
+			isValid: false,
+		},
+		// Invalid, too many retries
+		{
+			retry:   AWSAPIRetry{MaxRetries: 51},
+			isValid: false,
+		},
+		// Invalid, malformed max backoff
+		{
+			retry:   AWSAPIRetry{MaxBackoff: "30"},
+			isValid: false,
+		},
+		// Invalid, too short max backoff
+		{
+			retry:   AWSAPIRetry{MaxBackoff: "100ms"},
+			isValid: false,
+		},
+		// Invalid, too long max backoff
+		{
+			retry:   AWSAPIRetry{MaxBackoff: "10m"},
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.retry.Validate()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.retry, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.retry)
+		}
+	}
+}
+
+func TestAWSAPIRetryDefaults(t *testing.T) {
+	testCases := []struct {
+		retry              AWSAPIRetry
+		expectedMaxRetries int
+		expectedMaxBackoff time.Duration
+	}{
+		{
+			retry:              AWSAPIRetry{Mode: "standard"},
+			expectedMaxRetries: 3,
+			expectedMaxBackoff: 20 * time.Second,
+		},
+		{
+			retry:              AWSAPIRetry{Mode: "adaptive"},
+			expectedMaxRetries: 10,
+			expectedMaxBackoff: 20 * time.Second,
+		},
+		{
+			retry:              AWSAPIRetry{Mode: "adaptive", MaxRetries: 15, MaxBackoff: "1m"},
+			expectedMaxRetries: 15,
+			expectedMaxBackoff: time.Minute,
+		},
+	}
+
+	for i, testCase := range testCases {
+		if actual := testCase.retry.MaxRetriesOrDefault(); actual != testCase.expectedMaxRetries {
+			t.Errorf("case %d: unexpected max retries: expected %d, got %d", i, testCase.expectedMaxRetries, actual)
+		}
+		if actual := testCase.retry.MaxBackoffOrDefault(); actual != testCase.expectedMaxBackoff {
+			t.Errorf("case %d: unexpected max backoff: expected %v, got %v", i, testCase.expectedMaxBackoff, actual)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	DefaultAWSLoadBalancerControllerIngressClass = "alb"
+)
+
+// AWSLoadBalancerController is the set of settings for the AWS Load Balancer Controller which provisions ALBs for ingresses and NLBs for services
+// See https://kubernetes-sigs.github.io/aws-load-balancer-controller/
+type AWSLoadBalancerController struct {
+	Enabled bool `yaml:"enabled"`
+	// IAMRole is the IAM role assumed by the controller via kube2iam or kiam
+	IAMRole IAMRole `yaml:"iamRole,omitempty"`
+	// UseControllerNodeRole runs the controller on controller nodes and grants the IAM role of controller nodes the permissions required by the controller,
+	// instead of assuming `iamRole`
+	UseControllerNodeRole bool `yaml:"useControllerNodeRole,omitempty"`
+	// IngressClass is the name of the IngressClass handled by the controller. Defaults to `alb`
+	IngressClass string `yaml:"ingressClass,omitempty"`
+	UnknownKeys  `yaml:",inline"`
+}
+
+func (c AWSLoadBalancerController) IngressClassOrDefault() string {
+	if c.IngressClass == "" {
+		return DefaultAWSLoadBalancerControllerIngressClass
+	}
+	return c.IngressClass
+}
+
+func (c AWSLoadBalancerController) Validate(k8sVer string, experimental Experimental) error {
+	if !c.Enabled {
+		return nil
+	}
+
+	supported, err := k8sVersionSatisfies(">= 1.19", k8sVer)
+	if err != nil {
+		return err
+	}
+	if !supported {
+		return fmt.Errorf("`addons.awsLoadBalancerController` requires kubernetesVersion 1.19 or greater, but was %s", k8sVer)
+	}
+
+	if c.IAMRole.Arn == "" && !c.UseControllerNodeRole {
+		return errors.New("`addons.awsLoadBalancerController` requires an IAM role for the controller: specify either `iamRole.arn` assumed via kube2iam or kiam, or `useControllerNodeRole: true`")
+	}
+	if c.IAMRole.Arn != "" && c.UseControllerNodeRole {
+		return errors.New("`addons.awsLoadBalancerController.iamRole.arn` and `addons.awsLoadBalancerController.useControllerNodeRole` are mutually exclusive")
+	}
+	if c.IAMRole.Arn != "" && !experimental.Kube2IamSupport.Enabled && !experimental.KIAMSupport.Enabled {
+		return errors.New("`addons.awsLoadBalancerController.iamRole.arn` requires either `kube2IamSupport` or `kiamSupport` to be enabled")
+	}
+	if c.IAMRole.Arn != "" && !iamRoleARNRegexp.MatchString(c.IAMRole.Arn) {
+		return fmt.Errorf("invalid `addons.awsLoadBalancerController.iamRole.arn` \"%s\": it must be an IAM role ARN like arn:aws:iam::123456789012:role/name", c.IAMRole.Arn)
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestAWSLoadBalancerControllerValidate(t *testing.T) {
+	roleARN := "arn:aws:iam::123456789012:role/aws-load-balancer-controller"
+	kube2iam := Experimental{Kube2IamSupport: Kube2IamSupport{Enabled: true}}
+	kiam := Experimental{KIAMSupport: KIAMSupport{Enabled: true}}
+
+	testCases := []struct {
+		controller   AWSLoadBalancerController
+		k8sVer       string
+		experimental Experimental
+		isValid      bool
+	}{
+		// Valid, disabled
+		{
+			controller: AWSLoadBalancerController{},
+			k8sVer:     "v1.11.3",
+			isValid:    true,
+		},
+		// Valid, the controller node role
+		{
+			controller: AWSLoadBalancerController{Enabled: true, UseControllerNodeRole: true},
+			k8sVer:     "v1.19.4",
+			isValid:    true,
+		},
+		// Valid, the role assumed via kube2iam
+		{
+			controller:   AWSLoadBalancerController{Enabled: true, IAMRole: IAMRole{ARN: ARN{Arn: roleARN}}},
+			k8sVer:       "v1.20.2",
+			experimental: kube2iam,
+			isValid:      true,
+		},
+		// Valid, the role assumed via kiam
+		{
+			controller:   AWSLoadBalancerController{Enabled: true, IAMRole: IAMRole{ARN: ARN{Arn: roleARN}}, IngressClass: "alb-internal"},
+			k8sVer:       "v1.20.2",
+			experimental: kiam,
+			isValid:      true,
+		},
+		// Invalid, unsupported kubernetes version
+		{
+			controller: AWSLoadBalancerController{Enabled: true, UseControllerNodeRole: true},
+			k8sVer:     "v1.18.8",
+			isValid:    false,
+		},
+		// Invalid, no IAM role
+		{
+			controller: AWSLoadBalancerController{Enabled: true},
+			k8sVer:     "v1.20.2",
+			isValid:    false,
+		},
+		// Invalid, both the role and the controller node role
+		{
+			controller:   AWSLoadBalancerController{Enabled: true, IAMRole: IAMRole{ARN: ARN{Arn: roleARN}}, UseControllerNodeRole: true},
+			k8sVer:       "v1.20.2",
+			experimental: kube2iam,
+			isValid:      false,
+		},
+		// Invalid, the role without kube2iam or kiam
+		{
+			controller: AWSLoadBalancerController{Enabled: true, IAMRole: IAMRole{ARN: ARN{Arn: roleARN}}},
+			k8sVer:     "v1.20.2",
+			isValid:    false,
+		},
+		// Invalid, malformed role ARN
+		{
+			controller:   AWSLoadBalancerController{Enabled: true, IAMRole: IAMRole{ARN: ARN{Arn: "aws-load-balancer-controller"}}},
+			k8sVer:       "v1.20.2",
+			experimental: kube2iam,
+			isValid:      false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.controller.Validate(testCase.k8sVer, testCase.experimental)
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid for kubernetes %s but got an error: %v", i, testCase.controller, testCase.k8sVer, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid for kubernetes %s but was not", i, testCase.controller, testCase.k8sVer)
+		}
+	}
+}
+
+func TestAWSLoadBalancerControllerIngressClassOrDefault(t *testing.T) {
+	if c := (AWSLoadBalancerController{}).IngressClassOrDefault(); c != "alb" {
+		t.Errorf("expected the default ingress class to be alb but was %s", c)
+	}
+	if c := (AWSLoadBalancerController{IngressClass: "alb-internal"}).IngressClassOrDefault(); c != "alb-internal" {
+		t.Errorf("expected the ingress class to be alb-internal but was %s", c)
+	}
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"fmt"
+)
+
+const (
+	// DefaultBootstrapTaintKey is the key of the taint added to nodes while they are bootstrapping, unless customized
+	DefaultBootstrapTaintKey = "node.kube-aws.io/uninitialized"
+	// BootstrapTaintEffect is the effect of the bootstrap taint. It is a soft preference, so that pods not tolerating it are still
+	// scheduled onto bootstrapping nodes when there are no other nodes to run them
+	BootstrapTaintEffect = "PreferNoSchedule"
+)
+
+// BootstrapTaint is the taint kubelet registers nodes with, and which is removed by a systemd unit once the node completes its bootstrap
+type BootstrapTaint struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// Key defaults to `node.kube-aws.io/uninitialized`
+	Key string `yaml:"key,omitempty"`
+}
+
+func (b BootstrapTaint) Taint() Taint {
+	key := b.Key
+	if key == "" {
+		key = DefaultBootstrapTaintKey
+	}
+	return Taint{
+		Key:    key,
+		Effect: BootstrapTaintEffect,
+	}
+}
+
+// RegisterWithTaints returns the taints nodes should be registered with, that is the specified taints plus the bootstrap taint if enabled
+func (b BootstrapTaint) RegisterWithTaints(taints Taints) Taints {
+	if !b.Enabled {
+		return taints
+	}
+	return append(append(Taints{}, taints...), b.Taint())
+}
+
+// Validate returns an error if the bootstrap taint is invalid or conflicts with the taints explicitly specified for the node pool
+func (b BootstrapTaint) Validate(s NodeSettings) error {
+	if !b.Enabled {
+		if b.Key != "" {
+			return fmt.Errorf("`bootstrapTaint.enabled` must be true to specify `bootstrapTaint.key`")
+		}
+		return nil
+	}
+
+	taint := b.Taint()
+	if err := taint.Validate(); err != nil {
+		return fmt.Errorf("invalid `bootstrapTaint`: %v", err)
+	}
+	for _, t := range s.Taints {
+		if t.Key == taint.Key && t.Effect == taint.Effect {
+			return fmt.Errorf("`taints` contains \"%s\" which conflicts with the bootstrap taint \"%s\" removed once nodes complete bootstrapping", t.String(), taint.String())
+		}
+	}
+
+	return nil
+}
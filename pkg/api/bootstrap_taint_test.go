@@ -0,0 +1,75 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBootstrapTaintValidate(t *testing.T) {
+	testCases := []struct {
+		bootstrapTaint BootstrapTaint
+		settings       NodeSettings
+		isValid        bool
+	}{
+		// Valid, disabled
+		{
+			bootstrapTaint: BootstrapTaint{},
+			settings:       NodeSettings{Taints: Taints{{Key: DefaultBootstrapTaintKey, Effect: "PreferNoSchedule"}}},
+			isValid:        true,
+		},
+		// Valid, no conflict
+		{
+			bootstrapTaint: BootstrapTaint{Enabled: true},
+			settings:       NodeSettings{Taints: Taints{{Key: DefaultBootstrapTaintKey, Value: "true", Effect: "NoSchedule"}}},
+			isValid:        true,
+		},
+		// Valid, custom key
+		{
+			bootstrapTaint: BootstrapTaint{Enabled: true, Key: "example.com/bootstrapping"},
+			settings:       NodeSettings{Taints: Taints{{Key: DefaultBootstrapTaintKey, Effect: "PreferNoSchedule"}}},
+			isValid:        true,
+		},
+		// Invalid, key without enabling the bootstrap taint
+		{
+			bootstrapTaint: BootstrapTaint{Key: "example.com/bootstrapping"},
+			isValid:        false,
+		},
+		// Invalid, conflicting taint
+		{
+			bootstrapTaint: BootstrapTaint{Enabled: true},
+			settings:       NodeSettings{Taints: Taints{{Key: DefaultBootstrapTaintKey, Value: "true", Effect: "PreferNoSchedule"}}},
+			isValid:        false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.bootstrapTaint.Validate(testCase.settings)
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.bootstrapTaint, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.bootstrapTaint)
+		}
+	}
+}
+
+func TestBootstrapTaintRegisterWithTaints(t *testing.T) {
+	taints := Taints{{Key: "dedicated", Value: "search", Effect: "NoSchedule"}}
+
+	disabled := BootstrapTaint{}.RegisterWithTaints(taints)
+	if !reflect.DeepEqual(disabled, taints) {
+		t.Errorf("unexpected taints: expected=%v actual=%v", taints, disabled)
+	}
+
+	enabled := BootstrapTaint{Enabled: true}.RegisterWithTaints(taints)
+	expected := Taints{
+		{Key: "dedicated", Value: "search", Effect: "NoSchedule"},
+		{Key: DefaultBootstrapTaintKey, Effect: "PreferNoSchedule"},
+	}
+	if !reflect.DeepEqual(enabled, expected) {
+		t.Errorf("unexpected taints: expected=%v actual=%v", expected, enabled)
+	}
+	if len(taints) != 1 {
+		t.Errorf("the original taints shouldn't be modified: %v", taints)
+	}
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/semver"
+)
+
+const (
+	NodePoolOSFlatcar      = "flatcar"
+	NodePoolOSBottlerocket = "bottlerocket"
+
+	// awsIAMAuthenticatorPluginKey is the key of the aws-iam-authenticator plugin under `kubeAwsPlugins`,
+	// which Bottlerocket nodes rely on to authenticate kubelets with their IAM roles
+	awsIAMAuthenticatorPluginKey = "awsIamAuthenticator"
+
+	// BottlerocketRootVolumeDeviceName is the data volume of Bottlerocket nodes, to which `rootVolume` is mapped.
+	// `/dev/xvda` of Bottlerocket AMIs is the small OS volume containing nothing written by pods
+	BottlerocketRootVolumeDeviceName = "/dev/xvdb"
+)
+
+// Bottlerocket is the settings of node pools running Bottlerocket, specified by `os: bottlerocket`
+type Bottlerocket struct {
+	// AdminContainer is the host container which is accessible via SSH with the key pair specified by `keyName`. Defaults to disabled
+	AdminContainer BottlerocketHostContainer `yaml:"adminContainer,omitempty"`
+	// ControlContainer is the host container running the SSM agent, which allows `aws ssm start-session` into nodes. Defaults to enabled
+	ControlContainer BottlerocketHostContainer `yaml:"controlContainer,omitempty"`
+}
+
+type BottlerocketHostContainer struct {
+	Enabled *bool `yaml:"enabled,omitempty"`
+}
+
+func (c BottlerocketHostContainer) EnabledOr(defaultValue bool) bool {
+	if c.Enabled == nil {
+		return defaultValue
+	}
+	return *c.Enabled
+}
+
+func (b Bottlerocket) AdminContainerEnabled() bool {
+	return b.AdminContainer.EnabledOr(false)
+}
+
+func (b Bottlerocket) ControlContainerEnabled() bool {
+	return b.ControlContainer.EnabledOr(true)
+}
+
+// IsBottlerocket returns true when the nodes in the pool run Bottlerocket instead of Flatcar
+func (c WorkerNodePool) IsBottlerocket() bool {
+	return c.OS == NodePoolOSBottlerocket
+}
+
+// RootVolumeDeviceName returns the device `rootVolume` is mapped to
+func (c WorkerNodePool) RootVolumeDeviceName() string {
+	if c.IsBottlerocket() {
+		return BottlerocketRootVolumeDeviceName
+	}
+	if c.IsWindows() {
+		return WindowsRootVolumeDeviceName
+	}
+	return "/dev/xvda"
+}
+
+// BottlerocketAMISSMParameter returns the SSM parameter AWS publishes the latest Bottlerocket AMI for the Kubernetes version and the instance type in
+func BottlerocketAMISSMParameter(k8sVer string, instanceType string) (string, error) {
+	v, err := semver.NewVersion(k8sVer)
+	if err != nil {
+		return "", fmt.Errorf("invalid kubernetesVersion \"%s\": %v", k8sVer, err)
+	}
+	arch := "x86_64"
+	if instanceTypeArchitecture(instanceType) == "arm64" {
+		arch = "arm64"
+	}
+	return fmt.Sprintf("/aws/service/bottlerocket/aws-k8s-%d.%d/%s/latest/image_id", v.Major(), v.Minor(), arch), nil
+}
+
+func (c WorkerNodePool) validateOS() error {
+	switch c.OS {
+	case "", NodePoolOSFlatcar:
+	case NodePoolOSBottlerocket:
+	default:
+		return fmt.Errorf("invalid `os` \"%s\": it must be either \"%s\" or \"%s\"", c.OS, NodePoolOSFlatcar, NodePoolOSBottlerocket)
+	}
+	if !c.IsBottlerocket() && (c.Bottlerocket.AdminContainer.Enabled != nil || c.Bottlerocket.ControlContainer.Enabled != nil) {
+		return errors.New("`bottlerocket` can only be specified with `os: bottlerocket`")
+	}
+	return nil
+}
+
+// ValidateBottlerocket returns an error when the node pool relies on the settings which are only applied to Flatcar nodes via cloud-config,
+// or the cluster lacks what Bottlerocket nodes need to join it
+func (c WorkerNodePool) ValidateBottlerocket(k8sVer string, plugins PluginConfigs) error {
+	if v, err := semver.NewVersion(k8sVer); err == nil && v.LessThan(semver.MustParse("1.15.0")) {
+		return fmt.Errorf("`os: bottlerocket` requires Kubernetes 1.15 or greater, but kubernetesVersion was %s", k8sVer)
+	}
+	// Bottlerocket kubelets authenticate with the IAM roles of the nodes, as the TLS bootstrap token can't be decrypted with KMS on the nodes
+	if p, ok := plugins[awsIAMAuthenticatorPluginKey]; !ok || !p.Enabled {
+		return fmt.Errorf("`os: bottlerocket` requires `kubeAwsPlugins.%s.enabled` to be true so that kubelets authenticate with the IAM roles of the nodes", awsIAMAuthenticatorPluginKey)
+	}
+
+	unsupported := map[string]bool{
+		"spotFleet":          c.SpotFleet.Enabled(),
+		"customFiles":        len(c.CustomFiles) > 0,
+		"customSystemdUnits": len(c.CustomSystemdUnits) > 0,
+		"volumeMounts":       len(c.VolumeMounts) > 0,
+		"raid0Mounts":        len(c.Raid0Mounts) > 0,
+		"gpu.nvidia.enabled": c.Gpu.Nvidia.Enabled,
+		"sandboxRuntimes":    len(c.SandboxRuntimes) > 0,
+		"containerRuntime":   c.ContainerRuntime != "",
+		"instanceScript":     c.InstanceScript != (InstanceScript{}),
+		// Nothing removes the bootstrap taint from Bottlerocket nodes
+		"bootstrapTaint.enabled": c.BootstrapTaint.Enabled,
+		// Bottlerocket signals the auto scaling group by its fixed logical name, and nothing completes the lifecycle hook of the warm pool
+		"autoScalingGroup.subnetWeights": c.SubnetWeightsEnabled(),
+		"autoscaling.warmPool.enabled":   c.Autoscaling.WarmPool.Enabled,
+	}
+	for _, k := range []string{"spotFleet", "customFiles", "customSystemdUnits", "volumeMounts", "raid0Mounts", "gpu.nvidia.enabled", "sandboxRuntimes", "containerRuntime", "instanceScript", "bootstrapTaint.enabled", "autoScalingGroup.subnetWeights", "autoscaling.warmPool.enabled"} {
+		if unsupported[k] {
+			return fmt.Errorf("`%s` isn't supported with `os: bottlerocket`, whose nodes are configured by the Bottlerocket settings instead of cloud-config", k)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestBottlerocketAMISSMParameter(t *testing.T) {
+	testCases := []struct {
+		k8sVer       string
+		instanceType string
+		expected     string
+	}{
+		{
+			k8sVer:       "v1.16.2",
+			instanceType: "t3.medium",
+			expected:     "/aws/service/bottlerocket/aws-k8s-1.16/x86_64/latest/image_id",
+		},
+		{
+			k8sVer:       "v1.17.0",
+			instanceType: "m6g.large",
+			expected:     "/aws/service/bottlerocket/aws-k8s-1.17/arm64/latest/image_id",
+		},
+	}
+
+	for i, testCase := range testCases {
+		actual, err := BottlerocketAMISSMParameter(testCase.k8sVer, testCase.instanceType)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if actual != testCase.expected {
+			t.Errorf("case %d: expected %s but got %s", i, testCase.expected, actual)
+		}
+	}
+
+	if _, err := BottlerocketAMISSMParameter("latest", "t3.medium"); err == nil {
+		t.Errorf("expected an error for the invalid kubernetes version but got none")
+	}
+}
+
+func TestWorkerNodePoolValidateOS(t *testing.T) {
+	enabled := true
+	testCases := []struct {
+		pool    WorkerNodePool
+		isValid bool
+	}{
+		// Valid, defaults to flatcar
+		{
+			pool:    WorkerNodePool{},
+			isValid: true,
+		},
+		// Valid, bottlerocket with host containers
+		{
+			pool:    WorkerNodePool{OS: NodePoolOSBottlerocket, Bottlerocket: Bottlerocket{AdminContainer: BottlerocketHostContainer{Enabled: &enabled}}},
+			isValid: true,
+		},
+		// Invalid, unknown os
+		{
+			pool:    WorkerNodePool{OS: "ubuntu"},
+			isValid: false,
+		},
+		// Invalid, bottlerocket settings for flatcar nodes
+		{
+			pool:    WorkerNodePool{OS: NodePoolOSFlatcar, Bottlerocket: Bottlerocket{AdminContainer: BottlerocketHostContainer{Enabled: &enabled}}},
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.pool.validateOS()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.pool, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.pool)
+		}
+	}
+}
+
+func TestWorkerNodePoolValidateBottlerocket(t *testing.T) {
+	plugins := PluginConfigs{awsIAMAuthenticatorPluginKey: {Enabled: true}}
+	testCases := []struct {
+		pool    WorkerNodePool
+		k8sVer  string
+		plugins PluginConfigs
+		isValid bool
+	}{
+		// Valid
+		{
+			pool:    WorkerNodePool{OS: NodePoolOSBottlerocket},
+			k8sVer:  "v1.16.2",
+			plugins: plugins,
+			isValid: true,
+		},
+		// Invalid, kubernetes version too old
+		{
+			pool:    WorkerNodePool{OS: NodePoolOSBottlerocket},
+			k8sVer:  "v1.14.3",
+			plugins: plugins,
+			isValid: false,
+		},
+		// Invalid, without aws-iam-authenticator
+		{
+			pool:    WorkerNodePool{OS: NodePoolOSBottlerocket},
+			k8sVer:  "v1.16.2",
+			plugins: PluginConfigs{},
+			isValid: false,
+		},
+		// Invalid, custom files written via cloud-config
+		{
+			pool:    WorkerNodePool{OS: NodePoolOSBottlerocket, CustomFiles: []CustomFile{{Path: "/etc/foo"}}},
+			k8sVer:  "v1.16.2",
+			plugins: plugins,
+			isValid: false,
+		},
+		// Invalid, bootstrap taint removed by a systemd unit
+		{
+			pool:    WorkerNodePool{OS: NodePoolOSBottlerocket, BootstrapTaint: BootstrapTaint{Enabled: true}},
+			k8sVer:  "v1.16.2",
+			plugins: plugins,
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.pool.ValidateBottlerocket(testCase.k8sVer, testCase.plugins)
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.pool, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.pool)
+		}
+	}
+}
@@ -3,4 +3,11 @@ package api
 type CloudFormation struct {
 	RoleARN            string             `yaml:"roleARN,omitempty"`
 	StackNameOverrides StackNameOverrides `yaml:"stackNameOverrides,omitempty"`
+	// TerminationProtection enables CloudFormation termination protection on the root stack.
+	// Nested stacks are protected as well, as their protection is always managed by the root stack.
+	TerminationProtection bool `yaml:"terminationProtection,omitempty"`
+	// StackDependencies are the additional dependencies among the nested stacks and the resources added to the root stack by plugins
+	StackDependencies StackDependencies `yaml:"stackDependencies,omitempty"`
+	// Transforms are the macros every stack template is processed with, which requires the CAPABILITY_AUTO_EXPAND capability on the root stack
+	Transforms CloudFormationTransforms `yaml:"transforms,omitempty"`
 }
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// cfnMacroNamePattern matches names of CloudFormation macros, including the ones hosted by AWS like `AWS::Serverless-2016-10-31`
+var cfnMacroNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+(::[A-Za-z0-9_-]+)*$`)
+
+// CloudFormationTransform is a CloudFormation macro declared in the `Transform` section of every stack template kube-aws generates
+type CloudFormationTransform struct {
+	// Name is the name of the macro like `MyOrgPolicyInjection`
+	Name string `yaml:"name"`
+	// Parameters are passed to the macro as its `params`
+	Parameters map[string]interface{} `yaml:"parameters,omitempty"`
+}
+
+type CloudFormationTransforms []CloudFormationTransform
+
+// Section returns the value of the `Transform` section of a stack template, in which a macro is declared by its name,
+// or by an object containing the name and the parameters when it has parameters
+func (ts CloudFormationTransforms) Section() []interface{} {
+	section := make([]interface{}, len(ts))
+	for i, t := range ts {
+		if len(t.Parameters) == 0 {
+			section[i] = t.Name
+			continue
+		}
+		section[i] = map[string]interface{}{
+			"Name":       t.Name,
+			"Parameters": normalizeYAMLValue(t.Parameters),
+		}
+	}
+	return section
+}
+
+func (ts CloudFormationTransforms) Validate() error {
+	names := map[string]bool{}
+	for i, t := range ts {
+		if len(t.Name) > 255 || !cfnMacroNamePattern.MatchString(t.Name) {
+			return fmt.Errorf("invalid `cloudformation.transforms[%d].name` \"%s\": it must be a macro name consisting of up to 255 alphanumeric characters, hyphens, underscores and `::` separators", i, t.Name)
+		}
+		if names[t.Name] {
+			return fmt.Errorf("invalid `cloudformation.transforms[%d]`: duplicate transform \"%s\"", i, t.Name)
+		}
+		names[t.Name] = true
+	}
+	return nil
+}
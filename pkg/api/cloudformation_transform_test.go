@@ -0,0 +1,82 @@
+package api
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCloudFormationTransformsValidate(t *testing.T) {
+	testCases := []struct {
+		transforms CloudFormationTransforms
+		isValid    bool
+	}{
+		// Valid, not configured
+		{
+			transforms: CloudFormationTransforms{},
+			isValid:    true,
+		},
+		// Valid, custom macro with parameters
+		{
+			transforms: CloudFormationTransforms{{Name: "MyOrg-Policy_Injection", Parameters: map[string]interface{}{"CostCenter": "1234"}}},
+			isValid:    true,
+		},
+		// Valid, macros hosted by AWS
+		{
+			transforms: CloudFormationTransforms{{Name: "AWS::Serverless-2016-10-31"}, {Name: "AWS::LanguageExtensions"}},
+			isValid:    true,
+		},
+		// Invalid, empty name
+		{
+			transforms: CloudFormationTransforms{{Name: ""}},
+			isValid:    false,
+		},
+		// Invalid, unsupported characters
+		{
+			transforms: CloudFormationTransforms{{Name: "My Macro"}},
+			isValid:    false,
+		},
+		// Invalid, dangling separator
+		{
+			transforms: CloudFormationTransforms{{Name: "AWS::"}},
+			isValid:    false,
+		},
+		// Invalid, too long
+		{
+			transforms: CloudFormationTransforms{{Name: strings.Repeat("a", 256)}},
+			isValid:    false,
+		},
+		// Invalid, duplicate
+		{
+			transforms: CloudFormationTransforms{{Name: "MyMacro"}, {Name: "MyMacro"}},
+			isValid:    false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.transforms.Validate()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.transforms, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.transforms)
+		}
+	}
+}
+
+func TestCloudFormationTransformsSection(t *testing.T) {
+	transforms := CloudFormationTransforms{
+		{Name: "AWS::Serverless-2016-10-31"},
+		{Name: "MyMacro", Parameters: map[string]interface{}{"Tags": map[interface{}]interface{}{"team": "infra"}}},
+	}
+	expected := []interface{}{
+		"AWS::Serverless-2016-10-31",
+		map[string]interface{}{
+			"Name":       "MyMacro",
+			"Parameters": map[string]interface{}{"Tags": map[string]interface{}{"team": "infra"}},
+		},
+	}
+	if actual := transforms.Section(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v but was %+v", expected, actual)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/Masterminds/semver"
 	"github.com/kubernetes-incubator/kube-aws/cfnresource"
@@ -19,11 +20,12 @@ const (
 	k8sVer = "v1.11.3"
 
 	// Experimental SelfHosting feature default images.
-	kubeNetworkingSelfHostingDefaultCalicoNodeImageTag = "v3.2.3"
-	kubeNetworkingSelfHostingDefaultCalicoCniImageTag  = "v3.2.3"
-	kubeNetworkingSelfHostingDefaultFlannelImageTag    = "v0.10.0"
-	kubeNetworkingSelfHostingDefaultFlannelCniImageTag = "v0.3.0"
-	kubeNetworkingSelfHostingDefaultTyphaImageTag      = "v3.2.3"
+	kubeNetworkingSelfHostingDefaultCalicoNodeImageTag   = "v3.2.3"
+	kubeNetworkingSelfHostingDefaultCalicoCniImageTag    = "v3.2.3"
+	kubeNetworkingSelfHostingDefaultFlannelImageTag      = "v0.10.0"
+	kubeNetworkingSelfHostingDefaultFlannelCniImageTag   = "v0.3.0"
+	kubeNetworkingSelfHostingDefaultTyphaImageTag        = "v3.2.3"
+	kubeNetworkingSelfHostingDefaultAmazonVPCCNIImageTag = "1.2.0"
 )
 
 func NewDefaultCluster() *Cluster {
@@ -31,8 +33,8 @@ func NewDefaultCluster() *Cluster {
 		RotateCerts: RotateCerts{
 			Enabled: false,
 		},
-		SystemReservedResources: "",
-		KubeReservedResources:   "",
+		SystemReservedResources: ReservedResources{},
+		KubeReservedResources:   ReservedResources{},
 	}
 	experimental := Experimental{
 		Admission: Admission{
@@ -208,13 +210,14 @@ func NewDefaultCluster() *Cluster {
 						Enabled: false,
 					},
 					SelfHosting: SelfHosting{
-						Type:            "canal",
-						Typha:           false,
-						CalicoNodeImage: Image{Repo: "quay.io/calico/node", Tag: kubeNetworkingSelfHostingDefaultCalicoNodeImageTag, RktPullDocker: false},
-						CalicoCniImage:  Image{Repo: "quay.io/calico/cni", Tag: kubeNetworkingSelfHostingDefaultCalicoCniImageTag, RktPullDocker: false},
-						FlannelImage:    Image{Repo: "quay.io/coreos/flannel", Tag: kubeNetworkingSelfHostingDefaultFlannelImageTag, RktPullDocker: false},
-						FlannelCniImage: Image{Repo: "quay.io/coreos/flannel-cni", Tag: kubeNetworkingSelfHostingDefaultFlannelCniImageTag, RktPullDocker: false},
-						TyphaImage:      Image{Repo: "quay.io/calico/typha", Tag: kubeNetworkingSelfHostingDefaultTyphaImageTag, RktPullDocker: false},
+						Type:              "canal",
+						Typha:             false,
+						CalicoNodeImage:   Image{Repo: "quay.io/calico/node", Tag: kubeNetworkingSelfHostingDefaultCalicoNodeImageTag, RktPullDocker: false},
+						CalicoCniImage:    Image{Repo: "quay.io/calico/cni", Tag: kubeNetworkingSelfHostingDefaultCalicoCniImageTag, RktPullDocker: false},
+						FlannelImage:      Image{Repo: "quay.io/coreos/flannel", Tag: kubeNetworkingSelfHostingDefaultFlannelImageTag, RktPullDocker: false},
+						FlannelCniImage:   Image{Repo: "quay.io/coreos/flannel-cni", Tag: kubeNetworkingSelfHostingDefaultFlannelCniImageTag, RktPullDocker: false},
+						TyphaImage:        Image{Repo: "quay.io/calico/typha", Tag: kubeNetworkingSelfHostingDefaultTyphaImageTag, RktPullDocker: false},
+						AmazonVPCCNIImage: Image{Repo: defaultAmazonVPCCNIImageRepo, Tag: kubeNetworkingSelfHostingDefaultAmazonVPCCNIImageTag, RktPullDocker: false},
 					},
 				},
 			},
@@ -238,6 +241,13 @@ func NewDefaultCluster() *Cluster {
 			KubernetesDashboardImage:           Image{Repo: "k8s.gcr.io/kubernetes-dashboard-amd64", Tag: "v1.10.1", RktPullDocker: false},
 			PauseImage:                         Image{Repo: "k8s.gcr.io/pause-amd64", Tag: "3.1", RktPullDocker: false},
 			JournaldCloudWatchLogsImage:        Image{Repo: "jollinshead/journald-cloudwatch-logs", Tag: "0.1", RktPullDocker: true},
+			AWSLoadBalancerControllerImage:     Image{Repo: "public.ecr.aws/eks/aws-load-balancer-controller", Tag: "v2.4.7", RktPullDocker: false},
+			PodIdentityWebhookImage:            Image{Repo: "amazon/amazon-eks-pod-identity-webhook", Tag: "v0.5.0", RktPullDocker: false},
+			EFSCSIDriverImage:                  Image{Repo: "public.ecr.aws/efs-csi-driver/amazon/aws-efs-csi-driver", Tag: "v1.5.4", RktPullDocker: false},
+			CSIProvisionerImage:                Image{Repo: "registry.k8s.io/sig-storage/csi-provisioner", Tag: "v3.4.0", RktPullDocker: false},
+			CSINodeDriverRegistrarImage:        Image{Repo: "registry.k8s.io/sig-storage/csi-node-driver-registrar", Tag: "v2.7.0", RktPullDocker: false},
+			CSILivenessProbeImage:              Image{Repo: "registry.k8s.io/sig-storage/livenessprobe", Tag: "v2.9.0", RktPullDocker: false},
+			NvidiaGPUDevicePluginImage:         Image{Repo: "registry.k8s.io/nvidia-gpu-device-plugin", Tag: "sha256:0842734032018be107fa2490c98156992911e3e1f2a21e059ff0105b07dd8e9e", RktPullDocker: false},
 		},
 		KubeClusterSettings: KubeClusterSettings{
 			PodCIDR:      "10.2.0.0/16",
@@ -291,6 +301,10 @@ func (c Cluster) ControlPlaneStackName() string {
 func (c *Cluster) Load() error {
 	cpStackName := c.ControlPlaneStackName()
 
+	if err := c.carveSubnets(); err != nil {
+		return fmt.Errorf("invalid cluster: %v", err)
+	}
+
 	// If the user specified no subnets, we assume that a single AZ configuration with the default instanceCIDR is demanded
 	if len(c.Subnets) == 0 && c.InstanceCIDR == "" {
 		c.InstanceCIDR = "10.0.0.0/24"
@@ -300,6 +314,18 @@ func (c *Cluster) Load() error {
 
 	c.ConsumeDeprecatedKeys()
 
+	c.Kubernetes.Networking.consumeNetworkPluginType()
+
+	if err := c.consumeIRSA(); err != nil {
+		return fmt.Errorf("invalid cluster: %v", err)
+	}
+
+	if err := c.consumeNodePoolClusterAutoscaler(); err != nil {
+		return fmt.Errorf("invalid cluster: %v", err)
+	}
+
+	c.defaultHealthCheckTargetsToReadiness()
+
 	if err := c.validate(cpStackName); err != nil {
 		return fmt.Errorf("invalid cluster: %v", err)
 	}
@@ -308,6 +334,11 @@ func (c *Cluster) Load() error {
 		return fmt.Errorf("invalid cluster: %v", err)
 	}
 
+	// Validated after defaulting so that the subnets are linked to the top-level ones carrying their availability zones
+	if err := c.validateControllerSubnets(); err != nil {
+		return fmt.Errorf("invalid cluster: %v", err)
+	}
+
 	return nil
 }
 
@@ -338,6 +369,10 @@ func (c *Cluster) SetDefaults() error {
 		}
 	}
 
+	if c.Controller.DedicatedSubnets && len(c.Controller.Subnets) == 0 {
+		return errors.New("`controller.subnets` must be specified when `controller.dedicatedSubnets` is true")
+	}
+
 	for i, s := range c.Controller.Subnets {
 		linkedSubnet := c.FindSubnetMatching(s)
 		c.Controller.Subnets[i] = linkedSubnet
@@ -372,6 +407,8 @@ func (c *Cluster) SetDefaults() error {
 		}
 	}
 
+	c.Etcd.SetDefaultDataVolumeIOPS()
+
 	if len(c.Etcd.Subnets) == 0 {
 		c.Etcd.Subnets = c.PublicSubnets()
 
@@ -403,7 +440,7 @@ func (c *Cluster) SetDefaults() error {
 		)
 	}
 
-	if c.Addons.MetricsServer.Enabled {
+	if c.Addons.MetricsServer.Enabled || c.Controller.Aggregation.Enabled {
 		c.Addons.APIServerAggregator.Enabled = true
 	}
 
@@ -439,19 +476,23 @@ type ComputedDeploymentSettings struct {
 // Though it is highly configurable, it's basically users' responsibility to provide `correct` values if they're going beyond the defaults.
 type DeploymentSettings struct {
 	ComputedDeploymentSettings
-	CloudFormation                        CloudFormation  `yaml:"cloudformation,omitempty"`
-	ClusterName                           string          `yaml:"clusterName,omitempty"`
-	S3URI                                 string          `yaml:"s3URI,omitempty"`
-	DisableContainerLinuxAutomaticUpdates string          `yaml:"disableContainerLinuxAutomaticUpdates,omitempty"`
-	KeyName                               string          `yaml:"keyName,omitempty"`
-	Region                                Region          `yaml:",inline"`
-	AvailabilityZone                      string          `yaml:"availabilityZone,omitempty"`
-	ReleaseChannel                        string          `yaml:"releaseChannel,omitempty"`
-	AmiId                                 string          `yaml:"amiId,omitempty"`
-	DeprecatedVPCID                       string          `yaml:"vpcId,omitempty"`
-	VPC                                   VPC             `yaml:"vpc,omitempty"`
-	DeprecatedInternetGatewayID           string          `yaml:"internetGatewayId,omitempty"`
-	InternetGateway                       InternetGateway `yaml:"internetGateway,omitempty"`
+	CloudFormation                        CloudFormation   `yaml:"cloudformation,omitempty"`
+	AssumeRoles                           AssumeRoles      `yaml:"assumeRoles,omitempty"`
+	ClusterName                           string           `yaml:"clusterName,omitempty"`
+	S3URI                                 string           `yaml:"s3URI,omitempty"`
+	AssetPrefix                           string           `yaml:"assetPrefix,omitempty"`
+	AssetReplication                      AssetReplication `yaml:"assetReplication,omitempty"`
+	DisableContainerLinuxAutomaticUpdates string           `yaml:"disableContainerLinuxAutomaticUpdates,omitempty"`
+	KeyName                               string           `yaml:"keyName,omitempty"`
+	Region                                Region           `yaml:",inline"`
+	AvailabilityZone                      string           `yaml:"availabilityZone,omitempty"`
+	ReleaseChannel                        string           `yaml:"releaseChannel,omitempty"`
+	AmiId                                 string           `yaml:"amiId,omitempty"`
+	AmiSsmParameter                       string           `yaml:"amiSsmParameter,omitempty"`
+	DeprecatedVPCID                       string           `yaml:"vpcId,omitempty"`
+	VPC                                   VPC              `yaml:"vpc,omitempty"`
+	DeprecatedInternetGatewayID           string           `yaml:"internetGatewayId,omitempty"`
+	InternetGateway                       InternetGateway  `yaml:"internetGateway,omitempty"`
 	// Required for validations like e.g. if instance cidr is contained in vpc cidr
 	VPCCIDR                   string `yaml:"vpcCIDR,omitempty"`
 	InstanceCIDR              string `yaml:"instanceCIDR,omitempty"`
@@ -465,6 +506,7 @@ type DeploymentSettings struct {
 	ElasticFileSystemID       string            `yaml:"elasticFileSystemId,omitempty"`
 	SharedPersistentVolume    bool              `yaml:"sharedPersistentVolume,omitempty"`
 	SSHAuthorizedKeys         []string          `yaml:"sshAuthorizedKeys,omitempty"`
+	AdditionalTrustedCAs      TrustedCAs        `yaml:"additionalTrustedCAs,omitempty"`
 	Addons                    Addons            `yaml:"addons"`
 	Experimental              Experimental      `yaml:"experimental"`
 	Kubelet                   Kubelet           `yaml:"kubelet"`
@@ -497,8 +539,19 @@ type DeploymentSettings struct {
 	KubernetesDashboardImage           Image      `yaml:"kubernetesDashboardImage,omitempty"`
 	PauseImage                         Image      `yaml:"pauseImage,omitempty"`
 	JournaldCloudWatchLogsImage        Image      `yaml:"journaldCloudWatchLogsImage,omitempty"`
+	AWSLoadBalancerControllerImage     Image      `yaml:"awsLoadBalancerControllerImage,omitempty"`
+	PodIdentityWebhookImage            Image      `yaml:"podIdentityWebhookImage,omitempty"`
+	EFSCSIDriverImage                  Image      `yaml:"efsCsiDriverImage,omitempty"`
+	CSIProvisionerImage                Image      `yaml:"csiProvisionerImage,omitempty"`
+	CSINodeDriverRegistrarImage        Image      `yaml:"csiNodeDriverRegistrarImage,omitempty"`
+	CSILivenessProbeImage              Image      `yaml:"csiLivenessProbeImage,omitempty"`
+	NvidiaGPUDevicePluginImage         Image      `yaml:"nvidiaGpuDevicePluginImage,omitempty"`
 	Kubernetes                         Kubernetes `yaml:"kubernetes,omitempty"`
 	HostOS                             HostOS     `yaml:"hostOS,omitempty"`
+	// InstanceScript configures where the instance script bootstrapping nodes resides
+	InstanceScript InstanceScript `yaml:"instanceScript,omitempty"`
+	// Containerd configures node pools running the `containerd` container runtime
+	Containerd Containerd `yaml:"containerd,omitempty"`
 }
 
 // Part of configuration which is specific to worker nodes
@@ -537,6 +590,14 @@ type Cluster struct {
 	SSHAccessAllowedSourceCIDRs CIDRRanges             `yaml:"sshAccessAllowedSourceCIDRs,omitempty"`
 	CustomSettings              map[string]interface{} `yaml:"customSettings,omitempty"`
 	KubeResourcesAutosave       `yaml:"kubeResourcesAutosave,omitempty"`
+	AssetLayout                 AssetLayout       `yaml:"assetLayout,omitempty"`
+	IAM                         ClusterIAM        `yaml:"iam,omitempty"`
+	Defaults                    ClusterDefaults   `yaml:"defaults,omitempty"`
+	MaintenanceWindow           MaintenanceWindow `yaml:"maintenanceWindow,omitempty"`
+	// AWSAPIRetry configures the retries of the AWS API requests made by kube-aws itself
+	AWSAPIRetry AWSAPIRetry `yaml:"awsApiRetry,omitempty"`
+	// RuntimeClasses are created in the cluster for running pods on the sandbox runtimes installed on node pools
+	RuntimeClasses RuntimeClasses `yaml:"runtimeClasses,omitempty"`
 }
 
 type KubernetesDashboard struct {
@@ -590,10 +651,14 @@ func (c KubeClusterSettings) K8sNetworkPlugin() string {
 }
 
 type StackTemplateOptions struct {
-	AssetsDir             string
-	ControllerTmplFile    string
-	EtcdTmplFile          string
-	WorkerTmplFile        string
+	AssetsDir          string
+	ControllerTmplFile string
+	EtcdTmplFile       string
+	WorkerTmplFile     string
+	// BottlerocketWorkerTmplFile is the userdata template of node pools with `os: bottlerocket`
+	BottlerocketWorkerTmplFile string
+	// WindowsWorkerTmplFile is the userdata template of node pools with `platform: windows`
+	WindowsWorkerTmplFile string
 	StackTemplateTmplFile string
 	S3URI                 string
 	PrettyPrint           bool
@@ -638,6 +703,30 @@ func (c Cluster) ExternalDNSNames() []string {
 	return names
 }
 
+// warnAPIServerShutdownDelayNotObservedByELBs warns when the classic ELBs serving API endpoints can't notice apiservers
+// reporting unready during the shutdown delay, which results in ELBs routing requests to terminating apiservers
+func (c Cluster) warnAPIServerShutdownDelayNotObservedByELBs() {
+	delay := c.Controller.APIServer.ShutdownDelay()
+	if delay <= 0 {
+		return
+	}
+
+	for _, e := range c.APIEndpointConfigs {
+		if !e.LoadBalancer.ManageELB() || !e.LoadBalancer.ClassicLoadBalancer() {
+			continue
+		}
+
+		hc := e.LoadBalancer.HealthCheck
+		if target := hc.TargetOrDefault(); !strings.HasPrefix(target, "HTTP:") && !strings.HasPrefix(target, "HTTPS:") {
+			logger.Warnf("the health check target \"%s\" of the API endpoint \"%s\" doesn't observe the readiness of apiservers. Please consider setting `controller.apiServer.anonymousAuth` to \"healthEndpoints\", which defaults `healthCheck.target` to \"HTTPS:443/readyz\", for `controller.apiServer.shutdownDelayDuration` to take effect", target, e.Name)
+			continue
+		}
+		if detection := time.Duration(hc.IntervalOrDefault()*hc.UnhealthyThresholdOrDefault()) * time.Second; delay < detection {
+			logger.Warnf("`controller.apiServer.shutdownDelayDuration` %s is shorter than %s the ELB of the API endpoint \"%s\" takes to detect an unready apiserver", delay, detection, e.Name)
+		}
+	}
+}
+
 // APIAccessAllowedSourceCIDRsForControllerSG returns all the CIDRs of Kubernetes API endpoints that controller nodes must allow access from
 func (c Cluster) APIAccessAllowedSourceCIDRsForControllerSG() []string {
 	cidrs := []string{}
@@ -727,6 +816,26 @@ func (c Cluster) validate(cpStackName string) error {
 		return fmt.Errorf("serviceCIDR (%s) does not contain dnsServiceIP (%s)", c.ServiceCIDR, c.DNSServiceIP)
 	}
 
+	if err := c.Kubernetes.Networking.AmazonVPC.Validate(vpcNet, podNet, serviceNet, c.AvailabilityZones()); err != nil {
+		return err
+	}
+
+	if err := c.Defaults.NetworkPolicy.Validate(c.Kubernetes.Networking); err != nil {
+		return err
+	}
+
+	if err := c.MaintenanceWindow.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.AWSAPIRetry.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.validateControlPlaneResources(); err != nil {
+		return err
+	}
+
 	if dnsServiceIPAddr.Equal(kubernetesServiceIPAddr) {
 		return fmt.Errorf("dnsServiceIp conflicts with kubernetesServiceIp (%s)", dnsServiceIPAddr)
 	}
@@ -735,6 +844,58 @@ func (c Cluster) validate(cpStackName string) error {
 		return err
 	}
 
+	if err := c.Controller.APIServer.ServiceAccountIssuer.Validate(c.Region); err != nil {
+		return err
+	}
+
+	if err := c.validateControllerServiceAccount(); err != nil {
+		return err
+	}
+
+	if err := c.validateControllerEncryptionAtRest(); err != nil {
+		return err
+	}
+
+	if err := c.validateAPIServerCertificateReload(); err != nil {
+		return err
+	}
+
+	if err := c.InstanceScript.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Containerd.ImageGC.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Kubelet.ValidateResourceReservations(c.Controller.InstanceType, c.Controller.RootVolume.Size); err != nil {
+		return err
+	}
+
+	if err := c.Kubelet.ConfigFile.Validate(c.K8sVer); err != nil {
+		return err
+	}
+
+	if err := c.Kubelet.ValidateImagePulls(c.K8sVer); err != nil {
+		return err
+	}
+
+	if err := c.Kubelet.ValidateEviction(); err != nil {
+		return err
+	}
+
+	if err := c.KubeProxy.Conntrack.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.CloudFormation.StackDependencies.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.CloudFormation.Transforms.Validate(); err != nil {
+		return err
+	}
+
 	if err := c.DefaultWorkerSettings.Validate(); err != nil {
 		return err
 	}
@@ -760,6 +921,18 @@ func (c Cluster) validate(cpStackName string) error {
 		logger.Warn(`instance types "t2.nano" and "t2.micro" are not recommended. See https://github.com/kubernetes-incubator/kube-aws/issues/258 for more information`)
 	}
 
+	if err := c.IAM.Validate(); err != nil {
+		return err
+	}
+
+	if err := ValidateContainerRuntime(c.ContainerRuntime, c.Controller.InstanceType); err != nil {
+		return err
+	}
+	// Controller nodes run kubelet only on docker or rkt
+	if c.ContainerRuntime == ContainerRuntimeContainerd {
+		return errors.New("the `containerd` container runtime is supported only for node pools. Specify it in `worker.nodePools[].containerRuntime` instead")
+	}
+
 	if len(c.Controller.IAMConfig.Role.Name) > 0 {
 		if e := cfnresource.ValidateStableRoleNameLength(c.ClusterName, c.Controller.IAMConfig.Role.Name, c.Region.String(), c.Controller.IAMConfig.Role.StrictName); e != nil {
 			return e
@@ -775,6 +948,58 @@ func (c Cluster) validate(cpStackName string) error {
 		if err := w.Validate(c.Experimental); err != nil {
 			return err
 		}
+
+		k8sVer := w.K8sVer
+		if k8sVer == "" {
+			k8sVer = c.K8sVer
+		}
+
+		switch {
+		// Windows nodes run Docker shipped with the AMI regardless of `containerRuntime`
+		case w.IsWindows():
+			if err := w.ValidateWindows(k8sVer, c.K8sVer, c.Kubernetes.Networking, c.DefaultWorkerSettings.WorkerInstanceType); err != nil {
+				return fmt.Errorf("invalid node pool \"%s\": %v", w.NodePoolName, err)
+			}
+			// The node-local DNS resolver runs as a daemonset excluded from Windows nodes
+			if c.KubeDns.NodeLocalResolver {
+				return fmt.Errorf("invalid node pool \"%s\": `kubeDns.nodeLocalResolver` isn't supported with `platform: windows`", w.NodePoolName)
+			}
+		// Bottlerocket nodes run containerd shipped with the OS regardless of `containerRuntime`
+		case w.IsBottlerocket():
+			if err := w.ValidateBottlerocket(k8sVer, c.PluginConfigs); err != nil {
+				return fmt.Errorf("invalid node pool \"%s\": %v", w.NodePoolName, err)
+			}
+			// The cluster DNS IP of Bottlerocket nodes is fixed in their settings
+			if c.KubeDns.NodeLocalResolver {
+				return fmt.Errorf("invalid node pool \"%s\": `kubeDns.nodeLocalResolver` isn't supported with `os: bottlerocket`", w.NodePoolName)
+			}
+		default:
+			runtime := w.ContainerRuntime
+			if runtime == "" {
+				runtime = c.ContainerRuntime
+			}
+			if err := w.ValidateContainerRuntime(runtime, c.DefaultWorkerSettings.WorkerInstanceType); err != nil {
+				return fmt.Errorf("invalid node pool \"%s\": %v", w.NodePoolName, err)
+			}
+			if err := w.ValidateSandboxRuntimes(runtime, c.DefaultWorkerSettings.WorkerInstanceType); err != nil {
+				return fmt.Errorf("invalid node pool \"%s\": %v", w.NodePoolName, err)
+			}
+		}
+		if err := w.DeploymentSettings.Kubelet.ConfigFile.Validate(k8sVer); err != nil {
+			return fmt.Errorf("invalid node pool \"%s\": %v", w.NodePoolName, err)
+		}
+	}
+
+	if err := c.Worker.InterPoolCommunication.Validate(c.Worker.NodePools); err != nil {
+		return err
+	}
+
+	if err := c.validateNodePoolPodCIDRRanges(); err != nil {
+		return err
+	}
+
+	if err := c.validateRuntimeClasses(); err != nil {
+		return err
 	}
 
 	if c.Experimental.NodeAuthorizer.Enabled {
@@ -783,18 +1008,71 @@ func (c Cluster) validate(cpStackName string) error {
 		}
 	}
 
+	if err := c.validatePartition(); err != nil {
+		return err
+	}
+
 	for i, e := range c.APIEndpointConfigs {
 		if e.LoadBalancer.NetworkLoadBalancer() && !c.Region.SupportsNetworkLoadBalancers() {
 			return fmt.Errorf("api endpoint %d is not valid: network load balancer not supported in region", i)
 		}
 	}
 
-	if c.Kubernetes.Networking.SelfHosting.Type != "canal" && c.Kubernetes.Networking.SelfHosting.Type != "flannel" {
-		return fmt.Errorf("networkingdaemonsets - style must be either 'canal' or 'flannel'")
+	switch c.Kubernetes.Networking.SelfHosting.Type {
+	case "canal", "flannel", NetworkPluginCustom, NetworkPluginAmazonVPCCNI:
+	default:
+		return fmt.Errorf("networkingdaemonsets - style must be either 'canal', 'flannel', 'custom' or '%s'", NetworkPluginAmazonVPCCNI)
 	}
 	if c.Kubernetes.Networking.SelfHosting.Typha && c.Kubernetes.Networking.SelfHosting.Type != "canal" {
 		return fmt.Errorf("networkingdaemonsets - you can only enable typha when deploying type 'canal'")
 	}
+	if err := c.Kubernetes.Networking.validateCustomNetworkPlugin(c.Experimental); err != nil {
+		return err
+	}
+
+	if err := c.Kubernetes.APIServer.EgressSelector.Validate(c.K8sVer); err != nil {
+		return err
+	}
+
+	if len(c.Kubernetes.Secrets) > 0 || len(c.Kubernetes.ConfigMaps) > 0 {
+		return errors.New("`kubernetes.secrets` and `kubernetes.configMaps` can only be specified in kube-aws plugins under `spec.cluster.kubernetes`")
+	}
+
+	if err := c.Controller.KubeScheduler.Validate(c.K8sVer); err != nil {
+		return err
+	}
+
+	if err := c.Controller.KubeControllerManager.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Controller.APIServer.ValidateKubernetesVersion(c.K8sVer); err != nil {
+		return err
+	}
+	if err := c.validateAnonymousAuth(); err != nil {
+		return err
+	}
+	if err := c.validateAPIEndpointIdleTimeouts(); err != nil {
+		return err
+	}
+	c.warnAPIServerShutdownDelayNotObservedByELBs()
+	c.warnHealthChecksRejectedByAnonymousAuth()
+
+	if err := c.Addons.ClusterAutoscaler.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Addons.AWSLoadBalancerController.Validate(c.K8sVer, c.Experimental); err != nil {
+		return err
+	}
+
+	if err := c.Addons.EFSCSIDriver.Validate(c.K8sVer, c.Experimental); err != nil {
+		return err
+	}
+
+	if err := c.AssetLayout.Validate(); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -899,6 +1177,9 @@ func (c *Cluster) ControllerFeatureGates() FeatureGates {
 	if !c.Experimental.Admission.PersistentVolumeClaimResize.Enabled {
 		gates["ExpandPersistentVolumes"] = "false"
 	}
+	for name, v := range c.Controller.ServiceAccount.boundTokensFeatureGates(c.K8sVer) {
+		gates[name] = v
+	}
 	return gates
 }
 
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// ClusterAutoscalerSafeToEvictAnnotationKey is the pod annotation cluster-autoscaler reads to decide whether it can evict the pod
+	// while scaling down the node running it
+	ClusterAutoscalerSafeToEvictAnnotationKey = "cluster-autoscaler.kubernetes.io/safe-to-evict"
+	// ClusterAutoscalerScaleDownDisabledAnnotationKey is the node annotation which prevents cluster-autoscaler from scaling down the node
+	ClusterAutoscalerScaleDownDisabledAnnotationKey = "cluster-autoscaler.kubernetes.io/scale-down-disabled"
+
+	// DefaultClusterAutoscalerExpander scales out the node pool which would leave the least CPU and memory idle after scheduling pending pods
+	DefaultClusterAutoscalerExpander = "least-waste"
+
+	// clusterAutoscalerServiceAccount is the service account cluster-autoscaler runs as in kube-system
+	clusterAutoscalerServiceAccount = "cluster-autoscaler"
+)
+
+// clusterAutoscalerExpanders are the expanders supported on AWS. `price` is omitted as it is implemented only for GCE
+var clusterAutoscalerExpanders = []string{
+	"random",
+	"most-pods",
+	"least-waste",
+	"priority",
+}
+
+// clusterAutoscalerSafeToEvictAddons are the kube-aws add-ons whose pods can be annotated with `cluster-autoscaler.kubernetes.io/safe-to-evict`
+var clusterAutoscalerSafeToEvictAddons = []string{
+	"cluster-autoscaler",
+	"coredns",
+	"heapster",
+	"kube-dns",
+	"kube-dns-autoscaler",
+	"kube-rescheduler",
+	"kubernetes-dashboard",
+	"metrics-server",
+	"tiller",
+}
+
+// defaultClusterAutoscalerSafeToEvict is applied unless overridden via `addons.clusterAutoscaler.safeToEvict`.
+// cluster-autoscaler shouldn't evict itself while scaling down the node it is running on
+var defaultClusterAutoscalerSafeToEvict = map[string]bool{
+	"cluster-autoscaler": false,
+}
+
+// SafeToEvict returns the value of the `cluster-autoscaler.kubernetes.io/safe-to-evict` annotation for the pods of the add-on,
+// or an empty string when the pods shouldn't be annotated
+func (c ClusterAutoscalerSupport) SafeToEvict(addon string) string {
+	if !c.Enabled {
+		return ""
+	}
+	v, ok := c.SafeToEvictAddons[addon]
+	if !ok {
+		v, ok = defaultClusterAutoscalerSafeToEvict[addon]
+	}
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%t", v)
+}
+
+// ExpanderOrDefault returns the value of cluster-autoscaler's `--expander`
+func (c ClusterAutoscalerSupport) ExpanderOrDefault() string {
+	if c.Expander == "" {
+		return DefaultClusterAutoscalerExpander
+	}
+	return c.Expander
+}
+
+func (c ClusterAutoscalerSupport) Validate() error {
+	if c.Expander != "" {
+		if !c.Enabled {
+			return errors.New("`addons.clusterAutoscaler.expander` can't be specified unless `addons.clusterAutoscaler.enabled` is true")
+		}
+		if !containsString(clusterAutoscalerExpanders, c.Expander) {
+			return fmt.Errorf("invalid `addons.clusterAutoscaler.expander` \"%s\": it must be one of %s", c.Expander, strings.Join(clusterAutoscalerExpanders, ", "))
+		}
+		if _, ok := c.Options["expander"]; ok {
+			return errors.New("`addons.clusterAutoscaler.expander` and `addons.clusterAutoscaler.options.expander` are mutually exclusive. Remove `options.expander`")
+		}
+	}
+
+	if len(c.SafeToEvictAddons) == 0 {
+		return nil
+	}
+	if !c.Enabled {
+		return errors.New("`addons.clusterAutoscaler.safeToEvict` can't be specified unless `addons.clusterAutoscaler.enabled` is true")
+	}
+	addons := []string{}
+	for a := range c.SafeToEvictAddons {
+		addons = append(addons, a)
+	}
+	sort.Strings(addons)
+	for _, a := range addons {
+		if !containsString(clusterAutoscalerSafeToEvictAddons, a) {
+			return fmt.Errorf("`addons.clusterAutoscaler.safeToEvict` contains the unknown add-on \"%s\": it must be one of %s", a, strings.Join(clusterAutoscalerSafeToEvictAddons, ", "))
+		}
+	}
+	return nil
+}
+
+// ScaleDownDisabledAnnotation returns the node annotation which prevents cluster-autoscaler from scaling down nodes in the pool
+func (a ClusterAutoscaler) ScaleDownDisabledAnnotation() string {
+	return fmt.Sprintf("%s=true", ClusterAutoscalerScaleDownDisabledAnnotationKey)
+}
+
+func (a ClusterAutoscaler) Validate() error {
+	if a.ScaleDownDisabled && !a.Enabled {
+		return errors.New("`autoscaling.clusterAutoscaler.scaleDownDisabled` can't be true unless `autoscaling.clusterAutoscaler.enabled` is true")
+	}
+	return nil
+}
+
+// consumeNodePoolClusterAutoscaler enables the cluster-autoscaler add-on when any node pool is scaled by it,
+// so that `worker.nodePools[].autoscaling.clusterAutoscaler.enabled` alone deploys cluster-autoscaler to controller nodes.
+// The add-on disabled explicitly is never enabled, which fails instead
+func (c *Cluster) consumeNodePoolClusterAutoscaler() error {
+	for _, np := range c.Worker.NodePools {
+		if !np.Autoscaling.ClusterAutoscaler.Enabled {
+			continue
+		}
+		if c.Addons.ClusterAutoscaler.disabledExplicitly {
+			return fmt.Errorf("node pool \"%s\" can't be scaled by cluster-autoscaler because `addons.clusterAutoscaler.enabled` is false. "+
+				"Either remove `addons.clusterAutoscaler.enabled: false` to deploy cluster-autoscaler, or `autoscaling.clusterAutoscaler.enabled: true` from the node pool", np.NodePoolName)
+		}
+		c.Addons.ClusterAutoscaler.Enabled = true
+	}
+	return nil
+}
+
+// ClusterAutoscalerIRSAEnabled returns true when cluster-autoscaler assumes its dedicated IAM role via IAM roles for service accounts
+func (c Cluster) ClusterAutoscalerIRSAEnabled() bool {
+	return c.Addons.ClusterAutoscaler.Enabled && c.Kubernetes.OIDC.IRSA.PodIdentityWebhookEnabled()
+}
+
+// ClusterAutoscalerIAMRoleEnabled returns true when cluster-autoscaler assumes its dedicated IAM role created in the control-plane stack,
+// either via IAM roles for service accounts, kube2iam or kiam. Otherwise the IAM role of controller nodes is granted the permissions instead
+func (c Cluster) ClusterAutoscalerIAMRoleEnabled() bool {
+	return c.ClusterAutoscalerIRSAEnabled() ||
+		(c.Addons.ClusterAutoscaler.Enabled && (c.Experimental.Kube2IamSupport.Enabled || c.Experimental.KIAMSupport.Enabled))
+}
+
+// ClusterAutoscalerIAMRoleName returns the name of the dedicated IAM role, which is fixed so that it can be referenced from manifests
+func (c Cluster) ClusterAutoscalerIAMRoleName() string {
+	return fmt.Sprintf("%s-IAMRoleClusterAutoscaler", c.ClusterName)
+}
+
+// ClusterAutoscalerIRSATrustConditions returns the conditions of the trust policy of the dedicated IAM role,
+// which allow only the service account of cluster-autoscaler to assume the role with its projected token
+func (c Cluster) ClusterAutoscalerIRSATrustConditions() map[string]string {
+	issuer := strings.TrimPrefix(c.Controller.APIServer.ServiceAccountIssuer.URL, "https://")
+	return map[string]string{
+		issuer + ":aud": c.PodIdentityWebhookTokenAudience(),
+		issuer + ":sub": fmt.Sprintf("system:serviceaccount:kube-system:%s", clusterAutoscalerServiceAccount),
+	}
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClusterAutoscalerSafeToEvict(t *testing.T) {
+	testCases := []struct {
+		support  ClusterAutoscalerSupport
+		addon    string
+		expected string
+	}{
+		// cluster-autoscaler isn't deployed
+		{
+			support:  ClusterAutoscalerSupport{},
+			addon:    "cluster-autoscaler",
+			expected: "",
+		},
+		// cluster-autoscaler shouldn't evict itself by default
+		{
+			support:  ClusterAutoscalerSupport{Enabled: true},
+			addon:    "cluster-autoscaler",
+			expected: "false",
+		},
+		// Not annotated by default
+		{
+			support:  ClusterAutoscalerSupport{Enabled: true},
+			addon:    "kube-dns",
+			expected: "",
+		},
+		// Overridden
+		{
+			support:  ClusterAutoscalerSupport{Enabled: true, SafeToEvictAddons: map[string]bool{"cluster-autoscaler": true, "kube-dns": false}},
+			addon:    "cluster-autoscaler",
+			expected: "true",
+		},
+		{
+			support:  ClusterAutoscalerSupport{Enabled: true, SafeToEvictAddons: map[string]bool{"cluster-autoscaler": true, "kube-dns": false}},
+			addon:    "kube-dns",
+			expected: "false",
+		},
+	}
+
+	for i, testCase := range testCases {
+		actual := testCase.support.SafeToEvict(testCase.addon)
+		if actual != testCase.expected {
+			t.Errorf("case %d: expected the safe-to-evict annotation of %s to be \"%s\" but was \"%s\"", i, testCase.addon, testCase.expected, actual)
+		}
+	}
+}
+
+func TestClusterAutoscalerSupportValidate(t *testing.T) {
+	testCases := []struct {
+		support ClusterAutoscalerSupport
+		isValid bool
+	}{
+		// Valid, not configured
+		{
+			support: ClusterAutoscalerSupport{},
+			isValid: true,
+		},
+		// Valid, known add-ons
+		{
+			support: ClusterAutoscalerSupport{Enabled: true, SafeToEvictAddons: map[string]bool{"kube-dns": false, "metrics-server": true}},
+			isValid: true,
+		},
+		// Invalid, cluster-autoscaler isn't deployed
+		{
+			support: ClusterAutoscalerSupport{SafeToEvictAddons: map[string]bool{"kube-dns": false}},
+			isValid: false,
+		},
+		// Invalid, unknown add-on
+		{
+			support: ClusterAutoscalerSupport{Enabled: true, SafeToEvictAddons: map[string]bool{"kube-proxy": false}},
+			isValid: false,
+		},
+		// Valid, known expander
+		{
+			support: ClusterAutoscalerSupport{Enabled: true, Expander: "priority"},
+			isValid: true,
+		},
+		// Invalid, expander without cluster-autoscaler
+		{
+			support: ClusterAutoscalerSupport{Expander: "priority"},
+			isValid: false,
+		},
+		// Invalid, the price expander is only for GCE
+		{
+			support: ClusterAutoscalerSupport{Enabled: true, Expander: "price"},
+			isValid: false,
+		},
+		// Invalid, expander specified twice
+		{
+			support: ClusterAutoscalerSupport{Enabled: true, Expander: "random", Options: map[string]string{"expander": "most-pods"}},
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.support.Validate()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.support, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.support)
+		}
+	}
+}
+
+func TestClusterAutoscalerExpanderOrDefault(t *testing.T) {
+	if e := (ClusterAutoscalerSupport{}).ExpanderOrDefault(); e != "least-waste" {
+		t.Errorf("expected the default expander to be least-waste but was %s", e)
+	}
+	if e := (ClusterAutoscalerSupport{Expander: "most-pods"}).ExpanderOrDefault(); e != "most-pods" {
+		t.Errorf("expected the expander to be most-pods but was %s", e)
+	}
+}
+
+func TestConsumeNodePoolClusterAutoscaler(t *testing.T) {
+	c := NewDefaultCluster()
+	if err := c.consumeNodePoolClusterAutoscaler(); err != nil || c.Addons.ClusterAutoscaler.Enabled {
+		t.Errorf("expected cluster-autoscaler not to be enabled without node pools scaled by it, but got the error: %v", err)
+	}
+
+	c.Worker.NodePools = []WorkerNodePool{{}, {Autoscaling: Autoscaling{ClusterAutoscaler: ClusterAutoscaler{Enabled: true}}}}
+	if err := c.consumeNodePoolClusterAutoscaler(); err != nil || !c.Addons.ClusterAutoscaler.Enabled {
+		t.Errorf("expected cluster-autoscaler to be enabled for the node pool scaled by it, but got the error: %v", err)
+	}
+
+	c.Addons.ClusterAutoscaler = ClusterAutoscalerSupport{disabledExplicitly: true}
+	if err := c.consumeNodePoolClusterAutoscaler(); err == nil || c.Addons.ClusterAutoscaler.Enabled {
+		t.Errorf("expected cluster-autoscaler disabled explicitly not to be enabled but to fail")
+	}
+}
+
+func TestClusterAutoscalerIAMRole(t *testing.T) {
+	c := NewDefaultCluster()
+	c.ClusterName = "mycluster"
+	c.Addons.ClusterAutoscaler.Enabled = true
+	if c.ClusterAutoscalerIAMRoleEnabled() {
+		t.Errorf("expected cluster-autoscaler to use the IAM role of controller nodes without IRSA, kube2iam or kiam")
+	}
+
+	c.Experimental.Kube2IamSupport.Enabled = true
+	if !c.ClusterAutoscalerIAMRoleEnabled() || c.ClusterAutoscalerIRSAEnabled() {
+		t.Errorf("expected cluster-autoscaler to assume the dedicated IAM role via kube2iam")
+	}
+	if n := c.ClusterAutoscalerIAMRoleName(); n != "mycluster-IAMRoleClusterAutoscaler" {
+		t.Errorf("unexpected IAM role name: %s", n)
+	}
+
+	c.Experimental.Kube2IamSupport.Enabled = false
+	c.Kubernetes.OIDC.IRSA = IRSA{Enabled: true, S3Bucket: "mybucket"}
+	c.Controller.APIServer.ServiceAccountIssuer.URL = "https://mybucket.s3.us-west-1.amazonaws.com/mycluster"
+	if !c.ClusterAutoscalerIAMRoleEnabled() || !c.ClusterAutoscalerIRSAEnabled() {
+		t.Errorf("expected cluster-autoscaler to assume the dedicated IAM role via IRSA")
+	}
+	expected := map[string]string{
+		"mybucket.s3.us-west-1.amazonaws.com/mycluster:aud": "sts.amazonaws.com",
+		"mybucket.s3.us-west-1.amazonaws.com/mycluster:sub": "system:serviceaccount:kube-system:cluster-autoscaler",
+	}
+	if actual := c.ClusterAutoscalerIRSATrustConditions(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("unexpected trust conditions: expected=%v actual=%v", expected, actual)
+	}
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/kubernetes-incubator/kube-aws/logger"
+)
+
+// The resources of the control-plane static pods rendered when they aren't customized
+var (
+	defaultControllerManagerResources = ComputeResources{
+		Requests: ResourceQuota{Cpu: "100m", Memory: "100M"},
+		Limits:   ResourceQuota{Cpu: "250m", Memory: "512M"},
+	}
+	defaultKubeSchedulerResources = ComputeResources{
+		Requests: ResourceQuota{Cpu: "100m"},
+	}
+)
+
+func (r ComputeResources) IsEmpty() bool {
+	return r.Requests.IsEmpty() && r.Limits.IsEmpty()
+}
+
+// WithDefaults returns the resources with each unspecified quantity set to the one of the defaults
+func (r ComputeResources) WithDefaults(defaults ComputeResources) ComputeResources {
+	r.Requests = r.Requests.withDefaults(defaults.Requests)
+	r.Limits = r.Limits.withDefaults(defaults.Limits)
+	return r
+}
+
+// Validate returns an error when any quantity is invalid or a request exceeds its limit
+func (r ComputeResources) Validate(key string) error {
+	for _, q := range []struct {
+		name     string
+		quantity string
+	}{
+		{"requests.cpu", r.Requests.Cpu},
+		{"requests.memory", r.Requests.Memory},
+		{"limits.cpu", r.Limits.Cpu},
+		{"limits.memory", r.Limits.Memory},
+	} {
+		if q.quantity == "" {
+			continue
+		}
+		if v, err := ParseQuantity(q.quantity); err != nil {
+			return fmt.Errorf("`%s.%s` is invalid: %v", key, q.name, err)
+		} else if v == 0 {
+			return fmt.Errorf("`%s.%s` must be greater than 0", key, q.name)
+		}
+	}
+	for _, name := range []string{"cpu", "memory"} {
+		request, limit := r.Requests.quantity(name), r.Limits.quantity(name)
+		if limit > 0 && request > limit {
+			return fmt.Errorf("`%s.requests.%s` must not exceed `%s.limits.%s`", key, name, key, name)
+		}
+	}
+	return nil
+}
+
+// requested returns the amount of the resource requested, which defaults to the limit as Kubernetes does.
+// The quantities must have been validated beforehand
+func (r ComputeResources) requested(name string) float64 {
+	if q := r.Requests.quantity(name); q > 0 {
+		return q
+	}
+	return r.Limits.quantity(name)
+}
+
+func (q ResourceQuota) IsEmpty() bool {
+	return q.Cpu == "" && q.Memory == ""
+}
+
+func (q ResourceQuota) withDefaults(defaults ResourceQuota) ResourceQuota {
+	if q.Cpu == "" {
+		q.Cpu = defaults.Cpu
+	}
+	if q.Memory == "" {
+		q.Memory = defaults.Memory
+	}
+	return q
+}
+
+// quantity returns the amount of the resource in cores or bytes, or 0 when it isn't specified
+func (q ResourceQuota) quantity(name string) float64 {
+	s := q.Cpu
+	if name == "memory" {
+		s = q.Memory
+	}
+	if s == "" {
+		return 0
+	}
+	v, _ := ParseQuantity(s)
+	return v
+}
+
+func (m ControllerManager) ResourcesWithDefaults() ComputeResources {
+	return m.ComputeResources.WithDefaults(defaultControllerManagerResources)
+}
+
+func (s KubeScheduler) ResourcesWithDefaults() ComputeResources {
+	return s.Resources.WithDefaults(defaultKubeSchedulerResources)
+}
+
+// validateControlPlaneResources validates the resources of the apiserver, the controller-manager and the scheduler, and warns when
+// they don't fit the controller instance type, in which case the static pods are left pending or killed as the node runs out of memory
+func (c Cluster) validateControlPlaneResources() error {
+	components := []struct {
+		key       string
+		resources ComputeResources
+	}{
+		{"controller.apiServer.resources", c.Controller.APIServer.Resources},
+		{"kubernetes.controllerManager.resources", c.Kubernetes.ControllerManager.ResourcesWithDefaults()},
+		{"controller.kubeScheduler.resources", c.Controller.KubeScheduler.ResourcesWithDefaults()},
+	}
+	for _, component := range components {
+		if err := component.resources.Validate(component.key); err != nil {
+			return err
+		}
+	}
+
+	capacity, ok := InstanceCapacityOf(c.Controller.InstanceType)
+	if !ok {
+		return nil
+	}
+	var cpu, memory float64
+	for _, component := range components {
+		cpu += component.resources.requested("cpu")
+		memory += component.resources.requested("memory")
+		warnLimitsExceedingCapacity(component.key, component.resources, capacity, c.Controller.InstanceType)
+	}
+	if cpu > float64(capacity.VCPUs) {
+		logger.Warnf("the total cpu requested by the apiserver, the controller-manager and the scheduler (%g) exceeds %d vCPUs of the controller instance type %s", cpu, capacity.VCPUs, c.Controller.InstanceType)
+	}
+	if memory > float64(capacity.MemoryMiB)*(1<<20) {
+		logger.Warnf("the total memory requested by the apiserver, the controller-manager and the scheduler (%g bytes) exceeds %dMi of memory of the controller instance type %s", memory, capacity.MemoryMiB, c.Controller.InstanceType)
+	}
+	return nil
+}
+
+func warnLimitsExceedingCapacity(key string, r ComputeResources, capacity InstanceCapacity, instanceType string) {
+	if cpu := r.Limits.quantity("cpu"); cpu > float64(capacity.VCPUs) {
+		logger.Warnf("`%s.limits.cpu` (%g) exceeds %d vCPUs of the instance type %s", key, cpu, capacity.VCPUs, instanceType)
+	}
+	if memory := r.Limits.quantity("memory"); memory > float64(capacity.MemoryMiB)*(1<<20) {
+		logger.Warnf("`%s.limits.memory` (%g bytes) exceeds %dMi of memory of the instance type %s", key, memory, capacity.MemoryMiB, instanceType)
+	}
+}
+
+// validateResources validates the resources of etcd, which are enforced via the resource controls of its systemd unit as it doesn't run as a pod
+func (e Etcd) validateResources() error {
+	if err := e.Resources.Validate("etcd.resources"); err != nil {
+		return err
+	}
+	if e.Resources.Requests.Memory != "" {
+		return errors.New("`etcd.resources.requests.memory` is not supported because etcd runs as a systemd unit. Use `etcd.resources.limits.memory` instead")
+	}
+	if capacity, ok := InstanceCapacityOf(e.InstanceType); ok {
+		if cpu := e.Resources.requested("cpu"); cpu > float64(capacity.VCPUs) {
+			logger.Warnf("`etcd.resources.requests.cpu` (%g) exceeds %d vCPUs of the etcd instance type %s", cpu, capacity.VCPUs, e.InstanceType)
+		}
+		warnLimitsExceedingCapacity("etcd.resources", e.Resources, capacity, e.InstanceType)
+	}
+	return nil
+}
+
+// SystemdResourceControls returns the resource control settings of the systemd unit running etcd, translated from its resources the way
+// kubelet translates the resources of containers into cgroup settings
+func (e Etcd) SystemdResourceControls() []string {
+	controls := []string{}
+	if cpu := e.Resources.requested("cpu"); cpu > 0 {
+		controls = append(controls, fmt.Sprintf("CPUShares=%d", int(math.Max(2, math.Round(cpu*1024)))))
+	}
+	if cpu := e.Resources.Limits.quantity("cpu"); cpu > 0 {
+		controls = append(controls, fmt.Sprintf("CPUQuota=%d%%", int(math.Max(1, math.Round(cpu*100)))))
+	}
+	if memory := e.Resources.Limits.quantity("memory"); memory > 0 {
+		controls = append(controls, fmt.Sprintf("MemoryLimit=%d", int64(memory)))
+	}
+	return controls
+}
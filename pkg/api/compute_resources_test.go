@@ -0,0 +1,123 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestComputeResourcesValidate(t *testing.T) {
+	testCases := []struct {
+		resources ComputeResources
+		isValid   bool
+	}{
+		// Valid, not configured
+		{
+			resources: ComputeResources{},
+			isValid:   true,
+		},
+		// Valid, requests and limits
+		{
+			resources: ComputeResources{
+				Requests: ResourceQuota{Cpu: "250m", Memory: "512Mi"},
+				Limits:   ResourceQuota{Cpu: "1", Memory: "1Gi"},
+			},
+			isValid: true,
+		},
+		// Valid, only limits
+		{
+			resources: ComputeResources{Limits: ResourceQuota{Memory: "1Gi"}},
+			isValid:   true,
+		},
+		// Invalid, malformed quantity
+		{
+			resources: ComputeResources{Requests: ResourceQuota{Cpu: "one"}},
+			isValid:   false,
+		},
+		// Invalid, zero
+		{
+			resources: ComputeResources{Limits: ResourceQuota{Memory: "0"}},
+			isValid:   false,
+		},
+		// Invalid, request exceeding the limit
+		{
+			resources: ComputeResources{
+				Requests: ResourceQuota{Memory: "2Gi"},
+				Limits:   ResourceQuota{Memory: "1Gi"},
+			},
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.resources.Validate("controller.apiServer.resources")
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.resources, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.resources)
+		}
+	}
+}
+
+func TestComputeResourcesWithDefaults(t *testing.T) {
+	actual := KubeScheduler{Resources: ComputeResources{Limits: ResourceQuota{Memory: "256Mi"}}}.ResourcesWithDefaults()
+	expected := ComputeResources{
+		Requests: ResourceQuota{Cpu: "100m"},
+		Limits:   ResourceQuota{Memory: "256Mi"},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v but was %+v", expected, actual)
+	}
+
+	actual = ControllerManager{ComputeResources: ComputeResources{Requests: ResourceQuota{Cpu: "200m"}}}.ResourcesWithDefaults()
+	expected = ComputeResources{
+		Requests: ResourceQuota{Cpu: "200m", Memory: "100M"},
+		Limits:   ResourceQuota{Cpu: "250m", Memory: "512M"},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v but was %+v", expected, actual)
+	}
+}
+
+func TestEtcdSystemdResourceControls(t *testing.T) {
+	testCases := []struct {
+		resources ComputeResources
+		expected  []string
+	}{
+		{
+			resources: ComputeResources{},
+			expected:  []string{},
+		},
+		{
+			resources: ComputeResources{
+				Requests: ResourceQuota{Cpu: "500m"},
+				Limits:   ResourceQuota{Cpu: "1500m", Memory: "2Gi"},
+			},
+			expected: []string{"CPUShares=512", "CPUQuota=150%", "MemoryLimit=2147483648"},
+		},
+		// The cpu request defaults to the limit
+		{
+			resources: ComputeResources{Limits: ResourceQuota{Cpu: "2"}},
+			expected:  []string{"CPUShares=2048", "CPUQuota=200%"},
+		},
+	}
+
+	for i, testCase := range testCases {
+		actual := Etcd{Resources: testCase.resources}.SystemdResourceControls()
+		if !reflect.DeepEqual(actual, testCase.expected) {
+			t.Errorf("case %d: expected %v but was %v", i, testCase.expected, actual)
+		}
+	}
+}
+
+func TestEtcdValidateResources(t *testing.T) {
+	valid := Etcd{Resources: ComputeResources{Limits: ResourceQuota{Cpu: "1", Memory: "1Gi"}}}
+	if err := valid.validateResources(); err != nil {
+		t.Errorf("expected %+v to be valid but got an error: %v", valid.Resources, err)
+	}
+
+	invalid := Etcd{Resources: ComputeResources{Requests: ResourceQuota{Memory: "1Gi"}}}
+	if err := invalid.validateResources(); err == nil {
+		t.Errorf("expected %+v to be invalid but was not", invalid.Resources)
+	}
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	ContainerRuntimeDocker     = "docker"
+	ContainerRuntimeRkt        = "rkt"
+	ContainerRuntimeContainerd = "containerd"
+)
+
+var supportedContainerRuntimes = []string{
+	ContainerRuntimeDocker,
+	ContainerRuntimeRkt,
+	ContainerRuntimeContainerd,
+}
+
+// arm64InstanceTypePattern matches AWS Graviton instance types like `a1.large`, `m6g.xlarge` and `c6gd.2xlarge`
+var arm64InstanceTypePattern = regexp.MustCompile(`^(a1|[a-z]+[0-9]+g[a-z]*)\.`)
+
+// instanceTypeArchitecture returns the CPU architecture of the instance type, either `amd64` or `arm64`
+func instanceTypeArchitecture(instanceType string) string {
+	if arm64InstanceTypePattern.MatchString(instanceType) {
+		return "arm64"
+	}
+	return "amd64"
+}
+
+// ValidateContainerRuntime returns an error when the container runtime can't run on Container Linux nodes of the instance type
+func ValidateContainerRuntime(runtime string, instanceType string) error {
+	if !containsString(supportedContainerRuntimes, runtime) {
+		return fmt.Errorf("invalid `containerRuntime` \"%s\": it must be one of %s", runtime, strings.Join(supportedContainerRuntimes, ", "))
+	}
+	// The Container Linux AMIs and the images of system components used by kube-aws are built for amd64 only
+	if arch := instanceTypeArchitecture(instanceType); arch != "amd64" {
+		return fmt.Errorf("`containerRuntime` \"%s\" isn't supported on the %s instance type \"%s\": kube-aws supports amd64 nodes only", runtime, arch, instanceType)
+	}
+	return nil
+}
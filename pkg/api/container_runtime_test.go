@@ -0,0 +1,84 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestValidateContainerRuntime(t *testing.T) {
+	testCases := []struct {
+		runtime      string
+		instanceType string
+		isValid      bool
+	}{
+		// Valid, docker
+		{
+			runtime:      "docker",
+			instanceType: "t2.medium",
+			isValid:      true,
+		},
+		// Valid, containerd
+		{
+			runtime:      "containerd",
+			instanceType: "c5.2xlarge",
+			isValid:      true,
+		},
+		// Valid, rkt on a GPU instance type
+		{
+			runtime:      "rkt",
+			instanceType: "g3.4xlarge",
+			isValid:      true,
+		},
+		// Invalid, unknown runtime
+		{
+			runtime:      "cri-o",
+			instanceType: "t2.medium",
+			isValid:      false,
+		},
+		// Invalid, arm64
+		{
+			runtime:      "containerd",
+			instanceType: "m6g.large",
+			isValid:      false,
+		},
+		// Invalid, arm64 with local NVMe storage
+		{
+			runtime:      "docker",
+			instanceType: "c6gd.xlarge",
+			isValid:      false,
+		},
+		// Invalid, the first generation Graviton
+		{
+			runtime:      "docker",
+			instanceType: "a1.medium",
+			isValid:      false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := ValidateContainerRuntime(testCase.runtime, testCase.instanceType)
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %s on %s to be valid but got an error: %v", i, testCase.runtime, testCase.instanceType, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %s on %s to be invalid but was not", i, testCase.runtime, testCase.instanceType)
+		}
+	}
+}
+
+func TestWorkerNodePoolValidateContainerRuntime(t *testing.T) {
+	pool := WorkerNodePool{}
+	pool.InstanceType = "p2.xlarge"
+	pool.Gpu.Nvidia.Enabled = true
+	if err := pool.ValidateContainerRuntime("docker", "t2.medium"); err != nil {
+		t.Errorf("expected nvidia on docker to be valid but got an error: %v", err)
+	}
+	if err := pool.ValidateContainerRuntime("containerd", "t2.medium"); err == nil {
+		t.Error("expected nvidia on containerd to be invalid but was not")
+	}
+
+	spotFleetPool := WorkerNodePool{}
+	spotFleetPool.SpotFleet.LaunchSpecifications = []LaunchSpecification{NewLaunchSpecification(1, "c5.large"), NewLaunchSpecification(2, "c6g.xlarge")}
+	if err := spotFleetPool.ValidateContainerRuntime("containerd", "t2.medium"); err == nil {
+		t.Error("expected the spot fleet including an arm64 instance type to be invalid but was not")
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The kubelet's defaults of `--image-gc-high-threshold` and `--image-gc-low-threshold`
+const (
+	defaultImageGCHighThresholdPercent = 85
+	defaultImageGCLowThresholdPercent  = 80
+)
+
+// defaultImagePruneSchedule is the systemd calendar event the images unused by containers are pruned on
+const defaultImagePruneSchedule = "hourly"
+
+// Containerd is the set of settings for nodes running containerd as their container runtime
+type Containerd struct {
+	ImageGC ContainerdImageGC `yaml:"imageGc,omitempty"`
+}
+
+// ContainerdImageGC configures how the images and the content store of containerd are garbage collected, so that they don't fill node disks
+type ContainerdImageGC struct {
+	// HighThresholdPercent and LowThresholdPercent are the disk usages passed to the kubelet's `--image-gc-high-threshold` and `--image-gc-low-threshold`.
+	// The kubelet starts deleting unused images once the disk usage exceeds the former until it drops below the latter
+	HighThresholdPercent int `yaml:"highThresholdPercent,omitempty"`
+	LowThresholdPercent  int `yaml:"lowThresholdPercent,omitempty"`
+	// PruneThresholdPercent enables a systemd timer which removes every image unused by containers once the disk usage of containerd's root
+	// exceeds it, including the ones the kubelet's image GC keeps for being younger than its minimum GC age
+	PruneThresholdPercent int `yaml:"pruneThresholdPercent,omitempty"`
+	// PruneSchedule is the systemd calendar event like `hourly` or `*:0/15` the timer is triggered on. Defaults to `hourly`
+	PruneSchedule string `yaml:"pruneSchedule,omitempty"`
+	// DiscardUnpackedLayers makes containerd remove compressed layers from the content store once they are unpacked into snapshots
+	DiscardUnpackedLayers bool `yaml:"discardUnpackedLayers,omitempty"`
+}
+
+func (gc ContainerdImageGC) PruneEnabled() bool {
+	return gc.PruneThresholdPercent > 0
+}
+
+func (gc ContainerdImageGC) PruneScheduleOrDefault() string {
+	if gc.PruneSchedule == "" {
+		return defaultImagePruneSchedule
+	}
+	return gc.PruneSchedule
+}
+
+func (gc ContainerdImageGC) Validate() error {
+	for _, t := range []struct {
+		name    string
+		percent int
+	}{
+		{"highThresholdPercent", gc.HighThresholdPercent},
+		{"lowThresholdPercent", gc.LowThresholdPercent},
+		{"pruneThresholdPercent", gc.PruneThresholdPercent},
+	} {
+		if t.percent < 0 || t.percent > 100 {
+			return fmt.Errorf("`containerd.imageGc.%s` must be a percentage between 0 and 100, but was %d", t.name, t.percent)
+		}
+	}
+
+	high, low := gc.HighThresholdPercent, gc.LowThresholdPercent
+	if high == 0 {
+		high = defaultImageGCHighThresholdPercent
+	}
+	if low == 0 {
+		low = defaultImageGCLowThresholdPercent
+	}
+	if (gc.HighThresholdPercent > 0 || gc.LowThresholdPercent > 0) && low >= high {
+		return fmt.Errorf("`containerd.imageGc.lowThresholdPercent` %d must be less than `highThresholdPercent` %d", low, high)
+	}
+
+	if gc.PruneSchedule != "" {
+		if !gc.PruneEnabled() {
+			return errors.New("`containerd.imageGc.pruneSchedule` requires `pruneThresholdPercent`")
+		}
+		if strings.ContainsAny(gc.PruneSchedule, "\n\r") {
+			return fmt.Errorf("invalid `containerd.imageGc.pruneSchedule` %q: it must be a systemd calendar event like hourly", gc.PruneSchedule)
+		}
+	}
+	return nil
+}
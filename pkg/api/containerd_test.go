@@ -0,0 +1,68 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestContainerdImageGCValidate(t *testing.T) {
+	testCases := []struct {
+		gc      ContainerdImageGC
+		isValid bool
+	}{
+		// Valid, defaults
+		{
+			gc:      ContainerdImageGC{},
+			isValid: true,
+		},
+		// Valid, thresholds
+		{
+			gc:      ContainerdImageGC{HighThresholdPercent: 80, LowThresholdPercent: 70},
+			isValid: true,
+		},
+		// Valid, low threshold below the default high threshold
+		{
+			gc:      ContainerdImageGC{LowThresholdPercent: 60},
+			isValid: true,
+		},
+		// Valid, pruning on a schedule
+		{
+			gc:      ContainerdImageGC{PruneThresholdPercent: 90, PruneSchedule: "*:0/15"},
+			isValid: true,
+		},
+		// Invalid, threshold over 100 percent
+		{
+			gc:      ContainerdImageGC{HighThresholdPercent: 101},
+			isValid: false,
+		},
+		// Invalid, negative threshold
+		{
+			gc:      ContainerdImageGC{PruneThresholdPercent: -1},
+			isValid: false,
+		},
+		// Invalid, low threshold not below the high threshold
+		{
+			gc:      ContainerdImageGC{HighThresholdPercent: 70, LowThresholdPercent: 70},
+			isValid: false,
+		},
+		// Invalid, high threshold below the default low threshold
+		{
+			gc:      ContainerdImageGC{HighThresholdPercent: 75},
+			isValid: false,
+		},
+		// Invalid, schedule without threshold
+		{
+			gc:      ContainerdImageGC{PruneSchedule: "daily"},
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.gc.Validate()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.gc, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.gc)
+		}
+	}
+}
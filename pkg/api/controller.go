@@ -7,18 +7,26 @@ import (
 
 // TODO Merge this with WorkerNodePool
 type Controller struct {
-	AutoScalingGroup   AutoScalingGroup `yaml:"autoScalingGroup,omitempty"`
-	Autoscaling        Autoscaling      `yaml:"autoscaling,omitempty"`
-	EC2Instance        `yaml:",inline"`
-	LoadBalancer       ControllerElb       `yaml:"loadBalancer,omitempty"`
-	IAMConfig          IAMConfig           `yaml:"iam,omitempty"`
-	SecurityGroupIds   []string            `yaml:"securityGroupIds"`
-	VolumeMounts       []NodeVolumeMount   `yaml:"volumeMounts,omitempty"`
-	Subnets            Subnets             `yaml:"subnets,omitempty"`
-	CustomFiles        []CustomFile        `yaml:"customFiles,omitempty"`
-	CustomSystemdUnits []CustomSystemdUnit `yaml:"customSystemdUnits,omitempty"`
-	NodeSettings       `yaml:",inline"`
-	UnknownKeys        `yaml:",inline"`
+	AutoScalingGroup      AutoScalingGroup `yaml:"autoScalingGroup,omitempty"`
+	Autoscaling           Autoscaling      `yaml:"autoscaling,omitempty"`
+	EC2Instance           `yaml:",inline"`
+	LoadBalancer          ControllerElb              `yaml:"loadBalancer,omitempty"`
+	IAMConfig             IAMConfig                  `yaml:"iam,omitempty"`
+	SecurityGroupIds      []string                   `yaml:"securityGroupIds"`
+	VolumeMounts          []NodeVolumeMount          `yaml:"volumeMounts,omitempty"`
+	Subnets               Subnets                    `yaml:"subnets,omitempty"`
+	DedicatedSubnets      bool                       `yaml:"dedicatedSubnets,omitempty"`
+	CustomFiles           []CustomFile               `yaml:"customFiles,omitempty"`
+	CustomSystemdUnits    []CustomSystemdUnit        `yaml:"customSystemdUnits,omitempty"`
+	KubeScheduler         KubeScheduler              `yaml:"kubeScheduler,omitempty"`
+	KubeControllerManager KubeControllerManager      `yaml:"kubeControllerManager,omitempty"`
+	Aggregation           Aggregation                `yaml:"aggregation,omitempty"`
+	APIServer             ControllerAPIServer        `yaml:"apiServer,omitempty"`
+	ServiceAccount        ControllerServiceAccount   `yaml:"serviceAccount,omitempty"`
+	EncryptionAtRest      ControllerEncryptionAtRest `yaml:"encryptionAtRest,omitempty"`
+	SelfHosted            bool                       `yaml:"selfHosted,omitempty"`
+	NodeSettings          `yaml:",inline"`
+	UnknownKeys           `yaml:",inline"`
 }
 
 const DefaultControllerCount = 1
@@ -93,11 +101,18 @@ func (c Controller) Validate() error {
 		return err
 	}
 
+	if len(asg.SubnetWeights) > 0 {
+		return errors.New("`controller.autoScalingGroup.subnetWeights` is not supported. It can be specified only for worker node pools")
+	}
+
 	if c.Autoscaling.ClusterAutoscaler.Enabled {
 		return errors.New("cluster-autoscaler can't be enabled for a control plane because " +
 			"allowing so for a group of controller nodes spreading over 2 or more availability zones " +
 			"results in unreliability while scaling nodes out.")
 	}
+	if c.Autoscaling.WarmPool.Enabled {
+		return errors.New("`controller.autoscaling.warmPool` is not supported. It can be enabled only for worker node pools")
+	}
 	if err := c.IAMConfig.Validate(); err != nil {
 		return err
 	}
@@ -107,6 +122,12 @@ func (c Controller) Validate() error {
 	if len(c.Taints) > 0 {
 		return errors.New("`controller.taints` must not be specified because tainting controller nodes breaks the cluster")
 	}
+	if err := c.Aggregation.Validate(); err != nil {
+		return err
+	}
+	if err := c.APIServer.Validate(); err != nil {
+		return err
+	}
 	return nil
 }
 
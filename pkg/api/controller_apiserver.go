@@ -0,0 +1,265 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// maxGoawayChance is the upper bound of the apiserver's `--goaway-chance`
+// See https://kubernetes.io/docs/reference/command-line-tools-reference/kube-apiserver/
+const maxGoawayChance = 0.02
+
+// See https://golang.org/pkg/crypto/tls/#pkg-constants for the cipher suites accepted by the apiserver's `--tls-cipher-suites`
+var supportedTLSCipherSuites = []string{
+	// TLS 1.0 - 1.2 cipher suites
+	"TLS_RSA_WITH_RC4_128_SHA",
+	"TLS_RSA_WITH_3DES_EDE_CBC_SHA",
+	"TLS_RSA_WITH_AES_128_CBC_SHA",
+	"TLS_RSA_WITH_AES_256_CBC_SHA",
+	"TLS_RSA_WITH_AES_128_CBC_SHA256",
+	"TLS_RSA_WITH_AES_128_GCM_SHA256",
+	"TLS_RSA_WITH_AES_256_GCM_SHA384",
+	"TLS_ECDHE_ECDSA_WITH_RC4_128_SHA",
+	"TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA",
+	"TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA",
+	"TLS_ECDHE_RSA_WITH_RC4_128_SHA",
+	"TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA",
+	"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA",
+	"TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA",
+	"TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256",
+	"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256",
+	"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+	"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+	"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+	"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+	"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305",
+	"TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305",
+	// TLS 1.3 cipher suites
+	"TLS_AES_128_GCM_SHA256",
+	"TLS_AES_256_GCM_SHA384",
+	"TLS_CHACHA20_POLY1305_SHA256",
+}
+
+// HTTP/2 which the apiserver serves refuses to start unless one of these is allowed
+// See https://tools.ietf.org/html/rfc7540#section-9.2.2
+var http2RequiredTLSCipherSuites = []string{
+	"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+	"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+}
+
+var supportedTLSMinVersions = []string{
+	"VersionTLS10",
+	"VersionTLS11",
+	"VersionTLS12",
+	"VersionTLS13",
+}
+
+// See https://kubernetes.io/docs/reference/command-line-tools-reference/kube-apiserver/ for `--storage-media-type`
+var supportedStorageMediaTypes = []string{
+	"application/json",
+	"application/yaml",
+	"application/vnd.kubernetes.protobuf",
+}
+
+// ControllerAPIServer is the set of settings for the apiserver running on controller nodes
+type ControllerAPIServer struct {
+	// TLSCipherSuites is the list of cipher suites allowed for the apiserver's TLS connections. Defaults to the Go's default cipher suites
+	TLSCipherSuites []string `yaml:"tlsCipherSuites,omitempty"`
+	// TLSMinVersion is the minimum TLS version the apiserver accepts, like `VersionTLS12`. Defaults to `VersionTLS10`
+	TLSMinVersion string `yaml:"tlsMinVersion,omitempty"`
+	// StorageMediaType is the media type the apiserver stores objects in etcd with, like `application/vnd.kubernetes.protobuf`.
+	// Defaults to the apiserver's default
+	StorageMediaType string `yaml:"storageMediaType,omitempty"`
+	// EventTTL is the duration like `1h` the apiserver retains events in etcd for. Defaults to the apiserver's default, 1h
+	EventTTL string `yaml:"eventTtl,omitempty"`
+	// RequestTimeout is the duration like `2m` after which the apiserver times out requests other than the long-running ones
+	// like watch, exec, attach, port-forward, proxy and log. Defaults to the apiserver's default, 1m
+	RequestTimeout string `yaml:"requestTimeout,omitempty"`
+	// MinRequestTimeout is the duration like `1h` of whole seconds the apiserver keeps each watch request open for at least.
+	// Defaults to the apiserver's default, 30m
+	MinRequestTimeout string `yaml:"minRequestTimeout,omitempty"`
+	// ShutdownDelayDuration is the duration like `70s` the terminating apiserver keeps serving requests while reporting unready via `/readyz`,
+	// so that load balancers stop routing new requests to it before it stops accepting them. Defaults to no delay
+	ShutdownDelayDuration string `yaml:"shutdownDelayDuration,omitempty"`
+	// ShutdownSendRetryAfter makes the terminating apiserver reply new requests with `429 Too Many Requests` and `Retry-After` once the delay elapses,
+	// so that clients retry against other apiservers
+	ShutdownSendRetryAfter bool `yaml:"shutdownSendRetryAfter,omitempty"`
+	// GoawayChance is the probability of the apiserver sending GOAWAY to an HTTP/2 client per request, which makes the client
+	// reconnect and be rebalanced by the load balancer across apiservers. Defaults to 0, which never sends GOAWAY
+	GoawayChance float64 `yaml:"goawayChance,omitempty"`
+	// EnableAggregatorRouting makes the apiserver proxy requests to aggregated API servers via their endpoint IPs rather than
+	// their service cluster IPs
+	EnableAggregatorRouting bool `yaml:"enableAggregatorRouting,omitempty"`
+	// PriorityAndFairness configures API Priority and Fairness of the apiserver
+	PriorityAndFairness APIServerPriorityAndFairness `yaml:"priorityAndFairness,omitempty"`
+	// ServiceAccountIssuer configures the issuer of service account tokens and the publication of its OIDC discovery documents
+	ServiceAccountIssuer ServiceAccountIssuer `yaml:"serviceAccountIssuer,omitempty"`
+	// ClientCAs are the PEM bundles of the CAs the apiserver accepts client certificates signed by, in addition to the cluster's CA
+	// which the kubelets and the control plane components authenticate with. They are concatenated into the `--client-ca-file`
+	ClientCAs TrustedCAs `yaml:"clientCAs,omitempty"`
+	// CertificateReload makes the apiserver reload the serving certificate and the client CAs updated in place on controller nodes
+	CertificateReload APIServerCertificateReload `yaml:"certificateReload,omitempty"`
+	// ScaleProfile is the preset of APIServerScaleSettings for the size of the cluster, one of `small`, `medium` and `large`
+	ScaleProfile           string `yaml:"scaleProfile,omitempty"`
+	APIServerScaleSettings `yaml:",inline"`
+	// Resources are the compute resources of the kube-apiserver static pod. Defaults to none
+	Resources ComputeResources `yaml:"resources,omitempty"`
+	// AnonymousAuth is either `disabled` rejecting every anonymous request, or `healthEndpoints` allowing them only to the health endpoints
+	// so that load balancers can observe the health of apiservers. Defaults to `disabled`
+	AnonymousAuth string `yaml:"anonymousAuth,omitempty"`
+}
+
+func (s ControllerAPIServer) TLSCipherSuitesString() string {
+	return strings.Join(s.TLSCipherSuites, ",")
+}
+
+// ClientCAFile is the path to the `--client-ca-file` of the apiserver
+func (s ControllerAPIServer) ClientCAFile() string {
+	if len(s.ClientCAs) > 0 {
+		return "/etc/kubernetes/ssl/apiserver-client-ca-bundle.pem"
+	}
+	return "/etc/kubernetes/ssl/ca.pem"
+}
+
+// GracefulTerminationEnabled returns true when the apiserver should delay its shutdown and drain requests before exiting
+func (s ControllerAPIServer) GracefulTerminationEnabled() bool {
+	return s.ShutdownDelayDuration != ""
+}
+
+// ShutdownDelay returns the parsed `shutdownDelayDuration`, or zero when it isn't specified or invalid
+func (s ControllerAPIServer) ShutdownDelay() time.Duration {
+	d, err := time.ParseDuration(s.ShutdownDelayDuration)
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+// TerminationGracePeriodSeconds is the grace period of the apiserver pod, long enough for the shutdown delay and for in-flight requests
+// to finish within the request timeout
+func (s ControllerAPIServer) TerminationGracePeriodSeconds() int {
+	return int(math.Ceil(s.ShutdownDelay().Seconds())) + int(math.Ceil(s.RequestTimeoutOrDefault().Seconds()))
+}
+
+func (s ControllerAPIServer) Validate() error {
+	for _, c := range s.TLSCipherSuites {
+		if !containsString(supportedTLSCipherSuites, c) {
+			return fmt.Errorf("`controller.apiServer.tlsCipherSuites` contains the unknown cipher suite \"%s\": it must be one of %s", c, strings.Join(supportedTLSCipherSuites, ", "))
+		}
+	}
+
+	if len(s.TLSCipherSuites) > 0 && s.TLSMinVersion != "VersionTLS13" {
+		http2Supported := false
+		for _, c := range http2RequiredTLSCipherSuites {
+			http2Supported = http2Supported || containsString(s.TLSCipherSuites, c)
+		}
+		if !http2Supported {
+			return errors.New("`controller.apiServer.tlsCipherSuites` must contain either TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 or TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, which is required by HTTP/2 served by the apiserver")
+		}
+	}
+
+	if s.TLSMinVersion != "" && !containsString(supportedTLSMinVersions, s.TLSMinVersion) {
+		return fmt.Errorf("invalid `controller.apiServer.tlsMinVersion` \"%s\": it must be one of %s", s.TLSMinVersion, strings.Join(supportedTLSMinVersions, ", "))
+	}
+
+	if s.StorageMediaType != "" && !containsString(supportedStorageMediaTypes, s.StorageMediaType) {
+		return fmt.Errorf("invalid `controller.apiServer.storageMediaType` \"%s\": it must be one of %s", s.StorageMediaType, strings.Join(supportedStorageMediaTypes, ", "))
+	}
+
+	if s.EventTTL != "" {
+		d, err := time.ParseDuration(s.EventTTL)
+		if err != nil {
+			return fmt.Errorf("invalid `controller.apiServer.eventTtl` \"%s\": %v", s.EventTTL, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("`controller.apiServer.eventTtl` must be a positive duration like 1h, but was \"%s\"", s.EventTTL)
+		}
+	}
+	if err := s.validateRequestTimeouts(); err != nil {
+		return err
+	}
+	if s.ShutdownDelayDuration != "" {
+		d, err := time.ParseDuration(s.ShutdownDelayDuration)
+		if err != nil {
+			return fmt.Errorf("invalid `controller.apiServer.shutdownDelayDuration` \"%s\": %v", s.ShutdownDelayDuration, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("`controller.apiServer.shutdownDelayDuration` must be a positive duration like 70s, but was \"%s\"", s.ShutdownDelayDuration)
+		}
+	}
+	if s.GoawayChance < 0 || s.GoawayChance > maxGoawayChance {
+		return fmt.Errorf("invalid `controller.apiServer.goawayChance` %v: it must be between 0 and %v", s.GoawayChance, maxGoawayChance)
+	}
+	if s.ShutdownSendRetryAfter && !s.GracefulTerminationEnabled() {
+		return errors.New("`controller.apiServer.shutdownSendRetryAfter` requires `controller.apiServer.shutdownDelayDuration` to be specified")
+	}
+
+	if err := s.PriorityAndFairness.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.ClientCAs.validate("controller.apiServer.clientCAs"); err != nil {
+		return err
+	}
+
+	if err := s.validateScaleSettings(); err != nil {
+		return err
+	}
+
+	if err := s.validateAnonymousAuth(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ValidateKubernetesVersion returns an error when a setting isn't supported by the apiserver of the kubernetes version
+func (s ControllerAPIServer) ValidateKubernetesVersion(k8sVer string) error {
+	if s.GracefulTerminationEnabled() {
+		supported, err := k8sVersionSatisfies(">= 1.16", k8sVer)
+		if err != nil {
+			return err
+		}
+		if !supported {
+			return fmt.Errorf("`controller.apiServer.shutdownDelayDuration` requires kubernetesVersion 1.16 or greater, but was %s", k8sVer)
+		}
+	}
+	if s.GoawayChance > 0 {
+		supported, err := k8sVersionSatisfies(">= 1.18", k8sVer)
+		if err != nil {
+			return err
+		}
+		if !supported {
+			return fmt.Errorf("`controller.apiServer.goawayChance` requires kubernetesVersion 1.18 or greater, but was %s", k8sVer)
+		}
+	}
+	if s.ShutdownSendRetryAfter {
+		supported, err := k8sVersionSatisfies(">= 1.22", k8sVer)
+		if err != nil {
+			return err
+		}
+		if !supported {
+			return fmt.Errorf("`controller.apiServer.shutdownSendRetryAfter` requires kubernetesVersion 1.22 or greater, but was %s", k8sVer)
+		}
+	}
+	if s.AnonymousHealthEndpointsEnabled() {
+		// AnonymousAuthConfigurableEndpoints is enabled by default since 1.32
+		supported, err := k8sVersionSatisfies(">= 1.32", k8sVer)
+		if err != nil {
+			return err
+		}
+		if !supported {
+			return fmt.Errorf("`controller.apiServer.anonymousAuth: %s` requires kubernetesVersion 1.32 or greater, but was %s", APIServerAnonymousAuthHealthEndpoints, k8sVer)
+		}
+	}
+	if err := s.PriorityAndFairness.ValidateKubernetesVersion(k8sVer); err != nil {
+		return err
+	}
+	if err := s.ServiceAccountIssuer.ValidateKubernetesVersion(k8sVer); err != nil {
+		return err
+	}
+	return nil
+}
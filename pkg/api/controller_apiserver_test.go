@@ -0,0 +1,262 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestControllerAPIServerValidate(t *testing.T) {
+	clientCA := newTestCACertificatePEM(t, "client-ca")
+
+	testCases := []struct {
+		apiServer ControllerAPIServer
+		isValid   bool
+	}{
+		// Valid, not configured
+		{
+			apiServer: ControllerAPIServer{},
+			isValid:   true,
+		},
+		// Valid, strong cipher suites only
+		{
+			apiServer: ControllerAPIServer{
+				TLSCipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"},
+				TLSMinVersion:   "VersionTLS12",
+			},
+			isValid: true,
+		},
+		// Valid, TLS 1.3 only
+		{
+			apiServer: ControllerAPIServer{
+				TLSCipherSuites: []string{"TLS_AES_128_GCM_SHA256"},
+				TLSMinVersion:   "VersionTLS13",
+			},
+			isValid: true,
+		},
+		// Valid, protobuf storage
+		{
+			apiServer: ControllerAPIServer{StorageMediaType: "application/vnd.kubernetes.protobuf"},
+			isValid:   true,
+		},
+		// Valid, graceful termination
+		{
+			apiServer: ControllerAPIServer{ShutdownDelayDuration: "70s", ShutdownSendRetryAfter: true},
+			isValid:   true,
+		},
+		// Valid, goaway chance
+		{
+			apiServer: ControllerAPIServer{GoawayChance: 0.02},
+			isValid:   true,
+		},
+		// Valid, event TTL
+		{
+			apiServer: ControllerAPIServer{EventTTL: "30m"},
+			isValid:   true,
+		},
+		// Valid, request timeouts
+		{
+			apiServer: ControllerAPIServer{RequestTimeout: "2m", MinRequestTimeout: "1h"},
+			isValid:   true,
+		},
+		// Valid, client CAs
+		{
+			apiServer: ControllerAPIServer{ClientCAs: TrustedCAs{TrustedCA(clientCA), TrustedCA(clientCA + clientCA)}},
+			isValid:   true,
+		},
+		// Invalid, unknown cipher suite
+		{
+			apiServer: ControllerAPIServer{TLSCipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "ECDHE-RSA-AES128-GCM-SHA256"}},
+			isValid:   false,
+		},
+		// Invalid, missing the cipher suite required by HTTP/2
+		{
+			apiServer: ControllerAPIServer{TLSCipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"}},
+			isValid:   false,
+		},
+		// Invalid, unknown min version
+		{
+			apiServer: ControllerAPIServer{TLSMinVersion: "TLS1.2"},
+			isValid:   false,
+		},
+		// Invalid, unknown storage media type
+		{
+			apiServer: ControllerAPIServer{StorageMediaType: "protobuf"},
+			isValid:   false,
+		},
+		// Invalid, negative goaway chance
+		{
+			apiServer: ControllerAPIServer{GoawayChance: -0.001},
+			isValid:   false,
+		},
+		// Invalid, too high goaway chance
+		{
+			apiServer: ControllerAPIServer{GoawayChance: 0.1},
+			isValid:   false,
+		},
+		// Invalid, malformed shutdown delay
+		{
+			apiServer: ControllerAPIServer{ShutdownDelayDuration: "70"},
+			isValid:   false,
+		},
+		// Invalid, non-positive shutdown delay
+		{
+			apiServer: ControllerAPIServer{ShutdownDelayDuration: "0s"},
+			isValid:   false,
+		},
+		// Invalid, event TTL not a duration
+		{
+			apiServer: ControllerAPIServer{EventTTL: "1"},
+			isValid:   false,
+		},
+		// Invalid, zero event TTL
+		{
+			apiServer: ControllerAPIServer{EventTTL: "0s"},
+			isValid:   false,
+		},
+		// Invalid, request timeout not a duration
+		{
+			apiServer: ControllerAPIServer{RequestTimeout: "60"},
+			isValid:   false,
+		},
+		// Invalid, zero request timeout
+		{
+			apiServer: ControllerAPIServer{RequestTimeout: "0s"},
+			isValid:   false,
+		},
+		// Invalid, min request timeout of fractional seconds
+		{
+			apiServer: ControllerAPIServer{MinRequestTimeout: "1500ms"},
+			isValid:   false,
+		},
+		// Invalid, min request timeout shorter than a second
+		{
+			apiServer: ControllerAPIServer{MinRequestTimeout: "500ms"},
+			isValid:   false,
+		},
+		// Invalid, sending Retry-After without the shutdown delay
+		{
+			apiServer: ControllerAPIServer{ShutdownSendRetryAfter: true},
+			isValid:   false,
+		},
+		// Invalid, client CA not in PEM
+		{
+			apiServer: ControllerAPIServer{ClientCAs: TrustedCAs{TrustedCA(clientCA), TrustedCA("not a certificate")}},
+			isValid:   false,
+		},
+		// Valid, anonymous health endpoints
+		{
+			apiServer: ControllerAPIServer{AnonymousAuth: "healthEndpoints"},
+			isValid:   true,
+		},
+		// Invalid, anonymous auth
+		{
+			apiServer: ControllerAPIServer{AnonymousAuth: "enabled"},
+			isValid:   false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.apiServer.Validate()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.apiServer, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.apiServer)
+		}
+	}
+}
+
+func TestControllerAPIServerValidateKubernetesVersion(t *testing.T) {
+	testCases := []struct {
+		apiServer ControllerAPIServer
+		k8sVer    string
+		isValid   bool
+	}{
+		// Valid, not configured
+		{
+			apiServer: ControllerAPIServer{},
+			k8sVer:    "v1.11.3",
+			isValid:   true,
+		},
+		// Valid, shutdown delay
+		{
+			apiServer: ControllerAPIServer{ShutdownDelayDuration: "70s"},
+			k8sVer:    "v1.16.0",
+			isValid:   true,
+		},
+		// Valid, sending Retry-After
+		{
+			apiServer: ControllerAPIServer{ShutdownDelayDuration: "70s", ShutdownSendRetryAfter: true},
+			k8sVer:    "v1.22.1",
+			isValid:   true,
+		},
+		// Valid, goaway chance
+		{
+			apiServer: ControllerAPIServer{GoawayChance: 0.001},
+			k8sVer:    "v1.18.0",
+			isValid:   true,
+		},
+		// Invalid, shutdown delay unsupported
+		{
+			apiServer: ControllerAPIServer{ShutdownDelayDuration: "70s"},
+			k8sVer:    "v1.15.5",
+			isValid:   false,
+		},
+		// Invalid, goaway chance unsupported
+		{
+			apiServer: ControllerAPIServer{GoawayChance: 0.001},
+			k8sVer:    "v1.17.9",
+			isValid:   false,
+		},
+		// Invalid, sending Retry-After unsupported
+		{
+			apiServer: ControllerAPIServer{ShutdownDelayDuration: "70s", ShutdownSendRetryAfter: true},
+			k8sVer:    "v1.21.3",
+			isValid:   false,
+		},
+		// Valid, anonymous health endpoints
+		{
+			apiServer: ControllerAPIServer{AnonymousAuth: "healthEndpoints"},
+			k8sVer:    "v1.32.0",
+			isValid:   true,
+		},
+		// Invalid, anonymous health endpoints unsupported
+		{
+			apiServer: ControllerAPIServer{AnonymousAuth: "healthEndpoints"},
+			k8sVer:    "v1.31.4",
+			isValid:   false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.apiServer.ValidateKubernetesVersion(testCase.k8sVer)
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid for %s but got an error: %v", i, testCase.apiServer, testCase.k8sVer, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid for %s but was not", i, testCase.apiServer, testCase.k8sVer)
+		}
+	}
+}
+
+func TestControllerAPIServerTerminationGracePeriodSeconds(t *testing.T) {
+	s := ControllerAPIServer{ShutdownDelayDuration: "70500ms"}
+	if actual := s.TerminationGracePeriodSeconds(); actual != 131 {
+		t.Errorf("expected 131 but was %d", actual)
+	}
+}
+
+func TestControllerAPIServerTerminationGracePeriodSecondsWithRequestTimeout(t *testing.T) {
+	s := ControllerAPIServer{ShutdownDelayDuration: "70s", RequestTimeout: "2m"}
+	if actual := s.TerminationGracePeriodSeconds(); actual != 190 {
+		t.Errorf("expected 190 but was %d", actual)
+	}
+}
+
+func TestControllerAPIServerMinRequestTimeoutSeconds(t *testing.T) {
+	if actual := (ControllerAPIServer{}).MinRequestTimeoutSeconds(); actual != 1800 {
+		t.Errorf("expected 1800 but was %d", actual)
+	}
+	if actual := (ControllerAPIServer{MinRequestTimeout: "1h"}).MinRequestTimeoutSeconds(); actual != 3600 {
+		t.Errorf("expected 3600 but was %d", actual)
+	}
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	KMSPluginAPIVersionV1 = "v1"
+	KMSPluginAPIVersionV2 = "v2"
+
+	defaultKMSPluginName    = "aws-encryption-provider"
+	defaultKMSPluginTimeout = "3s"
+	// kmsPluginSocketDir is shared between the KMS plugin and the apiserver via the host
+	kmsPluginSocketDir = "/var/run/kmsplugin"
+	// kmsPluginKeyARNPlaceholder is replaced with the ARN of the KMS key created in the control-plane stack on each controller node
+	kmsPluginKeyARNPlaceholder = "__KMS_PLUGIN_KEY_ARN__"
+	kmsPluginKeyARNFile        = "/etc/kubernetes/kms-plugin-key-arn"
+)
+
+// ControllerEncryptionAtRest configures how the apiserver encrypts secrets at rest on top of `kubernetes.encryptionAtRest`
+type ControllerEncryptionAtRest struct {
+	KMS KMSPlugin `yaml:"kms,omitempty"`
+}
+
+// KMSPlugin is the KMS plugin run as a static pod on each controller node, which the apiserver talks to over the unix socket on the host to
+// encrypt and decrypt secrets with the KMS key. The kms provider precedes the aescbc provider in the encryption config, so that the secrets
+// are encrypted with the KMS key while the secrets already encrypted with the aescbc key remain readable
+type KMSPlugin struct {
+	// Name is the name of the kms provider, which is stored in the encrypted secrets. Defaults to `aws-encryption-provider`
+	Name string `yaml:"name,omitempty"`
+	// KeyARN is the ARN of the KMS key the plugin encrypts the data encryption keys with. It must be in the region of the cluster
+	KeyARN string `yaml:"keyArn,omitempty"`
+	// CreateKey creates the KMS key dedicated to the plugin in the control-plane stack instead of KeyARN.
+	// The key is retained on the deletion of the stack, as the secrets can't be decrypted without it
+	CreateKey bool `yaml:"createKey,omitempty"`
+	// Image is the image of the plugin like `kubernetes-sigs/aws-encryption-provider`
+	Image Image `yaml:"image,omitempty"`
+	// APIVersion is the version of the KMS plugin API, either `v1` or `v2`. Defaults to `v2` for kubernetesVersion 1.29 or greater,
+	// in which the v1 API is disabled by default, or `v1` otherwise
+	APIVersion string `yaml:"apiVersion,omitempty"`
+	// Timeout is how long the apiserver waits for the plugin like `3s`. Defaults to `3s`
+	Timeout string `yaml:"timeout,omitempty"`
+	// Args replaces the default arguments of the aws-encryption-provider, which listens on the socket with the key in the region of the cluster
+	Args []string `yaml:"args,omitempty"`
+}
+
+func (p KMSPlugin) Enabled() bool {
+	return p.KeyARN != "" || p.CreateKey || p.Image.Repo != "" || p.Image.Tag != "" || p.Name != "" || p.APIVersion != "" || p.Timeout != "" || len(p.Args) > 0
+}
+
+func (p KMSPlugin) NameOrDefault() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return defaultKMSPluginName
+}
+
+func (p KMSPlugin) TimeoutOrDefault() string {
+	if p.Timeout != "" {
+		return p.Timeout
+	}
+	return defaultKMSPluginTimeout
+}
+
+// APIVersionOrDefault returns the version of the KMS plugin API the apiserver speaks for the version of Kubernetes
+func (p KMSPlugin) APIVersionOrDefault(k8sVer string) string {
+	if p.APIVersion != "" {
+		return p.APIVersion
+	}
+	if v2, err := k8sVersionSatisfies(">= 1.29", k8sVer); err == nil && v2 {
+		return KMSPluginAPIVersionV2
+	}
+	return KMSPluginAPIVersionV1
+}
+
+// SocketDir returns the directory on the host in which the plugin creates its socket
+func (p KMSPlugin) SocketDir() string {
+	return kmsPluginSocketDir
+}
+
+// Endpoint returns the endpoint of the plugin referenced by the kms provider in the encryption config
+func (p KMSPlugin) Endpoint() string {
+	return fmt.Sprintf("unix://%s/socket.sock", kmsPluginSocketDir)
+}
+
+// KeyARNOrPlaceholder returns the ARN of the KMS key, or the placeholder replaced with the ARN of the key created in the control-plane stack
+// by configure-kms-encryption-provider on each controller node
+func (p KMSPlugin) KeyARNOrPlaceholder() string {
+	if p.CreateKey {
+		return kmsPluginKeyARNPlaceholder
+	}
+	return p.KeyARN
+}
+
+// KeyARNFile returns the file on each controller node the ARN of the KMS key created in the control-plane stack is written to
+func (p KMSPlugin) KeyARNFile() string {
+	return kmsPluginKeyARNFile
+}
+
+// ArgsOrDefault returns the arguments of the plugin container
+func (p KMSPlugin) ArgsOrDefault(region string) []string {
+	if len(p.Args) > 0 {
+		return p.Args
+	}
+	return []string{
+		fmt.Sprintf("--key=%s", p.KeyARNOrPlaceholder()),
+		fmt.Sprintf("--region=%s", region),
+		fmt.Sprintf("--listen=%s/socket.sock", kmsPluginSocketDir),
+		// The default health port 8080 conflicts with the insecure port of the apiserver on the host network
+		"--health-port=:8083",
+	}
+}
+
+func (c Cluster) validateControllerEncryptionAtRest() error {
+	p := c.Controller.EncryptionAtRest.KMS
+	if !p.Enabled() {
+		return nil
+	}
+
+	if !c.Kubernetes.EncryptionAtRest.Enabled {
+		return errors.New("`controller.encryptionAtRest.kms` requires `kubernetes.encryptionAtRest.enabled` to be true")
+	}
+	if p.CreateKey {
+		if p.KeyARN != "" {
+			return errors.New("`controller.encryptionAtRest.kms.keyArn` and `controller.encryptionAtRest.kms.createKey` are mutually exclusive")
+		}
+	} else {
+		if p.KeyARN == "" {
+			return errors.New("`controller.encryptionAtRest.kms.keyArn` must be set to the ARN of the KMS key the plugin encrypts secrets with, or `createKey` must be true")
+		}
+		m := kmsKeyARNPattern.FindStringSubmatch(p.KeyARN)
+		if m == nil {
+			return fmt.Errorf("invalid `controller.encryptionAtRest.kms.keyArn` \"%s\": it must be like arn:aws:kms:us-west-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab", p.KeyARN)
+		}
+		if !c.Region.IsEmpty() && m[2] != c.Region.String() {
+			return fmt.Errorf("`controller.encryptionAtRest.kms.keyArn` must reference the region %s of the cluster, but was \"%s\"", c.Region, p.KeyARN)
+		}
+	}
+	if p.Image.Repo == "" || p.Image.Tag == "" {
+		return errors.New("`controller.encryptionAtRest.kms.image.repo` and `controller.encryptionAtRest.kms.image.tag` must be set to the image of the KMS plugin")
+	}
+
+	switch p.APIVersion {
+	case "", KMSPluginAPIVersionV1, KMSPluginAPIVersionV2:
+	default:
+		return fmt.Errorf("invalid `controller.encryptionAtRest.kms.apiVersion` \"%s\": it must be either %s or %s", p.APIVersion, KMSPluginAPIVersionV1, KMSPluginAPIVersionV2)
+	}
+	switch p.APIVersionOrDefault(c.K8sVer) {
+	case KMSPluginAPIVersionV2:
+		supported, err := k8sVersionSatisfies(">= 1.25", c.K8sVer)
+		if err != nil {
+			return err
+		}
+		if !supported {
+			return fmt.Errorf("`controller.encryptionAtRest.kms.apiVersion` %s requires kubernetesVersion 1.25 or greater, but was %s", KMSPluginAPIVersionV2, c.K8sVer)
+		}
+	case KMSPluginAPIVersionV1:
+		disabled, err := k8sVersionSatisfies(">= 1.29", c.K8sVer)
+		if err != nil {
+			return err
+		}
+		if disabled {
+			return fmt.Errorf("`controller.encryptionAtRest.kms.apiVersion` %s is disabled by default since kubernetesVersion 1.29, but was %s. Use %s instead", KMSPluginAPIVersionV1, c.K8sVer, KMSPluginAPIVersionV2)
+		}
+	}
+
+	if d, err := time.ParseDuration(p.TimeoutOrDefault()); err != nil || d <= 0 {
+		return fmt.Errorf("invalid `controller.encryptionAtRest.kms.timeout` \"%s\": it must be a positive duration like 3s", p.Timeout)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestValidateControllerEncryptionAtRest(t *testing.T) {
+	keyARN := "arn:aws:kms:us-west-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+	image := Image{Repo: "example.com/aws-encryption-provider", Tag: "v0.1.0"}
+	cluster := func(k8sVer string, p KMSPlugin) Cluster {
+		c := Cluster{}
+		c.K8sVer = k8sVer
+		c.Region = RegionForName("us-west-1")
+		c.Kubernetes.EncryptionAtRest.Enabled = true
+		c.Controller.EncryptionAtRest.KMS = p
+		return c
+	}
+
+	testCases := []struct {
+		cluster Cluster
+		isValid bool
+	}{
+		// Valid, not configured
+		{
+			cluster: Cluster{},
+			isValid: true,
+		},
+		// Valid, the defaults
+		{
+			cluster: cluster("v1.11.3", KMSPlugin{KeyARN: keyARN, Image: image}),
+			isValid: true,
+		},
+		// Valid, the v2 API
+		{
+			cluster: cluster("v1.27.0", KMSPlugin{KeyARN: keyARN, Image: image, APIVersion: "v2", Timeout: "10s"}),
+			isValid: true,
+		},
+		// Valid, the v2 API by default
+		{
+			cluster: cluster("v1.29.0", KMSPlugin{KeyARN: keyARN, Image: image}),
+			isValid: true,
+		},
+		// Valid, the key created in the stack
+		{
+			cluster: cluster("v1.11.3", KMSPlugin{CreateKey: true, Image: image}),
+			isValid: true,
+		},
+		// Invalid, without the key
+		{
+			cluster: cluster("v1.11.3", KMSPlugin{Image: image}),
+			isValid: false,
+		},
+		// Invalid, both the key and the key created in the stack
+		{
+			cluster: cluster("v1.11.3", KMSPlugin{KeyARN: keyARN, CreateKey: true, Image: image}),
+			isValid: false,
+		},
+		// Invalid, malformed key ARN
+		{
+			cluster: cluster("v1.11.3", KMSPlugin{KeyARN: "1234abcd-12ab-34cd-56ef-1234567890ab", Image: image}),
+			isValid: false,
+		},
+		// Invalid, the key in another region
+		{
+			cluster: cluster("v1.11.3", KMSPlugin{KeyARN: "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab", Image: image}),
+			isValid: false,
+		},
+		// Invalid, without the image tag
+		{
+			cluster: cluster("v1.11.3", KMSPlugin{KeyARN: keyARN, Image: Image{Repo: image.Repo}}),
+			isValid: false,
+		},
+		// Invalid, unknown API version
+		{
+			cluster: cluster("v1.27.0", KMSPlugin{KeyARN: keyARN, Image: image, APIVersion: "v3"}),
+			isValid: false,
+		},
+		// Invalid, the v2 API on kubernetes older than 1.25
+		{
+			cluster: cluster("v1.24.0", KMSPlugin{KeyARN: keyARN, Image: image, APIVersion: "v2"}),
+			isValid: false,
+		},
+		// Invalid, the v1 API on kubernetes 1.29
+		{
+			cluster: cluster("v1.29.0", KMSPlugin{KeyARN: keyARN, Image: image, APIVersion: "v1"}),
+			isValid: false,
+		},
+		// Invalid, non-positive timeout
+		{
+			cluster: cluster("v1.11.3", KMSPlugin{KeyARN: keyARN, Image: image, Timeout: "0s"}),
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.cluster.validateControllerEncryptionAtRest()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.cluster.Controller.EncryptionAtRest.KMS, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.cluster.Controller.EncryptionAtRest.KMS)
+		}
+	}
+
+	withoutEncryptionAtRest := cluster("v1.11.3", KMSPlugin{KeyARN: keyARN, Image: image})
+	withoutEncryptionAtRest.Kubernetes.EncryptionAtRest.Enabled = false
+	if err := withoutEncryptionAtRest.validateControllerEncryptionAtRest(); err == nil {
+		t.Error("expected the KMS plugin without `kubernetes.encryptionAtRest.enabled` to be invalid but was not")
+	}
+}
+
+func TestKMSPluginArgsOrDefault(t *testing.T) {
+	p := KMSPlugin{KeyARN: "arn:aws:kms:us-west-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"}
+	args := p.ArgsOrDefault("us-west-1")
+	for _, e := range []string{"--key=" + p.KeyARN, "--region=us-west-1", "--listen=/var/run/kmsplugin/socket.sock"} {
+		if !containsString(args, e) {
+			t.Errorf("expected the default args %v to contain %s, but they didn't", args, e)
+		}
+	}
+
+	p.Args = []string{"--custom"}
+	if args := p.ArgsOrDefault("us-west-1"); len(args) != 1 || args[0] != "--custom" {
+		t.Errorf("expected the args to be replaced with %v, but were %v", p.Args, args)
+	}
+}
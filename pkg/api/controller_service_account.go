@@ -0,0 +1,57 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ControllerServiceAccount configures the service account tokens the apiserver issues to pods
+type ControllerServiceAccount struct {
+	// BoundTokens makes the apiserver mount projected service account tokens, which are bound to the pods and the audiences and time-limited,
+	// into all the pods instead of the never-expiring tokens stored in secrets. It requires `controller.apiServer.serviceAccountIssuer.url`
+	// so that the apiserver issues the tokens via the TokenRequest API signed with the service account key
+	BoundTokens bool `yaml:"boundTokens,omitempty"`
+	// ExtendTokenExpiration is passed to the apiserver's `--service-account-extend-token-expiration`. Defaults to true, which extends the
+	// lifetime of the projected tokens to a year so that clients not reloading their tokens keep working while the apiserver reports
+	// the uses of the expired tokens via the `serviceaccount_stale_tokens_total` metric. Set it to false to reject the expired tokens
+	ExtendTokenExpiration *bool `yaml:"extendTokenExpiration,omitempty"`
+}
+
+func (a ControllerServiceAccount) ExtendTokenExpirationOrDefault() bool {
+	if a.ExtendTokenExpiration != nil {
+		return *a.ExtendTokenExpiration
+	}
+	return true
+}
+
+// boundTokensFeatureGates returns the feature gates the control plane components require for the projected tokens.
+// The ServiceAccount admission plugin projects the tokens into pods by default since kube 1.21
+func (a ControllerServiceAccount) boundTokensFeatureGates(k8sVer string) FeatureGates {
+	gates := FeatureGates{}
+	if !a.BoundTokens {
+		return gates
+	}
+	if ok, err := k8sVersionSatisfies("< 1.21", k8sVer); err == nil && ok {
+		gates["BoundServiceAccountTokenVolume"] = "true"
+	}
+	return gates
+}
+
+func (c Cluster) validateControllerServiceAccount() error {
+	a := c.Controller.ServiceAccount
+	if !a.BoundTokens {
+		if a.ExtendTokenExpiration != nil {
+			return errors.New("`controller.serviceAccount.extendTokenExpiration` requires `controller.serviceAccount.boundTokens` to be true")
+		}
+		return nil
+	}
+	if !c.Controller.APIServer.ServiceAccountIssuer.Enabled() {
+		return errors.New("`controller.serviceAccount.boundTokens` requires `controller.apiServer.serviceAccountIssuer.url` so that the apiserver issues the projected tokens signed with the service account key")
+	}
+	for name := range a.boundTokensFeatureGates(c.K8sVer) {
+		if v, ok := c.Controller.NodeSettings.FeatureGates[name]; ok && v != "true" {
+			return fmt.Errorf("`controller.serviceAccount.boundTokens` conflicts with the feature gate `%s=%s` in `controller.featureGates`", name, v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestValidateControllerServiceAccount(t *testing.T) {
+	disabled := false
+	issuer := ServiceAccountIssuer{URL: "https://oidc.example.com/mycluster"}
+	testCases := []struct {
+		k8sVer         string
+		serviceAccount ControllerServiceAccount
+		issuer         ServiceAccountIssuer
+		featureGates   FeatureGates
+		isValid        bool
+	}{
+		// Valid, disabled
+		{
+			k8sVer:  "v1.20.2",
+			isValid: true,
+		},
+		// Valid, bound tokens
+		{
+			k8sVer:         "v1.20.2",
+			serviceAccount: ControllerServiceAccount{BoundTokens: true},
+			issuer:         issuer,
+			isValid:        true,
+		},
+		// Valid, bound tokens without extended expiration
+		{
+			k8sVer:         "v1.21.1",
+			serviceAccount: ControllerServiceAccount{BoundTokens: true, ExtendTokenExpiration: &disabled},
+			issuer:         issuer,
+			isValid:        true,
+		},
+		// Valid, the feature gate explicitly enabled
+		{
+			k8sVer:         "v1.20.2",
+			serviceAccount: ControllerServiceAccount{BoundTokens: true},
+			issuer:         issuer,
+			featureGates:   FeatureGates{"BoundServiceAccountTokenVolume": "true"},
+			isValid:        true,
+		},
+		// Invalid, without the issuer
+		{
+			k8sVer:         "v1.21.1",
+			serviceAccount: ControllerServiceAccount{BoundTokens: true},
+			isValid:        false,
+		},
+		// Invalid, extended expiration without bound tokens
+		{
+			k8sVer:         "v1.21.1",
+			serviceAccount: ControllerServiceAccount{ExtendTokenExpiration: &disabled},
+			issuer:         issuer,
+			isValid:        false,
+		},
+		// Invalid, the feature gate disabled
+		{
+			k8sVer:         "v1.20.2",
+			serviceAccount: ControllerServiceAccount{BoundTokens: true},
+			issuer:         issuer,
+			featureGates:   FeatureGates{"BoundServiceAccountTokenVolume": "false"},
+			isValid:        false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		c := Cluster{}
+		c.K8sVer = testCase.k8sVer
+		c.Controller.ServiceAccount = testCase.serviceAccount
+		c.Controller.APIServer.ServiceAccountIssuer = testCase.issuer
+		c.Controller.NodeSettings.FeatureGates = testCase.featureGates
+		err := c.validateControllerServiceAccount()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.serviceAccount, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.serviceAccount)
+		}
+	}
+}
+
+func TestControllerServiceAccountFeatureGates(t *testing.T) {
+	testCases := []struct {
+		k8sVer         string
+		serviceAccount ControllerServiceAccount
+		expected       string
+	}{
+		{
+			k8sVer:         "v1.20.2",
+			serviceAccount: ControllerServiceAccount{},
+			expected:       "",
+		},
+		{
+			k8sVer:         "v1.20.2",
+			serviceAccount: ControllerServiceAccount{BoundTokens: true},
+			expected:       "BoundServiceAccountTokenVolume=true",
+		},
+		// Enabled by default since kube 1.21
+		{
+			k8sVer:         "v1.21.1",
+			serviceAccount: ControllerServiceAccount{BoundTokens: true},
+			expected:       "",
+		},
+	}
+
+	for i, testCase := range testCases {
+		actual := testCase.serviceAccount.boundTokensFeatureGates(testCase.k8sVer).String()
+		if actual != testCase.expected {
+			t.Errorf("case %d: expected the feature gates to be \"%s\" but was \"%s\"", i, testCase.expected, actual)
+		}
+	}
+}
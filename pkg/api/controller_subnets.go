@@ -0,0 +1,92 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RequiredAvailabilityZones returns the number of availability zones controller nodes must span so that the control plane
+// survives an outage of a single availability zone
+func (c Controller) RequiredAvailabilityZones() int {
+	if c.MinControllerCount() >= 2 {
+		return 2
+	}
+	return 1
+}
+
+// validateControllerSubnets ensures that `controller.subnets` span enough availability zones for the number of controller nodes, and
+// that neither they nor their route tables are shared with etcd and worker nodes when `controller.dedicatedSubnets` is true
+func (c Cluster) validateControllerSubnets() error {
+	if !c.Controller.DedicatedSubnets {
+		return nil
+	}
+
+	required := c.Controller.RequiredAvailabilityZones()
+	if azs, known := c.Controller.Subnets.AvailabilityZones(); known && len(azs) < required {
+		return fmt.Errorf("`controller.subnets` must span at least %d availability zones for %d controller nodes, but spanned only %s",
+			required, c.Controller.MinControllerCount(), strings.Join(azs, ", "))
+	}
+
+	others := []struct {
+		owner   string
+		subnets Subnets
+	}{
+		{owner: "`etcd.subnets`", subnets: c.Etcd.Subnets},
+	}
+	for _, p := range c.Worker.NodePools {
+		others = append(others, struct {
+			owner   string
+			subnets Subnets
+		}{owner: fmt.Sprintf("the node pool \"%s\"", p.NodePoolName), subnets: c.nodePoolSubnets(p)})
+	}
+
+	for _, s := range c.Controller.Subnets {
+		for _, o := range others {
+			if o.subnets.containsName(s.Name) {
+				return fmt.Errorf("`controller.subnets` must not share the subnet \"%s\" with %s when `controller.dedicatedSubnets` is true", s.Name, o.owner)
+			}
+			// Routes added to a shared route table would apply to controller and the other nodes alike
+			if !s.RouteTable.HasIdentifier() {
+				continue
+			}
+			for _, ref := range o.subnets {
+				other, ok := c.subnetNamed(ref.Name)
+				if ok && other.RouteTable == s.RouteTable {
+					return fmt.Errorf("`controller.subnets` must not share the route table of the subnet \"%s\" with the subnet \"%s\" of %s when `controller.dedicatedSubnets` is true",
+						s.Name, other.Name, o.owner)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c Cluster) subnetNamed(name string) (Subnet, bool) {
+	for _, s := range c.Subnets {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Subnet{}, false
+}
+
+// nodePoolSubnets returns the subnets the node pool is going to be placed in, including the ones it defaults to
+func (c Cluster) nodePoolSubnets(p WorkerNodePool) Subnets {
+	if len(p.Subnets) > 0 {
+		return p.Subnets
+	}
+	if p.Private {
+		return c.PrivateSubnets()
+	}
+	return c.PublicSubnets()
+}
+
+func (ss Subnets) containsName(name string) bool {
+	for _, s := range ss {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
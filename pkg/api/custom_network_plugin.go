@@ -0,0 +1,107 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/kubernetes-incubator/kube-aws/gzipcompressor"
+)
+
+// CustomNetworkPluginManifestsDir is the directory on controllers the manifests of the custom CNI are written to
+const CustomNetworkPluginManifestsDir = "/srv/kubernetes/manifests/custom-cni"
+
+// CustomNetworkPlugin is a CNI installed by applying the manifests supplied by the user in place of canal or flannel.
+// The CNI is expected to install its binaries to /opt/cni/bin and its config to /etc/kubernetes/cni/net.d, which kubelets read.
+type CustomNetworkPlugin struct {
+	// Manifests are applied in order by controllers on bootstrap
+	Manifests []CustomNetworkPluginManifest `yaml:"manifests,omitempty"`
+	// AllocateNodeCIDRs lets the controller manager allocate a pod CIDR from `podCIDR` to each node.
+	// Defaults to true. Disable it for CNIs managing pod IPs on their own
+	AllocateNodeCIDRs *bool `yaml:"allocateNodeCIDRs,omitempty"`
+	// HostInterface is the host interface, or the pattern of it like `cali+`, through which pod traffic goes.
+	// Required by kube2iam and kiam
+	HostInterface string `yaml:"hostInterface,omitempty"`
+	// NetworkPolicy is true when the CNI enforces NetworkPolicy, which `defaults.networkPolicy` requires
+	NetworkPolicy bool `yaml:"networkPolicy,omitempty"`
+}
+
+type CustomNetworkPluginManifest struct {
+	// Name is the name of the file the manifest is written to, without the .yaml extension
+	Name string `yaml:"name"`
+	// Content is the manifest itself
+	Content string `yaml:"content,omitempty"`
+	// URL is where the manifest is fetched from by kubectl. Either `content` or `url` can be specified
+	URL string `yaml:"url,omitempty"`
+}
+
+// Path is the path of the file the manifest is written to on controllers
+func (m CustomNetworkPluginManifest) Path() string {
+	return fmt.Sprintf("%s/%s.yaml", CustomNetworkPluginManifestsDir, m.Name)
+}
+
+// Source is what kubectl applies, either the URL or the path of the written file
+func (m CustomNetworkPluginManifest) Source() string {
+	if m.URL != "" {
+		return m.URL
+	}
+	return m.Path()
+}
+
+func (m CustomNetworkPluginManifest) GzippedBase64Content() (string, error) {
+	return gzipcompressor.StringToGzippedBase64String(m.Content)
+}
+
+func (p CustomNetworkPlugin) IsEmpty() bool {
+	return len(p.Manifests) == 0 && p.AllocateNodeCIDRs == nil && p.HostInterface == "" && !p.NetworkPolicy
+}
+
+// validateCustomNetworkPlugin checks that the custom CNI is coherent with the rest of the networking settings and the IAM metadata proxies
+func (n Networking) validateCustomNetworkPlugin(experimental Experimental) error {
+	p := n.SelfHosting.Custom
+
+	if n.SelfHosting.Type != NetworkPluginCustom {
+		if !p.IsEmpty() {
+			return errors.New("`kubernetes.networking.selfHosting.custom` requires `kubernetes.networking.selfHosting.type` to be `custom`")
+		}
+		return nil
+	}
+
+	if n.AmazonVPC.Enabled {
+		return errors.New("`kubernetes.networking.selfHosting.type: custom` can't be used with `kubernetes.networking.amazonVPC.enabled`")
+	}
+
+	if len(p.Manifests) == 0 {
+		return errors.New("`kubernetes.networking.selfHosting.type: custom` requires at least one of `kubernetes.networking.selfHosting.custom.manifests`")
+	}
+
+	names := map[string]bool{}
+	for i, m := range p.Manifests {
+		if m.Name == "" || !kubernetesObjectNamePattern.MatchString(m.Name) {
+			return fmt.Errorf("invalid name \"%s\" of the custom CNI manifest %d: it must consist of lower case alphanumeric characters, '-' or '.'", m.Name, i)
+		}
+		if names[m.Name] {
+			return fmt.Errorf("duplicate custom CNI manifest \"%s\"", m.Name)
+		}
+		names[m.Name] = true
+
+		if (m.Content == "") == (m.URL == "") {
+			return fmt.Errorf("exactly one of `content` or `url` must be specified for the custom CNI manifest \"%s\"", m.Name)
+		}
+		if m.URL != "" {
+			u, err := url.Parse(m.URL)
+			if err != nil {
+				return fmt.Errorf("invalid `url` of the custom CNI manifest \"%s\": %v", m.Name, err)
+			}
+			if u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+				return fmt.Errorf("`url` of the custom CNI manifest \"%s\" must be an http or https URL with a host, but was \"%s\"", m.Name, m.URL)
+			}
+		}
+	}
+
+	if p.HostInterface == "" && (experimental.Kube2IamSupport.Enabled || experimental.KIAMSupport.Enabled) {
+		return errors.New("`kube2IamSupport` and `kiamSupport` require `kubernetes.networking.selfHosting.custom.hostInterface` to be set for the custom CNI")
+	}
+
+	return nil
+}
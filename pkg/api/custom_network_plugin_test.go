@@ -0,0 +1,137 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestValidateCustomNetworkPlugin(t *testing.T) {
+	manifests := []CustomNetworkPluginManifest{
+		{Name: "cilium", URL: "https://example.com/cilium.yaml"},
+		{Name: "cilium-config", Content: "apiVersion: v1\nkind: ConfigMap\n"},
+	}
+	kube2iam := Experimental{Kube2IamSupport: Kube2IamSupport{Enabled: true}}
+
+	testCases := []struct {
+		networking   Networking
+		experimental Experimental
+		isValid      bool
+	}{
+		// Valid, canal
+		{
+			networking: Networking{SelfHosting: SelfHosting{Type: "canal"}},
+			isValid:    true,
+		},
+		// Valid, manifests
+		{
+			networking: Networking{SelfHosting: SelfHosting{Type: "custom", Custom: CustomNetworkPlugin{Manifests: manifests}}},
+			isValid:    true,
+		},
+		// Valid, kube2iam with a host interface
+		{
+			networking:   Networking{SelfHosting: SelfHosting{Type: "custom", Custom: CustomNetworkPlugin{Manifests: manifests, HostInterface: "lxc+"}}},
+			experimental: kube2iam,
+			isValid:      true,
+		},
+		// Invalid, custom settings for canal
+		{
+			networking: Networking{SelfHosting: SelfHosting{Type: "canal", Custom: CustomNetworkPlugin{Manifests: manifests}}},
+			isValid:    false,
+		},
+		// Invalid, the VPC CNI
+		{
+			networking: Networking{AmazonVPC: AmazonVPC{Enabled: true}, SelfHosting: SelfHosting{Type: "custom", Custom: CustomNetworkPlugin{Manifests: manifests}}},
+			isValid:    false,
+		},
+		// Invalid, no manifests
+		{
+			networking: Networking{SelfHosting: SelfHosting{Type: "custom"}},
+			isValid:    false,
+		},
+		// Invalid, manifest name
+		{
+			networking: Networking{SelfHosting: SelfHosting{Type: "custom", Custom: CustomNetworkPlugin{Manifests: []CustomNetworkPluginManifest{{Name: "../cilium", Content: "a"}}}}},
+			isValid:    false,
+		},
+		// Invalid, duplicate manifest names
+		{
+			networking: Networking{SelfHosting: SelfHosting{Type: "custom", Custom: CustomNetworkPlugin{Manifests: []CustomNetworkPluginManifest{{Name: "cilium", Content: "a"}, {Name: "cilium", Content: "b"}}}}},
+			isValid:    false,
+		},
+		// Invalid, both content and url
+		{
+			networking: Networking{SelfHosting: SelfHosting{Type: "custom", Custom: CustomNetworkPlugin{Manifests: []CustomNetworkPluginManifest{{Name: "cilium", Content: "a", URL: "https://example.com/cilium.yaml"}}}}},
+			isValid:    false,
+		},
+		// Invalid, neither content nor url
+		{
+			networking: Networking{SelfHosting: SelfHosting{Type: "custom", Custom: CustomNetworkPlugin{Manifests: []CustomNetworkPluginManifest{{Name: "cilium"}}}}},
+			isValid:    false,
+		},
+		// Invalid, url
+		{
+			networking: Networking{SelfHosting: SelfHosting{Type: "custom", Custom: CustomNetworkPlugin{Manifests: []CustomNetworkPluginManifest{{Name: "cilium", URL: "file:///cilium.yaml"}}}}},
+			isValid:    false,
+		},
+		// Invalid, kube2iam without a host interface
+		{
+			networking:   Networking{SelfHosting: SelfHosting{Type: "custom", Custom: CustomNetworkPlugin{Manifests: manifests}}},
+			experimental: kube2iam,
+			isValid:      false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.networking.validateCustomNetworkPlugin(testCase.experimental)
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.networking, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.networking)
+		}
+	}
+}
+
+func TestNetworkingCustomNetworkPlugin(t *testing.T) {
+	disabled := false
+
+	testCases := []struct {
+		networking        Networking
+		allocateNodeCIDRs bool
+		hostInterface     string
+	}{
+		{
+			networking:        Networking{AmazonVPC: AmazonVPC{Enabled: true}},
+			allocateNodeCIDRs: false,
+			hostInterface:     "!eni0",
+		},
+		{
+			networking:        Networking{SelfHosting: SelfHosting{Type: "canal"}},
+			allocateNodeCIDRs: true,
+			hostInterface:     "cali+",
+		},
+		{
+			networking:        Networking{SelfHosting: SelfHosting{Type: "flannel"}},
+			allocateNodeCIDRs: true,
+			hostInterface:     "cni0",
+		},
+		{
+			networking:        Networking{SelfHosting: SelfHosting{Type: "custom", Custom: CustomNetworkPlugin{HostInterface: "lxc+"}}},
+			allocateNodeCIDRs: true,
+			hostInterface:     "lxc+",
+		},
+		{
+			networking:        Networking{SelfHosting: SelfHosting{Type: "custom", Custom: CustomNetworkPlugin{AllocateNodeCIDRs: &disabled}}},
+			allocateNodeCIDRs: false,
+			hostInterface:     "",
+		},
+	}
+
+	for i, testCase := range testCases {
+		if actual := testCase.networking.AllocateNodeCIDRs(); actual != testCase.allocateNodeCIDRs {
+			t.Errorf("case %d: expected AllocateNodeCIDRs to be %v but was %v", i, testCase.allocateNodeCIDRs, actual)
+		}
+		if actual := testCase.networking.HostInterface(); actual != testCase.hostInterface {
+			t.Errorf("case %d: expected HostInterface to be \"%s\" but was \"%s\"", i, testCase.hostInterface, actual)
+		}
+	}
+}
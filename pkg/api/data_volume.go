@@ -4,6 +4,7 @@ type DataVolume struct {
 	Size        int    `yaml:"size,omitempty"`
 	Type        string `yaml:"type,omitempty"`
 	IOPS        int    `yaml:"iops,omitempty"`
+	AutoIOPS    bool   `yaml:"autoIOPS,omitempty"`
 	Ephemeral   bool   `yaml:"ephemeral,omitempty"`
 	Encrypted   bool   `yaml:"encrypted,omitempty"`
 	UnknownKeys `yaml:",inline"`
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	networkPolicyTypeIngress = "Ingress"
+	networkPolicyTypeEgress  = "Egress"
+
+	// DefaultNetworkPolicyName is the name of the NetworkPolicy kube-aws deploys to namespaces
+	DefaultNetworkPolicyName = "default-deny-all"
+)
+
+// ClusterDefaults is the set of defaults kube-aws applies to the objects in the cluster
+type ClusterDefaults struct {
+	NetworkPolicy DefaultNetworkPolicy `yaml:"networkPolicy,omitempty"`
+}
+
+// DefaultNetworkPolicy is a NetworkPolicy denying all the traffic to and/or from every pod in a namespace
+// unless allowed by other NetworkPolicies
+type DefaultNetworkPolicy struct {
+	Enabled bool `yaml:"enabled"`
+	// Namespaces are the namespaces the policy is deployed to, created if missing, whenever the cluster is created or updated
+	Namespaces []string `yaml:"namespaces,omitempty"`
+	// NamespaceSelector is a label selector like `network-policy=default-deny`.
+	// When set, a controller keeps the policy deployed to the namespaces matching it, including ones created later
+	NamespaceSelector string `yaml:"namespaceSelector,omitempty"`
+	// PolicyTypes are the directions of the traffic denied by the policy. Defaults to both Ingress and Egress
+	PolicyTypes []string `yaml:"policyTypes,omitempty"`
+}
+
+func (p DefaultNetworkPolicy) Name() string {
+	return DefaultNetworkPolicyName
+}
+
+func (p DefaultNetworkPolicy) PolicyTypesOrDefault() []string {
+	if len(p.PolicyTypes) == 0 {
+		return []string{networkPolicyTypeIngress, networkPolicyTypeEgress}
+	}
+	return p.PolicyTypes
+}
+
+// DenyEgress returns true when the policy denies egress traffic.
+// DNS queries are still allowed so that pods keep resolving names
+func (p DefaultNetworkPolicy) DenyEgress() bool {
+	return containsString(p.PolicyTypesOrDefault(), networkPolicyTypeEgress)
+}
+
+// ControllerEnabled returns true when the policy should be kept deployed to namespaces matching the selector
+func (p DefaultNetworkPolicy) ControllerEnabled() bool {
+	return p.Enabled && p.NamespaceSelector != ""
+}
+
+func (p DefaultNetworkPolicy) Validate(networking Networking) error {
+	if !p.Enabled {
+		return nil
+	}
+
+	if !networking.SupportsNetworkPolicy() {
+		return errors.New("`defaults.networkPolicy` requires a CNI supporting NetworkPolicy. Set `kubernetes.networking.selfHosting.type` to `canal`, or to `custom` with `custom.networkPolicy` for a CNI enforcing it, and disable `kubernetes.networking.amazonVPC`")
+	}
+
+	if len(p.Namespaces) == 0 && p.NamespaceSelector == "" {
+		return errors.New("`defaults.networkPolicy` requires either `namespaces` or `namespaceSelector` to be set")
+	}
+
+	for _, ns := range p.Namespaces {
+		if len(ns) > 63 || !kubernetesObjectNamePattern.MatchString(ns) || strings.Contains(ns, ".") {
+			return fmt.Errorf("invalid namespace \"%s\" in `defaults.networkPolicy.namespaces`: it must be a valid DNS label", ns)
+		}
+	}
+
+	if strings.ContainsAny(p.NamespaceSelector, "'\"$`\\ \t\n") {
+		return fmt.Errorf("invalid `defaults.networkPolicy.namespaceSelector` \"%s\": it must be a label selector like `network-policy=default-deny`", p.NamespaceSelector)
+	}
+
+	seen := map[string]bool{}
+	for _, t := range p.PolicyTypes {
+		if t != networkPolicyTypeIngress && t != networkPolicyTypeEgress {
+			return fmt.Errorf("invalid policy type \"%s\" in `defaults.networkPolicy.policyTypes`: it must be either %s or %s", t, networkPolicyTypeIngress, networkPolicyTypeEgress)
+		}
+		if seen[t] {
+			return fmt.Errorf("duplicated policy type \"%s\" in `defaults.networkPolicy.policyTypes`", t)
+		}
+		seen[t] = true
+	}
+
+	return nil
+}
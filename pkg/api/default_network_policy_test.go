@@ -0,0 +1,107 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestDefaultNetworkPolicyValidate(t *testing.T) {
+	canal := Networking{SelfHosting: SelfHosting{Type: "canal"}}
+
+	testCases := []struct {
+		policy     DefaultNetworkPolicy
+		networking Networking
+		isValid    bool
+	}{
+		// Valid, disabled
+		{
+			policy:     DefaultNetworkPolicy{Namespaces: []string{"default"}},
+			networking: Networking{SelfHosting: SelfHosting{Type: "flannel"}},
+			isValid:    true,
+		},
+		// Valid, namespaces
+		{
+			policy:     DefaultNetworkPolicy{Enabled: true, Namespaces: []string{"default", "team-a"}},
+			networking: canal,
+			isValid:    true,
+		},
+		// Valid, a namespace selector and a policy type
+		{
+			policy:     DefaultNetworkPolicy{Enabled: true, NamespaceSelector: "network-policy=default-deny", PolicyTypes: []string{"Ingress"}},
+			networking: canal,
+			isValid:    true,
+		},
+		// Valid, a custom CNI enforcing NetworkPolicy
+		{
+			policy:     DefaultNetworkPolicy{Enabled: true, Namespaces: []string{"default"}},
+			networking: Networking{SelfHosting: SelfHosting{Type: "custom", Custom: CustomNetworkPlugin{NetworkPolicy: true}}},
+			isValid:    true,
+		},
+		// Invalid, a custom CNI not enforcing NetworkPolicy
+		{
+			policy:     DefaultNetworkPolicy{Enabled: true, Namespaces: []string{"default"}},
+			networking: Networking{SelfHosting: SelfHosting{Type: "custom"}},
+			isValid:    false,
+		},
+		// Invalid, flannel
+		{
+			policy:     DefaultNetworkPolicy{Enabled: true, Namespaces: []string{"default"}},
+			networking: Networking{SelfHosting: SelfHosting{Type: "flannel"}},
+			isValid:    false,
+		},
+		// Invalid, the VPC CNI
+		{
+			policy:     DefaultNetworkPolicy{Enabled: true, Namespaces: []string{"default"}},
+			networking: Networking{AmazonVPC: AmazonVPC{Enabled: true}, SelfHosting: SelfHosting{Type: "canal"}},
+			isValid:    false,
+		},
+		// Invalid, neither namespaces nor a namespace selector
+		{
+			policy:     DefaultNetworkPolicy{Enabled: true},
+			networking: canal,
+			isValid:    false,
+		},
+		// Invalid, namespace
+		{
+			policy:     DefaultNetworkPolicy{Enabled: true, Namespaces: []string{"team.a"}},
+			networking: canal,
+			isValid:    false,
+		},
+		// Invalid, namespace selector
+		{
+			policy:     DefaultNetworkPolicy{Enabled: true, NamespaceSelector: "a=b' ; rm -rf /"},
+			networking: canal,
+			isValid:    false,
+		},
+		// Invalid, policy type
+		{
+			policy:     DefaultNetworkPolicy{Enabled: true, Namespaces: []string{"default"}, PolicyTypes: []string{"ingress"}},
+			networking: canal,
+			isValid:    false,
+		},
+		// Invalid, duplicated policy types
+		{
+			policy:     DefaultNetworkPolicy{Enabled: true, Namespaces: []string{"default"}, PolicyTypes: []string{"Egress", "Egress"}},
+			networking: canal,
+			isValid:    false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.policy.Validate(testCase.networking)
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.policy, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.policy)
+		}
+	}
+}
+
+func TestDefaultNetworkPolicyDenyEgress(t *testing.T) {
+	if !(DefaultNetworkPolicy{}).DenyEgress() {
+		t.Errorf("expected egress to be denied by default")
+	}
+	if (DefaultNetworkPolicy{PolicyTypes: []string{"Ingress"}}).DenyEgress() {
+		t.Errorf("expected egress not to be denied when only Ingress is specified")
+	}
+}
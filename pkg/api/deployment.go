@@ -13,6 +13,12 @@ func (s DeploymentSettings) ValidateNodePool(name string) error {
 	if err := s.Experimental.Validate(name); err != nil {
 		return err
 	}
+	if err := s.InstanceScript.Validate(); err != nil {
+		return fmt.Errorf("invalid node pool \"%s\": %v", name, err)
+	}
+	if err := s.Containerd.ImageGC.Validate(); err != nil {
+		return fmt.Errorf("invalid node pool \"%s\": %v", name, err)
+	}
 	return nil
 }
 
@@ -32,8 +38,9 @@ func (c DeploymentSettings) WithDefaultsFrom(main DeploymentSettings) Deployment
 	if c.ReleaseChannel == "" {
 		c.ReleaseChannel = main.ReleaseChannel
 
-		if c.AmiId == "" {
+		if c.AmiId == "" && c.AmiSsmParameter == "" {
 			c.AmiId = main.AmiId
+			c.AmiSsmParameter = main.AmiSsmParameter
 		}
 	}
 
@@ -55,17 +62,35 @@ func (c DeploymentSettings) WithDefaultsFrom(main DeploymentSettings) Deployment
 		c.SSHAuthorizedKeys = main.SSHAuthorizedKeys
 	}
 
+	if len(c.AdditionalTrustedCAs) == 0 {
+		c.AdditionalTrustedCAs = main.AdditionalTrustedCAs
+	}
+
 	// And assuming that no one wants to differentiate these settings among control plane and node pools, we forbid customization of:
 	c.ManageCertificates = main.ManageCertificates
 	// And believing it is impossible to mix different values, we also forbid customization of:
 	// * Region
-	// * ContainerRuntime
 	// * KMSKeyARN
 	// * ElasticFileSystemID
 	c.Region = main.Region
-	c.ContainerRuntime = main.ContainerRuntime
 	c.KMSKeyARN = main.KMSKeyARN
 
+	// Node pools can store and compress their instance scripts differently from controller nodes
+	if c.InstanceScript.Storage == "" {
+		c.InstanceScript.Storage = main.InstanceScript.Storage
+	}
+	if c.InstanceScript.Compression == "" {
+		c.InstanceScript.Compression = main.InstanceScript.Compression
+	}
+
+	// Node pools can be migrated to a different container runtime one by one
+	if c.ContainerRuntime == "" {
+		c.ContainerRuntime = main.ContainerRuntime
+	}
+	if c.Containerd == (Containerd{}) {
+		c.Containerd = main.Containerd
+	}
+
 	// TODO Allow providing one or more elasticFileSystemId's to be mounted both per-node-pool/cluster-wide
 	// TODO Allow providing elasticFileSystemId to a node pool in managed subnets.
 	// Currently, per-node-pool elasticFileSystemId requires existing subnets configured by users to have appropriate MountTargets associated
@@ -82,6 +107,9 @@ func (c DeploymentSettings) WithDefaultsFrom(main DeploymentSettings) Deployment
 	//Inherit main KubeDns config
 	c.KubeDns.MergeIfEmpty(main.KubeDns)
 
+	// kube-proxy is configured cluster-wide by a single ConfigMap, so node pools tune their conntrack tables with the main settings
+	c.KubeProxy = main.KubeProxy
+
 	//Inherit main Kubernetes config (e.g. for Kubernetes.Networking.SelfHosting etc.)
 	c.Kubernetes = main.Kubernetes
 
@@ -98,18 +126,34 @@ func (c DeploymentSettings) Validate() (*DeploymentValidationResult, error) {
 		return nil, fmt.Errorf("releaseChannel %s is not supported", c.ReleaseChannel)
 	}
 
+	if err := validateAMISource(c.AmiId, c.AmiSsmParameter); err != nil {
+		return nil, err
+	}
+
 	if c.KeyName == "" && len(c.SSHAuthorizedKeys) == 0 {
 		return nil, errors.New("Either keyName or sshAuthorizedKeys must be set")
 	}
 	if c.ClusterName == "" {
 		return nil, errors.New("clusterName must be set")
 	}
+	if err := c.AdditionalTrustedCAs.Validate(); err != nil {
+		return nil, err
+	}
 	if c.S3URI == "" {
 		return nil, errors.New("s3URI must be set")
 	}
+	if err := c.validateAssetPrefix(); err != nil {
+		return nil, err
+	}
 	if c.KMSKeyARN == "" && c.AssetsEncryptionEnabled() {
 		return nil, errors.New("kmsKeyArn must be set")
 	}
+	if err := c.validateAssetReplication(); err != nil {
+		return nil, err
+	}
+	if err := c.AssumeRoles.Validate(); err != nil {
+		return nil, err
+	}
 
 	if c.Region.IsEmpty() {
 		return nil, errors.New("region must be set")
@@ -220,6 +264,19 @@ func (c DeploymentSettings) Validate() (*DeploymentValidationResult, error) {
 		return nil, err
 	}
 
+	// Only the apiservers on controller nodes record audit events
+	if err := c.Experimental.AuditLog.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := c.Experimental.AuditWebhook.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := c.KubeDns.DNSConfig.Validate(); err != nil {
+		return nil, err
+	}
+
 	for i, ngw := range c.NATGateways() {
 		if err := ngw.Validate(); err != nil {
 			return nil, fmt.Errorf("NGW %d is not valid: %v", i, err)
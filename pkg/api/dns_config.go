@@ -0,0 +1,47 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// The resolver in glibc supports up to 6 search domains, 256 characters in total
+	maxDNSSearches        = 6
+	maxDNSSearchListChars = 256
+	// kubelet prepends `<namespace>.svc.cluster.local`, `svc.cluster.local` and `cluster.local` to the search list of every pod
+	numClusterDomainSearches = 3
+)
+
+// DNSConfig is the cluster-wide default DNS configuration for pods.
+// The search domains and the options are added to the resolv.conf passed to kubelet via `--resolv-conf`
+// so that pods can resolve names with them in addition to the host's settings
+type DNSConfig struct {
+	Searches []string `yaml:"searches,omitempty"`
+	Options  []string `yaml:"options,omitempty"`
+}
+
+func (c DNSConfig) IsEmpty() bool {
+	return len(c.Searches) == 0 && len(c.Options) == 0
+}
+
+func (c DNSConfig) Validate() error {
+	if len(c.Searches) > maxDNSSearches-numClusterDomainSearches {
+		return fmt.Errorf("`kubeDns.dnsConfig.searches` can contain at most %d domains as kubelet adds %d cluster domains and the resolver supports up to %d, but was %d: %v",
+			maxDNSSearches-numClusterDomainSearches, numClusterDomainSearches, maxDNSSearches, len(c.Searches), c.Searches)
+	}
+	for i, s := range c.Searches {
+		if s == "" || strings.ContainsAny(s, " \t") {
+			return fmt.Errorf("`kubeDns.dnsConfig.searches[%d]` must be a non-empty domain without whitespaces, but was \"%s\"", i, s)
+		}
+	}
+	if l := len(strings.Join(c.Searches, " ")); l > maxDNSSearchListChars {
+		return fmt.Errorf("`kubeDns.dnsConfig.searches` must be at most %d characters long in total, but was %d", maxDNSSearchListChars, l)
+	}
+	for i, o := range c.Options {
+		if o == "" || strings.ContainsAny(o, " \t") {
+			return fmt.Errorf("`kubeDns.dnsConfig.options[%d]` must be a non-empty option like `ndots:2` without whitespaces, but was \"%s\"", i, o)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDNSConfigValidate(t *testing.T) {
+	testCases := []struct {
+		config  DNSConfig
+		isValid bool
+	}{
+		// Valid, empty
+		{
+			config:  DNSConfig{},
+			isValid: true,
+		},
+		// Valid, up to 3 search domains
+		{
+			config: DNSConfig{
+				Searches: []string{"a.example.com", "b.example.com", "c.example.com"},
+				Options:  []string{"ndots:2", "rotate"},
+			},
+			isValid: true,
+		},
+		// Invalid, more than 3 search domains
+		{
+			config: DNSConfig{
+				Searches: []string{"a.example.com", "b.example.com", "c.example.com", "d.example.com"},
+			},
+			isValid: false,
+		},
+		// Invalid, search domain contains whitespace
+		{
+			config: DNSConfig{
+				Searches: []string{"a.example.com b.example.com"},
+			},
+			isValid: false,
+		},
+		// Invalid, empty search domain
+		{
+			config: DNSConfig{
+				Searches: []string{""},
+			},
+			isValid: false,
+		},
+		// Invalid, search list too long
+		{
+			config: DNSConfig{
+				Searches: []string{strings.Repeat("a", 200) + ".example.com", strings.Repeat("b", 50) + ".example.com"},
+			},
+			isValid: false,
+		},
+		// Invalid, option contains whitespace
+		{
+			config: DNSConfig{
+				Options: []string{"ndots: 2"},
+			},
+			isValid: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		err := testCase.config.Validate()
+		if testCase.isValid && err != nil {
+			t.Errorf("Expected %+v to be valid, but got error: %v", testCase.config, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("Expected %+v to be invalid, but it was valid", testCase.config)
+		}
+	}
+}
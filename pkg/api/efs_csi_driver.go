@@ -0,0 +1,127 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+const (
+	DefaultEFSStorageClassName           = "efs"
+	DefaultEFSStorageClassDirectoryPerms = "700"
+)
+
+var (
+	efsFileSystemIDPattern        = regexp.MustCompile(`^fs-[0-9a-f]{8,40}$`)
+	efsDirectoryPermsPattern      = regexp.MustCompile(`^[0-7]{3,4}$`)
+	efsAccessPointBasePathPattern = regexp.MustCompile(`^(/[^/\s]+)+$`)
+)
+
+// EFSCSIDriver is the set of settings for the Amazon EFS CSI driver which provides ReadWriteMany persistent volumes backed by EFS
+// See https://github.com/kubernetes-sigs/aws-efs-csi-driver
+type EFSCSIDriver struct {
+	Enabled bool `yaml:"enabled"`
+	// IAMRole is the IAM role assumed by the controller of the driver via kube2iam or kiam
+	IAMRole IAMRole `yaml:"iamRole,omitempty"`
+	// UseControllerNodeRole runs the controller of the driver on controller nodes and grants the IAM role of controller nodes the permissions
+	// required by the driver, instead of assuming `iamRole`
+	UseControllerNodeRole bool `yaml:"useControllerNodeRole,omitempty"`
+	// StorageClass is the StorageClass dynamically provisioning a volume per EFS access point of the filesystem
+	StorageClass EFSStorageClass `yaml:"storageClass,omitempty"`
+	UnknownKeys  `yaml:",inline"`
+}
+
+// EFSStorageClass is a StorageClass provisioning volumes as access points of an existing EFS filesystem
+type EFSStorageClass struct {
+	Enabled bool `yaml:"enabled"`
+	// Name is the name of the StorageClass. Defaults to `efs`
+	Name string `yaml:"name,omitempty"`
+	// Default marks the StorageClass as the default one of the cluster
+	Default bool `yaml:"default,omitempty"`
+	// FileSystemID is the ID of the EFS filesystem like `fs-0123456789abcdef0`
+	FileSystemID string `yaml:"fileSystemId,omitempty"`
+	// DirectoryPerms is the permissions of the root directory of each access point. Defaults to `700`
+	DirectoryPerms string `yaml:"directoryPerms,omitempty"`
+	// GidRangeStart and GidRangeEnd is the range of the POSIX group IDs assigned to access points. Defaults to the driver's default
+	GidRangeStart int `yaml:"gidRangeStart,omitempty"`
+	GidRangeEnd   int `yaml:"gidRangeEnd,omitempty"`
+	// BasePath is the path on the filesystem under which the root directories of access points are created
+	BasePath    string `yaml:"basePath,omitempty"`
+	UnknownKeys `yaml:",inline"`
+}
+
+func (c EFSStorageClass) NameOrDefault() string {
+	if c.Name == "" {
+		return DefaultEFSStorageClassName
+	}
+	return c.Name
+}
+
+func (c EFSStorageClass) DirectoryPermsOrDefault() string {
+	if c.DirectoryPerms == "" {
+		return DefaultEFSStorageClassDirectoryPerms
+	}
+	return c.DirectoryPerms
+}
+
+func (c EFSCSIDriver) Validate(k8sVer string, experimental Experimental) error {
+	if !c.Enabled {
+		if c.StorageClass.Enabled {
+			return errors.New("`addons.efsCsiDriver.storageClass` can't be enabled unless `addons.efsCsiDriver.enabled` is true")
+		}
+		return nil
+	}
+
+	supported, err := k8sVersionSatisfies(">= 1.18", k8sVer)
+	if err != nil {
+		return err
+	}
+	if !supported {
+		return fmt.Errorf("`addons.efsCsiDriver` requires kubernetesVersion 1.18 or greater, but was %s", k8sVer)
+	}
+
+	if c.IAMRole.Arn == "" && !c.UseControllerNodeRole {
+		return errors.New("`addons.efsCsiDriver` requires an IAM role for the driver: specify either `iamRole.arn` assumed via kube2iam or kiam, or `useControllerNodeRole: true`")
+	}
+	if c.IAMRole.Arn != "" && c.UseControllerNodeRole {
+		return errors.New("`addons.efsCsiDriver.iamRole.arn` and `addons.efsCsiDriver.useControllerNodeRole` are mutually exclusive")
+	}
+	if c.IAMRole.Arn != "" && !experimental.Kube2IamSupport.Enabled && !experimental.KIAMSupport.Enabled {
+		return errors.New("`addons.efsCsiDriver.iamRole.arn` requires either `kube2IamSupport` or `kiamSupport` to be enabled")
+	}
+	if c.IAMRole.Arn != "" && !iamRoleARNRegexp.MatchString(c.IAMRole.Arn) {
+		return fmt.Errorf("invalid `addons.efsCsiDriver.iamRole.arn` \"%s\": it must be an IAM role ARN like arn:aws:iam::123456789012:role/name", c.IAMRole.Arn)
+	}
+
+	return c.StorageClass.Validate()
+}
+
+func (c EFSStorageClass) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.FileSystemID == "" {
+		return errors.New("`addons.efsCsiDriver.storageClass.fileSystemId` must be specified when the storage class is enabled")
+	}
+	if !efsFileSystemIDPattern.MatchString(c.FileSystemID) {
+		return fmt.Errorf("invalid `addons.efsCsiDriver.storageClass.fileSystemId` \"%s\": it must be an EFS filesystem ID like fs-0123456789abcdef0", c.FileSystemID)
+	}
+	if n := c.NameOrDefault(); len(n) > 253 || !kubernetesObjectNamePattern.MatchString(n) {
+		return fmt.Errorf("invalid `addons.efsCsiDriver.storageClass.name` \"%s\": it must be a valid DNS subdomain name", n)
+	}
+	if c.DirectoryPerms != "" && !efsDirectoryPermsPattern.MatchString(c.DirectoryPerms) {
+		return fmt.Errorf("invalid `addons.efsCsiDriver.storageClass.directoryPerms` \"%s\": it must be an octal permission like 700", c.DirectoryPerms)
+	}
+	if (c.GidRangeStart == 0) != (c.GidRangeEnd == 0) {
+		return errors.New("`addons.efsCsiDriver.storageClass.gidRangeStart` and `addons.efsCsiDriver.storageClass.gidRangeEnd` must be specified together")
+	}
+	if c.GidRangeStart < 0 || c.GidRangeStart > c.GidRangeEnd {
+		return fmt.Errorf("`addons.efsCsiDriver.storageClass.gidRangeStart` (%d) must be zero or greater and less than or equal to `addons.efsCsiDriver.storageClass.gidRangeEnd` (%d)", c.GidRangeStart, c.GidRangeEnd)
+	}
+	if c.BasePath != "" && !efsAccessPointBasePathPattern.MatchString(c.BasePath) {
+		return fmt.Errorf("invalid `addons.efsCsiDriver.storageClass.basePath` \"%s\": it must be an absolute path like /dynamic_provisioning", c.BasePath)
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestEFSCSIDriverValidate(t *testing.T) {
+	roleARN := "arn:aws:iam::123456789012:role/efs-csi-driver"
+	kube2iam := Experimental{Kube2IamSupport: Kube2IamSupport{Enabled: true}}
+	storageClass := EFSStorageClass{Enabled: true, FileSystemID: "fs-0123456789abcdef0"}
+
+	testCases := []struct {
+		driver       EFSCSIDriver
+		k8sVer       string
+		experimental Experimental
+		isValid      bool
+	}{
+		// Valid, disabled
+		{
+			driver:  EFSCSIDriver{},
+			k8sVer:  "v1.11.3",
+			isValid: true,
+		},
+		// Valid, the controller node role without a storage class
+		{
+			driver:  EFSCSIDriver{Enabled: true, UseControllerNodeRole: true},
+			k8sVer:  "v1.18.8",
+			isValid: true,
+		},
+		// Valid, the role assumed via kube2iam with a storage class
+		{
+			driver: EFSCSIDriver{
+				Enabled: true,
+				IAMRole: IAMRole{ARN: ARN{Arn: roleARN}},
+				StorageClass: EFSStorageClass{
+					Enabled:        true,
+					Name:           "efs-shared",
+					Default:        true,
+					FileSystemID:   "fs-0123abcd",
+					DirectoryPerms: "0750",
+					GidRangeStart:  1000,
+					GidRangeEnd:    2000,
+					BasePath:       "/dynamic_provisioning",
+				},
+			},
+			k8sVer:       "v1.20.2",
+			experimental: kube2iam,
+			isValid:      true,
+		},
+		// Invalid, unsupported kubernetes version
+		{
+			driver:  EFSCSIDriver{Enabled: true, UseControllerNodeRole: true},
+			k8sVer:  "v1.17.1",
+			isValid: false,
+		},
+		// Invalid, no IAM role
+		{
+			driver:  EFSCSIDriver{Enabled: true, StorageClass: storageClass},
+			k8sVer:  "v1.20.2",
+			isValid: false,
+		},
+		// Invalid, both the role and the controller node role
+		{
+			driver:       EFSCSIDriver{Enabled: true, IAMRole: IAMRole{ARN: ARN{Arn: roleARN}}, UseControllerNodeRole: true},
+			k8sVer:       "v1.20.2",
+			experimental: kube2iam,
+			isValid:      false,
+		},
+		// Invalid, the role without kube2iam or kiam
+		{
+			driver:  EFSCSIDriver{Enabled: true, IAMRole: IAMRole{ARN: ARN{Arn: roleARN}}},
+			k8sVer:  "v1.20.2",
+			isValid: false,
+		},
+		// Invalid, the storage class without the driver
+		{
+			driver:  EFSCSIDriver{StorageClass: storageClass},
+			k8sVer:  "v1.20.2",
+			isValid: false,
+		},
+		// Invalid, the storage class without a filesystem ID
+		{
+			driver:  EFSCSIDriver{Enabled: true, UseControllerNodeRole: true, StorageClass: EFSStorageClass{Enabled: true}},
+			k8sVer:  "v1.20.2",
+			isValid: false,
+		},
+		// Invalid, malformed filesystem ID
+		{
+			driver:  EFSCSIDriver{Enabled: true, UseControllerNodeRole: true, StorageClass: EFSStorageClass{Enabled: true, FileSystemID: "fsap-0123456789abcdef0"}},
+			k8sVer:  "v1.20.2",
+			isValid: false,
+		},
+		// Invalid, directory permissions
+		{
+			driver:  EFSCSIDriver{Enabled: true, UseControllerNodeRole: true, StorageClass: EFSStorageClass{Enabled: true, FileSystemID: "fs-0123abcd", DirectoryPerms: "rwx"}},
+			k8sVer:  "v1.20.2",
+			isValid: false,
+		},
+		// Invalid, only the start of the gid range
+		{
+			driver:  EFSCSIDriver{Enabled: true, UseControllerNodeRole: true, StorageClass: EFSStorageClass{Enabled: true, FileSystemID: "fs-0123abcd", GidRangeStart: 1000}},
+			k8sVer:  "v1.20.2",
+			isValid: false,
+		},
+		// Invalid, reversed gid range
+		{
+			driver:  EFSCSIDriver{Enabled: true, UseControllerNodeRole: true, StorageClass: EFSStorageClass{Enabled: true, FileSystemID: "fs-0123abcd", GidRangeStart: 2000, GidRangeEnd: 1000}},
+			k8sVer:  "v1.20.2",
+			isValid: false,
+		},
+		// Invalid, relative base path
+		{
+			driver:  EFSCSIDriver{Enabled: true, UseControllerNodeRole: true, StorageClass: EFSStorageClass{Enabled: true, FileSystemID: "fs-0123abcd", BasePath: "dynamic"}},
+			k8sVer:  "v1.20.2",
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.driver.Validate(testCase.k8sVer, testCase.experimental)
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid for kubernetes %s but got an error: %v", i, testCase.driver, testCase.k8sVer, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid for kubernetes %s but was not", i, testCase.driver, testCase.k8sVer)
+		}
+	}
+}
+
+func TestEFSStorageClassDefaults(t *testing.T) {
+	c := EFSStorageClass{}
+	if n := c.NameOrDefault(); n != "efs" {
+		t.Errorf("expected the default name of the storage class to be efs but was %s", n)
+	}
+	if p := c.DirectoryPermsOrDefault(); p != "700" {
+		t.Errorf("expected the default directory permissions to be 700 but was %s", p)
+	}
+}
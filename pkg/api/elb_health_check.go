@@ -0,0 +1,134 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultELBHealthCheckTarget             = "SSL:443"
+	DefaultELBHealthCheckInterval           = 10
+	DefaultELBHealthCheckTimeout            = 8
+	DefaultELBHealthCheckHealthyThreshold   = 3
+	DefaultELBHealthCheckUnhealthyThreshold = 3
+)
+
+// ELBHealthCheck is the health check of a classic ELB serving an API endpoint
+// See https://docs.aws.amazon.com/elasticloadbalancing/2012-06-01/APIReference/API_HealthCheck.html for the limits
+type ELBHealthCheck struct {
+	// Target is the instance pinged by the ELB, in the form of `PROTOCOL:PORT[/PATH]` like `HTTPS:443/healthz`. Defaults to `SSL:443`
+	Target string `yaml:"target,omitempty"`
+	// Interval is the approximate interval between health checks in seconds. Defaults to 10
+	Interval int `yaml:"interval,omitempty"`
+	// Timeout is the amount of time in seconds during which no response means a failed health check. Defaults to 8
+	Timeout int `yaml:"timeout,omitempty"`
+	// HealthyThreshold is the number of consecutive successful health checks required before moving an instance to the healthy state. Defaults to 3
+	HealthyThreshold int `yaml:"healthyThreshold,omitempty"`
+	// UnhealthyThreshold is the number of consecutive failed health checks required before moving an instance to the unhealthy state. Defaults to 3
+	UnhealthyThreshold int `yaml:"unhealthyThreshold,omitempty"`
+}
+
+// IsEmpty returns true when none of the health check settings are customized
+func (c ELBHealthCheck) IsEmpty() bool {
+	return c == ELBHealthCheck{}
+}
+
+func (c ELBHealthCheck) TargetOrDefault() string {
+	if c.Target == "" {
+		return DefaultELBHealthCheckTarget
+	}
+	return c.Target
+}
+
+func (c ELBHealthCheck) IntervalOrDefault() int {
+	if c.Interval == 0 {
+		return DefaultELBHealthCheckInterval
+	}
+	return c.Interval
+}
+
+func (c ELBHealthCheck) TimeoutOrDefault() int {
+	if c.Timeout == 0 {
+		return DefaultELBHealthCheckTimeout
+	}
+	return c.Timeout
+}
+
+func (c ELBHealthCheck) HealthyThresholdOrDefault() int {
+	if c.HealthyThreshold == 0 {
+		return DefaultELBHealthCheckHealthyThreshold
+	}
+	return c.HealthyThreshold
+}
+
+func (c ELBHealthCheck) UnhealthyThresholdOrDefault() int {
+	if c.UnhealthyThreshold == 0 {
+		return DefaultELBHealthCheckUnhealthyThreshold
+	}
+	return c.UnhealthyThreshold
+}
+
+func (c ELBHealthCheck) Validate() error {
+	if err := validateELBHealthCheckTarget(c.TargetOrDefault()); err != nil {
+		return err
+	}
+
+	interval := c.IntervalOrDefault()
+	if interval < 5 || interval > 300 {
+		return fmt.Errorf("invalid `healthCheck.interval` %d: it must be between 5 and 300 seconds", interval)
+	}
+	timeout := c.TimeoutOrDefault()
+	if timeout < 2 || timeout > 60 {
+		return fmt.Errorf("invalid `healthCheck.timeout` %d: it must be between 2 and 60 seconds", timeout)
+	}
+	if timeout >= interval {
+		return fmt.Errorf("`healthCheck.timeout` %d must be less than `healthCheck.interval` %d", timeout, interval)
+	}
+	if threshold := c.HealthyThresholdOrDefault(); threshold < 2 || threshold > 10 {
+		return fmt.Errorf("invalid `healthCheck.healthyThreshold` %d: it must be between 2 and 10", threshold)
+	}
+	if threshold := c.UnhealthyThresholdOrDefault(); threshold < 2 || threshold > 10 {
+		return fmt.Errorf("invalid `healthCheck.unhealthyThreshold` %d: it must be between 2 and 10", threshold)
+	}
+
+	return nil
+}
+
+func validateELBHealthCheckTarget(target string) error {
+	invalid := func(reason string) error {
+		return fmt.Errorf("invalid `healthCheck.target` \"%s\": %s", target, reason)
+	}
+
+	protocolAndRest := strings.SplitN(target, ":", 2)
+	if len(protocolAndRest) != 2 {
+		return invalid("it must be in the form of PROTOCOL:PORT[/PATH]")
+	}
+	protocol, rest := protocolAndRest[0], protocolAndRest[1]
+
+	port, path := rest, ""
+	if i := strings.Index(rest, "/"); i >= 0 {
+		port, path = rest[:i], rest[i:]
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return invalid("the port must be between 1 and 65535")
+	}
+
+	switch protocol {
+	case "TCP", "SSL":
+		if path != "" {
+			return invalid(fmt.Sprintf("a path can't be specified for the %s protocol", protocol))
+		}
+	case "HTTP", "HTTPS":
+		if path == "" {
+			return invalid(fmt.Sprintf("a path is required for the %s protocol", protocol))
+		}
+		if len(path) > 1024 {
+			return invalid("the path must be 1024 characters or less")
+		}
+	default:
+		return invalid("the protocol must be one of TCP, SSL, HTTP and HTTPS")
+	}
+
+	return nil
+}
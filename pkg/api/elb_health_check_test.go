@@ -0,0 +1,88 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestELBHealthCheckValidate(t *testing.T) {
+	testCases := []struct {
+		healthCheck ELBHealthCheck
+		isValid     bool
+	}{
+		// Valid, the defaults
+		{
+			healthCheck: ELBHealthCheck{},
+			isValid:     true,
+		},
+		// Valid, pinging the apiserver's healthz endpoint
+		{
+			healthCheck: ELBHealthCheck{Target: "HTTPS:443/healthz", Interval: 30, Timeout: 5, HealthyThreshold: 2, UnhealthyThreshold: 10},
+			isValid:     true,
+		},
+		// Valid, TCP
+		{
+			healthCheck: ELBHealthCheck{Target: "TCP:443"},
+			isValid:     true,
+		},
+		// Invalid, missing port
+		{
+			healthCheck: ELBHealthCheck{Target: "SSL"},
+			isValid:     false,
+		},
+		// Invalid, unsupported protocol
+		{
+			healthCheck: ELBHealthCheck{Target: "UDP:443"},
+			isValid:     false,
+		},
+		// Invalid, port out of range
+		{
+			healthCheck: ELBHealthCheck{Target: "TCP:0"},
+			isValid:     false,
+		},
+		// Invalid, path for SSL
+		{
+			healthCheck: ELBHealthCheck{Target: "SSL:443/healthz"},
+			isValid:     false,
+		},
+		// Invalid, missing path for HTTPS
+		{
+			healthCheck: ELBHealthCheck{Target: "HTTPS:443"},
+			isValid:     false,
+		},
+		// Invalid, interval too short
+		{
+			healthCheck: ELBHealthCheck{Interval: 4, Timeout: 2},
+			isValid:     false,
+		},
+		// Invalid, timeout too long
+		{
+			healthCheck: ELBHealthCheck{Interval: 300, Timeout: 61},
+			isValid:     false,
+		},
+		// Invalid, timeout not less than interval
+		{
+			healthCheck: ELBHealthCheck{Interval: 10, Timeout: 10},
+			isValid:     false,
+		},
+		// Invalid, healthy threshold too small
+		{
+			healthCheck: ELBHealthCheck{HealthyThreshold: 1},
+			isValid:     false,
+		},
+		// Invalid, unhealthy threshold too large
+		{
+			healthCheck: ELBHealthCheck{UnhealthyThreshold: 11},
+			isValid:     false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.healthCheck.Validate()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.healthCheck, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.healthCheck)
+		}
+	}
+}
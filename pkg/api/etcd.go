@@ -3,25 +3,54 @@ package api
 import (
 	"errors"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/kubernetes-incubator/kube-aws/logger"
 )
 
 const (
 	MaxQuotaBackendBytes     int = 8 * 1024 * 1024 * 1024
 	DefaultQuotaBackendBytes int = 2 * 1024 * 1024 * 1024
+
+	DefaultEtcdDataDir = "/var/lib/etcd2"
+
+	// MaxEtcdRequestBytes is the upper bound of `etcd.maxRequestBytes` recommended by etcd
+	MaxEtcdRequestBytes int = 10 * 1024 * 1024
+	// apiServerMaxRequestBodyBytes is the fixed limit of the size of write requests the apiserver accepts
+	apiServerMaxRequestBodyBytes int = 3 * 1024 * 1024
+)
+
+var (
+	etcdDataDirPattern           = regexp.MustCompile("^(/[a-zA-Z0-9]+)+$")
+	etcdVolumeMountOptionPattern = regexp.MustCompile("^[a-zA-Z0-9_.:=-]+$")
 )
 
 type Etcd struct {
-	Cluster            EtcdCluster          `yaml:",inline"`
-	CustomFiles        []CustomFile         `yaml:"customFiles,omitempty"`
-	CustomSystemdUnits []CustomSystemdUnit  `yaml:"customSystemdUnits,omitempty"`
-	DataVolume         DataVolume           `yaml:"dataVolume,omitempty"`
+	Cluster            EtcdCluster         `yaml:",inline"`
+	CustomFiles        []CustomFile        `yaml:"customFiles,omitempty"`
+	CustomSystemdUnits []CustomSystemdUnit `yaml:"customSystemdUnits,omitempty"`
+	DataVolume         DataVolume          `yaml:"dataVolume,omitempty"`
+	// DataDir is the directory the data volume is mounted to and etcd stores its data in. Defaults to /var/lib/etcd2
+	DataDir string `yaml:"dataDir,omitempty"`
+	// MaxRequestBytes is the maximum size of a client request etcd accepts, passed to `--max-request-bytes`.
+	// Defaults to etcd's default, which is 1.5 MiB
+	MaxRequestBytes int `yaml:"maxRequestBytes,omitempty"`
+	// GRPC is the keepalive and the stream settings of the gRPC connections from etcd clients like the apiserver
+	GRPC EtcdGRPC `yaml:"grpc,omitempty"`
+	// Resources are the cpu and memory etcd is allowed to consume, enforced via the resource controls of the systemd unit running etcd.
+	// `requests.cpu` is translated to `CPUShares`, `limits.cpu` to `CPUQuota` and `limits.memory` to `MemoryLimit`
+	Resources ComputeResources `yaml:"resources,omitempty"`
+	// VolumeMountOptions are the mount options like `noatime` of the data volume
+	VolumeMountOptions []string             `yaml:"volumeMountOptions,omitempty"`
 	DisasterRecovery   EtcdDisasterRecovery `yaml:"disasterRecovery,omitempty"`
 	VolumeMounts       []NodeVolumeMount    `yaml:"volumeMounts,omitempty"`
 	EC2Instance        `yaml:",inline"`
 	UserSuppliedArgs   UserSuppliedArgs `yaml:"userSuppliedArgs,omitempty"`
 	IAMConfig          IAMConfig        `yaml:"iam,omitempty"`
+	Metrics            EtcdMetrics      `yaml:"metrics,omitempty"`
 	Nodes              []EtcdNode       `yaml:"nodes,omitempty"`
 	SecurityGroupIds   []string         `yaml:"securityGroupIds"`
 	Snapshot           EtcdSnapshot     `yaml:"snapshot,omitempty"`
@@ -34,6 +63,45 @@ type EtcdVersion string
 
 type EtcdDisasterRecovery struct {
 	Automated bool `yaml:"automated,omitempty"`
+	// MemberFailurePeriodLimit is how long in seconds a member must keep failing while the cluster is healthy before etcdadm
+	// removes it and then re-adds it as a brand-new member with empty data. Defaults to etcdadm's default, 10,
+	// or to defaultMemberFailurePeriodLimitForStartFailures when `replaceMembersFailingToStart` is enabled
+	MemberFailurePeriodLimit int `yaml:"memberFailurePeriodLimit,omitempty"`
+	// ReplaceMembersFailingToStart makes etcdadm count a member which keeps failing to start as failing too, so that a member
+	// which has never become healthy, like the one on an instance replacing a lost one with a broken data volume, is replaced as well.
+	// Otherwise etcdadm replaces only members which have become unhealthy after starting
+	ReplaceMembersFailingToStart bool `yaml:"replaceMembersFailingToStart,omitempty"`
+}
+
+// The bounds of `etcd.disasterRecovery.memberFailurePeriodLimit` in seconds when `replaceMembersFailingToStart` is enabled.
+// The limit must outlast the first health check run 120 seconds after boot, or members just taking time to start would be replaced
+const (
+	defaultMemberFailurePeriodLimitForStartFailures = 600
+	minMemberFailurePeriodLimitForStartFailures     = 180
+)
+
+// MemberFailurePeriodLimitOrDefault returns the failure period limit passed to etcdadm, or 0 to leave it to etcdadm's default
+func (r EtcdDisasterRecovery) MemberFailurePeriodLimitOrDefault() int {
+	if r.MemberFailurePeriodLimit == 0 && r.ReplaceMembersFailingToStart {
+		return defaultMemberFailurePeriodLimitForStartFailures
+	}
+	return r.MemberFailurePeriodLimit
+}
+
+func (r EtcdDisasterRecovery) Validate() error {
+	if r.MemberFailurePeriodLimit < 0 {
+		return fmt.Errorf("`etcd.disasterRecovery.memberFailurePeriodLimit` must be a positive number of seconds, but was %d", r.MemberFailurePeriodLimit)
+	}
+	if r.ReplaceMembersFailingToStart {
+		if !r.Automated {
+			return errors.New("`etcd.disasterRecovery.replaceMembersFailingToStart` requires `etcd.disasterRecovery.automated` to be true")
+		}
+		if limit := r.MemberFailurePeriodLimitOrDefault(); limit < minMemberFailurePeriodLimitForStartFailures {
+			return fmt.Errorf("`etcd.disasterRecovery.memberFailurePeriodLimit` must be %d or greater with `replaceMembersFailingToStart`, but was %d",
+				minMemberFailurePeriodLimitForStartFailures, limit)
+		}
+	}
+	return nil
 }
 
 type UserSuppliedArgs struct {
@@ -50,8 +118,58 @@ func (r EtcdDisasterRecovery) IsAutomatedForEtcdVersion(etcdVersion EtcdVersion)
 	return etcdVersion.Is3() && r.Automated
 }
 
+const (
+	defaultEtcdSnapshotInterval = time.Minute
+	minEtcdSnapshotInterval     = 30 * time.Second
+)
+
 type EtcdSnapshot struct {
 	Automated bool `yaml:"automated,omitempty"`
+	// Interval is how often the leader takes a snapshot and uploads it to S3 like `1h`. Defaults to `1m`
+	Interval string `yaml:"interval,omitempty"`
+	// RetentionInDays is how long past snapshots are kept in addition to the latest one. Every snapshot is archived under
+	// the `history/` prefix of the bucket created in the etcd stack, whose lifecycle rule expires the ones older than this.
+	// Defaults to 0, which keeps only the latest snapshot without creating the bucket
+	RetentionInDays int `yaml:"retentionInDays,omitempty"`
+}
+
+// HistoryEnabled returns true when past snapshots are archived to the bucket created in the etcd stack
+func (s EtcdSnapshot) HistoryEnabled() bool {
+	return s.Automated && s.RetentionInDays > 0
+}
+
+func (s EtcdSnapshot) IntervalOrDefault() time.Duration {
+	if d, err := time.ParseDuration(s.Interval); err == nil && s.Interval != "" {
+		return d
+	}
+	return defaultEtcdSnapshotInterval
+}
+
+// IntervalSeconds returns the interval in seconds for the systemd timer taking snapshots
+func (s EtcdSnapshot) IntervalSeconds() int {
+	return int(s.IntervalOrDefault() / time.Second)
+}
+
+func (s EtcdSnapshot) Validate() error {
+	if !s.Automated {
+		if s.Interval != "" || s.RetentionInDays != 0 {
+			return errors.New("`etcd.snapshot.interval` and `etcd.snapshot.retentionInDays` require `etcd.snapshot.automated` to be true")
+		}
+		return nil
+	}
+	if s.Interval != "" {
+		d, err := time.ParseDuration(s.Interval)
+		if err != nil {
+			return fmt.Errorf("invalid `etcd.snapshot.interval` \"%s\": %v", s.Interval, err)
+		}
+		if d < minEtcdSnapshotInterval {
+			return fmt.Errorf("`etcd.snapshot.interval` must be %s or longer, but was \"%s\"", minEtcdSnapshotInterval, s.Interval)
+		}
+	}
+	if s.RetentionInDays < 0 {
+		return fmt.Errorf("`etcd.snapshot.retentionInDays` must be a positive number of days, but was %d", s.RetentionInDays)
+	}
+	return nil
 }
 
 func (s EtcdSnapshot) IsAutomatedForEtcdVersion(etcdVersion EtcdVersion) bool {
@@ -167,6 +285,43 @@ func (e Etcd) SystemdUnitName() string {
 	return "etcd2.service"
 }
 
+// DataDirOrDefault returns the directory etcd stores its data in
+func (e Etcd) DataDirOrDefault() string {
+	if e.DataDir == "" {
+		return DefaultEtcdDataDir
+	}
+	return e.DataDir
+}
+
+// DataVolumeSystemdMountName returns the name of the systemd mount unit, without the `.mount` suffix, mounting the data volume to the data dir
+func (e Etcd) DataVolumeSystemdMountName() string {
+	return strings.Replace(strings.TrimLeft(e.DataDirOrDefault(), "/"), "/", "-", -1)
+}
+
+// DataVolumeMountOptionsString returns the mount options of the data volume in the form of systemd mount unit's `Options=`
+func (e Etcd) DataVolumeMountOptionsString() string {
+	return strings.Join(e.VolumeMountOptions, ",")
+}
+
+func (e Etcd) validateDataVolumeMount() error {
+	if e.DataDir != "" {
+		if !etcdDataDirPattern.MatchString(e.DataDir) {
+			return fmt.Errorf("invalid `etcd.dataDir` \"%s\": it must be an absolute path consisting of alphanumeric directory names like /var/lib/etcd", e.DataDir)
+		}
+		for _, v := range e.VolumeMounts {
+			if v.Path == e.DataDir {
+				return fmt.Errorf("`etcd.dataDir` \"%s\" conflicts with the path of the volume mount %+v", e.DataDir, v)
+			}
+		}
+	}
+	for _, o := range e.VolumeMountOptions {
+		if !etcdVolumeMountOptionPattern.MatchString(o) {
+			return fmt.Errorf("invalid mount option \"%s\" in `etcd.volumeMountOptions`", o)
+		}
+	}
+	return nil
+}
+
 func ValidateQuotaBackendBytes(bytes int) error {
 	if bytes > MaxQuotaBackendBytes {
 		return fmt.Errorf("quotaBackendBytes: %v is higher than the maximum allowed value 8,589,934,592", bytes)
@@ -174,15 +329,62 @@ func ValidateQuotaBackendBytes(bytes int) error {
 	return nil
 }
 
+func (e Etcd) validateMaxRequestBytes() error {
+	if e.MaxRequestBytes == 0 {
+		return nil
+	}
+	if e.MaxRequestBytes < 0 || e.MaxRequestBytes > MaxEtcdRequestBytes {
+		return fmt.Errorf("`etcd.maxRequestBytes` must be between 1 and %d, but was %d", MaxEtcdRequestBytes, e.MaxRequestBytes)
+	}
+	if !e.Version().Is3() {
+		return fmt.Errorf("`etcd.maxRequestBytes` requires etcd 3, but the etcd version was %s", e.Version())
+	}
+	if e.MaxRequestBytes > apiServerMaxRequestBodyBytes {
+		logger.Warnf("`etcd.maxRequestBytes` %d is larger than %d bytes, which is the size of write requests the apiserver accepts at most. "+
+			"Objects written via the apiserver can't be larger than that", e.MaxRequestBytes, apiServerMaxRequestBodyBytes)
+	}
+	return nil
+}
+
 func (e Etcd) Validate() error {
 	if err := ValidateVolumeMounts(e.VolumeMounts); err != nil {
 		return err
 	}
 
+	if err := e.validateDataVolumeMount(); err != nil {
+		return err
+	}
+
 	if err := ValidateQuotaBackendBytes(e.UserSuppliedArgs.QuotaBackendBytes); err != nil {
 		return err
 	}
 
+	if err := e.validateMaxRequestBytes(); err != nil {
+		return err
+	}
+
+	if err := e.Metrics.Validate(e.Version()); err != nil {
+		return err
+	}
+
+	if err := e.GRPC.Validate(e.Version()); err != nil {
+		return err
+	}
+
+	if err := e.DisasterRecovery.Validate(); err != nil {
+		return err
+	}
+
+	if err := e.Snapshot.Validate(); err != nil {
+		return err
+	}
+
+	if err := e.validateResources(); err != nil {
+		return err
+	}
+
+	e.warnInsufficientDataVolumeIOPS()
+
 	return nil
 }
 
@@ -197,6 +399,12 @@ func (e Etcd) FormatOpts() string {
 		compactFlag := []string{"--auto-compaction-retention", strconv.Itoa(e.UserSuppliedArgs.AutoCompactionRetention)}
 		opts = append(opts, strings.Join(compactFlag, "="))
 	}
+
+	if e.MaxRequestBytes != 0 {
+		opts = append(opts, fmt.Sprintf("--max-request-bytes=%d", e.MaxRequestBytes))
+	}
+
+	opts = append(opts, e.GRPC.Opts()...)
 	return strings.Join(opts, " ")
 }
 
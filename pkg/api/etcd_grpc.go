@@ -0,0 +1,92 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Masterminds/semver"
+)
+
+// EtcdGRPC is the set of settings of the gRPC server etcd serves clients like the apiserver with.
+// Unspecified settings are left to etcd's defaults
+type EtcdGRPC struct {
+	// KeepaliveInterval is the duration like `2h` after which etcd pings a client connection without any activity, passed to
+	// `--grpc-keepalive-interval`. Defaults to etcd's default, which is 2h
+	KeepaliveInterval string `yaml:"keepaliveInterval,omitempty"`
+	// KeepaliveTimeout is the duration like `20s` etcd waits for the reply to the ping before closing the connection, passed to
+	// `--grpc-keepalive-timeout`. Defaults to etcd's default, which is 20s
+	KeepaliveTimeout string `yaml:"keepaliveTimeout,omitempty"`
+	// MaxConcurrentStreams is the number of concurrent streams like watches each client connection can open, passed to
+	// `--max-concurrent-streams`. Defaults to etcd's default, which is unlimited
+	MaxConcurrentStreams int `yaml:"maxConcurrentStreams,omitempty"`
+}
+
+const (
+	defaultEtcdGRPCKeepaliveInterval = 2 * time.Hour
+	defaultEtcdGRPCKeepaliveTimeout  = 20 * time.Second
+)
+
+// Opts returns the etcd flags for the settings
+func (g EtcdGRPC) Opts() []string {
+	opts := []string{}
+	if g.KeepaliveInterval != "" {
+		opts = append(opts, fmt.Sprintf("--grpc-keepalive-interval=%s", g.KeepaliveInterval))
+	}
+	if g.KeepaliveTimeout != "" {
+		opts = append(opts, fmt.Sprintf("--grpc-keepalive-timeout=%s", g.KeepaliveTimeout))
+	}
+	if g.MaxConcurrentStreams != 0 {
+		opts = append(opts, fmt.Sprintf("--max-concurrent-streams=%d", g.MaxConcurrentStreams))
+	}
+	return opts
+}
+
+func (g EtcdGRPC) Validate(etcdVersion EtcdVersion) error {
+	if g.KeepaliveInterval == "" && g.KeepaliveTimeout == "" && g.MaxConcurrentStreams == 0 {
+		return nil
+	}
+
+	v, err := semver.NewVersion(etcdVersion.String())
+	if err != nil {
+		return fmt.Errorf("failed to parse etcd version \"%s\": %v", etcdVersion, err)
+	}
+	if v.LessThan(semver.MustParse("3.2.0")) {
+		return fmt.Errorf("`etcd.grpc` requires etcd 3.2 or greater, but the etcd version was %s", etcdVersion)
+	}
+
+	interval, err := parseEtcdGRPCDuration("keepaliveInterval", g.KeepaliveInterval, "2h", defaultEtcdGRPCKeepaliveInterval)
+	if err != nil {
+		return err
+	}
+	timeout, err := parseEtcdGRPCDuration("keepaliveTimeout", g.KeepaliveTimeout, "20s", defaultEtcdGRPCKeepaliveTimeout)
+	if err != nil {
+		return err
+	}
+	// Otherwise etcd pings the connections again before it gives up waiting for the reply to the previous ping
+	if timeout >= interval {
+		return fmt.Errorf("`etcd.grpc.keepaliveTimeout` %v must be shorter than `etcd.grpc.keepaliveInterval` %v", timeout, interval)
+	}
+
+	if g.MaxConcurrentStreams != 0 {
+		if g.MaxConcurrentStreams < 0 || int64(g.MaxConcurrentStreams) > math.MaxUint32 {
+			return fmt.Errorf("`etcd.grpc.maxConcurrentStreams` must be between 1 and %d, but was %d", uint32(math.MaxUint32), g.MaxConcurrentStreams)
+		}
+		if v.LessThan(semver.MustParse("3.4.20")) {
+			return fmt.Errorf("`etcd.grpc.maxConcurrentStreams` requires etcd 3.4.20 or greater, but the etcd version was %s", etcdVersion)
+		}
+	}
+
+	return nil
+}
+
+func parseEtcdGRPCDuration(key, value, example string, defaultValue time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("`etcd.grpc.%s` must be a positive duration like %s, but was \"%s\"", key, example, value)
+	}
+	return d, nil
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestEtcdGRPCValidate(t *testing.T) {
+	testCases := []struct {
+		grpc        EtcdGRPC
+		etcdVersion EtcdVersion
+		isValid     bool
+	}{
+		// Valid, not configured
+		{
+			grpc:        EtcdGRPC{},
+			etcdVersion: "2.3.7",
+			isValid:     true,
+		},
+		// Valid, keepalive only
+		{
+			grpc:        EtcdGRPC{KeepaliveInterval: "30s", KeepaliveTimeout: "10s"},
+			etcdVersion: "3.2.13",
+			isValid:     true,
+		},
+		// Valid, keepalive interval shorter than the default but longer than the default timeout
+		{
+			grpc:        EtcdGRPC{KeepaliveInterval: "1m"},
+			etcdVersion: "3.2.13",
+			isValid:     true,
+		},
+		// Valid, max concurrent streams
+		{
+			grpc:        EtcdGRPC{MaxConcurrentStreams: 1000},
+			etcdVersion: "3.5.9",
+			isValid:     true,
+		},
+		// Invalid, etcd2
+		{
+			grpc:        EtcdGRPC{KeepaliveInterval: "30s"},
+			etcdVersion: "2.3.7",
+			isValid:     false,
+		},
+		// Invalid, keepalive interval not a duration
+		{
+			grpc:        EtcdGRPC{KeepaliveInterval: "30"},
+			etcdVersion: "3.2.13",
+			isValid:     false,
+		},
+		// Invalid, zero keepalive timeout
+		{
+			grpc:        EtcdGRPC{KeepaliveTimeout: "0s"},
+			etcdVersion: "3.2.13",
+			isValid:     false,
+		},
+		// Invalid, keepalive timeout equal to the interval
+		{
+			grpc:        EtcdGRPC{KeepaliveInterval: "20s", KeepaliveTimeout: "20s"},
+			etcdVersion: "3.2.13",
+			isValid:     false,
+		},
+		// Invalid, keepalive interval shorter than the default timeout
+		{
+			grpc:        EtcdGRPC{KeepaliveInterval: "10s"},
+			etcdVersion: "3.2.13",
+			isValid:     false,
+		},
+		// Invalid, negative max concurrent streams
+		{
+			grpc:        EtcdGRPC{MaxConcurrentStreams: -1},
+			etcdVersion: "3.5.9",
+			isValid:     false,
+		},
+		// Invalid, max concurrent streams unsupported by the etcd version
+		{
+			grpc:        EtcdGRPC{MaxConcurrentStreams: 1000},
+			etcdVersion: "3.4.3",
+			isValid:     false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.grpc.Validate(testCase.etcdVersion)
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid for etcd %s but got an error: %v", i, testCase.grpc, testCase.etcdVersion, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid for etcd %s but was not", i, testCase.grpc, testCase.etcdVersion)
+		}
+	}
+
+	e := Etcd{GRPC: EtcdGRPC{KeepaliveInterval: "30s", KeepaliveTimeout: "10s", MaxConcurrentStreams: 1000}}
+	expected := "--grpc-keepalive-interval=30s --grpc-keepalive-timeout=10s --max-concurrent-streams=1000"
+	if opts := e.FormatOpts(); opts != expected {
+		t.Errorf("etcd optional args incorrect, expected `%s`, got: `%s`", expected, opts)
+	}
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/kubernetes-incubator/kube-aws/logger"
+)
+
+const (
+	// The recommended IOPS for an instance type missing in etcdBaselineIOPSByInstanceSize
+	defaultEtcdBaselineIOPS = 500
+	// The IOPS recommended per GiB of the etcd database, which is capped by `quotaBackendBytes`
+	etcdIOPSPerDBGiB = 250
+	// gp3 volumes always come with a baseline of 3000 IOPS without any additional cost
+	gp3BaselineIOPS = 3000
+)
+
+// etcdBaselineIOPSByInstanceSize is the IOPS recommended for an etcd data volume per EC2 instance size.
+// Based on the example AWS configurations in the etcd hardware recommendations: https://github.com/etcd-io/etcd/blob/master/Documentation/op-guide/hardware.md
+var etcdBaselineIOPSByInstanceSize = map[string]int{
+	"nano":     defaultEtcdBaselineIOPS,
+	"micro":    defaultEtcdBaselineIOPS,
+	"small":    defaultEtcdBaselineIOPS,
+	"medium":   defaultEtcdBaselineIOPS,
+	"large":    500,
+	"xlarge":   1000,
+	"2xlarge":  1500,
+	"4xlarge":  3000,
+	"8xlarge":  3000,
+	"9xlarge":  3000,
+	"10xlarge": 3000,
+	"12xlarge": 3000,
+	"16xlarge": 3000,
+	"18xlarge": 3000,
+	"24xlarge": 3000,
+	"32xlarge": 3000,
+	"metal":    3000,
+}
+
+// maxIOPSPerGiBByVolumeType is the maximum ratio of provisioned IOPS to the volume size for each EBS volume type
+var maxIOPSPerGiBByVolumeType = map[string]int{
+	"io1": 50,
+	"io2": 500,
+	"gp3": 500,
+}
+
+// SupportsProvisionedIOPS returns true when the IOPS of the volume can be provisioned via `iops`
+func (v DataVolume) SupportsProvisionedIOPS() bool {
+	_, ok := maxIOPSPerGiBByVolumeType[v.Type]
+	return ok
+}
+
+// MaxIOPS returns the maximum IOPS which can be provisioned for the volume, or 0 when IOPS can't be provisioned for the volume type
+func (v DataVolume) MaxIOPS() int {
+	return maxIOPSPerGiBByVolumeType[v.Type] * v.Size
+}
+
+// RecommendedEtcdDataVolumeIOPS calculates the IOPS recommended for an etcd data volume attached to an instance of the type,
+// serving an etcd database of up to quotaBackendBytes.
+func RecommendedEtcdDataVolumeIOPS(instanceType string, quotaBackendBytes int) int {
+	iops := defaultEtcdBaselineIOPS
+	if parts := strings.SplitN(instanceType, ".", 2); len(parts) == 2 {
+		if baseline, ok := etcdBaselineIOPSByInstanceSize[parts[1]]; ok {
+			iops = baseline
+		}
+	}
+
+	if quotaBackendBytes <= 0 {
+		quotaBackendBytes = DefaultQuotaBackendBytes
+	}
+	dbSizeInGiB := (quotaBackendBytes + (1<<30 - 1)) >> 30
+	if dbIOPS := dbSizeInGiB * etcdIOPSPerDBGiB; dbIOPS > iops {
+		iops = dbIOPS
+	}
+
+	return iops
+}
+
+// RecommendedDataVolumeIOPS returns the IOPS recommended for the etcd data volume.
+// It is bounded by the maximum IOPS allowed for the type and the size of the volume, and returns 0 when IOPS can't be provisioned for the volume type.
+func (e Etcd) RecommendedDataVolumeIOPS() int {
+	if !e.DataVolume.SupportsProvisionedIOPS() {
+		return 0
+	}
+
+	iops := RecommendedEtcdDataVolumeIOPS(e.InstanceType, e.UserSuppliedArgs.QuotaBackendBytes)
+
+	if e.DataVolume.Type == "gp3" && iops < gp3BaselineIOPS {
+		iops = gp3BaselineIOPS
+	}
+	if max := e.DataVolume.MaxIOPS(); iops > max {
+		iops = max
+	}
+
+	return iops
+}
+
+// SetDefaultDataVolumeIOPS sets the recommended IOPS to the etcd data volume when `etcd.dataVolume.autoIOPS` is enabled
+// and the IOPS isn't explicitly specified
+func (e *Etcd) SetDefaultDataVolumeIOPS() {
+	if e.DataVolume.AutoIOPS && e.DataVolume.IOPS == 0 {
+		e.DataVolume.IOPS = e.RecommendedDataVolumeIOPS()
+	}
+}
+
+// warnInsufficientDataVolumeIOPS warns when the IOPS explicitly specified for the etcd data volume is below the recommendation
+func (e Etcd) warnInsufficientDataVolumeIOPS() {
+	if e.DataVolume.IOPS == 0 {
+		return
+	}
+	if recommended := e.RecommendedDataVolumeIOPS(); e.DataVolume.IOPS < recommended {
+		logger.Warnf("`etcd.dataVolume.iops` (%d) is below %d, which is recommended for the %s volume of etcd running on %s with `quotaBackendBytes` of %d. Consider increasing it or enabling `etcd.dataVolume.autoIOPS` without setting `iops`",
+			e.DataVolume.IOPS, recommended, e.DataVolume.Type, e.InstanceType, e.UserSuppliedArgs.QuotaBackendBytes)
+	}
+}
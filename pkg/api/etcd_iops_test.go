@@ -0,0 +1,150 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestRecommendedEtcdDataVolumeIOPS(t *testing.T) {
+	testCases := []struct {
+		instanceType      string
+		quotaBackendBytes int
+		iops              int
+	}{
+		// Baseline for small instances
+		{
+			instanceType:      "t2.medium",
+			quotaBackendBytes: DefaultQuotaBackendBytes,
+			iops:              500,
+		},
+		// Baseline per instance size
+		{
+			instanceType:      "m5.xlarge",
+			quotaBackendBytes: DefaultQuotaBackendBytes,
+			iops:              1000,
+		},
+		{
+			instanceType:      "m5.2xlarge",
+			quotaBackendBytes: DefaultQuotaBackendBytes,
+			iops:              1500,
+		},
+		{
+			instanceType:      "m5.24xlarge",
+			quotaBackendBytes: DefaultQuotaBackendBytes,
+			iops:              3000,
+		},
+		// Unknown instance sizes fall back to the default baseline
+		{
+			instanceType:      "unknown",
+			quotaBackendBytes: DefaultQuotaBackendBytes,
+			iops:              500,
+		},
+		// A large database requires more IOPS than the baseline
+		{
+			instanceType:      "m5.large",
+			quotaBackendBytes: MaxQuotaBackendBytes,
+			iops:              2000,
+		},
+		// Partial GiBs are rounded up
+		{
+			instanceType:      "m5.large",
+			quotaBackendBytes: 5*1024*1024*1024 + 1,
+			iops:              1500,
+		},
+		// quotaBackendBytes defaults to the etcd default
+		{
+			instanceType:      "m5.large",
+			quotaBackendBytes: 0,
+			iops:              500,
+		},
+	}
+
+	for _, testCase := range testCases {
+		actual := RecommendedEtcdDataVolumeIOPS(testCase.instanceType, testCase.quotaBackendBytes)
+		if actual != testCase.iops {
+			t.Errorf("Expected recommended IOPS for %s with quotaBackendBytes %d to be %d, but was %d", testCase.instanceType, testCase.quotaBackendBytes, testCase.iops, actual)
+		}
+	}
+}
+
+func TestEtcdRecommendedDataVolumeIOPS(t *testing.T) {
+	testCases := []struct {
+		instanceType string
+		dataVolume   DataVolume
+		iops         int
+	}{
+		// IOPS can't be provisioned for gp2
+		{
+			instanceType: "m5.4xlarge",
+			dataVolume:   DataVolume{Size: 100, Type: "gp2"},
+			iops:         0,
+		},
+		{
+			instanceType: "m5.xlarge",
+			dataVolume:   DataVolume{Size: 100, Type: "io1"},
+			iops:         1000,
+		},
+		// Bounded by the maximum IOPS-to-size ratio of io1
+		{
+			instanceType: "m5.4xlarge",
+			dataVolume:   DataVolume{Size: 30, Type: "io1"},
+			iops:         1500,
+		},
+		{
+			instanceType: "m5.4xlarge",
+			dataVolume:   DataVolume{Size: 30, Type: "io2"},
+			iops:         3000,
+		},
+		// gp3 comes with 3000 IOPS at least
+		{
+			instanceType: "m5.large",
+			dataVolume:   DataVolume{Size: 30, Type: "gp3"},
+			iops:         3000,
+		},
+	}
+
+	for _, testCase := range testCases {
+		etcd := NewDefaultEtcd()
+		etcd.InstanceType = testCase.instanceType
+		etcd.DataVolume = testCase.dataVolume
+		actual := etcd.RecommendedDataVolumeIOPS()
+		if actual != testCase.iops {
+			t.Errorf("Expected recommended IOPS for %+v on %s to be %d, but was %d", testCase.dataVolume, testCase.instanceType, testCase.iops, actual)
+		}
+	}
+}
+
+func TestEtcdSetDefaultDataVolumeIOPS(t *testing.T) {
+	testCases := []struct {
+		dataVolume DataVolume
+		iops       int
+	}{
+		// Disabled by default
+		{
+			dataVolume: DataVolume{Size: 100, Type: "io1"},
+			iops:       0,
+		},
+		{
+			dataVolume: DataVolume{Size: 100, Type: "io1", AutoIOPS: true},
+			iops:       1000,
+		},
+		// Explicitly specified IOPS overrides the recommendation
+		{
+			dataVolume: DataVolume{Size: 100, Type: "io1", IOPS: 200, AutoIOPS: true},
+			iops:       200,
+		},
+		{
+			dataVolume: DataVolume{Size: 100, Type: "gp2", AutoIOPS: true},
+			iops:       0,
+		},
+	}
+
+	for _, testCase := range testCases {
+		etcd := NewDefaultEtcd()
+		etcd.InstanceType = "m5.xlarge"
+		etcd.DataVolume = testCase.dataVolume
+		etcd.SetDefaultDataVolumeIOPS()
+		if etcd.DataVolume.IOPS != testCase.iops {
+			t.Errorf("Expected IOPS of %+v to be defaulted to %d, but was %d", testCase.dataVolume, testCase.iops, etcd.DataVolume.IOPS)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/Masterminds/semver"
+)
+
+const (
+	EtcdClientPort         = 2379
+	EtcdPeerPort           = 2380
+	DefaultEtcdMetricsPort = 2381
+)
+
+// EtcdMetrics is the set of settings to expose etcd metrics on a dedicated port via `--listen-metrics-urls`,
+// so that e.g. Prometheus is able to scrape them without a client certificate for the client port
+type EtcdMetrics struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// Port defaults to 2381
+	Port int `yaml:"port,omitempty"`
+	// TLS serves the metrics over HTTPS with the etcd server certificate. Scrapers need client certificates signed by the etcd trusted CA
+	TLS bool `yaml:"tls,omitempty"`
+	// AllowedSourceCIDRs is the list of network ranges the etcd security group allows scraping the metrics from
+	AllowedSourceCIDRs []string `yaml:"allowedSourceCIDRs,omitempty"`
+}
+
+func (m EtcdMetrics) PortOrDefault() int {
+	if m.Port == 0 {
+		return DefaultEtcdMetricsPort
+	}
+	return m.Port
+}
+
+func (m EtcdMetrics) Scheme() string {
+	if m.TLS {
+		return "https"
+	}
+	return "http"
+}
+
+func (m EtcdMetrics) Validate(etcdVersion EtcdVersion) error {
+	if !m.Enabled {
+		if m.Port != 0 || m.TLS || len(m.AllowedSourceCIDRs) > 0 {
+			return fmt.Errorf("`etcd.metrics.enabled` must be true to customize the etcd metrics endpoint")
+		}
+		return nil
+	}
+
+	v, err := semver.NewVersion(etcdVersion.String())
+	if err != nil {
+		return fmt.Errorf("failed to parse etcd version \"%s\": %v", etcdVersion, err)
+	}
+	if v.LessThan(semver.MustParse("3.3.0")) {
+		return fmt.Errorf("`etcd.metrics` requires etcd 3.3 or greater, but the etcd version was %s", etcdVersion)
+	}
+
+	port := m.PortOrDefault()
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("invalid `etcd.metrics.port` %d: it must be between 1 and 65535", port)
+	}
+	if port == EtcdClientPort || port == EtcdPeerPort {
+		return fmt.Errorf("`etcd.metrics.port` %d conflicts with the etcd client port %d or the peer port %d", port, EtcdClientPort, EtcdPeerPort)
+	}
+
+	for _, cidr := range m.AllowedSourceCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("invalid CIDR \"%s\" in `etcd.metrics.allowedSourceCIDRs`: %v", cidr, err)
+		}
+	}
+
+	return nil
+}
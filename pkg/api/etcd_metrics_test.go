@@ -0,0 +1,72 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestEtcdMetricsValidate(t *testing.T) {
+	testCases := []struct {
+		metrics     EtcdMetrics
+		etcdVersion EtcdVersion
+		isValid     bool
+	}{
+		// Valid, disabled
+		{
+			metrics:     EtcdMetrics{},
+			etcdVersion: "3.2.13",
+			isValid:     true,
+		},
+		// Valid, the default port
+		{
+			metrics:     EtcdMetrics{Enabled: true},
+			etcdVersion: "3.3.10",
+			isValid:     true,
+		},
+		// Valid, customized
+		{
+			metrics:     EtcdMetrics{Enabled: true, Port: 9379, TLS: true, AllowedSourceCIDRs: []string{"10.0.0.0/16"}},
+			etcdVersion: "3.4.3",
+			isValid:     true,
+		},
+		// Invalid, customized but disabled
+		{
+			metrics:     EtcdMetrics{Port: 9379},
+			etcdVersion: "3.3.10",
+			isValid:     false,
+		},
+		// Invalid, unsupported etcd version
+		{
+			metrics:     EtcdMetrics{Enabled: true},
+			etcdVersion: "3.2.13",
+			isValid:     false,
+		},
+		// Invalid, conflicting with the client port
+		{
+			metrics:     EtcdMetrics{Enabled: true, Port: 2379},
+			etcdVersion: "3.3.10",
+			isValid:     false,
+		},
+		// Invalid, out of range
+		{
+			metrics:     EtcdMetrics{Enabled: true, Port: 65536},
+			etcdVersion: "3.3.10",
+			isValid:     false,
+		},
+		// Invalid, malformed CIDR
+		{
+			metrics:     EtcdMetrics{Enabled: true, AllowedSourceCIDRs: []string{"10.0.0.0"}},
+			etcdVersion: "3.3.10",
+			isValid:     false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.metrics.Validate(testCase.etcdVersion)
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid for etcd %s but got an error: %v", i, testCase.metrics, testCase.etcdVersion, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid for etcd %s but was not", i, testCase.metrics, testCase.etcdVersion)
+		}
+	}
+}
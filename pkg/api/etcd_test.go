@@ -60,3 +60,233 @@ func TestEtcd(t *testing.T) {
 		t.Errorf("etcd optional args incorrect, expected `--quota-backend-bytes=100000000 --auto-compaction-retention=1`, got: `%s`", etcdTest.FormatOpts())
 	}
 }
+
+func TestEtcdValidateDataVolumeMount(t *testing.T) {
+	testCases := []struct {
+		etcd    Etcd
+		isValid bool
+	}{
+		// Valid, not configured
+		{
+			etcd:    Etcd{},
+			isValid: true,
+		},
+		// Valid, custom data dir and mount options
+		{
+			etcd:    Etcd{DataDir: "/var/lib/etcd", VolumeMountOptions: []string{"noatime", "nobarrier", "commit=60"}},
+			isValid: true,
+		},
+		// Invalid, relative data dir
+		{
+			etcd:    Etcd{DataDir: "var/lib/etcd"},
+			isValid: false,
+		},
+		// Invalid, trailing slash
+		{
+			etcd:    Etcd{DataDir: "/var/lib/etcd/"},
+			isValid: false,
+		},
+		// Invalid, root dir
+		{
+			etcd:    Etcd{DataDir: "/"},
+			isValid: false,
+		},
+		// Invalid, conflicting with a volume mount
+		{
+			etcd:    Etcd{DataDir: "/ebs", VolumeMounts: []NodeVolumeMount{{Type: "gp2", Size: 30, Device: "/dev/xvdg", Path: "/ebs"}}},
+			isValid: false,
+		},
+		// Invalid, options joined in a single item
+		{
+			etcd:    Etcd{VolumeMountOptions: []string{"noatime,nobarrier"}},
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.etcd.validateDataVolumeMount()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected dataDir %q and volumeMountOptions %v to be valid but got an error: %v", i, testCase.etcd.DataDir, testCase.etcd.VolumeMountOptions, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected dataDir %q and volumeMountOptions %v to be invalid but was not", i, testCase.etcd.DataDir, testCase.etcd.VolumeMountOptions)
+		}
+	}
+
+	e := Etcd{DataDir: "/mnt/etcd/data", VolumeMountOptions: []string{"noatime", "nobarrier"}}
+	if actual := e.DataVolumeSystemdMountName(); actual != "mnt-etcd-data" {
+		t.Errorf("expected mnt-etcd-data but was %s", actual)
+	}
+	if actual := e.DataVolumeMountOptionsString(); actual != "noatime,nobarrier" {
+		t.Errorf("expected noatime,nobarrier but was %s", actual)
+	}
+	if actual := (Etcd{}).DataVolumeSystemdMountName(); actual != "var-lib-etcd2" {
+		t.Errorf("expected var-lib-etcd2 but was %s", actual)
+	}
+}
+
+func TestEtcdMaxRequestBytes(t *testing.T) {
+	testCases := []struct {
+		etcd    Etcd
+		isValid bool
+	}{
+		// Valid, not configured
+		{
+			etcd:    Etcd{},
+			isValid: true,
+		},
+		// Valid, 4 MiB
+		{
+			etcd:    Etcd{MaxRequestBytes: 4 * 1024 * 1024},
+			isValid: true,
+		},
+		// Invalid, negative
+		{
+			etcd:    Etcd{MaxRequestBytes: -1},
+			isValid: false,
+		},
+		// Invalid, larger than 10 MiB
+		{
+			etcd:    Etcd{MaxRequestBytes: 10*1024*1024 + 1},
+			isValid: false,
+		},
+		// Invalid, etcd2
+		{
+			etcd:    Etcd{Cluster: EtcdCluster{Version: "2.3.7"}, MaxRequestBytes: 4 * 1024 * 1024},
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.etcd.validateMaxRequestBytes()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.etcd.MaxRequestBytes, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.etcd.MaxRequestBytes)
+		}
+	}
+
+	e := Etcd{MaxRequestBytes: 4194304, UserSuppliedArgs: UserSuppliedArgs{QuotaBackendBytes: DefaultQuotaBackendBytes}}
+	if opts := e.FormatOpts(); opts != "--quota-backend-bytes=2147483648 --max-request-bytes=4194304" {
+		t.Errorf("etcd optional args incorrect, expected `--quota-backend-bytes=2147483648 --max-request-bytes=4194304`, got: `%s`", opts)
+	}
+}
+
+func TestEtcdDisasterRecoveryValidate(t *testing.T) {
+	testCases := []struct {
+		disasterRecovery EtcdDisasterRecovery
+		isValid          bool
+	}{
+		// Valid, not configured
+		{
+			disasterRecovery: EtcdDisasterRecovery{},
+			isValid:          true,
+		},
+		// Valid, member failure period limit
+		{
+			disasterRecovery: EtcdDisasterRecovery{Automated: true, MemberFailurePeriodLimit: 60},
+			isValid:          true,
+		},
+		// Valid, replacing members failing to start with the default limit
+		{
+			disasterRecovery: EtcdDisasterRecovery{Automated: true, ReplaceMembersFailingToStart: true},
+			isValid:          true,
+		},
+		// Invalid, negative limit
+		{
+			disasterRecovery: EtcdDisasterRecovery{Automated: true, MemberFailurePeriodLimit: -1},
+			isValid:          false,
+		},
+		// Invalid, replacing members failing to start without automated disaster recovery
+		{
+			disasterRecovery: EtcdDisasterRecovery{ReplaceMembersFailingToStart: true},
+			isValid:          false,
+		},
+		// Invalid, replacing members failing to start before the first health check
+		{
+			disasterRecovery: EtcdDisasterRecovery{Automated: true, MemberFailurePeriodLimit: 120, ReplaceMembersFailingToStart: true},
+			isValid:          false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.disasterRecovery.Validate()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.disasterRecovery, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.disasterRecovery)
+		}
+	}
+
+	if limit := (EtcdDisasterRecovery{ReplaceMembersFailingToStart: true}).MemberFailurePeriodLimitOrDefault(); limit != 600 {
+		t.Errorf("expected the default limit with replaceMembersFailingToStart to be 600 but was %d", limit)
+	}
+	if limit := (EtcdDisasterRecovery{}).MemberFailurePeriodLimitOrDefault(); limit != 0 {
+		t.Errorf("expected the limit to be left to etcdadm's default but was %d", limit)
+	}
+}
+
+func TestEtcdSnapshotValidate(t *testing.T) {
+	testCases := []struct {
+		snapshot EtcdSnapshot
+		isValid  bool
+	}{
+		// Valid, not configured
+		{
+			snapshot: EtcdSnapshot{},
+			isValid:  true,
+		},
+		// Valid, the defaults
+		{
+			snapshot: EtcdSnapshot{Automated: true},
+			isValid:  true,
+		},
+		// Valid, the interval and the retention
+		{
+			snapshot: EtcdSnapshot{Automated: true, Interval: "1h", RetentionInDays: 7},
+			isValid:  true,
+		},
+		// Invalid, the retention without automated snapshot
+		{
+			snapshot: EtcdSnapshot{RetentionInDays: 7},
+			isValid:  false,
+		},
+		// Invalid, malformed interval
+		{
+			snapshot: EtcdSnapshot{Automated: true, Interval: "60"},
+			isValid:  false,
+		},
+		// Invalid, too short interval
+		{
+			snapshot: EtcdSnapshot{Automated: true, Interval: "10s"},
+			isValid:  false,
+		},
+		// Invalid, negative retention
+		{
+			snapshot: EtcdSnapshot{Automated: true, RetentionInDays: -1},
+			isValid:  false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.snapshot.Validate()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.snapshot, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.snapshot)
+		}
+	}
+
+	if s := (EtcdSnapshot{Automated: true}).IntervalSeconds(); s != 60 {
+		t.Errorf("expected the default interval to be 60 seconds but was %d", s)
+	}
+	if s := (EtcdSnapshot{Automated: true, Interval: "1h"}).IntervalSeconds(); s != 3600 {
+		t.Errorf("expected the interval to be 3600 seconds but was %d", s)
+	}
+	if (EtcdSnapshot{Automated: true}).HistoryEnabled() || !(EtcdSnapshot{Automated: true, RetentionInDays: 7}).HistoryEnabled() {
+		t.Errorf("expected the history of snapshots to be enabled only with the retention")
+	}
+}
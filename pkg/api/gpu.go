@@ -9,6 +9,10 @@ import (
 
 var GPUEnabledInstanceFamily = []string{"p2", "p3", "g2", "g3"}
 
+// NvidiaDevicePluginNodeLabel is the label given to nodes in node pools the NVIDIA device plugin is enabled for,
+// which the device plugin DaemonSet selects along with `kube-aws.coreos.com/gpu=nvidia` given to nodes once their drivers are loaded
+const NvidiaDevicePluginNodeLabel = "kube-aws.coreos.com/nvidia-device-plugin"
+
 type Gpu struct {
 	Nvidia NvidiaSetting `yaml:"nvidia"`
 }
@@ -16,6 +20,12 @@ type Gpu struct {
 type NvidiaSetting struct {
 	Enabled bool   `yaml:"enabled,omitempty"`
 	Version string `yaml:"version,omitempty"`
+	// DevicePlugin exposes GPUs as the `nvidia.com/gpu` resource to kubelet, mounting the drivers installed on the node into containers requesting it
+	DevicePlugin NvidiaDevicePlugin `yaml:"devicePlugin,omitempty"`
+}
+
+type NvidiaDevicePlugin struct {
+	Enabled bool `yaml:"enabled,omitempty"`
 }
 
 func isGpuEnabledInstanceType(instanceType string) bool {
@@ -41,6 +51,21 @@ func (c NvidiaSetting) IsEnabledOn(instanceType string) bool {
 	return isGpuEnabledInstanceType(instanceType) && c.Enabled
 }
 
+// DevicePluginEnabledOn returns true when the NVIDIA device plugin runs on nodes of the instance type
+func (c NvidiaSetting) DevicePluginEnabledOn(instanceType string) bool {
+	return c.IsEnabledOn(instanceType) && c.DevicePlugin.Enabled
+}
+
+// NvidiaDevicePluginEnabled returns true when the NVIDIA device plugin is enabled for any node pool
+func (c Cluster) NvidiaDevicePluginEnabled() bool {
+	for _, p := range c.Worker.NodePools {
+		if p.Gpu.Nvidia.Enabled && p.Gpu.Nvidia.DevicePlugin.Enabled {
+			return true
+		}
+	}
+	return false
+}
+
 func (c Gpu) Validate(instanceType string, experimentalGpuSupportEnabled bool) error {
 	if c.Nvidia.Enabled && !isGpuEnabledInstanceType(instanceType) {
 		return errors.New(fmt.Sprintf("instance type %v doesn't support GPU. You can enable Nvidia driver intallation support only when use %v instance family.", instanceType, GPUEnabledInstanceFamily))
@@ -54,6 +79,24 @@ func (c Gpu) Validate(instanceType string, experimentalGpuSupportEnabled bool) e
 	if c.Nvidia.Enabled && len(c.Nvidia.Version) == 0 {
 		return errors.New(`gpu.nvidia.version must not be empty when gpu.nvidia is enabled.`)
 	}
+	if c.Nvidia.DevicePlugin.Enabled && !c.Nvidia.Enabled {
+		return errors.New("`gpu.nvidia.devicePlugin.enabled` requires `gpu.nvidia.enabled` to be true")
+	}
 
 	return nil
 }
+
+// ValidateKubernetesVersion returns an error when the device plugin isn't supported by kubelet of the kubernetes version
+func (c NvidiaSetting) ValidateKubernetesVersion(k8sVer string) error {
+	if !c.DevicePlugin.Enabled {
+		return nil
+	}
+	supported, err := k8sVersionSatisfies(">= 1.10", k8sVer)
+	if err != nil {
+		return err
+	}
+	if !supported {
+		return fmt.Errorf("`gpu.nvidia.devicePlugin` requires kubernetesVersion 1.10 or greater, but was %s", k8sVer)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestGpuValidate(t *testing.T) {
+	testCases := []struct {
+		gpu          Gpu
+		instanceType string
+		isValid      bool
+	}{
+		// Valid, the device plugin on a GPU instance type
+		{
+			gpu: Gpu{Nvidia: NvidiaSetting{
+				Enabled:      true,
+				Version:      "384.66",
+				DevicePlugin: NvidiaDevicePlugin{Enabled: true},
+			}},
+			instanceType: "p3.2xlarge",
+			isValid:      true,
+		},
+		// Valid, the drivers without the device plugin
+		{
+			gpu:          Gpu{Nvidia: NvidiaSetting{Enabled: true, Version: "384.66"}},
+			instanceType: "p2.xlarge",
+			isValid:      true,
+		},
+		// Invalid, the device plugin without the drivers
+		{
+			gpu:          Gpu{Nvidia: NvidiaSetting{DevicePlugin: NvidiaDevicePlugin{Enabled: true}}},
+			instanceType: "p2.xlarge",
+			isValid:      false,
+		},
+		// Invalid, the drivers on a non-GPU instance type
+		{
+			gpu: Gpu{Nvidia: NvidiaSetting{
+				Enabled:      true,
+				Version:      "384.66",
+				DevicePlugin: NvidiaDevicePlugin{Enabled: true},
+			}},
+			instanceType: "t2.medium",
+			isValid:      false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.gpu.Validate(testCase.instanceType, false)
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.gpu, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.gpu)
+		}
+	}
+}
+
+func TestNvidiaSettingValidateKubernetesVersion(t *testing.T) {
+	s := NvidiaSetting{Enabled: true, Version: "384.66", DevicePlugin: NvidiaDevicePlugin{Enabled: true}}
+	if err := s.ValidateKubernetesVersion("v1.10.0"); err != nil {
+		t.Errorf("expected the device plugin to be supported in 1.10 but got an error: %v", err)
+	}
+	if err := s.ValidateKubernetesVersion("v1.9.3"); err == nil {
+		t.Error("expected the device plugin to be unsupported in 1.9 but was not")
+	}
+}
+
+func TestNvidiaDevicePluginEnabled(t *testing.T) {
+	c := Cluster{}
+	c.Worker.NodePools = []WorkerNodePool{
+		{Gpu: Gpu{Nvidia: NvidiaSetting{Enabled: true, Version: "384.66"}}},
+	}
+	if c.NvidiaDevicePluginEnabled() {
+		t.Error("expected the device plugin to be disabled")
+	}
+
+	c.Worker.NodePools = append(c.Worker.NodePools, WorkerNodePool{
+		Gpu: Gpu{Nvidia: NvidiaSetting{Enabled: true, Version: "384.66", DevicePlugin: NvidiaDevicePlugin{Enabled: true}}},
+	})
+	if !c.NvidiaDevicePluginEnabled() {
+		t.Error("expected the device plugin to be enabled")
+	}
+}
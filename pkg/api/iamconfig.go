@@ -51,8 +51,8 @@ func (c IAMConfig) Validate() error {
 		return errors.New("failed to parse `iam` config: either you set `role.*` options or `instanceProfile.arn` ones but not both")
 	}
 
-	managedPolicyRegexp := regexp.MustCompile(`arn:aws:iam::((\d{12})|aws):policy/([a-zA-Z0-9-=,\\.@_]{1,128})`)
-	instanceProfileRegexp := regexp.MustCompile(`arn:aws:iam::(\d{12}):instance-profile/([a-zA-Z0-9-=,\\.@_]{1,128})`)
+	managedPolicyRegexp := regexp.MustCompile(`arn:aws(-cn|-us-gov)?:iam::((\d{12})|aws):policy/([a-zA-Z0-9-=,\\.@_]{1,128})`)
+	instanceProfileRegexp := regexp.MustCompile(`arn:aws(-cn|-us-gov)?:iam::(\d{12}):instance-profile/([a-zA-Z0-9-=,\\.@_]{1,128})`)
 	for _, policy := range c.Role.ManagedPolicies {
 		if !managedPolicyRegexp.MatchString(policy.Arn) {
 			return fmt.Errorf("invalid managed policy arn, your managed policy must match this (=arn:aws:iam::(YOURACCOUNTID|aws):policy/POLICYNAME), provided this (%s)", policy.Arn)
@@ -67,3 +67,18 @@ func (c IAMConfig) Validate() error {
 	return nil
 
 }
+
+var permissionsBoundaryRegexp = regexp.MustCompile(`^arn:aws(-cn|-us-gov)?:iam::(\d{12}|aws):policy(/[a-zA-Z0-9+=,.@_-]+)+$`)
+
+// ClusterIAM is the set of IAM settings applied to all the IAM roles created by kube-aws
+type ClusterIAM struct {
+	// PermissionsBoundary is the ARN of the managed policy set as the permissions boundary of every IAM role created by kube-aws
+	PermissionsBoundary string `yaml:"permissionsBoundary,omitempty"`
+}
+
+func (c ClusterIAM) Validate() error {
+	if c.PermissionsBoundary != "" && !permissionsBoundaryRegexp.MatchString(c.PermissionsBoundary) {
+		return fmt.Errorf("invalid `iam.permissionsBoundary` \"%s\": it must be an IAM policy ARN like arn:aws:iam::YOURACCOUNTID:policy/POLICYNAME", c.PermissionsBoundary)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestClusterIAMValidate(t *testing.T) {
+	testCases := []struct {
+		iam     ClusterIAM
+		isValid bool
+	}{
+		// Valid, not configured
+		{
+			iam:     ClusterIAM{},
+			isValid: true,
+		},
+		// Valid, customer managed policy
+		{
+			iam:     ClusterIAM{PermissionsBoundary: "arn:aws:iam::123456789012:policy/KubeAWSPermissionsBoundary"},
+			isValid: true,
+		},
+		// Valid, policy with a path in the China partition
+		{
+			iam:     ClusterIAM{PermissionsBoundary: "arn:aws-cn:iam::123456789012:policy/boundaries/kube-aws"},
+			isValid: true,
+		},
+		// Valid, AWS managed policy
+		{
+			iam:     ClusterIAM{PermissionsBoundary: "arn:aws:iam::aws:policy/PowerUserAccess"},
+			isValid: true,
+		},
+		// Invalid, policy name
+		{
+			iam:     ClusterIAM{PermissionsBoundary: "KubeAWSPermissionsBoundary"},
+			isValid: false,
+		},
+		// Invalid, role ARN
+		{
+			iam:     ClusterIAM{PermissionsBoundary: "arn:aws:iam::123456789012:role/KubeAWSPermissionsBoundary"},
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.iam.Validate()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.iam, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.iam)
+		}
+	}
+}
+
+func TestIAMConfigValidate(t *testing.T) {
+	testCases := []struct {
+		iam     IAMConfig
+		isValid bool
+	}{
+		// Valid, not configured
+		{
+			iam:     IAMConfig{},
+			isValid: true,
+		},
+		// Valid, managed policies in the aws, China and GovCloud partitions
+		{
+			iam:     IAMConfig{Role: IAMRole{ManagedPolicies: []IAMManagedPolicy{{ARN: ARN{Arn: "arn:aws:iam::aws:policy/AdministratorAccess"}}, {ARN: ARN{Arn: "arn:aws-cn:iam::123456789012:policy/mypolicy"}}, {ARN: ARN{Arn: "arn:aws-us-gov:iam::123456789012:policy/mypolicy"}}}}},
+			isValid: true,
+		},
+		// Valid, instance profile in the GovCloud partition
+		{
+			iam:     IAMConfig{InstanceProfile: IAMInstanceProfile{ARN: ARN{Arn: "arn:aws-us-gov:iam::123456789012:instance-profile/myprofile"}}},
+			isValid: true,
+		},
+		// Invalid, unknown partition
+		{
+			iam:     IAMConfig{Role: IAMRole{ManagedPolicies: []IAMManagedPolicy{{ARN: ARN{Arn: "arn:aws-unknown:iam::123456789012:policy/mypolicy"}}}}},
+			isValid: false,
+		},
+		// Invalid, not an instance profile ARN
+		{
+			iam:     IAMConfig{InstanceProfile: IAMInstanceProfile{ARN: ARN{Arn: "arn:aws-cn:iam::123456789012:role/myrole"}}},
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.iam.Validate()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.iam, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.iam)
+		}
+	}
+}
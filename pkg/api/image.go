@@ -2,6 +2,7 @@ package api
 
 import (
 	"fmt"
+	"strings"
 )
 
 type Image struct {
@@ -39,6 +40,10 @@ func (i *Image) RktRepoWithoutTag() string {
 	return i.Repo
 }
 
+// RepoWithTag returns the reference to the image. A tag like `sha256:<digest>` is treated as the digest of the image
 func (i *Image) RepoWithTag() string {
+	if strings.HasPrefix(i.Tag, "sha256:") {
+		return fmt.Sprintf("%s@%s", i.Repo, i.Tag)
+	}
 	return fmt.Sprintf("%s:%s", i.Repo, i.Tag)
 }
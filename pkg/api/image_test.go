@@ -0,0 +1,13 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestImageRepoWithTagDigest(t *testing.T) {
+	i := Image{Repo: "registry.k8s.io/nvidia-gpu-device-plugin", Tag: "sha256:0842734032018be107fa2490c98156992911e3e1f2a21e059ff0105b07dd8e9e"}
+	expected := "registry.k8s.io/nvidia-gpu-device-plugin@sha256:0842734032018be107fa2490c98156992911e3e1f2a21e059ff0105b07dd8e9e"
+	if actual := i.RepoWithTag(); actual != expected {
+		t.Errorf("unexpected image: expected %s, but was %s", expected, actual)
+	}
+}
@@ -0,0 +1,67 @@
+package api
+
+// InstanceCapacity is the compute resources available in an EC2 instance
+type InstanceCapacity struct {
+	VCPUs     int
+	MemoryMiB int
+}
+
+// instanceCapacities is the compute resources of commonly used EC2 instance types.
+// Validations depending on it are skipped for instance types missing here.
+var instanceCapacities = map[string]InstanceCapacity{
+	"t2.nano":     {1, 512},
+	"t2.micro":    {1, 1024},
+	"t2.small":    {1, 2048},
+	"t2.medium":   {2, 4096},
+	"t2.large":    {2, 8192},
+	"t2.xlarge":   {4, 16384},
+	"t2.2xlarge":  {8, 32768},
+	"t3.nano":     {2, 512},
+	"t3.micro":    {2, 1024},
+	"t3.small":    {2, 2048},
+	"t3.medium":   {2, 4096},
+	"t3.large":    {2, 8192},
+	"t3.xlarge":   {4, 16384},
+	"t3.2xlarge":  {8, 32768},
+	"m4.large":    {2, 8192},
+	"m4.xlarge":   {4, 16384},
+	"m4.2xlarge":  {8, 32768},
+	"m4.4xlarge":  {16, 65536},
+	"m4.10xlarge": {40, 163840},
+	"m4.16xlarge": {64, 262144},
+	"m5.large":    {2, 8192},
+	"m5.xlarge":   {4, 16384},
+	"m5.2xlarge":  {8, 32768},
+	"m5.4xlarge":  {16, 65536},
+	"m5.12xlarge": {48, 196608},
+	"m5.24xlarge": {96, 393216},
+	"c4.large":    {2, 3840},
+	"c4.xlarge":   {4, 7680},
+	"c4.2xlarge":  {8, 15360},
+	"c4.4xlarge":  {16, 30720},
+	"c4.8xlarge":  {36, 61440},
+	"c5.large":    {2, 4096},
+	"c5.xlarge":   {4, 8192},
+	"c5.2xlarge":  {8, 16384},
+	"c5.4xlarge":  {16, 32768},
+	"c5.9xlarge":  {36, 73728},
+	"c5.18xlarge": {72, 147456},
+	"r4.large":    {2, 15616},
+	"r4.xlarge":   {4, 31232},
+	"r4.2xlarge":  {8, 62464},
+	"r4.4xlarge":  {16, 124928},
+	"r4.8xlarge":  {32, 249856},
+	"r4.16xlarge": {64, 499712},
+	"r5.large":    {2, 16384},
+	"r5.xlarge":   {4, 32768},
+	"r5.2xlarge":  {8, 65536},
+	"r5.4xlarge":  {16, 131072},
+	"r5.12xlarge": {48, 393216},
+	"r5.24xlarge": {96, 786432},
+}
+
+// InstanceCapacityOf returns the compute resources of the instance type, or false when it is unknown to kube-aws
+func InstanceCapacityOf(instanceType string) (InstanceCapacity, bool) {
+	c, ok := instanceCapacities[instanceType]
+	return c, ok
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// InstanceScriptStorageEmbedded embeds the instance script into the EC2 user-data
+	InstanceScriptStorageEmbedded = "embedded"
+	// InstanceScriptStorageS3 uploads the instance script to S3 along with the cloud-config, leaving only a stub fetching and executing it in the EC2 user-data
+	InstanceScriptStorageS3 = "s3"
+)
+
+const (
+	// InstanceScriptCompressionNone embeds the instance script into the EC2 user-data as-is
+	InstanceScriptCompressionNone = "none"
+	// InstanceScriptCompressionGzip embeds the instance script gzipped and base64-encoded, along with a few lines decompressing and executing it
+	InstanceScriptCompressionGzip = "gzip"
+)
+
+var instanceScriptStorages = []string{InstanceScriptStorageEmbedded, InstanceScriptStorageS3}
+
+var instanceScriptCompressions = []string{InstanceScriptCompressionNone, InstanceScriptCompressionGzip}
+
+// InstanceScript is the set of settings for the "instance-script" part of userdata, which bootstraps nodes by fetching the cloud-config from S3
+type InstanceScript struct {
+	// Storage is where the instance script resides, either `embedded` or `s3`. Defaults to `embedded`.
+	// Storing it in S3 keeps the EC2 user-data far below its 16KB limit even with a large instance script customized in the cloud-config templates
+	Storage string `yaml:"storage,omitempty"`
+	// Compression is how the embedded instance script is compressed, either `none` or `gzip`. Defaults to `none`.
+	// Gzip packs a large instance script into roughly a third of its size, which has no effect when the instance script is stored in S3
+	Compression string `yaml:"compression,omitempty"`
+}
+
+func (s InstanceScript) StoredInS3() bool {
+	return s.Storage == InstanceScriptStorageS3
+}
+
+// Compressed returns true when the instance script embedded into the EC2 user-data is gzipped
+func (s InstanceScript) Compressed() bool {
+	return !s.StoredInS3() && s.Compression == InstanceScriptCompressionGzip
+}
+
+func (s InstanceScript) Validate() error {
+	if s.Storage != "" && !containsString(instanceScriptStorages, s.Storage) {
+		return fmt.Errorf("invalid `instanceScript.storage` \"%s\": it must be one of %s", s.Storage, strings.Join(instanceScriptStorages, ", "))
+	}
+	if s.Compression != "" && !containsString(instanceScriptCompressions, s.Compression) {
+		return fmt.Errorf("invalid `instanceScript.compression` \"%s\": it must be one of %s", s.Compression, strings.Join(instanceScriptCompressions, ", "))
+	}
+	return nil
+}
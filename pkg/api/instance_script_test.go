@@ -0,0 +1,73 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestInstanceScriptValidate(t *testing.T) {
+	testCases := []struct {
+		instanceScript InstanceScript
+		isValid        bool
+	}{
+		// Valid, defaults to embedded
+		{
+			instanceScript: InstanceScript{},
+			isValid:        true,
+		},
+		// Valid, embedded
+		{
+			instanceScript: InstanceScript{Storage: "embedded"},
+			isValid:        true,
+		},
+		// Valid, s3
+		{
+			instanceScript: InstanceScript{Storage: "s3"},
+			isValid:        true,
+		},
+		// Valid, gzip
+		{
+			instanceScript: InstanceScript{Compression: "gzip"},
+			isValid:        true,
+		},
+		// Invalid, unknown storage
+		{
+			instanceScript: InstanceScript{Storage: "ssm"},
+			isValid:        false,
+		},
+		// Invalid, unknown compression
+		{
+			instanceScript: InstanceScript{Compression: "bzip2"},
+			isValid:        false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.instanceScript.Validate()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.instanceScript, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.instanceScript)
+		}
+	}
+}
+
+func TestInstanceScriptCompressed(t *testing.T) {
+	testCases := []struct {
+		instanceScript InstanceScript
+		expected       bool
+	}{
+		{instanceScript: InstanceScript{}, expected: false},
+		{instanceScript: InstanceScript{Compression: "none"}, expected: false},
+		{instanceScript: InstanceScript{Compression: "gzip"}, expected: true},
+		{instanceScript: InstanceScript{Storage: "embedded", Compression: "gzip"}, expected: true},
+		// The stub fetching the instance script from S3 is small enough
+		{instanceScript: InstanceScript{Storage: "s3", Compression: "gzip"}, expected: false},
+	}
+
+	for i, testCase := range testCases {
+		if actual := testCase.instanceScript.Compressed(); actual != testCase.expected {
+			t.Errorf("case %d: expected %+v to be compressed=%v, but was %v", i, testCase.instanceScript, testCase.expected, actual)
+		}
+	}
+}
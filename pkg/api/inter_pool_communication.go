@@ -0,0 +1,157 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kubernetes-incubator/kube-aws/naming"
+)
+
+// InterPoolCommunication allows the nodes of the node pool `from`, and the pods sharing their network interfaces,
+// to reach the nodes and the pods of the node pool `to` on the ports
+type InterPoolCommunication struct {
+	From  string          `yaml:"from"`
+	To    string          `yaml:"to"`
+	Ports []InterPoolPort `yaml:"ports"`
+}
+
+type InterPoolPort struct {
+	// Protocol is either tcp or udp. Defaults to tcp
+	Protocol string `yaml:"protocol,omitempty"`
+	// Port is the first port of the range
+	Port int `yaml:"port"`
+	// ToPort is the last port of the range. Defaults to Port
+	ToPort int `yaml:"toPort,omitempty"`
+}
+
+type InterPoolCommunications []InterPoolCommunication
+
+// InterPoolIngressRule is a security group ingress rule from the security group of a node pool to the one of another
+type InterPoolIngressRule struct {
+	// LogicalName is the logical name of the rule in the network stack, e.g. SecurityGroupWorkerPool2IngressFromPool1Tcp8080
+	LogicalName string
+	// GroupLogicalName is the logical name of the security group of the destination node pool
+	GroupLogicalName string
+	// SourceGroupLogicalName is the logical name of the security group of the source node pool
+	SourceGroupLogicalName string
+	IPProtocol             string
+	FromPort               int
+	ToPort                 int
+}
+
+// InterPoolSecurityGroupLogicalName returns the logical name of the security group dedicated to the node pool in the network stack
+func InterPoolSecurityGroupLogicalName(nodePoolName string) string {
+	return "SecurityGroupWorker" + naming.FromStackToCfnResource(nodePoolName)
+}
+
+func (p InterPoolPort) IPProtocol() string {
+	if p.Protocol == "" {
+		return "tcp"
+	}
+	return p.Protocol
+}
+
+func (p InterPoolPort) LastPort() int {
+	if p.ToPort == 0 {
+		return p.Port
+	}
+	return p.ToPort
+}
+
+func (p InterPoolPort) String() string {
+	if p.LastPort() == p.Port {
+		return fmt.Sprintf("%s/%d", p.IPProtocol(), p.Port)
+	}
+	return fmt.Sprintf("%s/%d-%d", p.IPProtocol(), p.Port, p.LastPort())
+}
+
+// References returns true when the node pool is either the source or the destination of any of the communications,
+// so that it needs its dedicated security group
+func (cs InterPoolCommunications) References(nodePoolName string) bool {
+	for _, c := range cs {
+		if c.From == nodePoolName || c.To == nodePoolName {
+			return true
+		}
+	}
+	return false
+}
+
+// NodePools returns the names of the referenced node pools in the order of their appearances
+func (cs InterPoolCommunications) NodePools() []string {
+	names := []string{}
+	for _, c := range cs {
+		for _, n := range []string{c.From, c.To} {
+			if !containsString(names, n) {
+				names = append(names, n)
+			}
+		}
+	}
+	return names
+}
+
+// SecurityGroupLogicalName returns the logical name of the security group dedicated to the node pool in the network stack
+func (cs InterPoolCommunications) SecurityGroupLogicalName(nodePoolName string) string {
+	return InterPoolSecurityGroupLogicalName(nodePoolName)
+}
+
+// IngressRules returns the ingress rules, one per port range, between the security groups dedicated to the node pools
+func (cs InterPoolCommunications) IngressRules() []InterPoolIngressRule {
+	rules := []InterPoolIngressRule{}
+	for _, c := range cs {
+		to, from := InterPoolSecurityGroupLogicalName(c.To), InterPoolSecurityGroupLogicalName(c.From)
+		for _, p := range c.Ports {
+			name := fmt.Sprintf("%sIngressFrom%s%s%d", to, naming.FromStackToCfnResource(c.From), strings.Title(p.IPProtocol()), p.Port)
+			if p.LastPort() != p.Port {
+				name = fmt.Sprintf("%sTo%d", name, p.LastPort())
+			}
+			rules = append(rules, InterPoolIngressRule{
+				LogicalName:            name,
+				GroupLogicalName:       to,
+				SourceGroupLogicalName: from,
+				IPProtocol:             p.IPProtocol(),
+				FromPort:               p.Port,
+				ToPort:                 p.LastPort(),
+			})
+		}
+	}
+	return rules
+}
+
+func (cs InterPoolCommunications) Validate(nodePools []WorkerNodePool) error {
+	names := map[string]bool{}
+	for _, p := range nodePools {
+		names[p.NodePoolName] = true
+	}
+
+	for i, c := range cs {
+		for _, n := range []string{c.From, c.To} {
+			if !names[n] {
+				return fmt.Errorf("`worker.interPoolCommunication[%d]` references the node pool \"%s\" which doesn't exist", i, n)
+			}
+		}
+		if c.From == c.To {
+			return fmt.Errorf("`worker.interPoolCommunication[%d]` must be between different node pools, but both `from` and `to` were \"%s\"", i, c.From)
+		}
+		if len(c.Ports) == 0 {
+			return fmt.Errorf("`worker.interPoolCommunication[%d]` requires at least one of `ports`", i)
+		}
+		for _, p := range c.Ports {
+			if p.IPProtocol() != "tcp" && p.IPProtocol() != "udp" {
+				return fmt.Errorf("invalid protocol \"%s\" in `worker.interPoolCommunication[%d].ports`: it must be either tcp or udp", p.Protocol, i)
+			}
+			if p.Port < 1 || p.LastPort() > 65535 || p.LastPort() < p.Port {
+				return fmt.Errorf("invalid port range %s in `worker.interPoolCommunication[%d].ports`: ports must be between 1 and 65535 and `toPort` must not be less than `port`", p, i)
+			}
+		}
+	}
+
+	rules := map[string]bool{}
+	for _, r := range cs.IngressRules() {
+		if rules[r.LogicalName] {
+			return fmt.Errorf("duplicate port ranges in `worker.interPoolCommunication`: %s", r.LogicalName)
+		}
+		rules[r.LogicalName] = true
+	}
+
+	return nil
+}
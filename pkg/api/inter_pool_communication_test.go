@@ -0,0 +1,131 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInterPoolCommunicationsValidate(t *testing.T) {
+	nodePools := []WorkerNodePool{{NodePoolName: "pool1"}, {NodePoolName: "pool2"}}
+
+	testCases := []struct {
+		communications InterPoolCommunications
+		isValid        bool
+	}{
+		// Valid, none
+		{
+			communications: InterPoolCommunications{},
+			isValid:        true,
+		},
+		// Valid, a port and a port range
+		{
+			communications: InterPoolCommunications{
+				{From: "pool1", To: "pool2", Ports: []InterPoolPort{{Port: 8080}, {Protocol: "udp", Port: 30000, ToPort: 30100}}},
+			},
+			isValid: true,
+		},
+		// Valid, both directions
+		{
+			communications: InterPoolCommunications{
+				{From: "pool1", To: "pool2", Ports: []InterPoolPort{{Port: 8080}}},
+				{From: "pool2", To: "pool1", Ports: []InterPoolPort{{Port: 8080}}},
+			},
+			isValid: true,
+		},
+		// Invalid, missing node pool
+		{
+			communications: InterPoolCommunications{
+				{From: "pool1", To: "pool3", Ports: []InterPoolPort{{Port: 8080}}},
+			},
+			isValid: false,
+		},
+		// Invalid, the same node pool
+		{
+			communications: InterPoolCommunications{
+				{From: "pool1", To: "pool1", Ports: []InterPoolPort{{Port: 8080}}},
+			},
+			isValid: false,
+		},
+		// Invalid, no ports
+		{
+			communications: InterPoolCommunications{
+				{From: "pool1", To: "pool2"},
+			},
+			isValid: false,
+		},
+		// Invalid, protocol
+		{
+			communications: InterPoolCommunications{
+				{From: "pool1", To: "pool2", Ports: []InterPoolPort{{Protocol: "icmp", Port: 8}}},
+			},
+			isValid: false,
+		},
+		// Invalid, port out of range
+		{
+			communications: InterPoolCommunications{
+				{From: "pool1", To: "pool2", Ports: []InterPoolPort{{Port: 65536}}},
+			},
+			isValid: false,
+		},
+		// Invalid, reversed port range
+		{
+			communications: InterPoolCommunications{
+				{From: "pool1", To: "pool2", Ports: []InterPoolPort{{Port: 8080, ToPort: 8000}}},
+			},
+			isValid: false,
+		},
+		// Invalid, duplicate ports
+		{
+			communications: InterPoolCommunications{
+				{From: "pool1", To: "pool2", Ports: []InterPoolPort{{Port: 8080}}},
+				{From: "pool1", To: "pool2", Ports: []InterPoolPort{{Protocol: "tcp", Port: 8080}}},
+			},
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.communications.Validate(nodePools)
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.communications, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.communications)
+		}
+	}
+}
+
+func TestInterPoolCommunicationsIngressRules(t *testing.T) {
+	communications := InterPoolCommunications{
+		{From: "pool-1", To: "pool2", Ports: []InterPoolPort{{Port: 8080}, {Protocol: "udp", Port: 30000, ToPort: 30100}}},
+	}
+
+	expected := []InterPoolIngressRule{
+		{
+			LogicalName:            "SecurityGroupWorkerPool2IngressFromPool1Tcp8080",
+			GroupLogicalName:       "SecurityGroupWorkerPool2",
+			SourceGroupLogicalName: "SecurityGroupWorkerPool1",
+			IPProtocol:             "tcp",
+			FromPort:               8080,
+			ToPort:                 8080,
+		},
+		{
+			LogicalName:            "SecurityGroupWorkerPool2IngressFromPool1Udp30000To30100",
+			GroupLogicalName:       "SecurityGroupWorkerPool2",
+			SourceGroupLogicalName: "SecurityGroupWorkerPool1",
+			IPProtocol:             "udp",
+			FromPort:               30000,
+			ToPort:                 30100,
+		},
+	}
+	if actual := communications.IngressRules(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("unexpected ingress rules:\nexpected: %+v\nactual: %+v", expected, actual)
+	}
+
+	if actual := communications.NodePools(); !reflect.DeepEqual(actual, []string{"pool-1", "pool2"}) {
+		t.Errorf("unexpected node pools: %v", actual)
+	}
+	if !communications.References("pool2") || communications.References("pool3") {
+		t.Error("expected only pool-1 and pool2 to be referenced")
+	}
+}
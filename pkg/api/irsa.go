@@ -0,0 +1,102 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+)
+
+// KubernetesOIDC is the set of settings for Kubernetes acting as an OIDC identity provider for external systems
+type KubernetesOIDC struct {
+	IRSA IRSA `yaml:"irsa,omitempty"`
+}
+
+// IRSA is the shorthand for configuring `controller.apiServer.serviceAccountIssuer` for IAM roles for service accounts.
+// The issuer URL is derived from the bucket the OIDC discovery documents are published to, and the IAM OIDC provider trusting it is created in the control-plane stack
+type IRSA struct {
+	Enabled bool `yaml:"enabled"`
+	// S3Bucket is the bucket `kube-aws apply` publishes the OIDC discovery documents to under the path of the cluster name
+	S3Bucket string `yaml:"s3Bucket,omitempty"`
+	// CloudFrontDomainName is the domain name of the CloudFront distribution serving the bucket. Omit it when the documents are served from the bucket itself
+	CloudFrontDomainName string `yaml:"cloudFrontDomainName,omitempty"`
+	// PodIdentityWebhook configures the amazon-eks-pod-identity-webhook, which injects the projected service account token and
+	// the AWS environment variables into pods whose service accounts are annotated with `eks.amazonaws.com/role-arn`
+	PodIdentityWebhook PodIdentityWebhook `yaml:"podIdentityWebhook,omitempty"`
+}
+
+type PodIdentityWebhook struct {
+	// Enabled defaults to true
+	Enabled *bool `yaml:"enabled,omitempty"`
+	// TokenExpirationSeconds is the lifetime of the projected service account tokens injected into pods. Defaults to 86400
+	TokenExpirationSeconds int `yaml:"tokenExpirationSeconds,omitempty"`
+}
+
+// PodIdentityWebhookEnabled returns true when kube-aws installs the webhook for IRSA
+func (i IRSA) PodIdentityWebhookEnabled() bool {
+	return i.Enabled && (i.PodIdentityWebhook.Enabled == nil || *i.PodIdentityWebhook.Enabled)
+}
+
+func (w PodIdentityWebhook) TokenExpirationSecondsOrDefault() int {
+	if w.TokenExpirationSeconds == 0 {
+		return 86400
+	}
+	return w.TokenExpirationSeconds
+}
+
+// issuer returns the service account issuer publishing its discovery documents to the bucket under the path of the cluster name
+func (i IRSA) issuer(region Region, clusterName string) ServiceAccountIssuer {
+	host := i.CloudFrontDomainName
+	if host == "" {
+		host = fmt.Sprintf("%s.s3.%s.%s", i.S3Bucket, region.Name, region.PublicDomainName())
+	}
+	return ServiceAccountIssuer{
+		URL: fmt.Sprintf("https://%s/%s", host, clusterName),
+		Discovery: ServiceAccountIssuerDiscovery{
+			S3Bucket:             i.S3Bucket,
+			CloudFrontDomainName: i.CloudFrontDomainName,
+		},
+	}
+}
+
+func (i IRSA) Validate() error {
+	if !i.Enabled {
+		if i.S3Bucket != "" || i.CloudFrontDomainName != "" {
+			return errors.New("`kubernetes.oidc.irsa.enabled` must be true to publish the discovery documents to `s3Bucket`")
+		}
+		return nil
+	}
+	if i.S3Bucket == "" {
+		return errors.New("`kubernetes.oidc.irsa.s3Bucket` must be specified to publish the OIDC discovery documents of the service account issuer")
+	}
+	if i.PodIdentityWebhook.TokenExpirationSeconds != 0 && i.PodIdentityWebhook.TokenExpirationSeconds < 600 {
+		return fmt.Errorf("`kubernetes.oidc.irsa.podIdentityWebhook.tokenExpirationSeconds` must be 600 or greater, but was %d", i.PodIdentityWebhook.TokenExpirationSeconds)
+	}
+	return nil
+}
+
+// consumeIRSA configures the service account issuer according to `kubernetes.oidc.irsa`,
+// so that the rest of kube-aws only has to look at `controller.apiServer.serviceAccountIssuer`
+func (c *Cluster) consumeIRSA() error {
+	irsa := c.Kubernetes.OIDC.IRSA
+	if err := irsa.Validate(); err != nil {
+		return err
+	}
+	if !irsa.Enabled {
+		return nil
+	}
+
+	issuer := &c.Controller.APIServer.ServiceAccountIssuer
+	if issuer.URL != "" || issuer.DiscoveryEnabled() || issuer.Discovery.CloudFrontDomainName != "" {
+		return errors.New("`kubernetes.oidc.irsa` derives `controller.apiServer.serviceAccountIssuer.url` and `discovery` from `s3Bucket` and `cloudFrontDomainName`, " +
+			"so they can't be specified together. Either remove them or configure the issuer without `kubernetes.oidc.irsa`")
+	}
+	derived := irsa.issuer(c.Region, c.ClusterName)
+	issuer.URL = derived.URL
+	issuer.Discovery = derived.Discovery
+	issuer.OIDCProvider.Create = true
+	return nil
+}
+
+// PodIdentityWebhookTokenAudience is the audience of the service account tokens injected by the webhook, which must be accepted by the IAM OIDC provider
+func (c Cluster) PodIdentityWebhookTokenAudience() string {
+	return c.Controller.APIServer.ServiceAccountIssuer.OIDCProvider.ClientIDsOrDefault()[0]
+}
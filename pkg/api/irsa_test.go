@@ -0,0 +1,100 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestConsumeIRSA(t *testing.T) {
+	region := RegionForName("us-west-1")
+	disabled := false
+
+	testCases := []struct {
+		irsa           IRSA
+		issuer         ServiceAccountIssuer
+		isValid        bool
+		expectedURL    string
+		expectedBucket string
+		webhook        bool
+	}{
+		// Valid, not configured
+		{
+			isValid: true,
+		},
+		// Valid, documents served from the bucket
+		{
+			irsa:           IRSA{Enabled: true, S3Bucket: "my-bucket"},
+			isValid:        true,
+			expectedURL:    "https://my-bucket.s3.us-west-1.amazonaws.com/my-cluster",
+			expectedBucket: "my-bucket",
+			webhook:        true,
+		},
+		// Valid, documents served via CloudFront without the webhook
+		{
+			irsa:           IRSA{Enabled: true, S3Bucket: "my-bucket", CloudFrontDomainName: "d111111abcdef8.cloudfront.net", PodIdentityWebhook: PodIdentityWebhook{Enabled: &disabled}},
+			isValid:        true,
+			expectedURL:    "https://d111111abcdef8.cloudfront.net/my-cluster",
+			expectedBucket: "my-bucket",
+		},
+		// Valid, audiences of the issuer customized
+		{
+			irsa:           IRSA{Enabled: true, S3Bucket: "my-bucket"},
+			issuer:         ServiceAccountIssuer{APIAudiences: []string{"https://my-bucket.s3.us-west-1.amazonaws.com/my-cluster", "vault"}},
+			isValid:        true,
+			expectedURL:    "https://my-bucket.s3.us-west-1.amazonaws.com/my-cluster",
+			expectedBucket: "my-bucket",
+			webhook:        true,
+		},
+		// Invalid, missing bucket
+		{
+			irsa:    IRSA{Enabled: true},
+			isValid: false,
+		},
+		// Invalid, bucket without enabled
+		{
+			irsa:    IRSA{S3Bucket: "my-bucket"},
+			isValid: false,
+		},
+		// Invalid, issuer url specified too
+		{
+			irsa:    IRSA{Enabled: true, S3Bucket: "my-bucket"},
+			issuer:  ServiceAccountIssuer{URL: "https://oidc.example.com"},
+			isValid: false,
+		},
+		// Invalid, too short token expiration
+		{
+			irsa:    IRSA{Enabled: true, S3Bucket: "my-bucket", PodIdentityWebhook: PodIdentityWebhook{TokenExpirationSeconds: 60}},
+			isValid: false,
+		},
+	}
+
+	for i, tc := range testCases {
+		c := Cluster{}
+		c.ClusterName = "my-cluster"
+		c.Region = region
+		c.Kubernetes.OIDC.IRSA = tc.irsa
+		c.Controller.APIServer.ServiceAccountIssuer = tc.issuer
+
+		err := c.consumeIRSA()
+		if tc.isValid && err != nil {
+			t.Errorf("case %d: expected valid, but got error: %v", i, err)
+			continue
+		}
+		if !tc.isValid {
+			if err == nil {
+				t.Errorf("case %d: expected invalid, but got no error", i)
+			}
+			continue
+		}
+
+		issuer := c.Controller.APIServer.ServiceAccountIssuer
+		if issuer.URL != tc.expectedURL || issuer.Discovery.S3Bucket != tc.expectedBucket || issuer.OIDCProvider.Create != tc.irsa.Enabled {
+			t.Errorf("case %d: expected the issuer %s published to %s with the OIDC provider created=%v, but got: %+v", i, tc.expectedURL, tc.expectedBucket, tc.irsa.Enabled, issuer)
+		}
+		if err := issuer.Validate(region); err != nil {
+			t.Errorf("case %d: expected the derived issuer to be valid, but got error: %v", i, err)
+		}
+		if c.Kubernetes.OIDC.IRSA.PodIdentityWebhookEnabled() != tc.webhook {
+			t.Errorf("case %d: expected the pod identity webhook enabled=%v", i, tc.webhook)
+		}
+	}
+}
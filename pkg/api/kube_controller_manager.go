@@ -0,0 +1,60 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// KubeControllerManager is the set of settings for kube-controller-manager running on controller nodes
+type KubeControllerManager struct {
+	// The numbers of objects allowed to sync concurrently per controller.
+	// 0 means the controller-manager's default. Increase these in large clusters so that the controllers keep up with changes,
+	// at the cost of more load on the apiserver and more CPU and memory consumed by the controller-manager.
+	ConcurrentDeploymentSyncs          int `yaml:"concurrentDeploymentSyncs,omitempty"`
+	ConcurrentReplicaSetSyncs          int `yaml:"concurrentReplicaSetSyncs,omitempty"`
+	ConcurrentServiceSyncs             int `yaml:"concurrentServiceSyncs,omitempty"`
+	ConcurrentEndpointSyncs            int `yaml:"concurrentEndpointSyncs,omitempty"`
+	ConcurrentNamespaceSyncs           int `yaml:"concurrentNamespaceSyncs,omitempty"`
+	ConcurrentGCSyncs                  int `yaml:"concurrentGCSyncs,omitempty"`
+	ConcurrentResourceQuotaSyncs       int `yaml:"concurrentResourceQuotaSyncs,omitempty"`
+	ConcurrentServiceAccountTokenSyncs int `yaml:"concurrentServiceAccountTokenSyncs,omitempty"`
+}
+
+type concurrentSyncsSetting struct {
+	key   string
+	flag  string
+	value int
+}
+
+func (m KubeControllerManager) concurrentSyncsSettings() []concurrentSyncsSetting {
+	return []concurrentSyncsSetting{
+		{"concurrentDeploymentSyncs", "concurrent-deployment-syncs", m.ConcurrentDeploymentSyncs},
+		{"concurrentReplicaSetSyncs", "concurrent-replicaset-syncs", m.ConcurrentReplicaSetSyncs},
+		{"concurrentServiceSyncs", "concurrent-service-syncs", m.ConcurrentServiceSyncs},
+		{"concurrentEndpointSyncs", "concurrent-endpoint-syncs", m.ConcurrentEndpointSyncs},
+		{"concurrentNamespaceSyncs", "concurrent-namespace-syncs", m.ConcurrentNamespaceSyncs},
+		{"concurrentGCSyncs", "concurrent-gc-syncs", m.ConcurrentGCSyncs},
+		{"concurrentResourceQuotaSyncs", "concurrent-resource-quota-syncs", m.ConcurrentResourceQuotaSyncs},
+		{"concurrentServiceAccountTokenSyncs", "concurrent-serviceaccount-token-syncs", m.ConcurrentServiceAccountTokenSyncs},
+	}
+}
+
+// Flags returns the command-line flags for the concurrent syncs set explicitly, in a stable order
+func (m KubeControllerManager) Flags() CommandLineFlags {
+	flags := CommandLineFlags{}
+	for _, s := range m.concurrentSyncsSettings() {
+		if s.value != 0 {
+			flags = append(flags, CommandLineFlag{Name: s.flag, Value: strconv.Itoa(s.value)})
+		}
+	}
+	return flags
+}
+
+func (m KubeControllerManager) Validate() error {
+	for _, s := range m.concurrentSyncsSettings() {
+		if s.value < 0 {
+			return fmt.Errorf("`controller.kubeControllerManager.%s` must be a positive integer, but was %d", s.key, s.value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKubeControllerManagerValidate(t *testing.T) {
+	testCases := []struct {
+		controllerManager KubeControllerManager
+		isValid           bool
+	}{
+		// Valid, not configured
+		{
+			controllerManager: KubeControllerManager{},
+			isValid:           true,
+		},
+		// Valid, positive
+		{
+			controllerManager: KubeControllerManager{ConcurrentDeploymentSyncs: 20, ConcurrentGCSyncs: 40},
+			isValid:           true,
+		},
+		// Invalid, negative
+		{
+			controllerManager: KubeControllerManager{ConcurrentEndpointSyncs: -1},
+			isValid:           false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.controllerManager.Validate()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.controllerManager, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.controllerManager)
+		}
+	}
+}
+
+func TestKubeControllerManagerFlags(t *testing.T) {
+	m := KubeControllerManager{ConcurrentServiceSyncs: 5, ConcurrentDeploymentSyncs: 20}
+	expected := CommandLineFlags{
+		{Name: "concurrent-deployment-syncs", Value: "20"},
+		{Name: "concurrent-service-syncs", Value: "5"},
+	}
+	if actual := m.Flags(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v but was %+v", expected, actual)
+	}
+}
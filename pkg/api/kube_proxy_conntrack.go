@@ -0,0 +1,91 @@
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+// The defaults of kube-proxy's `--conntrack-max-per-core` and `--conntrack-min`
+const (
+	defaultConntrackMaxPerCore = 32768
+	defaultConntrackMin        = 131072
+)
+
+// KubeProxyConntrack is the set of settings for the conntrack table kube-proxy tunes on every node.
+// The same values are set as sysctls while bootstrapping nodes so that the table is large enough even before kube-proxy starts
+type KubeProxyConntrack struct {
+	// MaxPerCore is the maximum number of NAT connections to track per CPU core. Defaults to kube-proxy's default, which is 32768
+	MaxPerCore int `yaml:"maxPerCore,omitempty"`
+	// Min is the minimum number of conntrack entries to allocate regardless of MaxPerCore. Defaults to kube-proxy's default, which is 131072
+	Min int `yaml:"min,omitempty"`
+	// TCPEstablishedTimeout is how long an idle TCP connection is kept like `24h`. Defaults to kube-proxy's default, which is 24h
+	TCPEstablishedTimeout string `yaml:"tcpEstablishedTimeout,omitempty"`
+	// TCPCloseWaitTimeout is how long a connection in the CLOSE_WAIT state is kept like `1h`. Defaults to kube-proxy's default, which is 1h
+	TCPCloseWaitTimeout string `yaml:"tcpCloseWaitTimeout,omitempty"`
+}
+
+func (c KubeProxyConntrack) Enabled() bool {
+	return c.MaxPerCore != 0 || c.Min != 0 || c.TCPEstablishedTimeout != "" || c.TCPCloseWaitTimeout != ""
+}
+
+// MaxPerCoreOrDefault and MinOrDefault return the values kube-proxy sizes the conntrack table with
+func (c KubeProxyConntrack) MaxPerCoreOrDefault() int {
+	if c.MaxPerCore == 0 {
+		return defaultConntrackMaxPerCore
+	}
+	return c.MaxPerCore
+}
+
+func (c KubeProxyConntrack) MinOrDefault() int {
+	if c.Min == 0 {
+		return defaultConntrackMin
+	}
+	return c.Min
+}
+
+// TCPEstablishedTimeoutSeconds returns the timeout for the `net.netfilter.nf_conntrack_tcp_timeout_established` sysctl
+func (c KubeProxyConntrack) TCPEstablishedTimeoutSeconds() int {
+	return durationSeconds(c.TCPEstablishedTimeout)
+}
+
+// TCPCloseWaitTimeoutSeconds returns the timeout for the `net.netfilter.nf_conntrack_tcp_timeout_close_wait` sysctl
+func (c KubeProxyConntrack) TCPCloseWaitTimeoutSeconds() int {
+	return durationSeconds(c.TCPCloseWaitTimeout)
+}
+
+func durationSeconds(s string) int {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0
+	}
+	return int(d.Seconds())
+}
+
+func (c KubeProxyConntrack) Validate() error {
+	if c.MaxPerCore < 0 {
+		return fmt.Errorf("`kubeProxy.conntrack.maxPerCore` must be a positive integer, but was %d", c.MaxPerCore)
+	}
+	if c.Min < 0 {
+		return fmt.Errorf("`kubeProxy.conntrack.min` must be a positive integer, but was %d", c.Min)
+	}
+	timeouts := []struct {
+		key   string
+		value string
+	}{
+		{"tcpEstablishedTimeout", c.TCPEstablishedTimeout},
+		{"tcpCloseWaitTimeout", c.TCPCloseWaitTimeout},
+	}
+	for _, t := range timeouts {
+		if t.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(t.value)
+		if err != nil {
+			return fmt.Errorf("invalid `kubeProxy.conntrack.%s` \"%s\": %v", t.key, t.value, err)
+		}
+		if d < time.Second {
+			return fmt.Errorf("`kubeProxy.conntrack.%s` must be a positive duration of 1s or greater like 1h, but was \"%s\"", t.key, t.value)
+		}
+	}
+	return nil
+}
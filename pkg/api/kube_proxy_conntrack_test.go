@@ -0,0 +1,71 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestKubeProxyConntrackValidate(t *testing.T) {
+	testCases := []struct {
+		conntrack KubeProxyConntrack
+		isValid   bool
+	}{
+		// Valid, not configured
+		{
+			conntrack: KubeProxyConntrack{},
+			isValid:   true,
+		},
+		// Valid, fully configured
+		{
+			conntrack: KubeProxyConntrack{
+				MaxPerCore:            65536,
+				Min:                   262144,
+				TCPEstablishedTimeout: "1h",
+				TCPCloseWaitTimeout:   "10m",
+			},
+			isValid: true,
+		},
+		// Invalid, negative max per core
+		{
+			conntrack: KubeProxyConntrack{MaxPerCore: -1},
+			isValid:   false,
+		},
+		// Invalid, negative min
+		{
+			conntrack: KubeProxyConntrack{Min: -1},
+			isValid:   false,
+		},
+		// Invalid, malformed timeout
+		{
+			conntrack: KubeProxyConntrack{TCPEstablishedTimeout: "1 hour"},
+			isValid:   false,
+		},
+		// Invalid, zero timeout
+		{
+			conntrack: KubeProxyConntrack{TCPCloseWaitTimeout: "0s"},
+			isValid:   false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.conntrack.Validate()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.conntrack, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.conntrack)
+		}
+	}
+}
+
+func TestKubeProxyConntrackDefaults(t *testing.T) {
+	c := KubeProxyConntrack{Min: 262144, TCPEstablishedTimeout: "1h"}
+	if v := c.MaxPerCoreOrDefault(); v != 32768 {
+		t.Errorf("unexpected max per core: %d", v)
+	}
+	if v := c.MinOrDefault(); v != 262144 {
+		t.Errorf("unexpected min: %d", v)
+	}
+	if v := c.TCPEstablishedTimeoutSeconds(); v != 3600 {
+		t.Errorf("unexpected tcp established timeout: %d", v)
+	}
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"fmt"
+)
+
+// KubeScheduler is the set of settings for kube-scheduler running on controller nodes
+type KubeScheduler struct {
+	// PercentageOfNodesToScore is the percentage of all the nodes that the scheduler stops searching for feasible nodes at, once found.
+	// 0 means the scheduler's default, which is adaptive to the size of the cluster.
+	// Lowering this speeds up scheduling in large clusters at the cost of less optimal placements.
+	PercentageOfNodesToScore *int `yaml:"percentageOfNodesToScore,omitempty"`
+	// Resources are the compute resources of the kube-scheduler static pod. Defaults to the request of 100m cpu
+	Resources ComputeResources `yaml:"resources,omitempty"`
+}
+
+// ConfigFileEnabled returns true when kube-scheduler should be configured via a KubeSchedulerConfiguration passed to `--config`
+func (s KubeScheduler) ConfigFileEnabled() bool {
+	return s.PercentageOfNodesToScore != nil
+}
+
+// APIVersion returns the apiVersion of KubeSchedulerConfiguration supported by the specified version of Kubernetes
+func (s KubeScheduler) APIVersion(k8sVer string) (string, error) {
+	versions := []struct {
+		constraint string
+		apiVersion string
+	}{
+		{">= 1.25", "kubescheduler.config.k8s.io/v1"},
+		{">= 1.22", "kubescheduler.config.k8s.io/v1beta2"},
+		{">= 1.19", "kubescheduler.config.k8s.io/v1beta1"},
+		{">= 1.18", "kubescheduler.config.k8s.io/v1alpha2"},
+	}
+	for _, v := range versions {
+		ok, err := k8sVersionSatisfies(v.constraint, k8sVer)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return v.apiVersion, nil
+		}
+	}
+	return "kubescheduler.config.k8s.io/v1alpha1", nil
+}
+
+func (s KubeScheduler) Validate(k8sVer string) error {
+	if !s.ConfigFileEnabled() {
+		return nil
+	}
+
+	supported, err := k8sVersionSatisfies(">= 1.12", k8sVer)
+	if err != nil {
+		return err
+	}
+	if !supported {
+		return fmt.Errorf("`controller.kubeScheduler.percentageOfNodesToScore` requires kubernetesVersion 1.12 or greater, but was %s", k8sVer)
+	}
+
+	if p := *s.PercentageOfNodesToScore; p < 0 || p > 100 {
+		return fmt.Errorf("`controller.kubeScheduler.percentageOfNodesToScore` must be between 0 and 100, but was %d", p)
+	}
+
+	return nil
+}
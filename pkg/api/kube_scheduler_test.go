@@ -0,0 +1,86 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestKubeSchedulerValidate(t *testing.T) {
+	percentage := func(p int) *int {
+		return &p
+	}
+
+	testCases := []struct {
+		scheduler KubeScheduler
+		k8sVer    string
+		isValid   bool
+	}{
+		// Valid, not configured
+		{
+			scheduler: KubeScheduler{},
+			k8sVer:    "v1.11.3",
+			isValid:   true,
+		},
+		// Valid, the scheduler's default
+		{
+			scheduler: KubeScheduler{PercentageOfNodesToScore: percentage(0)},
+			k8sVer:    "v1.12.0",
+			isValid:   true,
+		},
+		// Valid, upper bound
+		{
+			scheduler: KubeScheduler{PercentageOfNodesToScore: percentage(100)},
+			k8sVer:    "v1.20.2",
+			isValid:   true,
+		},
+		// Invalid, negative
+		{
+			scheduler: KubeScheduler{PercentageOfNodesToScore: percentage(-1)},
+			k8sVer:    "v1.20.2",
+			isValid:   false,
+		},
+		// Invalid, more than 100
+		{
+			scheduler: KubeScheduler{PercentageOfNodesToScore: percentage(101)},
+			k8sVer:    "v1.20.2",
+			isValid:   false,
+		},
+		// Invalid, unsupported kubernetes version
+		{
+			scheduler: KubeScheduler{PercentageOfNodesToScore: percentage(50)},
+			k8sVer:    "v1.11.3",
+			isValid:   false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.scheduler.Validate(testCase.k8sVer)
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid for %s but got an error: %v", i, testCase.scheduler, testCase.k8sVer, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid for %s but it wasn't", i, testCase.scheduler, testCase.k8sVer)
+		}
+	}
+}
+
+func TestKubeSchedulerAPIVersion(t *testing.T) {
+	testCases := map[string]string{
+		"v1.12.0": "kubescheduler.config.k8s.io/v1alpha1",
+		"v1.17.9": "kubescheduler.config.k8s.io/v1alpha1",
+		"v1.18.0": "kubescheduler.config.k8s.io/v1alpha2",
+		"v1.20.2": "kubescheduler.config.k8s.io/v1beta1",
+		"v1.22.1": "kubescheduler.config.k8s.io/v1beta2",
+		"v1.25.0": "kubescheduler.config.k8s.io/v1",
+	}
+
+	for k8sVer, expected := range testCases {
+		actual, err := KubeScheduler{}.APIVersion(k8sVer)
+		if err != nil {
+			t.Errorf("unexpected error for %s: %v", k8sVer, err)
+			continue
+		}
+		if actual != expected {
+			t.Errorf("unexpected apiVersion for %s: expected=%s, actual=%s", k8sVer, expected, actual)
+		}
+	}
+}
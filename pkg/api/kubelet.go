@@ -0,0 +1,211 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	NodeAllocatableEnforcementPods           = "pods"
+	NodeAllocatableEnforcementSystemReserved = "system-reserved"
+	NodeAllocatableEnforcementKubeReserved   = "kube-reserved"
+)
+
+// kubelet refuses to start unless the reserved cgroups exist in all these cgroup subsystems
+var kubeletCgroupSubsystems = []string{"cpu", "cpuacct", "cpuset", "hugetlb", "memory", "pids", "systemd"}
+
+var cgroupPathRegexp = regexp.MustCompile(`^(/[a-zA-Z0-9_.@:-]+)+$`)
+
+// HasResourceReservations returns true when any compute resource reservation or node allocatable enforcement is configured
+func (k Kubelet) HasResourceReservations() bool {
+	return !k.SystemReservedResources.IsEmpty() || !k.KubeReservedResources.IsEmpty() || len(k.EnforceNodeAllocatable) > 0
+}
+
+// MergeResourceReservationsIfEmpty inherits the compute resource reservations and the node allocatable enforcement from the other
+// unless any of them is configured for this kubelet
+func (k *Kubelet) MergeResourceReservationsIfEmpty(other Kubelet) {
+	if k.HasResourceReservations() {
+		return
+	}
+	k.SystemReservedResources = other.SystemReservedResources
+	k.KubeReservedResources = other.KubeReservedResources
+	k.EnforceNodeAllocatable = other.EnforceNodeAllocatable
+	k.SystemReservedCgroup = other.SystemReservedCgroup
+	k.KubeReservedCgroup = other.KubeReservedCgroup
+}
+
+// MergeConfigFileIfEmpty inherits the config file settings from the other unless the config file is enabled for this kubelet
+func (k *Kubelet) MergeConfigFileIfEmpty(other Kubelet) {
+	if k.ConfigFile.Enabled {
+		return
+	}
+	k.ConfigFile = other.ConfigFile
+}
+
+// MergeImagePullSettingsIfEmpty inherits the image pull settings from the other unless any of them is configured for this kubelet
+func (k *Kubelet) MergeImagePullSettingsIfEmpty(other Kubelet) {
+	if k.SerializeImagePulls != nil || k.MaxParallelImagePulls != 0 {
+		return
+	}
+	k.SerializeImagePulls = other.SerializeImagePulls
+	k.MaxParallelImagePulls = other.MaxParallelImagePulls
+}
+
+// MergeEvictionSettingsIfEmpty inherits the eviction settings from the other unless any of them is configured for this kubelet
+func (k *Kubelet) MergeEvictionSettingsIfEmpty(other Kubelet) {
+	if k.EvictionMaxPodGracePeriod != nil || k.EvictionPressureTransitionPeriod != "" {
+		return
+	}
+	k.EvictionMaxPodGracePeriod = other.EvictionMaxPodGracePeriod
+	k.EvictionPressureTransitionPeriod = other.EvictionPressureTransitionPeriod
+}
+
+// ImagePullsSerialized returns true unless `kubelet.serializeImagePulls` is set to false
+func (k Kubelet) ImagePullsSerialized() bool {
+	return k.SerializeImagePulls == nil || *k.SerializeImagePulls
+}
+
+// EnforceNodeAllocatableString returns the value of kubelet's `--enforce-node-allocatable`
+func (k Kubelet) EnforceNodeAllocatableString() string {
+	return strings.Join(k.EnforceNodeAllocatable, ",")
+}
+
+// ReservedCgroups returns the cgroups specified via `kubelet.systemReservedCgroup` and `kubelet.kubeReservedCgroup`
+func (k Kubelet) ReservedCgroups() []string {
+	cgroups := []string{}
+	for _, c := range []string{k.SystemReservedCgroup, k.KubeReservedCgroup} {
+		if c != "" && !containsString(cgroups, c) {
+			cgroups = append(cgroups, c)
+		}
+	}
+	return cgroups
+}
+
+// CgroupSubsystems returns the cgroup subsystems each of the reserved cgroups is created in while bootstrapping nodes
+func (k Kubelet) CgroupSubsystems() []string {
+	return kubeletCgroupSubsystems
+}
+
+func (k Kubelet) enforces(enforcement string) bool {
+	for _, e := range k.EnforceNodeAllocatable {
+		if e == enforcement {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateResourceReservations validates the compute resource reservations against the instance type and the size of the root volume in GiB
+func (k Kubelet) ValidateResourceReservations(instanceType string, rootVolumeSize int) error {
+	if err := k.SystemReservedResources.Validate("kubelet.systemReserved"); err != nil {
+		return err
+	}
+	if err := k.KubeReservedResources.Validate("kubelet.kubeReserved"); err != nil {
+		return err
+	}
+
+	for _, e := range k.EnforceNodeAllocatable {
+		switch e {
+		case NodeAllocatableEnforcementPods, NodeAllocatableEnforcementSystemReserved, NodeAllocatableEnforcementKubeReserved:
+		default:
+			return fmt.Errorf("`kubelet.enforceNodeAllocatable` contains an unsupported value \"%s\": it must be one of \"%s\", \"%s\" and \"%s\"",
+				e, NodeAllocatableEnforcementPods, NodeAllocatableEnforcementSystemReserved, NodeAllocatableEnforcementKubeReserved)
+		}
+	}
+	if err := validateCgroupPath("kubelet.systemReservedCgroup", k.SystemReservedCgroup); err != nil {
+		return err
+	}
+	if err := validateCgroupPath("kubelet.kubeReservedCgroup", k.KubeReservedCgroup); err != nil {
+		return err
+	}
+	if k.enforces(NodeAllocatableEnforcementSystemReserved) {
+		if k.SystemReservedResources.IsEmpty() {
+			return fmt.Errorf("`kubelet.systemReserved` must be specified to enforce \"%s\" in `kubelet.enforceNodeAllocatable`", NodeAllocatableEnforcementSystemReserved)
+		}
+		if k.SystemReservedCgroup == "" {
+			return fmt.Errorf("`kubelet.systemReservedCgroup` must be specified to enforce \"%s\" in `kubelet.enforceNodeAllocatable`", NodeAllocatableEnforcementSystemReserved)
+		}
+	}
+	if k.enforces(NodeAllocatableEnforcementKubeReserved) {
+		if k.KubeReservedResources.IsEmpty() {
+			return fmt.Errorf("`kubelet.kubeReserved` must be specified to enforce \"%s\" in `kubelet.enforceNodeAllocatable`", NodeAllocatableEnforcementKubeReserved)
+		}
+		if k.KubeReservedCgroup == "" {
+			return fmt.Errorf("`kubelet.kubeReservedCgroup` must be specified to enforce \"%s\" in `kubelet.enforceNodeAllocatable`", NodeAllocatableEnforcementKubeReserved)
+		}
+	}
+
+	reservedCPU := k.SystemReservedResources.quantity("cpu") + k.KubeReservedResources.quantity("cpu")
+	reservedMemory := k.SystemReservedResources.quantity("memory") + k.KubeReservedResources.quantity("memory")
+	reservedStorage := k.SystemReservedResources.quantity("ephemeral-storage") + k.KubeReservedResources.quantity("ephemeral-storage")
+
+	if capacity, ok := InstanceCapacityOf(instanceType); ok {
+		if reservedCPU >= float64(capacity.VCPUs) {
+			return fmt.Errorf("the total cpu reserved by `kubelet.systemReserved` and `kubelet.kubeReserved` (%g) must be less than %d vCPUs of the instance type %s", reservedCPU, capacity.VCPUs, instanceType)
+		}
+		if reservedMemory >= float64(capacity.MemoryMiB)*(1<<20) {
+			return fmt.Errorf("the total memory reserved by `kubelet.systemReserved` and `kubelet.kubeReserved` (%g bytes) must be less than %dMi of memory of the instance type %s", reservedMemory, capacity.MemoryMiB, instanceType)
+		}
+	}
+	if rootVolumeSize > 0 && reservedStorage >= float64(rootVolumeSize)*(1<<30) {
+		return fmt.Errorf("the total ephemeral-storage reserved by `kubelet.systemReserved` and `kubelet.kubeReserved` (%g bytes) must be less than the root volume size of %dGi", reservedStorage, rootVolumeSize)
+	}
+
+	return nil
+}
+
+// ValidateImagePulls validates the image pull settings against the kubernetes version
+func (k Kubelet) ValidateImagePulls(k8sVer string) error {
+	if k.MaxParallelImagePulls == 0 {
+		return nil
+	}
+	if k.MaxParallelImagePulls < 0 {
+		return fmt.Errorf("`kubelet.maxParallelImagePulls` must be a positive integer, but was %d", k.MaxParallelImagePulls)
+	}
+	if k.ImagePullsSerialized() {
+		return errors.New("`kubelet.maxParallelImagePulls` can be specified only when `kubelet.serializeImagePulls` is false")
+	}
+	// kubelet has no command-line flag for the setting
+	if !k.ConfigFile.Enabled {
+		return errors.New("`kubelet.maxParallelImagePulls` requires `kubelet.configFile.enabled` to be true")
+	}
+	supported, err := k8sVersionSatisfies(">= 1.27", k8sVer)
+	if err != nil {
+		return err
+	}
+	if !supported {
+		return fmt.Errorf("`kubelet.maxParallelImagePulls` requires kubernetesVersion 1.27 or greater, but was %s", k8sVer)
+	}
+	return nil
+}
+
+// ValidateEviction validates the eviction settings
+func (k Kubelet) ValidateEviction() error {
+	if k.EvictionMaxPodGracePeriod != nil && *k.EvictionMaxPodGracePeriod < 0 {
+		return fmt.Errorf("`kubelet.evictionMaxPodGracePeriod` must be a non-negative integer, but was %d", *k.EvictionMaxPodGracePeriod)
+	}
+	if k.EvictionPressureTransitionPeriod != "" {
+		d, err := time.ParseDuration(k.EvictionPressureTransitionPeriod)
+		if err != nil {
+			return fmt.Errorf("invalid `kubelet.evictionPressureTransitionPeriod` \"%s\": %v", k.EvictionPressureTransitionPeriod, err)
+		}
+		if d < 0 {
+			return fmt.Errorf("`kubelet.evictionPressureTransitionPeriod` must not be negative, but was %s", k.EvictionPressureTransitionPeriod)
+		}
+	}
+	return nil
+}
+
+func validateCgroupPath(name, cgroup string) error {
+	if cgroup == "" {
+		return nil
+	}
+	if !cgroupPathRegexp.MatchString(cgroup) || path.Clean(cgroup) != cgroup {
+		return fmt.Errorf("invalid `%s` \"%s\": it must be an absolute cgroup path like /system.slice", name, cgroup)
+	}
+	return nil
+}
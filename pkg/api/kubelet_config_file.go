@@ -0,0 +1,235 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-yaml/yaml"
+)
+
+const (
+	kubeletConfigAPIVersion = "kubelet.config.k8s.io/v1beta1"
+	kubeletConfigKind       = "KubeletConfiguration"
+)
+
+// See https://github.com/kubernetes/kubelet/blob/master/config/v1beta1/types.go
+var knownKubeletConfigFields = []string{
+	"address", "allowedUnsafeSysctls", "authentication", "authorization",
+	"cgroupDriver", "cgroupRoot", "cgroupsPerQOS", "clusterDNS", "clusterDomain",
+	"configMapAndSecretChangeDetectionStrategy", "containerLogMaxFiles", "containerLogMaxSize",
+	"containerRuntimeEndpoint", "contentType", "cpuCFSQuota", "cpuCFSQuotaPeriod",
+	"cpuManagerPolicy", "cpuManagerPolicyOptions", "cpuManagerReconcilePeriod",
+	"enableContentionProfiling", "enableControllerAttachDetach", "enableDebugFlagsHandler",
+	"enableDebuggingHandlers", "enableProfilingHandler", "enableServer", "enableSystemLogHandler",
+	"enforceNodeAllocatable", "eventBurst", "eventRecordQPS",
+	"evictionHard", "evictionMaxPodGracePeriod", "evictionMinimumReclaim",
+	"evictionPressureTransitionPeriod", "evictionSoft", "evictionSoftGracePeriod",
+	"failSwapOn", "featureGates", "fileCheckFrequency", "hairpinMode",
+	"healthzBindAddress", "healthzPort", "httpCheckFrequency",
+	"imageGCHighThresholdPercent", "imageGCLowThresholdPercent", "imageMinimumGCAge", "imageServiceEndpoint",
+	"iptablesDropBit", "iptablesMasqueradeBit", "kernelMemcgNotification",
+	"kubeAPIBurst", "kubeAPIQPS", "kubeReserved", "kubeReservedCgroup", "kubeletCgroups",
+	"localStorageCapacityIsolation", "logging", "makeIPTablesUtilChains",
+	"maxOpenFiles", "maxParallelImagePulls", "maxPods", "memoryManagerPolicy", "memorySwap", "memoryThrottlingFactor",
+	"nodeLeaseDurationSeconds", "nodeStatusMaxImages", "nodeStatusReportFrequency", "nodeStatusUpdateFrequency",
+	"oomScoreAdj", "podCIDR", "podPidsLimit", "podsPerCore", "port", "protectKernelDefaults", "providerID",
+	"qosReserved", "readOnlyPort", "registerNode", "registerWithTaints", "registryBurst", "registryPullQPS",
+	"reservedMemory", "reservedSystemCPUs", "resolvConf", "rotateCertificates", "runOnce", "runtimeRequestTimeout",
+	"seccompDefault", "serializeImagePulls", "serverTLSBootstrap", "showHiddenMetricsForVersion",
+	"shutdownGracePeriod", "shutdownGracePeriodByPodPriority", "shutdownGracePeriodCriticalPods",
+	"staticPodPath", "staticPodURL", "staticPodURLHeader", "streamingConnectionIdleTimeout", "syncFrequency",
+	"systemCgroups", "systemReserved", "systemReservedCgroup",
+	"tlsCertFile", "tlsCipherSuites", "tlsMinVersion", "tlsPrivateKeyFile",
+	"topologyManagerPolicy", "topologyManagerPolicyOptions", "topologyManagerScope", "tracing",
+	"volumePluginDir", "volumeStatsAggPeriod",
+}
+
+// KubeletConfigFile is the set of settings to configure kubelet via a KubeletConfiguration file passed to `--config`
+// rather than via command-line flags, many of which are deprecated in favor of the file
+type KubeletConfigFile struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// Overrides is the set of KubeletConfiguration fields deep-merged over the ones kube-aws generates
+	Overrides map[string]interface{} `yaml:"overrides,omitempty"`
+}
+
+// KubeletConfigDefaults is the set of kubelet settings kube-aws passes via command-line flags when the config file is disabled
+type KubeletConfigDefaults struct {
+	StaticPodPath string
+	ClusterDomain string
+	// ClusterDNS is omitted when it is determined on the node at runtime. e.g. when the node-local DNS resolver is enabled
+	ClusterDNS                string
+	ResolvConf                string
+	FeatureGates              FeatureGates
+	NodeStatusUpdateFrequency string
+	TLSCertFile               string
+	TLSPrivateKeyFile         string
+	RotateCertificates        bool
+}
+
+func (f KubeletConfigFile) Validate(k8sVer string) error {
+	if !f.Enabled {
+		if len(f.Overrides) > 0 {
+			return errors.New("`kubelet.configFile.enabled` must be true to specify `kubelet.configFile.overrides`")
+		}
+		return nil
+	}
+
+	supported, err := k8sVersionSatisfies(">= 1.10", k8sVer)
+	if err != nil {
+		return err
+	}
+	if !supported {
+		return fmt.Errorf("`kubelet.configFile` requires kubernetesVersion 1.10 or greater, but was %s", k8sVer)
+	}
+
+	keys := []string{}
+	for k := range f.Overrides {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if k == "apiVersion" || k == "kind" {
+			return fmt.Errorf("`kubelet.configFile.overrides` must not contain \"%s\", which is managed by kube-aws", k)
+		}
+		if !containsString(knownKubeletConfigFields, k) {
+			return fmt.Errorf("`kubelet.configFile.overrides` contains the unknown KubeletConfiguration field \"%s\"", k)
+		}
+	}
+
+	if _, err := yaml.Marshal(normalizeYAMLValue(f.Overrides)); err != nil {
+		return fmt.Errorf("`kubelet.configFile.overrides` can not be rendered as YAML: %v", err)
+	}
+
+	return nil
+}
+
+// RenderConfigFile returns the content of the KubeletConfiguration file, which consists of the defaults, the compute resource reservations
+// of this kubelet, and the overrides, in the order of precedence from the lowest
+func (k Kubelet) RenderConfigFile(d KubeletConfigDefaults) (string, error) {
+	config := map[string]interface{}{
+		// kubelet's defaults differ between the flags and the config file for these. Keep them consistent with the flag mode
+		"authentication": map[string]interface{}{
+			"anonymous": map[string]interface{}{"enabled": true},
+			"webhook":   map[string]interface{}{"enabled": false},
+		},
+		"authorization": map[string]interface{}{"mode": "AlwaysAllow"},
+		"readOnlyPort":  10255,
+
+		"staticPodPath": d.StaticPodPath,
+		"clusterDomain": d.ClusterDomain,
+	}
+	if d.ClusterDNS != "" {
+		config["clusterDNS"] = []string{d.ClusterDNS}
+	}
+	if d.ResolvConf != "" {
+		config["resolvConf"] = d.ResolvConf
+	}
+	if d.FeatureGates.Enabled() {
+		gates := map[string]interface{}{}
+		for name, v := range d.FeatureGates {
+			enabled, err := strconv.ParseBool(v)
+			if err != nil {
+				return "", fmt.Errorf("invalid value \"%s\" for the feature gate %s: it must be either true or false", v, name)
+			}
+			gates[name] = enabled
+		}
+		config["featureGates"] = gates
+	}
+	if d.NodeStatusUpdateFrequency != "" {
+		config["nodeStatusUpdateFrequency"] = d.NodeStatusUpdateFrequency
+	}
+	if d.TLSCertFile != "" {
+		config["tlsCertFile"] = d.TLSCertFile
+		config["tlsPrivateKeyFile"] = d.TLSPrivateKeyFile
+	}
+	if d.RotateCertificates {
+		config["rotateCertificates"] = true
+	}
+
+	if !k.SystemReservedResources.IsEmpty() {
+		config["systemReserved"] = map[string]string(k.SystemReservedResources)
+	}
+	if !k.KubeReservedResources.IsEmpty() {
+		config["kubeReserved"] = map[string]string(k.KubeReservedResources)
+	}
+	if len(k.EnforceNodeAllocatable) > 0 {
+		config["enforceNodeAllocatable"] = k.EnforceNodeAllocatable
+	}
+	if k.SystemReservedCgroup != "" {
+		config["systemReservedCgroup"] = k.SystemReservedCgroup
+	}
+	if k.KubeReservedCgroup != "" {
+		config["kubeReservedCgroup"] = k.KubeReservedCgroup
+	}
+	if k.SerializeImagePulls != nil {
+		config["serializeImagePulls"] = *k.SerializeImagePulls
+	}
+	if k.MaxParallelImagePulls > 0 {
+		config["maxParallelImagePulls"] = k.MaxParallelImagePulls
+	}
+	if k.EvictionMaxPodGracePeriod != nil {
+		config["evictionMaxPodGracePeriod"] = *k.EvictionMaxPodGracePeriod
+	}
+	if k.EvictionPressureTransitionPeriod != "" {
+		config["evictionPressureTransitionPeriod"] = k.EvictionPressureTransitionPeriod
+	}
+
+	merged := mergeYAMLMaps(normalizeYAMLValue(config).(map[string]interface{}), normalizeYAMLValue(k.ConfigFile.Overrides).(map[string]interface{}))
+
+	body, err := yaml.Marshal(merged)
+	if err != nil {
+		return "", fmt.Errorf("failed to render kubelet config file: %v", err)
+	}
+
+	header := fmt.Sprintf("apiVersion: %s\nkind: %s\n", kubeletConfigAPIVersion, kubeletConfigKind)
+	return strings.TrimSuffix(header+string(body), "\n"), nil
+}
+
+// normalizeYAMLValue converts maps decoded from YAML, whose keys are of type interface{}, into maps keyed by strings so that they can be merged
+func normalizeYAMLValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := map[string]interface{}{}
+		for k, v := range t {
+			m[fmt.Sprintf("%v", k)] = normalizeYAMLValue(v)
+		}
+		return m
+	case map[string]interface{}:
+		m := map[string]interface{}{}
+		for k, v := range t {
+			m[k] = normalizeYAMLValue(v)
+		}
+		return m
+	case map[string]string:
+		m := map[string]interface{}{}
+		for k, v := range t {
+			m[k] = v
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(t))
+		for i, v := range t {
+			l[i] = normalizeYAMLValue(v)
+		}
+		return l
+	default:
+		return v
+	}
+}
+
+// mergeYAMLMaps deep-merges src over dst. Values other than maps, including lists, in src replace the ones in dst
+func mergeYAMLMaps(dst, src map[string]interface{}) map[string]interface{} {
+	for k, v := range src {
+		srcMap, srcIsMap := v.(map[string]interface{})
+		dstMap, dstIsMap := dst[k].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			dst[k] = mergeYAMLMaps(dstMap, srcMap)
+		} else {
+			dst[k] = v
+		}
+	}
+	return dst
+}
@@ -0,0 +1,330 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKubeletConfigFileValidate(t *testing.T) {
+	testCases := []struct {
+		configFile KubeletConfigFile
+		k8sVer     string
+		isValid    bool
+	}{
+		// Valid, disabled
+		{
+			configFile: KubeletConfigFile{},
+			k8sVer:     "v1.9.3",
+			isValid:    true,
+		},
+		// Valid, enabled with overrides
+		{
+			configFile: KubeletConfigFile{
+				Enabled: true,
+				Overrides: map[string]interface{}{
+					"maxPods":        50,
+					"authentication": map[interface{}]interface{}{"webhook": map[interface{}]interface{}{"enabled": true}},
+				},
+			},
+			k8sVer:  "v1.11.3",
+			isValid: true,
+		},
+		// Invalid, overrides without enabling the config file
+		{
+			configFile: KubeletConfigFile{Overrides: map[string]interface{}{"maxPods": 50}},
+			k8sVer:     "v1.11.3",
+			isValid:    false,
+		},
+		// Invalid, unknown field
+		{
+			configFile: KubeletConfigFile{Enabled: true, Overrides: map[string]interface{}{"maxPod": 50}},
+			k8sVer:     "v1.11.3",
+			isValid:    false,
+		},
+		// Invalid, apiVersion is managed by kube-aws
+		{
+			configFile: KubeletConfigFile{Enabled: true, Overrides: map[string]interface{}{"apiVersion": "kubelet.config.k8s.io/v1"}},
+			k8sVer:     "v1.11.3",
+			isValid:    false,
+		},
+		// Invalid, unsupported kubernetes version
+		{
+			configFile: KubeletConfigFile{Enabled: true},
+			k8sVer:     "v1.9.3",
+			isValid:    false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.configFile.Validate(testCase.k8sVer)
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid for %s but got an error: %v", i, testCase.configFile, testCase.k8sVer, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid for %s but was not", i, testCase.configFile, testCase.k8sVer)
+		}
+	}
+}
+
+func TestKubeletRenderConfigFile(t *testing.T) {
+	notSerialized := false
+	gracePeriod := 0
+	defaults := KubeletConfigDefaults{
+		StaticPodPath: "/etc/kubernetes/manifests",
+		ClusterDomain: "cluster.local",
+		ClusterDNS:    "10.3.0.10",
+		FeatureGates:  FeatureGates{"PodPriority": "true"},
+	}
+
+	testCases := []struct {
+		kubelet  Kubelet
+		defaults KubeletConfigDefaults
+		expected string
+	}{
+		// Defaults only
+		{
+			kubelet:  Kubelet{},
+			defaults: defaults,
+			expected: `apiVersion: kubelet.config.k8s.io/v1beta1
+kind: KubeletConfiguration
+authentication:
+  anonymous:
+    enabled: true
+  webhook:
+    enabled: false
+authorization:
+  mode: AlwaysAllow
+clusterDNS:
+- 10.3.0.10
+clusterDomain: cluster.local
+featureGates:
+  PodPriority: true
+readOnlyPort: 10255
+staticPodPath: /etc/kubernetes/manifests`,
+		},
+		// Overrides are deep-merged and lists are replaced
+		{
+			kubelet: Kubelet{
+				SystemReservedResources: ReservedResources{"cpu": "100m"},
+				ConfigFile: KubeletConfigFile{
+					Enabled: true,
+					Overrides: map[string]interface{}{
+						"authentication": map[interface{}]interface{}{"webhook": map[interface{}]interface{}{"enabled": true}},
+						"clusterDNS":     []interface{}{"169.254.20.10"},
+						"systemReserved": map[interface{}]interface{}{"memory": "100Mi"},
+					},
+				},
+			},
+			defaults: KubeletConfigDefaults{
+				StaticPodPath: "/etc/kubernetes/manifests",
+				ClusterDomain: "cluster.local",
+				ClusterDNS:    "10.3.0.10",
+			},
+			expected: `apiVersion: kubelet.config.k8s.io/v1beta1
+kind: KubeletConfiguration
+authentication:
+  anonymous:
+    enabled: true
+  webhook:
+    enabled: true
+authorization:
+  mode: AlwaysAllow
+clusterDNS:
+- 169.254.20.10
+clusterDomain: cluster.local
+readOnlyPort: 10255
+staticPodPath: /etc/kubernetes/manifests
+systemReserved:
+  cpu: 100m
+  memory: 100Mi`,
+		},
+		// Parallel image pulls
+		{
+			kubelet: Kubelet{
+				SerializeImagePulls:   &notSerialized,
+				MaxParallelImagePulls: 5,
+				ConfigFile:            KubeletConfigFile{Enabled: true},
+			},
+			defaults: KubeletConfigDefaults{
+				StaticPodPath: "/etc/kubernetes/manifests",
+				ClusterDomain: "cluster.local",
+			},
+			expected: `apiVersion: kubelet.config.k8s.io/v1beta1
+kind: KubeletConfiguration
+authentication:
+  anonymous:
+    enabled: true
+  webhook:
+    enabled: false
+authorization:
+  mode: AlwaysAllow
+clusterDomain: cluster.local
+maxParallelImagePulls: 5
+readOnlyPort: 10255
+serializeImagePulls: false
+staticPodPath: /etc/kubernetes/manifests`,
+		},
+		// Eviction settings
+		{
+			kubelet: Kubelet{
+				EvictionMaxPodGracePeriod:        &gracePeriod,
+				EvictionPressureTransitionPeriod: "10m",
+				ConfigFile:                       KubeletConfigFile{Enabled: true},
+			},
+			defaults: KubeletConfigDefaults{
+				StaticPodPath: "/etc/kubernetes/manifests",
+				ClusterDomain: "cluster.local",
+			},
+			expected: `apiVersion: kubelet.config.k8s.io/v1beta1
+kind: KubeletConfiguration
+authentication:
+  anonymous:
+    enabled: true
+  webhook:
+    enabled: false
+authorization:
+  mode: AlwaysAllow
+clusterDomain: cluster.local
+evictionMaxPodGracePeriod: 0
+evictionPressureTransitionPeriod: 10m
+readOnlyPort: 10255
+staticPodPath: /etc/kubernetes/manifests`,
+		},
+	}
+
+	for i, testCase := range testCases {
+		actual, err := testCase.kubelet.RenderConfigFile(testCase.defaults)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if actual != testCase.expected {
+			t.Errorf("case %d: unexpected kubelet config file:\nexpected:\n%s\nactual:\n%s", i, testCase.expected, actual)
+		}
+	}
+
+	invalid := defaults
+	invalid.FeatureGates = FeatureGates{"PodPriority": "yes"}
+	if _, err := (Kubelet{}).RenderConfigFile(invalid); err == nil || !strings.Contains(err.Error(), "PodPriority") {
+		t.Errorf("expected an error for the invalid feature gate value but got: %v", err)
+	}
+}
+
+func TestKubeletValidateImagePulls(t *testing.T) {
+	serialized, notSerialized := true, false
+
+	testCases := []struct {
+		kubelet Kubelet
+		k8sVer  string
+		isValid bool
+	}{
+		// Valid, kubelet's defaults
+		{
+			kubelet: Kubelet{},
+			k8sVer:  "v1.11.3",
+			isValid: true,
+		},
+		// Valid, parallel image pulls without a limit
+		{
+			kubelet: Kubelet{SerializeImagePulls: &notSerialized},
+			k8sVer:  "v1.11.3",
+			isValid: true,
+		},
+		// Valid, parallel image pulls with a limit
+		{
+			kubelet: Kubelet{SerializeImagePulls: &notSerialized, MaxParallelImagePulls: 5, ConfigFile: KubeletConfigFile{Enabled: true}},
+			k8sVer:  "v1.27.1",
+			isValid: true,
+		},
+		// Invalid, a limit for serialized image pulls
+		{
+			kubelet: Kubelet{MaxParallelImagePulls: 5, ConfigFile: KubeletConfigFile{Enabled: true}},
+			k8sVer:  "v1.27.1",
+			isValid: false,
+		},
+		// Invalid, a limit for explicitly serialized image pulls
+		{
+			kubelet: Kubelet{SerializeImagePulls: &serialized, MaxParallelImagePulls: 5, ConfigFile: KubeletConfigFile{Enabled: true}},
+			k8sVer:  "v1.27.1",
+			isValid: false,
+		},
+		// Invalid, a negative limit
+		{
+			kubelet: Kubelet{SerializeImagePulls: &notSerialized, MaxParallelImagePulls: -1, ConfigFile: KubeletConfigFile{Enabled: true}},
+			k8sVer:  "v1.27.1",
+			isValid: false,
+		},
+		// Invalid, a limit without the config file
+		{
+			kubelet: Kubelet{SerializeImagePulls: &notSerialized, MaxParallelImagePulls: 5},
+			k8sVer:  "v1.27.1",
+			isValid: false,
+		},
+		// Invalid, unsupported kubernetes version
+		{
+			kubelet: Kubelet{SerializeImagePulls: &notSerialized, MaxParallelImagePulls: 5, ConfigFile: KubeletConfigFile{Enabled: true}},
+			k8sVer:  "v1.26.3",
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.kubelet.ValidateImagePulls(testCase.k8sVer)
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid for %s but got an error: %v", i, testCase.kubelet, testCase.k8sVer, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid for %s but was not", i, testCase.kubelet, testCase.k8sVer)
+		}
+	}
+}
+
+func TestKubeletValidateEviction(t *testing.T) {
+	zero, positive, negative := 0, 60, -1
+
+	testCases := []struct {
+		kubelet Kubelet
+		isValid bool
+	}{
+		// Valid, kubelet's defaults
+		{
+			kubelet: Kubelet{},
+			isValid: true,
+		},
+		// Valid, zero
+		{
+			kubelet: Kubelet{EvictionMaxPodGracePeriod: &zero, EvictionPressureTransitionPeriod: "0s"},
+			isValid: true,
+		},
+		// Valid, positive values
+		{
+			kubelet: Kubelet{EvictionMaxPodGracePeriod: &positive, EvictionPressureTransitionPeriod: "5m30s"},
+			isValid: true,
+		},
+		// Invalid, a negative grace period
+		{
+			kubelet: Kubelet{EvictionMaxPodGracePeriod: &negative},
+			isValid: false,
+		},
+		// Invalid, a negative transition period
+		{
+			kubelet: Kubelet{EvictionPressureTransitionPeriod: "-5m"},
+			isValid: false,
+		},
+		// Invalid, a transition period without a unit
+		{
+			kubelet: Kubelet{EvictionPressureTransitionPeriod: "300"},
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.kubelet.ValidateEviction()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.kubelet, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.kubelet)
+		}
+	}
+}
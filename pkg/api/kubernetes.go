@@ -7,9 +7,15 @@ type Kubernetes struct {
 	ControllerManager ControllerManager        `yaml:"controllerManager,omitempty"`
 
 	APIServer KubernetesAPIServer `yaml:"apiserver,omitempty"`
+	// OIDC configures the service account issuer trusted by external systems
+	OIDC KubernetesOIDC `yaml:"oidc,omitempty"`
 	// Manifests is a list of manifests to be installed to the cluster.
 	// Note that the list is sorted by their names by kube-aws so that it won't result in unnecessarily node replacements.
 	Manifests KubernetesManifests `yaml:"manifests,omitempty"`
+	// Secrets is a list of secrets created on the cluster with the data fetched from SSM Parameter Store or Secrets Manager
+	Secrets KubernetesSecrets `yaml:"secrets,omitempty"`
+	// ConfigMaps is a list of configmaps created on the cluster with the data fetched from SSM Parameter Store or Secrets Manager
+	ConfigMaps KubernetesConfigMaps `yaml:"configMaps,omitempty"`
 }
 
 type ControllerManager struct {
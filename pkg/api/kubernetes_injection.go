@@ -0,0 +1,199 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	DefaultInjectedKubernetesObjectNamespace = "kube-system"
+	DefaultKubernetesSecretType              = "Opaque"
+)
+
+var (
+	kubernetesObjectNamePattern     = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+	kubernetesNamespacePattern      = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	kubernetesDataKeyPattern        = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+	ssmParameterNamePattern         = regexp.MustCompile(`^/?[a-zA-Z0-9_.\-/]+$`)
+	secretsManagerSecretNamePattern = regexp.MustCompile(`^[a-zA-Z0-9/_+=.@\-]+$`)
+)
+
+type KubernetesSecrets []KubernetesSecret
+
+// KubernetesSecret is a kubernetes secret whose data is fetched from SSM Parameter Store or Secrets Manager by controller nodes,
+// so that the sensitive values never appear in cluster.yaml, plugins and rendered assets
+type KubernetesSecret struct {
+	Name string `yaml:"name,omitempty"`
+	// Namespace is the namespace of the secret. Defaults to `kube-system`
+	Namespace string `yaml:"namespace,omitempty"`
+	// Type is the type of the secret like `kubernetes.io/dockerconfigjson`. Defaults to `Opaque`
+	Type string                `yaml:"type,omitempty"`
+	Data KubernetesDataSources `yaml:"data,omitempty"`
+}
+
+type KubernetesConfigMaps []KubernetesConfigMap
+
+// KubernetesConfigMap is a kubernetes configmap whose data is fetched from SSM Parameter Store or Secrets Manager by controller nodes
+type KubernetesConfigMap struct {
+	Name string `yaml:"name,omitempty"`
+	// Namespace is the namespace of the configmap. Defaults to `kube-system`
+	Namespace string                `yaml:"namespace,omitempty"`
+	Data      KubernetesDataSources `yaml:"data,omitempty"`
+}
+
+type KubernetesDataSources []KubernetesDataSource
+
+// KubernetesDataSource is where the value of a key in a secret or a configmap is fetched from.
+// Exactly one of SSMParameter and SecretsManagerSecret must be specified
+type KubernetesDataSource struct {
+	Key string `yaml:"key,omitempty" json:"key"`
+	// SSMParameter is the name or the ARN of a SSM parameter. SecureString parameters are decrypted
+	SSMParameter string `yaml:"ssmParameter,omitempty" json:"ssmParameter,omitempty"`
+	// SecretsManagerSecret is the name or the ARN of a Secrets Manager secret
+	SecretsManagerSecret string `yaml:"secretsManagerSecret,omitempty" json:"secretsManagerSecret,omitempty"`
+	// JSONKey picks the value of the key from the secret string stored as a JSON object like the ones created in the AWS console
+	JSONKey string `yaml:"jsonKey,omitempty" json:"jsonKey,omitempty"`
+}
+
+// InjectedKubernetesObject is a secret or a configmap to be created on the cluster by controller nodes, with the data fetched from AWS.
+// It is written to controller nodes as a JSON file without the values
+type InjectedKubernetesObject struct {
+	Kind      string                `json:"kind"`
+	Namespace string                `json:"namespace"`
+	Name      string                `json:"name"`
+	Type      string                `json:"type,omitempty"`
+	Data      KubernetesDataSources `json:"data"`
+}
+
+type InjectedKubernetesObjects []InjectedKubernetesObject
+
+// InjectedObjects returns all the secrets and configmaps with the defaults applied
+func (k Kubernetes) InjectedObjects() InjectedKubernetesObjects {
+	objects := InjectedKubernetesObjects{}
+	for _, s := range k.Secrets {
+		t := s.Type
+		if t == "" {
+			t = DefaultKubernetesSecretType
+		}
+		objects = append(objects, InjectedKubernetesObject{Kind: "secret", Namespace: namespaceOrDefault(s.Namespace), Name: s.Name, Type: t, Data: s.Data})
+	}
+	for _, c := range k.ConfigMaps {
+		objects = append(objects, InjectedKubernetesObject{Kind: "configmap", Namespace: namespaceOrDefault(c.Namespace), Name: c.Name, Data: c.Data})
+	}
+	return objects
+}
+
+func namespaceOrDefault(ns string) string {
+	if ns == "" {
+		return DefaultInjectedKubernetesObjectNamespace
+	}
+	return ns
+}
+
+// FileName is the name of the file describing this object on controller nodes
+func (o InjectedKubernetesObject) FileName() string {
+	return fmt.Sprintf("%s-%s-%s.json", o.Kind, o.Namespace, o.Name)
+}
+
+func (k Kubernetes) ValidateInjectedObjects() error {
+	seen := map[string]bool{}
+	for _, o := range k.InjectedObjects() {
+		id := fmt.Sprintf("%s %s/%s", o.Kind, o.Namespace, o.Name)
+		if seen[id] {
+			return fmt.Errorf("%s is defined more than once", id)
+		}
+		seen[id] = true
+
+		if err := o.Validate(); err != nil {
+			return fmt.Errorf("invalid %s: %v", id, err)
+		}
+	}
+	return nil
+}
+
+func (o InjectedKubernetesObject) Validate() error {
+	if len(o.Name) > 253 || !kubernetesObjectNamePattern.MatchString(o.Name) {
+		return fmt.Errorf("`name` \"%s\" must be a valid DNS subdomain name", o.Name)
+	}
+	if len(o.Namespace) > 63 || !kubernetesNamespacePattern.MatchString(o.Namespace) {
+		return fmt.Errorf("`namespace` \"%s\" must be a valid DNS label", o.Namespace)
+	}
+	if len(o.Data) == 0 {
+		return errors.New("`data` must contain one or more keys")
+	}
+
+	keys := map[string]bool{}
+	for _, d := range o.Data {
+		if keys[d.Key] {
+			return fmt.Errorf("the key \"%s\" is duplicated in `data`", d.Key)
+		}
+		keys[d.Key] = true
+
+		if err := d.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d KubernetesDataSource) Validate() error {
+	if len(d.Key) > 253 || !kubernetesDataKeyPattern.MatchString(d.Key) || d.Key == "." || d.Key == ".." {
+		return fmt.Errorf("invalid `data[].key` \"%s\": it must consist of alphanumeric characters, '-', '_' or '.'", d.Key)
+	}
+	if (d.SSMParameter == "") == (d.SecretsManagerSecret == "") {
+		return fmt.Errorf("exactly one of `ssmParameter` and `secretsManagerSecret` must be specified for the key \"%s\"", d.Key)
+	}
+	if d.JSONKey != "" && d.SecretsManagerSecret == "" {
+		return fmt.Errorf("`jsonKey` can be specified only with `secretsManagerSecret` for the key \"%s\"", d.Key)
+	}
+	if d.SSMParameter != "" && !strings.HasPrefix(d.SSMParameter, "arn:") && !ssmParameterNamePattern.MatchString(d.SSMParameter) {
+		return fmt.Errorf("invalid `ssmParameter` \"%s\" for the key \"%s\"", d.SSMParameter, d.Key)
+	}
+	if d.SecretsManagerSecret != "" && !strings.HasPrefix(d.SecretsManagerSecret, "arn:") && !secretsManagerSecretNamePattern.MatchString(d.SecretsManagerSecret) {
+		return fmt.Errorf("invalid `secretsManagerSecret` \"%s\" for the key \"%s\"", d.SecretsManagerSecret, d.Key)
+	}
+	return nil
+}
+
+// SSMParameterARN returns the ARN of the SSM parameter, with the account ID wildcarded when only the name is specified
+func (d KubernetesDataSource) SSMParameterARN(region Region) string {
+	if strings.HasPrefix(d.SSMParameter, "arn:") {
+		return d.SSMParameter
+	}
+	return fmt.Sprintf("arn:%s:ssm:%s:*:parameter/%s", region.Partition(), region, strings.TrimPrefix(d.SSMParameter, "/"))
+}
+
+// SecretsManagerSecretARN returns the ARN of the Secrets Manager secret, with the account ID and the random suffix wildcarded
+// when only the name is specified
+func (d KubernetesDataSource) SecretsManagerSecretARN(region Region) string {
+	if strings.HasPrefix(d.SecretsManagerSecret, "arn:") {
+		return d.SecretsManagerSecret
+	}
+	return fmt.Sprintf("arn:%s:secretsmanager:%s:*:secret:%s-??????", region.Partition(), region, d.SecretsManagerSecret)
+}
+
+// IAMPolicyStatements returns the statements allowing controller nodes to fetch the data of the objects
+func (objects InjectedKubernetesObjects) IAMPolicyStatements(region Region) IAMPolicyStatements {
+	params, secrets := []string{}, []string{}
+	for _, o := range objects {
+		for _, d := range o.Data {
+			if arn := d.SSMParameterARN(region); d.SSMParameter != "" && !containsString(params, arn) {
+				params = append(params, arn)
+			}
+			if arn := d.SecretsManagerSecretARN(region); d.SecretsManagerSecret != "" && !containsString(secrets, arn) {
+				secrets = append(secrets, arn)
+			}
+		}
+	}
+
+	statements := IAMPolicyStatements{}
+	if len(params) > 0 {
+		statements = append(statements, IAMPolicyStatement{Effect: "Allow", Actions: []string{"ssm:GetParameter"}, Resources: params})
+	}
+	if len(secrets) > 0 {
+		statements = append(statements, IAMPolicyStatement{Effect: "Allow", Actions: []string{"secretsmanager:GetSecretValue"}, Resources: secrets})
+	}
+	return statements
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateInjectedObjects(t *testing.T) {
+	testCases := []struct {
+		kubernetes Kubernetes
+		isValid    bool
+	}{
+		// Valid, a secret and a configmap with the same name
+		{
+			kubernetes: Kubernetes{
+				Secrets: KubernetesSecrets{
+					{Name: "datadog", Data: KubernetesDataSources{{Key: "api-key", SSMParameter: "/datadog/api-key"}}},
+				},
+				ConfigMaps: KubernetesConfigMaps{
+					{Name: "datadog", Namespace: "monitoring", Data: KubernetesDataSources{{Key: "site", SSMParameter: "datadog-site"}}},
+				},
+			},
+			isValid: true,
+		},
+		// Valid, a value picked from a JSON secret referenced by its ARN
+		{
+			kubernetes: Kubernetes{
+				Secrets: KubernetesSecrets{
+					{Name: "registry", Type: "kubernetes.io/dockerconfigjson", Data: KubernetesDataSources{
+						{Key: ".dockerconfigjson", SecretsManagerSecret: "arn:aws:secretsmanager:us-west-1:123456789012:secret:registry-AbCdEf", JSONKey: "config"},
+					}},
+				},
+			},
+			isValid: true,
+		},
+		// Invalid, duplicated secrets
+		{
+			kubernetes: Kubernetes{
+				Secrets: KubernetesSecrets{
+					{Name: "datadog", Data: KubernetesDataSources{{Key: "api-key", SSMParameter: "/datadog/api-key"}}},
+					{Name: "datadog", Namespace: "kube-system", Data: KubernetesDataSources{{Key: "app-key", SSMParameter: "/datadog/app-key"}}},
+				},
+			},
+			isValid: false,
+		},
+		// Invalid, no data
+		{
+			kubernetes: Kubernetes{
+				Secrets: KubernetesSecrets{{Name: "datadog"}},
+			},
+			isValid: false,
+		},
+		// Invalid, name
+		{
+			kubernetes: Kubernetes{
+				ConfigMaps: KubernetesConfigMaps{
+					{Name: "Datadog", Data: KubernetesDataSources{{Key: "site", SSMParameter: "datadog-site"}}},
+				},
+			},
+			isValid: false,
+		},
+		// Invalid, duplicated keys
+		{
+			kubernetes: Kubernetes{
+				Secrets: KubernetesSecrets{
+					{Name: "datadog", Data: KubernetesDataSources{
+						{Key: "api-key", SSMParameter: "/datadog/api-key"},
+						{Key: "api-key", SSMParameter: "/datadog/app-key"},
+					}},
+				},
+			},
+			isValid: false,
+		},
+		// Invalid, both sources
+		{
+			kubernetes: Kubernetes{
+				Secrets: KubernetesSecrets{
+					{Name: "datadog", Data: KubernetesDataSources{{Key: "api-key", SSMParameter: "/datadog/api-key", SecretsManagerSecret: "datadog"}}},
+				},
+			},
+			isValid: false,
+		},
+		// Invalid, no source
+		{
+			kubernetes: Kubernetes{
+				Secrets: KubernetesSecrets{
+					{Name: "datadog", Data: KubernetesDataSources{{Key: "api-key"}}},
+				},
+			},
+			isValid: false,
+		},
+		// Invalid, jsonKey for a SSM parameter
+		{
+			kubernetes: Kubernetes{
+				Secrets: KubernetesSecrets{
+					{Name: "datadog", Data: KubernetesDataSources{{Key: "api-key", SSMParameter: "/datadog/api-key", JSONKey: "apiKey"}}},
+				},
+			},
+			isValid: false,
+		},
+		// Invalid, key
+		{
+			kubernetes: Kubernetes{
+				Secrets: KubernetesSecrets{
+					{Name: "datadog", Data: KubernetesDataSources{{Key: "api/key", SSMParameter: "/datadog/api-key"}}},
+				},
+			},
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.kubernetes.ValidateInjectedObjects()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.kubernetes.InjectedObjects(), err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.kubernetes.InjectedObjects())
+		}
+	}
+}
+
+func TestInjectedObjectsIAMPolicyStatements(t *testing.T) {
+	k := Kubernetes{
+		Secrets: KubernetesSecrets{
+			{Name: "datadog", Data: KubernetesDataSources{
+				{Key: "api-key", SSMParameter: "/datadog/api-key"},
+				{Key: "app-key", SecretsManagerSecret: "datadog", JSONKey: "appKey"},
+			}},
+		},
+		ConfigMaps: KubernetesConfigMaps{
+			{Name: "datadog", Data: KubernetesDataSources{
+				{Key: "api-key", SSMParameter: "datadog/api-key"},
+				{Key: "site", SSMParameter: "arn:aws-cn:ssm:cn-north-1:123456789012:parameter/datadog/site"},
+			}},
+		},
+	}
+
+	objects := k.InjectedObjects()
+	if o := objects[0]; o.Kind != "secret" || o.Namespace != "kube-system" || o.Type != "Opaque" || o.FileName() != "secret-kube-system-datadog.json" {
+		t.Errorf("unexpected secret: %+v", o)
+	}
+
+	expected := IAMPolicyStatements{
+		{
+			Effect:  "Allow",
+			Actions: []string{"ssm:GetParameter"},
+			Resources: []string{
+				"arn:aws-cn:ssm:cn-north-1:*:parameter/datadog/api-key",
+				"arn:aws-cn:ssm:cn-north-1:123456789012:parameter/datadog/site",
+			},
+		},
+		{
+			Effect:    "Allow",
+			Actions:   []string{"secretsmanager:GetSecretValue"},
+			Resources: []string{"arn:aws-cn:secretsmanager:cn-north-1:*:secret:datadog-??????"},
+		},
+	}
+	actual := objects.IAMPolicyStatements(RegionForName("cn-north-1"))
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v but was %+v", expected, actual)
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The bounds of `lambdaNodeDrainer.drainTimeout` in minutes. The upper bound is the maximum timeout of a Lambda function
+const (
+	defaultLambdaNodeDrainTimeout = 5
+	maxLambdaNodeDrainTimeout     = 15
+)
+
+// LambdaNodeDrainer is the set of settings for draining nodes of a node pool from a Lambda function before the auto scaling group
+// terminates them. An EventBridge rule invokes the function for every termination lifecycle action of the auto scaling group,
+// and the function cordons the node, evicts its pods via the Kubernetes API and then completes the lifecycle action.
+// Unlike `experimental.nodeDrainer`, nodes are drained even when the node itself is unresponsive
+type LambdaNodeDrainer struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// DrainTimeout is the maximum time in minutes to wait for the node to be completely drained. Defaults to 5
+	DrainTimeout int `yaml:"drainTimeout,omitempty"`
+}
+
+func (d LambdaNodeDrainer) DrainTimeoutInSeconds() int {
+	if d.DrainTimeout == 0 {
+		return defaultLambdaNodeDrainTimeout * 60
+	}
+	return d.DrainTimeout * 60
+}
+
+// HeartbeatTimeoutInSeconds returns the heartbeat timeout of the lifecycle hook, which outlasts the function so that the function
+// rather than the timeout completes the lifecycle action
+func (d LambdaNodeDrainer) HeartbeatTimeoutInSeconds() int {
+	return d.DrainTimeoutInSeconds() + 60
+}
+
+// EvictionAPIVersion returns the apiVersion of the Eviction the function posts to the apiserver of the specified version of Kubernetes
+func (d LambdaNodeDrainer) EvictionAPIVersion(k8sVer string) (string, error) {
+	v1, err := k8sVersionSatisfies(">= 1.22", k8sVer)
+	if err != nil {
+		return "", err
+	}
+	if v1 {
+		return "policy/v1", nil
+	}
+	return "policy/v1beta1", nil
+}
+
+func (d LambdaNodeDrainer) Validate() error {
+	if !d.Enabled {
+		return nil
+	}
+	if d.DrainTimeout < 0 || d.DrainTimeout > maxLambdaNodeDrainTimeout {
+		return fmt.Errorf("`lambdaNodeDrainer.drainTimeout` must be an integer between 1 and %d, but was %d", maxLambdaNodeDrainTimeout, d.DrainTimeout)
+	}
+	return nil
+}
+
+// LambdaNodeDrainerCredentialsParameterName returns the name of the SSM parameter controller nodes publish the service account token
+// and the CA certificate to, which are used by the functions to authenticate to the apiserver
+func (c DeploymentSettings) LambdaNodeDrainerCredentialsParameterName() string {
+	return fmt.Sprintf("/kube-aws/clusters/%s/lambda-node-drainer/credentials", c.ClusterName)
+}
+
+// LambdaNodeDrainerEnabled returns true when any node pool is drained by a Lambda function
+func (c Cluster) LambdaNodeDrainerEnabled() bool {
+	for _, p := range c.Worker.NodePools {
+		if p.LambdaNodeDrainer.Enabled {
+			return true
+		}
+	}
+	return false
+}
+
+func (c WorkerNodePool) validateLambdaNodeDrainer(experimental Experimental) error {
+	if !c.LambdaNodeDrainer.Enabled {
+		return nil
+	}
+	if err := c.LambdaNodeDrainer.Validate(); err != nil {
+		return err
+	}
+	if c.SpotFleet.Enabled() {
+		return errors.New("`lambdaNodeDrainer` can't be enabled for a node pool backed by a spot fleet because it reacts to lifecycle actions of auto scaling groups")
+	}
+	if experimental.NodeDrainer.Enabled {
+		return errors.New("`lambdaNodeDrainer` and `experimental.nodeDrainer` can't be enabled together because both complete the same lifecycle actions")
+	}
+	if c.NodeDrainer.Enabled {
+		return errors.New("`lambdaNodeDrainer` and `nodeDrainer` can't be enabled together for a node pool because both complete the same lifecycle actions")
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestLambdaNodeDrainerValidate(t *testing.T) {
+	testCases := []struct {
+		pool         WorkerNodePool
+		experimental Experimental
+		isValid      bool
+	}{
+		// Valid, disabled
+		{
+			pool:    WorkerNodePool{},
+			isValid: true,
+		},
+		// Valid, default drain timeout
+		{
+			pool:    WorkerNodePool{LambdaNodeDrainer: LambdaNodeDrainer{Enabled: true}},
+			isValid: true,
+		},
+		// Valid, maximum drain timeout
+		{
+			pool:    WorkerNodePool{LambdaNodeDrainer: LambdaNodeDrainer{Enabled: true, DrainTimeout: 15}},
+			isValid: true,
+		},
+		// Invalid, drain timeout exceeding the maximum timeout of Lambda functions
+		{
+			pool:    WorkerNodePool{LambdaNodeDrainer: LambdaNodeDrainer{Enabled: true, DrainTimeout: 16}},
+			isValid: false,
+		},
+		// Invalid, negative drain timeout
+		{
+			pool:    WorkerNodePool{LambdaNodeDrainer: LambdaNodeDrainer{Enabled: true, DrainTimeout: -1}},
+			isValid: false,
+		},
+		// Invalid, spot fleet
+		{
+			pool: WorkerNodePool{
+				LambdaNodeDrainer: LambdaNodeDrainer{Enabled: true},
+				SpotFleet:         SpotFleet{TargetCapacity: 1},
+			},
+			isValid: false,
+		},
+		// Invalid, enabled together with the node-local node drainer
+		{
+			pool:         WorkerNodePool{LambdaNodeDrainer: LambdaNodeDrainer{Enabled: true}},
+			experimental: Experimental{NodeDrainer: NodeDrainer{Enabled: true, DrainTimeout: 5}},
+			isValid:      false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.pool.validateLambdaNodeDrainer(testCase.experimental)
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.pool.LambdaNodeDrainer, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.pool.LambdaNodeDrainer)
+		}
+	}
+}
+
+func TestLambdaNodeDrainerTimeouts(t *testing.T) {
+	d := LambdaNodeDrainer{Enabled: true}
+	if d.DrainTimeoutInSeconds() != 300 || d.HeartbeatTimeoutInSeconds() != 360 {
+		t.Errorf("unexpected default timeouts: drain=%d heartbeat=%d", d.DrainTimeoutInSeconds(), d.HeartbeatTimeoutInSeconds())
+	}
+
+	d.DrainTimeout = 10
+	if d.DrainTimeoutInSeconds() != 600 || d.HeartbeatTimeoutInSeconds() != 660 {
+		t.Errorf("unexpected timeouts: drain=%d heartbeat=%d", d.DrainTimeoutInSeconds(), d.HeartbeatTimeoutInSeconds())
+	}
+}
+
+func TestLambdaNodeDrainerEvictionAPIVersion(t *testing.T) {
+	testCases := []struct {
+		k8sVer     string
+		apiVersion string
+	}{
+		{k8sVer: "v1.21.5", apiVersion: "policy/v1beta1"},
+		{k8sVer: "v1.22.0", apiVersion: "policy/v1"},
+		{k8sVer: "v1.29.2", apiVersion: "policy/v1"},
+	}
+
+	for i, testCase := range testCases {
+		v, err := LambdaNodeDrainer{}.EvictionAPIVersion(testCase.k8sVer)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+		}
+		if v != testCase.apiVersion {
+			t.Errorf("case %d: expected %s for %s but was %s", i, testCase.apiVersion, testCase.k8sVer, v)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const maintenanceWindowTimeLayout = "15:04"
+
+var maintenanceWindowDays = map[string]time.Weekday{
+	"Sun": time.Sunday,
+	"Mon": time.Monday,
+	"Tue": time.Tuesday,
+	"Wed": time.Wednesday,
+	"Thu": time.Thursday,
+	"Fri": time.Friday,
+	"Sat": time.Saturday,
+}
+
+// MaintenanceWindow is the recurring time range `kube-aws apply` and `kube-aws update` are allowed to update the cluster in.
+// Outside the window, they refuse to proceed unless forced
+type MaintenanceWindow struct {
+	// Days are the days of week like `Sat` the window starts on. Defaults to every day
+	Days []string `yaml:"days,omitempty"`
+	// Start and End are the times of day like `22:00`. A window ending earlier than it starts ends on the next day,
+	// and one ending at the time it starts lasts 24 hours. Omit both for all-day windows on the days
+	Start string `yaml:"start,omitempty"`
+	End   string `yaml:"end,omitempty"`
+	// TimeZone is the IANA time zone like `Asia/Tokyo` the days and the times are in. Defaults to UTC
+	TimeZone string `yaml:"timeZone,omitempty"`
+}
+
+func (w MaintenanceWindow) Enabled() bool {
+	return w.Start != "" || w.End != "" || len(w.Days) > 0
+}
+
+func (w MaintenanceWindow) location() (*time.Location, error) {
+	if w.TimeZone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(w.TimeZone)
+}
+
+// minutesOfDay returns the start and the end of the window in minutes since midnight
+func (w MaintenanceWindow) minutesOfDay() (int, int, error) {
+	if w.Start == "" && w.End == "" {
+		return 0, 0, nil
+	}
+	start, err := time.Parse(maintenanceWindowTimeLayout, w.Start)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid `maintenanceWindow.start` \"%s\": it must be a time of day like 22:00", w.Start)
+	}
+	end, err := time.Parse(maintenanceWindowTimeLayout, w.End)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid `maintenanceWindow.end` \"%s\": it must be a time of day like 06:00", w.End)
+	}
+	return start.Hour()*60 + start.Minute(), end.Hour()*60 + end.Minute(), nil
+}
+
+func (w MaintenanceWindow) startsOn(d time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, day := range w.Days {
+		if maintenanceWindowDays[day] == d {
+			return true
+		}
+	}
+	return false
+}
+
+func (w MaintenanceWindow) Validate() error {
+	if !w.Enabled() {
+		return nil
+	}
+
+	for _, d := range w.Days {
+		if _, ok := maintenanceWindowDays[d]; !ok {
+			return fmt.Errorf("invalid day \"%s\" in `maintenanceWindow.days`: it must be one of Sun, Mon, Tue, Wed, Thu, Fri and Sat", d)
+		}
+	}
+
+	if (w.Start == "") != (w.End == "") {
+		return errors.New("`maintenanceWindow.start` and `maintenanceWindow.end` must be specified together. Omit both for all-day windows on `maintenanceWindow.days`")
+	}
+	if _, _, err := w.minutesOfDay(); err != nil {
+		return err
+	}
+
+	if _, err := w.location(); err != nil {
+		return fmt.Errorf("invalid `maintenanceWindow.timeZone` \"%s\": %v", w.TimeZone, err)
+	}
+
+	return nil
+}
+
+// Contains returns true when the time is within the window
+func (w MaintenanceWindow) Contains(t time.Time) (bool, error) {
+	if !w.Enabled() {
+		return true, nil
+	}
+
+	loc, err := w.location()
+	if err != nil {
+		return false, err
+	}
+	start, end, err := w.minutesOfDay()
+	if err != nil {
+		return false, err
+	}
+
+	local := t.In(loc)
+	now := local.Hour()*60 + local.Minute()
+	if start < end {
+		return w.startsOn(local.Weekday()) && start <= now && now < end, nil
+	}
+	// The window spans midnight, or lasts 24 hours when it ends at the time it starts
+	if now >= start {
+		return w.startsOn(local.Weekday()), nil
+	}
+	if now < end {
+		return w.startsOn(local.AddDate(0, 0, -1).Weekday()), nil
+	}
+	return false, nil
+}
+
+func (w MaintenanceWindow) String() string {
+	days := "every day"
+	if len(w.Days) > 0 {
+		days = strings.Join(w.Days, ", ")
+	}
+	tz := w.TimeZone
+	if tz == "" {
+		tz = "UTC"
+	}
+	if w.Start == "" {
+		return fmt.Sprintf("all day %s on %s", tz, days)
+	}
+	return fmt.Sprintf("%s-%s %s on %s", w.Start, w.End, tz, days)
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMaintenanceWindowValidate(t *testing.T) {
+	testCases := []struct {
+		window  MaintenanceWindow
+		isValid bool
+	}{
+		// Valid, disabled
+		{
+			window:  MaintenanceWindow{},
+			isValid: true,
+		},
+		// Valid, every day
+		{
+			window:  MaintenanceWindow{Start: "22:00", End: "06:00"},
+			isValid: true,
+		},
+		// Valid, days and a time zone
+		{
+			window:  MaintenanceWindow{Days: []string{"Sat", "Sun"}, Start: "09:00", End: "17:30", TimeZone: "Asia/Tokyo"},
+			isValid: true,
+		},
+		// Invalid, day
+		{
+			window:  MaintenanceWindow{Days: []string{"Saturday"}, Start: "09:00", End: "17:00"},
+			isValid: false,
+		},
+		// Valid, all-day windows on the days
+		{
+			window:  MaintenanceWindow{Days: []string{"Sat"}},
+			isValid: true,
+		},
+		// Valid, a 24 hours window
+		{
+			window:  MaintenanceWindow{Days: []string{"Sat"}, Start: "06:00", End: "06:00"},
+			isValid: true,
+		},
+		// Invalid, start without end
+		{
+			window:  MaintenanceWindow{Days: []string{"Sat"}, Start: "09:00"},
+			isValid: false,
+		},
+		// Invalid, time
+		{
+			window:  MaintenanceWindow{Start: "9pm", End: "06:00"},
+			isValid: false,
+		},
+		// Invalid, time zone
+		{
+			window:  MaintenanceWindow{Start: "22:00", End: "06:00", TimeZone: "Mars/Olympus_Mons"},
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.window.Validate()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.window, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.window)
+		}
+	}
+}
+
+func TestMaintenanceWindowContains(t *testing.T) {
+	// 2021-01-01 is a Friday
+	at := func(day int, hour, minute int) time.Time {
+		return time.Date(2021, 1, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	testCases := []struct {
+		window   MaintenanceWindow
+		time     time.Time
+		expected bool
+	}{
+		{window: MaintenanceWindow{}, time: at(1, 12, 0), expected: true},
+		{window: MaintenanceWindow{Start: "09:00", End: "17:00"}, time: at(1, 9, 0), expected: true},
+		{window: MaintenanceWindow{Start: "09:00", End: "17:00"}, time: at(1, 17, 0), expected: false},
+		{window: MaintenanceWindow{Days: []string{"Sat"}, Start: "09:00", End: "17:00"}, time: at(1, 12, 0), expected: false},
+		{window: MaintenanceWindow{Days: []string{"Sat"}, Start: "09:00", End: "17:00"}, time: at(2, 12, 0), expected: true},
+		// The window starting on Friday night ends on Saturday morning
+		{window: MaintenanceWindow{Days: []string{"Fri"}, Start: "22:00", End: "06:00"}, time: at(1, 23, 0), expected: true},
+		{window: MaintenanceWindow{Days: []string{"Fri"}, Start: "22:00", End: "06:00"}, time: at(2, 5, 59), expected: true},
+		{window: MaintenanceWindow{Days: []string{"Fri"}, Start: "22:00", End: "06:00"}, time: at(1, 5, 0), expected: false},
+		{window: MaintenanceWindow{Days: []string{"Fri"}, Start: "22:00", End: "06:00"}, time: at(2, 12, 0), expected: false},
+		// All-day windows on the days
+		{window: MaintenanceWindow{Days: []string{"Sat"}}, time: at(2, 0, 0), expected: true},
+		{window: MaintenanceWindow{Days: []string{"Sat"}}, time: at(2, 23, 59), expected: true},
+		{window: MaintenanceWindow{Days: []string{"Sat"}}, time: at(1, 23, 59), expected: false},
+		{window: MaintenanceWindow{Days: []string{"Sat"}}, time: at(3, 0, 0), expected: false},
+		// The 24 hours window starting on Friday 06:00 ends on Saturday 06:00
+		{window: MaintenanceWindow{Days: []string{"Fri"}, Start: "06:00", End: "06:00"}, time: at(1, 6, 0), expected: true},
+		{window: MaintenanceWindow{Days: []string{"Fri"}, Start: "06:00", End: "06:00"}, time: at(2, 5, 59), expected: true},
+		{window: MaintenanceWindow{Days: []string{"Fri"}, Start: "06:00", End: "06:00"}, time: at(1, 5, 59), expected: false},
+		{window: MaintenanceWindow{Days: []string{"Fri"}, Start: "06:00", End: "06:00"}, time: at(2, 6, 0), expected: false},
+		// 2021-01-01 12:00 UTC is 21:00 in Tokyo
+		{window: MaintenanceWindow{Start: "20:00", End: "23:00", TimeZone: "Asia/Tokyo"}, time: at(1, 12, 0), expected: true},
+	}
+
+	for i, testCase := range testCases {
+		actual, err := testCase.window.Contains(testCase.time)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if actual != testCase.expected {
+			t.Errorf("case %d: expected %+v to contain %s to be %v but was %v", i, testCase.window, testCase.time, testCase.expected, actual)
+		}
+	}
+}
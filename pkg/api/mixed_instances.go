@@ -1,6 +1,24 @@
 package api
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// SpotAllocationStrategyLowestPrice is the default allocation strategy of spot instances, which is the only one spotInstancePools applies to
+	SpotAllocationStrategyLowestPrice = "lowest-price"
+	// SpotAllocationStrategyCapacityOptimizedPrioritized allocates spot instances from the pools with the most capacity,
+	// while trying to honor the priority given by the order of instanceTypes
+	SpotAllocationStrategyCapacityOptimizedPrioritized = "capacity-optimized-prioritized"
+)
+
+var (
+	// See https://docs.aws.amazon.com/AWSCloudFormation/latest/UserGuide/aws-properties-autoscaling-autoscalinggroup-instancesdistribution.html for valid values
+	onDemandAllocationStrategies = []string{"prioritized", "lowest-price"}
+	spotAllocationStrategies     = []string{SpotAllocationStrategyLowestPrice, "capacity-optimized", SpotAllocationStrategyCapacityOptimizedPrioritized, "price-capacity-optimized"}
+)
 
 type MixedInstances struct {
 	Enabled                             bool     `yaml:"enabled,omitempty"`
@@ -13,10 +31,20 @@ type MixedInstances struct {
 	InstanceTypes                       []string `yaml:"instanceTypes,omitempty"`
 }
 
+// SpotInstancePoolsEnabled returns true when the number of spot instance pools to allocate spot instances across is specified.
+// It is honored only by the lowest-price strategy, which is also the default when no spot allocation strategy is specified
+func (mi MixedInstances) SpotInstancePoolsEnabled() bool {
+	return mi.SpotInstancePools > 0
+}
+
+// SpotInstancesEnabled returns true when spot instances are launched above the on-demand base capacity
+func (mi MixedInstances) SpotInstancesEnabled() bool {
+	return mi.Enabled && mi.OnDemandPercentageAboveBaseCapacity < 100
+}
+
 func (mi MixedInstances) Validate() error {
-	// See https://docs.aws.amazon.com/AWSCloudFormation/latest/UserGuide/aws-properties-autoscaling-autoscalinggroup-instancesdistribution.html for valid values
-	if mi.OnDemandAllocationStrategy != "" && mi.OnDemandAllocationStrategy != "prioritized" {
-		return fmt.Errorf("`mixedInstances.onDemandAllocationStrategy` must be equal to 'prioritized' if specified")
+	if mi.OnDemandAllocationStrategy != "" && !containsString(onDemandAllocationStrategies, mi.OnDemandAllocationStrategy) {
+		return fmt.Errorf("`mixedInstances.onDemandAllocationStrategy` must be one of %s if specified, but was '%s'", quoteAll(onDemandAllocationStrategies), mi.OnDemandAllocationStrategy)
 	}
 	if mi.OnDemandBaseCapacity < 0 {
 		return fmt.Errorf("`mixedInstances.onDemandBaseCapacity` (%d) must be zero or greater if specified", mi.OnDemandBaseCapacity)
@@ -24,15 +52,65 @@ func (mi MixedInstances) Validate() error {
 	if mi.OnDemandPercentageAboveBaseCapacity < 0 || mi.OnDemandPercentageAboveBaseCapacity > 100 {
 		return fmt.Errorf("`mixedInstances.onDemandPercentageAboveBaseCapacity` (%d) must be in range 0-100", mi.OnDemandPercentageAboveBaseCapacity)
 	}
-	if mi.SpotAllocationStrategy != "" && mi.SpotAllocationStrategy != "lowest-price" {
-		return fmt.Errorf("`mixedInstances.spotAllocationStrategy` must be equal to 'lowest-price' if specified")
+	if mi.SpotAllocationStrategy != "" && !containsString(spotAllocationStrategies, mi.SpotAllocationStrategy) {
+		return fmt.Errorf("`mixedInstances.spotAllocationStrategy` must be one of %s if specified, but was '%s'", quoteAll(spotAllocationStrategies), mi.SpotAllocationStrategy)
 	}
 	if mi.SpotInstancePools < 0 || mi.SpotInstancePools > 20 {
 		return fmt.Errorf("`mixedInstances.spotInstancePools` (%d) must be in range 0-20", mi.SpotInstancePools)
 	}
+	if mi.SpotInstancePoolsEnabled() && mi.SpotAllocationStrategy != "" && mi.SpotAllocationStrategy != SpotAllocationStrategyLowestPrice {
+		return fmt.Errorf("`mixedInstances.spotInstancePools` can only be specified with the '%s' spot allocation strategy, but `mixedInstances.spotAllocationStrategy` was '%s'", SpotAllocationStrategyLowestPrice, mi.SpotAllocationStrategy)
+	}
+	if mi.SpotAllocationStrategy == SpotAllocationStrategyCapacityOptimizedPrioritized {
+		if err := mi.validatePrioritizedInstanceTypes(); err != nil {
+			return err
+		}
+	}
 	if len(mi.SpotMaxPrice) > 255 {
 		return fmt.Errorf("`mixedInstances.spotMaxPrice` can have a maximum length of 255")
 	}
+	if mi.SpotMaxPrice != "" {
+		if price, err := strconv.ParseFloat(mi.SpotMaxPrice, 64); err != nil || price <= 0 {
+			return fmt.Errorf("`mixedInstances.spotMaxPrice` must be a positive decimal number of the maximum hourly price in USD if specified, but was '%s'", mi.SpotMaxPrice)
+		}
+	}
+	if mi.OnDemandPercentageAboveBaseCapacity == 100 && (mi.SpotAllocationStrategy != "" || mi.SpotInstancePoolsEnabled() || mi.SpotMaxPrice != "") {
+		return fmt.Errorf("`mixedInstances.spotAllocationStrategy`, `mixedInstances.spotInstancePools` and `mixedInstances.spotMaxPrice` must not be specified when `mixedInstances.onDemandPercentageAboveBaseCapacity` is 100, as no spot instances would be launched")
+	}
 
 	return nil
 }
+
+// validatePrioritizedInstanceTypes ensures that instanceTypes is a list of distinct instance types, whose order is rendered into
+// the launch template overrides as the priority of each instance type
+func (mi MixedInstances) validatePrioritizedInstanceTypes() error {
+	if len(mi.InstanceTypes) < 2 {
+		return fmt.Errorf("`mixedInstances.instanceTypes` must list 2 or more instance types in the order of priority for the '%s' spot allocation strategy, but was %v", mi.SpotAllocationStrategy, mi.InstanceTypes)
+	}
+	for i, t := range mi.InstanceTypes {
+		if t == "" {
+			return fmt.Errorf("`mixedInstances.instanceTypes[%d]` must not be empty", i)
+		}
+		if containsString(mi.InstanceTypes[:i], t) {
+			return fmt.Errorf("`mixedInstances.instanceTypes` must not contain the duplicate instance type '%s', as the order of instance types is the priority for the '%s' spot allocation strategy", t, mi.SpotAllocationStrategy)
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func quoteAll(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("'%s'", v)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -1,5 +1,11 @@
 package api
 
+// NetworkPluginCustom is the self-hosting type for a CNI installed from the manifests supplied by the user
+const NetworkPluginCustom = "custom"
+
+// NetworkPluginAmazonVPCCNI is the self-hosting type for the Amazon VPC CNI, which is equivalent to `amazonVPC.enabled: true`
+const NetworkPluginAmazonVPCCNI = "amazon-vpc-cni"
+
 type Networking struct {
 	AmazonVPC   AmazonVPC   `yaml:"amazonVPC,omitempty"`
 	SelfHosting SelfHosting `yaml:"selfHosting,omitempty"`
@@ -13,4 +19,55 @@ type SelfHosting struct {
 	FlannelImage    Image  `yaml:"flannelImage"`
 	FlannelCniImage Image  `yaml:"flannelCniImage"`
 	TyphaImage      Image  `yaml:"typhaImage"`
+	// AmazonVPCCNIImage is the image of the aws-node daemonset run when the Amazon VPC CNI is enabled
+	AmazonVPCCNIImage Image `yaml:"amazonVPCCNIImage"`
+	// Custom is the CNI installed when Type is "custom"
+	Custom CustomNetworkPlugin `yaml:"custom,omitempty"`
+}
+
+// CustomNetworkPluginEnabled returns true when the CNI is installed from the manifests supplied by the user
+func (n Networking) CustomNetworkPluginEnabled() bool {
+	return !n.AmazonVPC.Enabled && n.SelfHosting.Type == NetworkPluginCustom
+}
+
+// consumeNetworkPluginType enables the Amazon VPC CNI when it is selected via `selfHosting.type`,
+// so that the rest of kube-aws only has to look at `amazonVPC.enabled`
+func (n *Networking) consumeNetworkPluginType() {
+	if n.SelfHosting.Type == NetworkPluginAmazonVPCCNI {
+		n.AmazonVPC.Enabled = true
+	}
+}
+
+// AllocateNodeCIDRs returns true when the controller manager should allocate a pod CIDR from `podCIDR` to each node
+func (n Networking) AllocateNodeCIDRs() bool {
+	if n.AmazonVPC.Enabled {
+		return false
+	}
+	if n.CustomNetworkPluginEnabled() {
+		return n.SelfHosting.Custom.AllocateNodeCIDRs == nil || *n.SelfHosting.Custom.AllocateNodeCIDRs
+	}
+	return true
+}
+
+// HostInterface returns the host interface, or the pattern of it, through which pod traffic goes.
+// kube2iam and kiam intercept the traffic to the EC2 metadata service on it
+func (n Networking) HostInterface() string {
+	switch {
+	case n.AmazonVPC.Enabled:
+		return "!eni0"
+	case n.SelfHosting.Type == "canal":
+		return "cali+"
+	case n.CustomNetworkPluginEnabled():
+		return n.SelfHosting.Custom.HostInterface
+	default:
+		return "cni0"
+	}
+}
+
+// SupportsNetworkPolicy returns true when the CNI enforces NetworkPolicy
+func (n Networking) SupportsNetworkPolicy() bool {
+	if n.AmazonVPC.Enabled {
+		return false
+	}
+	return n.SelfHosting.Type == "canal" || n.CustomNetworkPluginEnabled() && n.SelfHosting.Custom.NetworkPolicy
 }
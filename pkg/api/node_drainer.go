@@ -2,13 +2,112 @@ package api
 
 import (
 	"fmt"
+	"math"
+	"strings"
 	"time"
 )
 
+// defaultDrainTimeout is the timeout of each `kubectl drain` run by the node drainer, which is retried until the node is drained
+const defaultDrainTimeout = "60s"
+
 type NodeDrainer struct {
-	Enabled      bool    `yaml:"enabled"`
-	DrainTimeout int     `yaml:"drainTimeout"`
-	IAMRole      IAMRole `yaml:"iamRole,omitempty"`
+	Enabled      bool          `yaml:"enabled"`
+	DrainTimeout int           `yaml:"drainTimeout"`
+	IAMRole      IAMRole       `yaml:"iamRole,omitempty"`
+	Drain        DrainSettings `yaml:"drain,omitempty"`
+}
+
+// DrainSettings configures how the node drainer runs `kubectl drain` against the terminating node
+type DrainSettings struct {
+	// Timeout is the duration like `60s` each `kubectl drain` waits for the pods to be evicted before it is retried. Defaults to 60s
+	Timeout string `yaml:"timeout,omitempty"`
+	// GracePeriod is the duration like `30s` given to each pod to terminate gracefully, overriding the pod's own
+	// `terminationGracePeriodSeconds`. Defaults to the pod's own
+	GracePeriod string `yaml:"gracePeriod,omitempty"`
+	// Force is whether the pods not managed by controllers are deleted too. Defaults to true
+	Force *bool `yaml:"force,omitempty"`
+	// IgnoreDaemonSets is whether the pods managed by daemon sets are left running. Defaults to true.
+	// Disabling it prevents nodes running daemon sets from being drained at all, as `kubectl drain` refuses to drain them
+	IgnoreDaemonSets *bool `yaml:"ignoreDaemonSets,omitempty"`
+}
+
+func (s DrainSettings) TimeoutOrDefault() string {
+	if s.Timeout == "" {
+		return defaultDrainTimeout
+	}
+	return s.Timeout
+}
+
+// KubectlDrainFlags returns the flags of `kubectl drain` run by the node drainer
+func (s DrainSettings) KubectlDrainFlags() string {
+	force, ignoreDaemonSets := true, true
+	if s.Force != nil {
+		force = *s.Force
+	}
+	if s.IgnoreDaemonSets != nil {
+		ignoreDaemonSets = *s.IgnoreDaemonSets
+	}
+	flags := []string{
+		fmt.Sprintf("--ignore-daemonsets=%t", ignoreDaemonSets),
+		"--delete-local-data=true",
+		fmt.Sprintf("--force=%t", force),
+		"--timeout=" + s.TimeoutOrDefault(),
+	}
+	if s.GracePeriod != "" {
+		d, _ := time.ParseDuration(s.GracePeriod)
+		flags = append(flags, fmt.Sprintf("--grace-period=%d", int(math.Ceil(d.Seconds()))))
+	}
+	return strings.Join(flags, " ")
+}
+
+func (s DrainSettings) Validate() error {
+	for _, d := range []struct {
+		name     string
+		duration string
+	}{
+		{"timeout", s.Timeout},
+		{"gracePeriod", s.GracePeriod},
+	} {
+		if d.duration == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(d.duration)
+		if err != nil {
+			return fmt.Errorf("invalid `experimental.nodeDrainer.drain.%s` \"%s\": %v", d.name, d.duration, err)
+		}
+		if parsed <= 0 {
+			return fmt.Errorf("`experimental.nodeDrainer.drain.%s` must be a positive duration like 60s, but was \"%s\"", d.name, d.duration)
+		}
+	}
+	return nil
+}
+
+// NodeDrainerEnabled returns true when either controller nodes or the nodes of any node pool are drained on termination.
+// Every node is drained by the same daemon set, which reacts to the lifecycle actions reported by the status updater
+func (c Cluster) NodeDrainerEnabled() bool {
+	if c.Experimental.NodeDrainer.Enabled {
+		return true
+	}
+	for _, p := range c.Worker.NodePools {
+		if p.NodeDrainer.Enabled {
+			return true
+		}
+	}
+	return false
+}
+
+// validateNodeDrainer rejects the settings of the node drainer which are shared among all the nodes and hence can't be customized per node pool
+func (c WorkerNodePool) validateNodeDrainer() error {
+	if !c.NodeDrainer.Enabled {
+		return nil
+	}
+	if c.NodeDrainer.Drain != (DrainSettings{}) {
+		return fmt.Errorf("`worker.nodePools[].nodeDrainer.drain` can't be customized for node pool \"%s\" as every node is drained by the same daemon set. Use `experimental.nodeDrainer.drain` instead", c.NodePoolName)
+	}
+	if c.NodeDrainer.IAMRole.ARN.Arn != "" {
+		return fmt.Errorf("`worker.nodePools[].nodeDrainer.iamRole` can't be customized for node pool \"%s\" as it is the role of the status updater shared among all the node pools. Use `experimental.nodeDrainer.iamRole` instead", c.NodePoolName)
+	}
+	return nil
 }
 
 func (nd *NodeDrainer) DrainTimeoutInSeconds() int {
@@ -24,5 +123,9 @@ func (nd *NodeDrainer) Validate() error {
 		return fmt.Errorf("Drain timeout must be an integer between 1 and 60, but was %d", nd.DrainTimeout)
 	}
 
+	if err := nd.Drain.Validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -92,3 +92,98 @@ func TestValidate(t *testing.T) {
 		}
 	}
 }
+
+func TestDrainSettingsKubectlDrainFlags(t *testing.T) {
+	disabled := false
+
+	testCases := []struct {
+		drain    DrainSettings
+		expected string
+	}{
+		{
+			drain:    DrainSettings{},
+			expected: "--ignore-daemonsets=true --delete-local-data=true --force=true --timeout=60s",
+		},
+		{
+			drain:    DrainSettings{Timeout: "2m", GracePeriod: "1500ms", Force: &disabled, IgnoreDaemonSets: &disabled},
+			expected: "--ignore-daemonsets=false --delete-local-data=true --force=false --timeout=2m --grace-period=2",
+		},
+	}
+
+	for i, testCase := range testCases {
+		if actual := testCase.drain.KubectlDrainFlags(); actual != testCase.expected {
+			t.Errorf("case %d: expected \"%s\" but was \"%s\"", i, testCase.expected, actual)
+		}
+	}
+}
+
+func TestDrainSettingsValidate(t *testing.T) {
+	testCases := []struct {
+		drain   DrainSettings
+		isValid bool
+	}{
+		// Valid, not configured
+		{
+			drain:   DrainSettings{},
+			isValid: true,
+		},
+		// Valid, positive durations
+		{
+			drain:   DrainSettings{Timeout: "90s", GracePeriod: "30s"},
+			isValid: true,
+		},
+		// Invalid, not a duration
+		{
+			drain:   DrainSettings{Timeout: "60"},
+			isValid: false,
+		},
+		// Invalid, zero timeout
+		{
+			drain:   DrainSettings{Timeout: "0s"},
+			isValid: false,
+		},
+		// Invalid, negative grace period
+		{
+			drain:   DrainSettings{GracePeriod: "-1s"},
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.drain.Validate()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.drain, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.drain)
+		}
+	}
+}
+
+func TestClusterNodeDrainerEnabled(t *testing.T) {
+	drainedPool := WorkerNodePool{Experimental: Experimental{NodeDrainer: NodeDrainer{Enabled: true}}}
+
+	testCases := []struct {
+		cluster  Cluster
+		expected bool
+	}{
+		{
+			cluster:  Cluster{Worker: Worker{NodePools: []WorkerNodePool{{}}}},
+			expected: false,
+		},
+		{
+			cluster:  Cluster{DeploymentSettings: DeploymentSettings{Experimental: Experimental{NodeDrainer: NodeDrainer{Enabled: true}}}},
+			expected: true,
+		},
+		{
+			cluster:  Cluster{Worker: Worker{NodePools: []WorkerNodePool{{}, drainedPool}}},
+			expected: true,
+		},
+	}
+
+	for i, testCase := range testCases {
+		if actual := testCase.cluster.NodeDrainerEnabled(); actual != testCase.expected {
+			t.Errorf("case %d: expected %v but was %v", i, testCase.expected, actual)
+		}
+	}
+}
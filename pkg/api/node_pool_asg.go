@@ -0,0 +1,166 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// NodePoolAutoScalingGroup is one of the auto scaling groups a worker node pool is made of.
+// A node pool has a single auto scaling group spanning all its subnets unless `autoScalingGroup.subnetWeights` is specified
+type NodePoolAutoScalingGroup struct {
+	LogicalName                        string
+	Subnets                            []Subnet
+	MinCount                           int
+	MaxCount                           int
+	RollingUpdateMinInstancesInService int
+	// WarmPoolMinSize and WarmPoolMaxPreparedCapacity are the share of the group in the node pool's warm pool.
+	// WarmPoolMaxPreparedCapacity is -1 when it defaults to the max size of the group
+	WarmPoolMinSize             int
+	WarmPoolMaxPreparedCapacity int
+}
+
+// SubnetWeightsEnabled returns true when the node pool is split into one auto scaling group per subnet
+func (c WorkerNodePool) SubnetWeightsEnabled() bool {
+	return len(c.AutoScalingGroup.SubnetWeights) > 0
+}
+
+// AutoScalingGroups returns the auto scaling groups of this node pool in the order of its subnets
+func (c WorkerNodePool) AutoScalingGroups() []NodePoolAutoScalingGroup {
+	warmPool := c.Autoscaling.WarmPool
+	warmPoolMaxPreparedCapacity := -1
+	if warmPool.MaxPreparedCapacity != nil {
+		warmPoolMaxPreparedCapacity = *warmPool.MaxPreparedCapacity
+	}
+
+	if !c.SubnetWeightsEnabled() {
+		return []NodePoolAutoScalingGroup{
+			{
+				LogicalName:                        c.LogicalName(),
+				Subnets:                            c.Subnets,
+				MinCount:                           c.MinCount(),
+				MaxCount:                           c.MaxCount(),
+				RollingUpdateMinInstancesInService: c.RollingUpdateMinInstancesInService(),
+				WarmPoolMinSize:                    warmPool.MinSize,
+				WarmPoolMaxPreparedCapacity:        warmPoolMaxPreparedCapacity,
+			},
+		}
+	}
+
+	weights := make([]int, len(c.Subnets))
+	for i, s := range c.Subnets {
+		weights[i] = c.AutoScalingGroup.SubnetWeights[s.Name]
+	}
+
+	mins := distributeByWeights(c.MinCount(), weights)
+	maxes := distributeByWeights(c.MaxCount(), weights)
+	var minsInService []int
+	if min := c.rollingUpdateMinInstancesInService(); min != nil {
+		minsInService = distributeByWeights(*min, weights)
+	}
+	warmPoolMins := distributeByWeights(warmPool.MinSize, weights)
+	var warmPoolMaxPreparedCapacities []int
+	if warmPool.MaxPreparedCapacity != nil {
+		warmPoolMaxPreparedCapacities = distributeByWeights(*warmPool.MaxPreparedCapacity, weights)
+	}
+
+	groups := make([]NodePoolAutoScalingGroup, len(c.Subnets))
+	for i := range c.Subnets {
+		s := c.Subnets[i]
+		maxInService := 0
+		if maxes[i] > 0 {
+			maxInService = maxes[i] - 1
+		}
+		minInService := maxInService
+		if minsInService != nil && minsInService[i] < maxInService {
+			minInService = minsInService[i]
+		}
+		maxPreparedCapacity := -1
+		if warmPoolMaxPreparedCapacities != nil {
+			maxPreparedCapacity = warmPoolMaxPreparedCapacities[i]
+		}
+		groups[i] = NodePoolAutoScalingGroup{
+			LogicalName:                        c.LogicalName() + s.LogicalName(),
+			Subnets:                            []Subnet{s},
+			MinCount:                           mins[i],
+			MaxCount:                           maxes[i],
+			RollingUpdateMinInstancesInService: minInService,
+			WarmPoolMinSize:                    warmPoolMins[i],
+			WarmPoolMaxPreparedCapacity:        maxPreparedCapacity,
+		}
+	}
+	return groups
+}
+
+// MetadataLogicalName returns the logical name of the resource cfn-init reads the metadata of the node pool from
+func (c WorkerNodePool) MetadataLogicalName() string {
+	return c.AutoScalingGroups()[0].LogicalName
+}
+
+// ValidateSubnetWeights validates `autoScalingGroup.subnetWeights` against the subnets the node pool is finally deployed to
+func (c WorkerNodePool) ValidateSubnetWeights() error {
+	if !c.SubnetWeightsEnabled() {
+		return nil
+	}
+
+	if c.SpotFleet.Enabled() {
+		return errors.New("`autoScalingGroup.subnetWeights` can't be specified for a node pool backed by a spot fleet")
+	}
+
+	names := map[string]bool{}
+	for _, s := range c.Subnets {
+		if _, ok := c.AutoScalingGroup.SubnetWeights[s.Name]; !ok {
+			return fmt.Errorf("`autoScalingGroup.subnetWeights` must have a weight for every subnet of the node pool, but the subnet \"%s\" is missing", s.Name)
+		}
+		names[s.Name] = true
+	}
+	for name := range c.AutoScalingGroup.SubnetWeights {
+		if !names[name] {
+			return fmt.Errorf("the subnet \"%s\" in `autoScalingGroup.subnetWeights` isn't one of the subnets of the node pool", name)
+		}
+	}
+
+	for _, g := range c.AutoScalingGroups() {
+		if g.MaxCount < 1 {
+			return fmt.Errorf("`autoScalingGroup.maxSize` %d is too small to be distributed among the %d subnets according to `autoScalingGroup.subnetWeights`: the auto scaling group in the subnet \"%s\" would have no nodes. Increase maxSize or the weight of the subnet",
+				c.MaxCount(), len(c.Subnets), g.Subnets[0].Name)
+		}
+	}
+
+	return nil
+}
+
+// distributeByWeights splits total into the parts proportional to the weights with the largest remainder method,
+// so that the parts always sum up to the total. Ties are broken in favor of the earlier weight
+func distributeByWeights(total int, weights []int) []int {
+	sum := 0
+	for _, w := range weights {
+		sum += w
+	}
+
+	parts := make([]int, len(weights))
+	if sum == 0 {
+		return parts
+	}
+
+	remainders := make([]int, len(weights))
+	rest := total
+	for i, w := range weights {
+		parts[i] = total * w / sum
+		remainders[i] = total * w % sum
+		rest -= parts[i]
+	}
+
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]] > remainders[order[b]]
+	})
+	for i := 0; i < rest; i++ {
+		parts[order[i]]++
+	}
+
+	return parts
+}
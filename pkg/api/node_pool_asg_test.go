@@ -0,0 +1,166 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDistributeByWeights(t *testing.T) {
+	testCases := []struct {
+		total    int
+		weights  []int
+		expected []int
+	}{
+		{total: 10, weights: []int{6, 3, 1}, expected: []int{6, 3, 1}},
+		{total: 2, weights: []int{6, 3, 1}, expected: []int{1, 1, 0}},
+		{total: 5, weights: []int{1, 1}, expected: []int{3, 2}},
+		{total: 0, weights: []int{2, 1}, expected: []int{0, 0}},
+		{total: 7, weights: []int{1, 1, 1}, expected: []int{3, 2, 2}},
+	}
+
+	for i, testCase := range testCases {
+		actual := distributeByWeights(testCase.total, testCase.weights)
+		if !reflect.DeepEqual(actual, testCase.expected) {
+			t.Errorf("case %d: expected %d to be distributed by %v into %v but was %v", i, testCase.total, testCase.weights, testCase.expected, actual)
+		}
+	}
+}
+
+func TestNodePoolAutoScalingGroups(t *testing.T) {
+	minSize := 2
+	minInService := 4
+	subnets := []Subnet{{Name: "public1"}, {Name: "public2"}, {Name: "public3"}}
+
+	single := WorkerNodePool{
+		DeploymentSettings: DeploymentSettings{Subnets: subnets},
+		AutoScalingGroup:   AutoScalingGroup{MinSize: &minSize, MaxSize: 10},
+	}
+	groups := single.AutoScalingGroups()
+	if len(groups) != 1 {
+		t.Fatalf("expected a single auto scaling group but was %+v", groups)
+	}
+	if g := groups[0]; g.LogicalName != "Workers" || len(g.Subnets) != 3 || g.MinCount != 2 || g.MaxCount != 10 || g.RollingUpdateMinInstancesInService != 9 {
+		t.Errorf("unexpected auto scaling group: %+v", g)
+	}
+
+	weighted := WorkerNodePool{
+		DeploymentSettings: DeploymentSettings{Subnets: subnets},
+		AutoScalingGroup: AutoScalingGroup{
+			MinSize:                            &minSize,
+			MaxSize:                            10,
+			RollingUpdateMinInstancesInService: &minInService,
+			SubnetWeights:                      map[string]int{"public1": 6, "public2": 3, "public3": 1},
+		},
+	}
+	expected := []struct {
+		logicalName                                            string
+		minCount, maxCount, rollingUpdateMinInstancesInService int
+	}{
+		{"WorkersPublic1", 1, 6, 3},
+		{"WorkersPublic2", 1, 3, 1},
+		{"WorkersPublic3", 0, 1, 0},
+	}
+	groups = weighted.AutoScalingGroups()
+	if len(groups) != len(expected) {
+		t.Fatalf("expected %d auto scaling groups but was %+v", len(expected), groups)
+	}
+	for i, e := range expected {
+		g := groups[i]
+		if g.LogicalName != e.logicalName || len(g.Subnets) != 1 || g.Subnets[0].Name != subnets[i].Name ||
+			g.MinCount != e.minCount || g.MaxCount != e.maxCount || g.RollingUpdateMinInstancesInService != e.rollingUpdateMinInstancesInService {
+			t.Errorf("case %d: unexpected auto scaling group: %+v", i, g)
+		}
+	}
+	if n := weighted.MetadataLogicalName(); n != "WorkersPublic1" {
+		t.Errorf("expected the metadata to be read from WorkersPublic1 but was %s", n)
+	}
+}
+
+func TestNodePoolAutoScalingGroupsWithWarmPool(t *testing.T) {
+	maxPreparedCapacity := 10
+	subnets := []Subnet{{Name: "public1"}, {Name: "public2"}, {Name: "public3"}}
+
+	single := WorkerNodePool{
+		DeploymentSettings: DeploymentSettings{Subnets: subnets},
+		Autoscaling:        Autoscaling{WarmPool: WarmPool{Enabled: true, MinSize: 2}},
+	}
+	if g := single.AutoScalingGroups()[0]; g.WarmPoolMinSize != 2 || g.WarmPoolMaxPreparedCapacity != -1 {
+		t.Errorf("unexpected warm pool of the auto scaling group: %+v", g)
+	}
+
+	weighted := WorkerNodePool{
+		DeploymentSettings: DeploymentSettings{Subnets: subnets},
+		Autoscaling:        Autoscaling{WarmPool: WarmPool{Enabled: true, MinSize: 2, MaxPreparedCapacity: &maxPreparedCapacity}},
+		AutoScalingGroup: AutoScalingGroup{
+			MaxSize:       10,
+			SubnetWeights: map[string]int{"public1": 6, "public2": 3, "public3": 1},
+		},
+	}
+	expected := []struct {
+		minSize, maxPreparedCapacity int
+	}{
+		{1, 6},
+		{1, 3},
+		{0, 1},
+	}
+	for i, g := range weighted.AutoScalingGroups() {
+		if g.WarmPoolMinSize != expected[i].minSize || g.WarmPoolMaxPreparedCapacity != expected[i].maxPreparedCapacity {
+			t.Errorf("case %d: unexpected warm pool of the auto scaling group: %+v", i, g)
+		}
+	}
+}
+
+func TestValidateSubnetWeights(t *testing.T) {
+	minSize := 1
+	subnets := []Subnet{{Name: "public1"}, {Name: "public2"}}
+
+	testCases := []struct {
+		maxSize int
+		weights map[string]int
+		isValid bool
+	}{
+		// Valid, without weights
+		{
+			maxSize: 1,
+			isValid: true,
+		},
+		// Valid, every subnet gets a node at least
+		{
+			maxSize: 3,
+			weights: map[string]int{"public1": 2, "public2": 1},
+			isValid: true,
+		},
+		// Invalid, missing weight
+		{
+			maxSize: 3,
+			weights: map[string]int{"public1": 2},
+			isValid: false,
+		},
+		// Invalid, unknown subnet
+		{
+			maxSize: 3,
+			weights: map[string]int{"public1": 2, "public2": 1, "private1": 1},
+			isValid: false,
+		},
+		// Invalid, maxSize too small to retain the spread
+		{
+			maxSize: 2,
+			weights: map[string]int{"public1": 5, "public2": 1},
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		np := WorkerNodePool{
+			DeploymentSettings: DeploymentSettings{Subnets: subnets},
+			AutoScalingGroup:   AutoScalingGroup{MinSize: &minSize, MaxSize: testCase.maxSize, SubnetWeights: testCase.weights},
+		}
+		err := np.ValidateSubnetWeights()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %v to be valid but got an error: %v", i, testCase.weights, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %v to be invalid but was not", i, testCase.weights)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// cfnLogicalIDPattern matches logical IDs of CloudFormation resources, which must be alphanumeric
+var cfnLogicalIDPattern = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
+// customResourceAttributes are the attributes allowed in a custom resource. `Condition` isn't allowed because
+// node pool stacks don't define conditions custom resources could refer to
+var customResourceAttributes = []string{
+	"Type",
+	"Properties",
+	"DependsOn",
+	"Metadata",
+	"DeletionPolicy",
+	"UpdateReplacePolicy",
+	"CreationPolicy",
+	"UpdatePolicy",
+}
+
+// customResourceIntrinsicFunctions are the intrinsic functions custom resources can use in addition to `Ref`.
+// Condition functions and `Fn::Transform` are excluded because node pool stacks define neither conditions nor transforms
+var customResourceIntrinsicFunctions = []string{
+	"Fn::Base64",
+	"Fn::Cidr",
+	"Fn::FindInMap",
+	"Fn::GetAtt",
+	"Fn::GetAZs",
+	"Fn::ImportValue",
+	"Fn::Join",
+	"Fn::Select",
+	"Fn::Split",
+	"Fn::Sub",
+}
+
+// CustomCfnResources returns `customResources` parsed into CloudFormation resources, each given either as a JSON string or as a YAML map
+func (c WorkerNodePool) CustomCfnResources() (map[string]interface{}, error) {
+	resources := map[string]interface{}{}
+	for name, r := range c.CustomResources {
+		if s, ok := r.(string); ok {
+			var parsed interface{}
+			if err := json.Unmarshal([]byte(s), &parsed); err != nil {
+				return nil, fmt.Errorf("invalid custom resource \"%s\": %v", name, err)
+			}
+			r = parsed
+		}
+		resources[name] = normalizeYAMLValue(r)
+	}
+	return resources, nil
+}
+
+func (c WorkerNodePool) validateCustomResources() error {
+	resources, err := c.CustomCfnResources()
+	if err != nil {
+		return err
+	}
+
+	names := []string{}
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !cfnLogicalIDPattern.MatchString(name) {
+			return fmt.Errorf("invalid custom resource name \"%s\": it must be alphanumeric", name)
+		}
+		if err := validateCustomResource(resources[name]); err != nil {
+			return fmt.Errorf("invalid custom resource \"%s\": %v", name, err)
+		}
+	}
+	return nil
+}
+
+func validateCustomResource(r interface{}) error {
+	resource, ok := r.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("it must be an object, but was %v", r)
+	}
+	for k := range resource {
+		if !containsString(customResourceAttributes, k) {
+			return fmt.Errorf("unsupported attribute \"%s\": it must be one of %s", k, strings.Join(customResourceAttributes, ", "))
+		}
+	}
+	if t, ok := resource["Type"].(string); !ok || !strings.Contains(t, "::") {
+		return fmt.Errorf("`Type` must be a resource type like AWS::SNS::Topic, but was %v", resource["Type"])
+	}
+	return validateIntrinsicFunctions(resource)
+}
+
+// validateIntrinsicFunctions returns an error when the value uses an intrinsic function other than `Ref` and customResourceIntrinsicFunctions
+func validateIntrinsicFunctions(v interface{}) error {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			if strings.HasPrefix(k, "Fn::") && !containsString(customResourceIntrinsicFunctions, k) {
+				return fmt.Errorf("unsupported intrinsic function \"%s\": it must be Ref or one of %s", k, strings.Join(customResourceIntrinsicFunctions, ", "))
+			}
+			if err := validateIntrinsicFunctions(e); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, e := range t {
+			if err := validateIntrinsicFunctions(e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestWorkerNodePoolValidateCustomResources(t *testing.T) {
+	testCases := []struct {
+		resources map[string]interface{}
+		isValid   bool
+	}{
+		// Valid, none
+		{
+			resources: nil,
+			isValid:   true,
+		},
+		// Valid, YAML map
+		{
+			resources: map[string]interface{}{
+				"Topic": map[interface{}]interface{}{"Type": "AWS::SNS::Topic"},
+			},
+			isValid: true,
+		},
+		// Valid, JSON string with allowed intrinsic functions
+		{
+			resources: map[string]interface{}{
+				"Alarm": `{"Type": "AWS::CloudWatch::Alarm", "DependsOn": "Topic", "Properties": {"AlarmActions": [{"Ref": "Topic"}], "AlarmName": {"Fn::Sub": "${AWS::StackName}-cpu"}}}`,
+			},
+			isValid: true,
+		},
+		// Invalid, malformed JSON
+		{
+			resources: map[string]interface{}{
+				"Topic": `{"Type": "AWS::SNS::Topic"`,
+			},
+			isValid: false,
+		},
+		// Invalid, not an object
+		{
+			resources: map[string]interface{}{
+				"Topic": `["AWS::SNS::Topic"]`,
+			},
+			isValid: false,
+		},
+		// Invalid, non-alphanumeric name
+		{
+			resources: map[string]interface{}{
+				"my-topic": `{"Type": "AWS::SNS::Topic"}`,
+			},
+			isValid: false,
+		},
+		// Invalid, missing type
+		{
+			resources: map[string]interface{}{
+				"Topic": `{"Properties": {}}`,
+			},
+			isValid: false,
+		},
+		// Invalid, condition
+		{
+			resources: map[string]interface{}{
+				"Topic": `{"Type": "AWS::SNS::Topic", "Condition": "IsProduction"}`,
+			},
+			isValid: false,
+		},
+		// Invalid, disallowed intrinsic function
+		{
+			resources: map[string]interface{}{
+				"Topic": `{"Type": "AWS::SNS::Topic", "Properties": {"TopicName": {"Fn::If": ["IsProduction", "prod", "dev"]}}}`,
+			},
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		pool := WorkerNodePool{CustomResources: testCase.resources}
+		err := pool.validateCustomResources()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.resources, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.resources)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// NodePoolDeploymentStrategyRollingUpdate replaces the nodes of the auto scaling groups in place, a batch at a time
+	NodePoolDeploymentStrategyRollingUpdate = "rollingUpdate"
+	// NodePoolDeploymentStrategyBlueGreen replaces each auto scaling group with a new one, which is created alongside the old one
+	NodePoolDeploymentStrategyBlueGreen = "blueGreen"
+)
+
+var nodePoolDeploymentStrategies = []string{NodePoolDeploymentStrategyRollingUpdate, NodePoolDeploymentStrategyBlueGreen}
+
+// maxCfnResourceSignalTimeout is the maximum timeout of waiting for resource signals accepted by CloudFormation
+const maxCfnResourceSignalTimeout = 12 * time.Hour
+
+// cfnDurationPattern matches ISO 8601 durations like `PT15M` CloudFormation accepts as timeouts
+var cfnDurationPattern = regexp.MustCompile(`^PT(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?$`)
+
+// NodePoolBlueGreen configures the blue/green deployment of a node pool, in which CloudFormation creates a new (green) auto scaling group
+// alongside the old (blue) one on every update replacing the nodes, waits for all the green nodes to be ready, and then deletes the blue one.
+// The blue nodes are drained before they are terminated when `experimental.nodeDrainer` is enabled
+type NodePoolBlueGreen struct {
+	// SoakTime is the duration like `30m` the green nodes keep running alongside the blue ones after they become ready, before the blue
+	// auto scaling group is decommissioned. Defaults to 0
+	SoakTime string `yaml:"soakTime,omitempty"`
+}
+
+func (c WorkerNodePool) BlueGreenEnabled() bool {
+	return c.DeploymentStrategy == NodePoolDeploymentStrategyBlueGreen
+}
+
+// BlueGreenSoakSeconds returns the number of seconds each node waits after it becomes ready before signalling CloudFormation
+func (c WorkerNodePool) BlueGreenSoakSeconds() int {
+	d, _ := time.ParseDuration(c.BlueGreen.SoakTime)
+	return int(d.Seconds())
+}
+
+// ResourceSignalTimeout returns the duration CloudFormation waits for the nodes of a new auto scaling group to signal, which includes the soak time
+func (c WorkerNodePool) ResourceSignalTimeout() (string, error) {
+	if !c.BlueGreenEnabled() {
+		return c.CreateTimeout, nil
+	}
+	d, err := parseCfnDuration(c.CreateTimeout)
+	if err != nil {
+		return "", fmt.Errorf("invalid `createTimeout` \"%s\": %v", c.CreateTimeout, err)
+	}
+	return fmt.Sprintf("PT%dS", int(d.Seconds())+c.BlueGreenSoakSeconds()), nil
+}
+
+// ValidateDeploymentStrategy validates `deploymentStrategy` and `blueGreen` against the settings the node pool is finally deployed with
+func (c WorkerNodePool) ValidateDeploymentStrategy() error {
+	if c.DeploymentStrategy != "" && !containsString(nodePoolDeploymentStrategies, c.DeploymentStrategy) {
+		return fmt.Errorf("invalid `deploymentStrategy` \"%s\": it must be one of %s", c.DeploymentStrategy, strings.Join(nodePoolDeploymentStrategies, ", "))
+	}
+	if !c.BlueGreenEnabled() {
+		if c.BlueGreen.SoakTime != "" {
+			return errors.New("`blueGreen.soakTime` requires `deploymentStrategy: blueGreen`")
+		}
+		return nil
+	}
+
+	if c.SpotFleet.Enabled() {
+		return errors.New("`deploymentStrategy: blueGreen` can't be used for a node pool backed by a spot fleet")
+	}
+	// Without the signals, CloudFormation deletes the blue auto scaling group without waiting for the green nodes to be ready
+	if !c.WaitSignal.Enabled() {
+		return errors.New("`deploymentStrategy: blueGreen` requires `waitSignal.enabled` to be true")
+	}
+	// The event rule of the lambda node drainer only matches the current auto scaling groups, which are the green ones while the blue ones are being deleted
+	if c.LambdaNodeDrainer.Enabled {
+		return errors.New("`deploymentStrategy: blueGreen` can't be used with `lambdaNodeDrainer`. Use `experimental.nodeDrainer` to drain the blue nodes instead")
+	}
+	if c.BlueGreen.SoakTime != "" {
+		d, err := time.ParseDuration(c.BlueGreen.SoakTime)
+		if err != nil || d < 0 {
+			return fmt.Errorf("`blueGreen.soakTime` must be a non-negative duration like 30m, but was \"%s\"", c.BlueGreen.SoakTime)
+		}
+	}
+	timeout, err := c.ResourceSignalTimeout()
+	if err != nil {
+		return err
+	}
+	if d, _ := parseCfnDuration(timeout); d > maxCfnResourceSignalTimeout {
+		return fmt.Errorf("the sum of `createTimeout` \"%s\" and `blueGreen.soakTime` \"%s\" must not exceed 12 hours, which is the maximum time CloudFormation waits for the nodes to signal",
+			c.CreateTimeout, c.BlueGreen.SoakTime)
+	}
+	return nil
+}
+
+// parseCfnDuration parses an ISO 8601 duration like `PT1H30M` consisting of hours, minutes and seconds
+func parseCfnDuration(s string) (time.Duration, error) {
+	m := cfnDurationPattern.FindStringSubmatch(s)
+	if m == nil || s == "PT" {
+		return 0, fmt.Errorf("it must be an ISO 8601 duration like PT15M")
+	}
+	var d time.Duration
+	for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+		if m[i+1] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(m[i+1])
+		if err != nil {
+			return 0, err
+		}
+		d += time.Duration(n) * unit
+	}
+	return d, nil
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestWorkerNodePoolValidateDeploymentStrategy(t *testing.T) {
+	disabled := false
+	blueGreen := func(f func(*WorkerNodePool)) WorkerNodePool {
+		p := WorkerNodePool{DeploymentStrategy: NodePoolDeploymentStrategyBlueGreen}
+		p.CreateTimeout = "PT15M"
+		f(&p)
+		return p
+	}
+
+	testCases := []struct {
+		pool    WorkerNodePool
+		isValid bool
+	}{
+		// Valid, not configured
+		{
+			pool:    WorkerNodePool{},
+			isValid: true,
+		},
+		// Valid, rolling update
+		{
+			pool:    WorkerNodePool{DeploymentStrategy: NodePoolDeploymentStrategyRollingUpdate},
+			isValid: true,
+		},
+		// Valid, blue/green without soak time
+		{
+			pool:    blueGreen(func(p *WorkerNodePool) {}),
+			isValid: true,
+		},
+		// Valid, blue/green with soak time
+		{
+			pool:    blueGreen(func(p *WorkerNodePool) { p.BlueGreen.SoakTime = "30m" }),
+			isValid: true,
+		},
+		// Invalid, unknown strategy
+		{
+			pool:    WorkerNodePool{DeploymentStrategy: "recreate"},
+			isValid: false,
+		},
+		// Invalid, soak time without blue/green
+		{
+			pool:    WorkerNodePool{BlueGreen: NodePoolBlueGreen{SoakTime: "30m"}},
+			isValid: false,
+		},
+		// Invalid, malformed soak time
+		{
+			pool:    blueGreen(func(p *WorkerNodePool) { p.BlueGreen.SoakTime = "30" }),
+			isValid: false,
+		},
+		// Invalid, negative soak time
+		{
+			pool:    blueGreen(func(p *WorkerNodePool) { p.BlueGreen.SoakTime = "-1m" }),
+			isValid: false,
+		},
+		// Invalid, the total timeout exceeds 12 hours
+		{
+			pool:    blueGreen(func(p *WorkerNodePool) { p.BlueGreen.SoakTime = "12h" }),
+			isValid: false,
+		},
+		// Invalid, wait signal disabled
+		{
+			pool:    blueGreen(func(p *WorkerNodePool) { p.WaitSignal.EnabledOverride = &disabled }),
+			isValid: false,
+		},
+		// Invalid, spot fleet
+		{
+			pool:    blueGreen(func(p *WorkerNodePool) { p.SpotFleet.TargetCapacity = 1 }),
+			isValid: false,
+		},
+		// Invalid, lambda node drainer
+		{
+			pool:    blueGreen(func(p *WorkerNodePool) { p.LambdaNodeDrainer.Enabled = true }),
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.pool.ValidateDeploymentStrategy()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.pool, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.pool)
+		}
+	}
+}
+
+func TestWorkerNodePoolResourceSignalTimeout(t *testing.T) {
+	testCases := []struct {
+		pool     WorkerNodePool
+		expected string
+	}{
+		{
+			pool:     WorkerNodePool{EC2Instance: EC2Instance{CreateTimeout: "PT15M"}},
+			expected: "PT15M",
+		},
+		{
+			pool:     WorkerNodePool{EC2Instance: EC2Instance{CreateTimeout: "PT15M"}, DeploymentStrategy: NodePoolDeploymentStrategyBlueGreen},
+			expected: "PT900S",
+		},
+		{
+			pool: WorkerNodePool{
+				EC2Instance:        EC2Instance{CreateTimeout: "PT1H2M3S"},
+				DeploymentStrategy: NodePoolDeploymentStrategyBlueGreen,
+				BlueGreen:          NodePoolBlueGreen{SoakTime: "30m"},
+			},
+			expected: "PT5523S",
+		},
+	}
+
+	for i, testCase := range testCases {
+		actual, err := testCase.pool.ResourceSignalTimeout()
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if actual != testCase.expected {
+			t.Errorf("case %d: expected %s but was %s", i, testCase.expected, actual)
+		}
+	}
+}
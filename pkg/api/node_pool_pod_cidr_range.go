@@ -0,0 +1,108 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+
+	"github.com/kubernetes-incubator/kube-aws/netutil"
+)
+
+// NodePodCIDRMaskSize is the size of the pod CIDR assigned to each node, which is the default `--node-cidr-mask-size` of the controller manager
+const NodePodCIDRMaskSize = 24
+
+var nodePoolLabelInvalidChars = regexp.MustCompile("[^a-z0-9A-Z_.-]")
+
+// NodePoolPodCIDRRange is the range within `podCIDR` from which the nodes of a node pool are assigned their pod CIDRs
+type NodePoolPodCIDRRange struct {
+	NodePoolName string
+	// NodeLabel is the key of the `node-role.kubernetes.io/<node pool name>` label each node of the node pool registers itself with
+	NodeLabel string
+	CIDR      string
+}
+
+// controllerNodeRoleLabel is the label of controller nodes, which are assigned pod CIDRs outside the ranges of node pools
+const controllerNodeRoleLabel = "node-role.kubernetes.io/master"
+
+// NodePoolPodCIDRRanges returns the ranges of the node pools with `podCIDRRange`
+func (c Cluster) NodePoolPodCIDRRanges() []NodePoolPodCIDRRange {
+	ranges := []NodePoolPodCIDRRange{}
+	for _, p := range c.Worker.NodePools {
+		if p.PodCIDRRange == "" {
+			continue
+		}
+		ranges = append(ranges, NodePoolPodCIDRRange{
+			NodePoolName: p.NodePoolName,
+			NodeLabel:    "node-role.kubernetes.io/" + nodePoolLabelInvalidChars.ReplaceAllString(p.NodePoolName, "_"),
+			CIDR:         p.PodCIDRRange,
+		})
+	}
+	return ranges
+}
+
+// NodePoolPodCIDRRangesEnabled returns true when the pod CIDRs of nodes are assigned by kube-aws from the ranges of their node pools,
+// in place of the controller manager
+func (c Cluster) NodePoolPodCIDRRangesEnabled() bool {
+	return len(c.NodePoolPodCIDRRanges()) > 0
+}
+
+func (c Cluster) NodePodCIDRMaskSize() int {
+	return NodePodCIDRMaskSize
+}
+
+func (c Cluster) validateNodePoolPodCIDRRanges() error {
+	ranges := c.NodePoolPodCIDRRanges()
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	if !c.Kubernetes.Networking.AllocateNodeCIDRs() {
+		return fmt.Errorf("`worker.nodePools[].podCIDRRange` requires nodes to be assigned pod CIDRs from `podCIDR`, which isn't the case with the networking of this cluster")
+	}
+
+	_, podNet, err := net.ParseCIDR(c.PodCIDR)
+	if err != nil {
+		return fmt.Errorf("invalid podCIDR: %v", err)
+	}
+	podOnes, _ := podNet.Mask.Size()
+	if podOnes > NodePodCIDRMaskSize {
+		return fmt.Errorf("podCIDR (%s) must be /%d or larger to be divided among node pools", c.PodCIDR, NodePodCIDRMaskSize)
+	}
+
+	nets := make([]*net.IPNet, len(ranges))
+	var allocated uint64
+	for i, r := range ranges {
+		_, n, err := net.ParseCIDR(r.CIDR)
+		if err != nil {
+			return fmt.Errorf("invalid podCIDRRange of node pool \"%s\": %v", r.NodePoolName, err)
+		}
+		if n.IP.To4() == nil {
+			return fmt.Errorf("podCIDRRange (%s) of node pool \"%s\" must be an IPv4 CIDR", r.CIDR, r.NodePoolName)
+		}
+		// Controller nodes would otherwise be assigned pod CIDRs from the range, as they are labeled in the same way
+		if r.NodeLabel == controllerNodeRoleLabel {
+			return fmt.Errorf("node pool \"%s\" can't have podCIDRRange, as its nodes are labeled %s like controller nodes", r.NodePoolName, controllerNodeRoleLabel)
+		}
+		ones, _ := n.Mask.Size()
+		if ones > NodePodCIDRMaskSize {
+			return fmt.Errorf("podCIDRRange (%s) of node pool \"%s\" must be /%d or larger, as each node is assigned a /%d", r.CIDR, r.NodePoolName, NodePodCIDRMaskSize, NodePodCIDRMaskSize)
+		}
+		if !podNet.Contains(n.IP) || ones < podOnes {
+			return fmt.Errorf("podCIDRRange (%s) of node pool \"%s\" must be within podCIDR (%s)", r.CIDR, r.NodePoolName, c.PodCIDR)
+		}
+		for j := 0; j < i; j++ {
+			if netutil.CidrOverlap(nets[j], n) {
+				return fmt.Errorf("podCIDRRange (%s) of node pool \"%s\" overlaps with the one (%s) of node pool \"%s\"", r.CIDR, r.NodePoolName, ranges[j].CIDR, ranges[j].NodePoolName)
+			}
+		}
+		nets[i] = n
+		allocated += 1 << uint(32-ones)
+	}
+
+	// Controller nodes and node pools without ranges are assigned pod CIDRs from the rest of podCIDR
+	if allocated == 1<<uint(32-podOnes) {
+		return fmt.Errorf("podCIDRRange of node pools must leave a part of podCIDR (%s) for controller nodes", c.PodCIDR)
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNodePoolPodCIDRRanges(t *testing.T) {
+	c := Cluster{
+		Worker: Worker{
+			NodePools: []WorkerNodePool{
+				{NodePoolName: "pool1", PodCIDRRange: "10.2.128.0/18"},
+				{NodePoolName: "pool2"},
+				{NodePoolName: "pool:3", PodCIDRRange: "10.2.192.0/24"},
+			},
+		},
+	}
+
+	expected := []NodePoolPodCIDRRange{
+		{NodePoolName: "pool1", NodeLabel: "node-role.kubernetes.io/pool1", CIDR: "10.2.128.0/18"},
+		{NodePoolName: "pool:3", NodeLabel: "node-role.kubernetes.io/pool_3", CIDR: "10.2.192.0/24"},
+	}
+	if actual := c.NodePoolPodCIDRRanges(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("unexpected node pool pod CIDR ranges: expected %+v, got %+v", expected, actual)
+	}
+	if !c.NodePoolPodCIDRRangesEnabled() {
+		t.Error("expected the node pool pod CIDR ranges to be enabled")
+	}
+}
+
+func TestValidateNodePoolPodCIDRRanges(t *testing.T) {
+	testCases := []struct {
+		podCIDR    string
+		ranges     []string
+		networking Networking
+		isValid    bool
+	}{
+		// Valid, none
+		{
+			podCIDR: "10.2.0.0/16",
+			ranges:  []string{""},
+			isValid: true,
+		},
+		// Valid, non-overlapping ranges
+		{
+			podCIDR: "10.2.0.0/16",
+			ranges:  []string{"10.2.128.0/18", "10.2.64.0/24", ""},
+			isValid: true,
+		},
+		// Valid, custom CNI allocating node CIDRs
+		{
+			podCIDR:    "10.2.0.0/16",
+			ranges:     []string{"10.2.128.0/18"},
+			networking: Networking{SelfHosting: SelfHosting{Type: NetworkPluginCustom}},
+			isValid:    true,
+		},
+		// Invalid, Amazon VPC CNI
+		{
+			podCIDR:    "10.2.0.0/16",
+			ranges:     []string{"10.2.128.0/18"},
+			networking: Networking{AmazonVPC: AmazonVPC{Enabled: true}},
+			isValid:    false,
+		},
+		// Invalid, malformed
+		{
+			podCIDR: "10.2.0.0/16",
+			ranges:  []string{"10.2.128.0"},
+			isValid: false,
+		},
+		// Invalid, smaller than a node CIDR
+		{
+			podCIDR: "10.2.0.0/16",
+			ranges:  []string{"10.2.128.0/25"},
+			isValid: false,
+		},
+		// Invalid, outside podCIDR
+		{
+			podCIDR: "10.2.0.0/16",
+			ranges:  []string{"10.3.0.0/18"},
+			isValid: false,
+		},
+		// Invalid, larger than podCIDR
+		{
+			podCIDR: "10.2.0.0/16",
+			ranges:  []string{"10.0.0.0/8"},
+			isValid: false,
+		},
+		// Invalid, overlapping
+		{
+			podCIDR: "10.2.0.0/16",
+			ranges:  []string{"10.2.128.0/18", "10.2.160.0/20"},
+			isValid: false,
+		},
+		// Invalid, no room left for controller nodes
+		{
+			podCIDR: "10.2.0.0/16",
+			ranges:  []string{"10.2.0.0/17", "10.2.128.0/17"},
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		c := Cluster{
+			KubeClusterSettings: KubeClusterSettings{PodCIDR: testCase.podCIDR},
+			Kubernetes:          Kubernetes{Networking: testCase.networking},
+		}
+		for _, r := range testCase.ranges {
+			c.Worker.NodePools = append(c.Worker.NodePools, WorkerNodePool{NodePoolName: "pool", PodCIDRRange: r})
+		}
+		err := c.validateNodePoolPodCIDRRanges()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.ranges, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.ranges)
+		}
+	}
+}
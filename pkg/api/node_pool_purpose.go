@@ -0,0 +1,102 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+)
+
+const (
+	// NodePoolPurposeKey is the key of the label and the taint added to nodes in a node pool dedicated to a purpose
+	NodePoolPurposeKey = "kube-aws.coreos.com/purpose"
+	// NodePoolPurposeTaintEffect is the effect of the taint added to nodes in a node pool dedicated to a purpose,
+	// so that only pods tolerating it e.g. logging and monitoring agents are scheduled onto the nodes
+	NodePoolPurposeTaintEffect = "NoSchedule"
+
+	clusterAutoscalerNodeTemplateLabelTagKeyPrefix = "k8s.io/cluster-autoscaler/node-template/label/"
+	clusterAutoscalerNodeTemplateTaintTagKeyPrefix = "k8s.io/cluster-autoscaler/node-template/taint/"
+)
+
+var nodePoolPurposePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// NodePoolPurpose is the shorthand for dedicating a node pool to a kind of workloads like `observability`.
+// It adds the conventional label and taint `kube-aws.coreos.com/purpose=<purpose>` to the nodes in the pool, along with the matching
+// node-template tags which allow cluster-autoscaler to scale the pool from zero
+type NodePoolPurpose string
+
+func (p NodePoolPurpose) Enabled() bool {
+	return p != ""
+}
+
+func (p NodePoolPurpose) Label() (string, string) {
+	return NodePoolPurposeKey, string(p)
+}
+
+func (p NodePoolPurpose) Taint() Taint {
+	return Taint{
+		Key:    NodePoolPurposeKey,
+		Value:  string(p),
+		Effect: NodePoolPurposeTaintEffect,
+	}
+}
+
+// ClusterAutoscalerNodeTemplateTags returns the ASG tags for cluster-autoscaler to know the label and the taint of nodes the pool would launch
+func (p NodePoolPurpose) ClusterAutoscalerNodeTemplateTags() map[string]string {
+	if !p.Enabled() {
+		return map[string]string{}
+	}
+	taint := p.Taint()
+	return map[string]string{
+		clusterAutoscalerNodeTemplateLabelTagKeyPrefix + NodePoolPurposeKey: string(p),
+		clusterAutoscalerNodeTemplateTaintTagKeyPrefix + NodePoolPurposeKey: fmt.Sprintf("%s:%s", taint.Value, taint.Effect),
+	}
+}
+
+// ApplyTo returns the node settings with the conventional label and taint for the purpose added
+func (p NodePoolPurpose) ApplyTo(s NodeSettings) NodeSettings {
+	if !p.Enabled() {
+		return s
+	}
+
+	labels := NodeLabels{}
+	for k, v := range s.NodeLabels {
+		labels[k] = v
+	}
+	k, v := p.Label()
+	labels[k] = v
+	s.NodeLabels = labels
+
+	taint := p.Taint()
+	taints := Taints{}
+	for _, t := range s.Taints {
+		if t != taint {
+			taints = append(taints, t)
+		}
+	}
+	s.Taints = append(taints, taint)
+
+	return s
+}
+
+// Validate returns an error if the purpose is invalid or conflicts with the labels or the taints explicitly specified for the node pool
+func (p NodePoolPurpose) Validate(s NodeSettings) error {
+	if !p.Enabled() {
+		return nil
+	}
+
+	if len(p) > 63 || !nodePoolPurposePattern.MatchString(string(p)) {
+		return fmt.Errorf("invalid `purpose` \"%s\": it must consist of at most 63 lower case alphanumeric characters or '-', and must start and end with an alphanumeric character", p)
+	}
+
+	if v, ok := s.NodeLabels[NodePoolPurposeKey]; ok && v != string(p) {
+		return fmt.Errorf("`nodeLabels` contains \"%s=%s\" which conflicts with `purpose` \"%s\"", NodePoolPurposeKey, v, p)
+	}
+
+	taint := p.Taint()
+	for _, t := range s.Taints {
+		if t.Key == NodePoolPurposeKey && t != taint {
+			return fmt.Errorf("`taints` contains \"%s\" which conflicts with `purpose` \"%s\": remove it or make it \"%s\"", t.String(), p, taint.String())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNodePoolPurposeValidate(t *testing.T) {
+	testCases := []struct {
+		purpose  NodePoolPurpose
+		settings NodeSettings
+		isValid  bool
+	}{
+		// Valid, no purpose
+		{
+			purpose:  "",
+			settings: NodeSettings{Taints: Taints{{Key: NodePoolPurposeKey, Value: "foo", Effect: "NoExecute"}}},
+			isValid:  true,
+		},
+		// Valid, no conflict
+		{
+			purpose:  "observability",
+			settings: NodeSettings{NodeLabels: NodeLabels{"team": "sre"}, Taints: Taints{{Key: "dedicated", Value: "sre", Effect: "NoSchedule"}}},
+			isValid:  true,
+		},
+		// Valid, the conventional label and taint explicitly specified
+		{
+			purpose: "observability",
+			settings: NodeSettings{
+				NodeLabels: NodeLabels{NodePoolPurposeKey: "observability"},
+				Taints:     Taints{{Key: NodePoolPurposeKey, Value: "observability", Effect: "NoSchedule"}},
+			},
+			isValid: true,
+		},
+		// Invalid, not usable as a label value
+		{
+			purpose: "Observability!",
+			isValid: false,
+		},
+		// Invalid, conflicting label
+		{
+			purpose:  "observability",
+			settings: NodeSettings{NodeLabels: NodeLabels{NodePoolPurposeKey: "logging"}},
+			isValid:  false,
+		},
+		// Invalid, conflicting taint value
+		{
+			purpose:  "observability",
+			settings: NodeSettings{Taints: Taints{{Key: NodePoolPurposeKey, Value: "logging", Effect: "NoSchedule"}}},
+			isValid:  false,
+		},
+		// Invalid, conflicting taint effect
+		{
+			purpose:  "observability",
+			settings: NodeSettings{Taints: Taints{{Key: NodePoolPurposeKey, Value: "observability", Effect: "NoExecute"}}},
+			isValid:  false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.purpose.Validate(testCase.settings)
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected purpose \"%s\" to be valid but got an error: %v", i, testCase.purpose, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected purpose \"%s\" to be invalid but it wasn't", i, testCase.purpose)
+		}
+	}
+}
+
+func TestNodePoolPurposeApplyTo(t *testing.T) {
+	original := NodeSettings{
+		NodeLabels: NodeLabels{"team": "sre"},
+		Taints: Taints{
+			{Key: "dedicated", Value: "sre", Effect: "NoSchedule"},
+			{Key: NodePoolPurposeKey, Value: "observability", Effect: "NoSchedule"},
+		},
+	}
+
+	actual := NodePoolPurpose("observability").ApplyTo(original)
+
+	expectedLabels := NodeLabels{"team": "sre", NodePoolPurposeKey: "observability"}
+	if !reflect.DeepEqual(actual.NodeLabels, expectedLabels) {
+		t.Errorf("unexpected node labels: expected=%v, actual=%v", expectedLabels, actual.NodeLabels)
+	}
+	expectedTaints := Taints{
+		{Key: "dedicated", Value: "sre", Effect: "NoSchedule"},
+		{Key: NodePoolPurposeKey, Value: "observability", Effect: "NoSchedule"},
+	}
+	if !reflect.DeepEqual(actual.Taints, expectedTaints) {
+		t.Errorf("unexpected taints: expected=%v, actual=%v", expectedTaints, actual.Taints)
+	}
+	if _, ok := original.NodeLabels[NodePoolPurposeKey]; ok {
+		t.Error("the original node labels shouldn't be modified")
+	}
+
+	if unchanged := NodePoolPurpose("").ApplyTo(original); !reflect.DeepEqual(unchanged, original) {
+		t.Errorf("node settings shouldn't be changed without purpose: expected=%v, actual=%v", original, unchanged)
+	}
+}
+
+func TestNodePoolPurposeClusterAutoscalerNodeTemplateTags(t *testing.T) {
+	expected := map[string]string{
+		"k8s.io/cluster-autoscaler/node-template/label/kube-aws.coreos.com/purpose": "observability",
+		"k8s.io/cluster-autoscaler/node-template/taint/kube-aws.coreos.com/purpose": "observability:NoSchedule",
+	}
+	if actual := NodePoolPurpose("observability").ClusterAutoscalerNodeTemplateTags(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("unexpected tags: expected=%v, actual=%v", expected, actual)
+	}
+	if actual := NodePoolPurpose("").ClusterAutoscalerNodeTemplateTags(); len(actual) != 0 {
+		t.Errorf("expected no tags without purpose but got: %v", actual)
+	}
+}
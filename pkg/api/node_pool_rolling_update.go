@@ -0,0 +1,145 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// defaultRollingUpdatePauseTime is how long CloudFormation waits between batches of nodes by default, when it doesn't wait for the signals
+const defaultRollingUpdatePauseTime = "PT2M"
+
+// maxCfnRollingUpdatePauseTime is the maximum pause time accepted by CloudFormation
+const maxCfnRollingUpdatePauseTime = time.Hour
+
+// suspendableScalingProcesses are the scaling processes which can be suspended during rolling updates.
+// `Launch` and `Terminate` are excluded as CloudFormation can't replace the nodes without them
+var suspendableScalingProcesses = []string{
+	"AddToLoadBalancer",
+	"AlarmNotification",
+	"AZRebalance",
+	"HealthCheck",
+	"InstanceRefresh",
+	"ReplaceUnhealthy",
+	"ScheduledActions",
+}
+
+// NodePoolRollingUpdate configures the `AutoScalingRollingUpdate` update policy of the auto scaling groups of a node pool.
+// Combine it with `update.rollbackOnFailure` to stop and roll back an update once a batch of the new nodes fails to become Ready
+type NodePoolRollingUpdate struct {
+	// MaxBatchSize is the max number of nodes replaced at a time. Defaults to `waitSignal.maxBatchSize`, which defaults to 1
+	MaxBatchSize *int `yaml:"maxBatchSize,omitempty"`
+	// MinInstancesInService is the min number of nodes kept in service while the old ones are replaced.
+	// Defaults to `autoScalingGroup.rollingUpdateMinInstancesInService`, which defaults to one less than `autoScalingGroup.maxSize`
+	MinInstancesInService *int `yaml:"minInstancesInService,omitempty"`
+	// PauseTime is the ISO 8601 duration like `PT10M` CloudFormation waits for each batch of the new nodes to signal with `waitSignal`,
+	// or waits after each batch without it. Defaults to `createTimeout` with `waitSignal`, and PT2M without it
+	PauseTime string `yaml:"pauseTime,omitempty"`
+	// SuspendProcesses are the scaling processes like `AZRebalance` suspended during updates, so that they don't interfere with the replacement
+	SuspendProcesses []string `yaml:"suspendProcesses,omitempty"`
+}
+
+func (u NodePoolRollingUpdate) configured() bool {
+	return u.MaxBatchSize != nil || u.MinInstancesInService != nil || u.PauseTime != "" || len(u.SuspendProcesses) > 0
+}
+
+// RollingUpdateMaxBatchSize returns the `MaxBatchSize` of the update policy of the auto scaling groups
+func (c WorkerNodePool) RollingUpdateMaxBatchSize() int {
+	if c.RollingUpdate.MaxBatchSize != nil {
+		return *c.RollingUpdate.MaxBatchSize
+	}
+	if c.WaitSignal.Enabled() {
+		return c.WaitSignal.MaxBatchSize()
+	}
+	return 1
+}
+
+// RollingUpdatePauseTime returns the `PauseTime` of the update policy of the auto scaling groups
+func (c WorkerNodePool) RollingUpdatePauseTime() string {
+	if c.RollingUpdate.PauseTime != "" {
+		return c.RollingUpdate.PauseTime
+	}
+	if c.WaitSignal.Enabled() {
+		return c.CreateTimeout
+	}
+	return defaultRollingUpdatePauseTime
+}
+
+// rollingUpdateMinInstancesInService returns the min number of nodes in service during updates if specified by either
+// `rollingUpdate.minInstancesInService` or `autoScalingGroup.rollingUpdateMinInstancesInService`
+func (c WorkerNodePool) rollingUpdateMinInstancesInService() *int {
+	if c.RollingUpdate.MinInstancesInService != nil {
+		return c.RollingUpdate.MinInstancesInService
+	}
+	return c.AutoScalingGroup.RollingUpdateMinInstancesInService
+}
+
+// ValidateRollingUpdate validates `rollingUpdate` against the settings the node pool is finally deployed with
+func (c WorkerNodePool) ValidateRollingUpdate() error {
+	u := c.RollingUpdate
+	if !u.configured() {
+		return nil
+	}
+
+	if c.SpotFleet.Enabled() {
+		return errors.New("`rollingUpdate` can't be used for a node pool backed by a spot fleet")
+	}
+	if c.BlueGreenEnabled() {
+		return errors.New("`rollingUpdate` can't be used with `deploymentStrategy: blueGreen`, which replaces the auto scaling groups instead of updating them in place")
+	}
+
+	if u.MaxBatchSize != nil {
+		if c.WaitSignal.MaxBatchSizeOverride != nil {
+			return errors.New("`rollingUpdate.maxBatchSize` and `waitSignal.maxBatchSize` are mutually exclusive. Remove `waitSignal.maxBatchSize`")
+		}
+		if *u.MaxBatchSize < 1 {
+			return fmt.Errorf("`rollingUpdate.maxBatchSize` must be 1 or greater, but was %d", *u.MaxBatchSize)
+		}
+	}
+
+	if u.MinInstancesInService != nil {
+		if c.AutoScalingGroup.RollingUpdateMinInstancesInService != nil {
+			return errors.New("`rollingUpdate.minInstancesInService` and `autoScalingGroup.rollingUpdateMinInstancesInService` are mutually exclusive. Remove `autoScalingGroup.rollingUpdateMinInstancesInService`")
+		}
+		// Spot instances may be terminated at any time, hence no node is kept in service for them
+		if c.SpotPrice != "" {
+			return errors.New("`rollingUpdate.minInstancesInService` can't be used with `spotPrice`")
+		}
+		if *u.MinInstancesInService < 0 {
+			return fmt.Errorf("`rollingUpdate.minInstancesInService` must be zero or greater, but was %d", *u.MinInstancesInService)
+		}
+		// CloudFormation can't launch any new node while keeping all the nodes in service
+		if max := c.MaxCount(); max > 0 && *u.MinInstancesInService >= max {
+			return fmt.Errorf("`rollingUpdate.minInstancesInService` %d must be less than `autoScalingGroup.maxSize` %d", *u.MinInstancesInService, max)
+		}
+	}
+
+	if u.PauseTime != "" {
+		d, err := parseCfnDuration(u.PauseTime)
+		if err != nil {
+			return fmt.Errorf("invalid `rollingUpdate.pauseTime` \"%s\": %v", u.PauseTime, err)
+		}
+		if d > maxCfnRollingUpdatePauseTime {
+			return fmt.Errorf("`rollingUpdate.pauseTime` \"%s\" must not exceed PT1H, which is the maximum pause time accepted by CloudFormation", u.PauseTime)
+		}
+		// Otherwise CloudFormation gives up waiting for the signal before the node signals the failure
+		if c.Update.RollbackOnFailure && time.Duration(c.Update.HealthCheckTimeoutSeconds())*time.Second >= d {
+			return fmt.Errorf("`update.healthCheckTimeout` must be shorter than `rollingUpdate.pauseTime` \"%s\", which is how long CloudFormation waits for each batch of nodes to signal",
+				u.PauseTime)
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, p := range u.SuspendProcesses {
+		if !containsString(suspendableScalingProcesses, p) {
+			return fmt.Errorf("invalid process \"%s\" in `rollingUpdate.suspendProcesses`: it must be one of %s", p, strings.Join(suspendableScalingProcesses, ", "))
+		}
+		if seen[p] {
+			return fmt.Errorf("duplicate process \"%s\" in `rollingUpdate.suspendProcesses`", p)
+		}
+		seen[p] = true
+	}
+
+	return nil
+}
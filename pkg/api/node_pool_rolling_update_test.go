@@ -0,0 +1,189 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestWorkerNodePoolValidateRollingUpdate(t *testing.T) {
+	disabled := false
+	num := func(n int) *int { return &n }
+	pool := func(f func(*WorkerNodePool)) WorkerNodePool {
+		p := WorkerNodePool{AutoScalingGroup: AutoScalingGroup{MaxSize: 5}}
+		p.CreateTimeout = "PT15M"
+		f(&p)
+		return p
+	}
+
+	testCases := []struct {
+		pool    WorkerNodePool
+		isValid bool
+	}{
+		// Valid, not configured
+		{
+			pool:    WorkerNodePool{},
+			isValid: true,
+		},
+		// Valid, all the settings
+		{
+			pool: pool(func(p *WorkerNodePool) {
+				p.RollingUpdate = NodePoolRollingUpdate{
+					MaxBatchSize:          num(2),
+					MinInstancesInService: num(3),
+					PauseTime:             "PT20M",
+					SuspendProcesses:      []string{"AZRebalance", "ScheduledActions"},
+				}
+			}),
+			isValid: true,
+		},
+		// Valid, the pause time longer than the health check timeout of the rollback
+		{
+			pool: pool(func(p *WorkerNodePool) {
+				p.Update = NodePoolUpdate{RollbackOnFailure: true, HealthCheckTimeout: "5m"}
+				p.RollingUpdate.PauseTime = "PT6M"
+			}),
+			isValid: true,
+		},
+		// Invalid, spot fleet
+		{
+			pool: pool(func(p *WorkerNodePool) {
+				p.SpotFleet.TargetCapacity = 3
+				p.RollingUpdate.MaxBatchSize = num(2)
+			}),
+			isValid: false,
+		},
+		// Invalid, blue/green deployment
+		{
+			pool: pool(func(p *WorkerNodePool) {
+				p.DeploymentStrategy = NodePoolDeploymentStrategyBlueGreen
+				p.RollingUpdate.PauseTime = "PT5M"
+			}),
+			isValid: false,
+		},
+		// Invalid, max batch size too small
+		{
+			pool:    pool(func(p *WorkerNodePool) { p.RollingUpdate.MaxBatchSize = num(0) }),
+			isValid: false,
+		},
+		// Invalid, conflicting with waitSignal.maxBatchSize
+		{
+			pool: pool(func(p *WorkerNodePool) {
+				p.WaitSignal.MaxBatchSizeOverride = num(2)
+				p.RollingUpdate.MaxBatchSize = num(2)
+			}),
+			isValid: false,
+		},
+		// Invalid, conflicting with autoScalingGroup.rollingUpdateMinInstancesInService
+		{
+			pool: pool(func(p *WorkerNodePool) {
+				p.AutoScalingGroup.RollingUpdateMinInstancesInService = num(2)
+				p.RollingUpdate.MinInstancesInService = num(2)
+			}),
+			isValid: false,
+		},
+		// Invalid, keeping all the nodes in service
+		{
+			pool:    pool(func(p *WorkerNodePool) { p.RollingUpdate.MinInstancesInService = num(5) }),
+			isValid: false,
+		},
+		// Invalid, min instances in service for spot instances
+		{
+			pool: pool(func(p *WorkerNodePool) {
+				p.SpotPrice = "0.05"
+				p.RollingUpdate.MinInstancesInService = num(1)
+			}),
+			isValid: false,
+		},
+		// Invalid, pause time not an ISO 8601 duration
+		{
+			pool:    pool(func(p *WorkerNodePool) { p.RollingUpdate.PauseTime = "10m" }),
+			isValid: false,
+		},
+		// Invalid, pause time too long
+		{
+			pool:    pool(func(p *WorkerNodePool) { p.RollingUpdate.PauseTime = "PT2H" }),
+			isValid: false,
+		},
+		// Invalid, CloudFormation giving up waiting before the health check times out
+		{
+			pool: pool(func(p *WorkerNodePool) {
+				p.Update = NodePoolUpdate{RollbackOnFailure: true}
+				p.RollingUpdate.PauseTime = "PT5M"
+			}),
+			isValid: false,
+		},
+		// Invalid, unknown process
+		{
+			pool:    pool(func(p *WorkerNodePool) { p.RollingUpdate.SuspendProcesses = []string{"AZRebalancing"} }),
+			isValid: false,
+		},
+		// Invalid, the process required to replace nodes
+		{
+			pool:    pool(func(p *WorkerNodePool) { p.RollingUpdate.SuspendProcesses = []string{"Launch"} }),
+			isValid: false,
+		},
+		// Invalid, duplicate processes
+		{
+			pool:    pool(func(p *WorkerNodePool) { p.RollingUpdate.SuspendProcesses = []string{"HealthCheck", "HealthCheck"} }),
+			isValid: false,
+		},
+		// Valid, wait signal disabled
+		{
+			pool: pool(func(p *WorkerNodePool) {
+				p.WaitSignal.EnabledOverride = &disabled
+				p.RollingUpdate.PauseTime = "PT1M"
+			}),
+			isValid: true,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.pool.ValidateRollingUpdate()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but was not: %v", i, testCase.pool.RollingUpdate, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.pool.RollingUpdate)
+		}
+	}
+}
+
+func TestWorkerNodePoolRollingUpdateDefaults(t *testing.T) {
+	disabled := false
+	num := func(n int) *int { return &n }
+
+	p := WorkerNodePool{AutoScalingGroup: AutoScalingGroup{MaxSize: 4}}
+	p.CreateTimeout = "PT15M"
+	if actual := p.RollingUpdateMaxBatchSize(); actual != 1 {
+		t.Errorf("expected the max batch size to default to 1 but was %d", actual)
+	}
+	if actual := p.RollingUpdatePauseTime(); actual != "PT15M" {
+		t.Errorf("expected the pause time to default to the create timeout but was %s", actual)
+	}
+	if actual := p.RollingUpdateMinInstancesInService(); actual != 3 {
+		t.Errorf("expected the min instances in service to default to 3 but was %d", actual)
+	}
+
+	p.WaitSignal.MaxBatchSizeOverride = num(2)
+	if actual := p.RollingUpdateMaxBatchSize(); actual != 2 {
+		t.Errorf("expected the max batch size to default to waitSignal.maxBatchSize but was %d", actual)
+	}
+
+	p.WaitSignal.EnabledOverride = &disabled
+	if actual := p.RollingUpdateMaxBatchSize(); actual != 1 {
+		t.Errorf("expected the max batch size to be 1 without wait signal but was %d", actual)
+	}
+	if actual := p.RollingUpdatePauseTime(); actual != "PT2M" {
+		t.Errorf("expected the pause time to be PT2M without wait signal but was %s", actual)
+	}
+
+	p.RollingUpdate = NodePoolRollingUpdate{MaxBatchSize: num(3), MinInstancesInService: num(1), PauseTime: "PT5M"}
+	if actual := p.RollingUpdateMaxBatchSize(); actual != 3 {
+		t.Errorf("expected 3 but was %d", actual)
+	}
+	if actual := p.RollingUpdatePauseTime(); actual != "PT5M" {
+		t.Errorf("expected PT5M but was %s", actual)
+	}
+	if actual := p.RollingUpdateMinInstancesInService(); actual != 1 {
+		t.Errorf("expected 1 but was %d", actual)
+	}
+}
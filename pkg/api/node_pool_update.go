@@ -0,0 +1,80 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// defaultNodePoolUpdateHealthCheckTimeout is how long each new node waits for itself to become Ready by default
+const defaultNodePoolUpdateHealthCheckTimeout = 10 * time.Minute
+
+// NodePoolUpdate configures how CloudFormation reacts to the new nodes of a node pool failing to become Ready during updates.
+// With `rollbackOnFailure`, each new node signals CloudFormation only after the node is Ready in the cluster, and signals the failure
+// once the health check timeout expires. CloudFormation then fails the update of the auto scaling group when the healthy nodes fall below
+// `minHealthyPercent`, and rolls the stack back to the previous launch template, replacing the nodes already updated
+type NodePoolUpdate struct {
+	// RollbackOnFailure enables rolling back updates rolling out nodes failing to become Ready. Defaults to false
+	RollbackOnFailure bool `yaml:"rollbackOnFailure,omitempty"`
+	// HealthCheckTimeout is the duration like `10m` each new node waits for itself to become Ready before signalling the failure.
+	// Defaults to 10m
+	HealthCheckTimeout string `yaml:"healthCheckTimeout,omitempty"`
+	// MinHealthyPercent is the percentage of the new nodes that must become Ready for the update to succeed. Defaults to 100
+	MinHealthyPercent *int `yaml:"minHealthyPercent,omitempty"`
+}
+
+// HealthCheckTimeoutSeconds returns the number of seconds each new node waits for itself to become Ready
+func (u NodePoolUpdate) HealthCheckTimeoutSeconds() int {
+	if d, err := time.ParseDuration(u.HealthCheckTimeout); err == nil {
+		return int(d.Seconds())
+	}
+	return int(defaultNodePoolUpdateHealthCheckTimeout.Seconds())
+}
+
+// MinHealthyPercentOrDefault returns the `MinSuccessfulInstancesPercent` of the auto scaling groups
+func (u NodePoolUpdate) MinHealthyPercentOrDefault() int {
+	if u.MinHealthyPercent != nil {
+		return *u.MinHealthyPercent
+	}
+	return 100
+}
+
+// ValidateUpdate validates `update` against the settings the node pool is finally deployed with
+func (c WorkerNodePool) ValidateUpdate() error {
+	u := c.Update
+	if !u.RollbackOnFailure {
+		if u.HealthCheckTimeout != "" || u.MinHealthyPercent != nil {
+			return errors.New("`update.healthCheckTimeout` and `update.minHealthyPercent` require `update.rollbackOnFailure` to be true")
+		}
+		return nil
+	}
+
+	if c.SpotFleet.Enabled() {
+		return errors.New("`update.rollbackOnFailure` can't be used for a node pool backed by a spot fleet")
+	}
+	// CloudFormation can't tell the failed nodes without the signals
+	if !c.WaitSignal.Enabled() {
+		return errors.New("`update.rollbackOnFailure` requires `waitSignal.enabled` to be true")
+	}
+	if u.MinHealthyPercent != nil && (*u.MinHealthyPercent < 0 || *u.MinHealthyPercent > 100) {
+		return fmt.Errorf("`update.minHealthyPercent` must be between 0 and 100, but was %d", *u.MinHealthyPercent)
+	}
+	timeout := defaultNodePoolUpdateHealthCheckTimeout
+	if u.HealthCheckTimeout != "" {
+		d, err := time.ParseDuration(u.HealthCheckTimeout)
+		if err != nil || d < time.Minute {
+			return fmt.Errorf("`update.healthCheckTimeout` must be a duration of 1m or longer, but was \"%s\"", u.HealthCheckTimeout)
+		}
+		timeout = d
+	}
+	// Otherwise CloudFormation gives up waiting for the signal before the node signals the failure
+	createTimeout, err := parseCfnDuration(c.CreateTimeout)
+	if err != nil {
+		return fmt.Errorf("invalid `createTimeout` \"%s\": %v", c.CreateTimeout, err)
+	}
+	if timeout >= createTimeout {
+		return fmt.Errorf("`update.healthCheckTimeout` %v must be shorter than `createTimeout` \"%s\", which is how long CloudFormation waits for each node to signal",
+			timeout, c.CreateTimeout)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestWorkerNodePoolValidateUpdate(t *testing.T) {
+	disabled := false
+	percent := func(p int) *int { return &p }
+	rollback := func(f func(*WorkerNodePool)) WorkerNodePool {
+		p := WorkerNodePool{Update: NodePoolUpdate{RollbackOnFailure: true}}
+		p.CreateTimeout = "PT15M"
+		f(&p)
+		return p
+	}
+
+	testCases := []struct {
+		pool    WorkerNodePool
+		isValid bool
+	}{
+		// Valid, not configured
+		{
+			pool:    WorkerNodePool{},
+			isValid: true,
+		},
+		// Valid, the defaults
+		{
+			pool:    rollback(func(p *WorkerNodePool) {}),
+			isValid: true,
+		},
+		// Valid, tolerating a failed node out of five during blue/green deployments
+		{
+			pool: rollback(func(p *WorkerNodePool) {
+				p.DeploymentStrategy = NodePoolDeploymentStrategyBlueGreen
+				p.Update.HealthCheckTimeout = "14m"
+				p.Update.MinHealthyPercent = percent(80)
+			}),
+			isValid: true,
+		},
+		// Invalid, health check timeout without rollbackOnFailure
+		{
+			pool:    WorkerNodePool{Update: NodePoolUpdate{HealthCheckTimeout: "5m"}},
+			isValid: false,
+		},
+		// Invalid, min healthy percent without rollbackOnFailure
+		{
+			pool:    WorkerNodePool{Update: NodePoolUpdate{MinHealthyPercent: percent(50)}},
+			isValid: false,
+		},
+		// Invalid, spot fleet
+		{
+			pool:    rollback(func(p *WorkerNodePool) { p.SpotFleet.TargetCapacity = 3 }),
+			isValid: false,
+		},
+		// Invalid, wait signal disabled
+		{
+			pool:    rollback(func(p *WorkerNodePool) { p.WaitSignal.EnabledOverride = &disabled }),
+			isValid: false,
+		},
+		// Invalid, min healthy percent out of range
+		{
+			pool:    rollback(func(p *WorkerNodePool) { p.Update.MinHealthyPercent = percent(101) }),
+			isValid: false,
+		},
+		// Invalid, health check timeout not a duration
+		{
+			pool:    rollback(func(p *WorkerNodePool) { p.Update.HealthCheckTimeout = "PT5M" }),
+			isValid: false,
+		},
+		// Invalid, health check timeout too short
+		{
+			pool:    rollback(func(p *WorkerNodePool) { p.Update.HealthCheckTimeout = "30s" }),
+			isValid: false,
+		},
+		// Invalid, CloudFormation giving up waiting before the health check times out
+		{
+			pool:    rollback(func(p *WorkerNodePool) { p.Update.HealthCheckTimeout = "15m" }),
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.pool.ValidateUpdate()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but was not: %v", i, testCase.pool.Update, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.pool.Update)
+		}
+	}
+}
+
+func TestNodePoolUpdateDefaults(t *testing.T) {
+	u := NodePoolUpdate{RollbackOnFailure: true}
+	if actual := u.HealthCheckTimeoutSeconds(); actual != 600 {
+		t.Errorf("expected 600 but was %d", actual)
+	}
+	if actual := u.MinHealthyPercentOrDefault(); actual != 100 {
+		t.Errorf("expected 100 but was %d", actual)
+	}
+}
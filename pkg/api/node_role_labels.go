@@ -0,0 +1,35 @@
+package api
+
+// NodePoolNameLabel is the label Windows and Bottlerocket nodes on Kubernetes 1.16 or greater register themselves with in place of
+// `kubernetes.io/role` and `node-role.kubernetes.io/<node pool name>`, which kubelets are no longer allowed to set.
+// Controllers then label the nodes with the node role labels from the node pool name
+const NodePoolNameLabel = "node.kubernetes.io/node-pool"
+
+// NodeRoleLabeledByControllers returns true when the nodes in the pool rely on controllers to be labeled with their node roles,
+// as their kubelets of the Kubernetes version refuse to register the nodes with the labels in the kubernetes.io namespace.
+// Linux nodes on Flatcar keep registering themselves with the node role labels
+func (c WorkerNodePool) NodeRoleLabeledByControllers(k8sVer string) bool {
+	if !c.IsWindows() && !c.IsBottlerocket() {
+		return false
+	}
+	ok, err := k8sVersionSatisfies(">= 1.16", k8sVer)
+	return err == nil && ok
+}
+
+// NodeRoleLabelerEnabled returns true when controllers label the nodes of any node pool with their node roles
+func (c Cluster) NodeRoleLabelerEnabled() bool {
+	for _, np := range c.Worker.NodePools {
+		k8sVer := np.K8sVer
+		if k8sVer == "" {
+			k8sVer = c.K8sVer
+		}
+		if np.NodeRoleLabeledByControllers(k8sVer) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Cluster) NodePoolNameLabel() string {
+	return NodePoolNameLabel
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestWorkerNodePoolNodeRoleLabeledByControllers(t *testing.T) {
+	testCases := []struct {
+		pool     WorkerNodePool
+		k8sVer   string
+		expected bool
+	}{
+		{pool: WorkerNodePool{}, k8sVer: "v1.20.2", expected: false},
+		{pool: WorkerNodePool{Platform: NodePoolPlatformWindows}, k8sVer: "v1.15.11", expected: false},
+		{pool: WorkerNodePool{Platform: NodePoolPlatformWindows}, k8sVer: "v1.16.8", expected: true},
+		{pool: WorkerNodePool{OS: NodePoolOSBottlerocket}, k8sVer: "v1.15.11", expected: false},
+		{pool: WorkerNodePool{OS: NodePoolOSBottlerocket}, k8sVer: "v1.20.2", expected: true},
+	}
+
+	for i, testCase := range testCases {
+		if actual := testCase.pool.NodeRoleLabeledByControllers(testCase.k8sVer); actual != testCase.expected {
+			t.Errorf("case %d: expected %v but got %v", i, testCase.expected, actual)
+		}
+	}
+}
+
+func TestNodeRoleLabelerEnabled(t *testing.T) {
+	c := Cluster{}
+	c.K8sVer = "v1.15.11"
+	c.Worker.NodePools = []WorkerNodePool{{}, {Platform: NodePoolPlatformWindows}}
+	if c.NodeRoleLabelerEnabled() {
+		t.Error("expected the node role labeler to be disabled for windows nodes on kubernetes 1.15")
+	}
+
+	c.Worker.NodePools[1].K8sVer = "v1.16.8"
+	if !c.NodeRoleLabelerEnabled() {
+		t.Error("expected the node role labeler to be enabled by the kubernetes version of the windows node pool")
+	}
+}
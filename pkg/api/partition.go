@@ -0,0 +1,39 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultAmazonVPCCNIImageRepo is the ECR repository of the Amazon VPC CNI in the aws partition, which nodes in the other partitions can't pull from
+const defaultAmazonVPCCNIImageRepo = "602401143452.dkr.ecr.us-west-2.amazonaws.com/amazon-k8s-cni"
+
+// validatePartition rejects the settings relying on what isn't available in the AWS China and GovCloud (US) partitions,
+// so that they fail on validation rather than deep in stack creation.
+// It covers what kube-aws itself assumes to be in the aws partition, i.e. the AMI registry and the ECR registry of the images.
+// The availability of instance types and Route 53 features varies by region rather than by partition, and is left to EC2 and CloudFormation
+func (c Cluster) validatePartition() error {
+	partition := c.Region.Partition()
+	if partition == "aws" {
+		return nil
+	}
+
+	// The AMI registry of the release channels only lists the AMIs published to the regions in the aws partition
+	if c.AmiId == "" && c.AmiSsmParameter == "" {
+		return fmt.Errorf("`amiId` or `amiSsmParameter` must be specified for the region %s in the %s partition, where the AMI registry of the release channel has no AMI", c.Region, partition)
+	}
+	for i, np := range c.Worker.NodePools {
+		// Node pools without their own release channel inherit the AMI of the main cluster, and Bottlerocket ones look theirs up in SSM
+		if np.AmiId == "" && np.AmiSsmParameter == "" && np.ReleaseChannel != "" && !np.IsBottlerocket() && !np.IsWindows() {
+			return fmt.Errorf("`worker.nodePools[%d].amiId` or `amiSsmParameter` must be specified with `releaseChannel` for the region %s in the %s partition, "+
+				"where the AMI registry of the release channel has no AMI", i, c.Region, partition)
+		}
+	}
+
+	if c.Kubernetes.Networking.AmazonVPC.Enabled && c.Kubernetes.Networking.SelfHosting.AmazonVPCCNIImage.Repo == defaultAmazonVPCCNIImageRepo {
+		return fmt.Errorf("`kubernetes.networking.selfHosting.amazonVPCCNIImage.repo` must be specified for the region %s, as nodes in the %s partition can't pull the image from %s. "+
+			"Specify the amazon-k8s-cni repository of the ECR registry for the partition", c.Region, partition, strings.SplitN(defaultAmazonVPCCNIImageRepo, "/", 2)[0])
+	}
+
+	return nil
+}
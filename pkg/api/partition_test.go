@@ -0,0 +1,87 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestValidatePartition(t *testing.T) {
+	testCases := []struct {
+		region  string
+		setup   func(c *Cluster)
+		isValid bool
+	}{
+		// Valid, the AMI registry lists AMIs for the aws partition
+		{
+			region:  "us-west-1",
+			setup:   func(c *Cluster) {},
+			isValid: true,
+		},
+		// Valid, AMI specified
+		{
+			region:  "cn-north-1",
+			setup:   func(c *Cluster) { c.AmiId = "ami-12345678" },
+			isValid: true,
+		},
+		{
+			region:  "us-gov-west-1",
+			setup:   func(c *Cluster) { c.AmiSsmParameter = "/my/flatcar/ami" },
+			isValid: true,
+		},
+		// Valid, node pools inheriting the AMI or running Bottlerocket
+		{
+			region: "us-gov-west-1",
+			setup: func(c *Cluster) {
+				c.AmiId = "ami-12345678"
+				c.Worker.NodePools = []WorkerNodePool{{}, {OS: NodePoolOSBottlerocket, DeploymentSettings: DeploymentSettings{ReleaseChannel: "beta"}}}
+			},
+			isValid: true,
+		},
+		// Invalid, no AMI in the registry
+		{
+			region:  "cn-northwest-1",
+			setup:   func(c *Cluster) {},
+			isValid: false,
+		},
+		// Invalid, node pool looking up the AMI of its own release channel
+		{
+			region: "us-gov-east-1",
+			setup: func(c *Cluster) {
+				c.AmiId = "ami-12345678"
+				c.Worker.NodePools = []WorkerNodePool{{DeploymentSettings: DeploymentSettings{ReleaseChannel: "beta"}}}
+			},
+			isValid: false,
+		},
+		// Invalid, Amazon VPC CNI image in the ECR registry of the aws partition
+		{
+			region: "cn-north-1",
+			setup: func(c *Cluster) {
+				c.AmiId = "ami-12345678"
+				c.Kubernetes.Networking.AmazonVPC.Enabled = true
+			},
+			isValid: false,
+		},
+		// Valid, Amazon VPC CNI image in the partition
+		{
+			region: "cn-north-1",
+			setup: func(c *Cluster) {
+				c.AmiId = "ami-12345678"
+				c.Kubernetes.Networking.AmazonVPC.Enabled = true
+				c.Kubernetes.Networking.SelfHosting.AmazonVPCCNIImage.Repo = "123456789012.dkr.ecr.cn-north-1.amazonaws.com.cn/amazon-k8s-cni"
+			},
+			isValid: true,
+		},
+	}
+
+	for i, testCase := range testCases {
+		c := NewDefaultCluster()
+		c.Region = RegionForName(testCase.region)
+		testCase.setup(c)
+		err := c.validatePartition()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected the cluster in %s to be valid but got an error: %v", i, testCase.region, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected the cluster in %s to be invalid but was not", i, testCase.region)
+		}
+	}
+}
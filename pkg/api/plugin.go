@@ -27,6 +27,9 @@ func (p Plugin) Validate() error {
 	if err := p.Metadata.Validate(); err != nil {
 		return fmt.Errorf("Invalid metadata: %v", err)
 	}
+	if err := p.Spec.Cluster.Kubernetes.ValidateInjectedObjects(); err != nil {
+		return fmt.Errorf("Invalid kubernetes secrets or configmaps: %v", err)
+	}
 	return nil
 }
 
@@ -143,8 +146,9 @@ type HelmRelease struct {
 }
 
 type KubernetesAPIServer struct {
-	Flags   CommandLineFlags `yaml:"flags,omitempty"`
-	Volumes APIServerVolumes `yaml:"volumes,omitempty"`
+	Flags          CommandLineFlags        `yaml:"flags,omitempty"`
+	Volumes        APIServerVolumes        `yaml:"volumes,omitempty"`
+	EgressSelector APIServerEgressSelector `yaml:"egressSelector,omitempty"`
 }
 
 type CommandLineFlags []CommandLineFlag
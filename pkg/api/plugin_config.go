@@ -1,8 +1,100 @@
 package api
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
 type PluginConfigs map[string]PluginConfig
 
 type PluginConfig struct {
 	Enabled bool `yaml:"enabled,omitempty"`
 	Values  `yaml:",inline"`
 }
+
+// Validate returns an error when the configs enable a plugin missing in the `plugins/` directory, or set a value not declared in
+// `spec.cluster.values` of the plugin. Otherwise a typo in cluster.yaml is silently ignored and the plugin is rendered with the defaults.
+// keyPath is the path to the configs in cluster.yaml like `kubeAwsPlugins`
+func (c PluginConfigs) Validate(plugins []*Plugin, keyPath string) error {
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		pc := c[name]
+		if !pc.Enabled {
+			continue
+		}
+		var plugin *Plugin
+		available := []string{}
+		for _, p := range plugins {
+			if p.SettingKey() == name {
+				plugin = p
+			}
+			available = append(available, p.SettingKey())
+		}
+		if plugin == nil {
+			sort.Strings(available)
+			return fmt.Errorf("`%s.%s` enables the plugin missing in the plugins directory. Available plugins are: [%s]", keyPath, name, strings.Join(available, ", "))
+		}
+		if err := validatePluginValues(plugin.Spec.Cluster.Values, pc.Values, fmt.Sprintf("%s.%s", keyPath, name)); err != nil {
+			return fmt.Errorf("invalid values for the plugin \"%s\": %v", plugin.Name, err)
+		}
+	}
+	return nil
+}
+
+// validatePluginValues returns an error for the first value not declared in the defaults, looking into nested maps unless the default is an empty map,
+// which allows arbitrary keys
+func validatePluginValues(defaults map[string]interface{}, values map[string]interface{}, keyPath string) error {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		path := fmt.Sprintf("%s.%s", keyPath, k)
+		d, ok := defaults[k]
+		if !ok {
+			declared := make([]string, 0, len(defaults))
+			for k := range defaults {
+				declared = append(declared, k)
+			}
+			sort.Strings(declared)
+			return fmt.Errorf("unknown value `%s`: it must be one of the values declared in `spec.cluster.values` of the plugin: [%s]", path, strings.Join(declared, ", "))
+		}
+		nestedDefaults, isMap := stringKeyedMap(d)
+		if !isMap || len(nestedDefaults) == 0 {
+			continue
+		}
+		nestedValues, isMap := stringKeyedMap(values[k])
+		if !isMap {
+			return fmt.Errorf("`%s` must be a map like the default: %v", path, d)
+		}
+		if err := validatePluginValues(nestedDefaults, nestedValues, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stringKeyedMap converts a map decoded from YAML, whose keys may be of the type interface{}, into a map keyed by strings
+func stringKeyedMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case Values:
+		return m, true
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		r := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			r[fmt.Sprintf("%v", k)] = v
+		}
+		return r, true
+	}
+	return nil, false
+}
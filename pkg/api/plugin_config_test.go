@@ -0,0 +1,84 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPluginConfigsValidate(t *testing.T) {
+	plugins := []*Plugin{
+		{
+			Metadata: Metadata{Name: "my-plugin", Version: "0.0.1"},
+			Spec: PluginSpec{
+				Cluster: ClusterSpec{
+					Values: Values{
+						"queue": map[interface{}]interface{}{
+							"name": "bar",
+						},
+						"labels":   map[interface{}]interface{}{},
+						"replicas": 1,
+					},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		configs       PluginConfigs
+		expectedError string
+	}{
+		// Valid, no configs
+		{
+			configs: PluginConfigs{},
+		},
+		// Valid, the declared values
+		{
+			configs: PluginConfigs{
+				"myPlugin": {
+					Enabled: true,
+					Values: Values{
+						"queue":    map[interface{}]interface{}{"name": "baz"},
+						"labels":   map[interface{}]interface{}{"team": "a"},
+						"replicas": 2,
+					},
+				},
+			},
+		},
+		// Valid, a missing plugin disabled
+		{
+			configs: PluginConfigs{"otherPlugin": {Enabled: false}},
+		},
+		// Invalid, a missing plugin enabled
+		{
+			configs:       PluginConfigs{"otherPlugin": {Enabled: true}},
+			expectedError: "`kubeAwsPlugins.otherPlugin` enables the plugin missing in the plugins directory. Available plugins are: [myPlugin]",
+		},
+		// Invalid, an unknown value
+		{
+			configs:       PluginConfigs{"myPlugin": {Enabled: true, Values: Values{"queues": 1}}},
+			expectedError: "unknown value `kubeAwsPlugins.myPlugin.queues`: it must be one of the values declared in `spec.cluster.values` of the plugin: [labels, queue, replicas]",
+		},
+		// Invalid, an unknown nested value
+		{
+			configs: PluginConfigs{
+				"myPlugin": {Enabled: true, Values: Values{"queue": map[interface{}]interface{}{"nmae": "baz"}}},
+			},
+			expectedError: "unknown value `kubeAwsPlugins.myPlugin.queue.nmae`",
+		},
+		// Invalid, a scalar for a map
+		{
+			configs:       PluginConfigs{"myPlugin": {Enabled: true, Values: Values{"queue": "baz"}}},
+			expectedError: "`kubeAwsPlugins.myPlugin.queue` must be a map",
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.configs.Validate(plugins, "kubeAwsPlugins")
+		if testCase.expectedError == "" && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.configs, err)
+		}
+		if testCase.expectedError != "" && (err == nil || !strings.Contains(err.Error(), testCase.expectedError)) {
+			t.Errorf("case %d: expected an error containing \"%s\" but got: %v", i, testCase.expectedError, err)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// reservableResourceNames is the names of resources which can be reserved via `--system-reserved` and `--kube-reserved` in the order rendered
+	reservableResourceNames = []string{"cpu", "memory", "ephemeral-storage", "pid"}
+
+	quantityPattern = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)(m|k|M|G|T|P|E|Ki|Mi|Gi|Ti|Pi|Ei)?$`)
+
+	quantitySuffixMultipliers = map[string]float64{
+		"":   1,
+		"m":  1e-3,
+		"k":  1e3,
+		"M":  1e6,
+		"G":  1e9,
+		"T":  1e12,
+		"P":  1e15,
+		"E":  1e18,
+		"Ki": 1 << 10,
+		"Mi": 1 << 20,
+		"Gi": 1 << 30,
+		"Ti": 1 << 40,
+		"Pi": 1 << 50,
+		"Ei": 1 << 60,
+	}
+)
+
+// ReservedResources is a set of compute resources reserved for system or kubernetes daemons, keyed by resource names like `cpu`, `memory` and `ephemeral-storage`.
+// It can be written either as a YAML map or in the `cpu=100m,memory=100Mi` format accepted by kubelet.
+type ReservedResources map[string]string
+
+func (r *ReservedResources) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err == nil {
+		parsed, err := ParseReservedResources(str)
+		if err != nil {
+			return err
+		}
+		*r = parsed
+		return nil
+	}
+
+	m := map[string]string{}
+	if err := unmarshal(&m); err != nil {
+		return fmt.Errorf("reserved resources must be either a map or a string like \"cpu=100m,memory=100Mi\": %v", err)
+	}
+	*r = ReservedResources(m)
+	return nil
+}
+
+// ParseReservedResources parses reserved resources in the `cpu=100m,memory=100Mi` format
+func ParseReservedResources(str string) (ReservedResources, error) {
+	r := ReservedResources{}
+	if str == "" {
+		return r, nil
+	}
+	for _, kv := range strings.Split(str, ",") {
+		pair := strings.SplitN(kv, "=", 2)
+		if len(pair) != 2 || pair[0] == "" {
+			return nil, fmt.Errorf("invalid reserved resource \"%s\" in \"%s\": it must be in the form of NAME=QUANTITY", kv, str)
+		}
+		r[strings.TrimSpace(pair[0])] = strings.TrimSpace(pair[1])
+	}
+	return r, nil
+}
+
+func (r ReservedResources) IsEmpty() bool {
+	return len(r) == 0
+}
+
+// String returns the reserved resources in the format accepted by kubelet's `--system-reserved` and `--kube-reserved`
+func (r ReservedResources) String() string {
+	names := []string{}
+	for _, n := range reservableResourceNames {
+		if _, ok := r[n]; ok {
+			names = append(names, n)
+		}
+	}
+	others := []string{}
+	for n := range r {
+		if !r.isReservable(n) {
+			others = append(others, n)
+		}
+	}
+	sort.Strings(others)
+	names = append(names, others...)
+
+	pairs := make([]string, len(names))
+	for i, n := range names {
+		pairs[i] = fmt.Sprintf("%s=%s", n, r[n])
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (r ReservedResources) isReservable(name string) bool {
+	for _, n := range reservableResourceNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (r ReservedResources) Validate(key string) error {
+	for name, q := range r {
+		if !r.isReservable(name) {
+			return fmt.Errorf("`%s` contains an unsupported resource \"%s\": it must be one of %s", key, name, strings.Join(reservableResourceNames, ", "))
+		}
+		if _, err := ParseQuantity(q); err != nil {
+			return fmt.Errorf("`%s.%s` is invalid: %v", key, name, err)
+		}
+	}
+	return nil
+}
+
+// quantity returns the amount of the resource reserved, or 0 when it isn't reserved.
+// The quantity must have been validated beforehand
+func (r ReservedResources) quantity(name string) float64 {
+	q, ok := r[name]
+	if !ok {
+		return 0
+	}
+	v, _ := ParseQuantity(q)
+	return v
+}
+
+// ParseQuantity parses a quantity like `100m`, `0.5`, `512Mi` or `1G` in the notation of Kubernetes
+func ParseQuantity(q string) (float64, error) {
+	m := quantityPattern.FindStringSubmatch(q)
+	if m == nil {
+		return 0, fmt.Errorf("\"%s\" is not a valid quantity. It must be a number optionally followed by one of the suffixes m, k, M, G, T, P, E, Ki, Mi, Gi, Ti, Pi and Ei", q)
+	}
+	v, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("\"%s\" is not a valid quantity: %v", q, err)
+	}
+	return v * quantitySuffixMultipliers[m[2]], nil
+}
@@ -0,0 +1,275 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-yaml/yaml"
+)
+
+func TestReservedResourcesUnmarshalYAML(t *testing.T) {
+	testCases := []struct {
+		yaml     string
+		expected string
+		isValid  bool
+	}{
+		// Valid, legacy string format
+		{
+			yaml:     `reserved: "memory=100Mi,cpu=100m,ephemeral-storage=1Gi"`,
+			expected: "cpu=100m,memory=100Mi,ephemeral-storage=1Gi",
+			isValid:  true,
+		},
+		// Valid, map format
+		{
+			yaml: `reserved:
+  pid: "1000"
+  memory: 200Mi
+  cpu: 200m
+`,
+			expected: "cpu=200m,memory=200Mi,pid=1000",
+			isValid:  true,
+		},
+		// Valid, empty string
+		{
+			yaml:     `reserved: ""`,
+			expected: "",
+			isValid:  true,
+		},
+		// Invalid, missing quantity
+		{
+			yaml:    `reserved: "cpu"`,
+			isValid: false,
+		},
+		// Invalid, neither a string nor a map
+		{
+			yaml: `reserved:
+- cpu=100m
+`,
+			isValid: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		c := struct {
+			Reserved ReservedResources `yaml:"reserved"`
+		}{}
+		err := yaml.Unmarshal([]byte(testCase.yaml), &c)
+		if testCase.isValid && err != nil {
+			t.Errorf("expected %q to be parsed but got an error: %v", testCase.yaml, err)
+			continue
+		}
+		if !testCase.isValid {
+			if err == nil {
+				t.Errorf("expected %q to fail parsing but it didn't", testCase.yaml)
+			}
+			continue
+		}
+		if actual := c.Reserved.String(); actual != testCase.expected {
+			t.Errorf("unexpected reserved resources for %q: expected=%q, actual=%q", testCase.yaml, testCase.expected, actual)
+		}
+	}
+}
+
+func TestParseQuantity(t *testing.T) {
+	testCases := []struct {
+		quantity string
+		expected float64
+		isValid  bool
+	}{
+		{quantity: "100m", expected: 0.1, isValid: true},
+		{quantity: "2", expected: 2, isValid: true},
+		{quantity: "1.5", expected: 1.5, isValid: true},
+		{quantity: "512Mi", expected: 512 * (1 << 20), isValid: true},
+		{quantity: "1G", expected: 1e9, isValid: true},
+		{quantity: "", isValid: false},
+		{quantity: "100MB", isValid: false},
+		{quantity: "-1", isValid: false},
+		{quantity: "cpu", isValid: false},
+	}
+
+	for _, testCase := range testCases {
+		actual, err := ParseQuantity(testCase.quantity)
+		if testCase.isValid {
+			if err != nil {
+				t.Errorf("expected %q to be a valid quantity but got an error: %v", testCase.quantity, err)
+			} else if actual != testCase.expected {
+				t.Errorf("unexpected quantity for %q: expected=%g, actual=%g", testCase.quantity, testCase.expected, actual)
+			}
+		} else if err == nil {
+			t.Errorf("expected %q to be an invalid quantity but it wasn't", testCase.quantity)
+		}
+	}
+}
+
+func TestKubeletValidateResourceReservations(t *testing.T) {
+	testCases := []struct {
+		kubelet        Kubelet
+		instanceType   string
+		rootVolumeSize int
+		isValid        bool
+	}{
+		// Valid, nothing reserved
+		{
+			kubelet:        Kubelet{},
+			instanceType:   "t2.medium",
+			rootVolumeSize: 30,
+			isValid:        true,
+		},
+		// Valid, reservations fitting in the instance
+		{
+			kubelet: Kubelet{
+				SystemReservedResources: ReservedResources{"cpu": "500m", "memory": "1Gi"},
+				KubeReservedResources:   ReservedResources{"cpu": "500m", "memory": "1Gi", "ephemeral-storage": "10Gi"},
+				EnforceNodeAllocatable:  []string{"pods", "system-reserved", "kube-reserved"},
+				SystemReservedCgroup:    "/system.slice",
+				KubeReservedCgroup:      "/kube.slice",
+			},
+			instanceType:   "t2.medium",
+			rootVolumeSize: 30,
+			isValid:        true,
+		},
+		// Valid, unknown instance types aren't checked against their capacity
+		{
+			kubelet: Kubelet{
+				SystemReservedResources: ReservedResources{"cpu": "64"},
+			},
+			instanceType:   "x1.32xlarge",
+			rootVolumeSize: 30,
+			isValid:        true,
+		},
+		// Invalid, unparsable quantity
+		{
+			kubelet: Kubelet{
+				SystemReservedResources: ReservedResources{"cpu": "100 millicores"},
+			},
+			instanceType:   "t2.medium",
+			rootVolumeSize: 30,
+			isValid:        false,
+		},
+		// Invalid, unsupported resource
+		{
+			kubelet: Kubelet{
+				KubeReservedResources: ReservedResources{"gpu": "1"},
+			},
+			instanceType:   "t2.medium",
+			rootVolumeSize: 30,
+			isValid:        false,
+		},
+		// Invalid, cpu exceeding the instance capacity
+		{
+			kubelet: Kubelet{
+				SystemReservedResources: ReservedResources{"cpu": "1"},
+				KubeReservedResources:   ReservedResources{"cpu": "1"},
+			},
+			instanceType:   "t2.medium",
+			rootVolumeSize: 30,
+			isValid:        false,
+		},
+		// Invalid, memory exceeding the instance capacity
+		{
+			kubelet: Kubelet{
+				KubeReservedResources: ReservedResources{"memory": "4Gi"},
+			},
+			instanceType:   "t2.medium",
+			rootVolumeSize: 30,
+			isValid:        false,
+		},
+		// Invalid, ephemeral-storage exceeding the root volume
+		{
+			kubelet: Kubelet{
+				KubeReservedResources: ReservedResources{"ephemeral-storage": "30Gi"},
+			},
+			instanceType:   "t2.medium",
+			rootVolumeSize: 30,
+			isValid:        false,
+		},
+		// Invalid, unsupported enforcement
+		{
+			kubelet: Kubelet{
+				EnforceNodeAllocatable: []string{"none"},
+			},
+			instanceType:   "t2.medium",
+			rootVolumeSize: 30,
+			isValid:        false,
+		},
+		// Invalid, enforcing system-reserved without the cgroup
+		{
+			kubelet: Kubelet{
+				SystemReservedResources: ReservedResources{"cpu": "100m"},
+				EnforceNodeAllocatable:  []string{"pods", "system-reserved"},
+			},
+			instanceType:   "t2.medium",
+			rootVolumeSize: 30,
+			isValid:        false,
+		},
+		// Invalid, relative cgroup path
+		{
+			kubelet: Kubelet{
+				SystemReservedResources: ReservedResources{"cpu": "100m"},
+				EnforceNodeAllocatable:  []string{"system-reserved"},
+				SystemReservedCgroup:    "system.slice",
+			},
+			instanceType:   "t2.medium",
+			rootVolumeSize: 30,
+			isValid:        false,
+		},
+		// Invalid, cgroup path escaping its parent
+		{
+			kubelet: Kubelet{
+				KubeReservedResources:  ReservedResources{"cpu": "100m"},
+				EnforceNodeAllocatable: []string{"kube-reserved"},
+				KubeReservedCgroup:     "/kube.slice/../system.slice",
+			},
+			instanceType:   "t2.medium",
+			rootVolumeSize: 30,
+			isValid:        false,
+		},
+		// Invalid, enforcing kube-reserved without the reservation
+		{
+			kubelet: Kubelet{
+				EnforceNodeAllocatable: []string{"kube-reserved"},
+				KubeReservedCgroup:     "/kube.slice",
+			},
+			instanceType:   "t2.medium",
+			rootVolumeSize: 30,
+			isValid:        false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.kubelet.ValidateResourceReservations(testCase.instanceType, testCase.rootVolumeSize)
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.kubelet, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but it wasn't", i, testCase.kubelet)
+		}
+	}
+}
+
+func TestKubeletReservedCgroups(t *testing.T) {
+	testCases := []struct {
+		kubelet  Kubelet
+		expected []string
+	}{
+		{
+			kubelet:  Kubelet{},
+			expected: []string{},
+		},
+		{
+			kubelet:  Kubelet{SystemReservedCgroup: "/system.slice", KubeReservedCgroup: "/kube.slice"},
+			expected: []string{"/system.slice", "/kube.slice"},
+		},
+		{
+			kubelet:  Kubelet{SystemReservedCgroup: "/reserved.slice", KubeReservedCgroup: "/reserved.slice"},
+			expected: []string{"/reserved.slice"},
+		},
+	}
+
+	for i, testCase := range testCases {
+		actual := testCase.kubelet.ReservedCgroups()
+		if !reflect.DeepEqual(actual, testCase.expected) {
+			t.Errorf("case %d: expected reserved cgroups to be %v but was %v", i, testCase.expected, actual)
+		}
+	}
+}
@@ -8,12 +8,16 @@ import (
 type S3Folders struct {
 	clusterName string
 	s3URI       string
+	assetPrefix string
 }
 
-func NewS3Folders(s3URI string, clusterName string) S3Folders {
+// NewS3Folders returns the folders of the cluster's assets under the s3URI.
+// The assets are namespaced under the assetPrefix if it's not empty, or `kube-aws/clusters/<clusterName>` otherwise
+func NewS3Folders(s3URI string, clusterName string, assetPrefix string) S3Folders {
 	return S3Folders{
 		s3URI:       s3URI,
 		clusterName: clusterName,
+		assetPrefix: assetPrefix,
 	}
 }
 
@@ -22,6 +26,9 @@ func (n S3Folders) root() S3Folder {
 }
 
 func (n S3Folders) Cluster() S3Folder {
+	if n.assetPrefix != "" {
+		return n.root().subFolder(n.assetPrefix)
+	}
 	return n.root().subFolder(fmt.Sprintf("kube-aws/clusters/%s", n.clusterName))
 }
 
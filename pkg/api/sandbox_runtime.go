@@ -0,0 +1,211 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// SandboxRuntimeGVisor runs containers on the gVisor user-space kernel via `runsc`
+	SandboxRuntimeGVisor = "gvisor"
+	// SandboxRuntimeKata runs containers in lightweight VMs of Kata Containers, which requires bare metal instances for KVM
+	SandboxRuntimeKata = "kata"
+
+	defaultGVisorVersion = "20231009"
+	defaultKataVersion   = "3.2.0"
+)
+
+var (
+	sandboxRuntimeNames          = []string{SandboxRuntimeGVisor, SandboxRuntimeKata}
+	sandboxRuntimeVersionPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)*$`)
+	// metalInstanceTypePattern matches bare metal instance types like `m5.metal` and `m7i.metal-24xl`, which expose KVM to Kata Containers
+	metalInstanceTypePattern = regexp.MustCompile(`\.metal(-[0-9]+xl)?$`)
+)
+
+// SandboxRuntime is a sandboxed container runtime installed into containerd on the nodes of a node pool,
+// so that pods can run on it via a RuntimeClass with its handler
+type SandboxRuntime struct {
+	// Name is either `gvisor` or `kata`
+	Name string `yaml:"name"`
+	// Version is the release of the runtime like `20231009` for gVisor and `3.2.0` for Kata Containers.
+	// Defaults to 20231009 for gVisor and 3.2.0 for Kata Containers
+	Version string `yaml:"version,omitempty"`
+}
+
+// Handler returns the name of the containerd runtime, which RuntimeClasses refer to
+func (r SandboxRuntime) Handler() string {
+	return sandboxRuntimeHandler(r.Name)
+}
+
+// RuntimeType returns the containerd shim the runtime is run with
+func (r SandboxRuntime) RuntimeType() string {
+	if r.Name == SandboxRuntimeKata {
+		return "io.containerd.kata.v2"
+	}
+	return "io.containerd.runsc.v1"
+}
+
+func (r SandboxRuntime) VersionOrDefault() string {
+	if r.Version != "" {
+		return r.Version
+	}
+	if r.Name == SandboxRuntimeKata {
+		return defaultKataVersion
+	}
+	return defaultGVisorVersion
+}
+
+// NodeLabel returns the key of the label each node with the runtime registers itself with, which RuntimeClasses schedule pods by
+func (r SandboxRuntime) NodeLabel() string {
+	return sandboxRuntimeNodeLabel(r.Name)
+}
+
+func (r SandboxRuntime) IsGVisor() bool {
+	return r.Name == SandboxRuntimeGVisor
+}
+
+func (r SandboxRuntime) IsKata() bool {
+	return r.Name == SandboxRuntimeKata
+}
+
+func sandboxRuntimeHandler(name string) string {
+	if name == SandboxRuntimeKata {
+		return "kata"
+	}
+	return "runsc"
+}
+
+func sandboxRuntimeNodeLabel(name string) string {
+	return "kube-aws.coreos.com/sandbox-runtime-" + name
+}
+
+// SandboxRuntimes are the sandboxed container runtimes installed on the nodes of a node pool
+type SandboxRuntimes []SandboxRuntime
+
+func (rs SandboxRuntimes) Enabled() bool {
+	return len(rs) > 0
+}
+
+func (rs SandboxRuntimes) Includes(name string) bool {
+	for _, r := range rs {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateSandboxRuntimes returns an error when the sandbox runtimes can't be installed on the node pool running the container runtime,
+// which is either customized for the node pool or inherited from the main cluster
+func (c WorkerNodePool) ValidateSandboxRuntimes(runtime string, defaultInstanceType string) error {
+	if !c.SandboxRuntimes.Enabled() {
+		return nil
+	}
+	// Docker and rkt can't run containers with multiple runtimes per the RuntimeClass of each pod
+	if runtime != ContainerRuntimeContainerd {
+		return fmt.Errorf("`sandboxRuntimes` requires the `containerd` container runtime, but the container runtime was \"%s\"", runtime)
+	}
+
+	names := map[string]bool{}
+	for i, r := range c.SandboxRuntimes {
+		if !containsString(sandboxRuntimeNames, r.Name) {
+			return fmt.Errorf("invalid `sandboxRuntimes[%d].name` \"%s\": it must be one of %s", i, r.Name, strings.Join(sandboxRuntimeNames, ", "))
+		}
+		if names[r.Name] {
+			return fmt.Errorf("`sandboxRuntimes[%d].name` \"%s\" is duplicated", i, r.Name)
+		}
+		names[r.Name] = true
+		if r.Version != "" && !sandboxRuntimeVersionPattern.MatchString(r.Version) {
+			return fmt.Errorf("invalid `sandboxRuntimes[%d].version` \"%s\": it must be a release like %s", i, r.Version, SandboxRuntime{Name: r.Name}.VersionOrDefault())
+		}
+	}
+
+	if names[SandboxRuntimeKata] {
+		instanceType := c.InstanceType
+		if instanceType == "" {
+			instanceType = defaultInstanceType
+		}
+		instanceTypes := []string{instanceType}
+		for _, s := range c.SpotFleet.LaunchSpecifications {
+			instanceTypes = append(instanceTypes, s.InstanceType)
+		}
+		if c.AutoScalingGroup.MixedInstances.Enabled {
+			instanceTypes = append(instanceTypes, c.AutoScalingGroup.MixedInstances.InstanceTypes...)
+		}
+		for _, t := range instanceTypes {
+			if !metalInstanceTypePattern.MatchString(t) {
+				return fmt.Errorf("the `kata` sandbox runtime requires bare metal instance types like m5.metal for KVM, but the instance type was \"%s\"", t)
+			}
+		}
+	}
+	return nil
+}
+
+// RuntimeClass is a RuntimeClass created in the cluster for running pods on a sandbox runtime installed on node pools.
+// Pods with the RuntimeClass are scheduled only onto the nodes with the runtime
+type RuntimeClass struct {
+	Name string `yaml:"name"`
+	// Runtime is the name of the sandbox runtime, either `gvisor` or `kata`
+	Runtime string `yaml:"runtime"`
+}
+
+func (c RuntimeClass) Handler() string {
+	return sandboxRuntimeHandler(c.Runtime)
+}
+
+func (c RuntimeClass) NodeLabel() string {
+	return sandboxRuntimeNodeLabel(c.Runtime)
+}
+
+type RuntimeClasses []RuntimeClass
+
+// APIVersion returns the API version of RuntimeClasses served by the apiserver of the Kubernetes version
+func (cs RuntimeClasses) APIVersion(k8sVer string) (string, error) {
+	ok, err := k8sVersionSatisfies(">= 1.20", k8sVer)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return "node.k8s.io/v1", nil
+	}
+	return "node.k8s.io/v1beta1", nil
+}
+
+func (c Cluster) validateRuntimeClasses() error {
+	if len(c.RuntimeClasses) == 0 {
+		return nil
+	}
+	if ok, err := k8sVersionSatisfies(">= 1.16", c.K8sVer); err != nil {
+		return err
+	} else if !ok {
+		return fmt.Errorf("`runtimeClasses` requires kubernetesVersion 1.16 or greater, but was %s", c.K8sVer)
+	}
+
+	names := map[string]bool{}
+	for i, rc := range c.RuntimeClasses {
+		if !kubernetesObjectNamePattern.MatchString(rc.Name) {
+			return fmt.Errorf("invalid `runtimeClasses[%d].name` \"%s\": it must be a DNS subdomain", i, rc.Name)
+		}
+		if names[rc.Name] {
+			return fmt.Errorf("`runtimeClasses[%d].name` \"%s\" is duplicated", i, rc.Name)
+		}
+		names[rc.Name] = true
+		if !containsString(sandboxRuntimeNames, rc.Runtime) {
+			return fmt.Errorf("invalid `runtimeClasses[%d].runtime` \"%s\": it must be one of %s", i, rc.Runtime, strings.Join(sandboxRuntimeNames, ", "))
+		}
+		installed := false
+		for _, p := range c.Worker.NodePools {
+			if p.SandboxRuntimes.Includes(rc.Runtime) {
+				installed = true
+				break
+			}
+		}
+		// Otherwise pods with the RuntimeClass would be pending forever as no node has the label the RuntimeClass selects nodes by
+		if !installed {
+			return fmt.Errorf("the runtime \"%s\" of `runtimeClasses[%d]` \"%s\" must be installed on one or more node pools via `worker.nodePools[].sandboxRuntimes`",
+				rc.Runtime, i, rc.Name)
+		}
+	}
+	return nil
+}
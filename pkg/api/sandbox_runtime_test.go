@@ -0,0 +1,157 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestWorkerNodePoolValidateSandboxRuntimes(t *testing.T) {
+	testCases := []struct {
+		pool    WorkerNodePool
+		runtime string
+		isValid bool
+	}{
+		// Valid, not configured
+		{
+			pool:    WorkerNodePool{},
+			runtime: ContainerRuntimeDocker,
+			isValid: true,
+		},
+		// Valid, gVisor
+		{
+			pool:    WorkerNodePool{SandboxRuntimes: SandboxRuntimes{{Name: SandboxRuntimeGVisor, Version: "20231009.0"}}},
+			runtime: ContainerRuntimeContainerd,
+			isValid: true,
+		},
+		// Valid, both on bare metal instances
+		{
+			pool: WorkerNodePool{
+				EC2Instance:     EC2Instance{InstanceType: "m5.metal"},
+				SandboxRuntimes: SandboxRuntimes{{Name: SandboxRuntimeGVisor}, {Name: SandboxRuntimeKata}},
+			},
+			runtime: ContainerRuntimeContainerd,
+			isValid: true,
+		},
+		// Invalid, docker
+		{
+			pool:    WorkerNodePool{SandboxRuntimes: SandboxRuntimes{{Name: SandboxRuntimeGVisor}}},
+			runtime: ContainerRuntimeDocker,
+			isValid: false,
+		},
+		// Invalid, unknown runtime
+		{
+			pool:    WorkerNodePool{SandboxRuntimes: SandboxRuntimes{{Name: "firecracker"}}},
+			runtime: ContainerRuntimeContainerd,
+			isValid: false,
+		},
+		// Invalid, duplicated runtime
+		{
+			pool:    WorkerNodePool{SandboxRuntimes: SandboxRuntimes{{Name: SandboxRuntimeGVisor}, {Name: SandboxRuntimeGVisor}}},
+			runtime: ContainerRuntimeContainerd,
+			isValid: false,
+		},
+		// Invalid, malformed version
+		{
+			pool:    WorkerNodePool{SandboxRuntimes: SandboxRuntimes{{Name: SandboxRuntimeGVisor, Version: "latest; rm -rf /"}}},
+			runtime: ContainerRuntimeContainerd,
+			isValid: false,
+		},
+		// Invalid, kata on the default instance type of node pools
+		{
+			pool:    WorkerNodePool{SandboxRuntimes: SandboxRuntimes{{Name: SandboxRuntimeKata}}},
+			runtime: ContainerRuntimeContainerd,
+			isValid: false,
+		},
+		// Invalid, kata on mixed instances including a virtualized instance type
+		{
+			pool: func() WorkerNodePool {
+				p := WorkerNodePool{EC2Instance: EC2Instance{InstanceType: "m5.metal"}, SandboxRuntimes: SandboxRuntimes{{Name: SandboxRuntimeKata}}}
+				p.AutoScalingGroup.MixedInstances = MixedInstances{Enabled: true, InstanceTypes: []string{"m5.metal", "m5.24xlarge"}}
+				return p
+			}(),
+			runtime: ContainerRuntimeContainerd,
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.pool.ValidateSandboxRuntimes(testCase.runtime, "t2.medium")
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid with %s but got an error: %v", i, testCase.pool.SandboxRuntimes, testCase.runtime, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid with %s but was not", i, testCase.pool.SandboxRuntimes, testCase.runtime)
+		}
+	}
+}
+
+func TestClusterValidateRuntimeClasses(t *testing.T) {
+	cluster := func(k8sVer string, classes ...RuntimeClass) Cluster {
+		c := Cluster{RuntimeClasses: classes}
+		c.K8sVer = k8sVer
+		c.Worker.NodePools = []WorkerNodePool{{SandboxRuntimes: SandboxRuntimes{{Name: SandboxRuntimeGVisor}}}}
+		return c
+	}
+
+	testCases := []struct {
+		cluster Cluster
+		isValid bool
+	}{
+		// Valid, not configured
+		{
+			cluster: cluster("v1.11.3"),
+			isValid: true,
+		},
+		// Valid, gVisor installed on a node pool
+		{
+			cluster: cluster("v1.27.3", RuntimeClass{Name: "gvisor", Runtime: SandboxRuntimeGVisor}, RuntimeClass{Name: "untrusted", Runtime: SandboxRuntimeGVisor}),
+			isValid: true,
+		},
+		// Invalid, unsupported Kubernetes version
+		{
+			cluster: cluster("v1.15.12", RuntimeClass{Name: "gvisor", Runtime: SandboxRuntimeGVisor}),
+			isValid: false,
+		},
+		// Invalid, malformed name
+		{
+			cluster: cluster("v1.27.3", RuntimeClass{Name: "gVisor", Runtime: SandboxRuntimeGVisor}),
+			isValid: false,
+		},
+		// Invalid, duplicated name
+		{
+			cluster: cluster("v1.27.3", RuntimeClass{Name: "gvisor", Runtime: SandboxRuntimeGVisor}, RuntimeClass{Name: "gvisor", Runtime: SandboxRuntimeGVisor}),
+			isValid: false,
+		},
+		// Invalid, unknown runtime
+		{
+			cluster: cluster("v1.27.3", RuntimeClass{Name: "runc", Runtime: "runc"}),
+			isValid: false,
+		},
+		// Invalid, runtime installed on no node pool
+		{
+			cluster: cluster("v1.27.3", RuntimeClass{Name: "kata", Runtime: SandboxRuntimeKata}),
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.cluster.validateRuntimeClasses()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.cluster.RuntimeClasses, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.cluster.RuntimeClasses)
+		}
+	}
+}
+
+func TestRuntimeClassesAPIVersion(t *testing.T) {
+	for k8sVer, expected := range map[string]string{"v1.19.16": "node.k8s.io/v1beta1", "v1.20.0": "node.k8s.io/v1"} {
+		actual, err := RuntimeClasses{}.APIVersion(k8sVer)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if actual != expected {
+			t.Errorf("expected %s for %s but was %s", expected, k8sVer, actual)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package api
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+)
+
+// The paths of the discovery documents relative to the issuer URL
+const (
+	OpenIDConfigurationPath = ".well-known/openid-configuration"
+	JWKSPath                = "keys.json"
+)
+
+// defaultOIDCProviderClientID is the audience of the service account tokens exchanged for AWS credentials via `sts:AssumeRoleWithWebIdentity`
+const defaultOIDCProviderClientID = "sts.amazonaws.com"
+
+// ServiceAccountIssuer is the set of settings for the issuer of service account tokens, which can be trusted by an IAM OIDC provider
+// so that pods exchange their projected service account tokens for AWS credentials, a.k.a. IAM roles for service accounts
+type ServiceAccountIssuer struct {
+	// URL is passed to the apiserver's `--service-account-issuer` as the `iss` claim of service account tokens, like
+	// `https://my-bucket.s3.us-west-2.amazonaws.com/my-cluster` or `https://d111111abcdef8.cloudfront.net`
+	URL string `yaml:"url,omitempty"`
+	// APIAudiences are passed to the apiserver's `--api-audiences`, which are the audiences the apiserver accepts service account tokens for,
+	// e.g. the ones projected service account tokens are requested with for external systems. Defaults to the issuer URL.
+	// They must include the issuer URL, the audience tokens are issued for by default, unless APIAudiencesExcludeIssuer is true
+	APIAudiences []string `yaml:"apiAudiences,omitempty"`
+	// APIAudiencesExcludeIssuer allows APIAudiences not to include the issuer URL, so that the apiserver rejects tokens for the issuer
+	APIAudiencesExcludeIssuer bool `yaml:"apiAudiencesExcludeIssuer,omitempty"`
+	// Discovery configures the S3 bucket kube-aws publishes the OIDC discovery documents to
+	Discovery ServiceAccountIssuerDiscovery `yaml:"discovery,omitempty"`
+	// OIDCProvider configures the IAM OIDC provider trusting the issuer, which is created in the control-plane stack
+	OIDCProvider ServiceAccountIssuerOIDCProvider `yaml:"oidcProvider,omitempty"`
+}
+
+type ServiceAccountIssuerDiscovery struct {
+	// S3Bucket is the bucket `kube-aws apply` uploads `/.well-known/openid-configuration` and `/keys.json` under the path of the issuer URL to.
+	// The documents must be publicly readable via the issuer URL, either from the bucket itself or from a CloudFront distribution in front of it
+	S3Bucket string `yaml:"s3Bucket,omitempty"`
+	// CloudFrontDomainName is the domain name of the CloudFront distribution serving the bucket, like `d111111abcdef8.cloudfront.net`
+	// or its alternate domain name. Omit it when the issuer URL points to the bucket itself
+	CloudFrontDomainName string `yaml:"cloudFrontDomainName,omitempty"`
+}
+
+type ServiceAccountIssuerOIDCProvider struct {
+	Create bool `yaml:"create,omitempty"`
+	// ClientIDs are the audiences the provider accepts. Defaults to `sts.amazonaws.com`
+	ClientIDs []string `yaml:"clientIds,omitempty"`
+	// Thumbprints are the SHA-1 thumbprints of the certificates of the issuer's TLS server. Defaults to the ones IAM obtains by itself
+	Thumbprints []string `yaml:"thumbprints,omitempty"`
+}
+
+func (i ServiceAccountIssuer) Enabled() bool {
+	return i.URL != ""
+}
+
+// DiscoveryEnabled returns true when kube-aws publishes the OIDC discovery documents
+func (i ServiceAccountIssuer) DiscoveryEnabled() bool {
+	return i.Discovery.S3Bucket != ""
+}
+
+// APIAudiencesString returns the value of the apiserver's `--api-audiences`
+func (i ServiceAccountIssuer) APIAudiencesString() string {
+	if len(i.APIAudiences) == 0 {
+		return i.URL
+	}
+	return strings.Join(i.APIAudiences, ",")
+}
+
+// JWKSURI returns the URL of the JWKS document, which is passed to the apiserver's `--service-account-jwks-uri`
+func (i ServiceAccountIssuer) JWKSURI() string {
+	return strings.TrimSuffix(i.URL, "/") + "/" + JWKSPath
+}
+
+// DiscoveryObjectKey returns the key of the S3 object the document at the path relative to the issuer URL is published to
+func (i ServiceAccountIssuer) DiscoveryObjectKey(path string) (string, error) {
+	u, err := url.Parse(i.URL)
+	if err != nil {
+		return "", err
+	}
+	prefix := strings.Trim(u.Path, "/")
+	if prefix == "" {
+		return path, nil
+	}
+	return prefix + "/" + path, nil
+}
+
+func (p ServiceAccountIssuerOIDCProvider) ClientIDsOrDefault() []string {
+	if len(p.ClientIDs) == 0 {
+		return []string{defaultOIDCProviderClientID}
+	}
+	return p.ClientIDs
+}
+
+// OpenIDConfiguration returns the OIDC discovery document of the issuer, equivalent to the one served by the apiserver
+func (i ServiceAccountIssuer) OpenIDConfiguration() (string, error) {
+	doc := map[string]interface{}{
+		"issuer":                                i.URL,
+		"jwks_uri":                              i.JWKSURI(),
+		"authorization_endpoint":                "urn:kubernetes:programmatic_authorization",
+		"response_types_supported":              []string{"id_token"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"claims_supported":                      []string{"sub", "iss"},
+	}
+	b, err := json.MarshalIndent(doc, "", "  ")
+	return string(b), err
+}
+
+// JWKS returns the JWKS document containing the public key of the PEM-encoded RSA private key signing service account tokens.
+// The key ID is derived from the public key in the same way as the apiserver does, so that it matches the `kid` header of the tokens
+func (i ServiceAccountIssuer) JWKS(serviceAccountKeyPEM []byte) (string, error) {
+	block, _ := pem.Decode(serviceAccountKeyPEM)
+	if block == nil {
+		return "", errors.New("failed to decode the PEM-encoded service account key")
+	}
+	var pub *rsa.PublicKey
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		pub = &key.PublicKey
+	} else if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return "", errors.New("the service account key must be an RSA private key")
+		}
+		pub = &rsaKey.PublicKey
+	} else {
+		return "", fmt.Errorf("failed to parse the service account key: %v", err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	hasher := crypto.SHA256.New()
+	hasher.Write(der)
+	kid := base64.RawURLEncoding.EncodeToString(hasher.Sum(nil))
+
+	doc := map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"use": "sig",
+				"kty": "RSA",
+				"kid": kid,
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			},
+		},
+	}
+	b, err := json.MarshalIndent(doc, "", "  ")
+	return string(b), err
+}
+
+func (i ServiceAccountIssuer) Validate(region Region) error {
+	if !i.Enabled() {
+		if i.DiscoveryEnabled() || i.OIDCProvider.Create {
+			return errors.New("`controller.apiServer.serviceAccountIssuer.url` must be specified to publish the discovery documents or to create the IAM OIDC provider")
+		}
+		if len(i.APIAudiences) > 0 {
+			return errors.New("`controller.apiServer.serviceAccountIssuer.url` must be specified with `apiAudiences`")
+		}
+		return nil
+	}
+
+	u, err := url.Parse(i.URL)
+	if err != nil {
+		return fmt.Errorf("invalid `controller.apiServer.serviceAccountIssuer.url` \"%s\": %v", i.URL, err)
+	}
+	if u.Scheme != "https" || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("`controller.apiServer.serviceAccountIssuer.url` must be an https URL without a query or a fragment, but was \"%s\"", i.URL)
+	}
+
+	if err := i.validateAPIAudiences(); err != nil {
+		return err
+	}
+
+	d := i.Discovery
+	if d.CloudFrontDomainName != "" && d.S3Bucket == "" {
+		return errors.New("`controller.apiServer.serviceAccountIssuer.discovery.s3Bucket` must be specified with `cloudFrontDomainName`")
+	}
+	if d.S3Bucket != "" {
+		if d.CloudFrontDomainName != "" {
+			if u.Host != d.CloudFrontDomainName {
+				return fmt.Errorf("the host of `controller.apiServer.serviceAccountIssuer.url` \"%s\" must be the CloudFront domain name \"%s\"", u.Host, d.CloudFrontDomainName)
+			}
+		} else {
+			hosts := []string{
+				fmt.Sprintf("%s.s3.%s.%s", d.S3Bucket, region.Name, region.PublicDomainName()),
+				fmt.Sprintf("%s.s3.%s", d.S3Bucket, region.PublicDomainName()),
+			}
+			if !containsString(hosts, u.Host) {
+				return fmt.Errorf("the host of `controller.apiServer.serviceAccountIssuer.url` \"%s\" must be the domain name of the bucket \"%s\", which is one of %s. "+
+					"Specify `discovery.cloudFrontDomainName` when the bucket is served via CloudFront", u.Host, d.S3Bucket, strings.Join(hosts, ", "))
+			}
+		}
+	}
+
+	return nil
+}
+
+func (i ServiceAccountIssuer) validateAPIAudiences() error {
+	audiences := map[string]bool{}
+	for j, a := range i.APIAudiences {
+		if a == "" || strings.ContainsAny(a, ", \t\n") {
+			return fmt.Errorf("invalid `controller.apiServer.serviceAccountIssuer.apiAudiences[%d]` \"%s\": it must be non-empty and must not contain commas or whitespaces", j, a)
+		}
+		if audiences[a] {
+			return fmt.Errorf("invalid `controller.apiServer.serviceAccountIssuer.apiAudiences[%d]`: duplicate audience \"%s\"", j, a)
+		}
+		audiences[a] = true
+	}
+	if i.APIAudiencesExcludeIssuer {
+		if len(i.APIAudiences) == 0 {
+			return errors.New("`controller.apiServer.serviceAccountIssuer.apiAudiences` must be specified with `apiAudiencesExcludeIssuer`")
+		}
+		return nil
+	}
+	if len(i.APIAudiences) > 0 && !audiences[i.URL] {
+		return fmt.Errorf("`controller.apiServer.serviceAccountIssuer.apiAudiences` must include the issuer url \"%s\" so that the apiserver keeps accepting tokens for the issuer. "+
+			"Set `apiAudiencesExcludeIssuer` to true to exclude it", i.URL)
+	}
+	return nil
+}
+
+// ValidateKubernetesVersion returns an error when the issuer is configured for the apiserver of a kubernetes version which doesn't
+// issue projected service account tokens by default
+func (i ServiceAccountIssuer) ValidateKubernetesVersion(k8sVer string) error {
+	if !i.Enabled() {
+		return nil
+	}
+	supported, err := k8sVersionSatisfies(">= 1.20", k8sVer)
+	if err != nil {
+		return err
+	}
+	if !supported {
+		return fmt.Errorf("`controller.apiServer.serviceAccountIssuer` requires kubernetesVersion 1.20 or greater, but was %s", k8sVer)
+	}
+	return nil
+}
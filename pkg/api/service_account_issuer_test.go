@@ -0,0 +1,198 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+)
+
+func TestServiceAccountIssuerValidate(t *testing.T) {
+	region := RegionForName("us-west-1")
+
+	testCases := []struct {
+		issuer  ServiceAccountIssuer
+		isValid bool
+	}{
+		// Valid, not configured
+		{
+			issuer:  ServiceAccountIssuer{},
+			isValid: true,
+		},
+		// Valid, issuer without discovery
+		{
+			issuer:  ServiceAccountIssuer{URL: "https://oidc.example.com"},
+			isValid: true,
+		},
+		// Valid, regional domain name of the bucket
+		{
+			issuer: ServiceAccountIssuer{
+				URL:       "https://my-bucket.s3.us-west-1.amazonaws.com/my-cluster",
+				Discovery: ServiceAccountIssuerDiscovery{S3Bucket: "my-bucket"},
+			},
+			isValid: true,
+		},
+		// Valid, global domain name of the bucket
+		{
+			issuer: ServiceAccountIssuer{
+				URL:       "https://my-bucket.s3.amazonaws.com",
+				Discovery: ServiceAccountIssuerDiscovery{S3Bucket: "my-bucket"},
+			},
+			isValid: true,
+		},
+		// Valid, CloudFront
+		{
+			issuer: ServiceAccountIssuer{
+				URL:       "https://d111111abcdef8.cloudfront.net",
+				Discovery: ServiceAccountIssuerDiscovery{S3Bucket: "my-bucket", CloudFrontDomainName: "d111111abcdef8.cloudfront.net"},
+			},
+			isValid: true,
+		},
+		// Valid, custom audiences including the issuer
+		{
+			issuer:  ServiceAccountIssuer{URL: "https://oidc.example.com", APIAudiences: []string{"https://oidc.example.com", "vault.example.com"}},
+			isValid: true,
+		},
+		// Valid, custom audiences excluding the issuer
+		{
+			issuer:  ServiceAccountIssuer{URL: "https://oidc.example.com", APIAudiences: []string{"vault.example.com"}, APIAudiencesExcludeIssuer: true},
+			isValid: true,
+		},
+		// Invalid, custom audiences not including the issuer
+		{
+			issuer:  ServiceAccountIssuer{URL: "https://oidc.example.com", APIAudiences: []string{"vault.example.com"}},
+			isValid: false,
+		},
+		// Invalid, excluding the issuer without custom audiences
+		{
+			issuer:  ServiceAccountIssuer{URL: "https://oidc.example.com", APIAudiencesExcludeIssuer: true},
+			isValid: false,
+		},
+		// Invalid, audience containing a comma
+		{
+			issuer:  ServiceAccountIssuer{URL: "https://oidc.example.com", APIAudiences: []string{"https://oidc.example.com", "a,b"}},
+			isValid: false,
+		},
+		// Invalid, duplicate audiences
+		{
+			issuer:  ServiceAccountIssuer{URL: "https://oidc.example.com", APIAudiences: []string{"https://oidc.example.com", "https://oidc.example.com"}},
+			isValid: false,
+		},
+		// Invalid, audiences without the issuer url
+		{
+			issuer:  ServiceAccountIssuer{APIAudiences: []string{"vault.example.com"}},
+			isValid: false,
+		},
+		// Invalid, http
+		{
+			issuer:  ServiceAccountIssuer{URL: "http://oidc.example.com"},
+			isValid: false,
+		},
+		// Invalid, the host isn't the bucket
+		{
+			issuer: ServiceAccountIssuer{
+				URL:       "https://other-bucket.s3.us-west-1.amazonaws.com",
+				Discovery: ServiceAccountIssuerDiscovery{S3Bucket: "my-bucket"},
+			},
+			isValid: false,
+		},
+		// Invalid, the bucket in another region
+		{
+			issuer: ServiceAccountIssuer{
+				URL:       "https://my-bucket.s3.us-east-2.amazonaws.com",
+				Discovery: ServiceAccountIssuerDiscovery{S3Bucket: "my-bucket"},
+			},
+			isValid: false,
+		},
+		// Invalid, the host isn't the CloudFront domain name
+		{
+			issuer: ServiceAccountIssuer{
+				URL:       "https://my-bucket.s3.us-west-1.amazonaws.com",
+				Discovery: ServiceAccountIssuerDiscovery{S3Bucket: "my-bucket", CloudFrontDomainName: "d111111abcdef8.cloudfront.net"},
+			},
+			isValid: false,
+		},
+		// Invalid, CloudFront without the bucket
+		{
+			issuer: ServiceAccountIssuer{
+				URL:       "https://d111111abcdef8.cloudfront.net",
+				Discovery: ServiceAccountIssuerDiscovery{CloudFrontDomainName: "d111111abcdef8.cloudfront.net"},
+			},
+			isValid: false,
+		},
+		// Invalid, OIDC provider without the url
+		{
+			issuer:  ServiceAccountIssuer{OIDCProvider: ServiceAccountIssuerOIDCProvider{Create: true}},
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.issuer.Validate(region)
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.issuer, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.issuer)
+		}
+	}
+}
+
+func TestServiceAccountIssuerDiscoveryObjectKey(t *testing.T) {
+	testCases := []struct {
+		url      string
+		expected string
+	}{
+		{url: "https://my-bucket.s3.amazonaws.com", expected: "keys.json"},
+		{url: "https://my-bucket.s3.amazonaws.com/", expected: "keys.json"},
+		{url: "https://my-bucket.s3.amazonaws.com/clusters/my-cluster", expected: "clusters/my-cluster/keys.json"},
+	}
+
+	for i, testCase := range testCases {
+		actual, err := ServiceAccountIssuer{URL: testCase.url}.DiscoveryObjectKey(JWKSPath)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if actual != testCase.expected {
+			t.Errorf("case %d: expected %s but was %s", i, testCase.expected, actual)
+		}
+	}
+}
+
+func TestServiceAccountIssuerJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate a key: %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	issuer := ServiceAccountIssuer{URL: "https://d111111abcdef8.cloudfront.net"}
+	doc, err := issuer.JWKS(keyPEM)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var jwks struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	if err := json.Unmarshal([]byte(doc), &jwks); err != nil {
+		t.Fatalf("invalid JWKS: %v", err)
+	}
+	if len(jwks.Keys) != 1 {
+		t.Fatalf("expected 1 key but was %d: %s", len(jwks.Keys), doc)
+	}
+	k := jwks.Keys[0]
+	if k["kty"] != "RSA" || k["alg"] != "RS256" || k["use"] != "sig" || k["e"] != "AQAB" {
+		t.Errorf("unexpected key: %+v", k)
+	}
+	if len(k["kid"]) != 43 {
+		t.Errorf("expected the kid to be a base64url-encoded SHA-256 hash but was %s", k["kid"])
+	}
+
+	if _, err := issuer.JWKS([]byte("not a key")); err == nil {
+		t.Error("expected an error for an invalid key but was not")
+	}
+}
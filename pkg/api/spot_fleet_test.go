@@ -0,0 +1,12 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestSpotFleetIAMFleetRoleRef(t *testing.T) {
+	expected := `{"Fn::Sub":"arn:${AWS::Partition}:iam::${AWS::AccountId}:role/aws-ec2-spot-fleet-tagging-role"}`
+	if actual := (SpotFleet{}).IAMFleetRoleRef(); actual != expected {
+		t.Errorf("expected the default spot fleet role to be in the partition of the stack: expected=%s actual=%s", expected, actual)
+	}
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"fmt"
+)
+
+// StackDependency declares the stacks and the resources of the root stack a nested stack must be created and updated after,
+// in addition to the ones kube-aws orders the nested stacks with
+type StackDependency struct {
+	// Stack is the name of the dependent stack, which is the name of the control plane, etcd or network stack like `control-plane`,
+	// respecting `stackNameOverrides`, or a node pool name
+	Stack string `yaml:"stack"`
+	// DependsOn are the names of the stacks, or the logical names of the resources added to the root stack by plugins, the stack depends on
+	DependsOn []string `yaml:"dependsOn"`
+}
+
+type StackDependencies []StackDependency
+
+func (ds StackDependencies) Validate() error {
+	stacks := map[string]bool{}
+	for i, d := range ds {
+		if d.Stack == "" {
+			return fmt.Errorf("invalid `cloudformation.stackDependencies[%d]`: `stack` must not be empty", i)
+		}
+		if stacks[d.Stack] {
+			return fmt.Errorf("invalid `cloudformation.stackDependencies[%d]`: duplicate stack \"%s\"", i, d.Stack)
+		}
+		stacks[d.Stack] = true
+
+		if len(d.DependsOn) == 0 {
+			return fmt.Errorf("invalid `cloudformation.stackDependencies[%d]`: `dependsOn` must contain at least one stack or resource", i)
+		}
+		for _, n := range d.DependsOn {
+			if n == "" {
+				return fmt.Errorf("invalid `cloudformation.stackDependencies[%d]`: `dependsOn` must not contain an empty name", i)
+			}
+			if n == d.Stack {
+				return fmt.Errorf("invalid `cloudformation.stackDependencies[%d]`: the stack \"%s\" can not depend on itself", i, d.Stack)
+			}
+		}
+	}
+	return nil
+}
@@ -14,6 +14,8 @@ type Subnet struct {
 	NATGateway       NATGatewayConfig `yaml:"natGateway,omitempty"`
 	Private          bool             `yaml:"private,omitempty"`
 	RouteTable       RouteTable       `yaml:"routeTable,omitempty"`
+	// Auto is set only to the placeholder of `subnets.auto` until the subnets are carved
+	Auto *AutoSubnets `yaml:"-"`
 }
 
 func NewPublicSubnet(az string, cidr string) Subnet {
@@ -47,3 +47,18 @@ func (ss Subnets) ImportFromNetworkStackRetainingNames() (Subnets, error) {
 	}
 	return result, nil
 }
+
+// AvailabilityZones returns the distinct availability zones of the subnets. It returns false as well when the availability zone
+// of any subnet is unknown, like the one of an existing subnet referenced only by its ID
+func (ss Subnets) AvailabilityZones() ([]string, bool) {
+	azs := []string{}
+	for _, s := range ss {
+		if s.AvailabilityZone == "" {
+			return azs, false
+		}
+		if !containsString(azs, s.AvailabilityZone) {
+			azs = append(azs, s.AvailabilityZone)
+		}
+	}
+	return azs, true
+}
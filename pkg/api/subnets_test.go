@@ -30,3 +30,19 @@ func TestSubnetsMixed(t *testing.T) {
 		t.Error("Func ContainsBothPrivateAndPublic should return true when the set of subnets contains both private and public subnet(s) but it did not")
 	}
 }
+
+func TestSubnetsAvailabilityZones(t *testing.T) {
+	a1 := Subnet{Name: "A1", AvailabilityZone: "ap-northeast-1a", InstanceCIDR: "10.0.0.0/24"}
+	a2 := Subnet{Name: "A2", AvailabilityZone: "ap-northeast-1a", InstanceCIDR: "10.0.1.0/24"}
+	c1 := Subnet{Name: "C1", AvailabilityZone: "ap-northeast-1c", InstanceCIDR: "10.0.2.0/24"}
+	existing := Subnet{Name: "Existing", ID: "subnet-1a2b3c4d"}
+
+	azs, known := Subnets{a1, a2, c1}.AvailabilityZones()
+	if !known || len(azs) != 2 || azs[0] != "ap-northeast-1a" || azs[1] != "ap-northeast-1c" {
+		t.Errorf("Func AvailabilityZones should return the distinct availability zones of the subnets but it returned %v, %v", azs, known)
+	}
+
+	if _, known := (Subnets{a1, existing}).AvailabilityZones(); known {
+		t.Error("Func AvailabilityZones should return false when the availability zone of a subnet is unknown but it did not")
+	}
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+)
+
+// TrustedCA is a PEM bundle of one or more CA certificates to be added to the trust store of every node
+type TrustedCA string
+
+// Lines returns the PEM bundle split into lines, to be embedded in a cloud-config
+func (c TrustedCA) Lines() []string {
+	return strings.Split(strings.TrimSpace(string(c)), "\n")
+}
+
+func (c TrustedCA) Validate() error {
+	data := []byte(strings.TrimSpace(string(c)))
+	if len(data) == 0 {
+		return fmt.Errorf("it must not be empty")
+	}
+	count := 0
+	for len(data) > 0 {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return fmt.Errorf("failed to parse PEM: it must consist only of PEM-encoded certificates")
+		}
+		if block.Type != "CERTIFICATE" {
+			return fmt.Errorf("unexpected PEM block of type \"%s\": it must consist only of certificates", block.Type)
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return fmt.Errorf("failed to parse certificate #%d: %v", count+1, err)
+		}
+		count++
+		data = bytes.TrimSpace(data)
+	}
+	return nil
+}
+
+// TrustedCAs is a list of PEM bundles like the ones specified via `additionalTrustedCAs` in cluster.yaml
+type TrustedCAs []TrustedCA
+
+func (cs TrustedCAs) Validate() error {
+	return cs.validate("additionalTrustedCAs")
+}
+
+// validate returns an error mentioning the cluster.yaml key the list is specified via when any of the bundles is invalid
+func (cs TrustedCAs) validate(key string) error {
+	for i, c := range cs {
+		if err := c.Validate(); err != nil {
+			return fmt.Errorf("invalid `%s[%d]`: %v", key, i, err)
+		}
+	}
+	return nil
+}
+
+// Bundle returns the concatenation of the PEM bundles
+func (cs TrustedCAs) Bundle() string {
+	bundle := ""
+	for _, c := range cs {
+		bundle += strings.TrimSpace(string(c)) + "\n"
+	}
+	return bundle
+}
@@ -6,21 +6,36 @@ type Worker struct {
 	APIEndpointName         string           `yaml:"apiEndpointName,omitempty"`
 	NodePools               []WorkerNodePool `yaml:"nodePools,omitempty"`
 	NodePoolRollingStrategy string           `yaml:"nodePoolRollingStrategy,omitempty"`
-	UnknownKeys             `yaml:",inline"`
+	// InterPoolCommunication generates the security group ingress rules allowing node pools to reach each other on the ports
+	InterPoolCommunication InterPoolCommunications `yaml:"interPoolCommunication,omitempty"`
+	UnknownKeys            `yaml:",inline"`
 }
 
 // Kubelet options
 type Kubelet struct {
 	RotateCerts             RotateCerts            `yaml:"rotateCerts"`
-	SystemReservedResources string                 `yaml:"systemReserved"`
-	KubeReservedResources   string                 `yaml:"kubeReserved"`
+	SystemReservedResources ReservedResources      `yaml:"systemReserved"`
+	KubeReservedResources   ReservedResources      `yaml:"kubeReserved"`
+	EnforceNodeAllocatable  []string               `yaml:"enforceNodeAllocatable,omitempty"`
+	SystemReservedCgroup    string                 `yaml:"systemReservedCgroup,omitempty"`
+	KubeReservedCgroup      string                 `yaml:"kubeReservedCgroup,omitempty"`
 	Kubeconfig              string                 `yaml:"kubeconfig"`
 	Mounts                  []ContainerVolumeMount `yaml:"mounts"`
+	ConfigFile              KubeletConfigFile      `yaml:"configFile,omitempty"`
+	// SerializeImagePulls is whether kubelet pulls images one at a time. Defaults to kubelet's default, true
+	SerializeImagePulls *bool `yaml:"serializeImagePulls,omitempty"`
+	// MaxParallelImagePulls is the maximum number of images pulled in parallel when image pulls are not serialized
+	MaxParallelImagePulls int `yaml:"maxParallelImagePulls,omitempty"`
+	// EvictionMaxPodGracePeriod is the maximum grace period in seconds given to pods terminated by soft evictions
+	EvictionMaxPodGracePeriod *int `yaml:"evictionMaxPodGracePeriod,omitempty"`
+	// EvictionPressureTransitionPeriod is how long kubelet waits before transitioning out of an eviction pressure condition, like 5m
+	EvictionPressureTransitionPeriod string `yaml:"evictionPressureTransitionPeriod,omitempty"`
 }
 
 type Experimental struct {
 	Admission      Admission      `yaml:"admission"`
 	AuditLog       AuditLog       `yaml:"auditLog"`
+	AuditWebhook   AuditWebhook   `yaml:"auditWebhook,omitempty"`
 	Authentication Authentication `yaml:"authentication"`
 	AwsEnvironment AwsEnvironment `yaml:"awsEnvironment"`
 	AwsNodeLabels  AwsNodeLabels  `yaml:"awsNodeLabels"`
@@ -229,7 +244,8 @@ type TargetGroup struct {
 }
 
 type KubeProxy struct {
-	IPVSMode IPVSMode `yaml:"ipvsMode"`
+	IPVSMode  IPVSMode           `yaml:"ipvsMode"`
+	Conntrack KubeProxyConntrack `yaml:"conntrack,omitempty"`
 }
 
 type IPVSMode struct {
@@ -251,6 +267,7 @@ type KubeDns struct {
 	NodeLocalResolverOptions []string          `yaml:"nodeLocalResolverOptions"`
 	DeployToControllers      bool              `yaml:"deployToControllers"`
 	Autoscaler               KubeDnsAutoscaler `yaml:"autoscaler"`
+	DNSConfig                DNSConfig         `yaml:"dnsConfig,omitempty"`
 }
 
 func (c *KubeDns) MergeIfEmpty(other KubeDns) {
@@ -258,4 +275,7 @@ func (c *KubeDns) MergeIfEmpty(other KubeDns) {
 		c.NodeLocalResolver = other.NodeLocalResolver
 		c.DeployToControllers = other.DeployToControllers
 	}
+	if c.DNSConfig.IsEmpty() {
+		c.DNSConfig = other.DNSConfig
+	}
 }
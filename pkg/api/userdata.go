@@ -63,6 +63,16 @@ func UserDataPartsOpt(Parts ...PartDesc) UserDataOption {
 	}
 }
 
+// InstancePartOnlyOpt is for the userdata consisting only of the instance part embedded into the EC2 user-data
+func InstancePartOnlyOpt() UserDataOption {
+	return UserDataPartsOpt(PartDesc{USERDATA_INSTANCE, validateNone})
+}
+
+// PowerShellPartsOpt is for the userdata consisting of the instance part and the PowerShell script stored in S3 instead of cloud-config
+func PowerShellPartsOpt() UserDataOption {
+	return UserDataPartsOpt(PartDesc{USERDATA_INSTANCE, validateNone}, PartDesc{USERDATA_S3, validateNone})
+}
+
 // NewUserDataFromTemplateFile creates userdata struct from template file.
 // Template file is expected to have defined subtemplates (Parts) which are of various part and storage types
 // TODO Extract this out of the clusterapi package as this is an "implementation"
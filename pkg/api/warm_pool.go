@@ -0,0 +1,101 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// WarmPoolStateStopped keeps the pre-initialized instances stopped, paying only for their EBS volumes
+	WarmPoolStateStopped = "Stopped"
+	// WarmPoolStateRunning keeps the pre-initialized instances running, so that they join the cluster without booting
+	WarmPoolStateRunning = "Running"
+	// WarmPoolStateHibernated keeps the memory of the pre-initialized instances on their encrypted root volumes while stopped
+	WarmPoolStateHibernated = "Hibernated"
+
+	warmPoolLifecycleHookName = "kube-aws-warm-pool"
+)
+
+var warmPoolStates = []string{WarmPoolStateStopped, WarmPoolStateRunning, WarmPoolStateHibernated}
+
+// WarmPool is the pool of pre-initialized instances kept aside the auto scaling groups of a node pool, which are moved into service on scale-out.
+// Each warmed instance boots and prepares itself once, but doesn't start kubelet until the instance is moved into service
+type WarmPool struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// MinSize is the minimum number of instances kept in the warm pool. Defaults to 0
+	MinSize int `yaml:"minSize,omitempty"`
+	// MaxPreparedCapacity is the maximum number of instances of the node pool, either in service or in the warm pool.
+	// Defaults to the max size of the node pool
+	MaxPreparedCapacity *int `yaml:"maxPreparedCapacity,omitempty"`
+	// PoolState is the state of the instances in the warm pool, one of `Stopped`, `Running` or `Hibernated`. Defaults to `Stopped`
+	PoolState string `yaml:"poolState,omitempty"`
+}
+
+func (p WarmPool) PoolStateOrDefault() string {
+	if p.PoolState != "" {
+		return p.PoolState
+	}
+	return WarmPoolStateStopped
+}
+
+// Hibernated returns true when the instances are hibernated, which requires the launch template to enable hibernation
+func (p WarmPool) Hibernated() bool {
+	return p.Enabled && p.PoolState == WarmPoolStateHibernated
+}
+
+// LifecycleHookName returns the name of the launch lifecycle hook each instance completes once it is ready to be warmed or put in service
+func (p WarmPool) LifecycleHookName() string {
+	return warmPoolLifecycleHookName
+}
+
+func (p WarmPool) Validate() error {
+	if !p.Enabled {
+		if p.MinSize != 0 || p.MaxPreparedCapacity != nil || p.PoolState != "" {
+			return errors.New("`autoscaling.warmPool.minSize`, `autoscaling.warmPool.maxPreparedCapacity` and `autoscaling.warmPool.poolState` require `autoscaling.warmPool.enabled` to be true")
+		}
+		return nil
+	}
+
+	if p.PoolState != "" && !containsString(warmPoolStates, p.PoolState) {
+		return fmt.Errorf("invalid `autoscaling.warmPool.poolState` \"%s\": it must be one of %s", p.PoolState, strings.Join(warmPoolStates, ", "))
+	}
+	if p.MinSize < 0 {
+		return fmt.Errorf("`autoscaling.warmPool.minSize` must be 0 or greater, but was %d", p.MinSize)
+	}
+	if p.MaxPreparedCapacity != nil {
+		if *p.MaxPreparedCapacity < 0 {
+			return fmt.Errorf("`autoscaling.warmPool.maxPreparedCapacity` must be 0 or greater, but was %d", *p.MaxPreparedCapacity)
+		}
+		if p.MinSize > *p.MaxPreparedCapacity {
+			return fmt.Errorf("`autoscaling.warmPool.minSize` %d must be equal to or less than `autoscaling.warmPool.maxPreparedCapacity` %d", p.MinSize, *p.MaxPreparedCapacity)
+		}
+	}
+	return nil
+}
+
+// ValidateWarmPool validates `autoscaling.warmPool` against the settings the node pool is finally deployed with
+func (c WorkerNodePool) ValidateWarmPool() error {
+	p := c.Autoscaling.WarmPool
+	if !p.Enabled {
+		return nil
+	}
+
+	// Warm pools are made of on-demand instances of the single instance type of the launch template
+	if c.SpotFleet.Enabled() {
+		return errors.New("`autoscaling.warmPool` can't be enabled for a node pool backed by a spot fleet")
+	}
+	if c.SpotPrice != "" {
+		return errors.New("`autoscaling.warmPool` can't be enabled for a node pool of spot instances with `spotPrice`")
+	}
+	if c.AutoScalingGroup.MixedInstances.Enabled {
+		return errors.New("`autoscaling.warmPool` can't be enabled for a node pool with `autoScalingGroup.mixedInstances`")
+	}
+	if p.MaxPreparedCapacity != nil && *p.MaxPreparedCapacity > c.MaxCount() {
+		return fmt.Errorf("`autoscaling.warmPool.maxPreparedCapacity` %d must be equal to or less than the max size %d of the node pool", *p.MaxPreparedCapacity, c.MaxCount())
+	}
+	if p.MaxPreparedCapacity == nil && p.MinSize > c.MaxCount() {
+		return fmt.Errorf("`autoscaling.warmPool.minSize` %d must be equal to or less than the max size %d of the node pool", p.MinSize, c.MaxCount())
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestWarmPoolValidate(t *testing.T) {
+	capacity := func(c int) *int { return &c }
+
+	testCases := []struct {
+		warmPool WarmPool
+		isValid  bool
+	}{
+		// Valid, not configured
+		{
+			warmPool: WarmPool{},
+			isValid:  true,
+		},
+		// Valid, the defaults
+		{
+			warmPool: WarmPool{Enabled: true},
+			isValid:  true,
+		},
+		// Valid, hibernated
+		{
+			warmPool: WarmPool{Enabled: true, MinSize: 2, MaxPreparedCapacity: capacity(5), PoolState: "Hibernated"},
+			isValid:  true,
+		},
+		// Valid, the min size equal to the max prepared capacity
+		{
+			warmPool: WarmPool{Enabled: true, MinSize: 3, MaxPreparedCapacity: capacity(3), PoolState: "Running"},
+			isValid:  true,
+		},
+		// Invalid, settings without enabled
+		{
+			warmPool: WarmPool{PoolState: "Stopped"},
+			isValid:  false,
+		},
+		// Invalid, unknown pool state
+		{
+			warmPool: WarmPool{Enabled: true, PoolState: "stopped"},
+			isValid:  false,
+		},
+		// Invalid, negative min size
+		{
+			warmPool: WarmPool{Enabled: true, MinSize: -1},
+			isValid:  false,
+		},
+		// Invalid, negative max prepared capacity
+		{
+			warmPool: WarmPool{Enabled: true, MaxPreparedCapacity: capacity(-1)},
+			isValid:  false,
+		},
+		// Invalid, the min size greater than the max prepared capacity
+		{
+			warmPool: WarmPool{Enabled: true, MinSize: 4, MaxPreparedCapacity: capacity(3)},
+			isValid:  false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.warmPool.Validate()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.warmPool, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.warmPool)
+		}
+	}
+}
+
+func TestWorkerNodePoolValidateWarmPool(t *testing.T) {
+	capacity := func(c int) *int { return &c }
+	warmPool := func(p WarmPool, f func(*WorkerNodePool)) WorkerNodePool {
+		p.Enabled = true
+		pool := WorkerNodePool{
+			Autoscaling:      Autoscaling{WarmPool: p},
+			AutoScalingGroup: AutoScalingGroup{MaxSize: 5},
+		}
+		pool.Count = 1
+		f(&pool)
+		return pool
+	}
+
+	testCases := []struct {
+		pool    WorkerNodePool
+		isValid bool
+	}{
+		// Valid, not configured
+		{
+			pool:    WorkerNodePool{},
+			isValid: true,
+		},
+		// Valid, the max prepared capacity equal to the max size
+		{
+			pool:    warmPool(WarmPool{MaxPreparedCapacity: capacity(5)}, func(p *WorkerNodePool) {}),
+			isValid: true,
+		},
+		// Valid, the min size defaulting the max prepared capacity to the max size
+		{
+			pool:    warmPool(WarmPool{MinSize: 5}, func(p *WorkerNodePool) {}),
+			isValid: true,
+		},
+		// Invalid, the max prepared capacity greater than the max size
+		{
+			pool:    warmPool(WarmPool{MaxPreparedCapacity: capacity(6)}, func(p *WorkerNodePool) {}),
+			isValid: false,
+		},
+		// Invalid, the min size greater than the max size
+		{
+			pool:    warmPool(WarmPool{MinSize: 6}, func(p *WorkerNodePool) {}),
+			isValid: false,
+		},
+		// Invalid, spot instances
+		{
+			pool:    warmPool(WarmPool{}, func(p *WorkerNodePool) { p.SpotPrice = "0.05" }),
+			isValid: false,
+		},
+		// Invalid, mixed instances
+		{
+			pool:    warmPool(WarmPool{}, func(p *WorkerNodePool) { p.AutoScalingGroup.MixedInstances.Enabled = true }),
+			isValid: false,
+		},
+		// Invalid, spot fleet
+		{
+			pool:    warmPool(WarmPool{}, func(p *WorkerNodePool) { p.SpotFleet.TargetCapacity = 3 }),
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.pool.ValidateWarmPool()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.pool.Autoscaling.WarmPool, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.pool.Autoscaling.WarmPool)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/semver"
+)
+
+const (
+	NodePoolPlatformLinux   = "linux"
+	NodePoolPlatformWindows = "windows"
+
+	// WindowsRootVolumeDeviceName is the root device of Windows Server AMIs, to which `rootVolume` is mapped
+	WindowsRootVolumeDeviceName = "/dev/sda1"
+
+	// windowsTaintKey, windowsTaintValue and windowsTaintEffect make up the taint Windows nodes are registered with by default,
+	// so that pods built for Linux, which are the majority, are never scheduled onto them
+	windowsTaintKey    = "os"
+	windowsTaintValue  = NodePoolPlatformWindows
+	windowsTaintEffect = "NoSchedule"
+
+	// defaultFlannelVXLANPort is the UDP port of the flannel VXLAN backend used by the Linux kernel by default
+	defaultFlannelVXLANPort = 8472
+	// WindowsFlannelVXLANPort and WindowsFlannelVXLANVNI are the only VXLAN port and VNI flannel supports on Windows nodes,
+	// which all the nodes in the cluster use once any node pool runs Windows
+	WindowsFlannelVXLANPort = 4789
+	WindowsFlannelVXLANVNI  = 4096
+
+	defaultWindowsFlanneldDownloadURL   = "https://github.com/coreos/flannel/releases/download/v0.12.0/flanneld.exe"
+	defaultWindowsCNIPluginsDownloadURL = "https://github.com/containernetworking/plugins/releases/download/v0.8.6/cni-plugins-windows-amd64-v0.8.6.tgz"
+	defaultWindowsHNSModuleDownloadURL  = "https://raw.githubusercontent.com/microsoft/SDN/master/Kubernetes/windows/hns.psm1"
+)
+
+var defaultWindowsPauseImage = Image{Repo: "mcr.microsoft.com/k8s/core/pause", Tag: "1.2.0"}
+
+// Windows is the settings of node pools running Windows Server, specified by `platform: windows`
+type Windows struct {
+	// Taint registers the nodes with the `os=windows:NoSchedule` taint. Defaults to enabled
+	Taint WindowsTaint `yaml:"taint,omitempty"`
+	// PauseImage is the infra container image compatible with the Windows Server version of the AMI
+	PauseImage Image `yaml:"pauseImage,omitempty"`
+	// FlanneldDownloadURL is where flanneld.exe is downloaded from on the first boot of each node
+	FlanneldDownloadURL string `yaml:"flanneldDownloadUrl,omitempty"`
+	// CNIPluginsDownloadURL is where the tarball of the Windows CNI plugins including `flannel`, `win-overlay` and `host-local` is downloaded from
+	CNIPluginsDownloadURL string `yaml:"cniPluginsDownloadUrl,omitempty"`
+	// HNSModuleDownloadURL is where the PowerShell module managing the Host Networking Service networks used by flannel is downloaded from
+	HNSModuleDownloadURL string `yaml:"hnsModuleDownloadUrl,omitempty"`
+}
+
+type WindowsTaint struct {
+	Enabled *bool `yaml:"enabled,omitempty"`
+}
+
+func (w Windows) TaintEnabled() bool {
+	return w.Taint.Enabled == nil || *w.Taint.Enabled
+}
+
+func (w Windows) PauseImageRepoWithTag() string {
+	img := w.PauseImage
+	img.MergeIfEmpty(defaultWindowsPauseImage)
+	return img.RepoWithTag()
+}
+
+func (w Windows) FlanneldURL() string {
+	if w.FlanneldDownloadURL != "" {
+		return w.FlanneldDownloadURL
+	}
+	return defaultWindowsFlanneldDownloadURL
+}
+
+func (w Windows) CNIPluginsURL() string {
+	if w.CNIPluginsDownloadURL != "" {
+		return w.CNIPluginsDownloadURL
+	}
+	return defaultWindowsCNIPluginsDownloadURL
+}
+
+func (w Windows) HNSModuleURL() string {
+	if w.HNSModuleDownloadURL != "" {
+		return w.HNSModuleDownloadURL
+	}
+	return defaultWindowsHNSModuleDownloadURL
+}
+
+func (w Windows) taint() Taint {
+	return Taint{Key: windowsTaintKey, Value: windowsTaintValue, Effect: windowsTaintEffect}
+}
+
+// ApplyTo returns the node settings with the Windows taint added unless it is disabled
+func (w Windows) ApplyTo(s NodeSettings) NodeSettings {
+	if !w.TaintEnabled() {
+		return s
+	}
+	taint := w.taint()
+	taints := Taints{}
+	for _, t := range s.Taints {
+		if t != taint {
+			taints = append(taints, t)
+		}
+	}
+	s.Taints = append(taints, taint)
+	return s
+}
+
+// IsWindows returns true when the nodes in the pool run Windows Server instead of Linux
+func (c WorkerNodePool) IsWindows() bool {
+	return c.Platform == NodePoolPlatformWindows
+}
+
+// WindowsAMISSMParameter returns the SSM parameter AWS publishes the latest EKS-optimized Windows Server 2019 AMI for the Kubernetes version in.
+// The AMI ships Docker along with kubelet.exe and kube-proxy.exe of the Kubernetes version
+func WindowsAMISSMParameter(k8sVer string) (string, error) {
+	v, err := semver.NewVersion(k8sVer)
+	if err != nil {
+		return "", fmt.Errorf("invalid kubernetesVersion \"%s\": %v", k8sVer, err)
+	}
+	return fmt.Sprintf("/aws/service/ami-windows-latest/Windows_Server-2019-English-Core-EKS_Optimized-%d.%d/image_id", v.Major(), v.Minor()), nil
+}
+
+func (c WorkerNodePool) validatePlatform() error {
+	switch c.Platform {
+	case "", NodePoolPlatformLinux:
+	case NodePoolPlatformWindows:
+		if c.OS != "" {
+			return errors.New("`os` can't be specified with `platform: windows`, as it selects the Linux distribution of the nodes")
+		}
+	default:
+		return fmt.Errorf("invalid `platform` \"%s\": it must be either \"%s\" or \"%s\"", c.Platform, NodePoolPlatformLinux, NodePoolPlatformWindows)
+	}
+	if !c.IsWindows() && c.Windows != (Windows{}) {
+		return errors.New("`windows` can only be specified with `platform: windows`")
+	}
+	return nil
+}
+
+// ValidateWindows returns an error when the node pool relies on the settings which are only applied to Linux nodes,
+// or the cluster lacks what Windows nodes need to join it
+func (c WorkerNodePool) ValidateWindows(k8sVer string, controlPlaneK8sVer string, networking Networking, defaultInstanceType string) error {
+	for _, ver := range []string{k8sVer, controlPlaneK8sVer} {
+		if v, err := semver.NewVersion(ver); err == nil && v.LessThan(semver.MustParse("1.14.0")) {
+			return fmt.Errorf("`platform: windows` requires Kubernetes 1.14 or greater for both the node pool and the control plane, but kubernetesVersion was %s", ver)
+		}
+	}
+	// Neither calico nor the Amazon VPC CNI without the EKS control plane runs on Windows nodes
+	if networking.AmazonVPC.Enabled || networking.SelfHosting.Type != "flannel" {
+		return errors.New("`platform: windows` requires `kubernetes.networking.selfHosting.type` to be \"flannel\", which is the only network plugin kube-aws runs on Windows nodes")
+	}
+
+	instanceType := c.InstanceType
+	if instanceType == "" {
+		instanceType = defaultInstanceType
+	}
+	for _, t := range append([]string{instanceType}, c.AutoScalingGroup.MixedInstances.InstanceTypes...) {
+		if instanceTypeArchitecture(t) == "arm64" {
+			return fmt.Errorf("instance type \"%s\" can't be used with `platform: windows`, as Windows Server doesn't run on arm64 instances", t)
+		}
+	}
+
+	unsupported := map[string]bool{
+		"spotFleet":          c.SpotFleet.Enabled(),
+		"customFiles":        len(c.CustomFiles) > 0,
+		"customSystemdUnits": len(c.CustomSystemdUnits) > 0,
+		"volumeMounts":       len(c.VolumeMounts) > 0,
+		"raid0Mounts":        len(c.Raid0Mounts) > 0,
+		"gpu.nvidia.enabled": c.Gpu.Nvidia.Enabled,
+		"sandboxRuntimes":    len(c.SandboxRuntimes) > 0,
+		"containerRuntime":   c.ContainerRuntime != "",
+		"instanceScript":     c.InstanceScript != (InstanceScript{}),
+		// Nothing removes the bootstrap taint from Windows nodes
+		"bootstrapTaint.enabled": c.BootstrapTaint.Enabled,
+		// Windows nodes signal the auto scaling group by its fixed logical name, and nothing completes the lifecycle hook of the warm pool
+		"autoScalingGroup.subnetWeights": c.SubnetWeightsEnabled(),
+		"autoscaling.warmPool.enabled":   c.Autoscaling.WarmPool.Enabled,
+		// Windows nodes signal the success once kubelet becomes healthy, without waiting for the node to become Ready
+		"update.rollbackOnFailure": c.Update.RollbackOnFailure,
+	}
+	for _, k := range []string{"spotFleet", "customFiles", "customSystemdUnits", "volumeMounts", "raid0Mounts", "gpu.nvidia.enabled", "sandboxRuntimes", "containerRuntime", "instanceScript", "bootstrapTaint.enabled", "autoScalingGroup.subnetWeights", "autoscaling.warmPool.enabled", "update.rollbackOnFailure"} {
+		if unsupported[k] {
+			return fmt.Errorf("`%s` isn't supported with `platform: windows`, whose nodes are provisioned by PowerShell instead of cloud-config", k)
+		}
+	}
+	return nil
+}
+
+// WindowsNodePoolsEnabled returns true when any node pool runs Windows, for which the Linux-only daemonsets are excluded from Windows nodes
+// and flannel is configured to be compatible with Windows nodes
+func (c Cluster) WindowsNodePoolsEnabled() bool {
+	for _, np := range c.Worker.NodePools {
+		if np.IsWindows() {
+			return true
+		}
+	}
+	return false
+}
+
+// FlannelVXLANPort returns the UDP port flannel encapsulates the traffic among pods with.
+// The security groups keep allowing the default port along with the one for Windows, so that the nodes whose flannel pods are yet to be
+// rolled onto the port for Windows keep reaching each other
+func (c Cluster) FlannelVXLANPort() int {
+	if c.WindowsNodePoolsEnabled() {
+		return WindowsFlannelVXLANPort
+	}
+	return defaultFlannelVXLANPort
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestWindowsAMISSMParameter(t *testing.T) {
+	actual, err := WindowsAMISSMParameter("v1.16.2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "/aws/service/ami-windows-latest/Windows_Server-2019-English-Core-EKS_Optimized-1.16/image_id"
+	if actual != expected {
+		t.Errorf("expected %s but got %s", expected, actual)
+	}
+
+	if _, err := WindowsAMISSMParameter("latest"); err == nil {
+		t.Errorf("expected an error for the invalid kubernetes version but got none")
+	}
+}
+
+func TestWorkerNodePoolValidatePlatform(t *testing.T) {
+	disabled := false
+	testCases := []struct {
+		pool    WorkerNodePool
+		isValid bool
+	}{
+		// Valid, defaults to linux
+		{
+			pool:    WorkerNodePool{},
+			isValid: true,
+		},
+		// Valid, windows with the taint disabled
+		{
+			pool:    WorkerNodePool{Platform: NodePoolPlatformWindows, Windows: Windows{Taint: WindowsTaint{Enabled: &disabled}}},
+			isValid: true,
+		},
+		// Invalid, unknown platform
+		{
+			pool:    WorkerNodePool{Platform: "darwin"},
+			isValid: false,
+		},
+		// Invalid, a linux distribution for windows nodes
+		{
+			pool:    WorkerNodePool{Platform: NodePoolPlatformWindows, OS: NodePoolOSBottlerocket},
+			isValid: false,
+		},
+		// Invalid, windows settings for linux nodes
+		{
+			pool:    WorkerNodePool{Platform: NodePoolPlatformLinux, Windows: Windows{FlanneldDownloadURL: "https://example.com/flanneld.exe"}},
+			isValid: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.pool.validatePlatform()
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.pool, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.pool)
+		}
+	}
+}
+
+func TestWorkerNodePoolValidateWindows(t *testing.T) {
+	flannel := Networking{SelfHosting: SelfHosting{Type: "flannel"}}
+	testCases := []struct {
+		pool       WorkerNodePool
+		k8sVer     string
+		networking Networking
+		isValid    bool
+	}{
+		// Valid
+		{
+			pool:       WorkerNodePool{Platform: NodePoolPlatformWindows},
+			k8sVer:     "v1.16.2",
+			networking: flannel,
+			isValid:    true,
+		},
+		// Invalid, kubernetes version too old
+		{
+			pool:       WorkerNodePool{Platform: NodePoolPlatformWindows},
+			k8sVer:     "v1.13.5",
+			networking: flannel,
+			isValid:    false,
+		},
+		// Invalid, canal doesn't run on windows nodes
+		{
+			pool:       WorkerNodePool{Platform: NodePoolPlatformWindows},
+			k8sVer:     "v1.16.2",
+			networking: Networking{SelfHosting: SelfHosting{Type: "canal"}},
+			isValid:    false,
+		},
+		// Invalid, arm64 instances
+		{
+			pool:       WorkerNodePool{Platform: NodePoolPlatformWindows, InstanceType: "m6g.large"},
+			k8sVer:     "v1.16.2",
+			networking: flannel,
+			isValid:    false,
+		},
+		// Invalid, custom files written via cloud-config
+		{
+			pool:       WorkerNodePool{Platform: NodePoolPlatformWindows, CustomFiles: []CustomFile{{Path: "/etc/foo"}}},
+			k8sVer:     "v1.16.2",
+			networking: flannel,
+			isValid:    false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := testCase.pool.ValidateWindows(testCase.k8sVer, "v1.16.2", testCase.networking, "t3.medium")
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %+v to be valid but got an error: %v", i, testCase.pool, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %+v to be invalid but was not", i, testCase.pool)
+		}
+	}
+}
+
+func TestWindowsApplyTo(t *testing.T) {
+	taint := Taint{Key: "os", Value: "windows", Effect: "NoSchedule"}
+	s := Windows{}.ApplyTo(NodeSettings{Taints: Taints{{Key: "dedicated", Value: "ci", Effect: "NoExecute"}, taint}})
+	if len(s.Taints) != 2 || s.Taints[1] != taint {
+		t.Errorf("expected the windows taint to be added once but got %+v", s.Taints)
+	}
+
+	disabled := false
+	s = Windows{Taint: WindowsTaint{Enabled: &disabled}}.ApplyTo(NodeSettings{})
+	if len(s.Taints) != 0 {
+		t.Errorf("expected no taint but got %+v", s.Taints)
+	}
+}
+
+func TestWorkerNodePoolClusterAutoscalerNodeTemplateTagsForWindows(t *testing.T) {
+	tags := WorkerNodePool{Platform: NodePoolPlatformWindows}.ClusterAutoscalerNodeTemplateTags()
+	expected := map[string]string{
+		"k8s.io/cluster-autoscaler/node-template/label/kubernetes.io/os": "windows",
+		"k8s.io/cluster-autoscaler/node-template/taint/os":               "windows:NoSchedule",
+	}
+	if len(tags) != len(expected) {
+		t.Errorf("expected %v but got %v", expected, tags)
+	}
+	for k, v := range expected {
+		if tags[k] != v {
+			t.Errorf("expected tag %s to be %s but got %s", k, v, tags[k])
+		}
+	}
+}
+
+func TestClusterFlannelVXLANPort(t *testing.T) {
+	c := Cluster{}
+	if actual := c.FlannelVXLANPort(); actual != 8472 {
+		t.Errorf("expected 8472 but got %d", actual)
+	}
+
+	c.Worker.NodePools = []WorkerNodePool{{}, {Platform: NodePoolPlatformWindows}}
+	if actual := c.FlannelVXLANPort(); actual != 4789 {
+		t.Errorf("expected 4789 for windows node pools but got %d", actual)
+	}
+}
@@ -23,6 +23,8 @@ type WorkerNodePool struct {
 	Autoscaling               Autoscaling      `yaml:"autoscaling,omitempty"`
 	AutoScalingGroup          AutoScalingGroup `yaml:"autoScalingGroup,omitempty"`
 	SpotFleet                 SpotFleet        `yaml:"spotFleet,omitempty"`
+	Purpose                   NodePoolPurpose  `yaml:"purpose,omitempty"`
+	BootstrapTaint            BootstrapTaint   `yaml:"bootstrapTaint,omitempty"`
 	EC2Instance               `yaml:",inline"`
 	IAMConfig                 IAMConfig              `yaml:"iam,omitempty"`
 	SpotPrice                 string                 `yaml:"spotPrice,omitempty"`
@@ -35,8 +37,31 @@ type WorkerNodePool struct {
 	CustomFiles               []CustomFile        `yaml:"customFiles,omitempty"`
 	CustomSystemdUnits        []CustomSystemdUnit `yaml:"customSystemdUnits,omitempty"`
 	Gpu                       Gpu                 `yaml:"gpu"`
-	NodePoolRollingStrategy   string              `yaml:"nodePoolRollingStrategy,omitempty"`
-	UnknownKeys               `yaml:",inline"`
+	LambdaNodeDrainer         LambdaNodeDrainer   `yaml:"lambdaNodeDrainer,omitempty"`
+	// CustomResources are CloudFormation resources added to the node pool stack, keyed by their logical names.
+	// Each is either a JSON string or a YAML map
+	CustomResources         map[string]interface{} `yaml:"customResources,omitempty"`
+	NodePoolRollingStrategy string                 `yaml:"nodePoolRollingStrategy,omitempty"`
+	// DeploymentStrategy is how the nodes are replaced on updates, either `rollingUpdate` or `blueGreen`. Defaults to `rollingUpdate`
+	DeploymentStrategy string            `yaml:"deploymentStrategy,omitempty"`
+	BlueGreen          NodePoolBlueGreen `yaml:"blueGreen,omitempty"`
+	// Update configures rolling back updates rolling out nodes failing to become Ready
+	Update NodePoolUpdate `yaml:"update,omitempty"`
+	// RollingUpdate configures the update policy of the auto scaling groups replacing the nodes in place
+	RollingUpdate NodePoolRollingUpdate `yaml:"rollingUpdate,omitempty"`
+	// SandboxRuntimes are the sandboxed container runtimes like gVisor installed into containerd on the nodes
+	SandboxRuntimes SandboxRuntimes `yaml:"sandboxRuntimes,omitempty"`
+	// PodCIDRRange is the range within `podCIDR` from which each node of the pool is assigned its pod CIDR
+	PodCIDRRange string `yaml:"podCIDRRange,omitempty"`
+	// OS is the operating system of the nodes, either `flatcar` or `bottlerocket`. Defaults to `flatcar`
+	OS string `yaml:"os,omitempty"`
+	// Bottlerocket configures the host containers of the nodes when `os` is `bottlerocket`
+	Bottlerocket Bottlerocket `yaml:"bottlerocket,omitempty"`
+	// Platform is the platform of the nodes, either `linux` or `windows`. Defaults to `linux`
+	Platform string `yaml:"platform,omitempty"`
+	// Windows configures the nodes when `platform` is `windows`
+	Windows     Windows `yaml:"windows,omitempty"`
+	UnknownKeys `yaml:",inline"`
 }
 
 func (c *WorkerNodePool) UnmarshalYAML(unmarshal func(interface{}) error) error {
@@ -51,8 +76,11 @@ func (c *WorkerNodePool) UnmarshalYAML(unmarshal func(interface{}) error) error
 }
 
 type ClusterAutoscaler struct {
-	Enabled     bool `yaml:"enabled,omitempty"`
-	UnknownKeys `yaml:",inline"`
+	Enabled bool `yaml:"enabled,omitempty"`
+	// ScaleDownDisabled annotates nodes in the pool with `cluster-autoscaler.kubernetes.io/scale-down-disabled=true`
+	// so that cluster-autoscaler only scales the pool out
+	ScaleDownDisabled bool `yaml:"scaleDownDisabled,omitempty"`
+	UnknownKeys       `yaml:",inline"`
 }
 
 func (a ClusterAutoscaler) AutoDiscoveryTagKey() string {
@@ -73,6 +101,11 @@ func NewDefaultNodePoolConfig() WorkerNodePool {
 			},
 			Tenancy: "default",
 		},
+		Experimental: Experimental{
+			NodeDrainer: NodeDrainer{
+				DrainTimeout: 5,
+			},
+		},
 		NodeSettings:     newNodeSettings(),
 		SecurityGroupIds: []string{},
 		Gpu:              newDefaultGpu(),
@@ -126,6 +159,20 @@ func (c WorkerNodePool) validate(experimentalGpuSupportEnabled bool) error {
 		return fmt.Errorf("selected worker tenancy (%s) is incompatible with spot instances", c.Tenancy)
 	}
 
+	if c.Tenancy != "default" && c.AutoScalingGroup.MixedInstances.SpotInstancesEnabled() {
+		return fmt.Errorf("selected worker tenancy (%s) is incompatible with spot instances launched by `autoScalingGroup.mixedInstances`. Set `onDemandPercentageAboveBaseCapacity` to 100 to launch only on-demand instances", c.Tenancy)
+	}
+
+	// The mixed instances policy of an auto scaling group decides the purchase option of each instance by itself,
+	// hence it can be neither combined with a spot fleet nor with the spot price of the launch template
+	if c.AutoScalingGroup.MixedInstances.Enabled && c.SpotFleet.Enabled() {
+		return errors.New("`autoScalingGroup.mixedInstances` and `spotFleet` are mutually exclusive. Use `mixedInstances.onDemandPercentageAboveBaseCapacity` and `mixedInstances.spotAllocationStrategy` to launch spot instances instead")
+	}
+
+	if c.AutoScalingGroup.MixedInstances.Enabled && c.SpotPrice != "" {
+		return errors.New("`spotPrice` can not be specified with `autoScalingGroup.mixedInstances`. Use `mixedInstances.spotMaxPrice` instead")
+	}
+
 	if err := c.RootVolume.Validate(); err != nil {
 		return err
 	}
@@ -155,6 +202,14 @@ func (c WorkerNodePool) validate(experimentalGpuSupportEnabled bool) error {
 		return err
 	}
 
+	if err := c.Purpose.Validate(c.NodeSettings); err != nil {
+		return err
+	}
+
+	if err := c.BootstrapTaint.Validate(c.NodeSettings); err != nil {
+		return err
+	}
+
 	// By design, kube-aws doesn't allow customizing the following settings among node pools.
 	//
 	// Every node pool imports subnets from the main stack and therefore there's no need for setting:
@@ -177,18 +232,17 @@ func (c WorkerNodePool) validate(experimentalGpuSupportEnabled bool) error {
 	if c.InstanceCIDR != "" {
 		return fmt.Errorf("although you can't customize `instanceCIDR` per node pool but you did specify \"%s\" in your cluster.yaml", c.InstanceCIDR)
 	}
+	if c.Subnets.Auto() != nil {
+		return errors.New("`subnets` of a node pool doesn't support `auto`. Specify `auto` in the top-level `subnets` and refer to the carved subnets by their names")
+	}
 
 	// Believing it is impossible to mix different values, we also forbid customization of:
 	// * Region
-	// * ContainerRuntime
 	// * KMSKeyARN
 
 	if !c.Region.IsEmpty() {
 		return fmt.Errorf("although you can't customize `region` per node pool but you did specify \"%s\" in your cluster.yaml", c.Region)
 	}
-	if c.ContainerRuntime != "" {
-		return fmt.Errorf("although you can't customize `containerRuntime` per node pool but you did specify \"%s\" in your cluster.yaml", c.ContainerRuntime)
-	}
 	if c.KMSKeyARN != "" {
 		return fmt.Errorf("although you can't customize `kmsKeyArn` per node pool but you did specify \"%s\" in your cluster.yaml", c.KMSKeyARN)
 	}
@@ -197,9 +251,14 @@ func (c WorkerNodePool) validate(experimentalGpuSupportEnabled bool) error {
 		return err
 	}
 
-	if len(c.Subnets) > 1 && c.Autoscaling.ClusterAutoscaler.Enabled {
+	if err := c.Autoscaling.Validate(); err != nil {
+		return err
+	}
+
+	// With `autoScalingGroup.subnetWeights`, each auto scaling group spans a single subnet as cluster-autoscaler expects
+	if len(c.Subnets) > 1 && c.Autoscaling.ClusterAutoscaler.Enabled && !c.SubnetWeightsEnabled() {
 		return errors.New("cluster-autoscaler can't be enabled for a node pool with 2 or more subnets because allowing so" +
-			"results in unreliability while scaling nodes out. ")
+			"results in unreliability while scaling nodes out. Specify `autoScalingGroup.subnetWeights` to split the node pool into one auto scaling group per subnet")
 	}
 
 	return nil
@@ -220,19 +279,78 @@ func (c WorkerNodePool) MaxCount() int {
 }
 
 func (c WorkerNodePool) RollingUpdateMinInstancesInService() int {
-	if c.AutoScalingGroup.RollingUpdateMinInstancesInService == nil {
+	min := c.rollingUpdateMinInstancesInService()
+	if min == nil {
 		if c.MaxCount() > 0 {
 			return c.MaxCount() - 1
 		}
 		return 0
 	}
-	return *c.AutoScalingGroup.RollingUpdateMinInstancesInService
+	return *min
+}
+
+// RegisterWithTaints returns the taints passed to kubelet's `--register-with-taints`
+func (c WorkerNodePool) RegisterWithTaints() Taints {
+	return c.BootstrapTaint.RegisterWithTaints(c.Taints)
+}
+
+// ClusterAutoscalerNodeTemplateTags returns the ASG tags for cluster-autoscaler to know the labels and the taints of nodes the pool would launch
+func (c WorkerNodePool) ClusterAutoscalerNodeTemplateTags() map[string]string {
+	tags := c.Purpose.ClusterAutoscalerNodeTemplateTags()
+	if c.IsWindows() {
+		tags[clusterAutoscalerNodeTemplateLabelTagKeyPrefix+"kubernetes.io/os"] = NodePoolPlatformWindows
+		if c.Windows.TaintEnabled() {
+			taint := c.Windows.taint()
+			tags[clusterAutoscalerNodeTemplateTaintTagKeyPrefix+taint.Key] = fmt.Sprintf("%s:%s", taint.Value, taint.Effect)
+		}
+	}
+	return tags
 }
 
 func (c WorkerNodePool) Validate(experimental Experimental) error {
+	if err := c.validateNodeDrainer(); err != nil {
+		return err
+	}
+	if err := c.validateLambdaNodeDrainer(experimental); err != nil {
+		return err
+	}
+	if err := c.validateCustomResources(); err != nil {
+		return err
+	}
+	if err := validateAMISource(c.AmiId, c.AmiSsmParameter); err != nil {
+		return err
+	}
+	if err := c.validateOS(); err != nil {
+		return err
+	}
+	if err := c.validatePlatform(); err != nil {
+		return err
+	}
 	return c.validate(experimental.GpuSupport.Enabled)
 }
 
+// ValidateContainerRuntime returns an error when the container runtime, which is either customized for the node pool or inherited
+// from the main cluster, can't be used on the node pool
+func (c WorkerNodePool) ValidateContainerRuntime(runtime string, defaultInstanceType string) error {
+	instanceType := c.InstanceType
+	if instanceType == "" {
+		instanceType = defaultInstanceType
+	}
+	if err := ValidateContainerRuntime(runtime, instanceType); err != nil {
+		return err
+	}
+	for _, s := range c.SpotFleet.LaunchSpecifications {
+		if err := ValidateContainerRuntime(runtime, s.InstanceType); err != nil {
+			return err
+		}
+	}
+	// The nvidia driver installation relies on docker to expose GPUs to containers
+	if runtime == ContainerRuntimeContainerd && c.Gpu.Nvidia.Enabled {
+		return errors.New("`gpu.nvidia.enabled` isn't supported with the `containerd` container runtime")
+	}
+	return nil
+}
+
 func (c WorkerNodePool) WithDefaultsFrom(main DefaultWorkerSettings) WorkerNodePool {
 	if c.RootVolume.Type == "" {
 		c.RootVolume.Type = main.WorkerRootVolumeType
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/kubernetes-incubator/kube-aws/logger"
+)
+
+// ResolveAMIFromSSMParameter returns the AMI ID stored in the SSM parameter, which is either the ID itself like the `image_id` parameters
+// AWS publishes its optimized AMIs in, or a JSON object containing it as `image_id` like the `recommended` ones
+func (s *Context) ResolveAMIFromSSMParameter(name string) (string, error) {
+	if s.SSMParameterGetter == nil {
+		s.SSMParameterGetter = ssm.New(s.Session)
+	}
+	return resolveAMIFromSSMParameter(s.SSMParameterGetter, name)
+}
+
+func resolveAMIFromSSMParameter(svc SSMParameterGetter, name string) (string, error) {
+	logger.Debugf("Calling AWS SSM GetParameter for %s ->", name)
+	resp, err := svc.GetParameter(&ssm.GetParameterInput{Name: aws.String(name)})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == ssm.ErrCodeParameterNotFound {
+			return "", fmt.Errorf("the SSM parameter \"%s\" for `amiSsmParameter` does not exist", name)
+		}
+		return "", fmt.Errorf("failed to get the SSM parameter \"%s\" for `amiSsmParameter`: %v", name, err)
+	}
+	if resp.Parameter == nil || resp.Parameter.Value == nil {
+		return "", fmt.Errorf("the SSM parameter \"%s\" for `amiSsmParameter` has no value", name)
+	}
+
+	value := strings.TrimSpace(*resp.Parameter.Value)
+	if strings.HasPrefix(value, "{") {
+		var image struct {
+			ImageID string `json:"image_id"`
+		}
+		if err := json.Unmarshal([]byte(value), &image); err != nil {
+			return "", fmt.Errorf("the SSM parameter \"%s\" for `amiSsmParameter` is not a valid JSON object: %v", name, err)
+		}
+		value = image.ImageID
+	}
+	if !strings.HasPrefix(value, "ami-") {
+		return "", fmt.Errorf("the SSM parameter \"%s\" for `amiSsmParameter` doesn't contain an AMI ID: %s", name, value)
+	}
+	logger.Debugf("<- resolved the AMI %s", value)
+	return value, nil
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+type dummySSMParameterGetter struct {
+	parameters map[string]string
+	err        error
+}
+
+func (g dummySSMParameterGetter) GetParameter(input *ssm.GetParameterInput) (*ssm.GetParameterOutput, error) {
+	if g.err != nil {
+		return nil, g.err
+	}
+	value, ok := g.parameters[*input.Name]
+	if !ok {
+		return nil, awserr.New(ssm.ErrCodeParameterNotFound, "parameter not found", nil)
+	}
+	return &ssm.GetParameterOutput{Parameter: &ssm.Parameter{Name: input.Name, Value: aws.String(value)}}, nil
+}
+
+func TestResolveAMIFromSSMParameter(t *testing.T) {
+	svc := dummySSMParameterGetter{
+		parameters: map[string]string{
+			"/aws/service/ami/image_id":    "ami-0123456789abcdef0",
+			"/aws/service/ami/recommended": `{"image_id":"ami-0fedcba9876543210","image_name":"amazon-eks-node-1.14"}`,
+			"/aws/service/ami/not-an-ami":  "amazon-eks-node-1.14",
+			"/aws/service/ami/broken-json": `{"image_id":`,
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		svc      SSMParameterGetter
+		expected string
+		isValid  bool
+	}{
+		// Valid, AMI ID
+		{name: "/aws/service/ami/image_id", svc: svc, expected: "ami-0123456789abcdef0", isValid: true},
+		// Valid, JSON object containing the AMI ID
+		{name: "/aws/service/ami/recommended", svc: svc, expected: "ami-0fedcba9876543210", isValid: true},
+		// Invalid, missing parameter
+		{name: "/aws/service/ami/missing", svc: svc, isValid: false},
+		// Invalid, not an AMI ID
+		{name: "/aws/service/ami/not-an-ami", svc: svc, isValid: false},
+		// Invalid, malformed JSON
+		{name: "/aws/service/ami/broken-json", svc: svc, isValid: false},
+		// Invalid, API error
+		{name: "/aws/service/ami/image_id", svc: dummySSMParameterGetter{err: errors.New("access denied")}, isValid: false},
+	}
+
+	for i, testCase := range testCases {
+		actual, err := resolveAMIFromSSMParameter(testCase.svc, testCase.name)
+		if testCase.isValid && err != nil {
+			t.Errorf("case %d: expected %s to be resolved but got an error: %v", i, testCase.name, err)
+		}
+		if !testCase.isValid && err == nil {
+			t.Errorf("case %d: expected %s not to be resolved but was resolved to %s", i, testCase.name, actual)
+		}
+		if actual != testCase.expected {
+			t.Errorf("case %d: expected %s but was %s", i, testCase.expected, actual)
+		}
+	}
+}
@@ -28,6 +28,12 @@ func (b APIEndpointLB) RecordSetLogicalName() string {
 	return fmt.Sprintf("%sRecordSet", b.LogicalName())
 }
 
+// DNSNameOutputLogicalName returns the logical name of the control-plane stack output exposing the DNS name of this load balancer,
+// from which kube-aws creates the record set via `assumeRoles.route53` instead of CloudFormation
+func (b APIEndpointLB) DNSNameOutputLogicalName() string {
+	return fmt.Sprintf("%sDNSName", b.LogicalName())
+}
+
 // HostedZoneRef returns a CloudFormation ref for the hosted zone the record set for this load balancer is created in
 func (b APIEndpointLB) HostedZoneRef() string {
 	return b.HostedZone.Identifier.ID
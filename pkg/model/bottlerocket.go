@@ -0,0 +1,22 @@
+package model
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/kubernetes-incubator/kube-aws/gzipcompressor"
+)
+
+// BottlerocketClusterCertificate returns the base64-encoded CA certificate Bottlerocket nodes verify the apiserver with.
+// This function is used when rendering bottlerocket-worker
+func (c WorkerTmplCtx) BottlerocketClusterCertificate() (string, error) {
+	if c.AssetsConfig == nil || c.AssetsConfig.CACert == "" {
+		return "", errors.New("`os: bottlerocket` requires the CA certificate managed by kube-aws. Set `manageCertificates` to true")
+	}
+	caPEM, err := gzipcompressor.GzippedBase64StringToString(c.AssetsConfig.CACert)
+	if err != nil {
+		return "", fmt.Errorf("could not decompress the ca pem: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString([]byte(caPEM)), nil
+}
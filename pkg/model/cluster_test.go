@@ -18,6 +18,7 @@ region: cn-north-1
 s3URI: s3://mybucket/mydir
 availabilityZone: cn-north-1a
 clusterName: test-cluster-name
+amiId: ami-12345678
 `
 
 const minimalChinaConfigYaml = externalDNSNameConfig + chinaAPIEndpointMinimalConfigYaml
@@ -1144,6 +1145,20 @@ kubelet:
 		},
 		{
 			conf: `
+kubelet:
+  kubeReserved:
+    memory: 100Mi
+    cpu: 100m
+    ephemeral-storage: 1Gi
+  systemReserved:
+    cpu: 200m
+    memory: 200Mi
+`,
+			kubeReserved:   "cpu=100m,memory=100Mi,ephemeral-storage=1Gi",
+			systemReserved: "cpu=200m,memory=200Mi",
+		},
+		{
+			conf: `
 kubeReserved: "cpu=100m,memory=100Mi,ephemeral-storage=1Gi"
 systemReserved: "cpu=200m,memory=200Mi,ephemeral-storage=2Gi"
 `,
@@ -1159,7 +1174,7 @@ systemReserved: "cpu=200m,memory=200Mi,ephemeral-storage=2Gi"
 			t.Errorf("failed to parse config %s: %v", confBody, err)
 			continue
 		}
-		if !reflect.DeepEqual(c.Kubelet.KubeReservedResources, conf.kubeReserved) || !reflect.DeepEqual(c.Kubelet.SystemReservedResources, conf.systemReserved) {
+		if !reflect.DeepEqual(c.Kubelet.KubeReservedResources.String(), conf.kubeReserved) || !reflect.DeepEqual(c.Kubelet.SystemReservedResources.String(), conf.systemReserved) {
 			t.Errorf(
 				"parsed KubeReservedResources (%+v) and/or SystemReservedResources (%+v) settings does not match config: %s",
 				c.Kubelet.KubeReservedResources,
@@ -22,7 +22,8 @@ func Compile(cfgRef *api.Cluster, opts api.ClusterOptions) (*Config, error) {
 		ControllerFlags:  api.CommandLineFlags{},
 	}
 
-	if c.AmiId == "" {
+	// The AMI given by `amiSsmParameter` is resolved on render by Context.ResolveAMIFromSSMParameter, which requires AWS credentials
+	if c.AmiId == "" && c.AmiSsmParameter == "" {
 		var err error
 		if config.AMI, err = amiregistry.GetAMI(config.Region.String(), config.ReleaseChannel); err != nil {
 			return nil, errors.Wrapf(err, "failed getting AMI for config: %v", err)
@@ -78,7 +79,7 @@ func Compile(cfgRef *api.Cluster, opts api.ClusterOptions) (*Config, error) {
 		c.S3URI = strings.TrimSuffix(opts.S3URI, "/")
 	}
 
-	s3Folders := api.NewS3Folders(c.S3URI, c.ClusterName)
+	s3Folders := c.S3Folders()
 	//conf.S3URI = s3Folders.ClusterExportedStacks().URI()
 	c.KubeResourcesAutosave.S3Path = s3Folders.ClusterBackups().Path()
 
@@ -34,6 +34,9 @@ type Config struct {
 
 	KubernetesManifestFiles []*provisioner.RemoteFile
 	HelmReleaseFilesets     []api.HelmReleaseFileset
+
+	InjectedKubernetesObjects     api.InjectedKubernetesObjects
+	InjectedKubernetesObjectFiles []*provisioner.RemoteFile
 }
 
 func (c *Config) EtcdCluster() EtcdCluster {
@@ -114,7 +117,8 @@ func (c *Config) ManagedELBLogicalNames() []string {
 }
 
 type kubernetesManifestPlugin struct {
-	Manifests []*provisioner.RemoteFile
+	Manifests       []*provisioner.RemoteFile
+	InjectedObjects []*provisioner.RemoteFile
 }
 
 func (p kubernetesManifestPlugin) ManifestListFile() *provisioner.RemoteFile {
@@ -130,6 +134,16 @@ func (p kubernetesManifestPlugin) listFilePath() string {
 	return "/srv/kube-aws/plugins/kubernetes-manifests"
 }
 
+// InjectedObjectListFile lists the files describing the secrets and configmaps whose data is fetched from AWS
+func (p kubernetesManifestPlugin) InjectedObjectListFile() *provisioner.RemoteFile {
+	paths := []string{}
+	for _, o := range p.InjectedObjects {
+		paths = append(paths, o.Path)
+	}
+	bytes := []byte(strings.Join(paths, "\n"))
+	return provisioner.NewRemoteFileAtPath(filepath.Join(p.Directory(), "kubernetes-injected-objects"), bytes)
+}
+
 func (p kubernetesManifestPlugin) Directory() string {
 	return filepath.Dir(p.listFilePath())
 }
@@ -157,7 +171,8 @@ func (p helmReleasePlugin) Directory() string {
 
 func (c *Config) KubernetesManifestPlugin() kubernetesManifestPlugin {
 	p := kubernetesManifestPlugin{
-		Manifests: c.KubernetesManifestFiles,
+		Manifests:       c.KubernetesManifestFiles,
+		InjectedObjects: c.InjectedKubernetesObjectFiles,
 	}
 	return p
 }
@@ -23,7 +23,7 @@ func genAssets(t *testing.T) *credential.RawAssetsOnMemory {
 	}
 	cfg, err := Compile(c, api.ClusterOptions{})
 	r := NewCredentialGenerator(cfg)
-	assets, err := r.GenerateAssetsOnMemory(caKey, caCert, credential.GeneratorOptions{KIAM: true})
+	assets, err := r.GenerateAssetsOnMemory(caKey, caCert, credential.GeneratorOptions{KIAM: true, FrontProxy: true})
 	if err != nil {
 		t.Fatalf("failed generating assets: %v", err)
 	}
@@ -122,3 +122,31 @@ func TestTLSGeneration(t *testing.T) {
 		}
 	}
 }
+
+func TestFrontProxyTLSGeneration(t *testing.T) {
+	assets := genAssets(t)
+
+	if err := pki.VerifyClientCertificate(assets.FrontProxyCACert, assets.FrontProxyClientCert); err != nil {
+		t.Errorf("front-proxy client cert must be signed by the front-proxy ca: %v", err)
+	}
+
+	if err := pki.VerifyClientCertificate(assets.CACert, assets.FrontProxyClientCert); err == nil {
+		t.Errorf("front-proxy client cert must not be signed by the cluster ca")
+	}
+
+	if err := pki.VerifyClientCertificate(assets.FrontProxyCACert, assets.APIServerAggregatorCert); err == nil {
+		t.Errorf("client certs signed by the cluster ca must not be accepted by the front-proxy ca")
+	}
+
+	cert, err := pki.DecodeCertificatePEM(assets.FrontProxyClientCert)
+	if err != nil {
+		t.Fatalf("failed to parse front-proxy client cert: %v", err)
+	}
+	if cert.Subject.CommonName != api.FrontProxyClientCommonName {
+		t.Errorf("unexpected common name of the front-proxy client cert: expected %s, got %s", api.FrontProxyClientCommonName, cert.Subject.CommonName)
+	}
+
+	if _, err := pki.DecodePrivateKeyPEM(assets.FrontProxyClientKey); err != nil {
+		t.Errorf("failed to parse front-proxy client key: %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/kubernetes-incubator/kube-aws/logger"
+	"github.com/kubernetes-incubator/kube-aws/pkg/api"
+)
+
+type ssmGetParameterService interface {
+	GetParameter(*ssm.GetParameterInput) (*ssm.GetParameterOutput, error)
+}
+
+type secretsManagerDescribeSecretService interface {
+	DescribeSecret(*secretsmanager.DescribeSecretInput) (*secretsmanager.DescribeSecretOutput, error)
+}
+
+// ValidateInjectedKubernetesObjects ensures that the SSM parameters and Secrets Manager secrets referenced from
+// the secrets and configmaps injected by plugins exist
+func (s *Context) ValidateInjectedKubernetesObjects(c *Config) error {
+	if len(c.InjectedKubernetesObjects) == 0 {
+		return nil
+	}
+	return validateInjectedKubernetesObjects(c.InjectedKubernetesObjects, ssm.New(s.Session), secretsmanager.New(s.Session))
+}
+
+// validateInjectedKubernetesObjects fails only when a parameter or a secret is known to be missing.
+// Other errors like lacking permissions to read the parameter with the credentials in use result in warnings,
+// as controller nodes may still be able to read them
+func validateInjectedKubernetesObjects(objects api.InjectedKubernetesObjects, ssmSvc ssmGetParameterService, smSvc secretsManagerDescribeSecretService) error {
+	for _, o := range objects {
+		for _, d := range o.Data {
+			var ref string
+			var err error
+			if d.SSMParameter != "" {
+				ref = fmt.Sprintf("SSM parameter \"%s\"", d.SSMParameter)
+				_, err = ssmSvc.GetParameter(&ssm.GetParameterInput{Name: aws.String(d.SSMParameter)})
+			} else {
+				ref = fmt.Sprintf("Secrets Manager secret \"%s\"", d.SecretsManagerSecret)
+				_, err = smSvc.DescribeSecret(&secretsmanager.DescribeSecretInput{SecretId: aws.String(d.SecretsManagerSecret)})
+			}
+			if err == nil {
+				continue
+			}
+
+			if awsErr, ok := err.(awserr.Error); ok {
+				switch awsErr.Code() {
+				case ssm.ErrCodeParameterNotFound, secretsmanager.ErrCodeResourceNotFoundException:
+					return fmt.Errorf("%s for the key \"%s\" of %s %s/%s does not exist", ref, d.Key, o.Kind, o.Namespace, o.Name)
+				}
+			}
+			logger.Warnf("skipped verifying that %s for the key \"%s\" of %s %s/%s exists: %v", ref, d.Key, o.Kind, o.Namespace, o.Name, err)
+		}
+	}
+	return nil
+}
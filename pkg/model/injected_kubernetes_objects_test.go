@@ -0,0 +1,70 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/kubernetes-incubator/kube-aws/pkg/api"
+)
+
+type dummySSMGetParameterService struct {
+	Parameters map[string]bool
+	Err        error
+}
+
+func (svc dummySSMGetParameterService) GetParameter(input *ssm.GetParameterInput) (*ssm.GetParameterOutput, error) {
+	if svc.Err != nil {
+		return nil, svc.Err
+	}
+	if !svc.Parameters[*input.Name] {
+		return nil, awserr.New(ssm.ErrCodeParameterNotFound, "", errors.New(""))
+	}
+	return &ssm.GetParameterOutput{}, nil
+}
+
+type dummySecretsManagerDescribeSecretService struct {
+	Secrets map[string]bool
+}
+
+func (svc dummySecretsManagerDescribeSecretService) DescribeSecret(input *secretsmanager.DescribeSecretInput) (*secretsmanager.DescribeSecretOutput, error) {
+	if !svc.Secrets[*input.SecretId] {
+		return nil, awserr.New(secretsmanager.ErrCodeResourceNotFoundException, "", errors.New(""))
+	}
+	return &secretsmanager.DescribeSecretOutput{}, nil
+}
+
+func TestValidateInjectedKubernetesObjects(t *testing.T) {
+	objects := api.InjectedKubernetesObjects{
+		{
+			Kind:      "secret",
+			Namespace: "kube-system",
+			Name:      "datadog",
+			Data: api.KubernetesDataSources{
+				{Key: "api-key", SSMParameter: "/datadog/api-key"},
+				{Key: "app-key", SecretsManagerSecret: "datadog", JSONKey: "appKey"},
+			},
+		},
+	}
+	ssmSvc := dummySSMGetParameterService{Parameters: map[string]bool{"/datadog/api-key": true}}
+	smSvc := dummySecretsManagerDescribeSecretService{Secrets: map[string]bool{"datadog": true}}
+
+	if err := validateInjectedKubernetesObjects(objects, ssmSvc, smSvc); err != nil {
+		t.Errorf("returned an error for existing parameters and secrets: %v", err)
+	}
+
+	if err := validateInjectedKubernetesObjects(objects, dummySSMGetParameterService{}, smSvc); err == nil {
+		t.Errorf("failed to catch the missing parameter \"/datadog/api-key\"")
+	}
+
+	if err := validateInjectedKubernetesObjects(objects, ssmSvc, dummySecretsManagerDescribeSecretService{}); err == nil {
+		t.Errorf("failed to catch the missing secret \"datadog\"")
+	}
+
+	denied := dummySSMGetParameterService{Err: awserr.New("AccessDeniedException", "", errors.New(""))}
+	if err := validateInjectedKubernetesObjects(objects, denied, smSvc); err != nil {
+		t.Errorf("expected a parameter which could not be read to be skipped but got an error: %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"github.com/kubernetes-incubator/kube-aws/pkg/api"
+)
+
+// KubeletConfigFileContent returns the KubeletConfiguration for kubelets on controller nodes, which replaces the flags of the same settings
+func (c ControllerTmplCtx) KubeletConfigFileContent() (string, error) {
+	d := api.KubeletConfigDefaults{
+		StaticPodPath: "/etc/kubernetes/manifests",
+		ClusterDomain: "cluster.local",
+		FeatureGates:  c.ControllerFeatureGates(),
+	}
+	if !c.KubeDns.NodeLocalResolver {
+		d.ClusterDNS = c.DNSServiceIP
+	}
+	if !c.KubeDns.DNSConfig.IsEmpty() {
+		d.ResolvConf = "/etc/kubernetes/resolv.conf"
+	}
+	return c.Config.Kubelet.RenderConfigFile(d)
+}
+
+// KubeletConfigFileContent returns the KubeletConfiguration for kubelets on worker nodes, which replaces the flags of the same settings
+func (c WorkerTmplCtx) KubeletConfigFileContent() (string, error) {
+	d := api.KubeletConfigDefaults{
+		StaticPodPath:             "/etc/kubernetes/manifests",
+		ClusterDomain:             "cluster.local",
+		FeatureGates:              c.NodePoolConfig.FeatureGates(),
+		NodeStatusUpdateFrequency: c.NodeStatusUpdateFrequency,
+	}
+	if !c.KubeDns.NodeLocalResolver {
+		d.ClusterDNS = c.DNSServiceIP
+	}
+	if !c.KubeDns.DNSConfig.IsEmpty() {
+		d.ResolvConf = "/etc/kubernetes/resolv.conf"
+	}
+	if c.Experimental.TLSBootstrap.Enabled && c.AssetsConfig.HasTLSBootstrapToken() {
+		d.RotateCertificates = c.NodePoolConfig.Kubelet.RotateCerts.Enabled
+	} else {
+		d.TLSCertFile = "/etc/kubernetes/ssl/worker.pem"
+		d.TLSPrivateKeyFile = "/etc/kubernetes/ssl/worker-key.pem"
+	}
+	return c.NodePoolConfig.Kubelet.RenderConfigFile(d)
+}
@@ -15,17 +15,57 @@ func nodePoolPreprocess(c api.WorkerNodePool, main *Config) (*api.WorkerNodePool
 
 	c = c.WithDefaultsFrom(main.DefaultWorkerSettings)
 
+	// Bottlerocket and Windows pools never inherit the Flatcar AMI of the main cluster
+	if (c.IsBottlerocket() || c.IsWindows()) && c.AmiId == "" && c.AmiSsmParameter == "" {
+		k8sVer := c.K8sVer
+		if k8sVer == "" {
+			k8sVer = main.K8sVer
+		}
+		var param string
+		var err error
+		if c.IsWindows() {
+			param, err = api.WindowsAMISSMParameter(k8sVer)
+		} else {
+			param, err = api.BottlerocketAMISSMParameter(k8sVer, c.InstanceType)
+		}
+		if err != nil {
+			return nil, err
+		}
+		c.AmiSsmParameter = param
+	}
+
 	c.DeploymentSettings = c.DeploymentSettings.WithDefaultsFrom(main.DeploymentSettings)
 
 	// Inherit parameters from the control plane stack
 	c.KubeClusterSettings = main.KubeClusterSettings
 	c.HostOS = main.HostOS
 	c.Experimental.TLSBootstrap = main.DeploymentSettings.Experimental.TLSBootstrap
-	c.Experimental.NodeDrainer = main.DeploymentSettings.Experimental.NodeDrainer
+	// A node pool is drained either when the node drainer is enabled cluster-wide or only for the node pool, in which case
+	// the drain timeout of the node pool is used. How nodes are drained is shared among all the nodes
+	if c.Experimental.NodeDrainer.Enabled {
+		c.Experimental.NodeDrainer.Drain = main.DeploymentSettings.Experimental.NodeDrainer.Drain
+		c.Experimental.NodeDrainer.IAMRole = main.DeploymentSettings.Experimental.NodeDrainer.IAMRole
+	} else {
+		c.Experimental.NodeDrainer = main.DeploymentSettings.Experimental.NodeDrainer
+	}
 	c.Experimental.GpuSupport = main.DeploymentSettings.Experimental.GpuSupport
 	c.Kubelet.RotateCerts = main.DeploymentSettings.Kubelet.RotateCerts
-	c.Kubelet.SystemReservedResources = main.DeploymentSettings.Kubelet.SystemReservedResources
-	c.Kubelet.KubeReservedResources = main.DeploymentSettings.Kubelet.KubeReservedResources
+	// Compute resource reservations can be customized per node pool under `kubelet`. Otherwise the main ones are inherited
+	c.Kubelet.MergeResourceReservationsIfEmpty(c.DeploymentSettings.Kubelet)
+	c.Kubelet.MergeResourceReservationsIfEmpty(main.DeploymentSettings.Kubelet)
+	c.Kubelet.MergeConfigFileIfEmpty(c.DeploymentSettings.Kubelet)
+	c.Kubelet.MergeConfigFileIfEmpty(main.DeploymentSettings.Kubelet)
+	c.Kubelet.MergeImagePullSettingsIfEmpty(c.DeploymentSettings.Kubelet)
+	c.Kubelet.MergeImagePullSettingsIfEmpty(main.DeploymentSettings.Kubelet)
+	c.Kubelet.MergeEvictionSettingsIfEmpty(c.DeploymentSettings.Kubelet)
+	c.Kubelet.MergeEvictionSettingsIfEmpty(main.DeploymentSettings.Kubelet)
+
+	// Add the conventional label and taint for the node pool dedicated to the purpose
+	c.NodeSettings = c.Purpose.ApplyTo(c.NodeSettings)
+	// Keep pods built for Linux off Windows nodes unless they tolerate the taint
+	if c.IsWindows() {
+		c.NodeSettings = c.Windows.ApplyTo(c.NodeSettings)
+	}
 
 	if c.Experimental.ClusterAutoscalerSupport.Enabled {
 		if !main.Addons.ClusterAutoscaler.Enabled {
@@ -57,7 +97,12 @@ define one or more public subnets in cluster.yaml or explicitly reference privat
 
 	// Import all the managed subnets from the network stack i.e. don't create subnets inside the node pool cfn stack
 	var err error
-	c.Subnets, err = c.Subnets.ImportFromNetworkStack()
+	if c.SubnetWeightsEnabled() {
+		// Retain the names referenced from `autoScalingGroup.subnetWeights`
+		c.Subnets, err = c.Subnets.ImportFromNetworkStackRetainingNames()
+	} else {
+		c.Subnets, err = c.Subnets.ImportFromNetworkStack()
+	}
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to import subnets from network stack: %v", err)
 	}
@@ -85,18 +130,27 @@ func NodePoolCompile(spec api.WorkerNodePool, main *Config) (*NodePoolConfig, er
 	}
 
 	var ami string
-	if spec.AmiId == "" {
+	amiID := spec.AmiId
+	// Node pools in the aws partition run the latest AMI of the release channel unless they specify their own `amiId`.
+	// Outside it, where the AMI registry has none, they inherit `amiId` of the main cluster unless they have their own release channel
+	if main.Region.Partition() != "aws" {
+		amiID = cfg.AmiId
+	}
+	if amiID == "" && cfg.AmiSsmParameter == "" {
 		var err error
 		if ami, err = amiregistry.GetAMI(main.Region.String(), cfg.ReleaseChannel); err != nil {
 			return nil, errors.Wrapf(err, "unable to fetch AMI for worker node pool \"%s\"", spec.NodePoolName)
 		}
 	} else {
-		ami = spec.AmiId
+		ami = amiID
 	}
 	c.AMI = ami
 
 	c.EtcdNodes = main.EtcdNodes
 	c.KubeResourcesAutosave = main.KubeResourcesAutosave
+	c.IAM = main.IAM
+	c.CloudFormation = main.CloudFormation
+	c.InterPoolCommunication = main.Worker.InterPoolCommunication
 
 	var apiEndpoint APIEndpoint
 	if c.APIEndpointName != "" {
@@ -120,11 +174,6 @@ kube-aws can't save users from mistakes like that
 	}
 	c.APIEndpoint = apiEndpoint
 
-	if spec.Autoscaling.ClusterAutoscaler.Enabled && !main.Addons.ClusterAutoscaler.Enabled {
-		return nil, errors.New("Autoscaling with cluster-autoscaler can't be enabled for node pools because " +
-			"you didn't enabled the cluster-autoscaler addon. Enable it by turning on `addons.clusterAutoscaler.enabled`")
-	}
-
 	if err := c.Validate(); err != nil {
 		return nil, errors.Wrapf(err, "invalid node pool spec")
 	}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/kubernetes-incubator/kube-aws/builtin"
 	"github.com/kubernetes-incubator/kube-aws/cfnresource"
 	"github.com/kubernetes-incubator/kube-aws/logger"
 	"github.com/kubernetes-incubator/kube-aws/naming"
@@ -28,6 +29,10 @@ type NodePoolConfig struct {
 type MainClusterSettings struct {
 	EtcdNodes             []EtcdNode
 	KubeResourcesAutosave api.KubeResourcesAutosave
+	IAM                   api.ClusterIAM
+	CloudFormation        api.CloudFormation
+	// InterPoolCommunication is referenced to attach the security group dedicated to this node pool, if any
+	InterPoolCommunication api.InterPoolCommunications
 }
 
 // NestedStackName returns a sanitized name of this node pool which is usable as a valid cloudformation nested stack name
@@ -67,6 +72,12 @@ func (c NodePoolConfig) NodeLabels() api.NodeLabels {
 	if c.ClusterAutoscalerSupport.Enabled {
 		labels["kube-aws.coreos.com/cluster-autoscaler-supported"] = "true"
 	}
+	if c.Gpu.Nvidia.DevicePluginEnabledOn(c.InstanceType) {
+		if labels == nil {
+			labels = api.NodeLabels{}
+		}
+		labels[api.NvidiaDevicePluginNodeLabel] = "true"
+	}
 	return labels
 }
 
@@ -75,7 +86,8 @@ func (c NodePoolConfig) FeatureGates() api.FeatureGates {
 	if gates == nil {
 		gates = api.FeatureGates{}
 	}
-	if c.Gpu.Nvidia.IsEnabledOn(c.InstanceType) {
+	// The device plugin replaces the alpha accelerators support of kubelet
+	if c.Gpu.Nvidia.IsEnabledOn(c.InstanceType) && !c.Gpu.Nvidia.DevicePlugin.Enabled {
 		gates["Accelerators"] = "true"
 	}
 	if c.Experimental.GpuSupport.Enabled {
@@ -117,14 +129,58 @@ func (c NodePoolConfig) Validate() error {
 		return err
 	}
 
+	if numSGs := len(c.WorkerDeploymentSettings().WorkerSecurityGroupRefs()); numSGs > 3 && c.InterPoolCommunication.References(c.NodePoolName) {
+		return fmt.Errorf("number of user provided security groups must be less than or equal to 3 but was %d for the node pool \"%s\" referenced from `worker.interPoolCommunication`, which is given one more security group by kube-aws", numSGs, c.NodePoolName)
+	}
+
 	if err := c.Experimental.Validate(c.NodePoolName); err != nil {
 		return err
 	}
 
+	if err := c.WorkerNodePool.ValidateSubnetWeights(); err != nil {
+		return err
+	}
+
+	if err := c.WorkerNodePool.ValidateDeploymentStrategy(); err != nil {
+		return err
+	}
+
+	if err := c.WorkerNodePool.ValidateUpdate(); err != nil {
+		return err
+	}
+
+	if err := c.WorkerNodePool.ValidateRollingUpdate(); err != nil {
+		return err
+	}
+
+	if err := c.WorkerNodePool.ValidateWarmPool(); err != nil {
+		return err
+	}
+
 	if err := c.NodeSettings.Validate(); err != nil {
 		return err
 	}
 
+	if err := c.Kubelet.ValidateResourceReservations(c.InstanceType, c.RootVolume.Size); err != nil {
+		return err
+	}
+
+	if err := c.Kubelet.ConfigFile.Validate(c.K8sVer); err != nil {
+		return err
+	}
+
+	if err := c.Kubelet.ValidateImagePulls(c.K8sVer); err != nil {
+		return err
+	}
+
+	if err := c.Kubelet.ValidateEviction(); err != nil {
+		return err
+	}
+
+	if err := c.Gpu.Nvidia.ValidateKubernetesVersion(c.K8sVer); err != nil {
+		return err
+	}
+
 	clusterNamePlaceholder := "<my-cluster-name>"
 	nestedStackNamePlaceHolder := "<my-nested-stack-name>"
 	replacer := strings.NewReplacer(clusterNamePlaceholder, "", nestedStackNamePlaceHolder, "")
@@ -185,5 +241,28 @@ func (c NodePoolConfig) SecurityGroupRefs() []string {
 		`{"Fn::ImportValue" : {"Fn::Sub" : "${NetworkStackName}-WorkerSecurityGroup"}}`,
 	)
 
+	if c.InterPoolCommunication.References(c.NodePoolName) {
+		// The security group dedicated to this node pool, between which and the ones of the other node pools
+		// `worker.interPoolCommunication` allows the traffic
+		refs = append(refs, fmt.Sprintf(`{"Fn::ImportValue" : {"Fn::Sub" : "${NetworkStackName}-%s"}}`, api.InterPoolSecurityGroupLogicalName(c.NodePoolName)))
+	}
+
 	return refs
 }
+
+// LambdaNodeDrainerCode returns the source code of the Lambda function draining nodes of this node pool
+func (c NodePoolConfig) LambdaNodeDrainerCode() string {
+	return builtin.String("lambda-node-drainer/index.py")
+}
+
+// LambdaNodeDrainerSubnets returns the subnets the Lambda function draining nodes is placed in. The function runs in the subnets
+// of the node pool only when they're all private, so that it can reach a private API endpoint inside the VPC and AWS APIs via NAT gateways.
+// Otherwise it runs outside of the VPC, as a function in public subnets can't reach AWS APIs
+func (c NodePoolConfig) LambdaNodeDrainerSubnets() api.Subnets {
+	for _, s := range c.Subnets {
+		if !s.Private {
+			return nil
+		}
+	}
+	return c.Subnets
+}
@@ -101,5 +101,5 @@ func (c *NodePoolStackRef) Info() (*NodePoolStackInfo, error) {
 }
 
 func (c *NodePoolStackRef) Destroy() error {
-	return cfnstack.NewDestroyer(c.StackName(), c.session, c.CloudFormation.RoleARN).Destroy()
+	return cfnstack.NewDestroyer(c.StackName(), c.session, c.CloudFormation.RoleARN, false).Destroy()
 }
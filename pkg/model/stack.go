@@ -1,8 +1,10 @@
 package model
 
 import (
+	"encoding/json"
 	"fmt"
 	"net"
+	"sort"
 	"strings"
 
 	"errors"
@@ -25,9 +27,31 @@ var VERSION = "UNKNOWN"
 
 const STACK_TEMPLATE_FILENAME = "stack.json"
 
+// OIDC_CA_CERT_FILENAME is the name of the asset containing the gzipped, and encrypted when possible, CA bundle of the OIDC provider.
+// Unlike userdata parts, the name isn't fingerprinted so that controller nodes can fetch the latest bundle without being replaced
+const OIDC_CA_CERT_FILENAME = "oidc-ca.pem"
+
+// The names of the assets containing the serving certificate, the key and the client CAs of the apiserver, which controller nodes
+// periodically fetch when `controller.apiServer.certificateReload` is enabled. The certificate and the key are gzipped, and the key is encrypted when possible,
+// as they are in the userdata
+const (
+	APISERVER_CERT_FILENAME       = "apiserver.pem"
+	APISERVER_KEY_FILENAME        = "apiserver-key.pem"
+	APISERVER_CLIENT_CAS_FILENAME = "apiserver-client-cas.pem"
+)
+
+// LiveAssetFilenames are the names of the assets controller nodes periodically fetch from the same S3 keys,
+// which must not be uploaded until the cluster is updated
+var LiveAssetFilenames = []string{
+	OIDC_CA_CERT_FILENAME,
+	APISERVER_CERT_FILENAME,
+	APISERVER_KEY_FILENAME,
+	APISERVER_CLIENT_CAS_FILENAME,
+}
+
 // RenderAndAddUserData adds a userdata with the id that is loaded from the file located at `userdataTmplPath`.
 // When the id is "Controller", the loaded useradata can be referenced by `Userdata.Controller` in templates.
-func (s *Stack) RenderAndAddUserData(id, userdataTmplPath string) error {
+func (s *Stack) RenderAndAddUserData(id, userdataTmplPath string, opts ...api.UserDataOption) error {
 	var err error
 
 	id = strings.Title(id)
@@ -36,7 +60,7 @@ func (s *Stack) RenderAndAddUserData(id, userdataTmplPath string) error {
 		s.UserData = map[string]api.UserData{}
 	}
 
-	s.UserData[id], err = api.NewUserDataFromTemplateFile(userdataTmplPath, s.tmplCtx)
+	s.UserData[id], err = api.NewUserDataFromTemplateFile(userdataTmplPath, s.tmplCtx, opts...)
 
 	if err != nil {
 		return fmt.Errorf("failed to render userdata: %v", err)
@@ -60,6 +84,21 @@ func (p *Stack) RenderAddEtcdUserdata(opts api.StackTemplateOptions) error {
 }
 
 func (p *Stack) RenderAddWorkerUserdata(opts api.StackTemplateOptions) error {
+	// Bottlerocket reads its settings from the EC2 user-data as is, hence there's nothing to be stored in S3
+	if p.NodePoolConfig != nil && p.NodePoolConfig.IsBottlerocket() {
+		return p.RenderAndAddUserData(
+			"Worker",
+			p.BottlerocketWorkerTmplFile,
+			api.InstancePartOnlyOpt(),
+		)
+	}
+	if p.NodePoolConfig != nil && p.NodePoolConfig.IsWindows() {
+		return p.RenderAndAddUserData(
+			"Worker",
+			p.WindowsWorkerTmplFile,
+			api.PowerShellPartsOpt(),
+		)
+	}
 	return p.RenderAndAddUserData(
 		"Worker",
 		p.WorkerTmplFile,
@@ -88,12 +127,41 @@ func (c *Stack) buildAssets() (cfnstack.Assets, error) {
 		return nil, fmt.Errorf("failed to create node provisioner: %v", err)
 	}
 
+	if c.OidcCACertEnabled() {
+		if _, err := assetsBuilder.Add(OIDC_CA_CERT_FILENAME, c.AssetsConfig.OidcCACert); err != nil {
+			return nil, fmt.Errorf("failed to add %s: %v", OIDC_CA_CERT_FILENAME, err)
+		}
+	}
+
+	if c.APIServerCertificateReloadEnabled() {
+		certs := map[string]string{
+			APISERVER_CERT_FILENAME: c.AssetsConfig.APIServerCert,
+			APISERVER_KEY_FILENAME:  c.AssetsConfig.APIServerKey,
+		}
+		if clientCAs := c.Config.Controller.APIServer.ClientCAs; len(clientCAs) > 0 {
+			certs[APISERVER_CLIENT_CAS_FILENAME] = clientCAs.Bundle()
+		}
+		for name, content := range certs {
+			if _, err := assetsBuilder.Add(name, content); err != nil {
+				return nil, fmt.Errorf("failed to add %s: %v", name, err)
+			}
+		}
+	}
+
 	for id, _ := range c.UserData {
 		userdataS3PartAssetName := "userdata-" + strings.ToLower(id)
 
 		if err = assetsBuilder.AddUserDataPart(c.UserData[id], api.USERDATA_S3, userdataS3PartAssetName); err != nil {
 			return nil, fmt.Errorf("failed to addd %s: %v", userdataS3PartAssetName, err)
 		}
+
+		// Added after the s3 part, whose S3 URL the instance script refers to
+		if c.instanceScriptStoredInS3(id) {
+			instanceScriptAssetName := userdataS3PartAssetName + "-instance-script"
+			if err = assetsBuilder.AddUserDataPart(c.UserData[id], api.USERDATA_INSTANCE_SCRIPT, instanceScriptAssetName); err != nil {
+				return nil, fmt.Errorf("failed to add %s: %v", instanceScriptAssetName, err)
+			}
+		}
 	}
 
 	logger.Debugf("Buildings assets before templating %s stack template...", c.StackName)
@@ -111,6 +179,48 @@ func (c *Stack) buildAssets() (cfnstack.Assets, error) {
 	return assetsBuilder.Build(), nil
 }
 
+// instanceScriptStoredInS3 returns true when the instance script of the userdata is uploaded to S3 rather than embedded into the EC2 user-data.
+// The instance script of etcd nodes is rendered per etcd node and therefore always embedded
+func (c *Stack) instanceScriptStoredInS3(id string) bool {
+	switch {
+	case id == "Etcd":
+		return false
+	case c.NodePoolConfig != nil:
+		return c.NodePoolConfig.InstanceScript.StoredInS3()
+	case c.Config != nil:
+		return c.Config.InstanceScript.StoredInS3()
+	}
+	return false
+}
+
+// OidcCACertEnabled returns true when the apiserver verifies the OIDC provider against the CA bundle in `credentials/oidc-ca.pem`,
+// which is uploaded along with the control-plane stack
+func (c *Stack) OidcCACertEnabled() bool {
+	if c.Config == nil || c.NodePoolConfig != nil || c.StackName != c.Config.ControlPlaneStackName() {
+		return false
+	}
+	return c.Config.Experimental.Oidc.Enabled && c.AssetsConfig != nil && c.AssetsConfig.HasOidcCACert()
+}
+
+// OidcCACertS3URI returns the S3 URI of the OIDC CA bundle, which is periodically fetched by controller nodes
+func (c *Stack) OidcCACertS3URI() string {
+	return fmt.Sprintf("%s/%s/%s", c.ClusterExportedStacksS3URI(), c.StackName, OIDC_CA_CERT_FILENAME)
+}
+
+// APIServerCertificateReloadEnabled returns true when the serving certificate and the client CAs of the apiserver are uploaded along with
+// the control-plane stack rather than embedded into the controller userdata
+func (c *Stack) APIServerCertificateReloadEnabled() bool {
+	if c.Config == nil || c.NodePoolConfig != nil || c.StackName != c.Config.ControlPlaneStackName() {
+		return false
+	}
+	return c.Config.Controller.APIServer.CertificateReload.Enabled && c.Config.ManageCertificates && c.AssetsConfig != nil
+}
+
+// APIServerCertificatesS3URI returns the S3 URI of the directory containing the serving certificate and the client CAs of the apiserver
+func (c *Stack) APIServerCertificatesS3URI() string {
+	return fmt.Sprintf("%s/%s", c.ClusterExportedStacksS3URI(), c.StackName)
+}
+
 func (s *Stack) addTarballedAssets(assetsBuilder *cfnstack.AssetsBuilderImpl) error {
 	if len(s.archivedFiles) == 0 {
 		return nil
@@ -197,11 +307,33 @@ func (c *Stack) validateCertsAgainstSettings() error {
 	if !kubeAPIServerCert.ContainsIPAddress(kubernetesServiceIPAddr) {
 		return fmt.Errorf("the api server cert does not contain the kubernetes service ip address %v, please regenerate or resolve", kubernetesServiceIPAddr)
 	}
+
+	if c.Config.Controller.Aggregation.Enabled {
+		if err := c.validateFrontProxyCerts(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateFrontProxyCerts checks that the front-proxy client cert is signed by the front-proxy CA the apiserver trusts for the request header authentication
+func (c *Stack) validateFrontProxyCerts() error {
+	caPEM, err := gzipcompressor.GzippedBase64StringToString(c.AssetsConfig.FrontProxyCACert)
+	if err != nil {
+		return fmt.Errorf("could not decompress the front-proxy ca pem: %v", err)
+	}
+	clientPEM, err := gzipcompressor.GzippedBase64StringToString(c.AssetsConfig.FrontProxyClientCert)
+	if err != nil {
+		return fmt.Errorf("could not decompress the front-proxy client pem: %v", err)
+	}
+	if err := pki.VerifyClientCertificate([]byte(caPEM), []byte(clientPEM)); err != nil {
+		return fmt.Errorf("the front-proxy client cert is not valid against the front-proxy ca, please regenerate or resolve: %v", err)
+	}
 	return nil
 }
 
 func (c *Stack) s3Folders() api.S3Folders {
-	return api.NewS3Folders(c.S3URI, c.ClusterName)
+	return api.NewS3Folders(c.S3URI, c.ClusterName, c.AssetPrefix)
 }
 
 func (c *Stack) ClusterS3URI() string {
@@ -229,6 +361,15 @@ func (c Stack) EtcdSnapshotsS3Bucket() (string, error) {
 	return s3uri.Host, nil
 }
 
+// ClusterS3Prefix is the prefix of the keys of all the S3 objects of the cluster, which scopes the IAM policies listing the bucket shared among clusters
+func (c Stack) ClusterS3Prefix() (string, error) {
+	s3uri, err := url.Parse(c.ClusterS3URI())
+	if err != nil {
+		return "", fmt.Errorf("Error in ClusterS3Prefix : %v", err)
+	}
+	return strings.TrimLeft(s3uri.Path, "/"), nil
+}
+
 func (c Stack) EtcdSnapshotsS3PrefixRef() (string, error) {
 	s3uri, err := url.Parse(c.ClusterS3URI())
 	if err != nil {
@@ -249,6 +390,42 @@ func (c *Stack) RenderStackTemplateAsString() (string, error) {
 	return string(bytes), err
 }
 
+// validateCustomResourceNames returns an error when a custom resource of the node pool has the same name as a resource the stack template defines,
+// which would otherwise silently replace the latter in the rendered template
+func (c *Stack) validateCustomResourceNames() error {
+	customResources, err := c.NodePoolConfig.CustomCfnResources()
+	if err != nil || len(customResources) == 0 {
+		return err
+	}
+
+	extras := c.ExtraCfnResources
+	c.ExtraCfnResources = nil
+	defer func() { c.ExtraCfnResources = extras }()
+
+	bytes, err := c.RenderStackTemplateAsBytes()
+	if err != nil {
+		return fmt.Errorf("failed to render \"%s\" stack template: %v", c.StackName, err)
+	}
+	var template struct {
+		Resources map[string]interface{}
+	}
+	if err := json.Unmarshal(bytes, &template); err != nil {
+		return fmt.Errorf("failed to parse \"%s\" stack template: %v", c.StackName, err)
+	}
+
+	names := []string{}
+	for n := range customResources {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		if _, ok := template.Resources[n]; ok {
+			return fmt.Errorf("custom resource \"%s\" conflicts with the one of the same name defined by the node pool stack template", n)
+		}
+	}
+	return nil
+}
+
 func (c *Stack) GetUserData(id string) *api.UserData {
 	id = strings.Title(id)
 	if userdata, ok := c.UserData[id]; ok {
@@ -17,6 +17,7 @@ func newStack(stackName string, conf *Config, opts api.StackTemplateOptions, ass
 		StackTemplateOptions: opts,
 		ClusterName:          conf.ClusterName,
 		S3URI:                conf.S3URI,
+		AssetPrefix:          conf.AssetPrefix,
 		Region:               conf.Region,
 		AssetsConfig:         assetsConfig,
 		Config:               conf,
@@ -92,6 +93,9 @@ func NewControlPlaneStack(conf *Config, opts api.StackTemplateOptions, extras cl
 			}
 			conf.HelmReleaseFilesets = extraController.HelmReleaseFilesets
 			conf.KubernetesManifestFiles = extraController.KubernetesManifestFiles
+			conf.InjectedKubernetesObjects = extraController.InjectedKubernetesObjects
+			conf.InjectedKubernetesObjectFiles = extraController.InjectedKubernetesObjectFiles
+			conf.Controller.IAMConfig.Policy.Statements = append(conf.Controller.IAMConfig.Policy.Statements, extraController.InjectedKubernetesObjects.IAMPolicyStatements(conf.Region)...)
 
 			if len(conf.StackTags) == 0 {
 				conf.StackTags = make(map[string]string, 1)
@@ -192,7 +196,7 @@ func NewEtcdStack(conf *Config, opts api.StackTemplateOptions, extras clusterext
 }
 
 func NewWorkerStack(conf *Config, npconf *NodePoolConfig, opts api.StackTemplateOptions, extras clusterextension.ClusterExtension, assetsConfig *credential.CompactAssets) (*Stack, error) {
-	return newStack(
+	stack, err := newStack(
 		npconf.StackName(),
 		conf,
 		opts,
@@ -212,6 +216,24 @@ func NewWorkerStack(conf *Config, npconf *NodePoolConfig, opts api.StackTemplate
 			}
 			stack.ExtraCfnResources = extraStack.Resources
 
+			customResources, err := npconf.CustomCfnResources()
+			if err != nil {
+				return err
+			}
+			if len(customResources) > 0 {
+				resources := map[string]interface{}{}
+				for n, r := range stack.ExtraCfnResources {
+					resources[n] = r
+				}
+				for n, r := range customResources {
+					if _, ok := resources[n]; ok {
+						return fmt.Errorf("custom resource \"%s\" conflicts with the one of the same name added by plugins", n)
+					}
+					resources[n] = r
+				}
+				stack.ExtraCfnResources = resources
+			}
+
 			extraWorker, err := extras.Worker(conf)
 			if err != nil {
 				return fmt.Errorf("failed to load worker node extras from plugins: %v", err)
@@ -242,4 +264,12 @@ func NewWorkerStack(conf *Config, npconf *NodePoolConfig, opts api.StackTemplate
 			return stack.RenderAddWorkerUserdata(opts)
 		},
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := stack.validateCustomResourceNames(); err != nil {
+		return nil, err
+	}
+	return stack, nil
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/kubernetes-incubator/kube-aws/awsconn"
 	"github.com/kubernetes-incubator/kube-aws/logger"
 	"github.com/kubernetes-incubator/kube-aws/pkg/api"
 	"strings"
@@ -40,7 +41,7 @@ func (c *StackRef) Validate() error {
 		return err
 	}
 
-	if err := c.validateDNSConfig(route53.New(c.session)); err != nil {
+	if err := c.validateDNSConfig(route53.New(awsconn.WithAssumedRole(c.session, c.AssumeRoles.Route53))); err != nil {
 		return err
 	}
 
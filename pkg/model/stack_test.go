@@ -12,6 +12,7 @@ import (
 
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"strings"
 	"testing"
 
@@ -796,3 +797,210 @@ func TestRenderStackTemplate(t *testing.T) {
 		}
 	})
 }
+
+func TestOidcCACert(t *testing.T) {
+	pwd, err := os.Getwd()
+	if err != nil {
+		t.Errorf("%v", err)
+		t.FailNow()
+	}
+
+	oidcConfigYaml := singleAzConfigYaml + `
+experimental:
+  oidc:
+    enabled: true
+    issuerUrl: "https://accounts.example.com"
+    clientId: "kubernetes"
+`
+
+	for _, testCase := range []struct {
+		context    string
+		configYaml string
+		withCACert bool
+		enabled    bool
+	}{
+		{
+			context:    "WithOidcCACert",
+			configYaml: oidcConfigYaml,
+			withCACert: true,
+			enabled:    true,
+		},
+		{
+			context:    "WithoutOidcCACert",
+			configYaml: oidcConfigYaml,
+			withCACert: false,
+			enabled:    false,
+		},
+		{
+			context:    "WithOidcCACertButOidcDisabled",
+			configYaml: singleAzConfigYaml,
+			withCACert: true,
+			enabled:    false,
+		},
+	} {
+		t.Run(testCase.context, func(t *testing.T) {
+			helper.WithDummyCredentials(func(dir string) {
+				if testCase.withCACert {
+					ca, err := ioutil.ReadFile(filepath.Join(dir, "ca.pem"))
+					if err != nil {
+						t.Fatalf("failed to read ca.pem: %v", err)
+					}
+					if err := ioutil.WriteFile(filepath.Join(dir, "oidc-ca.pem"), ca, 0600); err != nil {
+						t.Fatalf("failed to write oidc-ca.pem: %v", err)
+					}
+				}
+
+				stack, err := yamlToStackForTesting(testCase.configYaml, api.StackTemplateOptions{
+					AssetsDir:             dir,
+					ControllerTmplFile:    filepath.Join(pwd, "../../builtin/files/userdata/cloud-config-controller"),
+					StackTemplateTmplFile: filepath.Join(pwd, "../../builtin/files/stack-templates/control-plane.json.tmpl"),
+				})
+				if err != nil {
+					t.Fatalf("failed to initialize the stack: %v", err)
+				}
+
+				if stack.OidcCACertEnabled() != testCase.enabled {
+					t.Errorf("expected OidcCACertEnabled to be %v but was %v", testCase.enabled, stack.OidcCACertEnabled())
+				}
+
+				asset, assetErr := stack.Assets().FindAssetByStackAndFileName(stack.StackName, OIDC_CA_CERT_FILENAME)
+				userdata, err := stack.UserData["Controller"].Parts[api.USERDATA_S3].Template()
+				if err != nil {
+					t.Fatalf("failed to render the controller userdata: %v", err)
+				}
+				flagRendered := strings.Contains(userdata, "--oidc-ca-file=/etc/kubernetes/ssl/oidc-ca.pem")
+
+				if !testCase.enabled {
+					if assetErr == nil {
+						t.Errorf("expected no OIDC CA bundle to be uploaded but it was")
+					}
+					if flagRendered {
+						t.Errorf("expected no --oidc-ca-file flag to be rendered but it was")
+					}
+					return
+				}
+
+				if assetErr != nil {
+					t.Fatalf("expected the OIDC CA bundle to be uploaded but it wasn't: %v", assetErr)
+				}
+				s3URL, err := asset.S3URL()
+				if err != nil {
+					t.Fatalf("%v", err)
+				}
+				if s3URL != stack.OidcCACertS3URI() {
+					t.Errorf("expected the OIDC CA bundle to be uploaded to %s but was %s", stack.OidcCACertS3URI(), s3URL)
+				}
+				if asset.Content != stack.AssetsConfig.OidcCACert {
+					t.Errorf("expected the encrypted OIDC CA bundle to be uploaded but it wasn't: %s", asset.Content)
+				}
+				if !flagRendered {
+					t.Errorf("expected --oidc-ca-file flag to be rendered but it wasn't")
+				}
+				if !strings.Contains(userdata, s3URL) {
+					t.Errorf("expected controllers to fetch the OIDC CA bundle from %s but they don't", s3URL)
+				}
+				// The bundle must not be embedded into the userdata, which would replace controllers on every change
+				if strings.Contains(userdata, stack.AssetsConfig.OidcCACert) {
+					t.Errorf("expected the OIDC CA bundle not to be embedded into the controller userdata but it was")
+				}
+			})
+		})
+	}
+}
+
+func TestAPIServerCertificateReload(t *testing.T) {
+	pwd, err := os.Getwd()
+	if err != nil {
+		t.Errorf("%v", err)
+		t.FailNow()
+	}
+
+	reloadConfigYaml := singleAzConfigYaml + `
+kubernetesVersion: v1.20.2
+controller:
+  apiServer:
+    certificateReload:
+      enabled: true
+      interval: 10m
+`
+
+	for _, testCase := range []struct {
+		context    string
+		configYaml string
+		enabled    bool
+	}{
+		{
+			context:    "WithCertificateReload",
+			configYaml: reloadConfigYaml,
+			enabled:    true,
+		},
+		{
+			context:    "WithoutCertificateReload",
+			configYaml: singleAzConfigYaml,
+			enabled:    false,
+		},
+	} {
+		t.Run(testCase.context, func(t *testing.T) {
+			helper.WithDummyCredentials(func(dir string) {
+				stack, err := yamlToStackForTesting(testCase.configYaml, api.StackTemplateOptions{
+					AssetsDir:             dir,
+					ControllerTmplFile:    filepath.Join(pwd, "../../builtin/files/userdata/cloud-config-controller"),
+					StackTemplateTmplFile: filepath.Join(pwd, "../../builtin/files/stack-templates/control-plane.json.tmpl"),
+				})
+				if err != nil {
+					t.Fatalf("failed to initialize the stack: %v", err)
+				}
+
+				if stack.APIServerCertificateReloadEnabled() != testCase.enabled {
+					t.Errorf("expected APIServerCertificateReloadEnabled to be %v but was %v", testCase.enabled, stack.APIServerCertificateReloadEnabled())
+				}
+
+				userdata, err := stack.UserData["Controller"].Parts[api.USERDATA_S3].Template()
+				if err != nil {
+					t.Fatalf("failed to render the controller userdata: %v", err)
+				}
+				embedded := strings.Contains(userdata, "- path: /etc/kubernetes/ssl/apiserver.pem")
+
+				for _, name := range []string{APISERVER_CERT_FILENAME, APISERVER_KEY_FILENAME} {
+					asset, assetErr := stack.Assets().FindAssetByStackAndFileName(stack.StackName, name)
+					if !testCase.enabled {
+						if assetErr == nil {
+							t.Errorf("expected no %s to be uploaded but it was", name)
+						}
+						continue
+					}
+					if assetErr != nil {
+						t.Fatalf("expected %s to be uploaded but it wasn't: %v", name, assetErr)
+					}
+					s3URL, err := asset.S3URL()
+					if err != nil {
+						t.Fatalf("%v", err)
+					}
+					if s3URL != stack.APIServerCertificatesS3URI()+"/"+name {
+						t.Errorf("expected %s to be uploaded to %s/%s but was %s", name, stack.APIServerCertificatesS3URI(), name, s3URL)
+					}
+					if !strings.Contains(userdata, s3URL) {
+						t.Errorf("expected controllers to fetch %s from %s but they don't", name, s3URL)
+					}
+				}
+				if _, err := stack.Assets().FindAssetByStackAndFileName(stack.StackName, APISERVER_CLIENT_CAS_FILENAME); err == nil {
+					t.Errorf("expected no client CAs to be uploaded without `controller.apiServer.clientCAs` but they were")
+				}
+
+				if !testCase.enabled {
+					if !embedded {
+						t.Errorf("expected the apiserver certificate to be embedded into the controller userdata but it wasn't")
+					}
+					return
+				}
+				// The certificate and the key must not be embedded into the userdata, which would replace controllers on every rotation
+				if embedded || strings.Contains(userdata, "- path: /etc/kubernetes/ssl/apiserver-key.pem") {
+					t.Errorf("expected the apiserver certificate and key not to be embedded into the controller userdata but they were")
+				}
+				if !strings.Contains(userdata, "OnUnitActiveSec=600") {
+					t.Errorf("expected controllers to fetch the apiserver certificate every 10 minutes but they don't")
+				}
+			})
+		})
+	}
+}
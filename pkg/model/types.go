@@ -4,6 +4,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/kubernetes-incubator/kube-aws/cfnstack"
 	"github.com/kubernetes-incubator/kube-aws/credential"
 	"github.com/kubernetes-incubator/kube-aws/pkg/api"
@@ -18,6 +19,7 @@ type Stack struct {
 
 	StackName   string
 	S3URI       string
+	AssetPrefix string
 	ClusterName string
 	Region      api.Region
 
@@ -45,6 +47,10 @@ type StackTemplateGetter interface {
 	GetTemplate(input *cloudformation.GetTemplateInput) (*cloudformation.GetTemplateOutput, error)
 }
 
+type SSMParameterGetter interface {
+	GetParameter(input *ssm.GetParameterInput) (*ssm.GetParameterOutput, error)
+}
+
 type Context struct {
 	Session *session.Session
 
@@ -52,6 +58,7 @@ type Context struct {
 	ProvidedCFInterrogator  cfnstack.CFInterrogator
 	ProvidedEC2Interrogator cfnstack.EC2Interrogator
 	StackTemplateGetter     StackTemplateGetter
+	SSMParameterGetter      SSMParameterGetter
 }
 
 // An EtcdTmplCtx contains configuration settings/options mixed with existing state in a way that can be
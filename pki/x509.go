@@ -6,6 +6,7 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"errors"
+	"fmt"
 	"math"
 	"math/big"
 	"net"
@@ -166,3 +167,27 @@ func NewSignedKIAMCertificate(cfg ClientCertConfig, key *rsa.PrivateKey, caCert
 	}
 	return x509.ParseCertificate(certDERBytes)
 }
+
+// VerifyClientCertificate verifies that the PEM encoded client certificate is signed by one of the PEM encoded CA certificates
+// and is usable for client authentication, so that e.g. the apiserver's proxy client certificate is accepted by aggregated apiservers
+func VerifyClientCertificate(caPEM []byte, certPEM []byte) error {
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caPEM) {
+		return errors.New("failed to parse CA certificates")
+	}
+	if !IsCertificatePEM(certPEM) {
+		return errors.New("client certificate must be PEM encoded")
+	}
+	cert, err := DecodeCertificatePEM(certPEM)
+	if err != nil {
+		return fmt.Errorf("failed to parse client certificate: %v", err)
+	}
+	opts := x509.VerifyOptions{
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if _, err := cert.Verify(opts); err != nil {
+		return fmt.Errorf("client certificate with the common name \"%s\" is not signed by the CA for client authentication: %v", cert.Subject.CommonName, err)
+	}
+	return nil
+}
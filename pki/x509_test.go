@@ -0,0 +1,34 @@
+package pki
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyClientCertificate(t *testing.T) {
+
+	caKey, caCert, err := NewCA(1, "front-proxy-ca")
+	require.NoError(t, err)
+	otherCAKey, otherCACert, err := NewCA(1, "kube-ca")
+	require.NoError(t, err)
+	key, err := NewPrivateKey()
+	require.NoError(t, err)
+
+	clientCert, err := NewSignedClientCertificate(ClientCertConfig{CommonName: "front-proxy-client", Duration: time.Hour}, key, caCert, caKey)
+	require.NoError(t, err)
+	serverCert, err := NewSignedServerCertificate(ServerCertConfig{CommonName: "front-proxy-client", Duration: time.Hour}, key, caCert, caKey)
+	require.NoError(t, err)
+	otherClientCert, err := NewSignedClientCertificate(ClientCertConfig{CommonName: "front-proxy-client", Duration: time.Hour}, key, otherCACert, otherCAKey)
+	require.NoError(t, err)
+
+	caPEM := EncodeCertificatePEM(caCert)
+
+	assert.NoError(t, VerifyClientCertificate(caPEM, EncodeCertificatePEM(clientCert)))
+	assert.Error(t, VerifyClientCertificate(caPEM, EncodeCertificatePEM(serverCert)), "a cert without the client auth usage must be rejected")
+	assert.Error(t, VerifyClientCertificate(caPEM, EncodeCertificatePEM(otherClientCert)), "a cert signed by another CA must be rejected")
+	assert.Error(t, VerifyClientCertificate(caPEM, EncodePrivateKeyPEM(key)), "a non-certificate PEM must be rejected")
+	assert.Error(t, VerifyClientCertificate([]byte("garbage"), EncodeCertificatePEM(clientCert)), "an invalid CA must be rejected")
+}
@@ -93,6 +93,9 @@ type controller struct {
 
 	KubernetesManifestFiles []*provisioner.RemoteFile
 	HelmReleaseFilesets     []api.HelmReleaseFileset
+
+	InjectedKubernetesObjects     api.InjectedKubernetesObjects
+	InjectedKubernetesObjectFiles []*provisioner.RemoteFile
 }
 
 type etcd struct {
@@ -291,6 +294,8 @@ func (e ClusterExtension) Controller(clusterConfig interface{}) (*controller, er
 	kubeletMounts := []api.ContainerVolumeMount{}
 	manifests := []*provisioner.RemoteFile{}
 	releaseFilesets := []api.HelmReleaseFileset{}
+	injectedObjects := api.InjectedKubernetesObjects{}
+	injectedObjectFiles := []*provisioner.RemoteFile{}
 
 	for _, p := range e.plugins {
 		//fmt.Fprintf(os.Stderr, "plugin=%+v configs=%+v", p, e.configs)
@@ -424,6 +429,22 @@ func (e ClusterExtension) Controller(clusterConfig interface{}) (*controller, er
 				}
 			}
 
+			// Secrets and configmaps are written without their values, which are fetched from AWS on controller nodes
+			for _, o := range p.Spec.Cluster.Kubernetes.InjectedObjects() {
+				content, err := json.Marshal(o)
+				if err != nil {
+					return nil, fmt.Errorf("failed to marshal %s %s/%s: %v", o.Kind, o.Namespace, o.Name, err)
+				}
+				f := api.CustomFile{
+					Path:        filepath.Join("/srv/kube-aws/plugins", p.Metadata.Name, "injected-objects", o.FileName()),
+					Permissions: 0644,
+					Content:     string(content),
+				}
+				files = append(files, f)
+				injectedObjects = append(injectedObjects, o)
+				injectedObjectFiles = append(injectedObjectFiles, provisioner.NewRemoteFileAtPath(f.Path, content))
+			}
+
 			// Merge all the configset files produced from `files` and `manifessts`
 			configsets[p.Name] = map[string]map[string]interface{}{
 				"files": configsetFiles,
@@ -478,6 +499,9 @@ func (e ClusterExtension) Controller(clusterConfig interface{}) (*controller, er
 		CfnInitConfigSets:       configsets,
 		KubernetesManifestFiles: manifests,
 		HelmReleaseFilesets:     releaseFilesets,
+
+		InjectedKubernetesObjects:     injectedObjects,
+		InjectedKubernetesObjectFiles: injectedObjectFiles,
 	}, nil
 }
 
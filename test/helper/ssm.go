@@ -0,0 +1,22 @@
+package helper
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// DummySSMParameterGetter is used to prevent calls to AWS - returns the value of the parameter from Parameters when it exists
+type DummySSMParameterGetter struct {
+	Parameters map[string]string
+}
+
+func (g DummySSMParameterGetter) GetParameter(input *ssm.GetParameterInput) (*ssm.GetParameterOutput, error) {
+	value, ok := g.Parameters[*input.Name]
+	if !ok {
+		return nil, awserr.New(ssm.ErrCodeParameterNotFound, "parameter not found", nil)
+	}
+	return &ssm.GetParameterOutput{
+		Parameter: &ssm.Parameter{Name: input.Name, Value: aws.String(value)},
+	}, nil
+}
@@ -1,6 +1,7 @@
 package integration
 
 import (
+	"bytes"
 	"fmt"
 	"log"
 	"os"
@@ -8,14 +9,17 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"text/template"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/kubernetes-incubator/kube-aws/builtin"
 	"github.com/kubernetes-incubator/kube-aws/cfnstack"
 	"github.com/kubernetes-incubator/kube-aws/core/root"
 	"github.com/kubernetes-incubator/kube-aws/core/root/config"
+	"github.com/kubernetes-incubator/kube-aws/gzipcompressor"
 	"github.com/kubernetes-incubator/kube-aws/pkg/api"
 	"github.com/kubernetes-incubator/kube-aws/pkg/model"
+	"github.com/kubernetes-incubator/kube-aws/pki"
 	"github.com/kubernetes-incubator/kube-aws/test/helper"
 )
 
@@ -449,9 +453,41 @@ func TestMainClusterConfig(t *testing.T) {
 availabilityZone: us-west-1c
 `
 
+	_, trustedCACert, err := pki.NewCA(365, "private-ca")
+	if err != nil {
+		t.Fatalf("failed to generate a CA certificate: %v", err)
+	}
+	trustedCAPEM := strings.TrimSpace(string(pki.EncodeCertificatePEM(trustedCACert)))
+	indentedTrustedCAPEM := func(indent string) string {
+		return indent + strings.Replace(trustedCAPEM, "\n", "\n"+indent, -1)
+	}
+
+	// defaultClusterYaml is the cluster.yaml rendered by `kube-aws init`
+	var defaultClusterYaml bytes.Buffer
+	initialConfig := config.InitialConfig{
+		AmiId:            "ami-12345678",
+		AvailabilityZone: kubeAwsSettings.region + "c",
+		ClusterName:      kubeAwsSettings.clusterName,
+		ExternalDNSName:  kubeAwsSettings.externalDNSName,
+		HostedZoneID:     "hostedzone-xxxx",
+		KMSKeyARN:        kubeAwsSettings.kmsKeyArn,
+		KeyName:          kubeAwsSettings.keyName,
+		Region:           api.RegionForName(kubeAwsSettings.region),
+		S3URI:            kubeAwsSettings.s3URI,
+	}
+	if err := template.Must(template.New("cluster.yaml").Parse(string(builtin.Bytes("cluster.yaml.tmpl")))).Execute(&defaultClusterYaml, initialConfig); err != nil {
+		t.Fatalf("failed to render the default cluster.yaml: %v", err)
+	}
+	autoscaledNodePool := "      name: nodepool1\n      autoscaling:\n        clusterAutoscaler:\n          enabled: true\n"
+	defaultClusterYamlWithAutoscaledNodePool := strings.Replace(defaultClusterYaml.String(), "      name: nodepool1\n", autoscaledNodePool, 1)
+	if !strings.Contains(defaultClusterYamlWithAutoscaledNodePool, autoscaledNodePool) {
+		t.Fatalf("[bug] failed to find the node pool in the default cluster.yaml: %s", defaultClusterYaml.String())
+	}
+
 	validCases := []struct {
 		context       string
 		configYaml    string
+		plugins       []*api.Plugin
 		assertConfig  []ConfigTester
 		assertCluster []ClusterTester
 	}{
@@ -533,6 +569,67 @@ worker:
 				hasDefaultCluster,
 			},
 		},
+		{
+			context: "WithClusterAutoscalerScaleDownProtection",
+			configYaml: minimalValidConfigYaml + `
+addons:
+  clusterAutoscaler:
+    enabled: true
+    safeToEvict:
+      kube-dns: false
+      metrics-server: true
+  metricsServer:
+    enabled: true
+worker:
+  nodePools:
+  - name: pool1
+    autoscaling:
+      clusterAutoscaler:
+        enabled: true
+        scaleDownDisabled: true
+  - name: pool2
+    autoscaling:
+      clusterAutoscaler:
+        enabled: true
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, expected := range []string{
+						`                app: cluster-autoscaler
+              annotations:
+                scheduler.alpha.kubernetes.io/critical-pod: ''
+                cluster-autoscaler.kubernetes.io/safe-to-evict: "false"
+`,
+						`                k8s-app: kube-dns
+              annotations:
+                scheduler.alpha.kubernetes.io/critical-pod: ''
+                cluster-autoscaler.kubernetes.io/safe-to-evict: "false"
+`,
+						`                k8s-app: metrics-server
+              annotations:
+                cluster-autoscaler.kubernetes.io/safe-to-evict: "true"
+`,
+					} {
+						if !strings.Contains(controllerUserdataS3Part, expected) {
+							t.Errorf("missing the safe-to-evict annotation in controller userdata: expected to contain:\n%s", expected)
+						}
+					}
+					if n := strings.Count(controllerUserdataS3Part, "cluster-autoscaler.kubernetes.io/safe-to-evict"); n != 3 {
+						t.Errorf("expected 3 add-ons to be annotated with safe-to-evict but was %d", n)
+					}
+
+					pool1Userdata := c.NodePools()[0].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					if !strings.Contains(pool1Userdata, "annotate --overwrite nodes/$(hostname) cluster-autoscaler.kubernetes.io/scale-down-disabled=true") {
+						t.Error("missing the scale-down-disabled annotation in pool1 userdata")
+					}
+					pool2Userdata := c.NodePools()[1].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					if strings.Contains(pool2Userdata, "scale-down-disabled") {
+						t.Error("pool2 userdata shouldn't contain the scale-down-disabled annotation")
+					}
+				},
+			},
+		},
 		{
 			context: "WithAPIEndpointLBAPIAccessAllowedSourceCIDRsSpecified",
 			configYaml: configYamlWithoutExernalDNSName + `
@@ -645,3956 +742,10299 @@ kubeProxy:
 			},
 		},
 		{
-			// See https://github.com/kubernetes-incubator/kube-aws/issues/365
-			context:    "WithClusterNameContainsHyphens",
-			configYaml: kubeAwsSettings.withClusterName("my-cluster").minimumValidClusterYaml(),
-		},
-		{
-			context: "WithCustomSettings",
+			context: "WithKubeDnsDNSConfig",
 			configYaml: minimalValidConfigYaml + `
-customSettings:
-  stack-type: control-plane
+kubeDns:
+  dnsConfig:
+    searches:
+    - legacy.example.com
+    - corp.example.com
+    options:
+    - ndots:2
 worker:
   nodePools:
   - name: pool1
-    customSettings:
-      stack-type: node-pool
+  - name: pool2
+    kubeDns:
+      dnsConfig:
+        searches:
+        - pool2.example.com
 `,
 			assertConfig: []ConfigTester{
-				hasDefaultEtcdSettings,
-				asgBasedNodePoolHasWaitSignalEnabled,
 				func(c *config.Config, t *testing.T) {
-					p := c.NodePools[0]
-
-					{
-						expected := map[string]interface{}{
-							"stack-type": "control-plane",
+					expected := api.DNSConfig{
+						Searches: []string{"legacy.example.com", "corp.example.com"},
+						Options:  []string{"ndots:2"},
+					}
+					if !reflect.DeepEqual(c.KubeDns.DNSConfig, expected) {
+						t.Errorf("unexpected dnsConfig: expected=%+v actual=%+v", expected, c.KubeDns.DNSConfig)
+					}
+					if !reflect.DeepEqual(c.NodePools[0].KubeDns.DNSConfig, expected) {
+						t.Errorf("dnsConfig should be inherited to a node pool: expected=%+v actual=%+v", expected, c.NodePools[0].KubeDns.DNSConfig)
+					}
+					expectedPool2 := api.DNSConfig{Searches: []string{"pool2.example.com"}}
+					if !reflect.DeepEqual(c.NodePools[1].KubeDns.DNSConfig, expectedPool2) {
+						t.Errorf("dnsConfig of a node pool shouldn't be overridden: expected=%+v actual=%+v", expectedPool2, c.NodePools[1].KubeDns.DNSConfig)
+					}
+				},
+			},
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					workerUserdataS3Part := c.NodePools()[0].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					for name, userdata := range map[string]string{"controller": controllerUserdataS3Part, "worker": workerUserdataS3Part} {
+						if !strings.Contains(userdata, "--resolv-conf=/etc/kubernetes/resolv.conf") {
+							t.Errorf("missing --resolv-conf flag in %s userdata", name)
 						}
-						actual := c.CustomSettings
-						if !reflect.DeepEqual(expected, actual) {
-							t.Errorf("customSettings didn't match : expected=%v actual=%v", expected, actual)
+						if !strings.Contains(userdata, "ExecStartPre=/opt/bin/generate-kubelet-resolv-conf") {
+							t.Errorf("missing generate-kubelet-resolv-conf in %s userdata", name)
 						}
-					}
-
-					{
-						expected := map[string]interface{}{
-							"stack-type": "node-pool",
+						if !strings.Contains(userdata, `searches="legacy.example.com corp.example.com $(awk`) {
+							t.Errorf("missing dns search domains in %s userdata", name)
 						}
-						actual := p.CustomSettings
-						if !reflect.DeepEqual(expected, actual) {
-							t.Errorf("customSettings didn't match : expected=%v actual=%v", expected, actual)
+						if !strings.Contains(userdata, `options="ndots:2 $(awk`) {
+							t.Errorf("missing dns options in %s userdata", name)
 						}
 					}
+
+					pool2UserdataS3Part := c.NodePools()[1].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					if !strings.Contains(pool2UserdataS3Part, `searches="pool2.example.com $(awk`) {
+						t.Error("missing node pool specific dns search domains in worker userdata")
+					}
 				},
 			},
-			assertCluster: []ClusterTester{
-				hasDefaultCluster,
-			},
 		},
 		{
-			context: "WithDifferentReleaseChannels",
+			context: "WithoutKubeDnsDNSConfig",
 			configYaml: minimalValidConfigYaml + `
-releaseChannel: stable
 worker:
   nodePools:
   - name: pool1
-    releaseChannel: alpha
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultEtcdSettings,
-				asgBasedNodePoolHasWaitSignalEnabled,
-			},
 			assertCluster: []ClusterTester{
 				func(c *root.Cluster, t *testing.T) {
-					cp := c.ControlPlane().Config.AMI
-					np := c.NodePools()[0].NodePoolConfig.AMI
-
-					if cp == "" {
-						t.Error("the default AMI ID should not be empty but it was")
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					workerUserdataS3Part := c.NodePools()[0].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					for name, userdata := range map[string]string{"controller": controllerUserdataS3Part, "worker": workerUserdataS3Part} {
+						if strings.Contains(userdata, "--resolv-conf") || strings.Contains(userdata, "generate-kubelet-resolv-conf") {
+							t.Errorf("kubelet resolv.conf shouldn't be customized by default in %s userdata", name)
+						}
 					}
-
-					if np == "" {
-						t.Error("the AMI ID for the node pool should not be empty but it was")
+				},
+			},
+		},
+		{
+			context: "WithAPIServerEgressSelector",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.20.2
+kubernetes:
+  apiserver:
+    egressSelector:
+      selections:
+      - name: cluster
+        proxyProtocol: HTTPConnect
+        url: https://egress-proxy.example.com:8131
+        tls:
+          caBundle: /etc/kubernetes/ssl/egress-proxy-ca.pem
+          clientCert: /etc/kubernetes/ssl/egress-proxy-client.pem
+          clientKey: /etc/kubernetes/ssl/egress-proxy-client-key.pem
+      - name: controlplane
+        proxyProtocol: GRPC
+        udsName: /etc/kubernetes/konnectivity/konnectivity-server.socket
+      - name: etcd
+        proxyProtocol: Direct
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					if !strings.Contains(controllerUserdataS3Part, "- --egress-selector-config-file=/etc/kubernetes/additional-configs/egress-selector-config.yaml") {
+						t.Error("missing apiserver flag --egress-selector-config-file")
+					}
+					if !strings.Contains(controllerUserdataS3Part, "- mountPath: /etc/kubernetes/additional-configs") {
+						t.Error("missing apiserver volume mount for additional configs")
+					}
+					expected := `  - path: /etc/kubernetes/additional-configs/egress-selector-config.yaml
+    content: |
+      apiVersion: apiserver.k8s.io/v1beta1
+      kind: EgressSelectorConfiguration
+      egressSelections:
+      - name: cluster
+        connection:
+          proxyProtocol: HTTPConnect
+          transport:
+            tcp:
+              url: "https://egress-proxy.example.com:8131"
+              tlsConfig:
+                caBundle: "/etc/kubernetes/ssl/egress-proxy-ca.pem"
+                clientCert: "/etc/kubernetes/ssl/egress-proxy-client.pem"
+                clientKey: "/etc/kubernetes/ssl/egress-proxy-client-key.pem"
+      - name: controlplane
+        connection:
+          proxyProtocol: GRPC
+          transport:
+            uds:
+              udsName: "/etc/kubernetes/konnectivity/konnectivity-server.socket"
+      - name: etcd
+        connection:
+          proxyProtocol: Direct
+`
+					if !strings.Contains(controllerUserdataS3Part, expected) {
+						t.Errorf("missing or invalid egress selector configuration. expected to contain:\n%s", expected)
 					}
-
-					if cp == np {
-						t.Errorf("the default AMI ID and the AMI ID for the node pool should not match but they did: default=%s, nodepool=%s", cp, np)
+				},
+			},
+		},
+		{
+			context:    "WithoutAPIServerEgressSelector",
+			configYaml: minimalValidConfigYaml,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					if strings.Contains(controllerUserdataS3Part, "egress-selector-config") {
+						t.Error("egress selector shouldn't be configured by default")
 					}
 				},
 			},
 		},
 		{
-			context: "WithElasticFileSystemId",
+			context: "WithKubeletResourceReservations",
 			configYaml: minimalValidConfigYaml + `
-elasticFileSystemId: efs-12345
+kubelet:
+  systemReserved:
+    cpu: 100m
+    memory: 200Mi
+  kubeReserved: "cpu=200m,memory=300Mi,ephemeral-storage=1Gi"
+  enforceNodeAllocatable:
+  - pods
+  - system-reserved
+  systemReservedCgroup: /system.slice
 worker:
   nodePools:
   - name: pool1
+  - name: pool2
+    instanceType: m5.large
+    kubelet:
+      systemReserved:
+        cpu: 500m
+        memory: 1Gi
+      enforceNodeAllocatable:
+      - pods
 `,
 			assertConfig: []ConfigTester{
 				func(c *config.Config, t *testing.T) {
-					if c.NodePools[0].ElasticFileSystemID != "efs-12345" {
-						t.Errorf("The value of worker.nodePools[0].elasticFileSystemId should match the one for the top-leve elasticFileSystemId, but it wan't: worker.nodePools[0].elasticFileSystemId=%s", c.NodePools[0].ElasticFileSystemID)
+					if actual := c.NodePools[0].Kubelet.SystemReservedResources.String(); actual != "cpu=100m,memory=200Mi" {
+						t.Errorf("systemReserved should be inherited to a node pool: actual=%s", actual)
+					}
+					if actual := c.NodePools[1].Kubelet.SystemReservedResources.String(); actual != "cpu=500m,memory=1Gi" {
+						t.Errorf("systemReserved of a node pool shouldn't be overridden: actual=%s", actual)
+					}
+					if !c.NodePools[1].Kubelet.KubeReservedResources.IsEmpty() {
+						t.Errorf("kubeReserved shouldn't be inherited to a node pool with its own reservations: actual=%s", c.NodePools[1].Kubelet.KubeReservedResources)
+					}
+				},
+			},
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					workerUserdataS3Part := c.NodePools()[0].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					for name, userdata := range map[string]string{"controller": controllerUserdataS3Part, "worker": workerUserdataS3Part} {
+						for _, flag := range []string{
+							"--system-reserved=cpu=100m,memory=200Mi",
+							"--kube-reserved=cpu=200m,memory=300Mi,ephemeral-storage=1Gi",
+							"--enforce-node-allocatable=pods,system-reserved",
+							"--system-reserved-cgroup=/system.slice",
+						} {
+							if !strings.Contains(userdata, flag) {
+								t.Errorf("missing %s in %s userdata", flag, name)
+							}
+						}
+						if strings.Contains(userdata, "--kube-reserved-cgroup") {
+							t.Errorf("unexpected --kube-reserved-cgroup in %s userdata", name)
+						}
+						createCgroup := "ExecStartPre=/usr/bin/mkdir -p /sys/fs/cgroup/cpu/system.slice /sys/fs/cgroup/cpuacct/system.slice /sys/fs/cgroup/cpuset/system.slice " +
+							"/sys/fs/cgroup/hugetlb/system.slice /sys/fs/cgroup/memory/system.slice /sys/fs/cgroup/pids/system.slice /sys/fs/cgroup/systemd/system.slice"
+						if !strings.Contains(userdata, createCgroup) {
+							t.Errorf("missing the creation of the system reserved cgroup in %s userdata: expected to contain %s", name, createCgroup)
+						}
+					}
+
+					pool2UserdataS3Part := c.NodePools()[1].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					for _, flag := range []string{"--system-reserved=cpu=500m,memory=1Gi", "--enforce-node-allocatable=pods"} {
+						if !strings.Contains(pool2UserdataS3Part, flag) {
+							t.Errorf("missing node pool specific %s in worker userdata", flag)
+						}
+					}
+					if strings.Contains(pool2UserdataS3Part, "--kube-reserved=") {
+						t.Error("unexpected --kube-reserved in node pool specific worker userdata")
+					}
+					if strings.Contains(pool2UserdataS3Part, "/sys/fs/cgroup/") {
+						t.Error("unexpected creation of cgroups in node pool specific worker userdata without reserved cgroups")
 					}
 				},
 			},
 		},
 		{
-			context: "WithElasticFileSystemIdInSpecificNodePool",
-			configYaml: mainClusterYaml + `
-subnets:
-- name: existing1
-  id: subnet-12345
-  availabilityZone: us-west-1a
+			context: "WithAdditionalTrustedCAs",
+			configYaml: minimalValidConfigYaml + `
+additionalTrustedCAs:
+- |
+` + indentedTrustedCAPEM("  ") + `
 worker:
   nodePools:
   - name: pool1
-    subnets:
-    - name: existing1
-    elasticFileSystemId: efs-12345
-  - name: pool2
 `,
 			assertConfig: []ConfigTester{
 				func(c *config.Config, t *testing.T) {
-					if c.NodePools[0].ElasticFileSystemID != "efs-12345" {
-						t.Errorf("Unexpected worker.nodePools[0].elasticFileSystemId: %s", c.NodePools[0].ElasticFileSystemID)
+					if len(c.NodePools[0].AdditionalTrustedCAs) != 1 {
+						t.Errorf("additionalTrustedCAs should be inherited to a node pool: actual=%v", c.NodePools[0].AdditionalTrustedCAs)
 					}
-					if c.NodePools[1].ElasticFileSystemID != "" {
-						t.Errorf("Unexpected worker.nodePools[1].elasticFileSystemId: %s", c.NodePools[1].ElasticFileSystemID)
+				},
+			},
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					userdata := map[string]string{
+						"controller": c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content,
+						"etcd":       c.Etcd().UserData["Etcd"].Parts[api.USERDATA_S3].Asset.Content,
+						"worker":     c.NodePools()[0].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content,
+					}
+					for name, content := range userdata {
+						if !strings.Contains(content, "- path: /etc/ssl/certs/kube-aws-additional-trusted-ca-0.pem") {
+							t.Errorf("missing additional trusted CA file in %s userdata", name)
+						}
+						if !strings.Contains(content, indentedTrustedCAPEM("      ")) {
+							t.Errorf("missing additional trusted CA certificate in %s userdata", name)
+						}
+						if !strings.Contains(content, "ExecStart=/usr/sbin/update-ca-certificates") {
+							t.Errorf("missing update-ca-certificates in %s userdata", name)
+						}
 					}
 				},
 			},
 		},
 		{
-			context: "WithEtcdDataVolumeEncrypted",
-			configYaml: minimalValidConfigYaml + `
-etcd:
-  dataVolume:
-    encrypted: true
-`,
-			assertConfig: []ConfigTester{
-				func(c *config.Config, t *testing.T) {
-					if !c.Etcd.DataVolume.Encrypted {
-						t.Errorf("Etcd data volume should be encrypted but was not: %v", c.Etcd)
+			context:    "WithoutAdditionalTrustedCAs",
+			configYaml: minimalValidConfigYaml,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					if strings.Contains(controllerUserdataS3Part, "kube-aws-additional-trusted-ca") {
+						t.Error("no additional trusted CA should be added by default")
 					}
 				},
 			},
 		},
 		{
-			context: "WithEtcdDataVolumeEncryptedKMSKeyARN",
+			context: "WithKubeSchedulerPercentageOfNodesToScore",
 			configYaml: minimalValidConfigYaml + `
-etcd:
-  dataVolume:
-    encrypted: true
-  kmsKeyArn: arn:aws:kms:eu-west-1:XXX:key/XXX
+kubernetesVersion: v1.20.2
+controller:
+  kubeScheduler:
+    percentageOfNodesToScore: 30
 `,
-			assertConfig: []ConfigTester{
-				func(c *config.Config, t *testing.T) {
-					expected := "arn:aws:kms:eu-west-1:XXX:key/XXX"
-					if c.Etcd.KMSKeyARN() != expected {
-						t.Errorf("Etcd data volume KMS Key ARN didn't match : expected=%v actual=%v", expected, c.Etcd.KMSKeyARN())
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					if !strings.Contains(controllerUserdataS3Part, "--config=/etc/kubernetes/additional-configs/kube-scheduler-config.yaml") {
+						t.Error("missing --config flag for kube-scheduler in controller userdata")
+					}
+					if strings.Contains(controllerUserdataS3Part, "--kubeconfig=/etc/kubernetes/kubeconfig/kube-scheduler.yaml") {
+						t.Error("--kubeconfig flag for kube-scheduler should be replaced with the config file")
+					}
+					expected := `  - path: /etc/kubernetes/additional-configs/kube-scheduler-config.yaml
+    content: |
+      apiVersion: kubescheduler.config.k8s.io/v1beta1
+      kind: KubeSchedulerConfiguration
+      clientConnection:
+        kubeconfig: /etc/kubernetes/kubeconfig/kube-scheduler.yaml
+      leaderElection:
+        leaderElect: true
+      percentageOfNodesToScore: 30
+`
+					if !strings.Contains(controllerUserdataS3Part, expected) {
+						t.Errorf("missing kube-scheduler config in controller userdata: expected to contain:\n%s", expected)
 					}
-					if !c.Etcd.DataVolume.Encrypted {
-						t.Error("Etcd data volume should be encrypted but was not")
+				},
+			},
+		},
+		{
+			context:    "WithoutKubeSchedulerPercentageOfNodesToScore",
+			configYaml: minimalValidConfigYaml,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					if strings.Contains(controllerUserdataS3Part, "kube-scheduler-config.yaml") {
+						t.Error("kube-scheduler shouldn't be configured via a config file by default")
+					}
+					if !strings.Contains(controllerUserdataS3Part, "--kubeconfig=/etc/kubernetes/kubeconfig/kube-scheduler.yaml") {
+						t.Error("missing --kubeconfig flag for kube-scheduler in controller userdata")
 					}
 				},
 			},
 		},
 		{
-			context: "WithEtcdMemberIdentityProviderEIP",
+			context: "WithKubeControllerManagerConcurrentSyncs",
 			configYaml: minimalValidConfigYaml + `
-etcd:
-  memberIdentityProvider: eip
+controller:
+  kubeControllerManager:
+    concurrentDeploymentSyncs: 20
+    concurrentServiceSyncs: 5
+    concurrentEndpointSyncs: 30
+    concurrentGCSyncs: 40
 `,
-			assertConfig: []ConfigTester{
-				func(c *config.Config, t *testing.T) {
-					subnet1 := api.NewPublicSubnet(firstAz, "10.0.0.0/24")
-					subnet1.Name = "Subnet0"
-					expected := api.EtcdSettings{
-						Etcd: api.Etcd{
-							Cluster: api.EtcdCluster{
-								MemberIdentityProvider: "eip",
-							},
-							EC2Instance: api.EC2Instance{
-								Count:        1,
-								InstanceType: "t2.medium",
-								Tenancy:      "default",
-								RootVolume: api.RootVolume{
-									Size: 30,
-									Type: "gp2",
-									IOPS: 0,
-								},
-							},
-							DataVolume: api.DataVolume{
-								Size:      30,
-								Type:      "gp2",
-								IOPS:      0,
-								Ephemeral: false,
-							},
-							Subnets: api.Subnets{
-								subnet1,
-							},
-							UserSuppliedArgs: api.UserSuppliedArgs{
-								QuotaBackendBytes: api.DefaultQuotaBackendBytes,
-							},
-						},
-					}
-					actual := c.EtcdSettings
-					if diff := cmp.Diff(actual, expected); diff != "" {
-						t.Errorf("EtcdSettings didn't match: %s", diff)
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, expected := range []string{
+						"- --concurrent-deployment-syncs=20\n",
+						"- --concurrent-service-syncs=5\n",
+						"- --concurrent-endpoint-syncs=30\n",
+						"- --concurrent-gc-syncs=40\n",
+					} {
+						if !strings.Contains(controllerUserdataS3Part, expected) {
+							t.Errorf("expected the controller userdata to contain %q, but it didn't", expected)
+						}
 					}
-
-					if !actual.NodeShouldHaveEIP() {
-						t.Errorf(
-							"NodeShouldHaveEIP returned unexpected value: %v",
-							actual.NodeShouldHaveEIP(),
-						)
+					if strings.Contains(controllerUserdataS3Part, "--concurrent-replicaset-syncs") {
+						t.Error("--concurrent-replicaset-syncs shouldn't be rendered when not configured")
 					}
 				},
 			},
+		},
+		{
+			context:    "WithoutKubeControllerManagerConcurrentSyncs",
+			configYaml: minimalValidConfigYaml,
 			assertCluster: []ClusterTester{
-				hasDefaultCluster,
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					if strings.Contains(controllerUserdataS3Part, "--concurrent-") {
+						t.Error("no --concurrent-*-syncs flags should be rendered by default")
+					}
+				},
 			},
 		},
 		{
-			context: "WithEtcdMemberIdentityProviderENI",
+			context: "WithDefaultNetworkPolicy",
 			configYaml: minimalValidConfigYaml + `
-etcd:
-  memberIdentityProvider: eni
+defaults:
+  networkPolicy:
+    enabled: true
+    namespaces:
+    - default
+    - team-a
+    namespaceSelector: network-policy=default-deny
 `,
-			assertConfig: []ConfigTester{
-				func(c *config.Config, t *testing.T) {
-					subnet1 := api.NewPublicSubnet(firstAz, "10.0.0.0/24")
-					subnet1.Name = "Subnet0"
-					expected := api.EtcdSettings{
-						Etcd: api.Etcd{
-							EC2Instance: api.EC2Instance{
-								Count:        1,
-								InstanceType: "t2.medium",
-								RootVolume: api.RootVolume{
-									Size: 30,
-									Type: "gp2",
-									IOPS: 0,
-								},
-								Tenancy: "default",
-							},
-							DataVolume: api.DataVolume{
-								Size:      30,
-								Type:      "gp2",
-								IOPS:      0,
-								Ephemeral: false,
-							},
-							Cluster: api.EtcdCluster{
-								MemberIdentityProvider: "eni",
-							},
-							Subnets: api.Subnets{
-								subnet1,
-							},
-							UserSuppliedArgs: api.UserSuppliedArgs{
-								QuotaBackendBytes: api.DefaultQuotaBackendBytes,
-							},
-						},
-					}
-					actual := c.EtcdSettings
-					if diff := cmp.Diff(actual, expected); diff != "" {
-						t.Errorf("EtcdSettings didn't match: %s", diff)
-					}
-
-					if !actual.NodeShouldHaveSecondaryENI() {
-						t.Errorf(
-							"NodeShouldHaveSecondaryENI returned unexpected value: %v",
-							actual.NodeShouldHaveSecondaryENI(),
-						)
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, expected := range []string{
+						"path: /srv/kubernetes/manifests/default-network-policy.yaml",
+						"name: default-deny-all\n          namespace: team-a\n",
+						"- Ingress\n          - Egress\n",
+						"path: /srv/kubernetes/manifests/default-network-policy-controller.yaml",
+						"/kubectl get namespaces -l 'network-policy=default-deny'",
+						`applyall "${mfdir}/default-network-policy.yaml"`,
+						`applyall "${mfdir}/default-network-policy-controller.yaml"`,
+					} {
+						if !strings.Contains(controllerUserdataS3Part, expected) {
+							t.Errorf("expected the controller userdata to contain %q, but it didn't", expected)
+						}
 					}
 				},
 			},
+		},
+		{
+			context:    "WithoutDefaultNetworkPolicy",
+			configYaml: minimalValidConfigYaml,
 			assertCluster: []ClusterTester{
-				hasDefaultCluster,
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					if strings.Contains(controllerUserdataS3Part, "default-network-policy") {
+						t.Error("the default network policy shouldn't be deployed by default")
+					}
+				},
 			},
 		},
 		{
-			context: "WithEtcdMemberIdentityProviderENIWithCustomDomain",
+			context: "WithNodePoolPurpose",
 			configYaml: minimalValidConfigYaml + `
-etcd:
-  memberIdentityProvider: eni
-  internalDomainName: internal.example.com
+addons:
+  clusterAutoscaler:
+    enabled: true
+worker:
+  nodePools:
+  - name: observability
+    purpose: observability
+    autoscaling:
+      clusterAutoscaler:
+        enabled: true
+    nodeLabels:
+      team: sre
+    taints:
+    - key: kube-aws.coreos.com/purpose
+      value: observability
+      effect: NoSchedule
+  - name: pool2
 `,
 			assertConfig: []ConfigTester{
 				func(c *config.Config, t *testing.T) {
-					subnet1 := api.NewPublicSubnet(firstAz, "10.0.0.0/24")
-					subnet1.Name = "Subnet0"
-					expected := api.EtcdSettings{
-						Etcd: api.Etcd{
-							Cluster: api.EtcdCluster{
-								MemberIdentityProvider: "eni",
-								InternalDomainName:     "internal.example.com",
-							},
-							EC2Instance: api.EC2Instance{
-								Count:        1,
-								InstanceType: "t2.medium",
-								RootVolume: api.RootVolume{
-									Size: 30,
-									Type: "gp2",
-									IOPS: 0,
-								},
-								Tenancy: "default",
-							},
-							DataVolume: api.DataVolume{
-								Size:      30,
-								Type:      "gp2",
-								IOPS:      0,
-								Ephemeral: false,
-							},
-							Subnets: api.Subnets{
-								subnet1,
-							},
-							UserSuppliedArgs: api.UserSuppliedArgs{
-								QuotaBackendBytes: api.DefaultQuotaBackendBytes,
-							},
-						},
+					expectedLabels := api.NodeLabels{
+						"team":                        "sre",
+						"kube-aws.coreos.com/purpose": "observability",
 					}
-					actual := c.EtcdSettings
-					if diff := cmp.Diff(actual, expected); diff != "" {
-						t.Errorf("EtcdSettings didn't match: %s", diff)
+					if !reflect.DeepEqual(c.NodePools[0].NodeSettings.NodeLabels, expectedLabels) {
+						t.Errorf("unexpected node labels: expected=%v actual=%v", expectedLabels, c.NodePools[0].NodeSettings.NodeLabels)
 					}
-
-					if !actual.NodeShouldHaveSecondaryENI() {
-						t.Errorf(
-							"NodeShouldHaveSecondaryENI returned unexpected value: %v",
-							actual.NodeShouldHaveSecondaryENI(),
-						)
+					expectedTaints := api.Taints{
+						{Key: "kube-aws.coreos.com/purpose", Value: "observability", Effect: "NoSchedule"},
+					}
+					if !reflect.DeepEqual(c.NodePools[0].Taints, expectedTaints) {
+						t.Errorf("unexpected taints: expected=%v actual=%v", expectedTaints, c.NodePools[0].Taints)
+					}
+					if len(c.NodePools[1].Taints) != 0 {
+						t.Errorf("node pools without purpose shouldn't be tainted: actual=%v", c.NodePools[1].Taints)
 					}
 				},
 			},
 			assertCluster: []ClusterTester{
-				hasDefaultCluster,
-			},
-		},
-		{
-			context: "WithEtcdMemberIdentityProviderENIWithCustomFQDNs",
-			configYaml: minimalValidConfigYaml + `
-etcd:
-  memberIdentityProvider: eni
-  internalDomainName: internal.example.com
-  nodes:
-  - fqdn: etcd1a.internal.example.com
-  - fqdn: etcd1b.internal.example.com
-  - fqdn: etcd1c.internal.example.com
-`,
-			assertConfig: []ConfigTester{
-				func(c *config.Config, t *testing.T) {
-					subnet1 := api.NewPublicSubnet(firstAz, "10.0.0.0/24")
-					subnet1.Name = "Subnet0"
-					expected := api.EtcdSettings{
-						Etcd: api.Etcd{
-							Cluster: api.EtcdCluster{
-								MemberIdentityProvider: "eni",
-								InternalDomainName:     "internal.example.com",
-							},
-							EC2Instance: api.EC2Instance{
-								Count:        1,
-								InstanceType: "t2.medium",
-								RootVolume: api.RootVolume{
-									Size: 30,
-									Type: "gp2",
-									IOPS: 0,
-								},
-								Tenancy: "default",
-							},
-							DataVolume: api.DataVolume{
-								Size:      30,
-								Type:      "gp2",
-								IOPS:      0,
-								Ephemeral: false,
-							},
-							Nodes: []api.EtcdNode{
-								api.EtcdNode{
-									FQDN: "etcd1a.internal.example.com",
-								},
-								api.EtcdNode{
-									FQDN: "etcd1b.internal.example.com",
-								},
-								api.EtcdNode{
-									FQDN: "etcd1c.internal.example.com",
-								},
-							},
-							Subnets: api.Subnets{
-								subnet1,
-							},
-							UserSuppliedArgs: api.UserSuppliedArgs{
-								QuotaBackendBytes: api.DefaultQuotaBackendBytes,
-							},
-						},
+				func(c *root.Cluster, t *testing.T) {
+					workerUserdataS3Part := c.NodePools()[0].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					if !strings.Contains(workerUserdataS3Part, ",kube-aws.coreos.com/purpose=observability,team=sre") {
+						t.Error("missing the purpose label in worker userdata")
 					}
-					actual := c.EtcdSettings
-					if diff := cmp.Diff(actual, expected); diff != "" {
-						t.Errorf("EtcdSettings didn't match: %s", diff)
+					if !strings.Contains(workerUserdataS3Part, "--register-with-taints=kube-aws.coreos.com/purpose=observability:NoSchedule") {
+						t.Error("missing the purpose taint in worker userdata")
 					}
 
-					if !actual.NodeShouldHaveSecondaryENI() {
-						t.Errorf(
-							"NodeShouldHaveSecondaryENI returned unexpected value: %v",
-							actual.NodeShouldHaveSecondaryENI(),
-						)
+					stackTemplate, err := c.NodePools()[0].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render node pool stack template: %v", err)
+					}
+					for _, tag := range []string{
+						`{"Key":"k8s.io/cluster-autoscaler/node-template/label/kube-aws.coreos.com/purpose","PropagateAtLaunch":"false","Value":"observability"}`,
+						`{"Key":"k8s.io/cluster-autoscaler/node-template/taint/kube-aws.coreos.com/purpose","PropagateAtLaunch":"false","Value":"observability:NoSchedule"}`,
+					} {
+						if !strings.Contains(stackTemplate, tag) {
+							t.Errorf("missing cluster-autoscaler node-template tag in node pool stack template: %s", tag)
+						}
 					}
 				},
 			},
-			assertCluster: []ClusterTester{
-				hasDefaultCluster,
-			},
 		},
 		{
-			context: "WithEtcdMemberIdentityProviderENIWithCustomNames",
+			context: "WithControllerAggregation",
 			configYaml: minimalValidConfigYaml + `
-etcd:
-  memberIdentityProvider: eni
-  internalDomainName: internal.example.com
-  nodes:
-  - name: etcd1a
-  - name: etcd1b
-  - name: etcd1c
+controller:
+  aggregation:
+    enabled: true
+    allowedNames:
+    - front-proxy-client
+    - my-proxy
 `,
 			assertConfig: []ConfigTester{
 				func(c *config.Config, t *testing.T) {
-					subnet1 := api.NewPublicSubnet(firstAz, "10.0.0.0/24")
-					subnet1.Name = "Subnet0"
-					expected := api.EtcdSettings{
-						Etcd: api.Etcd{
-							Cluster: api.EtcdCluster{
-								MemberIdentityProvider: "eni",
-								InternalDomainName:     "internal.example.com",
-							},
-							EC2Instance: api.EC2Instance{
-								Count:        1,
-								InstanceType: "t2.medium",
-								RootVolume: api.RootVolume{
-									Size: 30,
-									Type: "gp2",
-									IOPS: 0,
-								},
-								Tenancy: "default",
-							},
-							DataVolume: api.DataVolume{
-								Size:      30,
-								Type:      "gp2",
-								IOPS:      0,
-								Ephemeral: false,
-							},
-							Nodes: []api.EtcdNode{
-								api.EtcdNode{
-									Name: "etcd1a",
-								},
-								api.EtcdNode{
-									Name: "etcd1b",
-								},
-								api.EtcdNode{
-									Name: "etcd1c",
-								},
-							},
-							Subnets: api.Subnets{
-								subnet1,
-							},
-							UserSuppliedArgs: api.UserSuppliedArgs{
-								QuotaBackendBytes: api.DefaultQuotaBackendBytes,
-							},
-						},
-					}
-					actual := c.EtcdSettings
-					if diff := cmp.Diff(actual, expected); diff != "" {
-						t.Errorf("EtcdSettings didn't match: %s", diff)
-					}
-
-					if !actual.NodeShouldHaveSecondaryENI() {
-						t.Errorf(
-							"NodeShouldHaveSecondaryENI returned unexpected value: %v",
-							actual.NodeShouldHaveSecondaryENI(),
-						)
+					if !c.Addons.APIServerAggregator.Enabled {
+						t.Error("the apiserver aggregator should be enabled along with the controller aggregation")
 					}
 				},
 			},
 			assertCluster: []ClusterTester{
-				hasDefaultCluster,
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, flag := range []string{
+						"--requestheader-client-ca-file=/etc/kubernetes/ssl/front-proxy-ca.pem",
+						"--requestheader-allowed-names=front-proxy-client,my-proxy",
+						"--requestheader-extra-headers-prefix=X-Remote-Extra-",
+						"--requestheader-group-headers=X-Remote-Group",
+						"--requestheader-username-headers=X-Remote-User",
+						"--proxy-client-cert-file=/etc/kubernetes/ssl/front-proxy-client.pem",
+						"--proxy-client-key-file=/etc/kubernetes/ssl/front-proxy-client-key.pem",
+					} {
+						if !strings.Contains(controllerUserdataS3Part, flag) {
+							t.Errorf("missing apiserver flag in controller userdata: %s", flag)
+						}
+					}
+					if strings.Contains(controllerUserdataS3Part, "--requestheader-client-ca-file=/etc/kubernetes/ssl/ca.pem") {
+						t.Error("the cluster CA must not be trusted for the request header authentication")
+					}
+					for _, path := range []string{
+						"/etc/kubernetes/ssl/front-proxy-ca.pem",
+						"/etc/kubernetes/ssl/front-proxy-client.pem",
+						"/etc/kubernetes/ssl/front-proxy-client-key.pem.enc",
+					} {
+						if !strings.Contains(controllerUserdataS3Part, "- path: "+path) {
+							t.Errorf("missing file in controller userdata: %s", path)
+						}
+					}
+				},
 			},
 		},
 		{
-			context: "WithEtcdMemberIdentityProviderENIWithoutRecordSets",
+			context: "WithoutControllerAggregation",
 			configYaml: minimalValidConfigYaml + `
-etcd:
-  memberIdentityProvider: eni
-  internalDomainName: internal.example.com
-  manageRecordSets: false
-  nodes:
-  - name: etcd1a
-  - name: etcd1b
-  - name: etcd1c
+addons:
+  metricsServer:
+    enabled: true
 `,
-			assertConfig: []ConfigTester{
-				func(c *config.Config, t *testing.T) {
-					subnet1 := api.NewPublicSubnet(firstAz, "10.0.0.0/24")
-					subnet1.Name = "Subnet0"
-					manageRecordSets := false
-					expected := api.EtcdSettings{
-						Etcd: api.Etcd{
-							Cluster: api.EtcdCluster{
-								ManageRecordSets:       &manageRecordSets,
-								MemberIdentityProvider: "eni",
-								InternalDomainName:     "internal.example.com",
-							},
-							EC2Instance: api.EC2Instance{
-								Count:        1,
-								InstanceType: "t2.medium",
-								RootVolume: api.RootVolume{
-									Size: 30,
-									Type: "gp2",
-									IOPS: 0,
-								},
-								Tenancy: "default",
-							},
-							DataVolume: api.DataVolume{
-								Size:      30,
-								Type:      "gp2",
-								IOPS:      0,
-								Ephemeral: false,
-							},
-							Nodes: []api.EtcdNode{
-								api.EtcdNode{
-									Name: "etcd1a",
-								},
-								api.EtcdNode{
-									Name: "etcd1b",
-								},
-								api.EtcdNode{
-									Name: "etcd1c",
-								},
-							},
-							Subnets: api.Subnets{
-								subnet1,
-							},
-							UserSuppliedArgs: api.UserSuppliedArgs{
-								QuotaBackendBytes: api.DefaultQuotaBackendBytes,
-							},
-						},
-					}
-					actual := c.EtcdSettings
-					if diff := cmp.Diff(actual, expected); diff != "" {
-						t.Errorf("EtcdSettings didn't match: %s", diff)
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					if strings.Contains(controllerUserdataS3Part, "front-proxy") {
+						t.Error("no front-proxy certs should be used by default")
 					}
-
-					if !actual.NodeShouldHaveSecondaryENI() {
-						t.Errorf(
-							"NodeShouldHaveSecondaryENI returned unexpected value: %v",
-							actual.NodeShouldHaveSecondaryENI(),
-						)
+					if !strings.Contains(controllerUserdataS3Part, "--proxy-client-cert-file=/etc/kubernetes/ssl/apiserver-aggregator.pem") {
+						t.Error("missing the apiserver aggregator cert in controller userdata")
 					}
 				},
 			},
+		},
+		{
+			context: "WithLaunchTemplateVersions",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: latest
+  - name: default
+    autoscaling:
+      launchTemplateVersion: $Default
+  - name: pinned
+    autoscaling:
+      launchTemplateVersion: 3
+  - name: pinnedmixed
+    autoScalingGroup:
+      mixedInstances:
+        enabled: true
+        instanceTypes:
+        - m5.large
+        - m5a.large
+    autoscaling:
+      launchTemplateVersion: "4"
+`,
 			assertCluster: []ClusterTester{
-				hasDefaultCluster,
+				func(c *root.Cluster, t *testing.T) {
+					expected := []string{
+						`"LaunchTemplate":{"LaunchTemplateId":{"Ref":"WorkersLT"},"Version":{"Fn::GetAtt":["WorkersLT","LatestVersionNumber"]}}`,
+						`"LaunchTemplate":{"LaunchTemplateId":{"Ref":"WorkersLT"},"Version":{"Fn::GetAtt":["WorkersLT","DefaultVersionNumber"]}}`,
+						`"LaunchTemplate":{"LaunchTemplateId":{"Ref":"WorkersLT"},"Version":"3"}`,
+						`"LaunchTemplateSpecification":{"LaunchTemplateId":{"Ref":"WorkersLT"},"Version":"4"}`,
+					}
+					for i, e := range expected {
+						stackTemplate, err := c.NodePools()[i].RenderStackTemplateAsString()
+						if err != nil {
+							t.Fatalf("failed to render node pool stack template: %v", err)
+						}
+						if !strings.Contains(stackTemplate, e) {
+							t.Errorf("missing the launch template version in the stack template of node pool %d: expected to contain %s", i, e)
+						}
+					}
+				},
 			},
 		},
 		{
-			context: "WithEtcdMemberIdentityProviderENIWithHostedZoneID",
+			context: "WithMixedInstancesDistribution",
 			configYaml: minimalValidConfigYaml + `
-etcd:
-  memberIdentityProvider: eni
-  internalDomainName: internal.example.com
-  hostedZone:
-    id: hostedzone-abcdefg
-  nodes:
-  - name: etcd1a
-  - name: etcd1b
-  - name: etcd1c
+worker:
+  nodePools:
+  - name: lowestprice
+    autoScalingGroup:
+      minSize: 1
+      maxSize: 3
+      mixedInstances:
+        enabled: true
+        onDemandBaseCapacity: 1
+        onDemandPercentageAboveBaseCapacity: 25
+        spotAllocationStrategy: lowest-price
+        spotInstancePools: 4
+        spotMaxPrice: "0.5"
+        instanceTypes:
+        - m5.large
+        - m5a.large
+  - name: capacityoptimized
+    autoScalingGroup:
+      minSize: 1
+      maxSize: 3
+      mixedInstances:
+        enabled: true
+        onDemandAllocationStrategy: lowest-price
+        spotAllocationStrategy: capacity-optimized
+        instanceTypes:
+        - m5.large
+        - m5a.large
 `,
-			assertConfig: []ConfigTester{
-				func(c *config.Config, t *testing.T) {
-					subnet1 := api.NewPublicSubnet(firstAz, "10.0.0.0/24")
-					subnet1.Name = "Subnet0"
-					expected := api.EtcdSettings{
-						Etcd: api.Etcd{
-							Cluster: api.EtcdCluster{
-								HostedZone:             api.Identifier{ID: "hostedzone-abcdefg"},
-								MemberIdentityProvider: "eni",
-								InternalDomainName:     "internal.example.com",
-							},
-							EC2Instance: api.EC2Instance{
-								Count:        1,
-								InstanceType: "t2.medium",
-								RootVolume: api.RootVolume{
-									Size: 30,
-									Type: "gp2",
-									IOPS: 0,
-								},
-								Tenancy: "default",
-							},
-							DataVolume: api.DataVolume{
-								Size:      30,
-								Type:      "gp2",
-								IOPS:      0,
-								Ephemeral: false,
-							},
-							Nodes: []api.EtcdNode{
-								api.EtcdNode{
-									Name: "etcd1a",
-								},
-								api.EtcdNode{
-									Name: "etcd1b",
-								},
-								api.EtcdNode{
-									Name: "etcd1c",
-								},
-							},
-							Subnets: api.Subnets{
-								subnet1,
-							},
-							UserSuppliedArgs: api.UserSuppliedArgs{
-								QuotaBackendBytes: api.DefaultQuotaBackendBytes,
-							},
-						},
-					}
-					actual := c.EtcdSettings
-					if diff := cmp.Diff(actual, expected); diff != "" {
-						t.Errorf("EtcdSettings didn't match: %s", diff)
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					expected := []string{
+						`"InstancesDistribution":{"SpotAllocationStrategy":"lowest-price","SpotMaxPrice":"0.5","SpotInstancePools":4,"OnDemandBaseCapacity":1,"OnDemandPercentageAboveBaseCapacity":25}`,
+						`"InstancesDistribution":{"OnDemandAllocationStrategy":"lowest-price","SpotAllocationStrategy":"capacity-optimized","OnDemandBaseCapacity":0,"OnDemandPercentageAboveBaseCapacity":0}`,
 					}
-
-					if !actual.NodeShouldHaveSecondaryENI() {
-						t.Errorf(
-							"NodeShouldHaveSecondaryENI returned unexpected value: %v",
-							actual.NodeShouldHaveSecondaryENI(),
-						)
+					for i, e := range expected {
+						stackTemplate, err := c.NodePools()[i].RenderStackTemplateAsString()
+						if err != nil {
+							t.Fatalf("failed to render node pool stack template: %v", err)
+						}
+						if !strings.Contains(stackTemplate, e) {
+							t.Errorf("unexpected instances distribution in the stack template of node pool %d: expected to contain %s", i, e)
+						}
 					}
 				},
 			},
+		},
+		{
+			context: "WithKubeletConfigFile",
+			configYaml: minimalValidConfigYaml + `
+kubelet:
+  systemReserved:
+    cpu: 100m
+  configFile:
+    enabled: true
+    overrides:
+      maxPods: 200
+      serializeImagePulls: false
+      authentication:
+        webhook:
+          enabled: true
+worker:
+  nodePools:
+  - name: pool1
+    nodeStatusUpdateFrequency: 10s
+`,
 			assertCluster: []ClusterTester{
-				hasDefaultCluster,
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					workerUserdataS3Part := c.NodePools()[0].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					for role, userdata := range map[string]string{"controller": controllerUserdataS3Part, "worker": workerUserdataS3Part} {
+						kubeletFlags := kubeletFlagsIn(userdata)
+						if !strings.Contains(kubeletFlags, "--config=/etc/kubernetes/additional-configs/kubelet-config.yaml") {
+							t.Errorf("missing --config flag for kubelet in %s userdata", role)
+						}
+						for _, flag := range []string{"--pod-manifest-path", "--cluster-dns", "--cluster-domain", "--feature-gates", "--system-reserved", "--node-status-update-frequency", "--tls-cert-file"} {
+							if strings.Contains(kubeletFlags, flag+"=") {
+								t.Errorf("%s flag for kubelet in %s userdata should be replaced with the config file", flag, role)
+							}
+						}
+					}
+					expectedWorkerConfig := `  - path: /etc/kubernetes/additional-configs/kubelet-config.yaml
+    content: |
+      apiVersion: kubelet.config.k8s.io/v1beta1
+      kind: KubeletConfiguration
+      authentication:
+        anonymous:
+          enabled: true
+        webhook:
+          enabled: true
+      authorization:
+        mode: AlwaysAllow
+      clusterDNS:
+      - 10.3.0.10
+      clusterDomain: cluster.local
+      featureGates:
+        ExpandPersistentVolumes: false
+        PodPriority: false
+      maxPods: 200
+      nodeStatusUpdateFrequency: 10s
+      readOnlyPort: 10255
+      serializeImagePulls: false
+      staticPodPath: /etc/kubernetes/manifests
+      systemReserved:
+        cpu: 100m
+      tlsCertFile: /etc/kubernetes/ssl/worker.pem
+      tlsPrivateKeyFile: /etc/kubernetes/ssl/worker-key.pem
+`
+					if !strings.Contains(workerUserdataS3Part, expectedWorkerConfig) {
+						t.Errorf("missing kubelet config in worker userdata: expected to contain:\n%s", expectedWorkerConfig)
+					}
+					if !strings.Contains(controllerUserdataS3Part, "      maxPods: 200\n") || strings.Contains(controllerUserdataS3Part, "      tlsCertFile:") {
+						t.Error("unexpected kubelet config in controller userdata")
+					}
+				},
 			},
 		},
 		{
-			context: "WithExperimentalFeatures",
+			context: "WithKubeletSerializeImagePulls",
 			configYaml: minimalValidConfigYaml + `
-experimental:
-  admission:
-    podSecurityPolicy:
-      enabled: true
-    denyEscalatingExec:
-      enabled: true
-    alwaysPullImages:
-      enabled: true
-    priority:
-      enabled: true
-    mutatingAdmissionWebhook:
-      enabled: true
-    validatingAdmissionWebhook:
-      enabled: true
-    persistentVolumeClaimResize:
-      enabled: false
-  auditLog:
-    enabled: true
-    logPath: "/var/log/audit.log"
-    maxAge: 100
-    maxBackup: 10
-    maxSize: 5
-  authentication:
-    webhook:
-      enabled: true
-      cacheTTL: "1234s"
-      configBase64: "e30k"
-  awsEnvironment:
-    enabled: true
-    environment:
-      CFNSTACK: '{ "Ref" : "AWS::StackId" }'
-  awsNodeLabels:
-    enabled: true
-  tlsBootstrap:
-    enabled: true
-  ephemeralImageStorage:
-    enabled: true
-  kiamSupport:
-    enabled: false
-  kube2IamSupport:
-    enabled: true
-  gpuSupport:
-    enabled: true
-    version: "375.66"
-    installImage: "shelmangroup/coreos-nvidia-driver-installer:latest"
-  kubeletOpts: '--image-gc-low-threshold 60 --image-gc-high-threshold 70'
-  loadBalancer:
-    enabled: true
-    names:
-      - manuallymanagedlb
-    securityGroupIds:
-      - sg-12345678
-  targetGroup:
-    enabled: true
-    arns:
-      - arn:aws:elasticloadbalancing:eu-west-1:xxxxxxxxxxxx:targetgroup/manuallymanagedetg/xxxxxxxxxxxxxxxx
-    securityGroupIds:
-      - sg-12345678
-  oidc:
-    enabled: true
-    oidc-issuer-url: "https://accounts.google.com"
-    oidc-client-id: "kubernetes"
-    oidc-username-claim: "email"
-    oidc-groups-claim: "groups"
-  nodeDrainer:
-    enabled: true
-    drainTimeout: 3
-cloudWatchLogging:
-  enabled: true
-amazonSsmAgent:
-  enabled: true
+kubernetesVersion: v1.27.1
+kubelet:
+  serializeImagePulls: false
 worker:
   nodePools:
   - name: pool1
+  - name: pool2
+    kubelet:
+      serializeImagePulls: false
+      maxParallelImagePulls: 5
+      configFile:
+        enabled: true
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultEtcdSettings,
-				asgBasedNodePoolHasWaitSignalEnabled,
-				func(c *config.Config, t *testing.T) {
-					expected := api.Experimental{
-						Admission: api.Admission{
-							PodSecurityPolicy: api.PodSecurityPolicy{
-								Enabled: true,
-							},
-							AlwaysPullImages: api.AlwaysPullImages{
-								Enabled: true,
-							},
-							DenyEscalatingExec: api.DenyEscalatingExec{
-								Enabled: true,
-							},
-							Priority: api.Priority{
-								Enabled: true,
-							},
-							MutatingAdmissionWebhook: api.MutatingAdmissionWebhook{
-								Enabled: true,
-							},
-							ValidatingAdmissionWebhook: api.ValidatingAdmissionWebhook{
-								Enabled: true,
-							},
-							PersistentVolumeClaimResize: api.PersistentVolumeClaimResize{
-								Enabled: false,
-							},
-						},
-						AuditLog: api.AuditLog{
-							Enabled:   true,
-							LogPath:   "/var/log/audit.log",
-							MaxAge:    100,
-							MaxBackup: 10,
-							MaxSize:   5,
-						},
-						Authentication: api.Authentication{
-							Webhook: api.Webhook{
-								Enabled:  true,
-								CacheTTL: "1234s",
-								Config:   "e30k",
-							},
-						},
-						AwsEnvironment: api.AwsEnvironment{
-							Enabled: true,
-							Environment: map[string]string{
-								"CFNSTACK": `{ "Ref" : "AWS::StackId" }`,
-							},
-						},
-						AwsNodeLabels: api.AwsNodeLabels{
-							Enabled: true,
-						},
-						ClusterAutoscalerSupport: api.ClusterAutoscalerSupport{
-							Enabled: true,
-							Options: map[string]string{},
-						},
-						TLSBootstrap: api.TLSBootstrap{
-							Enabled: true,
-						},
-						EphemeralImageStorage: api.EphemeralImageStorage{
-							Enabled:    true,
-							Disk:       "xvdb",
-							Filesystem: "xfs",
-						},
-						KIAMSupport: api.KIAMSupport{
-							Enabled:         false,
-							Image:           api.Image{Repo: "quay.io/uswitch/kiam", Tag: "v2.8", RktPullDocker: false},
-							SessionDuration: "15m",
-							ServerAddresses: api.KIAMServerAddresses{ServerAddress: "localhost:443", AgentAddress: "kiam-server:443"},
-						},
-						Kube2IamSupport: api.Kube2IamSupport{
-							Enabled: true,
-						},
-						GpuSupport: api.GpuSupport{
-							Enabled:      true,
-							Version:      "375.66",
-							InstallImage: "shelmangroup/coreos-nvidia-driver-installer:latest",
-						},
-						KubeletOpts: "--image-gc-low-threshold 60 --image-gc-high-threshold 70",
-						LoadBalancer: api.LoadBalancer{
-							Enabled:          true,
-							Names:            []string{"manuallymanagedlb"},
-							SecurityGroupIds: []string{"sg-12345678"},
-						},
-						TargetGroup: api.TargetGroup{
-							Enabled:          true,
-							Arns:             []string{"arn:aws:elasticloadbalancing:eu-west-1:xxxxxxxxxxxx:targetgroup/manuallymanagedetg/xxxxxxxxxxxxxxxx"},
-							SecurityGroupIds: []string{"sg-12345678"},
-						},
-						Oidc: api.Oidc{
-							Enabled:       true,
-							IssuerUrl:     "https://accounts.google.com",
-							ClientId:      "kubernetes",
-							UsernameClaim: "email",
-							GroupsClaim:   "groups",
-						},
-						NodeDrainer: api.NodeDrainer{
-							Enabled:      true,
-							DrainTimeout: 3,
-						},
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					pool1UserdataS3Part := c.NodePools()[0].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					for role, userdata := range map[string]string{"controller": controllerUserdataS3Part, "pool1": pool1UserdataS3Part} {
+						if !strings.Contains(kubeletFlagsIn(userdata), "--serialize-image-pulls=false") {
+							t.Errorf("missing --serialize-image-pulls=false flag for kubelet in %s userdata", role)
+						}
 					}
-
-					actual := c.Experimental
-
-					if !reflect.DeepEqual(expected, actual) {
-						t.Errorf("experimental settings didn't match : expected=%+v actual=%+v", expected, actual)
+					pool2UserdataS3Part := c.NodePools()[1].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					if strings.Contains(kubeletFlagsIn(pool2UserdataS3Part), "--serialize-image-pulls") {
+						t.Error("--serialize-image-pulls flag for kubelet in pool2 userdata should be replaced with the config file")
 					}
-
-					p := c.NodePools[0]
-					if reflect.DeepEqual(expected, p.Experimental) {
-						t.Errorf("experimental settings shouldn't be inherited to a node pool but it did : toplevel=%v nodepool=%v", expected, p.Experimental)
+					for _, expected := range []string{"      maxParallelImagePulls: 5\n", "      serializeImagePulls: false\n"} {
+						if !strings.Contains(pool2UserdataS3Part, expected) {
+							t.Errorf("expected the kubelet config in pool2 userdata to contain %q, but it didn't", expected)
+						}
 					}
-
 				},
 			},
+		},
+		{
+			context: "WithAPIServerScaleProfile",
+			configYaml: minimalValidConfigYaml + `
+controller:
+  apiServer:
+    scaleProfile: large
+    defaultWatchCacheSize: 2000
+    watchCacheSizes:
+    - pods#8000
+`,
 			assertCluster: []ClusterTester{
-				hasDefaultCluster,
 				func(c *root.Cluster, t *testing.T) {
-					cp := c.ControlPlane()
-					controllerUserdataS3Part := cp.UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
-					if match, _ := regexp.MatchString(`--feature-gates=.*ExpandPersistentVolumes=false`, controllerUserdataS3Part); !match {
-						t.Error("missing controller feature gate: ExpandPersistentVolumes=false")
-					}
-
-					if !strings.Contains(controllerUserdataS3Part, `scheduling.k8s.io/v1alpha1=true`) {
-						t.Error("missing controller runtime config: scheduling.k8s.io/v1alpha1=true")
-					}
-
-					re, _ := regexp.Compile("--enable-admission-plugins=[a-zA-z,]*,Priority")
-					if len(re.FindString(controllerUserdataS3Part)) == 0 {
-						t.Error("missing controller --enable-admission-plugins config: Priority")
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, expected := range []string{
+						"          - --etcd-compaction-interval=10m\n",
+						"          - --default-watch-cache-size=2000\n",
+						"          - --watch-cache-sizes=pods#8000\n",
+						"          - --default-not-ready-toleration-seconds=300\n",
+						"          - --default-unreachable-toleration-seconds=600\n",
+					} {
+						if !strings.Contains(controllerUserdataS3Part, expected) {
+							t.Errorf("expected controller userdata to contain %q, but it didn't", expected)
+						}
 					}
-
 				},
 			},
 		},
 		{
-			context: "WithExperimentalFeaturesForWorkerNodePool",
+			context: "WithKubeProxyConntrack",
 			configYaml: minimalValidConfigYaml + `
-addons:
-  clusterAutoscaler:
-    enabled: true
+kubeProxy:
+  conntrack:
+    maxPerCore: 65536
+    tcpEstablishedTimeout: 1h
+    tcpCloseWaitTimeout: 10m
 worker:
   nodePools:
   - name: pool1
-    admission:
-      podSecurityPolicy:
-        enabled: true
-    auditLog:
-      enabled: true
-      maxage: 100
-      logpath: "/var/log/audit.log"
-    awsEnvironment:
-      enabled: true
-      environment:
-        CFNSTACK: '{ "Ref" : "AWS::StackId" }'
-    awsNodeLabels:
-      enabled: true
-    clusterAutoscalerSupport:
-      enabled: true
-    tlsBootstrap:
-      enabled: true # Must be ignored, value is synced with the one from control plane
-    ephemeralImageStorage:
-      enabled: true
-    kube2IamSupport:
-      enabled: true
-    loadBalancer:
-      enabled: true
-      names:
-        - manuallymanagedlb
-      securityGroupIds:
-        - sg-12345678
-    targetGroup:
-      enabled: true
-      arns:
-        - arn:aws:elasticloadbalancing:eu-west-1:xxxxxxxxxxxx:targetgroup/manuallymanagedetg/xxxxxxxxxxxxxxxx
-      securityGroupIds:
-        - sg-12345678
-    # Ignored, uses global setting
-    nodeDrainer:
-      enabled: true
-      drainTimeout: 5
-    nodeLabels:
-      kube-aws.coreos.com/role: worker
-    taints:
-      - key: reservation
-        value: spot
-        effect: NoSchedule
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultEtcdSettings,
-				asgBasedNodePoolHasWaitSignalEnabled,
-				func(c *config.Config, t *testing.T) {
-					expected := api.Experimental{
-						AwsEnvironment: api.AwsEnvironment{
-							Enabled: true,
-							Environment: map[string]string{
-								"CFNSTACK": `{ "Ref" : "AWS::StackId" }`,
-							},
-						},
-						AwsNodeLabels: api.AwsNodeLabels{
-							Enabled: true,
-						},
-						ClusterAutoscalerSupport: api.ClusterAutoscalerSupport{
-							Enabled: true,
-							Options: map[string]string{},
-						},
-						TLSBootstrap: api.TLSBootstrap{
-							Enabled: false,
-						},
-						EphemeralImageStorage: api.EphemeralImageStorage{
-							Enabled:    true,
-							Disk:       "xvdb",
-							Filesystem: "xfs",
-						},
-						Kube2IamSupport: api.Kube2IamSupport{
-							Enabled: true,
-						},
-						LoadBalancer: api.LoadBalancer{
-							Enabled:          true,
-							Names:            []string{"manuallymanagedlb"},
-							SecurityGroupIds: []string{"sg-12345678"},
-						},
-						TargetGroup: api.TargetGroup{
-							Enabled:          true,
-							Arns:             []string{"arn:aws:elasticloadbalancing:eu-west-1:xxxxxxxxxxxx:targetgroup/manuallymanagedetg/xxxxxxxxxxxxxxxx"},
-							SecurityGroupIds: []string{"sg-12345678"},
-						},
-						NodeDrainer: api.NodeDrainer{
-							Enabled:      false,
-							DrainTimeout: 0,
-						},
-					}
-					p := c.NodePools[0]
-					if reflect.DeepEqual(expected, p.Experimental) {
-						t.Errorf("experimental settings for node pool didn't match : expected=%v actual=%v", expected, p.Experimental)
-					}
-
-					expectedNodeLabels := api.NodeLabels{
-						"kube-aws.coreos.com/cluster-autoscaler-supported": "true",
-						"kube-aws.coreos.com/role":                         "worker",
-					}
-					actualNodeLabels := c.NodePools[0].NodeLabels()
-					if !reflect.DeepEqual(expectedNodeLabels, actualNodeLabels) {
-						t.Errorf("worker node labels didn't match: expected=%v, actual=%v", expectedNodeLabels, actualNodeLabels)
-					}
-
-					expectedTaints := api.Taints{
-						{Key: "reservation", Value: "spot", Effect: "NoSchedule"},
-					}
-					actualTaints := c.NodePools[0].Taints
-					if !reflect.DeepEqual(expectedTaints, actualTaints) {
-						t.Errorf("worker node taints didn't match: expected=%v, actual=%v", expectedTaints, actualTaints)
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					expectedConfig := `          clusterCIDR: 10.2.0.0/16
+          conntrack:
+            maxPerCore: 65536
+            tcpEstablishedTimeout: 1h
+            tcpCloseWaitTimeout: 10m
+`
+					if !strings.Contains(controllerUserdataS3Part, expectedConfig) {
+						t.Errorf("expected the kube-proxy config to contain %q, but it didn't", expectedConfig)
+					}
+
+					workerUserdataS3Part := c.NodePools()[0].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					for role, userdata := range map[string]string{"controller": controllerUserdataS3Part, "worker": workerUserdataS3Part} {
+						for _, expected := range []string{
+							"ExecStart=/opt/bin/tune-conntrack",
+							"      max=$(( 65536 * $(nproc) ))\n",
+							"      if (( max < 131072 )); then\n",
+							"      sysctl -w net.netfilter.nf_conntrack_tcp_timeout_established=3600\n",
+							"      sysctl -w net.netfilter.nf_conntrack_tcp_timeout_close_wait=600\n",
+						} {
+							if !strings.Contains(userdata, expected) {
+								t.Errorf("expected %s userdata to contain %q, but it didn't", role, expected)
+							}
+						}
 					}
 				},
 			},
 		},
 		{
-			context: "WithExperimentalFeatureKiam",
+			context: "WithStackDependencies",
 			configYaml: minimalValidConfigYaml + `
-experimental:
-  kiamSupport:
-    enabled: true
-    image:
-      repo: quay.io/uswitch/kiam
-      tag: v2.6
-    sessionDuration: 30m	
-    serverAddresses:
-      serverAddress: localhost
-      agentAddress: kiam-server
+cloudformation:
+  stackDependencies:
+  - stack: pool1
+    dependsOn:
+    - pool2
+  - stack: control-plane
+    dependsOn:
+    - etcd
 worker:
   nodePools:
   - name: pool1
+  - name: pool2
 `,
-			assertConfig: []ConfigTester{
-				func(c *config.Config, t *testing.T) {
-					expected := api.KIAMSupport{
-						Enabled:         true,
-						Image:           api.Image{Repo: "quay.io/uswitch/kiam", Tag: "v2.6", RktPullDocker: false},
-						SessionDuration: "30m",
-						ServerAddresses: api.KIAMServerAddresses{ServerAddress: "localhost", AgentAddress: "kiam-server"},
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					rootStackTemplate, err := c.RenderStackTemplateAsString()
+					if err != nil {
+						t.Errorf("failed to render root stack template: %v", err)
 					}
-
-					actual := c.Experimental
-
-					if !reflect.DeepEqual(expected, actual.KIAMSupport) {
-						t.Errorf("experimental settings didn't match : expected=%+v actual=%+v", expected, actual)
+					if !strings.Contains(rootStackTemplate, `"DependsOn":["Controlplane","Pool2"]`) {
+						t.Errorf("expected pool1 to depend on pool2 in the root stack template, but it didn't: %s", rootStackTemplate)
 					}
-
-					p := c.NodePools[0]
-					if reflect.DeepEqual(expected, p.Experimental.KIAMSupport) {
-						t.Errorf("experimental settings shouldn't be inherited to a node pool but it did : toplevel=%v nodepool=%v", expected, p.Experimental)
+					// The dependency of the control plane on etcd is already implied by the reference to the etcd stack
+					if strings.Count(rootStackTemplate, `"DependsOn"`) != 2 {
+						t.Errorf("expected only the node pools to have DependsOn in the root stack template, but it didn't: %s", rootStackTemplate)
 					}
 				},
 			},
 		},
 		{
-			context: "WithExperimentalFeatureKiamForWorkerNodePool",
+			context: "WithCloudFormationTransforms",
 			configYaml: minimalValidConfigYaml + `
+cloudformation:
+  transforms:
+  - name: AWS::Serverless-2016-10-31
+  - name: MyOrgPolicyInjection
+    parameters:
+      CostCenter: "1234"
 worker:
   nodePools:
   - name: pool1
-    kiamSupport:
-      enabled: true
 `,
-			assertConfig: []ConfigTester{
-				func(c *config.Config, t *testing.T) {
-					expected := api.Experimental{
-						KIAMSupport: api.KIAMSupport{
-							Enabled:         true,
-							Image:           api.Image{Repo: "quay.io/uswitch/kiam", Tag: "v2.8", RktPullDocker: false},
-							SessionDuration: "15m",
-							ServerAddresses: api.KIAMServerAddresses{ServerAddress: "localhost:443", AgentAddress: "kiam-server:443"},
-						},
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					expected := `"Transform":["AWS::Serverless-2016-10-31",{"Name":"MyOrgPolicyInjection","Parameters":{"CostCenter":"1234"}}]`
+					rootStackTemplate, err := c.RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render root stack template: %v", err)
 					}
-					p := c.NodePools[0]
-					if reflect.DeepEqual(expected, p.Experimental) {
-						t.Errorf("experimental settings for node pool didn't match : expected=%v actual=%v", expected, p.Experimental)
+					if !strings.Contains(rootStackTemplate, expected) {
+						t.Errorf("expected the root stack template to contain %s, but it didn't: %s", expected, rootStackTemplate)
+					}
+					for _, s := range []*model.Stack{c.ControlPlane(), c.Etcd(), c.Network(), c.NodePools()[0]} {
+						stackTemplate, err := s.RenderStackTemplateAsString()
+						if err != nil {
+							t.Fatalf("failed to render %s stack template: %v", s.StackName, err)
+						}
+						if !strings.Contains(stackTemplate, expected) {
+							t.Errorf("expected the %s stack template to contain %s, but it didn't: %s", s.StackName, expected, stackTemplate)
+						}
 					}
 				},
 			},
 		},
 		{
-			context: "WithKube2IamSupport",
+			context: "WithoutCloudFormationTransforms",
 			configYaml: minimalValidConfigYaml + `
-controller:
-  iam:
-    role:
-      name: myrole1
-experimental:
-  kube2IamSupport:
-    enabled: true
 worker:
   nodePools:
   - name: pool1
-    iam:
-      role:
-        name: myrole2
-    kube2IamSupport:
-      enabled: true
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultEtcdSettings,
-				asgBasedNodePoolHasWaitSignalEnabled,
-				func(c *config.Config, t *testing.T) {
-					expectedControllerRoleName := "myrole1"
-					expectedWorkerRoleName := "myrole2"
-
-					if expectedControllerRoleName != c.Controller.IAMConfig.Role.Name {
-						t.Errorf("controller's iam.role.name didn't match : expected=%v actual=%v", expectedControllerRoleName, c.Controller.IAMConfig.Role.Name)
-					}
-
-					if !c.Experimental.Kube2IamSupport.Enabled {
-						t.Errorf("controller's experimental.kube2IamSupport should be enabled but was not: %+v", c.Experimental)
-					}
-
-					p := c.NodePools[0]
-					if expectedWorkerRoleName != p.IAMConfig.Role.Name {
-						t.Errorf("worker node pool's iam.role.name didn't match : expected=%v actual=%v", expectedWorkerRoleName, p.IAMConfig.Role.Name)
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					rootStackTemplate, err := c.RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render root stack template: %v", err)
 					}
-
-					if !p.Kube2IamSupport.Enabled {
-						t.Errorf("worker node pool's kube2IamSupport should be enabled but was not: %+v", p.Experimental)
+					if strings.Contains(rootStackTemplate, `"Transform"`) {
+						t.Errorf("root stack template shouldn't contain Transform by default")
 					}
-				},
-			},
-		},
-		{
-			context:    "WithControllerIAMDefaultManageExternally",
-			configYaml: minimalValidConfigYaml,
-			assertConfig: []ConfigTester{
-				func(c *config.Config, t *testing.T) {
-					expectedValue := false
-
-					if c.Controller.IAMConfig.Role.ManageExternally != expectedValue {
-						t.Errorf("controller's iam.role.manageExternally didn't match : expected=%v actual=%v", expectedValue, c.Controller.IAMConfig.Role.ManageExternally)
+					for _, s := range []*model.Stack{c.ControlPlane(), c.Etcd(), c.Network(), c.NodePools()[0]} {
+						stackTemplate, err := s.RenderStackTemplateAsString()
+						if err != nil {
+							t.Fatalf("failed to render %s stack template: %v", s.StackName, err)
+						}
+						if strings.Contains(stackTemplate, `"Transform"`) {
+							t.Errorf("%s stack template shouldn't contain Transform by default", s.StackName)
+						}
 					}
 				},
 			},
 		},
 		{
-			context: "WithControllerIAMEnabledManageExternally",
+			context: "WithAuditLogAndAuditWebhook",
 			configYaml: minimalValidConfigYaml + `
-controller:
-  iam:
-   role:
-     name: myrole1
-     manageExternally: true
+experimental:
+  auditLog:
+    enabled: true
+    logPath: /var/log/kube-apiserver-audit.log
+    maxAge: 30
+    maxBackup: 1
+    maxSize: 100
+  auditWebhook:
+    enabled: true
+    configBase64: YXBpVmVyc2lvbjogdjE=
+    mode: blocking
+    initialBackoff: 10s
 `,
-			assertConfig: []ConfigTester{
-				func(c *config.Config, t *testing.T) {
-					expectedManageExternally := true
-					expectedRoleName := "myrole1"
-
-					if expectedRoleName != c.Controller.IAMConfig.Role.Name {
-						t.Errorf("controller's iam.role.name didn't match : expected=%v actual=%v", expectedRoleName, c.Controller.IAMConfig.Role.Name)
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, flag := range []string{
+						"--audit-log-path=/var/log/kube-apiserver-audit.log",
+						"--audit-log-maxage=30",
+						"--audit-webhook-config-file=/etc/kubernetes/apiserver/audit-webhook.yaml",
+						"--audit-webhook-mode=blocking",
+						"--audit-webhook-initial-backoff=10s",
+					} {
+						if !strings.Contains(controllerUserdataS3Part, flag) {
+							t.Errorf("missing %s flag for apiserver in controller userdata", flag)
+						}
 					}
-
-					if expectedManageExternally != c.Controller.IAMConfig.Role.ManageExternally {
-						t.Errorf("controller's iam.role.manageExternally didn't matchg : expected=%v actual=%v", expectedManageExternally, c.Controller.IAMConfig.Role.ManageExternally)
+					if n := strings.Count(controllerUserdataS3Part, "--audit-policy-file=/etc/kubernetes/apiserver/audit-policy.yaml"); n != 1 {
+						t.Errorf("expected the audit policy file to be shared by the backends, but the flag appeared %d times", n)
+					}
+					if !strings.Contains(controllerUserdataS3Part, "path: /etc/kubernetes/apiserver/audit-webhook.yaml") {
+						t.Error("missing the audit webhook config file in controller userdata")
 					}
 				},
 			},
 		},
 		{
-			context: "WithControllerIAMEnabledStrictName",
+			context: "WithAuditWebhookOnly",
 			configYaml: minimalValidConfigYaml + `
-controller:
-  iam:
-   role:
-     name: myrole1
-     strictName: true
+experimental:
+  auditWebhook:
+    enabled: true
+    configBase64: YXBpVmVyc2lvbjogdjE=
 `,
-			assertConfig: []ConfigTester{
-				func(c *config.Config, t *testing.T) {
-					expectedRoleName := "myrole1"
-					if expectedRoleName != c.Controller.IAMConfig.Role.Name {
-						t.Errorf("controller's iam.role.name didn't match : expected=%v actual=%v", expectedRoleName, c.Controller.IAMConfig.Role.Name)
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, flag := range []string{
+						"--audit-webhook-config-file=/etc/kubernetes/apiserver/audit-webhook.yaml",
+						"--audit-policy-file=/etc/kubernetes/apiserver/audit-policy.yaml",
+					} {
+						if !strings.Contains(controllerUserdataS3Part, flag) {
+							t.Errorf("missing %s flag for apiserver in controller userdata", flag)
+						}
+					}
+					for _, flag := range []string{"--audit-log-path", "--audit-webhook-mode", "--audit-webhook-initial-backoff"} {
+						if strings.Contains(controllerUserdataS3Part, flag) {
+							t.Errorf("unexpected %s flag for apiserver in controller userdata", flag)
+						}
+					}
+					if !strings.Contains(controllerUserdataS3Part, "path: /etc/kubernetes/apiserver/audit-policy.yaml") {
+						t.Error("missing the audit policy file in controller userdata")
 					}
 				},
 			},
 		},
 		{
-			context: "WithWaitSignalDisabled",
+			context: "WithServiceAccountIssuerAPIAudiences",
 			configYaml: minimalValidConfigYaml + `
-waitSignal:
-  enabled: false
+kubernetesVersion: v1.20.2
+controller:
+  apiServer:
+    serviceAccountIssuer:
+      url: https://oidc.example.com/it
+      apiAudiences:
+      - https://oidc.example.com/it
+      - vault.example.com
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultEtcdSettings,
-				func(c *config.Config, t *testing.T) {
-					if c.WaitSignal.Enabled() {
-						t.Errorf("waitSignal should be disabled but was not: %v", c.WaitSignal)
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					expected := "--api-audiences=https://oidc.example.com/it,vault.example.com\n"
+					if !strings.Contains(controllerUserdataS3Part, expected) {
+						t.Errorf("expected \"%s\" to be contained in the controller userdata, but it wasn't", expected)
 					}
 				},
 			},
 		},
 		{
-			context: "WithWaitSignalEnabled",
+			context: "WithServiceAccountIssuerAPIAudiencesExcludingIssuer",
 			configYaml: minimalValidConfigYaml + `
-waitSignal:
-  enabled: true
+kubernetesVersion: v1.20.2
+controller:
+  apiServer:
+    serviceAccountIssuer:
+      url: https://oidc.example.com/it
+      apiAudiences:
+      - vault.example.com
+      apiAudiencesExcludeIssuer: true
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultEtcdSettings,
-				func(c *config.Config, t *testing.T) {
-					if !c.WaitSignal.Enabled() {
-						t.Errorf("waitSignal should be enabled but was not: %v", c.WaitSignal)
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					expected := "--api-audiences=vault.example.com\n"
+					if !strings.Contains(controllerUserdataS3Part, expected) {
+						t.Errorf("expected \"%s\" to be contained in the controller userdata, but it wasn't", expected)
 					}
 				},
 			},
 		},
 		{
-			context: "WithNodePoolWithWaitSignalDisabled",
+			context: "WithBlueGreenNodePool",
 			configYaml: minimalValidConfigYaml + `
+experimental:
+  nodeDrainer:
+    enabled: true
+    drainTimeout: 5
 worker:
   nodePools:
   - name: pool1
-    waitSignal:
-      enabled: false
-  - name: pool2
-    waitSignal:
-      enabled: false
-      maxBatchSize: 2
+    deploymentStrategy: blueGreen
+    blueGreen:
+      soakTime: 30m
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultEtcdSettings,
-				func(c *config.Config, t *testing.T) {
-					if c.NodePools[0].WaitSignal.Enabled() {
-						t.Errorf("waitSignal should be disabled for node pool at index %d but was not", 0)
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					stackTemplate, err := c.NodePools()[0].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render node pool stack template: %v", err)
+					}
+					for _, expected := range []string{
+						`"UpdatePolicy":{"AutoScalingReplacingUpdate":{"WillReplace":"true"}}`,
+						`"Timeout":"PT2700S"`,
+						`"LifecycleHookSpecificationList":[{"LifecycleHookName":"WorkersNodeDrainerLH","DefaultResult":"CONTINUE","HeartbeatTimeout":"300","LifecycleTransition":"autoscaling:EC2_INSTANCE_TERMINATING"}]`,
+					} {
+						if !strings.Contains(stackTemplate, expected) {
+							t.Errorf("missing %s in node pool stack template", expected)
+						}
 					}
-					if c.NodePools[1].WaitSignal.Enabled() {
-						t.Errorf("waitSignal should be disabled for node pool at index %d but was not", 1)
+					if strings.Contains(stackTemplate, "AWS::AutoScaling::LifecycleHook") {
+						t.Error("the node drainer lifecycle hook should be defined inline in the auto scaling group")
+					}
+
+					workerUserdataS3Part := c.NodePools()[0].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					if !strings.Contains(workerUserdataS3Part, "ExecStartPre=/usr/bin/sleep 1800") {
+						t.Error("missing the soak time in worker userdata")
 					}
 				},
 			},
 		},
 		{
-			context: "WithNodePoolWithWaitSignalEnabled",
+			context: "WithAmiSsmParameter",
 			configYaml: minimalValidConfigYaml + `
 worker:
   nodePools:
   - name: pool1
-    waitSignal:
-      enabled: true
+    amiSsmParameter: /aws/service/eks/optimized-ami/1.14/amazon-linux-2/recommended/image_id
   - name: pool2
-    waitSignal:
-      enabled: true
-      maxBatchSize: 2
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultEtcdSettings,
-				func(c *config.Config, t *testing.T) {
-					if !c.NodePools[0].WaitSignal.Enabled() {
-						t.Errorf("waitSignal should be enabled for node pool at index %d but was not", 0)
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					pool1, err := c.NodePools()[0].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render node pool stack template: %v", err)
 					}
-					if c.NodePools[0].WaitSignal.MaxBatchSize() != 1 {
-						t.Errorf("waitSignal.maxBatchSize should be 1 for node pool at index %d but was %d", 0, c.NodePools[0].WaitSignal.MaxBatchSize())
+					if !strings.Contains(pool1, `"ImageId":"ami-0123456789abcdef0"`) {
+						t.Error("the AMI resolved from the SSM parameter is missing in the node pool stack template")
 					}
-					if !c.NodePools[1].WaitSignal.Enabled() {
-						t.Errorf("waitSignal should be enabled for node pool at index %d but was not", 1)
+
+					pool2, err := c.NodePools()[1].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render node pool stack template: %v", err)
 					}
-					if c.NodePools[1].WaitSignal.MaxBatchSize() != 2 {
-						t.Errorf("waitSignal.maxBatchSize should be 2 for node pool at index %d but was %d", 1, c.NodePools[1].WaitSignal.MaxBatchSize())
+					if strings.Contains(pool2, "ami-0123456789abcdef0") {
+						t.Error("the AMI resolved from the SSM parameter of another node pool shouldn't be used")
 					}
 				},
 			},
 		},
 		{
-			context: "WithDefaultNodePoolRollingStrategy",
+			context: "WithControlPlaneResources",
 			configYaml: minimalValidConfigYaml + `
-worker:
-  nodePools:
-  - name: pool1
+controller:
+  apiServer:
+    resources:
+      requests:
+        cpu: 250m
+        memory: 512Mi
+      limits:
+        memory: 1Gi
+  kubeScheduler:
+    resources:
+      limits:
+        cpu: 200m
+        memory: 256Mi
+kubernetes:
+  controllerManager:
+    resources:
+      requests:
+        cpu: 200m
+etcd:
+  resources:
+    requests:
+      cpu: 500m
+    limits:
+      cpu: "1"
+      memory: 2Gi
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultNodePoolRollingStrategy,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, expected := range []string{
+						`
+          resources:
+            requests:
+              cpu: 250m
+              memory: 512Mi
+            limits:
+              memory: 1Gi
+          livenessProbe:
+            httpGet:
+              host: 127.0.0.1
+              port: 8080`,
+						`
+          resources:
+            requests:
+              cpu: 200m
+              memory: 100M
+            limits:
+              cpu: 250m
+              memory: 512M`,
+						`
+          resources:
+            requests:
+              cpu: 100m
+            limits:
+              cpu: 200m
+              memory: 256Mi`,
+					} {
+						if !strings.Contains(controllerUserdataS3Part, expected) {
+							t.Errorf("missing resources in controller userdata: %s", expected)
+						}
+					}
+
+					etcdUserdataS3Part := c.Etcd().UserData["Etcd"].Parts[api.USERDATA_S3].Asset.Content
+					expected := `
+        - name: 50-resources.conf
+          content: |
+            [Service]
+            CPUShares=512
+            CPUQuota=100%
+            MemoryLimit=2147483648
+`
+					if !strings.Contains(etcdUserdataS3Part, expected) {
+						t.Errorf("missing resource controls in etcd userdata: %s", expected)
+					}
+				},
 			},
 		},
 		{
-			context: "WithSpecificNodePoolRollingStrategy",
+			context: "WithCustomNetworkPlugin",
 			configYaml: minimalValidConfigYaml + `
-worker:
-  nodePools:
-  - name: pool1
-    nodePoolRollingStrategy: Sequential`,
-			assertConfig: []ConfigTester{
-				hasSpecificNodePoolRollingStrategy("Sequential"),
+kubernetes:
+  networking:
+    selfHosting:
+      type: custom
+      custom:
+        manifests:
+        - name: cilium
+          url: https://example.com/cilium.yaml
+        - name: cilium-config
+          content: |
+            apiVersion: v1
+            kind: ConfigMap
+            metadata:
+              name: cilium-config
+              namespace: kube-system
+        allocateNodeCIDRs: false
+        hostInterface: lxc+
+experimental:
+  kube2IamSupport:
+    enabled: true
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, expected := range []string{
+						`applyall "https://example.com/cilium.yaml" "/srv/kubernetes/manifests/custom-cni/cilium-config.yaml"`,
+						`- path: /srv/kubernetes/manifests/custom-cni/cilium-config.yaml
+    encoding: gzip+base64`,
+						`- "--host-interface=lxc+"`,
+					} {
+						if !strings.Contains(controllerUserdataS3Part, expected) {
+							t.Errorf("missing the custom CNI in controller userdata: %s", expected)
+						}
+					}
+					for _, unexpected := range []string{
+						`applyall "${mfdir}/canal.yaml"`,
+						`applyall "${mfdir}/flannel.yaml"`,
+						`applyall "${rbac}/network-daemonsets.yaml"`,
+						`ensuredelete "${mfdir}/canal.yaml"`,
+						`- --allocate-node-cidrs=true`,
+						`/srv/kubernetes/manifests/custom-cni/cilium.yaml`,
+					} {
+						if strings.Contains(controllerUserdataS3Part, unexpected) {
+							t.Errorf("unexpected content in controller userdata: %s", unexpected)
+						}
+					}
+				},
 			},
 		},
 		{
-			context: "WithSpecificWorkerRollingStrategy",
+			context: "WithKubeletEvictionSettings",
 			configYaml: minimalValidConfigYaml + `
+kubelet:
+  evictionMaxPodGracePeriod: 60
+  evictionPressureTransitionPeriod: 10m
 worker:
-  nodePoolRollingStrategy: Sequential
   nodePools:
-  - name: pool1`,
-			assertConfig: []ConfigTester{
-				hasSpecificNodePoolRollingStrategy("Sequential"),
+  - name: pool1
+  - name: pool2
+    kubelet:
+      evictionMaxPodGracePeriod: 0
+      configFile:
+        enabled: true
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					pool1UserdataS3Part := c.NodePools()[0].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					for role, userdata := range map[string]string{"controller": controllerUserdataS3Part, "pool1": pool1UserdataS3Part} {
+						for _, expected := range []string{"--eviction-max-pod-grace-period=60", "--eviction-pressure-transition-period=10m"} {
+							if !strings.Contains(kubeletFlagsIn(userdata), expected) {
+								t.Errorf("missing %s flag for kubelet in %s userdata", expected, role)
+							}
+						}
+					}
+					pool2UserdataS3Part := c.NodePools()[1].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					if strings.Contains(kubeletFlagsIn(pool2UserdataS3Part), "--eviction-") {
+						t.Error("eviction flags for kubelet in pool2 userdata should be replaced with the config file")
+					}
+					if !strings.Contains(pool2UserdataS3Part, "      evictionMaxPodGracePeriod: 0\n") {
+						t.Error("expected the kubelet config in pool2 userdata to contain its own evictionMaxPodGracePeriod, but it didn't")
+					}
+					if strings.Contains(pool2UserdataS3Part, "evictionPressureTransitionPeriod") {
+						t.Error("pool2 has its own eviction settings and should not inherit evictionPressureTransitionPeriod")
+					}
+				},
 			},
 		},
 		{
-			context: "WithWorkerAndNodePoolStrategy",
+			context: "WithInterPoolCommunication",
 			configYaml: minimalValidConfigYaml + `
 worker:
-  nodePoolRollingStrategy: Sequential
+  interPoolCommunication:
+  - from: pool1
+    to: pool2
+    ports:
+    - port: 8080
+    - protocol: udp
+      port: 30000
+      toPort: 30100
   nodePools:
   - name: pool1
   - name: pool2
-    nodePoolRollingStrategy: Parallel
+  - name: pool3
 `,
-			assertConfig: []ConfigTester{
-				hasWorkerAndNodePoolStrategy("Sequential", "Parallel"),
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					networkStackTemplate, err := c.Network().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render network stack template: %v", err)
+					}
+					for _, expected := range []string{
+						`"SecurityGroupWorkerPool1":{"Properties":{"GroupDescription":{"Fn::Sub":"${AWS::StackName} node pool pool1"}`,
+						`"SecurityGroupWorkerPool2":{"Properties":{"GroupDescription":{"Fn::Sub":"${AWS::StackName} node pool pool2"}`,
+						`"SecurityGroupWorkerPool2IngressFromPool1Tcp8080":{"Properties":{"FromPort":8080,"GroupId":{"Ref":"SecurityGroupWorkerPool2"},"IpProtocol":"tcp","SourceSecurityGroupId":{"Ref":"SecurityGroupWorkerPool1"},"ToPort":8080},"Type":"AWS::EC2::SecurityGroupIngress"}`,
+						`"SecurityGroupWorkerPool2IngressFromPool1Udp30000To30100":{"Properties":{"FromPort":30000,"GroupId":{"Ref":"SecurityGroupWorkerPool2"},"IpProtocol":"udp","SourceSecurityGroupId":{"Ref":"SecurityGroupWorkerPool1"},"ToPort":30100},"Type":"AWS::EC2::SecurityGroupIngress"}`,
+						`"Export":{"Name":{"Fn::Sub":"${AWS::StackName}-SecurityGroupWorkerPool2"}}`,
+					} {
+						if !strings.Contains(networkStackTemplate, expected) {
+							t.Errorf("expected the network stack template to contain %s, but it didn't", expected)
+						}
+					}
+					if strings.Contains(networkStackTemplate, "SecurityGroupWorkerPool3") {
+						t.Error("pool3 is not referenced from interPoolCommunication and should not be given a dedicated security group")
+					}
+					if strings.Contains(networkStackTemplate, "ToIPAMD") {
+						t.Error("the ipamd port should not be opened among nodes without the Amazon VPC CNI")
+					}
+
+					for i, expected := range []bool{true, true, false} {
+						nodePoolStackTemplate, err := c.NodePools()[i].RenderStackTemplateAsString()
+						if err != nil {
+							t.Fatalf("failed to render node pool stack template: %v", err)
+						}
+						sg := fmt.Sprintf(`{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-SecurityGroupWorkerPool%d"}}`, i+1)
+						if actual := strings.Contains(nodePoolStackTemplate, sg); actual != expected {
+							t.Errorf("expected the presence of the dedicated security group in the stack template of the node pool %d to be %v, but was %v", i, expected, actual)
+						}
+					}
+				},
 			},
 		},
 		{
-			context:    "WithMinimalValidConfig",
-			configYaml: minimalValidConfigYaml,
-			assertConfig: []ConfigTester{
-				hasDefaultEtcdSettings,
-				hasDefaultExperimentalFeatures,
+			context: "WithAPIServerAnonymousHealthEndpoints",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.32.1
+controller:
+  apiServer:
+    anonymousAuth: healthEndpoints
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, expected := range []string{
+						"- --authentication-config=/etc/kubernetes/additional-configs/authentication-config.yaml",
+						`  - path: /etc/kubernetes/additional-configs/authentication-config.yaml
+    content: |
+      apiVersion: apiserver.config.k8s.io/v1beta1
+      kind: AuthenticationConfiguration
+      anonymous:
+        enabled: true
+        conditions:
+        - path: /healthz
+        - path: /livez
+        - path: /readyz
+`,
+						`          - mountPath: /etc/kubernetes/additional-configs
+            name: auth-additional-configs`,
+					} {
+						if !strings.Contains(controllerUserdataS3Part, expected) {
+							t.Errorf("missing the anonymous health endpoints config in controller userdata: %s", expected)
+						}
+					}
+					if strings.Contains(controllerUserdataS3Part, "--anonymous-auth") {
+						t.Error("--anonymous-auth must not be passed along with --authentication-config configuring anonymous auth")
+					}
+
+					controlPlaneStackTemplate, err := c.ControlPlane().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render control-plane stack template: %v", err)
+					}
+					if expected := `"Target":"HTTPS:443/readyz"`; !strings.Contains(controlPlaneStackTemplate, expected) {
+						t.Errorf("expected the control-plane stack template to contain %s, but it didn't", expected)
+					}
+				},
 			},
 		},
 		{
-			context: "WithVaryingWorkerCountPerNodePool",
+			context: "WithNodePoolPodCIDRRanges",
 			configYaml: minimalValidConfigYaml + `
 worker:
   nodePools:
   - name: pool1
+    podCIDRRange: 10.2.128.0/18
   - name: pool2
-    count: 2
-  - name: pool3
-    count: 0
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultEtcdSettings,
-				hasDefaultExperimentalFeatures,
-				func(c *config.Config, t *testing.T) {
-					if c.NodePools[0].Count != 1 {
-						t.Errorf("default worker count should be 1 but was: %d", c.NodePools[0].Count)
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, expected := range []string{
+						"    - name: allocate-node-pod-cidrs.service",
+						"  - path: /opt/bin/allocate-node-pod-cidrs",
+						`        "node-role.kubernetes.io/pool1 10.2.128.0/18"`,
+					} {
+						if !strings.Contains(controllerUserdataS3Part, expected) {
+							t.Errorf("missing the pod CIDR allocation in controller userdata: %s", expected)
+						}
 					}
-					if c.NodePools[1].Count != 2 {
-						t.Errorf("worker count should be set to 2 but was: %d", c.NodePools[1].Count)
+					if strings.Contains(controllerUserdataS3Part, "--allocate-node-cidrs=true") {
+						t.Error("the controller manager must not allocate pod CIDRs along with allocate-node-pod-cidrs")
 					}
-					if c.NodePools[2].Count != 0 {
-						t.Errorf("worker count should be be set to 0 but was: %d", c.NodePools[2].Count)
+
+					for i, expected := range []bool{true, false} {
+						workerUserdataS3Part := c.NodePools()[i].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+						if actual := strings.Contains(workerUserdataS3Part, `KUBE_POD_CIDR_RANGE="10.2.128.0/18"`); actual != expected {
+							t.Errorf("unexpected pod CIDR range in the userdata of node pool %d: expected included=%v, got %v", i, expected, actual)
+						}
 					}
 				},
 			},
 		},
 		{
-			context: "WithVaryingWorkerASGSizePerNodePool",
+			context: "WithCompressedInstanceScript",
 			configYaml: minimalValidConfigYaml + `
+instanceScript:
+  compression: gzip
 worker:
   nodePools:
   - name: pool1
   - name: pool2
-    count: 2
-  - name: pool3
-    autoScalingGroup:
-      minSize: 0
-      maxSize: 10
+    instanceScript:
+      compression: none
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultEtcdSettings,
-				hasDefaultExperimentalFeatures,
-				func(c *config.Config, t *testing.T) {
-					if c.NodePools[0].MaxCount() != 1 {
-						t.Errorf("worker max count should be 1 but was: %d", c.NodePools[0].MaxCount())
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					cp, err := c.ControlPlane().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render control-plane stack template: %v", err)
 					}
-					if c.NodePools[0].MinCount() != 1 {
-						t.Errorf("worker min count should be 1 but was: %d", c.NodePools[0].MinCount())
+					if strings.Contains(cp, "coreos-cloudinit") {
+						t.Errorf("expected the instance script of controller nodes to be compressed, but it wasn't: %s", cp)
 					}
-					if c.NodePools[1].MaxCount() != 2 {
-						t.Errorf("worker max count should be 2 but was: %d", c.NodePools[1].MaxCount())
+					m := regexp.MustCompile(`echo '([A-Za-z0-9+/=]+)' \| base64 -d \| gunzip`).FindStringSubmatch(cp)
+					if m == nil {
+						t.Fatalf("expected the compressed instance script of controller nodes to be contained in the control-plane stack template, but it wasn't: %s", cp)
 					}
-					if c.NodePools[1].MinCount() != 2 {
-						t.Errorf("worker min count should be 2 but was: %d", c.NodePools[1].MinCount())
+					decompressed, err := gzipcompressor.GzippedBase64StringToString(m[1])
+					if err != nil {
+						t.Fatalf("failed to decompress the instance script of controller nodes: %v", err)
 					}
-					if c.NodePools[2].MaxCount() != 10 {
-						t.Errorf("worker max count should be 10 but was: %d", c.NodePools[2].MaxCount())
+					expected, err := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_INSTANCE_SCRIPT].Template()
+					if err != nil {
+						t.Fatalf("failed to render the instance script of controller nodes: %v", err)
 					}
-					if c.NodePools[2].MinCount() != 0 {
-						t.Errorf("worker min count should be 0 but was: %d", c.NodePools[2].MinCount())
+					if decompressed != expected {
+						t.Errorf("unexpected instance script of controller nodes: expected %s, got %s", expected, decompressed)
+					}
+
+					pool1, err := c.NodePools()[0].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render node pool stack template: %v", err)
+					}
+					if !strings.Contains(pool1, "INSTANCE_SCRIPT_FILE=/var/run/coreos/instance-script-worker") || strings.Contains(pool1, "coreos-cloudinit") {
+						t.Errorf("expected the instance script of pool1 to be compressed, but it wasn't: %s", pool1)
+					}
+
+					pool2, err := c.NodePools()[1].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render node pool stack template: %v", err)
+					}
+					if strings.Contains(pool2, "instance-script-worker") || !strings.Contains(pool2, "coreos-cloudinit") {
+						t.Errorf("expected the instance script of pool2 to be embedded as-is, but it wasn't: %s", pool2)
 					}
 				},
 			},
 		},
 		{
-			context: "WithMultiAPIEndpoints",
-			configYaml: kubeAwsSettings.mainClusterYamlWithoutAPIEndpoint() + `
-vpc:
-  id: vpc-1a2b3c4d
-internetGateway:
-  id: igw-1a2b3c4d
-
-subnets:
-- name: privateSubnet1
-  availabilityZone: us-west-1a
-  instanceCIDR: "10.0.1.0/24"
-  private: true
-- name: privateSubnet2
-  availabilityZone: us-west-1b
-  instanceCIDR: "10.0.2.0/24"
-  private: true
-- name: publicSubnet1
-  availabilityZone: us-west-1a
-  instanceCIDR: "10.0.3.0/24"
-- name: publicSubnet2
-  availabilityZone: us-west-1b
-  instanceCIDR: "10.0.4.0/24"
-
+			context: "WithCapacityOptimizedPrioritizedSpotAllocation",
+			configYaml: minimalValidConfigYaml + `
 worker:
-  # cant be possibly "unversioned" one w/ existing elb because doing so would result in a worker kubelet has chances to
-  # connect to multiple masters from different clusters!
-  apiEndpointName: versionedPrivate
-  # btw apiEndpointName can be defaulted to a private/public managed(hence unstable/possibly versioned but not stable/unversioned)
-  # elb/round-robin if and only if there is only one. However we dont do the complex defaulting like that for now.
-
-adminAPIEndpointName: versionedPublic
-
+  nodePools:
+  - name: pool1
+    autoScalingGroup:
+      minSize: 1
+      maxSize: 3
+      mixedInstances:
+        enabled: true
+        spotAllocationStrategy: capacity-optimized-prioritized
+        instanceTypes:
+        - m5a.large
+        - m5.large
+        - m4.large
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					stackTemplate, err := c.NodePools()[0].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render node pool stack template: %v", err)
+					}
+					expected := []string{
+						`"InstancesDistribution":{"SpotAllocationStrategy":"capacity-optimized-prioritized","OnDemandBaseCapacity":0,"OnDemandPercentageAboveBaseCapacity":0}`,
+						`"Overrides":[{"InstanceType":"m5a.large"},{"InstanceType":"m5.large"},{"InstanceType":"m4.large"}]`,
+					}
+					for _, e := range expected {
+						if !strings.Contains(stackTemplate, e) {
+							t.Errorf("unexpected mixed instances policy in the stack template: expected to contain %s", e)
+						}
+					}
+				},
+			},
+		},
+		{
+			context: "WithAPIServerRequestTimeouts",
+			configYaml: configYamlWithoutExernalDNSName + `
 apiEndpoints:
-- name: unversionedPublic
-  dnsName: api.example.com
-  loadBalancer:
-    id: elb-internet-facing
-    ##you cant configure existing elb like below
-    #private: true
-    #subnets:
-    #- name: privateSubnet1
-    ##hostedZone must be omitted when elb id is specified.
-    ##in other words, it your responsibility to create an alias record for the elb
-    #hostedZone:
-    #  id: hostedzone-private
-- name: unversionedPrivate
-  dnsName: api.internal.example.com
-  loadBalancer:
-    id: elb-internal
-- name: versionedPublic
-  dnsName: v1api.example.com
-  loadBalancer:
-    subnets:
-    - name: publicSubnet1
-    hostedZone:
-      id: hostedzone-public
-- name: versionedPrivate
-  dnsName: v1api.internal.example.com
-  loadBalancer:
-    private: true
-    subnets:
-    - name: privateSubnet1
-    hostedZone:
-      id: hostedzone-private
-- name: versionedPublicAlt
-  dnsName: v1apialt.example.com
-  loadBalancer:
-    # "private: false" implies all the private subnets defined in the top-level "subnets"
-    #subnets:
-    #- name: publicSubnet1
-    #- name: publicSubnet2
-    hostedZone:
-      id: hostedzone-public
-- name: versionedPrivateAlt
-  dnsName: v1apialt.internal.example.com
+- name: default
+  dnsName: k8s.example.com
   loadBalancer:
-    private: true
-    # "private: true" implies all the private subnets defined in the top-level "subnets"
-    #subnets:
-    #- name: privateSubnet1
-    #- name: privateSubnet2
+    type: network
     hostedZone:
-      id: hostedzone-private
-- name: addedToCertCommonNames
-  dnsName: api-alt.example.com
-  loadBalancer:
-    managed: false
-- name: elbOnly
-  dnsName: registerme.example.com
-  loadBalancer:
-    recordSetManaged: false
+      id: a1b2c4
+    idleTimeout: 600
+controller:
+  apiServer:
+    requestTimeout: 5m
+    minRequestTimeout: 1h
 `,
 			assertCluster: []ClusterTester{
-				func(rootCluster *root.Cluster, t *testing.T) {
-					c := rootCluster.ControlPlane().Config
-
-					private1 := api.NewPrivateSubnet("us-west-1a", "10.0.1.0/24")
-					private1.Name = "privateSubnet1"
-
-					private2 := api.NewPrivateSubnet("us-west-1b", "10.0.2.0/24")
-					private2.Name = "privateSubnet2"
-
-					public1 := api.NewPublicSubnet("us-west-1a", "10.0.3.0/24")
-					public1.Name = "publicSubnet1"
-
-					public2 := api.NewPublicSubnet("us-west-1b", "10.0.4.0/24")
-					public2.Name = "publicSubnet2"
-					//private1 := api.NewPrivateSubnetFromFn("us-west-1a", `{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-PrivateSubnet1"}}`)
-					//private1.Name = "privateSubnet1"
-					//
-					//private2 := api.NewPrivateSubnetFromFn("us-west-1b", `{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-PrivateSubnet2"}}`)
-					//private2.Name = "privateSubnet2"
-					//
-					//public1 := api.NewPublicSubnetFromFn("us-west-1a", `{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-PublicSubnet1"}}`)
-					//public1.Name = "publicSubnet1"
-					//
-					//public2 := api.NewPublicSubnetFromFn("us-west-1b", `{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-PublicSubnet2"}}`)
-					//public2.Name = "publicSubnet2"
-
-					subnets := api.Subnets{
-						private1,
-						private2,
-						public1,
-						public2,
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, flag := range []string{"- --request-timeout=5m", "- --min-request-timeout=3600"} {
+						if !strings.Contains(controllerUserdataS3Part, flag) {
+							t.Errorf("missing %s in the apiserver flags", flag)
+						}
 					}
-					if !reflect.DeepEqual(c.AllSubnets(), subnets) {
-						t.Errorf("Managed subnets didn't match: expected=%+v actual=%+v", subnets, c.AllSubnets())
+					controlPlaneStackTemplate, err := c.ControlPlane().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render control plane stack template: %v", err)
 					}
-
-					publicSubnets := api.Subnets{
-						public1,
-						public2,
+					expected := `"ListenerAttributes":[{"Key":"tcp.idle_timeout.seconds","Value":"600"}]`
+					if !strings.Contains(controlPlaneStackTemplate, expected) {
+						t.Errorf("unexpected idle timeout of the API endpoint NLB: expected to contain %s", expected)
 					}
-
-					privateSubnets := api.Subnets{
-						private1,
-						private2,
+				},
+			},
+		},
+		{
+			context:    "WithoutAPIServerRequestTimeouts",
+			configYaml: minimalValidConfigYaml,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					if strings.Contains(controllerUserdataS3Part, "- --request-timeout") || strings.Contains(controllerUserdataS3Part, "--min-request-timeout") {
+						t.Error("the apiserver request timeouts should be left to the apiserver's defaults")
 					}
-
-					unversionedPublic := c.APIEndpoints["unversionedPublic"]
-					unversionedPrivate := c.APIEndpoints["unversionedPrivate"]
-					versionedPublic := c.APIEndpoints["versionedPublic"]
-					versionedPrivate := c.APIEndpoints["versionedPrivate"]
-					versionedPublicAlt := c.APIEndpoints["versionedPublicAlt"]
-					versionedPrivateAlt := c.APIEndpoints["versionedPrivateAlt"]
-					addedToCertCommonNames := c.APIEndpoints["addedToCertCommonNames"]
-					elbOnly := c.APIEndpoints["elbOnly"]
-
-					if len(unversionedPublic.LoadBalancer.Subnets) != 0 {
-						t.Errorf("unversionedPublic: subnets shuold be empty but was not: actual=%+v", unversionedPublic.LoadBalancer.Subnets)
+					controlPlaneStackTemplate, err := c.ControlPlane().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render control plane stack template: %v", err)
 					}
-					if !unversionedPublic.LoadBalancer.Enabled() {
-						t.Errorf("unversionedPublic: it should be enabled as the lb to which controller nodes are added, but it was not: loadBalancer=%+v", unversionedPublic.LoadBalancer)
+					expected := `"ConnectionSettings":{"IdleTimeout":"3600"}`
+					if !strings.Contains(controlPlaneStackTemplate, expected) {
+						t.Errorf("unexpected default idle timeout of the API endpoint ELB: expected to contain %s", expected)
 					}
-
-					if len(unversionedPrivate.LoadBalancer.Subnets) != 0 {
-						t.Errorf("unversionedPrivate: subnets shuold be empty but was not: actual=%+v", unversionedPrivate.LoadBalancer.Subnets)
+				},
+			},
+		},
+		{
+			context: "WithNodePoolRollbackOnFailure",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    update:
+      rollbackOnFailure: true
+      healthCheckTimeout: 5m
+      minHealthyPercent: 80
+  - name: pool2
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					pool1, err := c.NodePools()[0].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render node pool stack template: %v", err)
+					}
+					for _, expected := range []string{
+						`"CreationPolicy":{"AutoScalingCreationPolicy":{"MinSuccessfulInstancesPercent":"80"}`,
+						`"AutoScalingRollingUpdate":{"MinInstancesInService":"0","MinSuccessfulInstancesPercent":"80","WaitOnResourceSignals":"true"`,
+					} {
+						if !strings.Contains(pool1, expected) {
+							t.Errorf("missing %s in node pool stack template", expected)
+						}
 					}
-					if !unversionedPrivate.LoadBalancer.Enabled() {
-						t.Errorf("unversionedPrivate: it should be enabled as the lb to which controller nodes are added, but it was not: loadBalancer=%+v", unversionedPrivate.LoadBalancer)
+					workerUserdataS3Part := c.NodePools()[0].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					for _, expected := range []string{
+						"ExecStartPre=/opt/bin/wait-for-node-ready",
+						"deadline=$(( $(date +%s) + 300 ))",
+					} {
+						if !strings.Contains(workerUserdataS3Part, expected) {
+							t.Errorf("missing %s in worker userdata", expected)
+						}
 					}
 
-					if diff := cmp.Diff(versionedPublic.LoadBalancer.Subnets, api.Subnets{public1}); diff != "" {
-						t.Errorf("versionedPublic: subnets didn't match: %s", diff)
+					pool2, err := c.NodePools()[1].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render node pool stack template: %v", err)
 					}
-					if !versionedPublic.LoadBalancer.Enabled() {
-						t.Errorf("versionedPublic: it should be enabled as the lb to which controller nodes are added, but it was not: loadBalancer=%+v", versionedPublic.LoadBalancer)
+					if strings.Contains(pool2, "MinSuccessfulInstancesPercent") {
+						t.Error("node pools without `update.rollbackOnFailure` should leave MinSuccessfulInstancesPercent to the CloudFormation default")
 					}
-
-					if diff := cmp.Diff(versionedPrivate.LoadBalancer.Subnets, api.Subnets{private1}); diff != "" {
-						t.Errorf("versionedPrivate: subnets didn't match: %s", diff)
+					if strings.Contains(c.NodePools()[1].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content, "wait-for-node-ready") {
+						t.Error("node pools without `update.rollbackOnFailure` should signal without waiting for the nodes to be Ready")
 					}
-					if !versionedPrivate.LoadBalancer.Enabled() {
-						t.Errorf("versionedPrivate: it should be enabled as the lb to which controller nodes are added, but it was not: loadBalancer=%+v", versionedPrivate.LoadBalancer)
+				},
+			},
+		},
+		{
+			context: "WithNodePoolRollingUpdate",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    autoScalingGroup:
+      minSize: 3
+      maxSize: 5
+    update:
+      rollbackOnFailure: true
+      healthCheckTimeout: 5m
+    rollingUpdate:
+      maxBatchSize: 2
+      minInstancesInService: 3
+      pauseTime: PT10M
+      suspendProcesses:
+      - AZRebalance
+      - ScheduledActions
+  - name: pool2
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					pool1, err := c.NodePools()[0].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render node pool stack template: %v", err)
+					}
+					expected := `"AutoScalingRollingUpdate":{"MinInstancesInService":"3","MinSuccessfulInstancesPercent":"100","WaitOnResourceSignals":"true","SuspendProcesses":["AZRebalance","ScheduledActions"],"MaxBatchSize":"2","PauseTime":"PT10M"}`
+					if !strings.Contains(pool1, expected) {
+						t.Errorf("missing %s in node pool stack template: %s", expected, pool1)
 					}
 
-					if diff := cmp.Diff(versionedPublicAlt.LoadBalancer.Subnets, publicSubnets); diff != "" {
-						t.Errorf("versionedPublicAlt: subnets didn't match: %s", diff)
+					pool2, err := c.NodePools()[1].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render node pool stack template: %v", err)
 					}
-					if !versionedPublicAlt.LoadBalancer.Enabled() {
-						t.Errorf("versionedPublicAlt: it should be enabled as the lb to which controller nodes are added, but it was not: loadBalancer=%+v", versionedPublicAlt.LoadBalancer)
+					expected = `"AutoScalingRollingUpdate":{"MinInstancesInService":"0","WaitOnResourceSignals":"true","MaxBatchSize":"1","PauseTime":"PT15M"}`
+					if !strings.Contains(pool2, expected) {
+						t.Errorf("missing %s in node pool stack template: %s", expected, pool2)
+					}
+				},
+			},
+		},
+		{
+			context: "WithSandboxRuntimes",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.27.3
+runtimeClasses:
+- name: gvisor
+  runtime: gvisor
+worker:
+  nodePools:
+  - name: sandboxed
+    containerRuntime: containerd
+    sandboxRuntimes:
+    - name: gvisor
+  - name: pool2
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					sandboxedUserdata := c.NodePools()[0].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					for _, e := range []string{
+						"Requires=install-sandbox-runtimes.service",
+						"- name: install-sandbox-runtimes.service",
+						"- path: /opt/bin/install-sandbox-runtimes",
+						"url=https://storage.googleapis.com/gvisor/releases/release/20231009/x86_64",
+						"[plugins.\"io.containerd.grpc.v1.cri\".containerd.runtimes.runsc]\n      runtime_type = \"io.containerd.runsc.v1\"",
+						",kube-aws.coreos.com/sandbox-runtime-gvisor=true \\\n",
+					} {
+						if !strings.Contains(sandboxedUserdata, e) {
+							t.Errorf("missing %q in the userdata of the node pool with the sandbox runtime", e)
+						}
+					}
+					if strings.Contains(c.NodePools()[1].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content, "sandbox-runtime") {
+						t.Error("node pools without `sandboxRuntimes` shouldn't install sandbox runtimes")
+					}
+
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					expected := `  - path: /srv/kubernetes/manifests/runtime-classes.yaml
+    content: |
+      apiVersion: node.k8s.io/v1
+      kind: RuntimeClass
+      metadata:
+        name: gvisor
+      handler: runsc
+      scheduling:
+        nodeSelector:
+          kube-aws.coreos.com/sandbox-runtime-gvisor: "true"
+`
+					if !strings.Contains(controllerUserdataS3Part, expected) {
+						t.Errorf("missing the RuntimeClass manifest in controller userdata: expected to contain:\n%s", expected)
+					}
+					if !strings.Contains(controllerUserdataS3Part, `applyall "${mfdir}/runtime-classes.yaml"`) {
+						t.Error("the RuntimeClass manifest isn't applied")
+					}
+				},
+			},
+		},
+		{
+			context: "WithAssetPrefix",
+			configYaml: minimalValidConfigYaml + `
+assetPrefix: team-a/` + kubeAwsSettings.clusterName + `
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					prefix := s3Dir + "/team-a/" + kubeAwsSettings.clusterName
+					assets, err := c.EnsureAllAssetsGenerated()
+					if err != nil {
+						t.Fatalf("failed to list assets: %v", err)
+					}
+					for _, stack := range []string{kubeAwsSettings.clusterName, "control-plane", "etcd"} {
+						a, err := assets.FindAssetByStackAndFileName(stack, "stack.json")
+						if err != nil {
+							t.Fatalf("failed to find asset: %v", err)
+						}
+						if expected := prefix + "/exported/stacks/" + stack + "/stack.json"; a.Key != expected || a.Bucket != s3Bucket {
+							t.Errorf("the stack template of %s should be uploaded to %s in %s, but was %s in %s", stack, expected, s3Bucket, a.Key, a.Bucket)
+						}
 					}
 
-					if diff := cmp.Diff(versionedPrivateAlt.LoadBalancer.Subnets, privateSubnets); diff != "" {
-						t.Errorf("versionedPrivateAlt: subnets didn't match: %s", diff)
+					s3URL, err := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.S3URL()
+					if err != nil {
+						t.Fatalf("failed to get the S3 URL of the controller userdata: %v", err)
 					}
-					if !versionedPrivateAlt.LoadBalancer.Enabled() {
-						t.Errorf("versionedPrivateAlt: it should be enabled as the lb to which controller nodes are added, but it was not: loadBalancer=%+v", versionedPrivateAlt.LoadBalancer)
+					if expected := "s3://" + s3Bucket + "/" + prefix + "/exported/stacks/control-plane/userdata-controller-"; !strings.HasPrefix(s3URL, expected) {
+						t.Errorf("controller nodes should download their userdata from %s*, but was %s", expected, s3URL)
 					}
 
-					if len(addedToCertCommonNames.LoadBalancer.Subnets) != 0 {
-						t.Errorf("addedToCertCommonNames: subnets should be empty but was not: actual=%+v", addedToCertCommonNames.LoadBalancer.Subnets)
+					cpTemplate, err := c.ControlPlane().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render control-plane stack template: %v", err)
 					}
-					if addedToCertCommonNames.LoadBalancer.Enabled() {
-						t.Errorf("addedToCertCommonNames: it should not be enabled as the lb to which controller nodes are added, but it was: loadBalancer=%+v", addedToCertCommonNames.LoadBalancer)
+					if expected := `"arn:aws:s3:::` + s3Bucket + "/" + prefix + `/exported/stacks/control-plane*"`; !strings.Contains(cpTemplate, expected) {
+						t.Errorf("the IAM policy of controller nodes should be scoped to the asset prefix: missing %s", expected)
 					}
 
-					if diff := cmp.Diff(elbOnly.LoadBalancer.Subnets, publicSubnets); diff != "" {
-						t.Errorf("elbOnly: subnets didn't match: %s", diff)
+					etcdTemplate, err := c.Etcd().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render etcd stack template: %v", err)
 					}
-					if !elbOnly.LoadBalancer.Enabled() {
-						t.Errorf("elbOnly: it should be enabled but it was not: loadBalancer=%+v", elbOnly.LoadBalancer)
+					for _, e := range []string{
+						`"s3:prefix":"` + prefix + `/*"`,
+						`"` + s3Bucket + "/" + prefix + `/instances/"`,
+					} {
+						if !strings.Contains(etcdTemplate, e) {
+							t.Errorf("missing %s in the etcd stack template", e)
+						}
 					}
-					if elbOnly.LoadBalancer.ManageELBRecordSet() {
-						t.Errorf("elbOnly: record set should not be managed but it was: loadBalancer=%+v", elbOnly.LoadBalancer)
+				},
+			},
+		},
+		{
+			context: "WithBoundServiceAccountTokensOnKube120",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.20.2
+controller:
+  apiServer:
+    serviceAccountIssuer:
+      url: https://oidc.example.com/it
+  serviceAccount:
+    boundTokens: true
+    extendTokenExpiration: false
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, e := range []string{
+						"- --service-account-extend-token-expiration=false\n",
+						"- --service-account-signing-key-file=/etc/kubernetes/ssl/service-account-key.pem\n",
+					} {
+						if !strings.Contains(controllerUserdataS3Part, e) {
+							t.Errorf("expected \"%s\" to be contained in the controller userdata, but it wasn't", e)
+						}
 					}
-
-					if diff := cmp.Diff(c.ExternalDNSNames(), []string{"api-alt.example.com", "api.example.com", "api.internal.example.com", "registerme.example.com", "v1api.example.com", "v1api.internal.example.com", "v1apialt.example.com", "v1apialt.internal.example.com"}); diff != "" {
-						t.Errorf("unexpected external DNS names: %s", diff)
+					// The apiserver, the controller-manager and the scheduler
+					if n := strings.Count(controllerUserdataS3Part, "- --feature-gates=BoundServiceAccountTokenVolume=true,"); n != 3 {
+						t.Errorf("expected the BoundServiceAccountTokenVolume feature gate to be enabled for the 3 control plane components, but was enabled for %d", n)
+					}
+				},
+			},
+		},
+		{
+			context: "WithBoundServiceAccountTokensOnKube121",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.21.1
+controller:
+  apiServer:
+    serviceAccountIssuer:
+      url: https://oidc.example.com/it
+  serviceAccount:
+    boundTokens: true
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, e := range []string{
+						"BoundServiceAccountTokenVolume",
+						"--service-account-extend-token-expiration",
+					} {
+						if strings.Contains(controllerUserdataS3Part, e) {
+							t.Errorf("expected \"%s\" not to be contained in the controller userdata, but it was", e)
+						}
+					}
+				},
+			},
+		},
+		{
+			context: "WithAssetReplication",
+			configYaml: minimalValidConfigYaml + `
+kmsKeyArn: "arn:aws:kms:` + kubeAwsSettings.region + `:123456789012:key/mrk-1234abcd12ab34cd56ef1234567890ab"
+assetReplication:
+  s3URI: s3://my-dr-bucket/mydir
+  region: us-east-1
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					expected := api.AssetReplication{S3URI: "s3://my-dr-bucket/mydir", Region: api.RegionForName("us-east-1")}
+					if !reflect.DeepEqual(c.AssetReplication, expected) {
+						t.Errorf("assetReplication didn't match: expected=%+v actual=%+v", expected, c.AssetReplication)
+					}
+					if actual := c.ReplicaS3Folders().ClusterExportedStacks().URI(); actual != "s3://my-dr-bucket/mydir/kube-aws/clusters/"+kubeAwsSettings.clusterName+"/exported/stacks" {
+						t.Errorf("unexpected folder of the replicated stack templates: %s", actual)
+					}
+				},
+			},
+		},
+		{
+			context: "WithSelfHostedControlPlane",
+			configYaml: minimalValidConfigYaml + `
+controller:
+  selfHosted: true
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, e := range []string{
+						"- name: pivot-self-hosted-control-plane.service",
+						"ExecStart=/opt/bin/retry 3 /opt/bin/pivot-self-hosted-control-plane",
+						"- path: /opt/bin/pivot-self-hosted-control-plane",
+						`components="kube-controller-manager kube-scheduler"`,
+						// The static pods bootstrapping the self-hosted ones
+						"- path: /etc/kubernetes/manifests/kube-controller-manager.yaml",
+						"- path: /etc/kubernetes/manifests/kube-scheduler.yaml",
+						"- path: /etc/kubernetes/manifests/kube-apiserver.yaml",
+					} {
+						if !strings.Contains(controllerUserdataS3Part, e) {
+							t.Errorf("expected \"%s\" to be contained in the controller userdata, but it wasn't", e)
+						}
+					}
+				},
+			},
+		},
+		{
+			context:    "WithoutSelfHostedControlPlane",
+			configYaml: minimalValidConfigYaml,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					if strings.Contains(c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content, "pivot-self-hosted-control-plane") {
+						t.Error("the control plane shouldn't be self-hosted by default")
+					}
+				},
+			},
+		},
+		{
+			context: "WithNodePoolWarmPool",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    autoScalingGroup:
+      minSize: 1
+      maxSize: 5
+    autoscaling:
+      warmPool:
+        enabled: true
+        minSize: 2
+        maxPreparedCapacity: 4
+        poolState: Hibernated
+  - name: pool2
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					pool1, err := c.NodePools()[0].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render node pool stack template: %v", err)
+					}
+					for _, expected := range []string{
+						`"WorkersWarmPool":{"Properties":{"AutoScalingGroupName":{"Ref":"Workers"},"MaxGroupPreparedCapacity":4,"MinSize":2,"PoolState":"Hibernated"},"Type":"AWS::AutoScaling::WarmPool"}`,
+						`"WorkersWarmPoolLH":{"Properties":{"AutoScalingGroupName":{"Ref":"Workers"},"DefaultResult":"CONTINUE","HeartbeatTimeout":"600","LifecycleHookName":"kube-aws-warm-pool","LifecycleTransition":"autoscaling:EC2_INSTANCE_LAUNCHING"}`,
+						`"HibernationOptions":{"Configured":true}`,
+						`"Encrypted":"true"`,
+						`"Action":"autoscaling:CompleteLifecycleAction"`,
+					} {
+						if !strings.Contains(pool1, expected) {
+							t.Errorf("missing %s in node pool stack template", expected)
+						}
+					}
+					workerUserdataS3Part := c.NodePools()[0].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					for _, expected := range []string{
+						"- name: wait-for-in-service.service",
+						"Requires=wait-for-in-service.service\n        After=wait-for-in-service.service",
+						"- path: /opt/bin/wait-for-in-service",
+						"--lifecycle-hook-name kube-aws-warm-pool",
+					} {
+						if !strings.Contains(workerUserdataS3Part, expected) {
+							t.Errorf("missing %s in worker userdata", expected)
+						}
 					}
 
-					if !reflect.DeepEqual(c.APIEndpoints.ManagedELBLogicalNames(), []string{"APIEndpointElbOnlyELB", "APIEndpointVersionedPrivateAltELB", "APIEndpointVersionedPrivateELB", "APIEndpointVersionedPublicAltELB", "APIEndpointVersionedPublicELB"}) {
-						t.Errorf("unexpected managed ELB logical names: %s", strings.Join(c.APIEndpoints.ManagedELBLogicalNames(), ", "))
+					pool2, err := c.NodePools()[1].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render node pool stack template: %v", err)
+					}
+					for _, unexpected := range []string{"AWS::AutoScaling::WarmPool", "HibernationOptions", "autoscaling:EC2_INSTANCE_LAUNCHING"} {
+						if strings.Contains(pool2, unexpected) {
+							t.Errorf("node pools without `autoscaling.warmPool` should not have %s in the stack template", unexpected)
+						}
+					}
+					if strings.Contains(c.NodePools()[1].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content, "wait-for-in-service") {
+						t.Error("node pools without `autoscaling.warmPool` should start kubelet without waiting to be in service")
 					}
 				},
 			},
 		},
 		{
-			context: "WithNetworkTopologyExplicitSubnets",
-			configYaml: mainClusterYaml + `
-vpc:
-  id: vpc-1a2b3c4d
-internetGateway:
-  id: igw-1a2b3c4d
-# routeTableId must be omitted
-# See https://github.com/kubernetes-incubator/kube-aws/pull/284#issuecomment-275962332
-# routeTableId: rtb-1a2b3c4d
+			context: "WithAutoSubnets",
+			configYaml: kubeAwsSettings.mainClusterYaml() + `
+vpcCIDR: 10.1.0.0/16
 subnets:
-- name: private1
-  availabilityZone: us-west-1a
-  instanceCIDR: "10.0.1.0/24"
-  private: true
-- name: private2
-  availabilityZone: us-west-1b
-  instanceCIDR: "10.0.2.0/24"
-  private: true
-- name: public1
-  availabilityZone: us-west-1a
-  instanceCIDR: "10.0.3.0/24"
-- name: public2
-  availabilityZone: us-west-1b
-  instanceCIDR: "10.0.4.0/24"
+  auto:
+    availabilityZones:
+    - us-west-1a
+    - us-west-1b
+    prefixLength: 20
 controller:
   subnets:
-  - name: private1
-  - name: private2
-  loadBalancer:
-    subnets:
-    - name: public1
-    - name: public2
-    private: false
-etcd:
-  subnets:
-  - name: private1
-  - name: private2
+  - name: private-us-west-1a
+  - name: private-us-west-1b
 worker:
   nodePools:
   - name: pool1
     subnets:
-    - name: public1
-    - name: public2
+    - name: private-us-west-1b
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultExperimentalFeatures,
-				everyPublicSubnetHasRouteToIGW,
-				hasTwoManagedNGWsAndEIPs,
-				func(c *config.Config, t *testing.T) {
-					private1 := api.NewPrivateSubnet("us-west-1a", "10.0.1.0/24")
-					private1.Name = "private1"
-
-					private2 := api.NewPrivateSubnet("us-west-1b", "10.0.2.0/24")
-					private2.Name = "private2"
-
-					public1 := api.NewPublicSubnet("us-west-1a", "10.0.3.0/24")
-					public1.Name = "public1"
-
-					public2 := api.NewPublicSubnet("us-west-1b", "10.0.4.0/24")
-					public2.Name = "public2"
-
-					subnets := api.Subnets{
-						private1,
-						private2,
-						public1,
-						public2,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					subnets := c.ControlPlane().Config.Subnets
+					expected := []struct {
+						name, az, cidr string
+						private        bool
+					}{
+						{"public-us-west-1a", "us-west-1a", "10.1.0.0/20", false},
+						{"public-us-west-1b", "us-west-1b", "10.1.16.0/20", false},
+						{"private-us-west-1a", "us-west-1a", "10.1.32.0/20", true},
+						{"private-us-west-1b", "us-west-1b", "10.1.48.0/20", true},
+					}
+					if len(subnets) != len(expected) {
+						t.Fatalf("expected %d subnets to be carved but was %+v", len(expected), subnets)
+					}
+					for i, e := range expected {
+						s := subnets[i]
+						if s.Name != e.name || s.AvailabilityZone != e.az || s.InstanceCIDR != e.cidr || s.Private != e.private {
+							t.Errorf("subnet %d: expected %+v but was %+v", i, e, s)
+						}
 					}
-					if !reflect.DeepEqual(c.AllSubnets(), subnets) {
-						t.Errorf("Managed subnets didn't match: expected=%v actual=%v", subnets, c.AllSubnets())
+					if n := len(c.ControlPlane().Config.NATGateways()); n != 2 {
+						t.Errorf("expected a NAT gateway per private subnet but was %d", n)
 					}
 
-					publicSubnets := api.Subnets{
-						public1,
-						public2,
+					network, err := c.Network().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render network stack template: %v", err)
 					}
-					importedPublicSubnets := api.Subnets{
-						api.NewPublicSubnetFromFn("us-west-1a", `{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-Public1"}}`),
-						api.NewPublicSubnetFromFn("us-west-1b", `{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-Public2"}}`),
+					for _, e := range []string{`"PublicUsWest1a":`, `"PrivateUsWest1b":`, `"CidrBlock":"10.1.48.0/20"`} {
+						if !strings.Contains(network, e) {
+							t.Errorf("missing %s in network stack template", e)
+						}
 					}
 
-					p := c.NodePools[0]
-					if !reflect.DeepEqual(p.Subnets, importedPublicSubnets) {
-						t.Errorf("Worker subnets didn't match: expected=%v actual=%v", importedPublicSubnets, p.Subnets)
+					pool1, err := c.NodePools()[0].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render node pool stack template: %v", err)
 					}
-
-					privateSubnets := api.Subnets{
-						private1,
-						private2,
+					if !strings.Contains(pool1, `${NetworkStackName}-PrivateUsWest1b`) {
+						t.Error("expected the node pool to be deployed to the carved private subnet")
 					}
-					if !reflect.DeepEqual(c.Controller.Subnets, privateSubnets) {
-						t.Errorf("Controller subnets didn't match: expected=%v actual=%v", privateSubnets, c.Controller.Subnets)
+				},
+			},
+		},
+		{
+			context: "WithControllerKMSPlugin",
+			configYaml: minimalValidConfigYaml + `
+kubernetes:
+  encryptionAtRest:
+    enabled: true
+controller:
+  encryptionAtRest:
+    kms:
+      keyArn: arn:aws:kms:us-west-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab
+      image:
+        repo: example.com/aws-encryption-provider
+        tag: v0.1.0
+      timeout: 5s
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, e := range []string{
+						"- name: configure-kms-encryption-provider.service",
+						"Wants=configure-kms-encryption-provider.service",
+						"- path: /opt/bin/configure-kms-encryption-provider",
+						`print indent "    name: aws-encryption-provider"`,
+						`print indent "    endpoint: unix:///var/run/kmsplugin/socket.sock"`,
+						`print indent "    timeout: 5s"`,
+						// kubernetesVersion defaults to 1.11, which has no --encryption-provider-config
+						"- --experimental-encryption-provider-config=/etc/kubernetes/additional-configs/encryption-config-kms.yaml",
+						"- path: /etc/kubernetes/manifests/kms-plugin.yaml",
+						"image: example.com/aws-encryption-provider:v0.1.0",
+						`- "--key=arn:aws:kms:us-west-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"`,
+						`- "--region=us-west-1"`,
+						`- "--listen=/var/run/kmsplugin/socket.sock"`,
+						"- mountPath: /var/run/kmsplugin",
+					} {
+						if !strings.Contains(controllerUserdataS3Part, e) {
+							t.Errorf("expected \"%s\" to be contained in the controller userdata, but it wasn't", e)
+						}
 					}
-					if !reflect.DeepEqual(c.Controller.LoadBalancer.Subnets, publicSubnets) {
-						t.Errorf("Controller loadbalancer subnets didn't match: expected=%v actual=%v", publicSubnets, c.Controller.LoadBalancer.Subnets)
+					// The v1 API of KMS plugins has no apiVersion in the encryption config
+					if strings.Contains(controllerUserdataS3Part, `print indent "    apiVersion: v2"`) {
+						t.Error("expected the kms provider to use the v1 API for kubernetesVersion older than 1.29, but it didn't")
 					}
-					if diff := cmp.Diff(c.Etcd.Subnets, privateSubnets); diff != "" {
-						t.Errorf("Etcd subnets didn't match: %s", diff)
+
+					cp, err := c.ControlPlane().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render control-plane stack template: %v", err)
+					}
+					if !strings.Contains(cp, `{"Action":["kms:Encrypt","kms:Decrypt","kms:DescribeKey"],"Effect":"Allow","Resource":"arn:aws:kms:us-west-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"}`) {
+						t.Errorf("expected the controller role to be allowed to encrypt with the key of the KMS plugin, but it wasn't: %s", cp)
+					}
+				},
+			},
+		},
+		{
+			context: "WithControllerKMSPluginV2",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.29.2
+kubernetes:
+  encryptionAtRest:
+    enabled: true
+controller:
+  encryptionAtRest:
+    kms:
+      keyArn: arn:aws:kms:us-west-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab
+      image:
+        repo: example.com/aws-encryption-provider
+        tag: v0.1.0
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, e := range []string{
+						`print indent "    apiVersion: v2"`,
+						"- --encryption-provider-config=/etc/kubernetes/additional-configs/encryption-config-kms.yaml",
+					} {
+						if !strings.Contains(controllerUserdataS3Part, e) {
+							t.Errorf("expected \"%s\" to be contained in the controller userdata, but it wasn't", e)
+						}
+					}
+					if strings.Contains(controllerUserdataS3Part, "--experimental-encryption-provider-config") {
+						t.Error("expected the apiserver not to be passed the flag removed in favor of --encryption-provider-config, but it was")
+					}
+				},
+			},
+		},
+		{
+			context: "WithControllerKMSPluginCreatingKey",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.20.2
+kubernetes:
+  encryptionAtRest:
+    enabled: true
+controller:
+  encryptionAtRest:
+    kms:
+      createKey: true
+      image:
+        repo: example.com/aws-encryption-provider
+        tag: v0.1.0
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, e := range []string{
+						"- path: /etc/kubernetes/kms-plugin.yaml.tmpl",
+						`- "--key=__KMS_PLUGIN_KEY_ARN__"`,
+						"key_arn=$(cat /etc/kubernetes/kms-plugin-key-arn)",
+						`sed "s|__KMS_PLUGIN_KEY_ARN__|${key_arn}|g" /etc/kubernetes/kms-plugin.yaml.tmpl > /etc/kubernetes/manifests/kms-plugin.yaml`,
+						"- --encryption-provider-config=/etc/kubernetes/additional-configs/encryption-config-kms.yaml",
+					} {
+						if !strings.Contains(controllerUserdataS3Part, e) {
+							t.Errorf("expected \"%s\" to be contained in the controller userdata, but it wasn't", e)
+						}
+					}
+					if strings.Contains(controllerUserdataS3Part, "--experimental-encryption-provider-config") {
+						t.Error("expected the apiserver not to be passed the flag removed in favor of --encryption-provider-config, but it was")
+					}
+					if strings.Contains(controllerUserdataS3Part, "- path: /etc/kubernetes/manifests/kms-plugin.yaml") {
+						t.Error("expected the static pod manifest of the KMS plugin to be rendered with the ARN of the created key on the node, but it was written as is")
 					}
 
-					for i, s := range c.PrivateSubnets() {
-						if !s.ManageNATGateway() {
-							t.Errorf("NAT gateway for the private subnet #%d should be created by kube-aws but it is not going to be", i)
+					cp, err := c.ControlPlane().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render control-plane stack template: %v", err)
+					}
+					for _, e := range []string{
+						`"KMSPluginKey":{"Type":"AWS::KMS::Key","DeletionPolicy":"Retain","UpdateReplacePolicy":"Retain"`,
+						`"EnableKeyRotation":true`,
+						`{"Action":["kms:Encrypt","kms:Decrypt","kms:DescribeKey"],"Effect":"Allow","Resource":{"Fn::GetAtt":["KMSPluginKey","Arn"]}}`,
+						`{"Fn::Sub":"mkdir -p /etc/kubernetes && echo '${KMSPluginKey.Arn}' >/etc/kubernetes/kms-plugin-key-arn"}`,
+						`"KMSPluginKeyArn":{"Description"`,
+					} {
+						if !strings.Contains(cp, e) {
+							t.Errorf("expected \"%s\" to be contained in the control-plane stack template, but it wasn't: %s", e, cp)
+						}
+					}
+				},
+			},
+		},
+		{
+			context: "WithEncryptionAtRestOnKubernetes113",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.13.5
+kubernetes:
+  encryptionAtRest:
+    enabled: true
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					if !strings.Contains(controllerUserdataS3Part, "- --encryption-provider-config=/etc/kubernetes/additional-configs/encryption-config.yaml") {
+						t.Error("expected the apiserver to use the encryption config via --encryption-provider-config, but it didn't")
+					}
+					if strings.Contains(controllerUserdataS3Part, "--experimental-encryption-provider-config") {
+						t.Error("expected the apiserver not to be passed the flag removed in favor of --encryption-provider-config, but it was")
+					}
+				},
+			},
+		},
+		{
+			context: "WithoutControllerKMSPlugin",
+			configYaml: minimalValidConfigYaml + `
+kubernetes:
+  encryptionAtRest:
+    enabled: true
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					if !strings.Contains(controllerUserdataS3Part, "- --experimental-encryption-provider-config=/etc/kubernetes/additional-configs/encryption-config.yaml") {
+						t.Error("expected the apiserver to use the encryption config with the aescbc provider, but it didn't")
+					}
+					if strings.Contains(controllerUserdataS3Part, "kms-plugin") {
+						t.Error("the KMS plugin shouldn't be deployed by default")
+					}
+				},
+			},
+		},
+		{
+			context: "WithAPIServerCertificateReload",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.20.2
+controller:
+  apiServer:
+    certificateReload:
+      enabled: true
+    clientCAs:
+    - |
+` + indentedTrustedCAPEM("      ") + `
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, e := range []string{
+						"- name: sync-apiserver-certs.service",
+						"- name: sync-apiserver-certs.timer",
+						"OnUnitActiveSec=300",
+						"Wants=sync-apiserver-certs.service",
+						"- path: /opt/bin/sync-apiserver-certs",
+						"/control-plane/apiserver.pem - | base64 -d | gunzip > /work/apiserver.pem",
+						"/control-plane/apiserver-client-cas.pem /work/apiserver-client-cas.pem",
+						"update $workdir/apiserver-client-ca-bundle.pem /etc/kubernetes/ssl/apiserver-client-ca-bundle.pem 0644",
+						"- --client-ca-file=/etc/kubernetes/ssl/apiserver-client-ca-bundle.pem",
+					} {
+						if !strings.Contains(controllerUserdataS3Part, e) {
+							t.Errorf("expected \"%s\" to be contained in the controller userdata, but it wasn't", e)
+						}
+					}
+					for _, e := range []string{
+						"- path: /etc/kubernetes/ssl/apiserver.pem",
+						"- path: /etc/kubernetes/ssl/apiserver-client-cas/0.pem",
+						"- name: apiserver-client-ca-bundle.service",
+					} {
+						if strings.Contains(controllerUserdataS3Part, e) {
+							t.Errorf("expected \"%s\" not to be contained in the controller userdata, but it was", e)
+						}
+					}
+				},
+			},
+		},
+		{
+			context: "WithAmazonVPCCNINetworkPluginType",
+			configYaml: minimalValidConfigYaml + `
+kubernetes:
+  networking:
+    selfHosting:
+      type: amazon-vpc-cni
+      amazonVPCCNIImage:
+        repo: example.com/amazon-k8s-cni
+        tag: v1.5.0
+worker:
+  nodePools:
+  - name: pool1
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					n := c.Kubernetes.Networking
+					if !n.AmazonVPC.Enabled {
+						t.Errorf("expected the Amazon VPC CNI to be enabled by the self-hosting type")
+					}
+					if n.AllocateNodeCIDRs() {
+						t.Errorf("expected node CIDRs not to be allocated for the Amazon VPC CNI")
+					}
+				},
+			},
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, expected := range []string{
+						"- image: example.com/amazon-k8s-cni:v1.5.0",
+						`ensuredelete "${mfdir}/canal.yaml" "${mfdir}/flannel.yaml"`,
+						`applyall "${mfdir}/aws-k8s-cni.yaml"`,
+						"--max-pods=$$(/opt/bin/aws-k8s-cni-max-pods)",
+					} {
+						if !strings.Contains(controllerUserdataS3Part, expected) {
+							t.Errorf("expected the controller userdata to contain %s, but it didn't", expected)
 						}
+					}
 
-						if s.ManageRouteToInternet() {
-							t.Errorf("Route to IGW shouldn't be created for a private subnet: %v", s)
+					workerUserdataS3Part := c.NodePools()[0].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					for _, expected := range []string{
+						"--max-pods=$$(/opt/bin/aws-k8s-cni-max-pods)",
+						"- path: /opt/bin/aws-k8s-cni-max-pods",
+					} {
+						if !strings.Contains(workerUserdataS3Part, expected) {
+							t.Errorf("expected the worker userdata to contain %s, but it didn't", expected)
+						}
+					}
+
+					for name, render := range map[string]func() (string, error){
+						"control-plane": c.ControlPlane().RenderStackTemplateAsString,
+						"node-pool":     c.NodePools()[0].RenderStackTemplateAsString,
+					} {
+						stackTemplate, err := render()
+						if err != nil {
+							t.Fatalf("failed to render %s stack template: %v", name, err)
+						}
+						if expected := `"ec2:AssignPrivateIpAddresses","ec2:UnassignPrivateIpAddresses"`; !strings.Contains(stackTemplate, expected) {
+							t.Errorf("expected the %s stack template to contain %s, but it didn't", name, expected)
+						}
+					}
+
+					networkStackTemplate, err := c.Network().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render network stack template: %v", err)
+					}
+					for _, expected := range []string{
+						`"SecurityGroupControllerToWorker":{"Properties":{"FromPort":"0"`,
+						`"SecurityGroupWorkerIngressFromWorkerToIPAMD":{"Properties":{"FromPort":61678,`,
+						`"SecurityGroupWorkerIngressFromControllerToIPAMD":{"Properties":{"FromPort":61678,`,
+						`"SecurityGroupControllerIngressFromWorkerToIPAMD":{"Properties":{"FromPort":61678,`,
+						`"SecurityGroupControllerIngressFromControllerToIPAMD":{"Properties":{"FromPort":61678,`,
+					} {
+						if !strings.Contains(networkStackTemplate, expected) {
+							t.Errorf("expected the network stack template to contain %s, but it didn't", expected)
+						}
+					}
+				},
+			},
+		},
+		{
+			context: "WithEtcdSnapshotIntervalAndRetention",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  snapshot:
+    automated: true
+    interval: 1h
+    retentionInDays: 7
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					etcdUserdataS3Part := c.Etcd().UserData["Etcd"].Parts[api.USERDATA_S3].Asset.Content
+					if expected := "OnUnitInactiveSec=3600sec"; !strings.Contains(etcdUserdataS3Part, expected) {
+						t.Errorf("expected the etcd userdata to contain %s, but it didn't", expected)
+					}
+
+					etcdStackTemplate, err := c.Etcd().RenderStackTemplateAsString()
+					if err != nil {
+						t.Errorf("failed to render etcd stack template: %v", err)
+					}
+					for _, expected := range []string{
+						`"ETCDADM_SNAPSHOTS_HISTORY_S3_URI='",{"Fn::Join":["",["s3://",{"Ref":"EtcdSnapshotsHistoryBucket"},"/history"]]}`,
+						`"EtcdSnapshotsHistoryBucket":{"Type":"AWS::S3::Bucket","DeletionPolicy":"Retain"`,
+						`{"Id":"ExpireEtcdSnapshots","Prefix":"history/","Status":"Enabled","ExpirationInDays":7}`,
+					} {
+						if !strings.Contains(etcdStackTemplate, expected) {
+							t.Errorf("expected the etcd stack template to contain %s, but it didn't", expected)
+						}
+					}
+				},
+			},
+		},
+		{
+			context: "WithEtcdSnapshotWithoutRetention",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  snapshot:
+    automated: true
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					etcdStackTemplate, err := c.Etcd().RenderStackTemplateAsString()
+					if err != nil {
+						t.Errorf("failed to render etcd stack template: %v", err)
+					}
+					if strings.Contains(etcdStackTemplate, "EtcdSnapshotsHistoryBucket") {
+						t.Error("expected no bucket for the history of etcd snapshots without the retention, but there was")
+					}
+				},
+			},
+		},
+		{
+			context: "WithNodeDrainerForWorkerNodePool",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    nodeDrainer:
+      enabled: true
+      drainTimeout: 10
+  - name: pool2
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, e := range []string{
+						`"${mfdir}/kube-node-drainer-ds.yaml"`,
+						`"${mfdir}/kube-node-drainer-asg-status-updater-de.yaml"`,
+					} {
+						if !strings.Contains(controllerUserdataS3Part, e) {
+							t.Errorf("expected \"%s\" to be contained in the controller userdata, but it wasn't", e)
+						}
+					}
+
+					cp, err := c.ControlPlane().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render control-plane stack template: %v", err)
+					}
+					if !strings.Contains(cp, `"autoscaling:DescribeAutoScalingGroups"`) {
+						t.Errorf("expected controller nodes to be allowed to find the nodes to be drained, but they weren't: %s", cp)
+					}
+					if strings.Contains(cp, "NodeDrainerLH") {
+						t.Errorf("expected controller nodes not to be drained, but they were: %s", cp)
+					}
+
+					pool1, err := c.NodePools()[0].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render node pool stack template: %v", err)
+					}
+					for _, e := range []string{
+						`"WorkersNodeDrainerLH":{"Properties":{"AutoScalingGroupName":{"Ref":"Workers"},"DefaultResult":"CONTINUE","HeartbeatTimeout":"600"`,
+						`"autoscaling:CompleteLifecycleAction"`,
+					} {
+						if !strings.Contains(pool1, e) {
+							t.Errorf("expected \"%s\" to be contained in the node pool stack template, but it wasn't: %s", e, pool1)
+						}
+					}
+
+					pool2, err := c.NodePools()[1].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render node pool stack template: %v", err)
+					}
+					if strings.Contains(pool2, "NodeDrainerLH") {
+						t.Errorf("expected the nodes of pool2 not to be drained, but they were: %s", pool2)
+					}
+				},
+			},
+		},
+		{
+			context: "WithIRSA",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.20.2
+kubernetes:
+  oidc:
+    irsa:
+      enabled: true
+      s3Bucket: my-oidc-bucket
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					issuer := c.Controller.APIServer.ServiceAccountIssuer
+					if issuer.URL != "https://my-oidc-bucket.s3.us-west-1.amazonaws.com/it" || issuer.Discovery.S3Bucket != "my-oidc-bucket" || !issuer.OIDCProvider.Create {
+						t.Errorf("expected the service account issuer to be derived from kubernetes.oidc.irsa, but got: %+v", issuer)
+					}
+				},
+			},
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, e := range []string{
+						"--service-account-issuer=https://my-oidc-bucket.s3.us-west-1.amazonaws.com/it",
+						"--service-account-signing-key-file=/etc/kubernetes/ssl/service-account-key.pem",
+						"--api-audiences=https://my-oidc-bucket.s3.us-west-1.amazonaws.com/it",
+						`"${mfdir}/pod-identity-webhook-mwc.yaml"`,
+						"image: amazon/amazon-eks-pod-identity-webhook:v0.5.0",
+						"- --token-audience=sts.amazonaws.com",
+						"- --token-expiration=86400",
+						"- --aws-default-region=us-west-1",
+					} {
+						if !strings.Contains(controllerUserdataS3Part, e) {
+							t.Errorf("expected \"%s\" to be contained in the controller userdata, but it wasn't", e)
+						}
+					}
+
+					cp, err := c.ControlPlane().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render control-plane stack template: %v", err)
+					}
+					e := `"ServiceAccountIssuerOIDCProvider":{"Type":"AWS::IAM::OIDCProvider","Properties":{"Url":"https://my-oidc-bucket.s3.us-west-1.amazonaws.com/it","ClientIdList":["sts.amazonaws.com"]}}`
+					if !strings.Contains(cp, e) {
+						t.Errorf("expected \"%s\" to be contained in the control-plane stack template, but it wasn't: %s", e, cp)
+					}
+				},
+			},
+		},
+		{
+			context: "WithIRSAWithoutPodIdentityWebhook",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.20.2
+kubernetes:
+  oidc:
+    irsa:
+      enabled: true
+      s3Bucket: my-oidc-bucket
+      cloudFrontDomainName: d111111abcdef8.cloudfront.net
+      podIdentityWebhook:
+        enabled: false
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					if !strings.Contains(controllerUserdataS3Part, "--service-account-issuer=https://d111111abcdef8.cloudfront.net/it") {
+						t.Errorf("expected the issuer to be served via CloudFront, but it wasn't")
+					}
+					if strings.Contains(controllerUserdataS3Part, "pod-identity-webhook") {
+						t.Errorf("expected the pod identity webhook not to be installed, but it was")
+					}
+				},
+			},
+		},
+		{
+			context:    "WithClusterAutoscalerEnabledByNodePoolInDefaultClusterYaml",
+			configYaml: defaultClusterYamlWithAutoscaledNodePool,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					if !c.Addons.ClusterAutoscaler.Enabled {
+						t.Error("expected cluster-autoscaler to be turned on by the node pool in the default cluster.yaml, but it wasn't")
+					}
+				},
+			},
+		},
+		{
+			context: "WithClusterAutoscalerEnabledByNodePool",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    autoscaling:
+      clusterAutoscaler:
+        enabled: true
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					if !c.Addons.ClusterAutoscaler.Enabled {
+						t.Errorf("expected the cluster-autoscaler add-on to be enabled by the node pool, but it wasn't")
+					}
+				},
+			},
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, e := range []string{
+						`"${mfdir}/cluster-autoscaler-de.yaml"`,
+						`"${rbac}/cluster-autoscaler.yaml"`,
+						`        apiVersion: apps/v1
+        kind: Deployment
+        metadata:
+          name: cluster-autoscaler
+`,
+						`              serviceAccountName: cluster-autoscaler
+`,
+						`              nodeSelector:
+                node-role.kubernetes.io/master: ""
+`,
+						"- --expander=least-waste",
+						"- --node-group-auto-discovery=asg:tag=k8s.io/cluster-autoscaler/enabled,kubernetes.io/cluster/it",
+						"value: us-west-1",
+					} {
+						if !strings.Contains(controllerUserdataS3Part, e) {
+							t.Errorf("expected \"%s\" to be contained in the controller userdata, but it wasn't", e)
+						}
+					}
+					for _, e := range []string{"eks.amazonaws.com/role-arn", "iam.amazonaws.com/role: it-IAMRoleClusterAutoscaler"} {
+						if strings.Contains(controllerUserdataS3Part, e) {
+							t.Errorf("expected \"%s\" not to be contained in the controller userdata, but it was", e)
+						}
+					}
+
+					cp, err := c.ControlPlane().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render control-plane stack template: %v", err)
+					}
+					if strings.Contains(cp, "IAMRoleClusterAutoscaler") {
+						t.Errorf("expected the dedicated IAM role not to be created without IRSA, kube2iam or kiam, but it was")
+					}
+					if !strings.Contains(cp, `"autoscaling:DescribeLaunchConfigurations","ec2:DescribeLaunchTemplateVersions"]`) {
+						t.Errorf("expected the IAM role of controller nodes to be granted the permissions of cluster-autoscaler, but it wasn't: %s", cp)
+					}
+				},
+			},
+		},
+		{
+			context: "WithClusterAutoscalerIAMRoleViaKube2Iam",
+			configYaml: minimalValidConfigYaml + `
+addons:
+  clusterAutoscaler:
+    enabled: true
+    expander: priority
+experimental:
+  kube2IamSupport:
+    enabled: true
+worker:
+  nodePools:
+  - name: pool1
+    autoscaling:
+      clusterAutoscaler:
+        enabled: true
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, e := range []string{
+						"- --expander=priority",
+						`                iam.amazonaws.com/role: it-IAMRoleClusterAutoscaler
+`,
+					} {
+						if !strings.Contains(controllerUserdataS3Part, e) {
+							t.Errorf("expected \"%s\" to be contained in the controller userdata, but it wasn't", e)
+						}
+					}
+
+					cp, err := c.ControlPlane().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render control-plane stack template: %v", err)
+					}
+					for _, e := range []string{
+						`"Condition":{"Null":{"autoscaling:ResourceTag/kubernetes.io/cluster/it":"false","autoscaling:ResourceTag/k8s.io/cluster-autoscaler/enabled":"false"}}`,
+						`"IAMRoleClusterAutoscaler":{"Properties":{"AssumeRolePolicyDocument":{"Statement":[{"Action":["sts:AssumeRole"],"Effect":"Allow","Principal":{"AWS":[{"Fn::GetAtt":["IAMRoleController","Arn"]}]}}]`,
+						`"RoleName":"it-IAMRoleClusterAutoscaler","ManagedPolicyArns":[{"Ref":"IAMManagedPolicyClusterAutoscaler"}]`,
+					} {
+						if !strings.Contains(cp, e) {
+							t.Errorf("expected \"%s\" to be contained in the control-plane stack template, but it wasn't: %s", e, cp)
 						}
 					}
+					if strings.Contains(cp, `"autoscaling:DescribeLaunchConfigurations","ec2:DescribeLaunchTemplateVersions"]`) {
+						t.Errorf("expected the IAM role of controller nodes not to be granted the permissions of cluster-autoscaler, but it was")
+					}
 				},
 			},
 		},
 		{
-			context: "WithNetworkTopologyImplicitSubnets",
-			configYaml: mainClusterYaml + `
+			context: "WithClusterAutoscalerIAMRoleViaIRSA",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.20.2
+kubernetes:
+  oidc:
+    irsa:
+      enabled: true
+      s3Bucket: my-oidc-bucket
+worker:
+  nodePools:
+  - name: pool1
+    autoscaling:
+      clusterAutoscaler:
+        enabled: true
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, e := range []string{
+						`sed "s|__ACCOUNT_ID__|${ca_account_id}|g" "${mfdir}/cluster-autoscaler-sa.yaml.tmpl" > "${mfdir}/cluster-autoscaler-sa.yaml"`,
+						"eks.amazonaws.com/role-arn: arn:aws:iam::__ACCOUNT_ID__:role/it-IAMRoleClusterAutoscaler",
+					} {
+						if !strings.Contains(controllerUserdataS3Part, e) {
+							t.Errorf("expected \"%s\" to be contained in the controller userdata, but it wasn't", e)
+						}
+					}
+					if strings.Contains(controllerUserdataS3Part, "iam.amazonaws.com/role: it-IAMRoleClusterAutoscaler") {
+						t.Errorf("expected cluster-autoscaler not to assume the IAM role via kube2iam or kiam, but it did")
+					}
+
+					cp, err := c.ControlPlane().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render control-plane stack template: %v", err)
+					}
+					e := `"IAMRoleClusterAutoscaler":{"Properties":{"AssumeRolePolicyDocument":{"Statement":[{"Action":["sts:AssumeRoleWithWebIdentity"],"Effect":"Allow","Principal":{"Federated":{"Ref":"ServiceAccountIssuerOIDCProvider"}},` +
+						`"Condition":{"StringEquals":{"my-oidc-bucket.s3.us-west-1.amazonaws.com/it:aud":"sts.amazonaws.com","my-oidc-bucket.s3.us-west-1.amazonaws.com/it:sub":"system:serviceaccount:kube-system:cluster-autoscaler"}}}]`
+					if !strings.Contains(cp, e) {
+						t.Errorf("expected \"%s\" to be contained in the control-plane stack template, but it wasn't: %s", e, cp)
+					}
+				},
+			},
+		},
+		{
+			context: "WithAMIOfMainClusterInAWSPartition",
+			configYaml: minimalValidConfigYaml + `
+amiId: ami-12345678
+worker:
+  nodePools:
+  - name: pool1
+  - name: pool2
+    amiId: ami-87654321
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					if ami := c.NodePools()[0].NodePoolConfig.AMI; ami == "" || ami == "ami-12345678" {
+						t.Errorf("expected the node pool to run the latest AMI of the release channel rather than the AMI of the main cluster, but was \"%s\"", ami)
+					}
+					if ami := c.NodePools()[1].NodePoolConfig.AMI; ami != "ami-87654321" {
+						t.Errorf("expected the node pool to run its own AMI, but was %s", ami)
+					}
+				},
+			},
+		},
+		{
+			context: "WithGovCloudRegion",
+			configYaml: kubeAwsSettings.withRegion("us-gov-west-1").minimumValidClusterYaml() + `
+amiId: ami-12345678
+kubernetes:
+  networking:
+    amazonVPC:
+      enabled: true
+    selfHosting:
+      amazonVPCCNIImage:
+        repo: 013241004608.dkr.ecr.us-gov-west-1.amazonaws.com/amazon-k8s-cni
+elasticFileSystemId: fs-12345678
+worker:
+  nodePools:
+  - name: pool1
+    spotFleet:
+      targetCapacity: 1
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					if ami := c.NodePools()[0].NodePoolConfig.AMI; ami != "ami-12345678" {
+						t.Errorf("expected the node pool to inherit the AMI of the main cluster, but was %s", ami)
+					}
+					for _, s := range []*model.Stack{c.ControlPlane(), c.Etcd(), c.Network(), c.NodePools()[0]} {
+						stackTemplate, err := s.RenderStackTemplateAsString()
+						if err != nil {
+							t.Fatalf("failed to render %s stack template: %v", s.StackName, err)
+						}
+						if strings.Contains(stackTemplate, "arn:aws:") {
+							t.Errorf("expected the %s stack template not to contain ARNs in the aws partition, but it did: %s", s.StackName, stackTemplate)
+						}
+					}
+
+					cp, err := c.ControlPlane().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render control-plane stack template: %v", err)
+					}
+					for _, e := range []string{
+						`"Resource":"arn:aws-us-gov:ec2:*:*:network-interface/*"`,
+					} {
+						if !strings.Contains(cp, e) {
+							t.Errorf("expected \"%s\" to be contained in the control-plane stack template, but it wasn't: %s", e, cp)
+						}
+					}
+
+					pool, err := c.NodePools()[0].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render node pool stack template: %v", err)
+					}
+					e := `"IamFleetRole":{"Fn::Sub":"arn:${AWS::Partition}:iam::${AWS::AccountId}:role/aws-ec2-spot-fleet-tagging-role"}`
+					if !strings.Contains(pool, e) {
+						t.Errorf("expected \"%s\" to be contained in the node pool stack template, but it wasn't: %s", e, pool)
+					}
+				},
+			},
+		},
+		{
+			context: "WithChinaRegion",
+			configYaml: kubeAwsSettings.withRegion("cn-north-1").minimumValidClusterYaml() + `
+amiId: ami-12345678
+elasticFileSystemId: fs-12345678
+worker:
+  nodePools:
+  - name: pool1
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					cp, err := c.ControlPlane().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render control-plane stack template: %v", err)
+					}
+					for _, e := range []string{
+						`"Principal":{"Service":["ec2.amazonaws.com.cn"]}`,
+					} {
+						if !strings.Contains(cp, e) {
+							t.Errorf("expected \"%s\" to be contained in the control-plane stack template, but it wasn't: %s", e, cp)
+						}
+					}
+					workerUserdataS3Part := c.NodePools()[0].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					if !strings.Contains(workerUserdataS3Part, ".efs.cn-north-1.amazonaws.com.cn:/ /efs") {
+						t.Errorf("expected the EFS to be mounted via the endpoint in the China partition, but it wasn't")
+					}
+				},
+			},
+		},
+		{
+			context: "WithFlannelWithoutWindowsNodePools",
+			configYaml: minimalValidConfigYaml + `
+kubernetes:
+  networking:
+    selfHosting:
+      type: flannel
+worker:
+  nodePools:
+  - name: pool1
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					if strings.Contains(controllerUserdataS3Part, "kube-aws.coreos.com/flannel-vxlan-port") {
+						t.Errorf("expected the flannel pods not to be rolled without windows nodes, but they were: %s", controllerUserdataS3Part)
+					}
+
+					network, err := c.Network().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render the network stack template: %v", err)
+					}
+					if !strings.Contains(network, `"FromPort":8472`) || strings.Contains(network, `"FromPort":4789`) {
+						t.Errorf("expected flannel to use the default VXLAN port alone without windows nodes, but it didn't: %s", network)
+					}
+				},
+			},
+		},
+		{
+			context: "WithWindowsNodePool",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.16.8
+kubernetes:
+  networking:
+    selfHosting:
+      type: flannel
+worker:
+  nodePools:
+  - name: pool1
+    platform: windows
+    instanceType: m5.large
+    nodeLabels:
+      role: windows
+  - name: pool2
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					pool1, err := c.NodePools()[0].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render the stack template of pool1: %v", err)
+					}
+					for _, expected := range []string{
+						`"ImageId":"ami-0e9542fa35cdde6e4"`,
+						`"DeviceName":"/dev/sda1"`,
+						`"<powershell>"`,
+						`Read-S3Object`,
+					} {
+						if !strings.Contains(pool1, expected) {
+							t.Errorf("expected the stack template of pool1 to contain %s, but it didn't: %s", expected, pool1)
+						}
+					}
+					if strings.Contains(pool1, "coreos-cloudinit") {
+						t.Errorf("expected the userdata of windows nodes not to contain cloud-config, but it did: %s", pool1)
+					}
+
+					pool1UserdataS3Part := c.NodePools()[0].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					for _, expected := range []string{
+						`--register-with-taints=os=windows:NoSchedule`,
+						// Kubelet 1.16 or greater refuses to register the node with the labels in the kubernetes.io namespace
+						`"--node-labels=node.kubernetes.io/role=node,node.kubernetes.io/node-pool=pool1,role=windows",`,
+						`--pod-infra-container-image=mcr.microsoft.com/k8s/core/pause:1.2.0`,
+						`"VNI": 4096`,
+						`Send-CFNResourceSignal`,
+					} {
+						if !strings.Contains(pool1UserdataS3Part, expected) {
+							t.Errorf("expected the userdata of pool1 to contain %s, but it didn't: %s", expected, pool1UserdataS3Part)
+						}
+					}
+					if strings.Contains(pool1UserdataS3Part, "node-role.kubernetes.io/") || strings.Contains(pool1UserdataS3Part, "=kubernetes.io/role=node") {
+						t.Errorf("expected windows nodes not to register themselves with the node role labels, but they did: %s", pool1UserdataS3Part)
+					}
+
+					pool2, err := c.NodePools()[1].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render the stack template of pool2: %v", err)
+					}
+					if strings.Contains(pool2, "ami-0e9542fa35cdde6e4") || !strings.Contains(pool2, `"DeviceName":"/dev/xvda"`) {
+						t.Errorf("expected pool2 to run linux, but it didn't: %s", pool2)
+					}
+
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, expected := range []string{
+						`"VNI": 4096`,
+						`"Port": 4789`,
+						`beta.kubernetes.io/os: linux`,
+						`kube-aws.coreos.com/flannel-vxlan-port: "4789"`,
+						`ExecStart=/opt/bin/label-node-roles`,
+						`if nodes=$(kubectl get nodes -l 'node.kubernetes.io/node-pool,!node-role.kubernetes.io/node' -o json); then`,
+						`kubectl label --overwrite node ${node} kubernetes.io/role=node node-role.kubernetes.io/node= node-role.kubernetes.io/${pool}=`,
+					} {
+						if !strings.Contains(controllerUserdataS3Part, expected) {
+							t.Errorf("expected the controller userdata to contain %s, but it didn't: %s", expected, controllerUserdataS3Part)
+						}
+					}
+
+					network, err := c.Network().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render the network stack template: %v", err)
+					}
+					for _, expected := range []string{
+						`"FromPort":3389`,
+						// Both the VXLAN ports are allowed while the flannel pods are rolled onto the one for Windows
+						`"SecurityGroupWorkerIngressFromWorkerToFlannel":{"Properties":{"FromPort":8472,`,
+						`"SecurityGroupWorkerIngressFromWorkerToWindowsFlannel":{"Properties":{"FromPort":4789,`,
+						`"SecurityGroupWorkerIngressFromControllerToFlannel":{"Properties":{"FromPort":8472,`,
+						`"SecurityGroupWorkerIngressFromControllerToWindowsFlannel":{"Properties":{"FromPort":4789,`,
+						`"SecurityGroupWorkerIngressFromFlannelToController":{"Properties":{"FromPort":8472,`,
+						`"SecurityGroupWorkerIngressFromWindowsFlannelToController":{"Properties":{"FromPort":4789,`,
+						`"SecurityGroupControllerIngressFromControllerToFlannel":{"Properties":{"FromPort":8472,`,
+						`"SecurityGroupControllerIngressFromControllerToWindowsFlannel":{"Properties":{"FromPort":4789,`,
+					} {
+						if !strings.Contains(network, expected) {
+							t.Errorf("expected the network stack template to contain %s, but it didn't: %s", expected, network)
+						}
+					}
+				},
+			},
+		},
+		{
+			context: "WithWindowsNodePoolOnKubernetes115",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.15.11
+kubernetes:
+  networking:
+    selfHosting:
+      type: flannel
+worker:
+  nodePools:
+  - name: pool1
+    platform: windows
+    instanceType: m5.large
+    amiId: ami-0123456789abcdef0
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					pool1UserdataS3Part := c.NodePools()[0].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					if expected := `"--node-labels=kubernetes.io/role=node,node-role.kubernetes.io/node=,node-role.kubernetes.io/pool1=",`; !strings.Contains(pool1UserdataS3Part, expected) {
+						t.Errorf("expected the userdata of pool1 to contain %s, but it didn't: %s", expected, pool1UserdataS3Part)
+					}
+					if controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content; strings.Contains(controllerUserdataS3Part, "ExecStart=/opt/bin/label-node-roles") {
+						t.Error("expected no node role labeler for the windows nodes registering themselves with the node role labels")
+					}
+				},
+			},
+		},
+		{
+			context: "WithBottlerocketNodePool",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.20.2
+kubeAwsPlugins:
+  awsIamAuthenticator:
+    enabled: true
+worker:
+  nodePools:
+  - name: pool1
+    os: bottlerocket
+    bottlerocket:
+      adminContainer:
+        enabled: true
+    nodeLabels:
+      role: bottlerocket
+    taints:
+    - key: dedicated
+      value: bottlerocket
+      effect: NoSchedule
+  - name: pool2
+`,
+			plugins: []*api.Plugin{
+				{Metadata: api.Metadata{Name: "aws-iam-authenticator", Version: "0.1"}},
+			},
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					pool1, err := c.NodePools()[0].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render the stack template of pool1: %v", err)
+					}
+					for _, expected := range []string{
+						`"ImageId":"ami-0b0770c7fd236f2f3"`,
+						`"DeviceName":"/dev/xvdb"`,
+						`[settings.kubernetes]`,
+						`[settings.kubernetes.node-labels]`,
+						`\"node.kubernetes.io/role\" = \"node\"\n\"node.kubernetes.io/node-pool\" = \"pool1\"`,
+						`\"role\" = \"bottlerocket\"`,
+						`\"dedicated\" = \"bottlerocket:NoSchedule\"`,
+						`[settings.host-containers.admin]\nenabled = true`,
+						`[settings.host-containers.control]\nenabled = true`,
+					} {
+						if !strings.Contains(pool1, expected) {
+							t.Errorf("expected the stack template of pool1 to contain %s, but it didn't: %s", expected, pool1)
+						}
+					}
+					if strings.Contains(pool1, "coreos-cloudinit") {
+						t.Errorf("expected the userdata of bottlerocket nodes not to contain cloud-config, but it did: %s", pool1)
+					}
+					if strings.Contains(pool1, "node-role.kubernetes.io/") {
+						t.Errorf("expected bottlerocket nodes not to register themselves with the node role labels, but they did: %s", pool1)
+					}
+					if controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content; !strings.Contains(controllerUserdataS3Part, "ExecStart=/opt/bin/label-node-roles") {
+						t.Error("expected controllers to label bottlerocket nodes with their node roles, but they didn't")
+					}
+					if _, ok := c.NodePools()[0].UserData["Worker"].Parts[api.USERDATA_S3]; ok {
+						t.Error("expected the userdata of bottlerocket nodes not to be uploaded to S3, but it was")
+					}
+
+					pool2, err := c.NodePools()[1].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render the stack template of pool2: %v", err)
+					}
+					if strings.Contains(pool2, "ami-0b0770c7fd236f2f3") || !strings.Contains(pool2, `"DeviceName":"/dev/xvda"`) {
+						t.Errorf("expected pool2 to run flatcar, but it didn't: %s", pool2)
+					}
+				},
+			},
+		},
+		{
+			context: "WithNodePoolPodCIDRRangesExcludedFromControllerNodes",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    podCIDRRange: 10.2.128.0/18
+  - name: pool2
+    podCIDRRange: 10.2.64.0/20
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					// Controller nodes aren't labeled with the node pools, hence assigned pod CIDRs from podCIDR excluding every podCIDRRange
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, expected := range []string{
+						`      podcidr="10.2.0.0/16"`,
+						`        "node-role.kubernetes.io/pool1 10.2.128.0/18"`,
+						`        "node-role.kubernetes.io/pool2 10.2.64.0/20"`,
+						`          pool_cidrs+=(${pool_range#* })`,
+						`(keys[]), (.["node.kubernetes.io/node-pool"] // empty | "node-role.kubernetes.io/" + .)`,
+						`            cidr=$(next_free ${podcidr} "${pool_cidrs[@]}")`,
+					} {
+						if !strings.Contains(controllerUserdataS3Part, expected) {
+							t.Errorf("missing the exclusion of the node pool pod CIDR ranges for controller nodes in controller userdata: %s", expected)
+						}
+					}
+					if strings.Contains(controllerUserdataS3Part, `"node-role.kubernetes.io/master `) {
+						t.Error("controller nodes must not be assigned pod CIDRs from the range of any node pool")
+					}
+					if strings.Contains(controllerUserdataS3Part, "ExecStart=/opt/bin/label-node-roles") {
+						t.Error("expected no node role labeler without windows or bottlerocket node pools")
+					}
+				},
+			},
+		},
+		{
+			context: "WithoutKubeletConfigFile",
+			configYaml: minimalValidConfigYaml + `
+kubelet:
+  systemReserved:
+    cpu: 100m
+worker:
+  nodePools:
+  - name: pool1
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					workerUserdataS3Part := c.NodePools()[0].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					for role, userdata := range map[string]string{"controller": controllerUserdataS3Part, "worker": workerUserdataS3Part} {
+						if strings.Contains(userdata, "kubelet-config.yaml") {
+							t.Errorf("kubelet in %s userdata shouldn't be configured via a config file by default", role)
+						}
+						kubeletFlags := kubeletFlagsIn(userdata)
+						for _, flag := range []string{"--pod-manifest-path=/etc/kubernetes/manifests", "--cluster-dns=10.3.0.10", "--cluster-domain=cluster.local", "--system-reserved=cpu=100m"} {
+							if !strings.Contains(kubeletFlags, flag) {
+								t.Errorf("missing %s flag for kubelet in %s userdata", flag, role)
+							}
+						}
+					}
+					if !strings.Contains(workerUserdataS3Part, "--tls-cert-file=/etc/kubernetes/ssl/worker.pem") {
+						t.Error("missing --tls-cert-file flag for kubelet in worker userdata")
+					}
+				},
+			},
+		},
+		{
+			context: "WithAssumeRoles",
+			configYaml: minimalValidConfigYaml + `
+assumeRoles:
+  s3:
+    roleARN: arn:aws:iam::123456789012:role/kube-aws-assets
+  route53:
+    roleARN: arn:aws:iam::123456789012:role/kube-aws-dns
+    externalID: test-cluster
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					expected := api.AssumeRoles{
+						S3:      api.AssumeRole{RoleARN: "arn:aws:iam::123456789012:role/kube-aws-assets"},
+						Route53: api.AssumeRole{RoleARN: "arn:aws:iam::123456789012:role/kube-aws-dns", ExternalID: "test-cluster"},
+					}
+					if !reflect.DeepEqual(c.AssumeRoles, expected) {
+						t.Errorf("unexpected assumeRoles: expected=%+v, actual=%+v", expected, c.AssumeRoles)
+					}
+				},
+			},
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					cp, err := c.ControlPlane().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render the control-plane stack template: %v", err)
+					}
+					// The record set is created via the assumed role as CloudFormation can't create it in the hosted zone of another account
+					if strings.Contains(cp, `"AWS::Route53::RecordSet"`) {
+						t.Errorf("expected the control-plane stack template not to contain the record set, but it did: %s", cp)
+					}
+					if !strings.Contains(cp, `"APIEndpointPublicELBDNSName":{`) {
+						t.Errorf("expected the control-plane stack template to output the DNS name of the load balancer, but it didn't: %s", cp)
+					}
+				},
+			},
+		},
+		{
+			context: "WithNodePoolBootstrapTaint",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    bootstrapTaint:
+      enabled: true
+    taints:
+    - key: dedicated
+      value: search
+      effect: NoSchedule
+  - name: pool2
+    bootstrapTaint:
+      enabled: true
+      key: example.com/bootstrapping
+  - name: pool3
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					pool1Userdata := c.NodePools()[0].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					if !strings.Contains(pool1Userdata, "--register-with-taints=dedicated=search:NoSchedule,node.kube-aws.io/uninitialized=:PreferNoSchedule\\\n") {
+						t.Errorf("missing the bootstrap taint in pool1 userdata: %s", kubeletFlagsIn(pool1Userdata))
+					}
+					if !strings.Contains(pool1Userdata, "- name: remove-bootstrap-taint.service") {
+						t.Error("missing remove-bootstrap-taint.service in pool1 userdata")
+					}
+					if !strings.Contains(pool1Userdata, "$kctl taint nodes $(hostname) node.kube-aws.io/uninitialized:PreferNoSchedule-;") {
+						t.Error("missing the command to remove the bootstrap taint in pool1 userdata")
+					}
+
+					pool2Userdata := c.NodePools()[1].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					if !strings.Contains(pool2Userdata, "--register-with-taints=example.com/bootstrapping=:PreferNoSchedule\\\n") {
+						t.Errorf("missing the custom bootstrap taint in pool2 userdata: %s", kubeletFlagsIn(pool2Userdata))
+					}
+					if !strings.Contains(pool2Userdata, "$kctl taint nodes $(hostname) example.com/bootstrapping:PreferNoSchedule-;") {
+						t.Error("missing the command to remove the custom bootstrap taint in pool2 userdata")
+					}
+
+					pool3Userdata := c.NodePools()[2].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					for _, s := range []string{"--register-with-taints", "remove-bootstrap-taint"} {
+						if strings.Contains(pool3Userdata, s) {
+							t.Errorf("pool3 userdata shouldn't contain %s", s)
+						}
+					}
+				},
+			},
+		},
+		{
+			context: "WithAPIServerTLSSettings",
+			configYaml: minimalValidConfigYaml + `
+controller:
+  apiServer:
+    tlsCipherSuites:
+    - TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256
+    - TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384
+    tlsMinVersion: VersionTLS12
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					expected := `          - --tls-private-key-file=/etc/kubernetes/ssl/apiserver-key.pem
+          - --tls-cipher-suites=TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384
+          - --tls-min-version=VersionTLS12
+          - --client-ca-file=/etc/kubernetes/ssl/ca.pem
+`
+					if !strings.Contains(controllerUserdataS3Part, expected) {
+						t.Errorf("missing the tls flags for apiserver in controller userdata: expected to contain:\n%s", expected)
+					}
+				},
+			},
+		},
+		{
+			context:    "WithoutAPIServerTLSSettings",
+			configYaml: minimalValidConfigYaml,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, flag := range []string{"--tls-cipher-suites", "--tls-min-version", "--storage-media-type", "--shutdown-delay-duration", "--shutdown-send-retry-after", "kube-apiserver-graceful-shutdown.service", "--goaway-chance", "--enable-aggregator-routing"} {
+						if strings.Contains(controllerUserdataS3Part, flag) {
+							t.Errorf("controller userdata shouldn't contain %s by default", flag)
+						}
+					}
+				},
+			},
+		},
+		{
+			context: "WithAPIServerStorageMediaType",
+			configYaml: minimalValidConfigYaml + `
+controller:
+  apiServer:
+    storageMediaType: application/vnd.kubernetes.protobuf
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					expected := `          - --tls-private-key-file=/etc/kubernetes/ssl/apiserver-key.pem
+          - --storage-media-type=application/vnd.kubernetes.protobuf
+          - --client-ca-file=/etc/kubernetes/ssl/ca.pem
+`
+					if !strings.Contains(controllerUserdataS3Part, expected) {
+						t.Errorf("missing the storage media type flag for apiserver in controller userdata: expected to contain:\n%s", expected)
+					}
+				},
+			},
+		},
+		{
+			context: "WithAPIServerGracefulShutdown",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.22.4
+controller:
+  apiServer:
+    shutdownDelayDuration: 70s
+    shutdownSendRetryAfter: true
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					expected := `          - --tls-private-key-file=/etc/kubernetes/ssl/apiserver-key.pem
+          - --shutdown-delay-duration=70s
+          - --shutdown-send-retry-after=true
+          - --client-ca-file=/etc/kubernetes/ssl/ca.pem
+`
+					if !strings.Contains(controllerUserdataS3Part, expected) {
+						t.Errorf("missing the shutdown flags for apiserver in controller userdata: expected to contain:\n%s", expected)
+					}
+					expectedPodSpec := `        hostNetwork: true
+        terminationGracePeriodSeconds: 130
+        containers:
+        - name: kube-apiserver
+`
+					if !strings.Contains(controllerUserdataS3Part, expectedPodSpec) {
+						t.Errorf("missing the termination grace period of the apiserver pod in controller userdata: expected to contain:\n%s", expectedPodSpec)
+					}
+					for _, s := range []string{"- name: kube-apiserver-graceful-shutdown.service", "xargs -r docker stop -t 130", "TimeoutStopSec=140"} {
+						if !strings.Contains(controllerUserdataS3Part, s) {
+							t.Errorf("missing %s in controller userdata", s)
+						}
+					}
+				},
+			},
+		},
+		{
+			context: "WithAPIServerGoawayChance",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.20.2
+controller:
+  apiServer:
+    goawayChance: 0.001
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					expected := `          - --tls-private-key-file=/etc/kubernetes/ssl/apiserver-key.pem
+          - --goaway-chance=0.001
+          - --client-ca-file=/etc/kubernetes/ssl/ca.pem
+`
+					if !strings.Contains(controllerUserdataS3Part, expected) {
+						t.Errorf("missing the goaway chance flag for apiserver in controller userdata: expected to contain:\n%s", expected)
+					}
+				},
+			},
+		},
+		{
+			context: "WithContainerRuntimePerNodePool",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    containerRuntime: containerd
+    ephemeralImageStorage:
+      enabled: true
+  - name: pool2
+    containerRuntime: rkt
+  - name: pool3
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					pool1Userdata := c.NodePools()[0].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					expected := []string{
+						"--container-runtime=remote \\\n",
+						"--container-runtime-endpoint=unix:///run/docker/libcontainerd/docker-containerd.sock \\\n",
+						"- name: containerd.service",
+						"Environment=CONTAINERD_CONFIG=/etc/containerd/config.toml",
+						"- path: /etc/containerd/config.toml",
+						"sandbox_image = \"k8s.gcr.io/pause-amd64:3.1\"",
+						"- name: var-lib-containerd.mount",
+						"Where=/var/lib/containerd",
+					}
+					for _, e := range expected {
+						if !strings.Contains(pool1Userdata, e) {
+							t.Errorf("missing %q in pool1 userdata: %s", e, kubeletFlagsIn(pool1Userdata))
+						}
+					}
+					if strings.Contains(pool1Userdata, "--container-runtime=containerd") || strings.Contains(pool1Userdata, "- name: var-lib-docker.mount") {
+						t.Error("pool1 userdata shouldn't configure kubelet or the ephemeral storage for docker")
+					}
+
+					pool2Userdata := c.NodePools()[1].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					if !strings.Contains(pool2Userdata, "--container-runtime=rkt \\\n") {
+						t.Errorf("missing the rkt container runtime in pool2 userdata: %s", kubeletFlagsIn(pool2Userdata))
+					}
+
+					pool3Userdata := c.NodePools()[2].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					if !strings.Contains(pool3Userdata, "--container-runtime=docker \\\n") {
+						t.Errorf("missing the docker container runtime inherited from the cluster in pool3 userdata: %s", kubeletFlagsIn(pool3Userdata))
+					}
+					for i, u := range []string{pool2Userdata, pool3Userdata} {
+						if strings.Contains(u, "containerd.service") || strings.Contains(u, "/etc/containerd/config.toml") {
+							t.Errorf("userdata of pool%d shouldn't configure containerd", i+2)
+						}
+					}
+
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					if !strings.Contains(controllerUserdataS3Part, "--container-runtime=docker \\\n") {
+						t.Error("missing the docker container runtime in controller userdata")
+					}
+				},
+			},
+		},
+		{
+			context: "WithIAMPermissionsBoundary",
+			configYaml: minimalValidConfigYaml + `
+iam:
+  permissionsBoundary: arn:aws:iam::123456789012:policy/KubeAWSPermissionsBoundary
+experimental:
+  kiamSupport:
+    enabled: true
+kubeResourcesAutosave:
+  enabled: true
+worker:
+  nodePools:
+  - name: pool1
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					expected := `"PermissionsBoundary":"arn:aws:iam::123456789012:policy/KubeAWSPermissionsBoundary"`
+					stacks := []struct {
+						name  string
+						stack *model.Stack
+						roles int
+					}{
+						{name: "control-plane", stack: c.ControlPlane(), roles: 2},
+						{name: "etcd", stack: c.Etcd(), roles: 1},
+						{name: "pool1", stack: c.NodePools()[0], roles: 1},
+					}
+					for _, s := range stacks {
+						stackTemplate, err := s.stack.RenderStackTemplateAsString()
+						if err != nil {
+							t.Fatalf("failed to render %s stack template: %v", s.name, err)
+						}
+						if n := strings.Count(stackTemplate, expected); n != s.roles {
+							t.Errorf("expected the permissions boundary to be set on %d roles in the %s stack template but was %d", s.roles, s.name, n)
+						}
+					}
+				},
+			},
+		},
+		{
+			context: "WithoutIAMPermissionsBoundary",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					for _, s := range []*model.Stack{c.ControlPlane(), c.Etcd(), c.NodePools()[0]} {
+						stackTemplate, err := s.RenderStackTemplateAsString()
+						if err != nil {
+							t.Fatalf("failed to render stack template: %v", err)
+						}
+						if strings.Contains(stackTemplate, "PermissionsBoundary") {
+							t.Errorf("stack template shouldn't contain PermissionsBoundary by default")
+						}
+					}
+				},
+			},
+		},
+		{
+			context: "WithAmazonVPCSecondaryCIDR",
+			configYaml: minimalValidConfigYaml + `
+kubernetes:
+  networking:
+    amazonVPC:
+      enabled: true
+      secondaryCIDR: 100.64.0.0/16
+      podSubnets:
+      - availabilityZone: us-west-1c
+        instanceCIDR: 100.64.0.0/17
+worker:
+  nodePools:
+  - name: pool1
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					networkStackTemplate, err := c.Network().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render network stack template: %v", err)
+					}
+					for _, expected := range []string{
+						`"VPCSecondaryCidrBlock":{"Properties":{"CidrBlock":"100.64.0.0/16"`,
+						`"PodSubnetUsWest1c":{"DependsOn":"VPCSecondaryCidrBlock","Properties":{"AvailabilityZone":"us-west-1c","CidrBlock":"100.64.0.0/17"`,
+						`{"Key":"k8s.amazonaws.com/eniConfig","Value":"us-west-1c"}`,
+						`"Export":{"Name":{"Fn::Sub":"${AWS::StackName}-PodSubnetUsWest1c"}}`,
+					} {
+						if !strings.Contains(networkStackTemplate, expected) {
+							t.Errorf("expected the network stack template to contain %s, but it didn't", expected)
+						}
+					}
+
+					controlPlaneStackTemplate, err := c.ControlPlane().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render control-plane stack template: %v", err)
+					}
+					for _, expected := range []string{
+						`"amazon-vpc-cni-eniconfigs":["amazon-vpc-cni-eniconfigs-env"]`,
+						`"  name: us-west-1c\n"`,
+						`"  subnet: ",{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-PodSubnetUsWest1c"}}`,
+					} {
+						if !strings.Contains(controlPlaneStackTemplate, expected) {
+							t.Errorf("expected the control-plane stack template to contain %s, but it didn't", expected)
+						}
+					}
+
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, expected := range []string{
+						"- name: AWS_VPC_K8S_CNI_CUSTOM_NETWORK_CFG",
+						`applyall "${mfdir}/aws-k8s-cni-eniconfigs.yaml"`,
+						"ExecStart=/opt/bin/annotate-eniconfig",
+					} {
+						if !strings.Contains(controllerUserdataS3Part, expected) {
+							t.Errorf("expected the controller userdata to contain %s, but it didn't", expected)
+						}
+					}
+
+					workerUserdataS3Part := c.NodePools()[0].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					if expected := "annotate --overwrite nodes/$(hostname) k8s.amazonaws.com/eniConfig=${AVAILABILITY_ZONE}"; !strings.Contains(workerUserdataS3Part, expected) {
+						t.Errorf("expected the worker userdata to contain %s, but it didn't", expected)
+					}
+				},
+			},
+		},
+		{
+			context: "WithAPIServerAggregatorRouting",
+			configYaml: minimalValidConfigYaml + `
+controller:
+  apiServer:
+    enableAggregatorRouting: true
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					expected := `          - --tls-private-key-file=/etc/kubernetes/ssl/apiserver-key.pem
+          - --enable-aggregator-routing=true
+          - --client-ca-file=/etc/kubernetes/ssl/ca.pem
+`
+					if !strings.Contains(controllerUserdataS3Part, expected) {
+						t.Errorf("missing the aggregator routing flag for apiserver in controller userdata: expected to contain:\n%s", expected)
+					}
+				},
+			},
+		},
+		{
+			context: "WithAPIServerAggregatorRoutingAndControllerAggregation",
+			configYaml: minimalValidConfigYaml + `
+controller:
+  aggregation:
+    enabled: true
+  apiServer:
+    enableAggregatorRouting: true
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					if n := strings.Count(controllerUserdataS3Part, "--enable-aggregator-routing="); n != 1 {
+						t.Errorf("expected the aggregator routing flag to be rendered once, but was rendered %d times", n)
+					}
+					expected := `          - --enable-aggregator-routing=true
+          - --proxy-client-cert-file=/etc/kubernetes/ssl/front-proxy-client.pem
+`
+					if !strings.Contains(controllerUserdataS3Part, expected) {
+						t.Errorf("missing the aggregator routing flag for apiserver in controller userdata: expected to contain:\n%s", expected)
+					}
+				},
+			},
+		},
+		{
+			context: "WithAPIServerPriorityAndFairness",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.23.4
+controller:
+  apiServer:
+    priorityAndFairness:
+      enabled: true
+      maxRequestsInflight: 800
+      maxMutatingRequestsInflight: 400
+      priorityLevelConfigurations:
+      - name: noisy-clients
+        spec:
+          type: Limited
+          limited:
+            assuredConcurrencyShares: 5
+            limitResponse:
+              type: Reject
+      flowSchemas:
+      - name: noisy-clients
+        spec:
+          priorityLevelConfiguration:
+            name: noisy-clients
+          matchingPrecedence: 1000
+          rules:
+          - subjects:
+            - kind: Group
+              group:
+                name: noisy
+            resourceRules:
+            - verbs: ["*"]
+              apiGroups: ["*"]
+              resources: ["*"]
+              namespaces: ["*"]
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					expected := `          - --enable-priority-and-fairness=true
+          - --max-requests-inflight=800
+          - --max-mutating-requests-inflight=400
+          - --client-ca-file=/etc/kubernetes/ssl/ca.pem
+`
+					if !strings.Contains(controllerUserdataS3Part, expected) {
+						t.Errorf("missing the priority and fairness flags for apiserver in controller userdata: expected to contain:\n%s", expected)
+					}
+					expectedManifest := `  - path: /srv/kubernetes/manifests/apiserver-priority-and-fairness.yaml
+    content: |
+      apiVersion: flowcontrol.apiserver.k8s.io/v1beta2
+      kind: PriorityLevelConfiguration
+      metadata:
+        name: noisy-clients
+      spec:
+        limited:
+          assuredConcurrencyShares: 5
+          limitResponse:
+            type: Reject
+        type: Limited
+      ---
+      apiVersion: flowcontrol.apiserver.k8s.io/v1beta2
+      kind: FlowSchema
+`
+					if !strings.Contains(controllerUserdataS3Part, expectedManifest) {
+						t.Errorf("missing the priority and fairness objects in controller userdata: expected to contain:\n%s", expectedManifest)
+					}
+					if !strings.Contains(controllerUserdataS3Part, `applyall "${mfdir}/apiserver-priority-and-fairness.yaml"`) {
+						t.Error("the priority and fairness objects should be applied while bootstrapping controller nodes")
+					}
+				},
+			},
+		},
+		{
+			context: "WithEtcdMaxRequestBytes",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  maxRequestBytes: 4194304
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					etcdStackTemplate, err := c.Etcd().RenderStackTemplateAsString()
+					if err != nil {
+						t.Errorf("failed to render etcd stack template: %v", err)
+					}
+					if !strings.Contains(etcdStackTemplate, "--max-request-bytes=4194304") {
+						t.Errorf("expected etcd options in the etcd stack template to contain --max-request-bytes=4194304, but it didn't: %s", etcdStackTemplate)
+					}
+				},
+			},
+		},
+		{
+			context: "WithEtcdGRPC",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  version: 3.4.27
+  grpc:
+    keepaliveInterval: 30s
+    keepaliveTimeout: 10s
+    maxConcurrentStreams: 1000
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					etcdStackTemplate, err := c.Etcd().RenderStackTemplateAsString()
+					if err != nil {
+						t.Errorf("failed to render etcd stack template: %v", err)
+					}
+					expected := "--quota-backend-bytes=2147483648 --grpc-keepalive-interval=30s --grpc-keepalive-timeout=10s --max-concurrent-streams=1000"
+					if !strings.Contains(etcdStackTemplate, expected) {
+						t.Errorf("expected etcd options in the etcd stack template to contain %s, but it didn't: %s", expected, etcdStackTemplate)
+					}
+				},
+			},
+		},
+		{
+			context:    "WithoutEtcdGRPC",
+			configYaml: minimalValidConfigYaml,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					etcdStackTemplate, err := c.Etcd().RenderStackTemplateAsString()
+					if err != nil {
+						t.Errorf("failed to render etcd stack template: %v", err)
+					}
+					if strings.Contains(etcdStackTemplate, "--grpc-keepalive") || strings.Contains(etcdStackTemplate, "--max-concurrent-streams") {
+						t.Error("the etcd gRPC settings should be left to etcd's defaults")
+					}
+				},
+			},
+		},
+		{
+			context: "WithEtcdMetrics",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  version: 3.3.10
+  metrics:
+    enabled: true
+    port: 9379
+    tls: true
+    allowedSourceCIDRs:
+    - 10.0.0.0/16
+    - 192.168.0.0/24
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					etcdUserdataS3Part := c.Etcd().UserData["Etcd"].Parts[api.USERDATA_S3].Asset.Content
+					if !strings.Contains(etcdUserdataS3Part, "ETCD_LISTEN_METRICS_URLS=https://$private_ip:9379\" >> /var/run/coreos/etcd-environment") {
+						t.Error("missing ETCD_LISTEN_METRICS_URLS in etcd userdata")
+					}
+
+					networkStackTemplate, err := c.Network().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render network stack template: %v", err)
+					}
+					for _, cidr := range []string{"10.0.0.0/16", "192.168.0.0/24"} {
+						expected := fmt.Sprintf(`{"CidrIp":"%s","FromPort":9379,"IpProtocol":"tcp","ToPort":9379}`, cidr)
+						if !strings.Contains(networkStackTemplate, expected) {
+							t.Errorf("missing the etcd security group ingress for the metrics from %s: expected to contain %s", cidr, expected)
+						}
+					}
+				},
+			},
+		},
+		{
+			context:    "WithoutEtcdMetrics",
+			configYaml: minimalValidConfigYaml,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					etcdUserdataS3Part := c.Etcd().UserData["Etcd"].Parts[api.USERDATA_S3].Asset.Content
+					if strings.Contains(etcdUserdataS3Part, "ETCD_LISTEN_METRICS_URLS") {
+						t.Error("etcd userdata shouldn't contain ETCD_LISTEN_METRICS_URLS by default")
+					}
+				},
+			},
+		},
+		{
+			context: "WithEtcdDataDirAndVolumeMountOptions",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  dataDir: /var/lib/etcd
+  volumeMountOptions:
+  - noatime
+  - nobarrier
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					etcdUserdataS3Part := c.Etcd().UserData["Etcd"].Parts[api.USERDATA_S3].Asset.Content
+					expectedMount := `    - name: var-lib-etcd.mount
+      enable: true
+      content: |
+        [Unit]
+        Before=etcd-member.service
+
+        [Mount]
+        What=/dev/xvdf
+        Where=/var/lib/etcd
+        Type=ext4
+        Options=noatime,nobarrier
+`
+					if !strings.Contains(etcdUserdataS3Part, expectedMount) {
+						t.Errorf("missing the mount unit for the etcd data dir in etcd userdata: expected to contain:\n%s", expectedMount)
+					}
+					expected := []string{
+						"ETCD_DATA_DIR=/var/lib/etcd\n",
+						"ExecStartPre=/usr/bin/chown -R etcd:etcd /var/lib/etcd\n",
+						"Before=var-lib-etcd.mount\n",
+						"RequiredBy=var-lib-etcd.mount\n",
+					}
+					for _, e := range expected {
+						if !strings.Contains(etcdUserdataS3Part, e) {
+							t.Errorf("missing %q in etcd userdata", e)
+						}
+					}
+					if strings.Contains(etcdUserdataS3Part, "/var/lib/etcd2") || strings.Contains(etcdUserdataS3Part, "var-lib-etcd2.mount") {
+						t.Error("etcd userdata shouldn't contain the default data dir")
+					}
+				},
+			},
+		},
+		{
+			context:    "WithoutEtcdDataDir",
+			configYaml: minimalValidConfigYaml,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					etcdUserdataS3Part := c.Etcd().UserData["Etcd"].Parts[api.USERDATA_S3].Asset.Content
+					for _, e := range []string{"- name: var-lib-etcd2.mount", "Where=/var/lib/etcd2\n", "ETCD_DATA_DIR=/var/lib/etcd2\n"} {
+						if !strings.Contains(etcdUserdataS3Part, e) {
+							t.Errorf("missing %q in etcd userdata", e)
+						}
+					}
+					if strings.Contains(etcdUserdataS3Part, "Options=") {
+						t.Error("etcd userdata shouldn't contain mount options by default")
+					}
+				},
+			},
+		},
+		{
+			context: "WithAWSLoadBalancerControllerUsingControllerNodeRole",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.20.2
+addons:
+  awsLoadBalancerController:
+    enabled: true
+    useControllerNodeRole: true
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					expected := []string{
+						"/srv/kubernetes/manifests/aws-load-balancer-controller-de.yaml",
+						"/srv/kubernetes/manifests/aws-load-balancer-controller-webhook.yaml.tmpl",
+						fmt.Sprintf("- --cluster-name=%s", kubeAwsSettings.clusterName),
+						"- --ingress-class=alb",
+						"node-role.kubernetes.io/master: \"\"",
+					}
+					for _, e := range expected {
+						if !strings.Contains(controllerUserdataS3Part, e) {
+							t.Errorf("missing \"%s\" in controller userdata", e)
+						}
+					}
+					if strings.Contains(controllerUserdataS3Part, "iam.amazonaws.com/role: ") {
+						t.Error("the aws-load-balancer-controller pods shouldn't be annotated to assume an IAM role when the controller node role is used")
+					}
+
+					controlPlaneStackTemplate, err := c.ControlPlane().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render control plane stack template: %v", err)
+					}
+					if !strings.Contains(controlPlaneStackTemplate, `"iam:AWSServiceName":"elasticloadbalancing.amazonaws.com"`) {
+						t.Error("missing the permission to create the service-linked role for elastic load balancing in the controller IAM policy")
+					}
+
+					networkStackTemplate, err := c.Network().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render network stack template: %v", err)
+					}
+					if !strings.Contains(networkStackTemplate, `{"Key":"kubernetes.io/role/elb","Value":"1"}`) {
+						t.Error("missing the kubernetes.io/role/elb tag for the public subnet")
+					}
+				},
+			},
+		},
+		{
+			context: "WithAWSLoadBalancerControllerUsingKube2Iam",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.20.2
+experimental:
+  kube2IamSupport:
+    enabled: true
+addons:
+  awsLoadBalancerController:
+    enabled: true
+    iamRole:
+      arn: arn:aws:iam::123456789012:role/aws-load-balancer-controller
+    ingressClass: alb-internal
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					expected := []string{
+						"iam.amazonaws.com/role: arn:aws:iam::123456789012:role/aws-load-balancer-controller",
+						"- --ingress-class=alb-internal",
+					}
+					for _, e := range expected {
+						if !strings.Contains(controllerUserdataS3Part, e) {
+							t.Errorf("missing \"%s\" in controller userdata", e)
+						}
+					}
+
+					controlPlaneStackTemplate, err := c.ControlPlane().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render control plane stack template: %v", err)
+					}
+					if strings.Contains(controlPlaneStackTemplate, "elasticloadbalancing.amazonaws.com") {
+						t.Error("the controller IAM policy shouldn't be granted the aws-load-balancer-controller permissions when the controller assumes its own IAM role")
+					}
+				},
+			},
+		},
+		{
+			context:    "WithoutAWSLoadBalancerController",
+			configYaml: minimalValidConfigYaml,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					if strings.Contains(controllerUserdataS3Part, "aws-load-balancer-controller") {
+						t.Error("controller userdata shouldn't contain aws-load-balancer-controller by default")
+					}
+				},
+			},
+		},
+		{
+			context: "WithAssetLayout",
+			configYaml: minimalValidConfigYaml + `
+assetLayout:
+  baseDir: clusters/prod
+  credentialsDir: secrets
+  userDataDir: cloud-configs
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					for _, p := range []struct{ actual, expected string }{
+						{c.AssetLayout.CredentialsPath(), "clusters/prod/secrets"},
+						{c.AssetLayout.UserDataPath(), "clusters/prod/cloud-configs"},
+						{c.AssetLayout.StackTemplatesPath(), "clusters/prod/stack-templates"},
+						{c.AssetLayout.KubeconfigPath(), "clusters/prod/kubeconfig"},
+					} {
+						if p.actual != p.expected {
+							t.Errorf("unexpected asset path: expected=%s, actual=%s", p.expected, p.actual)
+						}
+					}
+				},
+			},
+		},
+		{
+			context: "WithClassicELBHealthCheck",
+			configYaml: configYamlWithoutExernalDNSName + `
+apiEndpoints:
+- name: default
+  dnsName: k8s.example.com
+  loadBalancer:
+    hostedZone:
+      id: a1b2c4
+    healthCheck:
+      target: HTTPS:443/healthz
+      interval: 30
+      timeout: 5
+      healthyThreshold: 2
+      unhealthyThreshold: 4
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controlPlaneStackTemplate, err := c.ControlPlane().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render control plane stack template: %v", err)
+					}
+					expected := `"HealthCheck":{"HealthyThreshold":"2","Interval":"30","Target":"HTTPS:443/healthz","Timeout":"5","UnhealthyThreshold":"4"}`
+					if !strings.Contains(controlPlaneStackTemplate, expected) {
+						t.Errorf("unexpected health check of the API endpoint ELB: expected to contain %s", expected)
+					}
+				},
+			},
+		},
+		{
+			context:    "WithoutClassicELBHealthCheck",
+			configYaml: minimalValidConfigYaml,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controlPlaneStackTemplate, err := c.ControlPlane().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render control plane stack template: %v", err)
+					}
+					expected := `"HealthCheck":{"HealthyThreshold":"3","Interval":"10","Target":"SSL:443","Timeout":"8","UnhealthyThreshold":"3"}`
+					if !strings.Contains(controlPlaneStackTemplate, expected) {
+						t.Errorf("unexpected default health check of the API endpoint ELB: expected to contain %s", expected)
+					}
+				},
+			},
+		},
+		{
+			context: "WithSubnetWeights",
+			configYaml: kubeAwsSettings.mainClusterYamlWithoutAPIEndpoint() + `
+subnets:
+- name: public1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.1.0/24"
+- name: public2
+  availabilityZone: us-west-1b
+  instanceCIDR: "10.0.2.0/24"
+- name: public3
+  availabilityZone: us-west-1c
+  instanceCIDR: "10.0.3.0/24"
+apiEndpoints:
+- name: default
+  dnsName: k8s.example.com
+  loadBalancer:
+    hostedZone:
+      id: a1b2c4
+addons:
+  clusterAutoscaler:
+    enabled: true
+worker:
+  nodePools:
+  - name: pool1
+    subnets:
+    - name: public1
+    - name: public2
+    - name: public3
+    autoscaling:
+      clusterAutoscaler:
+        enabled: true
+    autoScalingGroup:
+      minSize: 2
+      maxSize: 10
+      subnetWeights:
+        public1: 6
+        public2: 3
+        public3: 1
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					stackTemplate, err := c.NodePools()[0].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render node pool stack template: %v", err)
+					}
+					if strings.Contains(stackTemplate, `"Workers":{`) {
+						t.Error("unexpected auto scaling group spanning all the subnets in the node pool stack template")
+					}
+					for name, sizes := range map[string]string{
+						"WorkersPublic1": `"MaxSize":"6","MetricsCollection":[{"Granularity":"1Minute"}],"MinSize":"1"`,
+						"WorkersPublic2": `"MaxSize":"3","MetricsCollection":[{"Granularity":"1Minute"}],"MinSize":"1"`,
+						"WorkersPublic3": `"MaxSize":"1","MetricsCollection":[{"Granularity":"1Minute"}],"MinSize":"0"`,
+					} {
+						expected := fmt.Sprintf(`"%s":{"DependsOn":"WorkersLT","Properties":{"HealthCheckGracePeriod":600,"HealthCheckType":"EC2",%s`, name, sizes)
+						if !strings.Contains(stackTemplate, expected) {
+							t.Errorf("unexpected auto scaling group %s in the node pool stack template: expected to contain %s", name, expected)
+						}
+					}
+
+					workerUserdataS3Part := c.NodePools()[0].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					if !strings.Contains(workerUserdataS3Part, `"Name=key,Values=aws:cloudformation:logical-id"`) {
+						t.Error("missing the lookup of the auto scaling group to signal in worker userdata")
+					}
+				},
+			},
+		},
+		{
+			context: "WithEFSCSIDriverUsingControllerNodeRole",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.20.2
+addons:
+  efsCsiDriver:
+    enabled: true
+    useControllerNodeRole: true
+    storageClass:
+      enabled: true
+      fileSystemId: fs-0123456789abcdef0
+      default: true
+      gidRangeStart: 1000
+      gidRangeEnd: 2000
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					expected := []string{
+						"/srv/kubernetes/manifests/efs-csi-controller-de.yaml",
+						"/srv/kubernetes/manifests/efs-csi-node-ds.yaml",
+						"node-role.kubernetes.io/master: \"\"",
+						`        kind: StorageClass
+        metadata:
+          name: efs
+          annotations:
+            storageclass.kubernetes.io/is-default-class: "true"
+        provisioner: efs.csi.aws.com
+        parameters:
+          provisioningMode: efs-ap
+          fileSystemId: fs-0123456789abcdef0
+          directoryPerms: "700"
+          gidRangeStart: "1000"
+          gidRangeEnd: "2000"
+`,
+						`applyall "${mfdir}/efs-storage-class.yaml"`,
+					}
+					for _, e := range expected {
+						if !strings.Contains(controllerUserdataS3Part, e) {
+							t.Errorf("missing \"%s\" in controller userdata", e)
+						}
+					}
+					if strings.Contains(controllerUserdataS3Part, "iam.amazonaws.com/role: ") {
+						t.Error("the efs-csi-controller pods shouldn't be annotated to assume an IAM role when the controller node role is used")
+					}
+
+					controlPlaneStackTemplate, err := c.ControlPlane().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render control plane stack template: %v", err)
+					}
+					if !strings.Contains(controlPlaneStackTemplate, `"aws:RequestTag/efs.csi.aws.com/cluster":"true"`) {
+						t.Error("missing the permission to create EFS access points in the controller IAM policy")
+					}
+				},
+			},
+		},
+		{
+			context: "WithEFSCSIDriverUsingKube2Iam",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.20.2
+experimental:
+  kube2IamSupport:
+    enabled: true
+addons:
+  efsCsiDriver:
+    enabled: true
+    iamRole:
+      arn: arn:aws:iam::123456789012:role/efs-csi-driver
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					if !strings.Contains(controllerUserdataS3Part, "iam.amazonaws.com/role: arn:aws:iam::123456789012:role/efs-csi-driver") {
+						t.Error("missing the IAM role of the efs-csi-controller pods in controller userdata")
+					}
+					if strings.Contains(controllerUserdataS3Part, "efs-storage-class.yaml") {
+						t.Error("controller userdata shouldn't contain the EFS storage class unless it is enabled")
+					}
+
+					controlPlaneStackTemplate, err := c.ControlPlane().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render control plane stack template: %v", err)
+					}
+					if strings.Contains(controlPlaneStackTemplate, "elasticfilesystem:") {
+						t.Error("the controller IAM policy shouldn't be granted the EFS CSI driver permissions when the driver assumes its own IAM role")
+					}
+				},
+			},
+		},
+		{
+			context:    "WithoutEFSCSIDriver",
+			configYaml: minimalValidConfigYaml,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					if strings.Contains(controllerUserdataS3Part, "efs-csi") {
+						t.Error("controller userdata shouldn't contain the EFS CSI driver by default")
+					}
+				},
+			},
+		},
+		{
+			// See https://github.com/kubernetes-incubator/kube-aws/issues/365
+			context:    "WithClusterNameContainsHyphens",
+			configYaml: kubeAwsSettings.withClusterName("my-cluster").minimumValidClusterYaml(),
+		},
+		{
+			context: "WithCustomSettings",
+			configYaml: minimalValidConfigYaml + `
+customSettings:
+  stack-type: control-plane
+worker:
+  nodePools:
+  - name: pool1
+    customSettings:
+      stack-type: node-pool
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultEtcdSettings,
+				asgBasedNodePoolHasWaitSignalEnabled,
+				func(c *config.Config, t *testing.T) {
+					p := c.NodePools[0]
+
+					{
+						expected := map[string]interface{}{
+							"stack-type": "control-plane",
+						}
+						actual := c.CustomSettings
+						if !reflect.DeepEqual(expected, actual) {
+							t.Errorf("customSettings didn't match : expected=%v actual=%v", expected, actual)
+						}
+					}
+
+					{
+						expected := map[string]interface{}{
+							"stack-type": "node-pool",
+						}
+						actual := p.CustomSettings
+						if !reflect.DeepEqual(expected, actual) {
+							t.Errorf("customSettings didn't match : expected=%v actual=%v", expected, actual)
+						}
+					}
+				},
+			},
+			assertCluster: []ClusterTester{
+				hasDefaultCluster,
+			},
+		},
+		{
+			context: "WithDifferentReleaseChannels",
+			configYaml: minimalValidConfigYaml + `
+releaseChannel: stable
+worker:
+  nodePools:
+  - name: pool1
+    releaseChannel: alpha
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultEtcdSettings,
+				asgBasedNodePoolHasWaitSignalEnabled,
+			},
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					cp := c.ControlPlane().Config.AMI
+					np := c.NodePools()[0].NodePoolConfig.AMI
+
+					if cp == "" {
+						t.Error("the default AMI ID should not be empty but it was")
+					}
+
+					if np == "" {
+						t.Error("the AMI ID for the node pool should not be empty but it was")
+					}
+
+					if cp == np {
+						t.Errorf("the default AMI ID and the AMI ID for the node pool should not match but they did: default=%s, nodepool=%s", cp, np)
+					}
+				},
+			},
+		},
+		{
+			context: "WithElasticFileSystemId",
+			configYaml: minimalValidConfigYaml + `
+elasticFileSystemId: efs-12345
+worker:
+  nodePools:
+  - name: pool1
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					if c.NodePools[0].ElasticFileSystemID != "efs-12345" {
+						t.Errorf("The value of worker.nodePools[0].elasticFileSystemId should match the one for the top-leve elasticFileSystemId, but it wan't: worker.nodePools[0].elasticFileSystemId=%s", c.NodePools[0].ElasticFileSystemID)
+					}
+				},
+			},
+		},
+		{
+			context: "WithElasticFileSystemIdInSpecificNodePool",
+			configYaml: mainClusterYaml + `
+subnets:
+- name: existing1
+  id: subnet-12345
+  availabilityZone: us-west-1a
+worker:
+  nodePools:
+  - name: pool1
+    subnets:
+    - name: existing1
+    elasticFileSystemId: efs-12345
+  - name: pool2
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					if c.NodePools[0].ElasticFileSystemID != "efs-12345" {
+						t.Errorf("Unexpected worker.nodePools[0].elasticFileSystemId: %s", c.NodePools[0].ElasticFileSystemID)
+					}
+					if c.NodePools[1].ElasticFileSystemID != "" {
+						t.Errorf("Unexpected worker.nodePools[1].elasticFileSystemId: %s", c.NodePools[1].ElasticFileSystemID)
+					}
+				},
+			},
+		},
+		{
+			context: "WithEtcdDataVolumeEncrypted",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  dataVolume:
+    encrypted: true
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					if !c.Etcd.DataVolume.Encrypted {
+						t.Errorf("Etcd data volume should be encrypted but was not: %v", c.Etcd)
+					}
+				},
+			},
+		},
+		{
+			context: "WithEtcdDataVolumeEncryptedKMSKeyARN",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  dataVolume:
+    encrypted: true
+  kmsKeyArn: arn:aws:kms:eu-west-1:XXX:key/XXX
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					expected := "arn:aws:kms:eu-west-1:XXX:key/XXX"
+					if c.Etcd.KMSKeyARN() != expected {
+						t.Errorf("Etcd data volume KMS Key ARN didn't match : expected=%v actual=%v", expected, c.Etcd.KMSKeyARN())
+					}
+					if !c.Etcd.DataVolume.Encrypted {
+						t.Error("Etcd data volume should be encrypted but was not")
+					}
+				},
+			},
+		},
+		{
+			context: "WithEtcdMemberIdentityProviderEIP",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  memberIdentityProvider: eip
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					subnet1 := api.NewPublicSubnet(firstAz, "10.0.0.0/24")
+					subnet1.Name = "Subnet0"
+					expected := api.EtcdSettings{
+						Etcd: api.Etcd{
+							Cluster: api.EtcdCluster{
+								MemberIdentityProvider: "eip",
+							},
+							EC2Instance: api.EC2Instance{
+								Count:        1,
+								InstanceType: "t2.medium",
+								Tenancy:      "default",
+								RootVolume: api.RootVolume{
+									Size: 30,
+									Type: "gp2",
+									IOPS: 0,
+								},
+							},
+							DataVolume: api.DataVolume{
+								Size:      30,
+								Type:      "gp2",
+								IOPS:      0,
+								Ephemeral: false,
+							},
+							Subnets: api.Subnets{
+								subnet1,
+							},
+							UserSuppliedArgs: api.UserSuppliedArgs{
+								QuotaBackendBytes: api.DefaultQuotaBackendBytes,
+							},
+						},
+					}
+					actual := c.EtcdSettings
+					if diff := cmp.Diff(actual, expected); diff != "" {
+						t.Errorf("EtcdSettings didn't match: %s", diff)
+					}
+
+					if !actual.NodeShouldHaveEIP() {
+						t.Errorf(
+							"NodeShouldHaveEIP returned unexpected value: %v",
+							actual.NodeShouldHaveEIP(),
+						)
+					}
+				},
+			},
+			assertCluster: []ClusterTester{
+				hasDefaultCluster,
+			},
+		},
+		{
+			context: "WithEtcdMemberIdentityProviderENI",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  memberIdentityProvider: eni
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					subnet1 := api.NewPublicSubnet(firstAz, "10.0.0.0/24")
+					subnet1.Name = "Subnet0"
+					expected := api.EtcdSettings{
+						Etcd: api.Etcd{
+							EC2Instance: api.EC2Instance{
+								Count:        1,
+								InstanceType: "t2.medium",
+								RootVolume: api.RootVolume{
+									Size: 30,
+									Type: "gp2",
+									IOPS: 0,
+								},
+								Tenancy: "default",
+							},
+							DataVolume: api.DataVolume{
+								Size:      30,
+								Type:      "gp2",
+								IOPS:      0,
+								Ephemeral: false,
+							},
+							Cluster: api.EtcdCluster{
+								MemberIdentityProvider: "eni",
+							},
+							Subnets: api.Subnets{
+								subnet1,
+							},
+							UserSuppliedArgs: api.UserSuppliedArgs{
+								QuotaBackendBytes: api.DefaultQuotaBackendBytes,
+							},
+						},
+					}
+					actual := c.EtcdSettings
+					if diff := cmp.Diff(actual, expected); diff != "" {
+						t.Errorf("EtcdSettings didn't match: %s", diff)
+					}
+
+					if !actual.NodeShouldHaveSecondaryENI() {
+						t.Errorf(
+							"NodeShouldHaveSecondaryENI returned unexpected value: %v",
+							actual.NodeShouldHaveSecondaryENI(),
+						)
+					}
+				},
+			},
+			assertCluster: []ClusterTester{
+				hasDefaultCluster,
+			},
+		},
+		{
+			context: "WithEtcdMemberIdentityProviderENIWithCustomDomain",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  memberIdentityProvider: eni
+  internalDomainName: internal.example.com
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					subnet1 := api.NewPublicSubnet(firstAz, "10.0.0.0/24")
+					subnet1.Name = "Subnet0"
+					expected := api.EtcdSettings{
+						Etcd: api.Etcd{
+							Cluster: api.EtcdCluster{
+								MemberIdentityProvider: "eni",
+								InternalDomainName:     "internal.example.com",
+							},
+							EC2Instance: api.EC2Instance{
+								Count:        1,
+								InstanceType: "t2.medium",
+								RootVolume: api.RootVolume{
+									Size: 30,
+									Type: "gp2",
+									IOPS: 0,
+								},
+								Tenancy: "default",
+							},
+							DataVolume: api.DataVolume{
+								Size:      30,
+								Type:      "gp2",
+								IOPS:      0,
+								Ephemeral: false,
+							},
+							Subnets: api.Subnets{
+								subnet1,
+							},
+							UserSuppliedArgs: api.UserSuppliedArgs{
+								QuotaBackendBytes: api.DefaultQuotaBackendBytes,
+							},
+						},
+					}
+					actual := c.EtcdSettings
+					if diff := cmp.Diff(actual, expected); diff != "" {
+						t.Errorf("EtcdSettings didn't match: %s", diff)
+					}
+
+					if !actual.NodeShouldHaveSecondaryENI() {
+						t.Errorf(
+							"NodeShouldHaveSecondaryENI returned unexpected value: %v",
+							actual.NodeShouldHaveSecondaryENI(),
+						)
+					}
+				},
+			},
+			assertCluster: []ClusterTester{
+				hasDefaultCluster,
+			},
+		},
+		{
+			context: "WithEtcdMemberIdentityProviderENIWithCustomFQDNs",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  memberIdentityProvider: eni
+  internalDomainName: internal.example.com
+  nodes:
+  - fqdn: etcd1a.internal.example.com
+  - fqdn: etcd1b.internal.example.com
+  - fqdn: etcd1c.internal.example.com
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					subnet1 := api.NewPublicSubnet(firstAz, "10.0.0.0/24")
+					subnet1.Name = "Subnet0"
+					expected := api.EtcdSettings{
+						Etcd: api.Etcd{
+							Cluster: api.EtcdCluster{
+								MemberIdentityProvider: "eni",
+								InternalDomainName:     "internal.example.com",
+							},
+							EC2Instance: api.EC2Instance{
+								Count:        1,
+								InstanceType: "t2.medium",
+								RootVolume: api.RootVolume{
+									Size: 30,
+									Type: "gp2",
+									IOPS: 0,
+								},
+								Tenancy: "default",
+							},
+							DataVolume: api.DataVolume{
+								Size:      30,
+								Type:      "gp2",
+								IOPS:      0,
+								Ephemeral: false,
+							},
+							Nodes: []api.EtcdNode{
+								api.EtcdNode{
+									FQDN: "etcd1a.internal.example.com",
+								},
+								api.EtcdNode{
+									FQDN: "etcd1b.internal.example.com",
+								},
+								api.EtcdNode{
+									FQDN: "etcd1c.internal.example.com",
+								},
+							},
+							Subnets: api.Subnets{
+								subnet1,
+							},
+							UserSuppliedArgs: api.UserSuppliedArgs{
+								QuotaBackendBytes: api.DefaultQuotaBackendBytes,
+							},
+						},
+					}
+					actual := c.EtcdSettings
+					if diff := cmp.Diff(actual, expected); diff != "" {
+						t.Errorf("EtcdSettings didn't match: %s", diff)
+					}
+
+					if !actual.NodeShouldHaveSecondaryENI() {
+						t.Errorf(
+							"NodeShouldHaveSecondaryENI returned unexpected value: %v",
+							actual.NodeShouldHaveSecondaryENI(),
+						)
+					}
+				},
+			},
+			assertCluster: []ClusterTester{
+				hasDefaultCluster,
+			},
+		},
+		{
+			context: "WithEtcdMemberIdentityProviderENIWithCustomNames",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  memberIdentityProvider: eni
+  internalDomainName: internal.example.com
+  nodes:
+  - name: etcd1a
+  - name: etcd1b
+  - name: etcd1c
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					subnet1 := api.NewPublicSubnet(firstAz, "10.0.0.0/24")
+					subnet1.Name = "Subnet0"
+					expected := api.EtcdSettings{
+						Etcd: api.Etcd{
+							Cluster: api.EtcdCluster{
+								MemberIdentityProvider: "eni",
+								InternalDomainName:     "internal.example.com",
+							},
+							EC2Instance: api.EC2Instance{
+								Count:        1,
+								InstanceType: "t2.medium",
+								RootVolume: api.RootVolume{
+									Size: 30,
+									Type: "gp2",
+									IOPS: 0,
+								},
+								Tenancy: "default",
+							},
+							DataVolume: api.DataVolume{
+								Size:      30,
+								Type:      "gp2",
+								IOPS:      0,
+								Ephemeral: false,
+							},
+							Nodes: []api.EtcdNode{
+								api.EtcdNode{
+									Name: "etcd1a",
+								},
+								api.EtcdNode{
+									Name: "etcd1b",
+								},
+								api.EtcdNode{
+									Name: "etcd1c",
+								},
+							},
+							Subnets: api.Subnets{
+								subnet1,
+							},
+							UserSuppliedArgs: api.UserSuppliedArgs{
+								QuotaBackendBytes: api.DefaultQuotaBackendBytes,
+							},
+						},
+					}
+					actual := c.EtcdSettings
+					if diff := cmp.Diff(actual, expected); diff != "" {
+						t.Errorf("EtcdSettings didn't match: %s", diff)
+					}
+
+					if !actual.NodeShouldHaveSecondaryENI() {
+						t.Errorf(
+							"NodeShouldHaveSecondaryENI returned unexpected value: %v",
+							actual.NodeShouldHaveSecondaryENI(),
+						)
+					}
+				},
+			},
+			assertCluster: []ClusterTester{
+				hasDefaultCluster,
+			},
+		},
+		{
+			context: "WithEtcdMemberIdentityProviderENIWithoutRecordSets",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  memberIdentityProvider: eni
+  internalDomainName: internal.example.com
+  manageRecordSets: false
+  nodes:
+  - name: etcd1a
+  - name: etcd1b
+  - name: etcd1c
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					subnet1 := api.NewPublicSubnet(firstAz, "10.0.0.0/24")
+					subnet1.Name = "Subnet0"
+					manageRecordSets := false
+					expected := api.EtcdSettings{
+						Etcd: api.Etcd{
+							Cluster: api.EtcdCluster{
+								ManageRecordSets:       &manageRecordSets,
+								MemberIdentityProvider: "eni",
+								InternalDomainName:     "internal.example.com",
+							},
+							EC2Instance: api.EC2Instance{
+								Count:        1,
+								InstanceType: "t2.medium",
+								RootVolume: api.RootVolume{
+									Size: 30,
+									Type: "gp2",
+									IOPS: 0,
+								},
+								Tenancy: "default",
+							},
+							DataVolume: api.DataVolume{
+								Size:      30,
+								Type:      "gp2",
+								IOPS:      0,
+								Ephemeral: false,
+							},
+							Nodes: []api.EtcdNode{
+								api.EtcdNode{
+									Name: "etcd1a",
+								},
+								api.EtcdNode{
+									Name: "etcd1b",
+								},
+								api.EtcdNode{
+									Name: "etcd1c",
+								},
+							},
+							Subnets: api.Subnets{
+								subnet1,
+							},
+							UserSuppliedArgs: api.UserSuppliedArgs{
+								QuotaBackendBytes: api.DefaultQuotaBackendBytes,
+							},
+						},
+					}
+					actual := c.EtcdSettings
+					if diff := cmp.Diff(actual, expected); diff != "" {
+						t.Errorf("EtcdSettings didn't match: %s", diff)
+					}
+
+					if !actual.NodeShouldHaveSecondaryENI() {
+						t.Errorf(
+							"NodeShouldHaveSecondaryENI returned unexpected value: %v",
+							actual.NodeShouldHaveSecondaryENI(),
+						)
+					}
+				},
+			},
+			assertCluster: []ClusterTester{
+				hasDefaultCluster,
+			},
+		},
+		{
+			context: "WithEtcdMemberIdentityProviderENIWithHostedZoneID",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  memberIdentityProvider: eni
+  internalDomainName: internal.example.com
+  hostedZone:
+    id: hostedzone-abcdefg
+  nodes:
+  - name: etcd1a
+  - name: etcd1b
+  - name: etcd1c
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					subnet1 := api.NewPublicSubnet(firstAz, "10.0.0.0/24")
+					subnet1.Name = "Subnet0"
+					expected := api.EtcdSettings{
+						Etcd: api.Etcd{
+							Cluster: api.EtcdCluster{
+								HostedZone:             api.Identifier{ID: "hostedzone-abcdefg"},
+								MemberIdentityProvider: "eni",
+								InternalDomainName:     "internal.example.com",
+							},
+							EC2Instance: api.EC2Instance{
+								Count:        1,
+								InstanceType: "t2.medium",
+								RootVolume: api.RootVolume{
+									Size: 30,
+									Type: "gp2",
+									IOPS: 0,
+								},
+								Tenancy: "default",
+							},
+							DataVolume: api.DataVolume{
+								Size:      30,
+								Type:      "gp2",
+								IOPS:      0,
+								Ephemeral: false,
+							},
+							Nodes: []api.EtcdNode{
+								api.EtcdNode{
+									Name: "etcd1a",
+								},
+								api.EtcdNode{
+									Name: "etcd1b",
+								},
+								api.EtcdNode{
+									Name: "etcd1c",
+								},
+							},
+							Subnets: api.Subnets{
+								subnet1,
+							},
+							UserSuppliedArgs: api.UserSuppliedArgs{
+								QuotaBackendBytes: api.DefaultQuotaBackendBytes,
+							},
+						},
+					}
+					actual := c.EtcdSettings
+					if diff := cmp.Diff(actual, expected); diff != "" {
+						t.Errorf("EtcdSettings didn't match: %s", diff)
+					}
+
+					if !actual.NodeShouldHaveSecondaryENI() {
+						t.Errorf(
+							"NodeShouldHaveSecondaryENI returned unexpected value: %v",
+							actual.NodeShouldHaveSecondaryENI(),
+						)
+					}
+				},
+			},
+			assertCluster: []ClusterTester{
+				hasDefaultCluster,
+			},
+		},
+		{
+			context: "WithExperimentalFeatures",
+			configYaml: minimalValidConfigYaml + `
+experimental:
+  admission:
+    podSecurityPolicy:
+      enabled: true
+    denyEscalatingExec:
+      enabled: true
+    alwaysPullImages:
+      enabled: true
+    priority:
+      enabled: true
+    mutatingAdmissionWebhook:
+      enabled: true
+    validatingAdmissionWebhook:
+      enabled: true
+    persistentVolumeClaimResize:
+      enabled: false
+  auditLog:
+    enabled: true
+    logPath: "/var/log/audit.log"
+    maxAge: 100
+    maxBackup: 10
+    maxSize: 5
+  authentication:
+    webhook:
+      enabled: true
+      cacheTTL: "1234s"
+      configBase64: "e30k"
+  awsEnvironment:
+    enabled: true
+    environment:
+      CFNSTACK: '{ "Ref" : "AWS::StackId" }'
+  awsNodeLabels:
+    enabled: true
+  tlsBootstrap:
+    enabled: true
+  ephemeralImageStorage:
+    enabled: true
+  kiamSupport:
+    enabled: false
+  kube2IamSupport:
+    enabled: true
+  gpuSupport:
+    enabled: true
+    version: "375.66"
+    installImage: "shelmangroup/coreos-nvidia-driver-installer:latest"
+  kubeletOpts: '--image-gc-low-threshold 60 --image-gc-high-threshold 70'
+  loadBalancer:
+    enabled: true
+    names:
+      - manuallymanagedlb
+    securityGroupIds:
+      - sg-12345678
+  targetGroup:
+    enabled: true
+    arns:
+      - arn:aws:elasticloadbalancing:eu-west-1:xxxxxxxxxxxx:targetgroup/manuallymanagedetg/xxxxxxxxxxxxxxxx
+    securityGroupIds:
+      - sg-12345678
+  oidc:
+    enabled: true
+    oidc-issuer-url: "https://accounts.google.com"
+    oidc-client-id: "kubernetes"
+    oidc-username-claim: "email"
+    oidc-groups-claim: "groups"
+  nodeDrainer:
+    enabled: true
+    drainTimeout: 3
+cloudWatchLogging:
+  enabled: true
+amazonSsmAgent:
+  enabled: true
+worker:
+  nodePools:
+  - name: pool1
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultEtcdSettings,
+				asgBasedNodePoolHasWaitSignalEnabled,
+				func(c *config.Config, t *testing.T) {
+					expected := api.Experimental{
+						Admission: api.Admission{
+							PodSecurityPolicy: api.PodSecurityPolicy{
+								Enabled: true,
+							},
+							AlwaysPullImages: api.AlwaysPullImages{
+								Enabled: true,
+							},
+							DenyEscalatingExec: api.DenyEscalatingExec{
+								Enabled: true,
+							},
+							Priority: api.Priority{
+								Enabled: true,
+							},
+							MutatingAdmissionWebhook: api.MutatingAdmissionWebhook{
+								Enabled: true,
+							},
+							ValidatingAdmissionWebhook: api.ValidatingAdmissionWebhook{
+								Enabled: true,
+							},
+							PersistentVolumeClaimResize: api.PersistentVolumeClaimResize{
+								Enabled: false,
+							},
+						},
+						AuditLog: api.AuditLog{
+							Enabled:   true,
+							LogPath:   "/var/log/audit.log",
+							MaxAge:    100,
+							MaxBackup: 10,
+							MaxSize:   5,
+						},
+						Authentication: api.Authentication{
+							Webhook: api.Webhook{
+								Enabled:  true,
+								CacheTTL: "1234s",
+								Config:   "e30k",
+							},
+						},
+						AwsEnvironment: api.AwsEnvironment{
+							Enabled: true,
+							Environment: map[string]string{
+								"CFNSTACK": `{ "Ref" : "AWS::StackId" }`,
+							},
+						},
+						AwsNodeLabels: api.AwsNodeLabels{
+							Enabled: true,
+						},
+						ClusterAutoscalerSupport: api.ClusterAutoscalerSupport{
+							Enabled: true,
+							Options: map[string]string{},
+						},
+						TLSBootstrap: api.TLSBootstrap{
+							Enabled: true,
+						},
+						EphemeralImageStorage: api.EphemeralImageStorage{
+							Enabled:    true,
+							Disk:       "xvdb",
+							Filesystem: "xfs",
+						},
+						KIAMSupport: api.KIAMSupport{
+							Enabled:         false,
+							Image:           api.Image{Repo: "quay.io/uswitch/kiam", Tag: "v2.8", RktPullDocker: false},
+							SessionDuration: "15m",
+							ServerAddresses: api.KIAMServerAddresses{ServerAddress: "localhost:443", AgentAddress: "kiam-server:443"},
+						},
+						Kube2IamSupport: api.Kube2IamSupport{
+							Enabled: true,
+						},
+						GpuSupport: api.GpuSupport{
+							Enabled:      true,
+							Version:      "375.66",
+							InstallImage: "shelmangroup/coreos-nvidia-driver-installer:latest",
+						},
+						KubeletOpts: "--image-gc-low-threshold 60 --image-gc-high-threshold 70",
+						LoadBalancer: api.LoadBalancer{
+							Enabled:          true,
+							Names:            []string{"manuallymanagedlb"},
+							SecurityGroupIds: []string{"sg-12345678"},
+						},
+						TargetGroup: api.TargetGroup{
+							Enabled:          true,
+							Arns:             []string{"arn:aws:elasticloadbalancing:eu-west-1:xxxxxxxxxxxx:targetgroup/manuallymanagedetg/xxxxxxxxxxxxxxxx"},
+							SecurityGroupIds: []string{"sg-12345678"},
+						},
+						Oidc: api.Oidc{
+							Enabled:       true,
+							IssuerUrl:     "https://accounts.google.com",
+							ClientId:      "kubernetes",
+							UsernameClaim: "email",
+							GroupsClaim:   "groups",
+						},
+						NodeDrainer: api.NodeDrainer{
+							Enabled:      true,
+							DrainTimeout: 3,
+						},
+					}
+
+					actual := c.Experimental
+
+					if !reflect.DeepEqual(expected, actual) {
+						t.Errorf("experimental settings didn't match : expected=%+v actual=%+v", expected, actual)
+					}
+
+					p := c.NodePools[0]
+					if reflect.DeepEqual(expected, p.Experimental) {
+						t.Errorf("experimental settings shouldn't be inherited to a node pool but it did : toplevel=%v nodepool=%v", expected, p.Experimental)
+					}
+
+				},
+			},
+			assertCluster: []ClusterTester{
+				hasDefaultCluster,
+				func(c *root.Cluster, t *testing.T) {
+					cp := c.ControlPlane()
+					controllerUserdataS3Part := cp.UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					if match, _ := regexp.MatchString(`--feature-gates=.*ExpandPersistentVolumes=false`, controllerUserdataS3Part); !match {
+						t.Error("missing controller feature gate: ExpandPersistentVolumes=false")
+					}
+
+					if !strings.Contains(controllerUserdataS3Part, `scheduling.k8s.io/v1alpha1=true`) {
+						t.Error("missing controller runtime config: scheduling.k8s.io/v1alpha1=true")
+					}
+
+					re, _ := regexp.Compile("--enable-admission-plugins=[a-zA-z,]*,Priority")
+					if len(re.FindString(controllerUserdataS3Part)) == 0 {
+						t.Error("missing controller --enable-admission-plugins config: Priority")
+					}
+
+				},
+			},
+		},
+		{
+			context: "WithExperimentalFeaturesForWorkerNodePool",
+			configYaml: minimalValidConfigYaml + `
+addons:
+  clusterAutoscaler:
+    enabled: true
+worker:
+  nodePools:
+  - name: pool1
+    admission:
+      podSecurityPolicy:
+        enabled: true
+    auditLog:
+      enabled: true
+      maxage: 100
+      logpath: "/var/log/audit.log"
+    awsEnvironment:
+      enabled: true
+      environment:
+        CFNSTACK: '{ "Ref" : "AWS::StackId" }'
+    awsNodeLabels:
+      enabled: true
+    clusterAutoscalerSupport:
+      enabled: true
+    tlsBootstrap:
+      enabled: true # Must be ignored, value is synced with the one from control plane
+    ephemeralImageStorage:
+      enabled: true
+    kube2IamSupport:
+      enabled: true
+    loadBalancer:
+      enabled: true
+      names:
+        - manuallymanagedlb
+      securityGroupIds:
+        - sg-12345678
+    targetGroup:
+      enabled: true
+      arns:
+        - arn:aws:elasticloadbalancing:eu-west-1:xxxxxxxxxxxx:targetgroup/manuallymanagedetg/xxxxxxxxxxxxxxxx
+      securityGroupIds:
+        - sg-12345678
+    # Ignored, uses global setting
+    nodeDrainer:
+      enabled: true
+      drainTimeout: 5
+    nodeLabels:
+      kube-aws.coreos.com/role: worker
+    taints:
+      - key: reservation
+        value: spot
+        effect: NoSchedule
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultEtcdSettings,
+				asgBasedNodePoolHasWaitSignalEnabled,
+				func(c *config.Config, t *testing.T) {
+					expected := api.Experimental{
+						AwsEnvironment: api.AwsEnvironment{
+							Enabled: true,
+							Environment: map[string]string{
+								"CFNSTACK": `{ "Ref" : "AWS::StackId" }`,
+							},
+						},
+						AwsNodeLabels: api.AwsNodeLabels{
+							Enabled: true,
+						},
+						ClusterAutoscalerSupport: api.ClusterAutoscalerSupport{
+							Enabled: true,
+							Options: map[string]string{},
+						},
+						TLSBootstrap: api.TLSBootstrap{
+							Enabled: false,
+						},
+						EphemeralImageStorage: api.EphemeralImageStorage{
+							Enabled:    true,
+							Disk:       "xvdb",
+							Filesystem: "xfs",
+						},
+						Kube2IamSupport: api.Kube2IamSupport{
+							Enabled: true,
+						},
+						LoadBalancer: api.LoadBalancer{
+							Enabled:          true,
+							Names:            []string{"manuallymanagedlb"},
+							SecurityGroupIds: []string{"sg-12345678"},
+						},
+						TargetGroup: api.TargetGroup{
+							Enabled:          true,
+							Arns:             []string{"arn:aws:elasticloadbalancing:eu-west-1:xxxxxxxxxxxx:targetgroup/manuallymanagedetg/xxxxxxxxxxxxxxxx"},
+							SecurityGroupIds: []string{"sg-12345678"},
+						},
+						NodeDrainer: api.NodeDrainer{
+							Enabled:      false,
+							DrainTimeout: 0,
+						},
+					}
+					p := c.NodePools[0]
+					if reflect.DeepEqual(expected, p.Experimental) {
+						t.Errorf("experimental settings for node pool didn't match : expected=%v actual=%v", expected, p.Experimental)
+					}
+
+					expectedNodeLabels := api.NodeLabels{
+						"kube-aws.coreos.com/cluster-autoscaler-supported": "true",
+						"kube-aws.coreos.com/role":                         "worker",
+					}
+					actualNodeLabels := c.NodePools[0].NodeLabels()
+					if !reflect.DeepEqual(expectedNodeLabels, actualNodeLabels) {
+						t.Errorf("worker node labels didn't match: expected=%v, actual=%v", expectedNodeLabels, actualNodeLabels)
+					}
+
+					expectedTaints := api.Taints{
+						{Key: "reservation", Value: "spot", Effect: "NoSchedule"},
+					}
+					actualTaints := c.NodePools[0].Taints
+					if !reflect.DeepEqual(expectedTaints, actualTaints) {
+						t.Errorf("worker node taints didn't match: expected=%v, actual=%v", expectedTaints, actualTaints)
+					}
+				},
+			},
+		},
+		{
+			context: "WithExperimentalFeatureKiam",
+			configYaml: minimalValidConfigYaml + `
+experimental:
+  kiamSupport:
+    enabled: true
+    image:
+      repo: quay.io/uswitch/kiam
+      tag: v2.6
+    sessionDuration: 30m	
+    serverAddresses:
+      serverAddress: localhost
+      agentAddress: kiam-server
+worker:
+  nodePools:
+  - name: pool1
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					expected := api.KIAMSupport{
+						Enabled:         true,
+						Image:           api.Image{Repo: "quay.io/uswitch/kiam", Tag: "v2.6", RktPullDocker: false},
+						SessionDuration: "30m",
+						ServerAddresses: api.KIAMServerAddresses{ServerAddress: "localhost", AgentAddress: "kiam-server"},
+					}
+
+					actual := c.Experimental
+
+					if !reflect.DeepEqual(expected, actual.KIAMSupport) {
+						t.Errorf("experimental settings didn't match : expected=%+v actual=%+v", expected, actual)
+					}
+
+					p := c.NodePools[0]
+					if reflect.DeepEqual(expected, p.Experimental.KIAMSupport) {
+						t.Errorf("experimental settings shouldn't be inherited to a node pool but it did : toplevel=%v nodepool=%v", expected, p.Experimental)
+					}
+				},
+			},
+		},
+		{
+			context: "WithExperimentalFeatureKiamForWorkerNodePool",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    kiamSupport:
+      enabled: true
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					expected := api.Experimental{
+						KIAMSupport: api.KIAMSupport{
+							Enabled:         true,
+							Image:           api.Image{Repo: "quay.io/uswitch/kiam", Tag: "v2.8", RktPullDocker: false},
+							SessionDuration: "15m",
+							ServerAddresses: api.KIAMServerAddresses{ServerAddress: "localhost:443", AgentAddress: "kiam-server:443"},
+						},
+					}
+					p := c.NodePools[0]
+					if reflect.DeepEqual(expected, p.Experimental) {
+						t.Errorf("experimental settings for node pool didn't match : expected=%v actual=%v", expected, p.Experimental)
+					}
+				},
+			},
+		},
+		{
+			context: "WithKube2IamSupport",
+			configYaml: minimalValidConfigYaml + `
+controller:
+  iam:
+    role:
+      name: myrole1
+experimental:
+  kube2IamSupport:
+    enabled: true
+worker:
+  nodePools:
+  - name: pool1
+    iam:
+      role:
+        name: myrole2
+    kube2IamSupport:
+      enabled: true
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultEtcdSettings,
+				asgBasedNodePoolHasWaitSignalEnabled,
+				func(c *config.Config, t *testing.T) {
+					expectedControllerRoleName := "myrole1"
+					expectedWorkerRoleName := "myrole2"
+
+					if expectedControllerRoleName != c.Controller.IAMConfig.Role.Name {
+						t.Errorf("controller's iam.role.name didn't match : expected=%v actual=%v", expectedControllerRoleName, c.Controller.IAMConfig.Role.Name)
+					}
+
+					if !c.Experimental.Kube2IamSupport.Enabled {
+						t.Errorf("controller's experimental.kube2IamSupport should be enabled but was not: %+v", c.Experimental)
+					}
+
+					p := c.NodePools[0]
+					if expectedWorkerRoleName != p.IAMConfig.Role.Name {
+						t.Errorf("worker node pool's iam.role.name didn't match : expected=%v actual=%v", expectedWorkerRoleName, p.IAMConfig.Role.Name)
+					}
+
+					if !p.Kube2IamSupport.Enabled {
+						t.Errorf("worker node pool's kube2IamSupport should be enabled but was not: %+v", p.Experimental)
+					}
+				},
+			},
+		},
+		{
+			context:    "WithControllerIAMDefaultManageExternally",
+			configYaml: minimalValidConfigYaml,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					expectedValue := false
+
+					if c.Controller.IAMConfig.Role.ManageExternally != expectedValue {
+						t.Errorf("controller's iam.role.manageExternally didn't match : expected=%v actual=%v", expectedValue, c.Controller.IAMConfig.Role.ManageExternally)
+					}
+				},
+			},
+		},
+		{
+			context: "WithControllerIAMEnabledManageExternally",
+			configYaml: minimalValidConfigYaml + `
+controller:
+  iam:
+   role:
+     name: myrole1
+     manageExternally: true
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					expectedManageExternally := true
+					expectedRoleName := "myrole1"
+
+					if expectedRoleName != c.Controller.IAMConfig.Role.Name {
+						t.Errorf("controller's iam.role.name didn't match : expected=%v actual=%v", expectedRoleName, c.Controller.IAMConfig.Role.Name)
+					}
+
+					if expectedManageExternally != c.Controller.IAMConfig.Role.ManageExternally {
+						t.Errorf("controller's iam.role.manageExternally didn't matchg : expected=%v actual=%v", expectedManageExternally, c.Controller.IAMConfig.Role.ManageExternally)
+					}
+				},
+			},
+		},
+		{
+			context: "WithControllerIAMEnabledStrictName",
+			configYaml: minimalValidConfigYaml + `
+controller:
+  iam:
+   role:
+     name: myrole1
+     strictName: true
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					expectedRoleName := "myrole1"
+					if expectedRoleName != c.Controller.IAMConfig.Role.Name {
+						t.Errorf("controller's iam.role.name didn't match : expected=%v actual=%v", expectedRoleName, c.Controller.IAMConfig.Role.Name)
+					}
+				},
+			},
+		},
+		{
+			context: "WithWaitSignalDisabled",
+			configYaml: minimalValidConfigYaml + `
+waitSignal:
+  enabled: false
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultEtcdSettings,
+				func(c *config.Config, t *testing.T) {
+					if c.WaitSignal.Enabled() {
+						t.Errorf("waitSignal should be disabled but was not: %v", c.WaitSignal)
+					}
+				},
+			},
+		},
+		{
+			context: "WithWaitSignalEnabled",
+			configYaml: minimalValidConfigYaml + `
+waitSignal:
+  enabled: true
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultEtcdSettings,
+				func(c *config.Config, t *testing.T) {
+					if !c.WaitSignal.Enabled() {
+						t.Errorf("waitSignal should be enabled but was not: %v", c.WaitSignal)
+					}
+				},
+			},
+		},
+		{
+			context: "WithNodePoolWithWaitSignalDisabled",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    waitSignal:
+      enabled: false
+  - name: pool2
+    waitSignal:
+      enabled: false
+      maxBatchSize: 2
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultEtcdSettings,
+				func(c *config.Config, t *testing.T) {
+					if c.NodePools[0].WaitSignal.Enabled() {
+						t.Errorf("waitSignal should be disabled for node pool at index %d but was not", 0)
+					}
+					if c.NodePools[1].WaitSignal.Enabled() {
+						t.Errorf("waitSignal should be disabled for node pool at index %d but was not", 1)
+					}
+				},
+			},
+		},
+		{
+			context: "WithNodePoolWithWaitSignalEnabled",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    waitSignal:
+      enabled: true
+  - name: pool2
+    waitSignal:
+      enabled: true
+      maxBatchSize: 2
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultEtcdSettings,
+				func(c *config.Config, t *testing.T) {
+					if !c.NodePools[0].WaitSignal.Enabled() {
+						t.Errorf("waitSignal should be enabled for node pool at index %d but was not", 0)
+					}
+					if c.NodePools[0].WaitSignal.MaxBatchSize() != 1 {
+						t.Errorf("waitSignal.maxBatchSize should be 1 for node pool at index %d but was %d", 0, c.NodePools[0].WaitSignal.MaxBatchSize())
+					}
+					if !c.NodePools[1].WaitSignal.Enabled() {
+						t.Errorf("waitSignal should be enabled for node pool at index %d but was not", 1)
+					}
+					if c.NodePools[1].WaitSignal.MaxBatchSize() != 2 {
+						t.Errorf("waitSignal.maxBatchSize should be 2 for node pool at index %d but was %d", 1, c.NodePools[1].WaitSignal.MaxBatchSize())
+					}
+				},
+			},
+		},
+		{
+			context: "WithDefaultNodePoolRollingStrategy",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultNodePoolRollingStrategy,
+			},
+		},
+		{
+			context: "WithSpecificNodePoolRollingStrategy",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    nodePoolRollingStrategy: Sequential`,
+			assertConfig: []ConfigTester{
+				hasSpecificNodePoolRollingStrategy("Sequential"),
+			},
+		},
+		{
+			context: "WithSpecificWorkerRollingStrategy",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePoolRollingStrategy: Sequential
+  nodePools:
+  - name: pool1`,
+			assertConfig: []ConfigTester{
+				hasSpecificNodePoolRollingStrategy("Sequential"),
+			},
+		},
+		{
+			context: "WithWorkerAndNodePoolStrategy",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePoolRollingStrategy: Sequential
+  nodePools:
+  - name: pool1
+  - name: pool2
+    nodePoolRollingStrategy: Parallel
+`,
+			assertConfig: []ConfigTester{
+				hasWorkerAndNodePoolStrategy("Sequential", "Parallel"),
+			},
+		},
+		{
+			context:    "WithMinimalValidConfig",
+			configYaml: minimalValidConfigYaml,
+			assertConfig: []ConfigTester{
+				hasDefaultEtcdSettings,
+				hasDefaultExperimentalFeatures,
+			},
+		},
+		{
+			context: "WithVaryingWorkerCountPerNodePool",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+  - name: pool2
+    count: 2
+  - name: pool3
+    count: 0
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultEtcdSettings,
+				hasDefaultExperimentalFeatures,
+				func(c *config.Config, t *testing.T) {
+					if c.NodePools[0].Count != 1 {
+						t.Errorf("default worker count should be 1 but was: %d", c.NodePools[0].Count)
+					}
+					if c.NodePools[1].Count != 2 {
+						t.Errorf("worker count should be set to 2 but was: %d", c.NodePools[1].Count)
+					}
+					if c.NodePools[2].Count != 0 {
+						t.Errorf("worker count should be be set to 0 but was: %d", c.NodePools[2].Count)
+					}
+				},
+			},
+		},
+		{
+			context: "WithVaryingWorkerASGSizePerNodePool",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+  - name: pool2
+    count: 2
+  - name: pool3
+    autoScalingGroup:
+      minSize: 0
+      maxSize: 10
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultEtcdSettings,
+				hasDefaultExperimentalFeatures,
+				func(c *config.Config, t *testing.T) {
+					if c.NodePools[0].MaxCount() != 1 {
+						t.Errorf("worker max count should be 1 but was: %d", c.NodePools[0].MaxCount())
+					}
+					if c.NodePools[0].MinCount() != 1 {
+						t.Errorf("worker min count should be 1 but was: %d", c.NodePools[0].MinCount())
+					}
+					if c.NodePools[1].MaxCount() != 2 {
+						t.Errorf("worker max count should be 2 but was: %d", c.NodePools[1].MaxCount())
+					}
+					if c.NodePools[1].MinCount() != 2 {
+						t.Errorf("worker min count should be 2 but was: %d", c.NodePools[1].MinCount())
+					}
+					if c.NodePools[2].MaxCount() != 10 {
+						t.Errorf("worker max count should be 10 but was: %d", c.NodePools[2].MaxCount())
+					}
+					if c.NodePools[2].MinCount() != 0 {
+						t.Errorf("worker min count should be 0 but was: %d", c.NodePools[2].MinCount())
+					}
+				},
+			},
+		},
+		{
+			context: "WithMultiAPIEndpoints",
+			configYaml: kubeAwsSettings.mainClusterYamlWithoutAPIEndpoint() + `
+vpc:
+  id: vpc-1a2b3c4d
+internetGateway:
+  id: igw-1a2b3c4d
+
+subnets:
+- name: privateSubnet1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.1.0/24"
+  private: true
+- name: privateSubnet2
+  availabilityZone: us-west-1b
+  instanceCIDR: "10.0.2.0/24"
+  private: true
+- name: publicSubnet1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.3.0/24"
+- name: publicSubnet2
+  availabilityZone: us-west-1b
+  instanceCIDR: "10.0.4.0/24"
+
+worker:
+  # cant be possibly "unversioned" one w/ existing elb because doing so would result in a worker kubelet has chances to
+  # connect to multiple masters from different clusters!
+  apiEndpointName: versionedPrivate
+  # btw apiEndpointName can be defaulted to a private/public managed(hence unstable/possibly versioned but not stable/unversioned)
+  # elb/round-robin if and only if there is only one. However we dont do the complex defaulting like that for now.
+
+adminAPIEndpointName: versionedPublic
+
+apiEndpoints:
+- name: unversionedPublic
+  dnsName: api.example.com
+  loadBalancer:
+    id: elb-internet-facing
+    ##you cant configure existing elb like below
+    #private: true
+    #subnets:
+    #- name: privateSubnet1
+    ##hostedZone must be omitted when elb id is specified.
+    ##in other words, it your responsibility to create an alias record for the elb
+    #hostedZone:
+    #  id: hostedzone-private
+- name: unversionedPrivate
+  dnsName: api.internal.example.com
+  loadBalancer:
+    id: elb-internal
+- name: versionedPublic
+  dnsName: v1api.example.com
+  loadBalancer:
+    subnets:
+    - name: publicSubnet1
+    hostedZone:
+      id: hostedzone-public
+- name: versionedPrivate
+  dnsName: v1api.internal.example.com
+  loadBalancer:
+    private: true
+    subnets:
+    - name: privateSubnet1
+    hostedZone:
+      id: hostedzone-private
+- name: versionedPublicAlt
+  dnsName: v1apialt.example.com
+  loadBalancer:
+    # "private: false" implies all the private subnets defined in the top-level "subnets"
+    #subnets:
+    #- name: publicSubnet1
+    #- name: publicSubnet2
+    hostedZone:
+      id: hostedzone-public
+- name: versionedPrivateAlt
+  dnsName: v1apialt.internal.example.com
+  loadBalancer:
+    private: true
+    # "private: true" implies all the private subnets defined in the top-level "subnets"
+    #subnets:
+    #- name: privateSubnet1
+    #- name: privateSubnet2
+    hostedZone:
+      id: hostedzone-private
+- name: addedToCertCommonNames
+  dnsName: api-alt.example.com
+  loadBalancer:
+    managed: false
+- name: elbOnly
+  dnsName: registerme.example.com
+  loadBalancer:
+    recordSetManaged: false
+`,
+			assertCluster: []ClusterTester{
+				func(rootCluster *root.Cluster, t *testing.T) {
+					c := rootCluster.ControlPlane().Config
+
+					private1 := api.NewPrivateSubnet("us-west-1a", "10.0.1.0/24")
+					private1.Name = "privateSubnet1"
+
+					private2 := api.NewPrivateSubnet("us-west-1b", "10.0.2.0/24")
+					private2.Name = "privateSubnet2"
+
+					public1 := api.NewPublicSubnet("us-west-1a", "10.0.3.0/24")
+					public1.Name = "publicSubnet1"
+
+					public2 := api.NewPublicSubnet("us-west-1b", "10.0.4.0/24")
+					public2.Name = "publicSubnet2"
+					//private1 := api.NewPrivateSubnetFromFn("us-west-1a", `{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-PrivateSubnet1"}}`)
+					//private1.Name = "privateSubnet1"
+					//
+					//private2 := api.NewPrivateSubnetFromFn("us-west-1b", `{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-PrivateSubnet2"}}`)
+					//private2.Name = "privateSubnet2"
+					//
+					//public1 := api.NewPublicSubnetFromFn("us-west-1a", `{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-PublicSubnet1"}}`)
+					//public1.Name = "publicSubnet1"
+					//
+					//public2 := api.NewPublicSubnetFromFn("us-west-1b", `{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-PublicSubnet2"}}`)
+					//public2.Name = "publicSubnet2"
+
+					subnets := api.Subnets{
+						private1,
+						private2,
+						public1,
+						public2,
+					}
+					if !reflect.DeepEqual(c.AllSubnets(), subnets) {
+						t.Errorf("Managed subnets didn't match: expected=%+v actual=%+v", subnets, c.AllSubnets())
+					}
+
+					publicSubnets := api.Subnets{
+						public1,
+						public2,
+					}
+
+					privateSubnets := api.Subnets{
+						private1,
+						private2,
+					}
+
+					unversionedPublic := c.APIEndpoints["unversionedPublic"]
+					unversionedPrivate := c.APIEndpoints["unversionedPrivate"]
+					versionedPublic := c.APIEndpoints["versionedPublic"]
+					versionedPrivate := c.APIEndpoints["versionedPrivate"]
+					versionedPublicAlt := c.APIEndpoints["versionedPublicAlt"]
+					versionedPrivateAlt := c.APIEndpoints["versionedPrivateAlt"]
+					addedToCertCommonNames := c.APIEndpoints["addedToCertCommonNames"]
+					elbOnly := c.APIEndpoints["elbOnly"]
+
+					if len(unversionedPublic.LoadBalancer.Subnets) != 0 {
+						t.Errorf("unversionedPublic: subnets shuold be empty but was not: actual=%+v", unversionedPublic.LoadBalancer.Subnets)
+					}
+					if !unversionedPublic.LoadBalancer.Enabled() {
+						t.Errorf("unversionedPublic: it should be enabled as the lb to which controller nodes are added, but it was not: loadBalancer=%+v", unversionedPublic.LoadBalancer)
+					}
+
+					if len(unversionedPrivate.LoadBalancer.Subnets) != 0 {
+						t.Errorf("unversionedPrivate: subnets shuold be empty but was not: actual=%+v", unversionedPrivate.LoadBalancer.Subnets)
+					}
+					if !unversionedPrivate.LoadBalancer.Enabled() {
+						t.Errorf("unversionedPrivate: it should be enabled as the lb to which controller nodes are added, but it was not: loadBalancer=%+v", unversionedPrivate.LoadBalancer)
+					}
+
+					if diff := cmp.Diff(versionedPublic.LoadBalancer.Subnets, api.Subnets{public1}); diff != "" {
+						t.Errorf("versionedPublic: subnets didn't match: %s", diff)
+					}
+					if !versionedPublic.LoadBalancer.Enabled() {
+						t.Errorf("versionedPublic: it should be enabled as the lb to which controller nodes are added, but it was not: loadBalancer=%+v", versionedPublic.LoadBalancer)
+					}
+
+					if diff := cmp.Diff(versionedPrivate.LoadBalancer.Subnets, api.Subnets{private1}); diff != "" {
+						t.Errorf("versionedPrivate: subnets didn't match: %s", diff)
+					}
+					if !versionedPrivate.LoadBalancer.Enabled() {
+						t.Errorf("versionedPrivate: it should be enabled as the lb to which controller nodes are added, but it was not: loadBalancer=%+v", versionedPrivate.LoadBalancer)
+					}
+
+					if diff := cmp.Diff(versionedPublicAlt.LoadBalancer.Subnets, publicSubnets); diff != "" {
+						t.Errorf("versionedPublicAlt: subnets didn't match: %s", diff)
+					}
+					if !versionedPublicAlt.LoadBalancer.Enabled() {
+						t.Errorf("versionedPublicAlt: it should be enabled as the lb to which controller nodes are added, but it was not: loadBalancer=%+v", versionedPublicAlt.LoadBalancer)
+					}
+
+					if diff := cmp.Diff(versionedPrivateAlt.LoadBalancer.Subnets, privateSubnets); diff != "" {
+						t.Errorf("versionedPrivateAlt: subnets didn't match: %s", diff)
+					}
+					if !versionedPrivateAlt.LoadBalancer.Enabled() {
+						t.Errorf("versionedPrivateAlt: it should be enabled as the lb to which controller nodes are added, but it was not: loadBalancer=%+v", versionedPrivateAlt.LoadBalancer)
+					}
+
+					if len(addedToCertCommonNames.LoadBalancer.Subnets) != 0 {
+						t.Errorf("addedToCertCommonNames: subnets should be empty but was not: actual=%+v", addedToCertCommonNames.LoadBalancer.Subnets)
+					}
+					if addedToCertCommonNames.LoadBalancer.Enabled() {
+						t.Errorf("addedToCertCommonNames: it should not be enabled as the lb to which controller nodes are added, but it was: loadBalancer=%+v", addedToCertCommonNames.LoadBalancer)
+					}
+
+					if diff := cmp.Diff(elbOnly.LoadBalancer.Subnets, publicSubnets); diff != "" {
+						t.Errorf("elbOnly: subnets didn't match: %s", diff)
+					}
+					if !elbOnly.LoadBalancer.Enabled() {
+						t.Errorf("elbOnly: it should be enabled but it was not: loadBalancer=%+v", elbOnly.LoadBalancer)
+					}
+					if elbOnly.LoadBalancer.ManageELBRecordSet() {
+						t.Errorf("elbOnly: record set should not be managed but it was: loadBalancer=%+v", elbOnly.LoadBalancer)
+					}
+
+					if diff := cmp.Diff(c.ExternalDNSNames(), []string{"api-alt.example.com", "api.example.com", "api.internal.example.com", "registerme.example.com", "v1api.example.com", "v1api.internal.example.com", "v1apialt.example.com", "v1apialt.internal.example.com"}); diff != "" {
+						t.Errorf("unexpected external DNS names: %s", diff)
+					}
+
+					if !reflect.DeepEqual(c.APIEndpoints.ManagedELBLogicalNames(), []string{"APIEndpointElbOnlyELB", "APIEndpointVersionedPrivateAltELB", "APIEndpointVersionedPrivateELB", "APIEndpointVersionedPublicAltELB", "APIEndpointVersionedPublicELB"}) {
+						t.Errorf("unexpected managed ELB logical names: %s", strings.Join(c.APIEndpoints.ManagedELBLogicalNames(), ", "))
+					}
+				},
+			},
+		},
+		{
+			context: "WithNetworkTopologyExplicitSubnets",
+			configYaml: mainClusterYaml + `
+vpc:
+  id: vpc-1a2b3c4d
+internetGateway:
+  id: igw-1a2b3c4d
+# routeTableId must be omitted
+# See https://github.com/kubernetes-incubator/kube-aws/pull/284#issuecomment-275962332
+# routeTableId: rtb-1a2b3c4d
+subnets:
+- name: private1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.1.0/24"
+  private: true
+- name: private2
+  availabilityZone: us-west-1b
+  instanceCIDR: "10.0.2.0/24"
+  private: true
+- name: public1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.3.0/24"
+- name: public2
+  availabilityZone: us-west-1b
+  instanceCIDR: "10.0.4.0/24"
+controller:
+  subnets:
+  - name: private1
+  - name: private2
+  loadBalancer:
+    subnets:
+    - name: public1
+    - name: public2
+    private: false
+etcd:
+  subnets:
+  - name: private1
+  - name: private2
+worker:
+  nodePools:
+  - name: pool1
+    subnets:
+    - name: public1
+    - name: public2
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultExperimentalFeatures,
+				everyPublicSubnetHasRouteToIGW,
+				hasTwoManagedNGWsAndEIPs,
+				func(c *config.Config, t *testing.T) {
+					private1 := api.NewPrivateSubnet("us-west-1a", "10.0.1.0/24")
+					private1.Name = "private1"
+
+					private2 := api.NewPrivateSubnet("us-west-1b", "10.0.2.0/24")
+					private2.Name = "private2"
+
+					public1 := api.NewPublicSubnet("us-west-1a", "10.0.3.0/24")
+					public1.Name = "public1"
+
+					public2 := api.NewPublicSubnet("us-west-1b", "10.0.4.0/24")
+					public2.Name = "public2"
+
+					subnets := api.Subnets{
+						private1,
+						private2,
+						public1,
+						public2,
+					}
+					if !reflect.DeepEqual(c.AllSubnets(), subnets) {
+						t.Errorf("Managed subnets didn't match: expected=%v actual=%v", subnets, c.AllSubnets())
+					}
+
+					publicSubnets := api.Subnets{
+						public1,
+						public2,
+					}
+					importedPublicSubnets := api.Subnets{
+						api.NewPublicSubnetFromFn("us-west-1a", `{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-Public1"}}`),
+						api.NewPublicSubnetFromFn("us-west-1b", `{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-Public2"}}`),
+					}
+
+					p := c.NodePools[0]
+					if !reflect.DeepEqual(p.Subnets, importedPublicSubnets) {
+						t.Errorf("Worker subnets didn't match: expected=%v actual=%v", importedPublicSubnets, p.Subnets)
+					}
+
+					privateSubnets := api.Subnets{
+						private1,
+						private2,
+					}
+					if !reflect.DeepEqual(c.Controller.Subnets, privateSubnets) {
+						t.Errorf("Controller subnets didn't match: expected=%v actual=%v", privateSubnets, c.Controller.Subnets)
+					}
+					if !reflect.DeepEqual(c.Controller.LoadBalancer.Subnets, publicSubnets) {
+						t.Errorf("Controller loadbalancer subnets didn't match: expected=%v actual=%v", publicSubnets, c.Controller.LoadBalancer.Subnets)
+					}
+					if diff := cmp.Diff(c.Etcd.Subnets, privateSubnets); diff != "" {
+						t.Errorf("Etcd subnets didn't match: %s", diff)
+					}
+
+					for i, s := range c.PrivateSubnets() {
+						if !s.ManageNATGateway() {
+							t.Errorf("NAT gateway for the private subnet #%d should be created by kube-aws but it is not going to be", i)
+						}
+
+						if s.ManageRouteToInternet() {
+							t.Errorf("Route to IGW shouldn't be created for a private subnet: %v", s)
+						}
+					}
+				},
+			},
+		},
+		{
+			context: "WithNetworkTopologyImplicitSubnets",
+			configYaml: mainClusterYaml + `
+vpc:
+  id: vpc-1a2b3c4d
+internetGateway:
+  id: igw-1a2b3c4d
+# routeTableId must be omitted
+# See https://github.com/kubernetes-incubator/kube-aws/pull/284#issuecomment-275962332
+# routeTableId: rtb-1a2b3c4d
+subnets:
+- name: private1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.1.0/24"
+  private: true
+- name: private2
+  availabilityZone: us-west-1b
+  instanceCIDR: "10.0.2.0/24"
+  private: true
+- name: public1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.3.0/24"
+- name: public2
+  availabilityZone: us-west-1b
+  instanceCIDR: "10.0.4.0/24"
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultExperimentalFeatures,
+				everyPublicSubnetHasRouteToIGW,
+				hasTwoManagedNGWsAndEIPs,
+				func(c *config.Config, t *testing.T) {
+					private1 := api.NewPrivateSubnet("us-west-1a", "10.0.1.0/24")
+					private1.Name = "private1"
+
+					private2 := api.NewPrivateSubnet("us-west-1b", "10.0.2.0/24")
+					private2.Name = "private2"
+
+					public1 := api.NewPublicSubnet("us-west-1a", "10.0.3.0/24")
+					public1.Name = "public1"
+
+					public2 := api.NewPublicSubnet("us-west-1b", "10.0.4.0/24")
+					public2.Name = "public2"
+
+					subnets := api.Subnets{
+						private1,
+						private2,
+						public1,
+						public2,
+					}
+					if !reflect.DeepEqual(c.AllSubnets(), subnets) {
+						t.Errorf("Managed subnets didn't match: expected=%v actual=%v", subnets, c.AllSubnets())
+					}
+
+					publicSubnets := api.Subnets{
+						public1,
+						public2,
+					}
+
+					if !reflect.DeepEqual(c.Controller.Subnets, publicSubnets) {
+						t.Errorf("Controller subnets didn't match: expected=%v actual=%v", publicSubnets, c.Controller.Subnets)
+					}
+					if !reflect.DeepEqual(c.Controller.LoadBalancer.Subnets, publicSubnets) {
+						t.Errorf("Controller loadbalancer subnets didn't match: expected=%v actual=%v", publicSubnets, c.Controller.LoadBalancer.Subnets)
+					}
+					if !reflect.DeepEqual(c.Etcd.Subnets, publicSubnets) {
+						t.Errorf("Etcd subnets didn't match: expected=%v actual=%v", publicSubnets, c.Etcd.Subnets)
+					}
+
+					for i, s := range c.PrivateSubnets() {
+						if !s.ManageNATGateway() {
+							t.Errorf("NAT gateway for the private subnet #%d should be created by kube-aws but it is not going to be", i)
+						}
+
+						if s.ManageRouteToInternet() {
+							t.Errorf("Route to IGW shouldn't be created for a private subnet: %v", s)
+						}
+					}
+				},
+			},
+		},
+		{
+			context: "WithNetworkTopologyControllerPrivateLB",
+			configYaml: mainClusterYaml + `
+vpc:
+  id: vpc-1a2b3c4d
+internetGateway:
+  id: igw-1a2b3c4d
+# routeTableId must be omitted
+# See https://github.com/kubernetes-incubator/kube-aws/pull/284#issuecomment-275962332
+# routeTableId: rtb-1a2b3c4d
+subnets:
+- name: private1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.1.0/24"
+  private: true
+- name: private2
+  availabilityZone: us-west-1b
+  instanceCIDR: "10.0.2.0/24"
+  private: true
+- name: public1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.3.0/24"
+- name: public2
+  availabilityZone: us-west-1b
+  instanceCIDR: "10.0.4.0/24"
+controller:
+  subnets:
+  - name: private1
+  - name: private2
+  loadBalancer:
+    private: true
+etcd:
+  subnets:
+  - name: private1
+  - name: private2
+worker:
+  nodePools:
+  - name: pool1
+    subnets:
+    - name: public1
+    - name: public2
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultExperimentalFeatures,
+				everyPublicSubnetHasRouteToIGW,
+				hasTwoManagedNGWsAndEIPs,
+				func(c *config.Config, t *testing.T) {
+					private1 := api.NewPrivateSubnet("us-west-1a", "10.0.1.0/24")
+					private1.Name = "private1"
+
+					private2 := api.NewPrivateSubnet("us-west-1b", "10.0.2.0/24")
+					private2.Name = "private2"
+
+					public1 := api.NewPublicSubnet("us-west-1a", "10.0.3.0/24")
+					public1.Name = "public1"
+
+					public2 := api.NewPublicSubnet("us-west-1b", "10.0.4.0/24")
+					public2.Name = "public2"
+
+					subnets := api.Subnets{
+						private1,
+						private2,
+						public1,
+						public2,
+					}
+					if !reflect.DeepEqual(c.AllSubnets(), subnets) {
+						t.Errorf("Managed subnets didn't match: expected=%v actual=%v", subnets, c.AllSubnets())
+					}
+
+					importedPublicSubnets := api.Subnets{
+						api.NewPublicSubnetFromFn("us-west-1a", `{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-Public1"}}`),
+						api.NewPublicSubnetFromFn("us-west-1b", `{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-Public2"}}`),
+					}
+					p := c.NodePools[0]
+					if !reflect.DeepEqual(p.Subnets, importedPublicSubnets) {
+						t.Errorf("Worker subnets didn't match: expected=%v actual=%v", importedPublicSubnets, p.Subnets)
+					}
+
+					privateSubnets := api.Subnets{
+						private1,
+						private2,
+					}
+					if !reflect.DeepEqual(c.Controller.Subnets, privateSubnets) {
+						t.Errorf("Controller subnets didn't match: expected=%v actual=%v", privateSubnets, c.Controller.Subnets)
+					}
+					if !reflect.DeepEqual(c.Controller.LoadBalancer.Subnets, privateSubnets) {
+						t.Errorf("Controller loadbalancer subnets didn't match: expected=%v actual=%v", privateSubnets, c.Controller.LoadBalancer.Subnets)
+					}
+					if !reflect.DeepEqual(c.Etcd.Subnets, privateSubnets) {
+						t.Errorf("Etcd subnets didn't match: expected=%v actual=%v", privateSubnets, c.Etcd.Subnets)
+					}
+
+					for i, s := range c.PrivateSubnets() {
+						if !s.ManageNATGateway() {
+							t.Errorf("NAT gateway for the private subnet #%d should be created by kube-aws but it is not going to be", i)
+						}
+
+						if s.ManageRouteToInternet() {
+							t.Errorf("Route to IGW shouldn't be created for a private subnet: %v", s)
+						}
+					}
+				},
+			},
+		},
+		{
+			context: "WithNetworkTopologyControllerPublicLB",
+			configYaml: mainClusterYaml + `
+vpc:
+  id: vpc-1a2b3c4d
+internetGateway:
+  id: igw-1a2b3c4d
+# routeTableId must be omitted
+# See https://github.com/kubernetes-incubator/kube-aws/pull/284#issuecomment-275962332
+# routeTableId: rtb-1a2b3c4d
+subnets:
+- name: private1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.1.0/24"
+  private: true
+- name: private2
+  availabilityZone: us-west-1b
+  instanceCIDR: "10.0.2.0/24"
+  private: true
+- name: public1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.3.0/24"
+- name: public2
+  availabilityZone: us-west-1b
+  instanceCIDR: "10.0.4.0/24"
+controller:
+  loadBalancer:
+    private: false
+etcd:
+  subnets:
+  - name: private1
+  - name: private2
+worker:
+  nodePools:
+  - name: pool1
+    subnets:
+    - name: public1
+    - name: public2
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultExperimentalFeatures,
+				everyPublicSubnetHasRouteToIGW,
+				hasTwoManagedNGWsAndEIPs,
+				func(c *config.Config, t *testing.T) {
+					private1 := api.NewPrivateSubnet("us-west-1a", "10.0.1.0/24")
+					private1.Name = "private1"
+
+					private2 := api.NewPrivateSubnet("us-west-1b", "10.0.2.0/24")
+					private2.Name = "private2"
+
+					public1 := api.NewPublicSubnet("us-west-1a", "10.0.3.0/24")
+					public1.Name = "public1"
+
+					public2 := api.NewPublicSubnet("us-west-1b", "10.0.4.0/24")
+					public2.Name = "public2"
+
+					subnets := api.Subnets{
+						private1,
+						private2,
+						public1,
+						public2,
+					}
+					publicSubnets := api.Subnets{
+						public1,
+						public2,
+					}
+					privateSubnets := api.Subnets{
+						private1,
+						private2,
+					}
+					importedPublicSubnets := api.Subnets{
+						api.NewPublicSubnetFromFn("us-west-1a", `{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-Public1"}}`),
+						api.NewPublicSubnetFromFn("us-west-1b", `{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-Public2"}}`),
+					}
+
+					if !reflect.DeepEqual(c.AllSubnets(), subnets) {
+						t.Errorf("Managed subnets didn't match: expected=%v actual=%v", subnets, c.AllSubnets())
+					}
+					p := c.NodePools[0]
+					if !reflect.DeepEqual(p.Subnets, importedPublicSubnets) {
+						t.Errorf("Worker subnets didn't match: expected=%v actual=%v", importedPublicSubnets, p.Subnets)
+					}
+					if !reflect.DeepEqual(c.Controller.Subnets, publicSubnets) {
+						t.Errorf("Controller subnets didn't match: expected=%v actual=%v", privateSubnets, c.Controller.Subnets)
+					}
+					if !reflect.DeepEqual(c.Controller.LoadBalancer.Subnets, publicSubnets) {
+						t.Errorf("Controller loadbalancer subnets didn't match: expected=%v actual=%v", privateSubnets, c.Controller.LoadBalancer.Subnets)
+					}
+					if !reflect.DeepEqual(c.Etcd.Subnets, privateSubnets) {
+						t.Errorf("Etcd subnets didn't match: expected=%v actual=%v", privateSubnets, c.Etcd.Subnets)
+					}
+
+					for i, s := range c.PrivateSubnets() {
+						if !s.ManageNATGateway() {
+							t.Errorf("NAT gateway for the private subnet #%d should be created by kube-aws but it is not going to be", i)
+						}
+
+						if s.ManageRouteToInternet() {
+							t.Errorf("Route to IGW shouldn't be created for a private subnet: %v", s)
+						}
+					}
+				},
+			},
+		},
+		{
+			context: "WithNetworkTopologyExistingVaryingSubnets",
+			configYaml: mainClusterYaml + `
+vpc:
+  id: vpc-1a2b3c4d
+subnets:
+- name: private1
+  availabilityZone: us-west-1a
+  id: subnet-1
+  private: true
+- name: private2
+  availabilityZone: us-west-1b
+  idFromStackOutput: mycluster-private-subnet-1
+  private: true
+- name: public1
+  availabilityZone: us-west-1a
+  id: subnet-2
+- name: public2
+  availabilityZone: us-west-1b
+  idFromStackOutput: mycluster-public-subnet-1
+controller:
+  loadBalancer:
+    private: false
+etcd:
+  subnets:
+  - name: private1
+  - name: private2
+worker:
+  nodePools:
+  - name: pool1
+    subnets:
+    - name: public1
+    - name: public2
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultExperimentalFeatures,
+				hasNoNGWsOrEIPsOrRoutes,
+				func(c *config.Config, t *testing.T) {
+					private1 := api.NewExistingPrivateSubnet("us-west-1a", "subnet-1")
+					private1.Name = "private1"
+
+					private2 := api.NewImportedPrivateSubnet("us-west-1b", "mycluster-private-subnet-1")
+					private2.Name = "private2"
+
+					public1 := api.NewExistingPublicSubnet("us-west-1a", "subnet-2")
+					public1.Name = "public1"
+
+					public2 := api.NewImportedPublicSubnet("us-west-1b", "mycluster-public-subnet-1")
+					public2.Name = "public2"
+
+					subnets := api.Subnets{
+						private1,
+						private2,
+						public1,
+						public2,
+					}
+					publicSubnets := api.Subnets{
+						public1,
+						public2,
+					}
+					privateSubnets := api.Subnets{
+						private1,
+						private2,
+					}
+
+					if !reflect.DeepEqual(c.AllSubnets(), subnets) {
+						t.Errorf("Managed subnets didn't match: expected=%v actual=%v", subnets, c.AllSubnets())
+					}
+					p := c.NodePools[0]
+					if !reflect.DeepEqual(p.Subnets, publicSubnets) {
+						t.Errorf("Worker subnets didn't match: expected=%v actual=%v", publicSubnets, p.Subnets)
+					}
+					if !reflect.DeepEqual(c.Controller.Subnets, publicSubnets) {
+						t.Errorf("Controller subnets didn't match: expected=%v actual=%v", privateSubnets, c.Controller.Subnets)
+					}
+					if !reflect.DeepEqual(c.Controller.LoadBalancer.Subnets, publicSubnets) {
+						t.Errorf("Controller loadbalancer subnets didn't match: expected=%v actual=%v", privateSubnets, c.Controller.LoadBalancer.Subnets)
+					}
+					if !reflect.DeepEqual(c.Etcd.Subnets, privateSubnets) {
+						t.Errorf("Etcd subnets didn't match: expected=%v actual=%v", privateSubnets, c.Etcd.Subnets)
+					}
+
+					for i, s := range c.PrivateSubnets() {
+						if s.ManageNATGateway() {
+							t.Errorf("NAT gateway for the existing private subnet #%d should not be created by kube-aws", i)
+						}
+
+						if s.ManageRouteToInternet() {
+							t.Errorf("Route to IGW shouldn't be created for a private subnet: %v", s)
+						}
+					}
+				},
+			},
+		},
+		{
+			context: "WithNetworkTopologyAllExistingPrivateSubnets",
+			configYaml: kubeAwsSettings.mainClusterYamlWithoutAPIEndpoint() + fmt.Sprintf(`
+vpc:
+  id: vpc-1a2b3c4d
+subnets:
+- name: private1
+  availabilityZone: us-west-1a
+  id: subnet-1
+  private: true
+- name: private2
+  availabilityZone: us-west-1b
+  idFromStackOutput: mycluster-private-subnet-1
+  private: true
+controller:
+  subnets:
+  - name: private1
+  - name: private2
+etcd:
+  subnets:
+  - name: private1
+  - name: private2
+worker:
+  nodePools:
+  - name: pool1
+    subnets:
+    - name: private1
+    - name: private2
+apiEndpoints:
+- name: public
+  dnsName: "%s"
+  loadBalancer:
+    hostedZone:
+      id: hostedzone-xxxx
+    private: true
+`, kubeAwsSettings.externalDNSName),
+			assertConfig: []ConfigTester{
+				hasDefaultExperimentalFeatures,
+				hasNoNGWsOrEIPsOrRoutes,
+			},
+		},
+		{
+			context: "WithNetworkTopologyAllExistingPublicSubnets",
+			configYaml: mainClusterYaml + `
+vpc:
+  id: vpc-1a2b3c4d
+subnets:
+- name: public1
+  availabilityZone: us-west-1a
+  id: subnet-2
+- name: public2
+  availabilityZone: us-west-1b
+  idFromStackOutput: mycluster-public-subnet-1
+etcd:
+  subnets:
+  - name: public1
+  - name: public2
+worker:
+  nodePools:
+  - name: pool1
+    subnets:
+    - name: public1
+    - name: public2
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultExperimentalFeatures,
+				hasNoNGWsOrEIPsOrRoutes,
+			},
+		},
+		{
+			context: "WithNetworkTopologyExistingNATGateways",
+			configYaml: mainClusterYaml + `
+vpc:
+  id: vpc-1a2b3c4d
+internetGateway:
+  id: igw-1a2b3c4d
+subnets:
+- name: private1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.1.0/24"
+  private: true
+  natGateway:
+    id: ngw-11111111
+- name: private2
+  availabilityZone: us-west-1b
+  instanceCIDR: "10.0.2.0/24"
+  private: true
+  natGateway:
+    id: ngw-22222222
+- name: public1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.3.0/24"
+- name: public2
+  availabilityZone: us-west-1b
+  instanceCIDR: "10.0.4.0/24"
+etcd:
+  subnets:
+  - name: private1
+  - name: private2
+worker:
+  nodePools:
+  - name: pool1
+    subnets:
+    - name: public1
+    - name: public2
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultExperimentalFeatures,
+				hasNoManagedNGWsButSpecificNumOfRoutesToUnmanagedNGWs(2),
+				func(c *config.Config, t *testing.T) {
+					private1 := api.NewPrivateSubnetWithPreconfiguredNATGateway("us-west-1a", "10.0.1.0/24", "ngw-11111111")
+					private1.Name = "private1"
+
+					private2 := api.NewPrivateSubnetWithPreconfiguredNATGateway("us-west-1b", "10.0.2.0/24", "ngw-22222222")
+					private2.Name = "private2"
+
+					public1 := api.NewPublicSubnet("us-west-1a", "10.0.3.0/24")
+					public1.Name = "public1"
+
+					public2 := api.NewPublicSubnet("us-west-1b", "10.0.4.0/24")
+					public2.Name = "public2"
+
+					subnets := api.Subnets{
+						private1,
+						private2,
+						public1,
+						public2,
+					}
+					publicSubnets := api.Subnets{
+						public1,
+						public2,
+					}
+					privateSubnets := api.Subnets{
+						private1,
+						private2,
+					}
+					importedPublicSubnets := api.Subnets{
+						api.NewPublicSubnetFromFn("us-west-1a", `{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-Public1"}}`),
+						api.NewPublicSubnetFromFn("us-west-1b", `{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-Public2"}}`),
+					}
+
+					if diff := cmp.Diff(c.AllSubnets(), subnets); diff != "" {
+						t.Errorf("Managed subnets didn't match: %s", diff)
+					}
+					p := c.NodePools[0]
+					if diff := cmp.Diff(p.Subnets, importedPublicSubnets); diff != "" {
+						t.Errorf("Worker subnets didn't match: %s", diff)
+					}
+					if diff := cmp.Diff(c.Controller.Subnets, publicSubnets); diff != "" {
+						t.Errorf("Controller subnets didn't match: %s", diff)
+					}
+					if diff := cmp.Diff(c.Controller.LoadBalancer.Subnets, publicSubnets); diff != "" {
+						t.Errorf("Controller loadbalancer subnets didn't match: %s", diff)
+					}
+					if diff := cmp.Diff(c.Etcd.Subnets, privateSubnets); diff != "" {
+						t.Errorf("Etcd subnets didn't match: %s", diff)
+					}
+
+					for i, s := range c.PrivateSubnets() {
+						if s.ManageNATGateway() {
+							t.Errorf("NAT gateway for the existing private subnet #%d should not be created by kube-aws", i)
+						}
+
+						if s.ManageRouteToInternet() {
+							t.Errorf("Route to IGW shouldn't be created for a private subnet: %v", s)
+						}
+					}
+				},
+			},
+		},
+		{
+			context: "WithNetworkTopologyExistingNATGatewayEIPs",
+			configYaml: mainClusterYaml + `
+vpc:
+  id: vpc-1a2b3c4d
+internetGateway:
+  id: igw-1a2b3c4d
+subnets:
+- name: private1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.1.0/24"
+  private: true
+  natGateway:
+    eipAllocationId: eipalloc-11111111
+- name: private2
+  availabilityZone: us-west-1b
+  instanceCIDR: "10.0.2.0/24"
+  private: true
+  natGateway:
+    eipAllocationId: eipalloc-22222222
+- name: public1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.3.0/24"
+- name: public2
+  availabilityZone: us-west-1b
+  instanceCIDR: "10.0.4.0/24"
+etcd:
+  subnets:
+  - name: private1
+  - name: private2
+worker:
+  nodePools:
+  - name: pool1
+    subnets:
+    - name: public1
+    - name: public2
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultExperimentalFeatures,
+				hasSpecificNumOfManagedNGWsWithUnmanagedEIPs(2),
+				hasPrivateSubnetsWithManagedNGWs(2),
+				func(c *config.Config, t *testing.T) {
+					private1 := api.NewPrivateSubnetWithPreconfiguredNATGatewayEIP("us-west-1a", "10.0.1.0/24", "eipalloc-11111111")
+					private1.Name = "private1"
+
+					private2 := api.NewPrivateSubnetWithPreconfiguredNATGatewayEIP("us-west-1b", "10.0.2.0/24", "eipalloc-22222222")
+					private2.Name = "private2"
+
+					public1 := api.NewPublicSubnet("us-west-1a", "10.0.3.0/24")
+					public1.Name = "public1"
+
+					public2 := api.NewPublicSubnet("us-west-1b", "10.0.4.0/24")
+					public2.Name = "public2"
+
+					subnets := api.Subnets{
+						private1,
+						private2,
+						public1,
+						public2,
+					}
+					publicSubnets := api.Subnets{
+						public1,
+						public2,
+					}
+					privateSubnets := api.Subnets{
+						private1,
+						private2,
+					}
+					importedPublicSubnets := api.Subnets{
+						api.NewPublicSubnetFromFn("us-west-1a", `{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-Public1"}}`),
+						api.NewPublicSubnetFromFn("us-west-1b", `{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-Public2"}}`),
+					}
+
+					if diff := cmp.Diff(c.AllSubnets(), subnets); diff != "" {
+						t.Errorf("Managed subnets didn't match: %s", diff)
+					}
+					p := c.NodePools[0]
+					if diff := cmp.Diff(p.Subnets, importedPublicSubnets); diff != "" {
+						t.Errorf("Worker subnets didn't match: %s", diff)
+					}
+					if diff := cmp.Diff(c.Controller.Subnets, publicSubnets); diff != "" {
+						t.Errorf("Controller subnets didn't match: %s", diff)
+					}
+					if diff := cmp.Diff(c.Controller.LoadBalancer.Subnets, publicSubnets); diff != "" {
+						t.Errorf("Controller loadbalancer subnets didn't match: %s", diff)
+					}
+					if diff := cmp.Diff(c.Etcd.Subnets, privateSubnets); diff != "" {
+						t.Errorf("Etcd subnets didn't match: %s", diff)
+					}
+				},
+			},
+		},
+		{
+			context: "WithNetworkTopologyVaryingPublicSubnets",
+			configYaml: mainClusterYaml + `
+vpc:
+  id: vpc-1a2b3c4d
+#required only for the managed subnet "public1"
+# "public2" is assumed to have an existing route table and an igw already associated to it
+internetGateway:
+  id: igw-1a2b3c4d
+subnets:
+- name: public1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.1.0/24"
+- name: public2
+  availabilityZone: us-west-1b
+  id: subnet-2
+controller:
+  loadBalancer:
+    private: false
+etcd:
+  subnets:
+  - name: public1
+  - name: public2
+worker:
+  nodePools:
+  - name: pool1
+    subnets:
+    - name: public1
+    - name: public2
+`,
+			assertConfig: []ConfigTester{},
+		},
+		{
+			context: "WithSpotFleetEnabled",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    spotFleet:
+      targetCapacity: 10
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultExperimentalFeatures,
+				hasDefaultLaunchSpecifications,
+				spotFleetBasedNodePoolHasWaitSignalDisabled,
+			},
+		},
+		{
+			context: "WithSpotFleetEnabledWithCustomIamRole",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    spotFleet:
+      targetCapacity: 10
+      iamFleetRoleArn: custom-iam-role
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultExperimentalFeatures,
+				hasDefaultLaunchSpecifications,
+				spotFleetBasedNodePoolHasWaitSignalDisabled,
+			},
+		},
+		{
+			context: "WithSpotFleetWithCustomGp2RootVolumeSettings",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    spotFleet:
+      targetCapacity: 10
+      unitRootVolumeSize: 40
+      launchSpecifications:
+      - weightedCapacity: 1
+        instanceType: c4.large
+      - weightedCapacity: 2
+        instanceType: c4.xlarge
+        rootVolume:
+          size: 100
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultExperimentalFeatures,
+				spotFleetBasedNodePoolHasWaitSignalDisabled,
+				func(c *config.Config, t *testing.T) {
+					expected := []api.LaunchSpecification{
+						{
+							WeightedCapacity: 1,
+							InstanceType:     "c4.large",
+							SpotPrice:        "",
+							// RootVolumeSize was not specified in the configYaml but should default to workerRootVolumeSize * weightedCapacity
+							// RootVolumeType was not specified in the configYaml but should default to "gp2"
+							RootVolume: api.NewGp2RootVolume(40),
+						},
+						{
+							WeightedCapacity: 2,
+							InstanceType:     "c4.xlarge",
+							SpotPrice:        "",
+							RootVolume:       api.NewGp2RootVolume(100),
+						},
+					}
+					p := c.NodePools[0]
+					actual := p.WorkerNodePool.SpotFleet.LaunchSpecifications
+					if !reflect.DeepEqual(expected, actual) {
+						t.Errorf(
+							"LaunchSpecifications didn't match: expected=%v actual=%v",
+							expected,
+							actual,
+						)
+					}
+				},
+			},
+		},
+		{
+			context: "WithSpotFleetWithCustomInstanceTypes",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    spotFleet:
+      targetCapacity: 10
+      unitRootVolumeSize: 40
+      launchSpecifications:
+      - weightedCapacity: 1
+        instanceType: m4.large
+      - weightedCapacity: 2
+        instanceType: m4.xlarge
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultExperimentalFeatures,
+				spotFleetBasedNodePoolHasWaitSignalDisabled,
+				func(c *config.Config, t *testing.T) {
+					expected := []api.LaunchSpecification{
+						{
+							WeightedCapacity: 1,
+							InstanceType:     "m4.large",
+							SpotPrice:        "",
+							// RootVolumeType was not specified in the configYaml but should default to gp2:
+							RootVolume: api.NewGp2RootVolume(40),
+						},
+						{
+							WeightedCapacity: 2,
+							InstanceType:     "m4.xlarge",
+							SpotPrice:        "",
+							RootVolume:       api.NewGp2RootVolume(80),
+						},
+					}
+					p := c.NodePools[0]
+					actual := p.WorkerNodePool.SpotFleet.LaunchSpecifications
+					if !reflect.DeepEqual(expected, actual) {
+						t.Errorf(
+							"LaunchSpecifications didn't match: expected=%v actual=%v",
+							expected,
+							actual,
+						)
+					}
+				},
+			},
+		},
+		{
+			context: "WithSpotFleetWithCustomIo1RootVolumeSettings",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    spotFleet:
+      targetCapacity: 10
+      rootVolumeType: io1
+      unitRootVolumeSize: 40
+      unitRootVolumeIOPS: 100
+      launchSpecifications:
+      - weightedCapacity: 1
+        instanceType: c4.large
+      - weightedCapacity: 2
+        instanceType: c4.xlarge
+        rootVolume:
+          iops: 500
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultExperimentalFeatures,
+				spotFleetBasedNodePoolHasWaitSignalDisabled,
+				func(c *config.Config, t *testing.T) {
+					expected := []api.LaunchSpecification{
+						{
+							WeightedCapacity: 1,
+							InstanceType:     "c4.large",
+							SpotPrice:        "",
+							// RootVolumeSize was not specified in the configYaml but should default to workerRootVolumeSize * weightedCapacity
+							// RootVolumeIOPS was not specified in the configYaml but should default to workerRootVolumeIOPS * weightedCapacity
+							// RootVolumeType was not specified in the configYaml but should default to "io1"
+							RootVolume: api.NewIo1RootVolume(40, 100),
+						},
+						{
+							WeightedCapacity: 2,
+							InstanceType:     "c4.xlarge",
+							SpotPrice:        "",
+							// RootVolumeType was not specified in the configYaml but should default to:
+							RootVolume: api.NewIo1RootVolume(80, 500),
+						},
+					}
+					p := c.NodePools[0]
+					actual := p.WorkerNodePool.SpotFleet.LaunchSpecifications
+					if !reflect.DeepEqual(expected, actual) {
+						t.Errorf(
+							"LaunchSpecifications didn't match: expected=%v actual=%v",
+							expected,
+							actual,
+						)
+					}
+				},
+			},
+		},
+		{
+			context: "WithVpcIdSpecified",
+			configYaml: minimalValidConfigYaml + `
+vpc:
+  id: vpc-1a2b3c4d
+internetGateway:
+  id: igw-1a2b3c4d
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultEtcdSettings,
+				hasDefaultExperimentalFeatures,
+				func(c *config.Config, t *testing.T) {
+					vpcId := "vpc-1a2b3c4d"
+					if c.VPC.ID != vpcId {
+						t.Errorf("vpc id didn't match: expected=%v actual=%v", vpcId, c.VPC.ID)
+					}
+					igwId := "igw-1a2b3c4d"
+					if c.InternetGateway.ID != igwId {
+						t.Errorf("internet gateway id didn't match: expected=%v actual=%v", igwId, c.InternetGateway.ID)
+					}
+				},
+			},
+		},
+		{
+			context: "WithLegacyVpcAndIGWIdSpecified",
+			configYaml: minimalValidConfigYaml + `
+vpcId: vpc-1a2b3c4d
+internetGatewayId: igw-1a2b3c4d
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultEtcdSettings,
+				hasDefaultExperimentalFeatures,
+				func(c *config.Config, t *testing.T) {
+					vpcId := "vpc-1a2b3c4d"
+					if c.VPC.ID != vpcId {
+						t.Errorf("vpc id didn't match: expected=%v actual=%v", vpcId, c.VPC.ID)
+					}
+					igwId := "igw-1a2b3c4d"
+					if c.InternetGateway.ID != igwId {
+						t.Errorf("internet gateway id didn't match: expected=%v actual=%v", igwId, c.InternetGateway.ID)
+					}
+				},
+			},
+		},
+		{
+			context: "WithVpcIdAndRouteTableIdSpecified",
+			configYaml: mainClusterYaml + `
+vpc:
+  id: vpc-1a2b3c4d
+subnets:
+- name: Subnet0
+  availabilityZone: ` + firstAz + `
+  instanceCIDR: "10.0.0.0/24"
+  routeTable:
+    id: rtb-1a2b3c4d
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultExperimentalFeatures,
+				func(c *config.Config, t *testing.T) {
+					subnet1 := api.NewPublicSubnetWithPreconfiguredRouteTable(firstAz, "10.0.0.0/24", "rtb-1a2b3c4d")
+					subnet1.Name = "Subnet0"
+					subnets := api.Subnets{
+						subnet1,
+					}
+					expected := api.EtcdSettings{
+						Etcd: api.Etcd{
+							EC2Instance: api.EC2Instance{
+								Count:        1,
+								InstanceType: "t2.medium",
+								RootVolume: api.RootVolume{
+									Size: 30,
+									Type: "gp2",
+									IOPS: 0,
+								},
+								Tenancy: "default",
+							},
+							DataVolume: api.DataVolume{
+								Size:      30,
+								Type:      "gp2",
+								IOPS:      0,
+								Ephemeral: false,
+							},
+							Subnets: subnets,
+							UserSuppliedArgs: api.UserSuppliedArgs{
+								QuotaBackendBytes: api.DefaultQuotaBackendBytes,
+							},
+						},
+					}
+					actual := c.EtcdSettings
+					if !reflect.DeepEqual(expected, actual) {
+						t.Errorf(
+							"EtcdSettings didn't match: expected=%v actual=%v",
+							expected,
+							actual,
+						)
+					}
+				},
+			},
+		},
+		{
+			context: "WithWorkerManagedIamRoleName",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    iam:
+      role:
+        name: "myManagedRole"
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultEtcdSettings,
+				hasDefaultExperimentalFeatures,
+				func(c *config.Config, t *testing.T) {
+					if c.NodePools[0].IAMConfig.Role.Name != "myManagedRole" {
+						t.Errorf("iam.role.name: expected=myManagedRole actual=%s", c.NodePools[0].IAMConfig.Role.Name)
+					}
+				},
+			},
+		},
+		{
+			context: "WithWorkerManagedPolicies",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    iam:
+      role:
+        managedPolicies:
+         - arn: "arn:aws:iam::aws:policy/AdministratorAccess"
+         - arn: "arn:aws:iam::000000000000:policy/myManagedPolicy"
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultEtcdSettings,
+				hasDefaultExperimentalFeatures,
+				func(c *config.Config, t *testing.T) {
+					if len(c.NodePools[0].IAMConfig.Role.ManagedPolicies) < 2 {
+						t.Errorf("iam.role.managedPolicies: incorrect number of policies expected=2 actual=%d", len(c.NodePools[0].IAMConfig.Role.ManagedPolicies))
+					}
+					if c.NodePools[0].IAMConfig.Role.ManagedPolicies[0].Arn != "arn:aws:iam::aws:policy/AdministratorAccess" {
+						t.Errorf("iam.role.managedPolicies: expected=arn:aws:iam::aws:policy/AdministratorAccess actual=%s", c.NodePools[0].IAMConfig.Role.ManagedPolicies[0].Arn)
+					}
+					if c.NodePools[0].IAMConfig.Role.ManagedPolicies[1].Arn != "arn:aws:iam::000000000000:policy/myManagedPolicy" {
+						t.Errorf("iam.role.managedPolicies: expected=arn:aws:iam::000000000000:policy/myManagedPolicy actual=%s", c.NodePools[0].IAMConfig.Role.ManagedPolicies[1].Arn)
+					}
+				},
+			},
+		},
+		{
+			context: "WithWorkerExistingInstanceProfile",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    iam:
+      instanceProfile:
+        arn: "arn:aws:iam::000000000000:instance-profile/myInstanceProfile"
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultEtcdSettings,
+				hasDefaultExperimentalFeatures,
+				func(c *config.Config, t *testing.T) {
+					if c.NodePools[0].IAMConfig.InstanceProfile.Arn != "arn:aws:iam::000000000000:instance-profile/myInstanceProfile" {
+						t.Errorf("existingInstanceProfile: expected=arn:aws:iam::000000000000:instance-profile/myInstanceProfile actual=%s", c.NodePools[0].IAMConfig.InstanceProfile.Arn)
+					}
+				},
+			},
+		},
+		{
+			context: "WithWorkerAndControllerExistingInstanceProfile",
+			configYaml: minimalValidConfigYaml + `
+controller:
+  iam:
+    instanceProfile:
+      arn: "arn:aws:iam::000000000000:instance-profile/myControllerInstanceProfile"
+worker:
+  nodePools:
+  - name: pool1
+    iam:
+      instanceProfile:
+        arn: "arn:aws:iam::000000000000:instance-profile/myInstanceProfile"
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultEtcdSettings,
+				hasDefaultExperimentalFeatures,
+				func(c *config.Config, t *testing.T) {
+					if c.Controller.IAMConfig.InstanceProfile.Arn != "arn:aws:iam::000000000000:instance-profile/myControllerInstanceProfile" {
+						t.Errorf("existingInstanceProfile: expected=arn:aws:iam::000000000000:instance-profile/myControllerInstanceProfile actual=%s", c.Controller.IAMConfig.InstanceProfile.Arn)
+					}
+					if c.NodePools[0].IAMConfig.InstanceProfile.Arn != "arn:aws:iam::000000000000:instance-profile/myInstanceProfile" {
+						t.Errorf("existingInstanceProfile: expected=arn:aws:iam::000000000000:instance-profile/myInstanceProfile actual=%s", c.NodePools[0].IAMConfig.InstanceProfile.Arn)
+					}
+				},
+			},
+		},
+		{
+			context: "WithWorkerSecurityGroupIds",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    securityGroupIds:
+    - sg-12345678
+    - sg-abcdefab
+    - sg-23456789
+    - sg-bcdefabc
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultEtcdSettings,
+				hasDefaultExperimentalFeatures,
+				func(c *config.Config, t *testing.T) {
+					p := c.NodePools[0]
+					expectedWorkerSecurityGroupIds := []string{
+						`sg-12345678`, `sg-abcdefab`, `sg-23456789`, `sg-bcdefabc`,
+					}
+					if !reflect.DeepEqual(p.SecurityGroupIds, expectedWorkerSecurityGroupIds) {
+						t.Errorf("WorkerSecurityGroupIds didn't match: expected=%v actual=%v", expectedWorkerSecurityGroupIds, p.SecurityGroupIds)
+					}
+
+					expectedWorkerSecurityGroupRefs := []string{
+						`"sg-12345678"`, `"sg-abcdefab"`, `"sg-23456789"`, `"sg-bcdefabc"`,
+						`{"Fn::ImportValue" : {"Fn::Sub" : "${NetworkStackName}-WorkerSecurityGroup"}}`,
+					}
+					if !reflect.DeepEqual(p.SecurityGroupRefs(), expectedWorkerSecurityGroupRefs) {
+						t.Errorf("SecurityGroupRefs didn't match: expected=%v actual=%v", expectedWorkerSecurityGroupRefs, p.SecurityGroupRefs())
+					}
+				},
+			},
+		},
+		{
+			context: "WithWorkerAndLBSecurityGroupIds",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    securityGroupIds:
+    - sg-12345678
+    - sg-abcdefab
+    loadBalancer:
+      enabled: true
+      securityGroupIds:
+        - sg-23456789
+        - sg-bcdefabc
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultEtcdSettings,
+				func(c *config.Config, t *testing.T) {
+					p := c.NodePools[0]
+					expectedWorkerSecurityGroupIds := []string{
+						`sg-12345678`, `sg-abcdefab`,
+					}
+					if !reflect.DeepEqual(p.SecurityGroupIds, expectedWorkerSecurityGroupIds) {
+						t.Errorf("WorkerSecurityGroupIds didn't match: expected=%v actual=%v", expectedWorkerSecurityGroupIds, p.SecurityGroupIds)
+					}
+
+					expectedLBSecurityGroupIds := []string{
+						`sg-23456789`, `sg-bcdefabc`,
+					}
+					if !reflect.DeepEqual(p.LoadBalancer.SecurityGroupIds, expectedLBSecurityGroupIds) {
+						t.Errorf("LBSecurityGroupIds didn't match: expected=%v actual=%v", expectedLBSecurityGroupIds, p.LoadBalancer.SecurityGroupIds)
+					}
+
+					expectedWorkerSecurityGroupRefs := []string{
+						`"sg-23456789"`, `"sg-bcdefabc"`, `"sg-12345678"`, `"sg-abcdefab"`,
+						`{"Fn::ImportValue" : {"Fn::Sub" : "${NetworkStackName}-WorkerSecurityGroup"}}`,
+					}
+					if !reflect.DeepEqual(p.SecurityGroupRefs(), expectedWorkerSecurityGroupRefs) {
+						t.Errorf("SecurityGroupRefs didn't match: expected=%v actual=%v", expectedWorkerSecurityGroupRefs, p.SecurityGroupRefs())
+					}
+				},
+			},
+		},
+		{
+			context: "WithWorkerAndALBSecurityGroupIds",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    securityGroupIds:
+    - sg-12345678
+    - sg-abcdefab
+    targetGroup:
+      enabled: true
+      securityGroupIds:
+        - sg-23456789
+        - sg-bcdefabc
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultEtcdSettings,
+				func(c *config.Config, t *testing.T) {
+					p := c.NodePools[0]
+					expectedWorkerSecurityGroupIds := []string{
+						`sg-12345678`, `sg-abcdefab`,
+					}
+					if !reflect.DeepEqual(p.SecurityGroupIds, expectedWorkerSecurityGroupIds) {
+						t.Errorf("WorkerSecurityGroupIds didn't match: expected=%v actual=%v", expectedWorkerSecurityGroupIds, p.SecurityGroupIds)
+					}
+
+					expectedALBSecurityGroupIds := []string{
+						`sg-23456789`, `sg-bcdefabc`,
+					}
+					if !reflect.DeepEqual(p.TargetGroup.SecurityGroupIds, expectedALBSecurityGroupIds) {
+						t.Errorf("LBSecurityGroupIds didn't match: expected=%v actual=%v", expectedALBSecurityGroupIds, p.TargetGroup.SecurityGroupIds)
+					}
+
+					expectedWorkerSecurityGroupRefs := []string{
+						`"sg-23456789"`, `"sg-bcdefabc"`, `"sg-12345678"`, `"sg-abcdefab"`,
+						`{"Fn::ImportValue" : {"Fn::Sub" : "${NetworkStackName}-WorkerSecurityGroup"}}`,
+					}
+					if !reflect.DeepEqual(p.SecurityGroupRefs(), expectedWorkerSecurityGroupRefs) {
+						t.Errorf("SecurityGroupRefs didn't match: expected=%v actual=%v", expectedWorkerSecurityGroupRefs, p.SecurityGroupRefs())
+					}
+				},
+			},
+		},
+		{
+			context: "WithDedicatedInstanceTenancy",
+			configYaml: minimalValidConfigYaml + `
+workerTenancy: dedicated
+controller:
+  tenancy: dedicated
+etcd:
+  tenancy: dedicated
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					if c.Etcd.Tenancy != "dedicated" {
+						t.Errorf("Etcd.Tenancy didn't match: expected=dedicated actual=%s", c.Etcd.Tenancy)
+					}
+					if c.WorkerTenancy != "dedicated" {
+						t.Errorf("WorkerTenancy didn't match: expected=dedicated actual=%s", c.WorkerTenancy)
+					}
+					if c.Controller.Tenancy != "dedicated" {
+						t.Errorf("Controller.Tenancy didn't match: expected=dedicated actual=%s", c.Controller.Tenancy)
+					}
+				},
+			},
+		},
+		{
+			context: "WithControllerNodeLabels",
+			configYaml: minimalValidConfigYaml + `
+controller:
+  nodeLabels:
+    kube-aws.coreos.com/role: controller
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultExperimentalFeatures,
+				func(c *config.Config, t *testing.T) {
+					expected := api.NodeLabels{"kube-aws.coreos.com/role": "controller"}
+					actual := c.NodeLabels()
+					if !reflect.DeepEqual(expected, actual) {
+						t.Errorf("unexpected controller node labels: expected=%v, actual=%v", expected, actual)
+					}
+				},
+			},
+		},
+		{
+			context: "WithSSHAccessAllowedSourceCIDRsSpecified",
+			configYaml: minimalValidConfigYaml + `
+sshAccessAllowedSourceCIDRs:
+- 1.2.3.255/32
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					l := len(c.SSHAccessAllowedSourceCIDRs)
+					if l != 1 {
+						t.Errorf("unexpected size of sshAccessAllowedSouceCIDRs: %d", l)
+						t.FailNow()
+					}
+					actual := c.SSHAccessAllowedSourceCIDRs[0].String()
+					expected := "1.2.3.255/32"
+					if actual != expected {
+						t.Errorf("unexpected cidr in sshAccessAllowedSourecCIDRs[0]. expected = %s, actual = %s", expected, actual)
+					}
+				},
+			},
+		},
+		{
+			context:    "WithSSHAccessAllowedSourceCIDRsOmitted",
+			configYaml: minimalValidConfigYaml,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					l := len(c.SSHAccessAllowedSourceCIDRs)
+					if l != 1 {
+						t.Errorf("unexpected size of sshAccessAllowedSouceCIDRs: %d", l)
+						t.FailNow()
+					}
+					actual := c.SSHAccessAllowedSourceCIDRs[0].String()
+					expected := "0.0.0.0/0"
+					if actual != expected {
+						t.Errorf("unexpected cidr in sshAccessAllowedSourecCIDRs[0]. expected = %s, actual = %s", expected, actual)
+					}
+				},
+			},
+		},
+		{
+			context: "WithSSHAccessAllowedSourceCIDRsEmptied",
+			configYaml: minimalValidConfigYaml + `
+sshAccessAllowedSourceCIDRs:
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					l := len(c.SSHAccessAllowedSourceCIDRs)
+					if l != 0 {
+						t.Errorf("unexpected size of sshAccessAllowedSouceCIDRs: %d", l)
+						t.FailNow()
+					}
+				},
+			},
+		},
+		{
+			context: "WithWorkerWithoutGPUSettings",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					enabled := c.NodePools[0].Gpu.Nvidia.Enabled
+					if enabled {
+						t.Errorf("unexpected enabled of gpu.nvidia: %v.  its default value should be false", enabled)
+						t.FailNow()
+					}
+				},
+			},
+		},
+		{
+			context: "WithGPUEnabledWorker",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    instanceType: p2.xlarge
+    gpu:
+      nvidia:
+        enabled: true
+        version: "123.45"
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					enabled := c.NodePools[0].Gpu.Nvidia.Enabled
+					version := c.NodePools[0].Gpu.Nvidia.Version
+					if !enabled {
+						t.Errorf("unexpected enabled value of gpu.nvidia: %v.", enabled)
+						t.FailNow()
+					}
+					if version != "123.45" {
+						t.Errorf("unexpected version value of gpu.nvidia: %v.", version)
+						t.FailNow()
+					}
+				},
+			},
+		},
+		{
+			context: "WithGPUDevicePluginEnabledWorker",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    instanceType: p2.xlarge
+    gpu:
+      nvidia:
+        enabled: true
+        version: "123.45"
+        devicePlugin:
+          enabled: true
+  - name: pool2
+    instanceType: p2.xlarge
+    gpu:
+      nvidia:
+        enabled: true
+        version: "123.45"
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, expected := range []string{
+						"/srv/kubernetes/manifests/nvidia-gpu-device-plugin-ds.yaml",
+						`applyall "${mfdir}/nvidia-gpu-device-plugin-ds.yaml"`,
+						"registry.k8s.io/nvidia-gpu-device-plugin@sha256:",
+					} {
+						if !strings.Contains(controllerUserdataS3Part, expected) {
+							t.Errorf("expected controller userdata to contain %q, but it didn't", expected)
+						}
+					}
+
+					pool1Flags := kubeletFlagsIn(c.NodePools()[0].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content)
+					if !strings.Contains(pool1Flags, "kube-aws.coreos.com/nvidia-device-plugin=true") {
+						t.Error("missing the node label for the device plugin in pool1 userdata")
+					}
+					if strings.Contains(pool1Flags, "Accelerators=true") {
+						t.Error("the Accelerators feature gate should be disabled in pool1 userdata")
+					}
+					pool2Flags := kubeletFlagsIn(c.NodePools()[1].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content)
+					if strings.Contains(pool2Flags, "kube-aws.coreos.com/nvidia-device-plugin") {
+						t.Error("unexpected node label for the device plugin in pool2 userdata")
+					}
+				},
+			},
+		},
+		{
+			context: "WithDedicatedControllerSubnets",
+			configYaml: mainClusterYaml + `
+subnets:
+- name: controller1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.1.0/24"
+  private: true
+- name: controller2
+  availabilityZone: us-west-1b
+  instanceCIDR: "10.0.2.0/24"
+  private: true
+- name: public1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.3.0/24"
+- name: public2
+  availabilityZone: us-west-1b
+  instanceCIDR: "10.0.4.0/24"
+controller:
+  count: 2
+  dedicatedSubnets: true
+  subnets:
+  - name: controller1
+  - name: controller2
+etcd:
+  subnets:
+  - name: public1
+  - name: public2
+worker:
+  nodePools:
+  - name: pool1
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					names := []string{}
+					for _, s := range c.Controller.Subnets {
+						names = append(names, s.Name)
+					}
+					if !reflect.DeepEqual(names, []string{"controller1", "controller2"}) {
+						t.Errorf("unexpected controller subnets: %v", names)
+					}
+				},
+			},
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					cp, err := c.ControlPlane().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render control-plane stack template: %v", err)
+					}
+					if !strings.Contains(cp, `"VPCZoneIdentifier":[{"Ref":"Controller1"},{"Ref":"Controller2"}]`) {
+						t.Errorf("expected the controller ASG to be placed in the dedicated subnets, but it wasn't: %s", cp)
+					}
+				},
+			},
+		},
+		{
+			context: "WithLambdaNodeDrainer",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    lambdaNodeDrainer:
+      enabled: true
+      drainTimeout: 10
+  - name: pool2
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, e := range []string{
+						"/srv/kubernetes/manifests/lambda-node-drainer-rbac.yaml",
+						`--name "/kube-aws/clusters/it/lambda-node-drainer/credentials"`,
+					} {
+						if !strings.Contains(controllerUserdataS3Part, e) {
+							t.Errorf("expected \"%s\" to be contained in the controller userdata, but it wasn't", e)
+						}
+					}
+
+					cp, err := c.ControlPlane().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render control-plane stack template: %v", err)
+					}
+					if !strings.Contains(cp, `"Action":"ssm:PutParameter"`) {
+						t.Errorf("expected controller nodes to be allowed to publish the credentials for the lambda node drainer, but they weren't: %s", cp)
+					}
+
+					pool1, err := c.NodePools()[0].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render node pool stack template: %v", err)
+					}
+					for _, e := range []string{
+						`"WorkersLambdaNodeDrainerLH":{"Properties":{"AutoScalingGroupName":{"Ref":"Workers"},"DefaultResult":"CONTINUE","HeartbeatTimeout":"660"`,
+						`"Type":"AWS::Lambda::Function"`,
+						`"Timeout":600`,
+						`"EVICTION_API_VERSION":"policy/v1beta1"`,
+						`"AutoScalingGroupName":[{"Ref":"Workers"}]`,
+						`"Principal":"events.amazonaws.com"`,
+					} {
+						if !strings.Contains(pool1, e) {
+							t.Errorf("expected \"%s\" to be contained in the node pool stack template, but it wasn't: %s", e, pool1)
+						}
+					}
+					if strings.Contains(pool1, `"VpcConfig"`) {
+						t.Errorf("expected the lambda node drainer for public subnets to run outside of the VPC, but it didn't: %s", pool1)
+					}
+
+					pool2, err := c.NodePools()[1].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render node pool stack template: %v", err)
+					}
+					if strings.Contains(pool2, "LambdaNodeDrainer") {
+						t.Errorf("expected the lambda node drainer not to be deployed for pool2, but it was: %s", pool2)
+					}
+				},
+			},
+		},
+		{
+			context: "WithLambdaNodeDrainerInPrivateSubnets",
+			configYaml: mainClusterYaml + `
+subnets:
+- name: private1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.1.0/24"
+  private: true
+- name: public1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.2.0/24"
+worker:
+  nodePools:
+  - name: pool1
+    private: true
+    lambdaNodeDrainer:
+      enabled: true
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					pool1, err := c.NodePools()[0].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render node pool stack template: %v", err)
+					}
+					e := `"VpcConfig":{"SecurityGroupIds":[{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-WorkerSecurityGroup"}}],"SubnetIds":[{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-Private1"}}]}`
+					if !strings.Contains(pool1, e) {
+						t.Errorf("expected the lambda node drainer to run in the private subnets of the node pool, but it didn't: %s", pool1)
+					}
+				},
+			},
+		},
+		{
+			context: "WithServiceAccountIssuer",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.20.2
+controller:
+  apiServer:
+    serviceAccountIssuer:
+      url: https://my-oidc-bucket.s3.us-west-1.amazonaws.com/it
+      discovery:
+        s3Bucket: my-oidc-bucket
+      oidcProvider:
+        create: true
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					for _, e := range []string{
+						"--service-account-issuer=https://my-oidc-bucket.s3.us-west-1.amazonaws.com/it",
+						"--service-account-signing-key-file=/etc/kubernetes/ssl/service-account-key.pem",
+						"--service-account-jwks-uri=https://my-oidc-bucket.s3.us-west-1.amazonaws.com/it/keys.json",
+						"--api-audiences=https://my-oidc-bucket.s3.us-west-1.amazonaws.com/it",
+					} {
+						if !strings.Contains(controllerUserdataS3Part, e) {
+							t.Errorf("expected \"%s\" to be contained in the controller userdata, but it wasn't", e)
+						}
+					}
+
+					cp, err := c.ControlPlane().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render control-plane stack template: %v", err)
+					}
+					for _, e := range []string{
+						`"ServiceAccountIssuerOIDCProvider":{"Type":"AWS::IAM::OIDCProvider","Properties":{"Url":"https://my-oidc-bucket.s3.us-west-1.amazonaws.com/it","ClientIdList":["sts.amazonaws.com"]}}`,
+						`"ServiceAccountIssuerOIDCProviderArn":{"Description"`,
+					} {
+						if !strings.Contains(cp, e) {
+							t.Errorf("expected \"%s\" to be contained in the control-plane stack template, but it wasn't: %s", e, cp)
+						}
+					}
+				},
+			},
+		},
+		{
+			context: "WithInstanceScriptStoredInS3",
+			configYaml: minimalValidConfigYaml + `
+instanceScript:
+  storage: s3
+worker:
+  nodePools:
+  - name: pool1
+  - name: pool2
+    instanceScript:
+      storage: embedded
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					cp, err := c.ControlPlane().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render control-plane stack template: %v", err)
+					}
+					for _, e := range []string{
+						"INSTANCE_SCRIPT_FILE=/var/run/coreos/instance-script-controller",
+						"/exported/stacks/control-plane/userdata-controller-instance-script-",
+					} {
+						if !strings.Contains(cp, e) {
+							t.Errorf("expected \"%s\" to be contained in the control-plane stack template, but it wasn't: %s", e, cp)
+						}
+					}
+					if strings.Contains(cp, "coreos-cloudinit") {
+						t.Errorf("expected the instance script of controller nodes not to be embedded, but it was: %s", cp)
+					}
+
+					pool1, err := c.NodePools()[0].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render node pool stack template: %v", err)
+					}
+					if !strings.Contains(pool1, "/exported/stacks/pool1/userdata-worker-instance-script-") {
+						t.Errorf("expected the instance script of pool1 to be stored in S3, but it wasn't: %s", pool1)
+					}
+
+					pool2, err := c.NodePools()[1].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render node pool stack template: %v", err)
+					}
+					if strings.Contains(pool2, "instance-script-worker") || !strings.Contains(pool2, "coreos-cloudinit") {
+						t.Errorf("expected the instance script of pool2 to be embedded, but it wasn't: %s", pool2)
+					}
+				},
+			},
+		},
+		{
+			context: "WithNodePoolCustomResources",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    customResources:
+      WorkersAlarmTopic:
+        Type: AWS::SNS::Topic
+      WorkersCPUAlarm: |
+        {"Type": "AWS::CloudWatch::Alarm", "Properties": {"AlarmActions": [{"Ref": "WorkersAlarmTopic"}], "Dimensions": [{"Name": "AutoScalingGroupName", "Value": {"Ref": "Workers"}}]}}
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					pool1, err := c.NodePools()[0].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render node pool stack template: %v", err)
+					}
+					for _, e := range []string{
+						`"WorkersAlarmTopic":{"Type":"AWS::SNS::Topic"}`,
+						`"WorkersCPUAlarm":{"Properties":{"AlarmActions":[{"Ref":"WorkersAlarmTopic"}],"Dimensions":[{"Name":"AutoScalingGroupName","Value":{"Ref":"Workers"}}]},"Type":"AWS::CloudWatch::Alarm"}`,
+					} {
+						if !strings.Contains(pool1, e) {
+							t.Errorf("expected \"%s\" to be contained in the node pool stack template, but it wasn't: %s", e, pool1)
+						}
+					}
+				},
+			},
+		},
+		{
+			context: "WithContainerdImageGC",
+			configYaml: minimalValidConfigYaml + `
+containerd:
+  imageGc:
+    highThresholdPercent: 80
+    lowThresholdPercent: 70
+    pruneThresholdPercent: 90
+    pruneSchedule: "*:0/15"
+    discardUnpackedLayers: true
+worker:
+  nodePools:
+  - name: pool1
+    containerRuntime: containerd
+  - name: pool2
+    containerRuntime: containerd
+    containerd:
+      imageGc:
+        highThresholdPercent: 90
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					pool1Userdata := c.NodePools()[0].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					for _, e := range []string{
+						"--image-gc-high-threshold=80 \\\n",
+						"--image-gc-low-threshold=70 \\\n",
+						"discard_unpacked_layers = true",
+						"[plugins.\"io.containerd.gc.v1.scheduler\"]\n      deletion_threshold = 1",
+						"- name: prune-containerd-images.timer",
+						"OnCalendar=*:0/15",
+						"- path: /opt/bin/prune-containerd-images",
+						"if (( usage < 90 )); then",
+					} {
+						if !strings.Contains(pool1Userdata, e) {
+							t.Errorf("missing %q in pool1 userdata: %s", e, pool1Userdata)
+						}
+					}
+
+					pool2Userdata := c.NodePools()[1].UserData["Worker"].Parts[api.USERDATA_S3].Asset.Content
+					if !strings.Contains(pool2Userdata, "--image-gc-high-threshold=90 \\\n") {
+						t.Errorf("expected pool2 to override the image GC settings, but it didn't: %s", kubeletFlagsIn(pool2Userdata))
+					}
+					for _, e := range []string{"--image-gc-low-threshold", "prune-containerd-images", "discard_unpacked_layers"} {
+						if strings.Contains(pool2Userdata, e) {
+							t.Errorf("unexpected %q in pool2 userdata", e)
+						}
+					}
+				},
+			},
+		},
+		{
+			context: "WithAPIServerClientCAs",
+			configYaml: minimalValidConfigYaml + `
+controller:
+  apiServer:
+    clientCAs:
+    - |
+` + indentedTrustedCAPEM("      ") + `
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					if !strings.Contains(controllerUserdataS3Part, "- --client-ca-file=/etc/kubernetes/ssl/apiserver-client-ca-bundle.pem") {
+						t.Error("missing the client CA bundle in the apiserver flags")
+					}
+					if !strings.Contains(controllerUserdataS3Part, "- path: /etc/kubernetes/ssl/apiserver-client-cas/0.pem") {
+						t.Error("missing the client CA file in controller userdata")
+					}
+					if !strings.Contains(controllerUserdataS3Part, indentedTrustedCAPEM("      ")) {
+						t.Error("missing the client CA certificate in controller userdata")
+					}
+					// The client CAs are kept apart from the bundle, which is never matched by the glob and is replaced as a whole on reboots
+					if !strings.Contains(controllerUserdataS3Part, "cat /etc/kubernetes/ssl/ca.pem /etc/kubernetes/ssl/apiserver-client-cas/*.pem > /etc/kubernetes/ssl/apiserver-client-ca-bundle.pem.tmp && "+
+						"mv /etc/kubernetes/ssl/apiserver-client-ca-bundle.pem.tmp /etc/kubernetes/ssl/apiserver-client-ca-bundle.pem") {
+						t.Error("missing the concatenation of the client CA bundle in controller userdata")
+					}
+				},
+			},
+		},
+		{
+			context:    "WithoutAPIServerClientCAs",
+			configYaml: minimalValidConfigYaml,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					if !strings.Contains(controllerUserdataS3Part, "- --client-ca-file=/etc/kubernetes/ssl/ca.pem") {
+						t.Error("the apiserver should accept client certificates signed by the cluster's CA by default")
+					}
+					if strings.Contains(controllerUserdataS3Part, "apiserver-client-ca") {
+						t.Error("no client CA bundle should be created by default")
+					}
+				},
+			},
+		},
+		{
+			context: "WithNodeDrainerDrainSettings",
+			configYaml: minimalValidConfigYaml + `
+experimental:
+  nodeDrainer:
+    enabled: true
+    drainTimeout: 5
+    drain:
+      timeout: 2m
+      gracePeriod: 45s
+      force: false
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					expected := `kubectl drain --ignore-daemonsets=true --delete-local-data=true --force=false --timeout=2m --grace-period=45 "${NODE_NAME}"`
+					if !strings.Contains(controllerUserdataS3Part, expected) {
+						t.Errorf("missing the configured flags of kubectl drain in the node drainer: expected to contain %s", expected)
+					}
+				},
+			},
+		},
+		{
+			context: "WithNodeDrainerDefaultDrainSettings",
+			configYaml: minimalValidConfigYaml + `
+experimental:
+  nodeDrainer:
+    enabled: true
+    drainTimeout: 5
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					expected := `kubectl drain --ignore-daemonsets=true --delete-local-data=true --force=true --timeout=60s "${NODE_NAME}"`
+					if !strings.Contains(controllerUserdataS3Part, expected) {
+						t.Errorf("missing the default flags of kubectl drain in the node drainer: expected to contain %s", expected)
+					}
+				},
+			},
+		},
+		{
+			context: "WithAPIServerEventTTL",
+			configYaml: minimalValidConfigYaml + `
+controller:
+  apiServer:
+    eventTtl: 30m
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					if !strings.Contains(controllerUserdataS3Part, "- --event-ttl=30m") {
+						t.Error("missing --event-ttl in the apiserver flags")
+					}
+				},
+			},
+		},
+		{
+			context:    "WithoutAPIServerEventTTL",
+			configYaml: minimalValidConfigYaml,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					controllerUserdataS3Part := c.ControlPlane().UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					if strings.Contains(controllerUserdataS3Part, "--event-ttl") {
+						t.Error("--event-ttl should be left to the apiserver's default")
+					}
+				},
+			},
+		},
+		{
+			context: "WithEtcdReplacingMembersFailingToStart",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  snapshot:
+    automated: true
+  disasterRecovery:
+    automated: true
+    replaceMembersFailingToStart: true
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					etcdStackTemplate, err := c.Etcd().RenderStackTemplateAsString()
+					if err != nil {
+						t.Errorf("failed to render etcd stack template: %v", err)
+					}
+					for _, expected := range []string{
+						`"ETCD_MEMBER_FAILURE_PERIOD_LIMIT='","600"`,
+						`"ETCDADM_REPLACE_MEMBERS_FAILING_TO_START='","true"`,
+					} {
+						if !strings.Contains(etcdStackTemplate, expected) {
+							t.Errorf("expected the etcdadm environment in the etcd stack template to contain %s, but it didn't", expected)
+						}
+					}
+				},
+			},
+		},
+		{
+			context: "WithEtcdMemberFailurePeriodLimit",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  snapshot:
+    automated: true
+  disasterRecovery:
+    automated: true
+    memberFailurePeriodLimit: 60
+`,
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					etcdStackTemplate, err := c.Etcd().RenderStackTemplateAsString()
+					if err != nil {
+						t.Errorf("failed to render etcd stack template: %v", err)
+					}
+					if !strings.Contains(etcdStackTemplate, `"ETCD_MEMBER_FAILURE_PERIOD_LIMIT='","60"`) {
+						t.Error("expected the etcdadm environment in the etcd stack template to contain the member failure period limit, but it didn't")
+					}
+					if strings.Contains(etcdStackTemplate, "ETCDADM_REPLACE_MEMBERS_FAILING_TO_START") {
+						t.Error("members failing to start should not be replaced by default")
+					}
+				},
+			},
+		},
+		{
+			context: "WithGPUDisabledWorker",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    gpu:
+      nvidia:
+        enabled: false
+        version: "123.45"
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					enabled := c.NodePools[0].Gpu.Nvidia.Enabled
+					version := c.NodePools[0].Gpu.Nvidia.Version
+					if enabled {
+						t.Errorf("unexpected enabled value of gpu.nvidia: %v.", enabled)
+						t.FailNow()
+					}
+					if version != "123.45" {
+						t.Errorf("unexpected version value of gpu.nvidia: %v.", version)
+						t.FailNow()
+					}
+				},
+			},
+		},
+	}
+
+	for _, validCase := range validCases {
+		t.Run(validCase.context, func(t *testing.T) {
+			configBytes := validCase.configYaml
+			plugins := validCase.plugins
+			providedConfig, err := config.ConfigFromBytes([]byte(configBytes), plugins)
+			if err != nil {
+				t.Errorf("failed to parse config %s: %+v", configBytes, err)
+				t.FailNow()
+			}
+
+			t.Run("AssertConfig", func(t *testing.T) {
+				for _, assertion := range validCase.assertConfig {
+					assertion(providedConfig, t)
+				}
+			})
+
+			helper.WithDummyCredentials(func(dummyAssetsDir string) {
+				var stackTemplateOptions = root.NewOptions(false, false)
+				stackTemplateOptions.AssetsDir = dummyAssetsDir
+				stackTemplateOptions.ControllerTmplFile = "../../builtin/files/userdata/cloud-config-controller"
+				stackTemplateOptions.WorkerTmplFile = "../../builtin/files/userdata/cloud-config-worker"
+				stackTemplateOptions.BottlerocketWorkerTmplFile = "../../builtin/files/userdata/bottlerocket-worker"
+				stackTemplateOptions.WindowsWorkerTmplFile = "../../builtin/files/userdata/windows-worker"
+				stackTemplateOptions.EtcdTmplFile = "../../builtin/files/userdata/cloud-config-etcd"
+				stackTemplateOptions.RootStackTemplateTmplFile = "../../builtin/files/stack-templates/root.json.tmpl"
+				stackTemplateOptions.NodePoolStackTemplateTmplFile = "../../builtin/files/stack-templates/node-pool.json.tmpl"
+				stackTemplateOptions.ControlPlaneStackTemplateTmplFile = "../../builtin/files/stack-templates/control-plane.json.tmpl"
+				stackTemplateOptions.NetworkStackTemplateTmplFile = "../../builtin/files/stack-templates/network.json.tmpl"
+				stackTemplateOptions.EtcdStackTemplateTmplFile = "../../builtin/files/stack-templates/etcd.json.tmpl"
+
+				cl, err := root.CompileClusterFromConfig(providedConfig, stackTemplateOptions, false)
+				if err != nil {
+					t.Errorf("failed to create cluster driver : %v", err)
+					t.FailNow()
+				}
+
+				cl.Context = &model.Context{
+					ProvidedEncryptService:  helper.DummyEncryptService{},
+					ProvidedCFInterrogator:  helper.DummyCFInterrogator{},
+					ProvidedEC2Interrogator: helper.DummyEC2Interrogator{},
+					StackTemplateGetter:     helper.DummyStackTemplateGetter{},
+					SSMParameterGetter: helper.DummySSMParameterGetter{
+						Parameters: map[string]string{
+							"/aws/service/eks/optimized-ami/1.14/amazon-linux-2/recommended/image_id":                      "ami-0123456789abcdef0",
+							"/aws/service/bottlerocket/aws-k8s-1.20/x86_64/latest/image_id":                                "ami-0b0770c7fd236f2f3",
+							"/aws/service/ami-windows-latest/Windows_Server-2019-English-Core-EKS_Optimized-1.16/image_id": "ami-0e9542fa35cdde6e4",
+						},
+					},
+				}
+
+				_, err = cl.EnsureAllAssetsGenerated()
+				if err != nil {
+					t.Errorf("%v", err)
+					t.FailNow()
+				}
+
+				t.Run("AssertCluster", func(t *testing.T) {
+					for _, assertion := range validCase.assertCluster {
+						assertion(cl, t)
+					}
+				})
+
+				t.Run("ValidateTemplates", func(t *testing.T) {
+					if err := cl.ValidateTemplates(); err != nil {
+						t.Errorf("failed to render stack template: %v", err)
+					}
+				})
+
+				if os.Getenv("KUBE_AWS_INTEGRATION_TEST") == "" {
+					t.Skipf("`export KUBE_AWS_INTEGRATION_TEST=1` is required to run integration tests. Skipping.")
+					t.SkipNow()
+				} else {
+					t.Run("ValidateStack", func(t *testing.T) {
+						if !s3URIExists {
+							t.Errorf("failed to obtain value for KUBE_AWS_S3_DIR_URI")
+							t.FailNow()
+						}
+
+						report, err := cl.ValidateStack()
+
+						if err != nil {
+							t.Errorf("failed to validate stack: %s %v", report, err)
+						}
+					})
+				}
+			})
+		})
+	}
+
+	parseErrorCases := []struct {
+		context              string
+		configYaml           string
+		expectedErrorMessage string
+	}{
+		{
+			context: "WithAPIEndpointLBAPIAccessAllowedSourceCIDRsEmptied",
+			configYaml: configYamlWithoutExernalDNSName + `
+apiEndpoints:
+- name: default
+  dnsName: k8s.example.com
+  loadBalancer:
+    apiAccessAllowedSourceCIDRs:
+    hostedZone:
+      id: a1b2c4
+`,
+			expectedErrorMessage: `invalid cluster: invalid apiEndpoint "default" at index 0: invalid loadBalancer: either apiAccessAllowedSourceCIDRs or securityGroupIds must be present. Try not to explicitly empty apiAccessAllowedSourceCIDRs or set one or more securityGroupIDs`,
+		},
+		{
+			context: "WithClusterAutoscalerEnabledForControlPlane",
+			configYaml: minimalValidConfigYaml + `
+controller:
+  autoscaling:
+    clusterAutoscaler:
+      enabled: true
+`,
+			expectedErrorMessage: "cluster-autoscaler can't be enabled for a control plane because " +
+				"allowing so for a group of controller nodes spreading over 2 or more availability zones " +
+				"results in unreliability while scaling nodes out.",
+		},
+		{
+			context: "WithTooManyKubeDnsDNSConfigSearches",
+			configYaml: minimalValidConfigYaml + `
+kubeDns:
+  dnsConfig:
+    searches:
+    - a.example.com
+    - b.example.com
+    - c.example.com
+    - d.example.com
+`,
+			expectedErrorMessage: "`kubeDns.dnsConfig.searches` can contain at most 3 domains",
+		},
+		{
+			context: "WithInvalidKubeDnsDNSConfigOption",
+			configYaml: minimalValidConfigYaml + `
+kubeDns:
+  dnsConfig:
+    options:
+    - "ndots: 2"
+`,
+			expectedErrorMessage: "`kubeDns.dnsConfig.options[0]` must be a non-empty option",
+		},
+		{
+			context: "WithAPIServerEgressSelectorForUnsupportedKubernetesVersion",
+			configYaml: minimalValidConfigYaml + `
+kubernetes:
+  apiserver:
+    egressSelector:
+      selections:
+      - name: cluster
+        proxyProtocol: Direct
+`,
+			expectedErrorMessage: "`kubernetes.apiserver.egressSelector` requires kubernetesVersion 1.18 or greater",
+		},
+		{
+			context: "WithAPIServerEgressSelectorWithoutProxy",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.20.2
+kubernetes:
+  apiserver:
+    egressSelector:
+      selections:
+      - name: cluster
+        proxyProtocol: HTTPConnect
+`,
+			expectedErrorMessage: "invalid `kubernetes.apiserver.egressSelector.selections[0]`: either `url` or `udsName` must be specified",
+		},
+		{
+			context: "WithInvalidKubeletReservedQuantity",
+			configYaml: minimalValidConfigYaml + `
+kubelet:
+  systemReserved:
+    cpu: 100 millicores
+`,
+			expectedErrorMessage: "`kubelet.systemReserved.cpu` is invalid",
+		},
+		{
+			context: "WithKubeletReservationsExceedingNodePoolInstanceCapacity",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    instanceType: t2.medium
+    kubelet:
+      kubeReserved:
+        cpu: "2"
+`,
+			expectedErrorMessage: "must be less than 2 vCPUs of the instance type t2.medium",
+		},
+		{
+			context: "WithInvalidAdditionalTrustedCA",
+			configYaml: minimalValidConfigYaml + `
+additionalTrustedCAs:
+- |
+  -----BEGIN CERTIFICATE-----
+  bm90IGEgY2VydGlmaWNhdGUK
+  -----END CERTIFICATE-----
+`,
+			expectedErrorMessage: "invalid `additionalTrustedCAs[0]`: failed to parse certificate #1",
+		},
+		{
+			context: "WithDefaultNetworkPolicyOnFlannel",
+			configYaml: minimalValidConfigYaml + `
+kubernetes:
+  networking:
+    selfHosting:
+      type: flannel
+defaults:
+  networkPolicy:
+    enabled: true
+    namespaces:
+    - default
+`,
+			expectedErrorMessage: "`defaults.networkPolicy` requires a CNI supporting NetworkPolicy",
+		},
+		{
+			context: "WithDefaultNetworkPolicyWithoutNamespaces",
+			configYaml: minimalValidConfigYaml + `
+defaults:
+  networkPolicy:
+    enabled: true
+`,
+			expectedErrorMessage: "`defaults.networkPolicy` requires either `namespaces` or `namespaceSelector` to be set",
+		},
+		{
+			context: "WithKubeControllerManagerNegativeConcurrentSyncs",
+			configYaml: minimalValidConfigYaml + `
+controller:
+  kubeControllerManager:
+    concurrentServiceSyncs: -1
+`,
+			expectedErrorMessage: "`controller.kubeControllerManager.concurrentServiceSyncs` must be a positive integer, but was -1",
+		},
+		{
+			context: "WithKubeSchedulerPercentageOfNodesToScoreOutOfRange",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.20.2
+controller:
+  kubeScheduler:
+    percentageOfNodesToScore: 101
+`,
+			expectedErrorMessage: "`controller.kubeScheduler.percentageOfNodesToScore` must be between 0 and 100, but was 101",
+		},
+		{
+			context: "WithKubeSchedulerPercentageOfNodesToScoreForUnsupportedKubernetesVersion",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.11.3
+controller:
+  kubeScheduler:
+    percentageOfNodesToScore: 50
+`,
+			expectedErrorMessage: "`controller.kubeScheduler.percentageOfNodesToScore` requires kubernetesVersion 1.12 or greater",
+		},
+		{
+			context: "WithNodePoolPurposeConflictingWithTaints",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: observability
+    purpose: observability
+    taints:
+    - key: kube-aws.coreos.com/purpose
+      value: observability
+      effect: NoExecute
+`,
+			expectedErrorMessage: "`taints` contains \"kube-aws.coreos.com/purpose=observability:NoExecute\" which conflicts with `purpose` \"observability\"",
+		},
+		{
+			context: "WithNodePoolPurposeConflictingWithNodeLabels",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: observability
+    purpose: observability
+    nodeLabels:
+      kube-aws.coreos.com/purpose: logging
+`,
+			expectedErrorMessage: "`nodeLabels` contains \"kube-aws.coreos.com/purpose=logging\" which conflicts with `purpose` \"observability\"",
+		},
+		{
+			context: "WithControllerAggregationCustomizedButDisabled",
+			configYaml: minimalValidConfigYaml + `
+controller:
+  aggregation:
+    allowedNames:
+    - my-proxy
+`,
+			expectedErrorMessage: "`controller.aggregation.enabled` must be true to customize the request header authentication",
+		},
+		{
+			context: "WithControllerAggregationInvalidHeader",
+			configYaml: minimalValidConfigYaml + `
+controller:
+  aggregation:
+    enabled: true
+    groupHeaders:
+    - X-Remote-Group,X-Group
+`,
+			expectedErrorMessage: "invalid `controller.aggregation.groupHeaders`: \"X-Remote-Group,X-Group\" must be non-empty and must not contain commas or whitespaces",
+		},
+		{
+			context: "WithSpotInstancePoolsForCapacityOptimizedAllocation",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    autoScalingGroup:
+      minSize: 1
+      maxSize: 3
+      mixedInstances:
+        enabled: true
+        spotAllocationStrategy: capacity-optimized
+        spotInstancePools: 4
+        instanceTypes:
+        - m5.large
+        - m5a.large
+`,
+			expectedErrorMessage: "`mixedInstances.spotInstancePools` can only be specified with the 'lowest-price' spot allocation strategy",
+		},
+		{
+			context: "WithKubeletConfigFileUnknownField",
+			configYaml: minimalValidConfigYaml + `
+kubelet:
+  configFile:
+    enabled: true
+    overrides:
+      maxPod: 200
+`,
+			expectedErrorMessage: "`kubelet.configFile.overrides` contains the unknown KubeletConfiguration field \"maxPod\"",
+		},
+		{
+			context: "WithKubeletMaxParallelImagePullsButSerialized",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.27.1
+worker:
+  nodePools:
+  - name: pool1
+    kubelet:
+      maxParallelImagePulls: 5
+      configFile:
+        enabled: true
+`,
+			expectedErrorMessage: "`kubelet.maxParallelImagePulls` can be specified only when `kubelet.serializeImagePulls` is false",
+		},
+		{
+			context: "WithNodePoolKubeletConfigFileOverridesButDisabled",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    kubelet:
+      configFile:
+        overrides:
+          maxPods: 200
+`,
+			expectedErrorMessage: "`kubelet.configFile.enabled` must be true to specify `kubelet.configFile.overrides`",
+		},
+		{
+			context: "WithInvalidAssumeRoleARN",
+			configYaml: minimalValidConfigYaml + `
+assumeRoles:
+  route53:
+    roleARN: arn:aws:iam::123456789012:user/kube-aws-dns
+`,
+			expectedErrorMessage: "invalid `assumeRoles.route53.roleARN` \"arn:aws:iam::123456789012:user/kube-aws-dns\"",
+		},
+		{
+			context: "WithNodePoolBootstrapTaintConflictingWithTaints",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    bootstrapTaint:
+      enabled: true
+    taints:
+    - key: node.kube-aws.io/uninitialized
+      value: "true"
+      effect: PreferNoSchedule
+`,
+			expectedErrorMessage: "`taints` contains \"node.kube-aws.io/uninitialized=true:PreferNoSchedule\" which conflicts with the bootstrap taint",
+		},
+		{
+			context: "WithNodePoolBootstrapTaintKeyButDisabled",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    bootstrapTaint:
+      key: example.com/bootstrapping
+`,
+			expectedErrorMessage: "`bootstrapTaint.enabled` must be true to specify `bootstrapTaint.key`",
+		},
+		{
+			context: "WithAPIServerUnknownTLSCipherSuite",
+			configYaml: minimalValidConfigYaml + `
+controller:
+  apiServer:
+    tlsCipherSuites:
+    - TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256
+    - ECDHE-RSA-AES256-GCM-SHA384
+`,
+			expectedErrorMessage: "`controller.apiServer.tlsCipherSuites` contains the unknown cipher suite \"ECDHE-RSA-AES256-GCM-SHA384\"",
+		},
+		{
+			context: "WithAPIServerInvalidTLSMinVersion",
+			configYaml: minimalValidConfigYaml + `
+controller:
+  apiServer:
+    tlsMinVersion: "1.2"
+`,
+			expectedErrorMessage: "invalid `controller.apiServer.tlsMinVersion` \"1.2\"",
+		},
+		{
+			context: "WithAPIServerUnknownStorageMediaType",
+			configYaml: minimalValidConfigYaml + `
+controller:
+  apiServer:
+    storageMediaType: protobuf
+`,
+			expectedErrorMessage: "invalid `controller.apiServer.storageMediaType` \"protobuf\"",
+		},
+		{
+			context: "WithEtcdMetricsPortConflictingWithPeerPort",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  version: 3.3.10
+  metrics:
+    enabled: true
+    port: 2380
+`,
+			expectedErrorMessage: "`etcd.metrics.port` 2380 conflicts with the etcd client port 2379 or the peer port 2380",
+		},
+		{
+			context: "WithEtcdMetricsForUnsupportedEtcdVersion",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  metrics:
+    enabled: true
+`,
+			expectedErrorMessage: "`etcd.metrics` requires etcd 3.3 or greater, but the etcd version was 3.2.13",
+		},
+		{
+			context: "WithAWSLoadBalancerControllerWithoutIAMRole",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.20.2
+addons:
+  awsLoadBalancerController:
+    enabled: true
+`,
+			expectedErrorMessage: "`addons.awsLoadBalancerController` requires an IAM role for the controller: specify either `iamRole.arn` assumed via kube2iam or kiam, or `useControllerNodeRole: true`",
+		},
+		{
+			context: "WithAWSLoadBalancerControllerIAMRoleWithoutKube2IamOrKIAM",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.20.2
+addons:
+  awsLoadBalancerController:
+    enabled: true
+    iamRole:
+      arn: arn:aws:iam::123456789012:role/aws-load-balancer-controller
+`,
+			expectedErrorMessage: "`addons.awsLoadBalancerController.iamRole.arn` requires either `kube2IamSupport` or `kiamSupport` to be enabled",
+		},
+		{
+			context: "WithAWSLoadBalancerControllerForUnsupportedKubernetesVersion",
+			configYaml: minimalValidConfigYaml + `
+addons:
+  awsLoadBalancerController:
+    enabled: true
+    useControllerNodeRole: true
+`,
+			expectedErrorMessage: "`addons.awsLoadBalancerController` requires kubernetesVersion 1.19 or greater, but was v1.11.3",
+		},
+		{
+			context: "WithAssetLayoutCollidingDirectories",
+			configYaml: minimalValidConfigYaml + `
+assetLayout:
+  userDataDir: rendered
+  stackTemplatesDir: rendered
+`,
+			expectedErrorMessage: "`assetLayout.userDataDir` \"rendered\" and `assetLayout.stackTemplatesDir` \"rendered\" collide: they must be distinct and not nested in each other",
+		},
+		{
+			context: "WithAssetLayoutAbsoluteCredentialsDir",
+			configYaml: minimalValidConfigYaml + `
+assetLayout:
+  credentialsDir: /etc/kube-aws/credentials
+`,
+			expectedErrorMessage: "invalid `assetLayout.credentialsDir` \"/etc/kube-aws/credentials\": it must be a directory name relative to `assetLayout.baseDir`",
+		},
+		{
+			context: "WithClassicELBHealthCheckTimeoutExceedingInterval",
+			configYaml: configYamlWithoutExernalDNSName + `
+apiEndpoints:
+- name: default
+  dnsName: k8s.example.com
+  loadBalancer:
+    hostedZone:
+      id: a1b2c4
+    healthCheck:
+      interval: 5
+      timeout: 10
+`,
+			expectedErrorMessage: "`healthCheck.timeout` 10 must be less than `healthCheck.interval` 5",
+		},
+		{
+			context: "WithHealthCheckForNetworkLoadBalancer",
+			configYaml: configYamlWithoutExernalDNSName + `
+apiEndpoints:
+- name: default
+  dnsName: k8s.example.com
+  loadBalancer:
+    type: network
+    hostedZone:
+      id: a1b2c4
+    healthCheck:
+      target: TCP:443
+`,
+			expectedErrorMessage: "healthCheck can only be specified for a classic load balancer",
+		},
+		{
+			context: "WithSubnetWeightsMissingSubnet",
+			configYaml: kubeAwsSettings.mainClusterYamlWithoutAPIEndpoint() + `
+subnets:
+- name: public1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.1.0/24"
+- name: public2
+  availabilityZone: us-west-1b
+  instanceCIDR: "10.0.2.0/24"
+- name: public3
+  availabilityZone: us-west-1c
+  instanceCIDR: "10.0.3.0/24"
+apiEndpoints:
+- name: default
+  dnsName: k8s.example.com
+  loadBalancer:
+    hostedZone:
+      id: a1b2c4
+worker:
+  nodePools:
+  - name: pool1
+    subnets:
+    - name: public1
+    - name: public2
+    autoScalingGroup:
+      minSize: 1
+      maxSize: 3
+      subnetWeights:
+        public1: 2
+`,
+			expectedErrorMessage: "`autoScalingGroup.subnetWeights` must have a weight for every subnet of the node pool, but the subnet \"public2\" is missing",
+		},
+		{
+			context: "WithSubnetWeightsTooSmallMaxSize",
+			configYaml: kubeAwsSettings.mainClusterYamlWithoutAPIEndpoint() + `
+subnets:
+- name: public1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.1.0/24"
+- name: public2
+  availabilityZone: us-west-1b
+  instanceCIDR: "10.0.2.0/24"
+- name: public3
+  availabilityZone: us-west-1c
+  instanceCIDR: "10.0.3.0/24"
+apiEndpoints:
+- name: default
+  dnsName: k8s.example.com
+  loadBalancer:
+    hostedZone:
+      id: a1b2c4
+worker:
+  nodePools:
+  - name: pool1
+    subnets:
+    - name: public1
+    - name: public2
+    - name: public3
+    autoScalingGroup:
+      minSize: 1
+      maxSize: 2
+      subnetWeights:
+        public1: 6
+        public2: 3
+        public3: 1
+`,
+			expectedErrorMessage: "`autoScalingGroup.maxSize` 2 is too small to be distributed among the 3 subnets according to `autoScalingGroup.subnetWeights`",
+		},
+		{
+			context: "WithAutoscalingEnabledButClusterAutoscalerIsNot",
+			configYaml: minimalValidConfigYaml + `
+addons:
+  clusterAutoscaler:
+    enabled: false
+worker:
+  nodePools:
+  - name: pool1
+    autoscaling:
+      clusterAutoscaler:
+        enabled: true
+`,
+			expectedErrorMessage: "node pool \"pool1\" can't be scaled by cluster-autoscaler because `addons.clusterAutoscaler.enabled` is false",
+		},
+		{
+			context: "WithClusterAutoscalerSafeToEvictUnknownAddon",
+			configYaml: minimalValidConfigYaml + `
+addons:
+  clusterAutoscaler:
+    enabled: true
+    safeToEvict:
+      nginx-ingress: false
+`,
+			expectedErrorMessage: "`addons.clusterAutoscaler.safeToEvict` contains the unknown add-on \"nginx-ingress\"",
+		},
+		{
+			context: "WithClusterAutoscalerSafeToEvictWithoutClusterAutoscaler",
+			configYaml: minimalValidConfigYaml + `
+addons:
+  clusterAutoscaler:
+    safeToEvict:
+      kube-dns: false
+`,
+			expectedErrorMessage: "`addons.clusterAutoscaler.safeToEvict` can't be specified unless `addons.clusterAutoscaler.enabled` is true",
+		},
+		{
+			context: "WithClusterAutoscalerScaleDownDisabledWithoutAutoscaling",
+			configYaml: minimalValidConfigYaml + `
+addons:
+  clusterAutoscaler:
+    enabled: true
+worker:
+  nodePools:
+  - name: pool1
+    autoscaling:
+      clusterAutoscaler:
+        scaleDownDisabled: true
+`,
+			expectedErrorMessage: "`autoscaling.clusterAutoscaler.scaleDownDisabled` can't be true unless `autoscaling.clusterAutoscaler.enabled` is true",
+		},
+		{
+			context: "WithEFSCSIDriverWithoutIAMRole",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.20.2
+addons:
+  efsCsiDriver:
+    enabled: true
+`,
+			expectedErrorMessage: "`addons.efsCsiDriver` requires an IAM role for the driver: specify either `iamRole.arn` assumed via kube2iam or kiam, or `useControllerNodeRole: true`",
+		},
+		{
+			context: "WithEFSStorageClassWithoutFileSystemID",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.20.2
+addons:
+  efsCsiDriver:
+    enabled: true
+    useControllerNodeRole: true
+    storageClass:
+      enabled: true
+`,
+			expectedErrorMessage: "`addons.efsCsiDriver.storageClass.fileSystemId` must be specified when the storage class is enabled",
+		},
+		{
+			context: "WithAPIServerNonPositiveShutdownDelayDuration",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.20.2
+controller:
+  apiServer:
+    shutdownDelayDuration: 0s
+`,
+			expectedErrorMessage: "`controller.apiServer.shutdownDelayDuration` must be a positive duration like 70s, but was \"0s\"",
+		},
+		{
+			context: "WithAPIServerShutdownSendRetryAfterOnUnsupportedKubernetesVersion",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.20.2
+controller:
+  apiServer:
+    shutdownDelayDuration: 70s
+    shutdownSendRetryAfter: true
+`,
+			expectedErrorMessage: "`controller.apiServer.shutdownSendRetryAfter` requires kubernetesVersion 1.22 or greater, but was v1.20.2",
+		},
+		{
+			context: "WithRelativeEtcdDataDir",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  dataDir: var/lib/etcd
+`,
+			expectedErrorMessage: "invalid `etcd.dataDir` \"var/lib/etcd\": it must be an absolute path",
+		},
+		{
+			context: "WithAPIServerTooHighGoawayChance",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.20.2
+controller:
+  apiServer:
+    goawayChance: 0.5
+`,
+			expectedErrorMessage: "invalid `controller.apiServer.goawayChance` 0.5: it must be between 0 and 0.02",
+		},
+		{
+			context: "WithAPIServerFlowSchemaForUnknownPriorityLevel",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.23.4
+controller:
+  apiServer:
+    priorityAndFairness:
+      flowSchemas:
+      - name: noisy-clients
+        spec:
+          priorityLevelConfiguration:
+            name: noisy-clients
+          rules:
+          - subjects:
+            - kind: Group
+              group:
+                name: noisy
+`,
+			expectedErrorMessage: "invalid `controller.apiServer.priorityAndFairness.flowSchemas[0]`: `spec.priorityLevelConfiguration.name` refers to the unknown PriorityLevelConfiguration \"noisy-clients\"",
+		},
+		{
+			context: "WithAPIServerPriorityAndFairnessOnUnsupportedKubernetesVersion",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.19.4
+controller:
+  apiServer:
+    priorityAndFairness:
+      maxRequestsInflight: 800
+`,
+			expectedErrorMessage: "`controller.apiServer.priorityAndFairness` requires kubernetesVersion 1.20 or greater, but was v1.19.4",
+		},
+		{
+			context: "WithInvalidMaintenanceWindow",
+			configYaml: minimalValidConfigYaml + `
+maintenanceWindow:
+  days: [Sat]
+  start: "22:00"
+  end: "6am"
+`,
+			expectedErrorMessage: "invalid `maintenanceWindow.end` \"6am\": it must be a time of day like 06:00",
+		},
+		{
+			context: "WithMaintenanceWindowWithoutEnd",
+			configYaml: minimalValidConfigYaml + `
+maintenanceWindow:
+  days: [Sat]
+  start: "22:00"
+`,
+			expectedErrorMessage: "`maintenanceWindow.start` and `maintenanceWindow.end` must be specified together",
+		},
+		{
+			context: "WithContainerdForControllers",
+			configYaml: minimalValidConfigYaml + `
+containerRuntime: containerd
+`,
+			expectedErrorMessage: "the `containerd` container runtime is supported only for node pools",
+		},
+		{
+			context: "WithUnknownContainerRuntimeForNodePool",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    containerRuntime: cri-o
+`,
+			expectedErrorMessage: "invalid node pool \"pool1\": invalid `containerRuntime` \"cri-o\"",
+		},
+		{
+			context: "WithContainerRuntimeOnArm64NodePool",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    containerRuntime: containerd
+    instanceType: m6g.large
+`,
+			expectedErrorMessage: "invalid node pool \"pool1\": `containerRuntime` \"containerd\" isn't supported on the arm64 instance type \"m6g.large\"",
+		},
+		{
+			context: "WithInvalidIAMPermissionsBoundary",
+			configYaml: minimalValidConfigYaml + `
+iam:
+  permissionsBoundary: KubeAWSPermissionsBoundary
+`,
+			expectedErrorMessage: "invalid `iam.permissionsBoundary` \"KubeAWSPermissionsBoundary\": it must be an IAM policy ARN",
+		},
+		{
+			context: "WithUnknownLaunchTemplateVersion",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    autoscaling:
+      launchTemplateVersion: latest
+`,
+			expectedErrorMessage: "invalid `autoscaling.launchTemplateVersion` \"latest\": it must be one of $Latest, $Default or a version number",
+		},
+		{
+			context: "WithAmazonVPCSecondaryCIDROverlappingVPCCIDR",
+			configYaml: minimalValidConfigYaml + `
+kubernetes:
+  networking:
+    amazonVPC:
+      enabled: true
+      secondaryCIDR: 10.0.128.0/17
+      podSubnets:
+      - availabilityZone: us-west-1c
+        instanceCIDR: 10.0.128.0/18
+`,
+			expectedErrorMessage: "secondaryCIDR (10.0.128.0/17) overlaps with vpcCIDR (10.0.0.0/16)",
+		},
+		{
+			context: "WithAmazonVPCSecondaryCIDRWithoutPodSubnetForAvailabilityZone",
+			configYaml: minimalValidConfigYaml + `
+kubernetes:
+  networking:
+    amazonVPC:
+      enabled: true
+      secondaryCIDR: 100.64.0.0/16
+      podSubnets:
+      - availabilityZone: us-west-1a
+        instanceCIDR: 100.64.0.0/17
+`,
+			expectedErrorMessage: "there was none for us-west-1c",
+		},
+		{
+			// See https://github.com/kubernetes-incubator/kube-aws/issues/365
+			context:              "WithClusterNameContainsDots",
+			configYaml:           kubeAwsSettings.withClusterName("my.cluster").minimumValidClusterYaml(),
+			expectedErrorMessage: "clusterName(=my.cluster) is malformed. It must consist only of alphanumeric characters, colons, or hyphens",
+		},
+		{
+			context: "WithControllerTaint",
+			configYaml: minimalValidConfigYaml + `
+controller:
+  taints:
+  - key: foo
+    value: bar
+    effect: NoSchedule
+`,
+			expectedErrorMessage: "`controller.taints` must not be specified because tainting controller nodes breaks the cluster",
+		},
+		{
+			context: "WithElasticFileSystemIdInSpecificNodePoolWithManagedSubnets",
+			configYaml: mainClusterYaml + `
+subnets:
+- name: managed1
+  availabilityZone: us-west-1a
+  instanceCIDR: 10.0.1.0/24
+worker:
+  nodePools:
+  - name: pool1
+    subnets:
+    - name: managed1
+    elasticFileSystemId: efs-12345
+  - name: pool2
+`,
+			expectedErrorMessage: "invalid node pool at index 0: elasticFileSystemId cannot be specified for a node pool in managed subnet(s), but was: efs-12345",
+		},
+		{
+			context: "WithEtcdAutomatedDisasterRecoveryRequiresAutomatedSnapshot",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  version: 3
+  snapshot:
+    automated: false
+  disasterRecovery:
+    automated: true
+`,
+			expectedErrorMessage: "`etcd.disasterRecovery.automated` is set to true but `etcd.snapshot.automated` is not - automated disaster recovery requires snapshot to be also automated",
+		},
+		{
+			context: "WithEtcdAutomatedDisasterRecoveryDoesntSupportEtcd2",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  version: 2
+  snapshot:
+    automated: true
+  disasterRecovery:
+    automated: false
+`,
+			expectedErrorMessage: "`etcd.snapshot.automated` is set to true for enabling automated snapshot. However the feature is available only for etcd version 3",
+		},
+		{
+			context: "WithEtcdAutomatedSnapshotDoesntSupportEtcd2",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  version: 2
+  snapshot:
+    automated: false
+  disasterRecovery:
+    automated: true
+`,
+			expectedErrorMessage: "`etcd.disasterRecovery.automated` is set to true for enabling automated disaster recovery. However the feature is available only for etcd version 3",
+		},
+		{
+			context: "WithEtcdReplacingMembersFailingToStartTooEarly",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  snapshot:
+    automated: true
+  disasterRecovery:
+    automated: true
+    memberFailurePeriodLimit: 60
+    replaceMembersFailingToStart: true
+`,
+			expectedErrorMessage: "`etcd.disasterRecovery.memberFailurePeriodLimit` must be 180 or greater with `replaceMembersFailingToStart`, but was 60",
+		},
+		{
+			context: "WithInvalidNodeDrainTimeout",
+			configYaml: minimalValidConfigYaml + `
+experimental:
+  nodeDrainer:
+    enabled: true
+    drainTimeout: 100
+`,
+			expectedErrorMessage: "Drain timeout must be an integer between 1 and 60, but was 100",
+		},
+		{
+			context: "WithInvalidNodeDrainerGracePeriod",
+			configYaml: minimalValidConfigYaml + `
+experimental:
+  nodeDrainer:
+    enabled: true
+    drainTimeout: 5
+    drain:
+      gracePeriod: 0s
+`,
+			expectedErrorMessage: "`experimental.nodeDrainer.drain.gracePeriod` must be a positive duration like 60s, but was \"0s\"",
+		},
+		{
+			context: "WithInvalidTaint",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    taints:
+    - key: foo
+      value: bar
+      effect: UnknownEffect
+`,
+			expectedErrorMessage: "invalid taint effect: UnknownEffect",
+		},
+		{
+			context: "WithLegacyControllerSettingKeys",
+			configYaml: minimalValidConfigYaml + `
+vpc:
+  id: vpc-1a2b3c4d
+internetGateway:
+  id: igw-1a2b3c4d
+routeTableId: rtb-1a2b3c4d
+controllerCount: 2
+controllerCreateTimeout: PT10M
+controllerInstanceType: t2.large
+controllerRootVolumeSize: 101
+controllerRootVolumeType: io1
+controllerRootVolumeIOPS: 102
+controllerTenancy: dedicated
+`,
+			expectedErrorMessage: "unknown keys found: controllerCount, controllerCreateTimeout, controllerInstanceType, controllerRootVolumeIOPS, controllerRootVolumeSize, controllerRootVolumeType, controllerTenancy",
+		},
+		{
+			context: "WithLegacyEtcdSettingKeys",
+			configYaml: minimalValidConfigYaml + `
+vpc:
+  id: vpc-1a2b3c4d
+internetGateway:
+  id: igw-1a2b3c4d
+routeTableId: rtb-1a2b3c4d
+etcdCount: 2
+etcdTenancy: dedicated
+etcdInstanceType: t2.large
+etcdRootVolumeSize: 101
+etcdRootVolumeType: io1
+etcdRootVolumeIOPS: 102
+etcdDataVolumeSize: 103
+etcdDataVolumeType: io1
+etcdDataVolumeIOPS: 104
+etcdDataVolumeEncrypted: true
+`,
+			expectedErrorMessage: "unknown keys found: etcdCount, etcdDataVolumeEncrypted, etcdDataVolumeIOPS, etcdDataVolumeSize, etcdDataVolumeType, etcdInstanceType, etcdRootVolumeIOPS, etcdRootVolumeSize, etcdRootVolumeType, etcdTenancy",
+		},
+		{
+			context: "WithAwsNodeLabelEnabledForTooLongClusterNameAndPoolName",
+			configYaml: minimalValidConfigYaml + `
+# clusterName + nodePools[].name should be less than or equal to 25 characters or the launch configuration name
+# "mykubeawsclustername-mynestedstackname-1N2C4K3LLBEDZ-WorkersLC-BC2S9P3JG2QD" exceeds the limit of 63 characters
+# See https://kubernetes.io/docs/user-guide/labels/#syntax-and-character-set
+clusterName: my-cluster1 # 11 characters
+worker:
+  nodePools:
+  - name: workernodepool1 # 15 characters
+    awsNodeLabels:
+      enabled: true
+`,
+			expectedErrorMessage: "awsNodeLabels can't be enabled for node pool because the total number of characters in clusterName(=\"my-cluster1\") + node pool's name(=\"workernodepool1\") exceeds the limit of 25",
+		},
+		{
+			context: "WithAwsNodeLabelEnabledForTooLongClusterName",
+			configYaml: minimalValidConfigYaml + `
+# clusterName should be less than or equal to 21 characters or the launch configuration name
+# "mykubeawsclustername-mynestedstackname-1N2C4K3LLBEDZ-ControllersLC-BC2S9P3JG2QD" exceeds the limit of 63 characters
+# See https://kubernetes.io/docs/user-guide/labels/#syntax-and-character-set
+clusterName: mycluster # 9
+experimental:
+  awsNodeLabels:
+     enabled: true
+`,
+			expectedErrorMessage: "awsNodeLabels can't be enabled for controllers because the total number of characters in clusterName(=\"mycluster\") exceeds the limit of 8",
+		},
+		{
+			context: "WithMultiAPIEndpointsInvalidLB",
+			configYaml: kubeAwsSettings.mainClusterYamlWithoutAPIEndpoint() + `
 vpc:
   id: vpc-1a2b3c4d
 internetGateway:
   id: igw-1a2b3c4d
-# routeTableId must be omitted
-# See https://github.com/kubernetes-incubator/kube-aws/pull/284#issuecomment-275962332
-# routeTableId: rtb-1a2b3c4d
+
 subnets:
-- name: private1
+- name: publicSubnet1
   availabilityZone: us-west-1a
   instanceCIDR: "10.0.1.0/24"
-  private: true
-- name: private2
-  availabilityZone: us-west-1b
-  instanceCIDR: "10.0.2.0/24"
-  private: true
-- name: public1
-  availabilityZone: us-west-1a
-  instanceCIDR: "10.0.3.0/24"
-- name: public2
-  availabilityZone: us-west-1b
-  instanceCIDR: "10.0.4.0/24"
-`,
-			assertConfig: []ConfigTester{
-				hasDefaultExperimentalFeatures,
-				everyPublicSubnetHasRouteToIGW,
-				hasTwoManagedNGWsAndEIPs,
-				func(c *config.Config, t *testing.T) {
-					private1 := api.NewPrivateSubnet("us-west-1a", "10.0.1.0/24")
-					private1.Name = "private1"
-
-					private2 := api.NewPrivateSubnet("us-west-1b", "10.0.2.0/24")
-					private2.Name = "private2"
-
-					public1 := api.NewPublicSubnet("us-west-1a", "10.0.3.0/24")
-					public1.Name = "public1"
 
-					public2 := api.NewPublicSubnet("us-west-1b", "10.0.4.0/24")
-					public2.Name = "public2"
+worker:
+  apiEndpointName: unversionedPublic
 
-					subnets := api.Subnets{
-						private1,
-						private2,
-						public1,
-						public2,
-					}
-					if !reflect.DeepEqual(c.AllSubnets(), subnets) {
-						t.Errorf("Managed subnets didn't match: expected=%v actual=%v", subnets, c.AllSubnets())
-					}
+apiEndpoints:
+- name: unversionedPublic
+  dnsName: api.example.com
+  loadBalancer:
+    id: elb-internet-facing
+    private: true
+    subnets:
+    - name: publicSubnet1
+    hostedZone:
+      id: hostedzone-public
+`,
+			expectedErrorMessage: "invalid apiEndpoint \"unversionedPublic\" at index 0: invalid loadBalancer: type, private, subnets, hostedZone must be omitted when id is specified to reuse an existing ELB",
+		},
+		{
+			context: "WithMultiAPIEndpointsInvalidWorkerAPIEndpointName",
+			configYaml: kubeAwsSettings.mainClusterYamlWithoutAPIEndpoint() + `
+vpc:
+  id: vpc-1a2b3c4d
+internetGateway:
+  id: igw-1a2b3c4d
 
-					publicSubnets := api.Subnets{
-						public1,
-						public2,
-					}
+subnets:
+- name: publicSubnet1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.1.0/24"
 
-					if !reflect.DeepEqual(c.Controller.Subnets, publicSubnets) {
-						t.Errorf("Controller subnets didn't match: expected=%v actual=%v", publicSubnets, c.Controller.Subnets)
-					}
-					if !reflect.DeepEqual(c.Controller.LoadBalancer.Subnets, publicSubnets) {
-						t.Errorf("Controller loadbalancer subnets didn't match: expected=%v actual=%v", publicSubnets, c.Controller.LoadBalancer.Subnets)
-					}
-					if !reflect.DeepEqual(c.Etcd.Subnets, publicSubnets) {
-						t.Errorf("Etcd subnets didn't match: expected=%v actual=%v", publicSubnets, c.Etcd.Subnets)
-					}
+worker:
+  # no api endpoint named like that exists!
+  apiEndpointName: unknownEndpoint
 
-					for i, s := range c.PrivateSubnets() {
-						if !s.ManageNATGateway() {
-							t.Errorf("NAT gateway for the private subnet #%d should be created by kube-aws but it is not going to be", i)
-						}
+adminAPIEndpointName: versionedPublic
 
-						if s.ManageRouteToInternet() {
-							t.Errorf("Route to IGW shouldn't be created for a private subnet: %v", s)
-						}
-					}
-				},
-			},
+apiEndpoints:
+- name: unversionedPublic
+  dnsName: api.example.com
+  loadBalancer:
+    subnets:
+    - name: publicSubnet1
+    hostedZone:
+      id: hostedzone-public
+- name: versionedPublic
+  dnsName: apiv1.example.com
+  loadBalancer:
+    subnets:
+    - name: publicSubnet1
+    hostedZone:
+      id: hostedzone-public
+`,
+			expectedErrorMessage: "invalid value for worker.apiEndpointName: no API endpoint named \"unknownEndpoint\" found",
 		},
 		{
-			context: "WithNetworkTopologyControllerPrivateLB",
-			configYaml: mainClusterYaml + `
+			context: "WithMultiAPIEndpointsInvalidWorkerNodePoolAPIEndpointName",
+			configYaml: kubeAwsSettings.mainClusterYamlWithoutAPIEndpoint() + `
 vpc:
   id: vpc-1a2b3c4d
 internetGateway:
   id: igw-1a2b3c4d
-# routeTableId must be omitted
-# See https://github.com/kubernetes-incubator/kube-aws/pull/284#issuecomment-275962332
-# routeTableId: rtb-1a2b3c4d
+
 subnets:
-- name: private1
+- name: publicSubnet1
   availabilityZone: us-west-1a
   instanceCIDR: "10.0.1.0/24"
-  private: true
-- name: private2
-  availabilityZone: us-west-1b
-  instanceCIDR: "10.0.2.0/24"
-  private: true
-- name: public1
-  availabilityZone: us-west-1a
-  instanceCIDR: "10.0.3.0/24"
-- name: public2
-  availabilityZone: us-west-1b
-  instanceCIDR: "10.0.4.0/24"
-controller:
-  subnets:
-  - name: private1
-  - name: private2
-  loadBalancer:
-    private: true
-etcd:
-  subnets:
-  - name: private1
-  - name: private2
+
 worker:
+  # this one is ok but...
+  apiEndpointName: versionedPublic
   nodePools:
   - name: pool1
-    subnets:
-    - name: public1
-    - name: public2
-`,
-			assertConfig: []ConfigTester{
-				hasDefaultExperimentalFeatures,
-				everyPublicSubnetHasRouteToIGW,
-				hasTwoManagedNGWsAndEIPs,
-				func(c *config.Config, t *testing.T) {
-					private1 := api.NewPrivateSubnet("us-west-1a", "10.0.1.0/24")
-					private1.Name = "private1"
-
-					private2 := api.NewPrivateSubnet("us-west-1b", "10.0.2.0/24")
-					private2.Name = "private2"
-
-					public1 := api.NewPublicSubnet("us-west-1a", "10.0.3.0/24")
-					public1.Name = "public1"
-
-					public2 := api.NewPublicSubnet("us-west-1b", "10.0.4.0/24")
-					public2.Name = "public2"
-
-					subnets := api.Subnets{
-						private1,
-						private2,
-						public1,
-						public2,
-					}
-					if !reflect.DeepEqual(c.AllSubnets(), subnets) {
-						t.Errorf("Managed subnets didn't match: expected=%v actual=%v", subnets, c.AllSubnets())
-					}
-
-					importedPublicSubnets := api.Subnets{
-						api.NewPublicSubnetFromFn("us-west-1a", `{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-Public1"}}`),
-						api.NewPublicSubnetFromFn("us-west-1b", `{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-Public2"}}`),
-					}
-					p := c.NodePools[0]
-					if !reflect.DeepEqual(p.Subnets, importedPublicSubnets) {
-						t.Errorf("Worker subnets didn't match: expected=%v actual=%v", importedPublicSubnets, p.Subnets)
-					}
+    # this one is ng; no api endpoint named this exists!
+    apiEndpointName: unknownEndpoint
 
-					privateSubnets := api.Subnets{
-						private1,
-						private2,
-					}
-					if !reflect.DeepEqual(c.Controller.Subnets, privateSubnets) {
-						t.Errorf("Controller subnets didn't match: expected=%v actual=%v", privateSubnets, c.Controller.Subnets)
-					}
-					if !reflect.DeepEqual(c.Controller.LoadBalancer.Subnets, privateSubnets) {
-						t.Errorf("Controller loadbalancer subnets didn't match: expected=%v actual=%v", privateSubnets, c.Controller.LoadBalancer.Subnets)
-					}
-					if !reflect.DeepEqual(c.Etcd.Subnets, privateSubnets) {
-						t.Errorf("Etcd subnets didn't match: expected=%v actual=%v", privateSubnets, c.Etcd.Subnets)
-					}
+adminAPIEndpointName: versionedPublic
 
-					for i, s := range c.PrivateSubnets() {
-						if !s.ManageNATGateway() {
-							t.Errorf("NAT gateway for the private subnet #%d should be created by kube-aws but it is not going to be", i)
-						}
+apiEndpoints:
+- name: unversionedPublic
+  dnsName: api.example.com
+  loadBalancer:
+    subnets:
+    - name: publicSubnet1
+    hostedZone:
+      id: hostedzone-public
+- name: versionedPublic
+  dnsName: apiv1.example.com
+  loadBalancer:
+    subnets:
+    - name: publicSubnet1
+    hostedZone:
+      id: hostedzone-public
+`,
+			expectedErrorMessage: "invalid node pool at index 0: failed to find an API endpoint named \"unknownEndpoint\": no API endpoint named \"unknownEndpoint\" defined under the `apiEndpoints[]`",
+		},
+		{
+			context: "WithMultiAPIEndpointsMissingDNSName",
+			configYaml: kubeAwsSettings.mainClusterYamlWithoutAPIEndpoint() + `
+vpc:
+  id: vpc-1a2b3c4d
+internetGateway:
+  id: igw-1a2b3c4d
 
-						if s.ManageRouteToInternet() {
-							t.Errorf("Route to IGW shouldn't be created for a private subnet: %v", s)
-						}
-					}
-				},
-			},
+subnets:
+- name: publicSubnet1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.1.0/24"
+
+apiEndpoints:
+- name: unversionedPublic
+  dnsName:
+  loadBalancer:
+    hostedZone:
+      id: hostedzone-public
+`,
+			expectedErrorMessage: "invalid apiEndpoint \"unversionedPublic\" at index 0: dnsName must be set",
 		},
 		{
-			context: "WithNetworkTopologyControllerPublicLB",
-			configYaml: mainClusterYaml + `
+			context: "WithMultiAPIEndpointsMissingGlobalAPIEndpointName",
+			configYaml: kubeAwsSettings.mainClusterYamlWithoutAPIEndpoint() + `
 vpc:
   id: vpc-1a2b3c4d
 internetGateway:
   id: igw-1a2b3c4d
-# routeTableId must be omitted
-# See https://github.com/kubernetes-incubator/kube-aws/pull/284#issuecomment-275962332
-# routeTableId: rtb-1a2b3c4d
+
 subnets:
-- name: private1
+- name: publicSubnet1
   availabilityZone: us-west-1a
   instanceCIDR: "10.0.1.0/24"
-  private: true
-- name: private2
-  availabilityZone: us-west-1b
-  instanceCIDR: "10.0.2.0/24"
-  private: true
-- name: public1
-  availabilityZone: us-west-1a
-  instanceCIDR: "10.0.3.0/24"
-- name: public2
-  availabilityZone: us-west-1b
-  instanceCIDR: "10.0.4.0/24"
-controller:
-  loadBalancer:
-    private: false
-etcd:
-  subnets:
-  - name: private1
-  - name: private2
+
 worker:
   nodePools:
   - name: pool1
-    subnets:
-    - name: public1
-    - name: public2
-`,
-			assertConfig: []ConfigTester{
-				hasDefaultExperimentalFeatures,
-				everyPublicSubnetHasRouteToIGW,
-				hasTwoManagedNGWsAndEIPs,
-				func(c *config.Config, t *testing.T) {
-					private1 := api.NewPrivateSubnet("us-west-1a", "10.0.1.0/24")
-					private1.Name = "private1"
-
-					private2 := api.NewPrivateSubnet("us-west-1b", "10.0.2.0/24")
-					private2.Name = "private2"
-
-					public1 := api.NewPublicSubnet("us-west-1a", "10.0.3.0/24")
-					public1.Name = "public1"
-
-					public2 := api.NewPublicSubnet("us-west-1b", "10.0.4.0/24")
-					public2.Name = "public2"
-
-					subnets := api.Subnets{
-						private1,
-						private2,
-						public1,
-						public2,
-					}
-					publicSubnets := api.Subnets{
-						public1,
-						public2,
-					}
-					privateSubnets := api.Subnets{
-						private1,
-						private2,
-					}
-					importedPublicSubnets := api.Subnets{
-						api.NewPublicSubnetFromFn("us-west-1a", `{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-Public1"}}`),
-						api.NewPublicSubnetFromFn("us-west-1b", `{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-Public2"}}`),
-					}
-
-					if !reflect.DeepEqual(c.AllSubnets(), subnets) {
-						t.Errorf("Managed subnets didn't match: expected=%v actual=%v", subnets, c.AllSubnets())
-					}
-					p := c.NodePools[0]
-					if !reflect.DeepEqual(p.Subnets, importedPublicSubnets) {
-						t.Errorf("Worker subnets didn't match: expected=%v actual=%v", importedPublicSubnets, p.Subnets)
-					}
-					if !reflect.DeepEqual(c.Controller.Subnets, publicSubnets) {
-						t.Errorf("Controller subnets didn't match: expected=%v actual=%v", privateSubnets, c.Controller.Subnets)
-					}
-					if !reflect.DeepEqual(c.Controller.LoadBalancer.Subnets, publicSubnets) {
-						t.Errorf("Controller loadbalancer subnets didn't match: expected=%v actual=%v", privateSubnets, c.Controller.LoadBalancer.Subnets)
-					}
-					if !reflect.DeepEqual(c.Etcd.Subnets, privateSubnets) {
-						t.Errorf("Etcd subnets didn't match: expected=%v actual=%v", privateSubnets, c.Etcd.Subnets)
-					}
+    # this one is ng; no api endpoint named this exists!
+    apiEndpointName: unknownEndpoint
+  - name: pool1
+    # this one is ng; missing apiEndpointName
 
-					for i, s := range c.PrivateSubnets() {
-						if !s.ManageNATGateway() {
-							t.Errorf("NAT gateway for the private subnet #%d should be created by kube-aws but it is not going to be", i)
-						}
+adminAPIEndpointName: versionedPublic
 
-						if s.ManageRouteToInternet() {
-							t.Errorf("Route to IGW shouldn't be created for a private subnet: %v", s)
-						}
-					}
-				},
-			},
+apiEndpoints:
+- name: unversionedPublic
+  dnsName: api.example.com
+  loadBalancer:
+    subnets:
+    - name: publicSubnet1
+    hostedZone:
+      id: hostedzone-public
+- name: versionedPublic
+  dnsName: apiv1.example.com
+  loadBalancer:
+    subnets:
+    - name: publicSubnet1
+    hostedZone:
+      id: hostedzone-public
+`,
+			expectedErrorMessage: "worker.apiEndpointName must not be empty when there're 2 or more API endpoints under the key `apiEndpoints` and one of worker.nodePools[] are missing apiEndpointName",
 		},
 		{
-			context: "WithNetworkTopologyExistingVaryingSubnets",
-			configYaml: mainClusterYaml + `
+			context: "WithMultiAPIEndpointsRecordSetImpliedBySubnetsMissingHostedZoneID",
+			configYaml: kubeAwsSettings.mainClusterYamlWithoutAPIEndpoint() + `
 vpc:
   id: vpc-1a2b3c4d
+internetGateway:
+  id: igw-1a2b3c4d
+
 subnets:
-- name: private1
-  availabilityZone: us-west-1a
-  id: subnet-1
-  private: true
-- name: private2
-  availabilityZone: us-west-1b
-  idFromStackOutput: mycluster-private-subnet-1
-  private: true
-- name: public1
+- name: publicSubnet1
   availabilityZone: us-west-1a
-  id: subnet-2
-- name: public2
-  availabilityZone: us-west-1b
-  idFromStackOutput: mycluster-public-subnet-1
-controller:
-  loadBalancer:
-    private: false
-etcd:
-  subnets:
-  - name: private1
-  - name: private2
+  instanceCIDR: "10.0.1.0/24"
+
 worker:
-  nodePools:
-  - name: pool1
+  apiEndpointName: unversionedPublic
+
+apiEndpoints:
+- name: unversionedPublic
+  dnsName: api.example.com
+  loadBalancer:
+    # an internet-facing(which is the default) lb in the public subnet is going to be created with a corresponding record set
+    # however no hosted zone for the record set is provided!
     subnets:
-    - name: public1
-    - name: public2
+    - name: publicSubnet1
+    # missing hosted zone id here!
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultExperimentalFeatures,
-				hasNoNGWsOrEIPsOrRoutes,
-				func(c *config.Config, t *testing.T) {
-					private1 := api.NewExistingPrivateSubnet("us-west-1a", "subnet-1")
-					private1.Name = "private1"
-
-					private2 := api.NewImportedPrivateSubnet("us-west-1b", "mycluster-private-subnet-1")
-					private2.Name = "private2"
-
-					public1 := api.NewExistingPublicSubnet("us-west-1a", "subnet-2")
-					public1.Name = "public1"
-
-					public2 := api.NewImportedPublicSubnet("us-west-1b", "mycluster-public-subnet-1")
-					public2.Name = "public2"
-
-					subnets := api.Subnets{
-						private1,
-						private2,
-						public1,
-						public2,
-					}
-					publicSubnets := api.Subnets{
-						public1,
-						public2,
-					}
-					privateSubnets := api.Subnets{
-						private1,
-						private2,
-					}
+			expectedErrorMessage: "invalid apiEndpoint \"unversionedPublic\" at index 0: invalid loadBalancer: missing hostedZone.id",
+		},
+		{
+			context: "WithMultiAPIEndpointsRecordSetImpliedByExplicitPublicMissingHostedZoneID",
+			configYaml: kubeAwsSettings.mainClusterYamlWithoutAPIEndpoint() + `
+vpc:
+  id: vpc-1a2b3c4d
+internetGateway:
+  id: igw-1a2b3c4d
 
-					if !reflect.DeepEqual(c.AllSubnets(), subnets) {
-						t.Errorf("Managed subnets didn't match: expected=%v actual=%v", subnets, c.AllSubnets())
-					}
-					p := c.NodePools[0]
-					if !reflect.DeepEqual(p.Subnets, publicSubnets) {
-						t.Errorf("Worker subnets didn't match: expected=%v actual=%v", publicSubnets, p.Subnets)
-					}
-					if !reflect.DeepEqual(c.Controller.Subnets, publicSubnets) {
-						t.Errorf("Controller subnets didn't match: expected=%v actual=%v", privateSubnets, c.Controller.Subnets)
-					}
-					if !reflect.DeepEqual(c.Controller.LoadBalancer.Subnets, publicSubnets) {
-						t.Errorf("Controller loadbalancer subnets didn't match: expected=%v actual=%v", privateSubnets, c.Controller.LoadBalancer.Subnets)
-					}
-					if !reflect.DeepEqual(c.Etcd.Subnets, privateSubnets) {
-						t.Errorf("Etcd subnets didn't match: expected=%v actual=%v", privateSubnets, c.Etcd.Subnets)
-					}
+subnets:
+- name: publicSubnet1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.1.0/24"
 
-					for i, s := range c.PrivateSubnets() {
-						if s.ManageNATGateway() {
-							t.Errorf("NAT gateway for the existing private subnet #%d should not be created by kube-aws", i)
-						}
+worker:
+  apiEndpointName: unversionedPublic
 
-						if s.ManageRouteToInternet() {
-							t.Errorf("Route to IGW shouldn't be created for a private subnet: %v", s)
-						}
-					}
-				},
-			},
+apiEndpoints:
+- name: unversionedPublic
+  dnsName: api.example.com
+  loadBalancer:
+    # an internet-facing lb is going to be created with a corresponding record set
+    # however no hosted zone for the record set is provided!
+    private: false
+    # missing hosted zone id here!
+`,
+			expectedErrorMessage: "invalid apiEndpoint \"unversionedPublic\" at index 0: invalid loadBalancer: missing hostedZone.id",
 		},
-		{
-			context: "WithNetworkTopologyAllExistingPrivateSubnets",
-			configYaml: kubeAwsSettings.mainClusterYamlWithoutAPIEndpoint() + fmt.Sprintf(`
+		{
+			context: "WithMultiAPIEndpointsRecordSetImpliedByExplicitPrivateMissingHostedZoneID",
+			configYaml: kubeAwsSettings.mainClusterYamlWithoutAPIEndpoint() + `
 vpc:
   id: vpc-1a2b3c4d
+internetGateway:
+  id: igw-1a2b3c4d
+
 subnets:
-- name: private1
+- name: publicSubnet1
   availabilityZone: us-west-1a
-  id: subnet-1
-  private: true
-- name: private2
-  availabilityZone: us-west-1b
-  idFromStackOutput: mycluster-private-subnet-1
-  private: true
-controller:
-  subnets:
-  - name: private1
-  - name: private2
-etcd:
-  subnets:
-  - name: private1
-  - name: private2
+  instanceCIDR: "10.0.1.0/24"
+- name: privateSubnet1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.2.0/24"
+
 worker:
-  nodePools:
-  - name: pool1
-    subnets:
-    - name: private1
-    - name: private2
+  apiEndpointName: unversionedPublic
+
 apiEndpoints:
-- name: public
-  dnsName: "%s"
+- name: unversionedPublic
+  dnsName: api.example.com
   loadBalancer:
-    hostedZone:
-      id: hostedzone-xxxx
+    # an internal lb is going to be created with a corresponding record set
+    # however no hosted zone for the record set is provided!
     private: true
-`, kubeAwsSettings.externalDNSName),
-			assertConfig: []ConfigTester{
-				hasDefaultExperimentalFeatures,
-				hasNoNGWsOrEIPsOrRoutes,
-			},
+    # missing hosted zone id here!
+`,
+			expectedErrorMessage: "invalid apiEndpoint \"unversionedPublic\" at index 0: invalid loadBalancer: missing hostedZone.id",
 		},
 		{
-			context: "WithNetworkTopologyAllExistingPublicSubnets",
+			context: "WithNetworkTopologyAllExistingPrivateSubnetsRejectingExistingIGW",
 			configYaml: mainClusterYaml + `
 vpc:
   id: vpc-1a2b3c4d
+internetGateway:
+  id: igw-1a2b3c4d
 subnets:
-- name: public1
+- name: private1
   availabilityZone: us-west-1a
-  id: subnet-2
-- name: public2
-  availabilityZone: us-west-1b
-  idFromStackOutput: mycluster-public-subnet-1
+  id: subnet-1
+  private: true
+controller:
+  loadBalancer:
+    private: true
 etcd:
   subnets:
-  - name: public1
-  - name: public2
+  - name: private1
 worker:
   nodePools:
   - name: pool1
     subnets:
-    - name: public1
-    - name: public2
+    - name: private1
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultExperimentalFeatures,
-				hasNoNGWsOrEIPsOrRoutes,
-			},
+			expectedErrorMessage: `internet gateway id can't be specified when all the subnets are existing private subnets`,
 		},
 		{
-			context: "WithNetworkTopologyExistingNATGateways",
+			context: "WithNetworkTopologyAllExistingPublicSubnetsRejectingExistingIGW",
 			configYaml: mainClusterYaml + `
 vpc:
   id: vpc-1a2b3c4d
 internetGateway:
   id: igw-1a2b3c4d
 subnets:
-- name: private1
-  availabilityZone: us-west-1a
-  instanceCIDR: "10.0.1.0/24"
-  private: true
-  natGateway:
-    id: ngw-11111111
-- name: private2
-  availabilityZone: us-west-1b
-  instanceCIDR: "10.0.2.0/24"
-  private: true
-  natGateway:
-    id: ngw-22222222
 - name: public1
   availabilityZone: us-west-1a
-  instanceCIDR: "10.0.3.0/24"
-- name: public2
-  availabilityZone: us-west-1b
-  instanceCIDR: "10.0.4.0/24"
+  id: subnet-1
+controller:
+  loadBalancer:
+    private: false
 etcd:
   subnets:
-  - name: private1
-  - name: private2
+  - name: public1
 worker:
   nodePools:
   - name: pool1
     subnets:
     - name: public1
-    - name: public2
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultExperimentalFeatures,
-				hasNoManagedNGWsButSpecificNumOfRoutesToUnmanagedNGWs(2),
-				func(c *config.Config, t *testing.T) {
-					private1 := api.NewPrivateSubnetWithPreconfiguredNATGateway("us-west-1a", "10.0.1.0/24", "ngw-11111111")
-					private1.Name = "private1"
-
-					private2 := api.NewPrivateSubnetWithPreconfiguredNATGateway("us-west-1b", "10.0.2.0/24", "ngw-22222222")
-					private2.Name = "private2"
-
-					public1 := api.NewPublicSubnet("us-west-1a", "10.0.3.0/24")
-					public1.Name = "public1"
-
-					public2 := api.NewPublicSubnet("us-west-1b", "10.0.4.0/24")
-					public2.Name = "public2"
-
-					subnets := api.Subnets{
-						private1,
-						private2,
-						public1,
-						public2,
-					}
-					publicSubnets := api.Subnets{
-						public1,
-						public2,
-					}
-					privateSubnets := api.Subnets{
-						private1,
-						private2,
-					}
-					importedPublicSubnets := api.Subnets{
-						api.NewPublicSubnetFromFn("us-west-1a", `{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-Public1"}}`),
-						api.NewPublicSubnetFromFn("us-west-1b", `{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-Public2"}}`),
-					}
-
-					if diff := cmp.Diff(c.AllSubnets(), subnets); diff != "" {
-						t.Errorf("Managed subnets didn't match: %s", diff)
-					}
-					p := c.NodePools[0]
-					if diff := cmp.Diff(p.Subnets, importedPublicSubnets); diff != "" {
-						t.Errorf("Worker subnets didn't match: %s", diff)
-					}
-					if diff := cmp.Diff(c.Controller.Subnets, publicSubnets); diff != "" {
-						t.Errorf("Controller subnets didn't match: %s", diff)
-					}
-					if diff := cmp.Diff(c.Controller.LoadBalancer.Subnets, publicSubnets); diff != "" {
-						t.Errorf("Controller loadbalancer subnets didn't match: %s", diff)
-					}
-					if diff := cmp.Diff(c.Etcd.Subnets, privateSubnets); diff != "" {
-						t.Errorf("Etcd subnets didn't match: %s", diff)
-					}
-
-					for i, s := range c.PrivateSubnets() {
-						if s.ManageNATGateway() {
-							t.Errorf("NAT gateway for the existing private subnet #%d should not be created by kube-aws", i)
-						}
-
-						if s.ManageRouteToInternet() {
-							t.Errorf("Route to IGW shouldn't be created for a private subnet: %v", s)
-						}
-					}
-				},
-			},
+			expectedErrorMessage: `internet gateway id can't be specified when all the public subnets have existing route tables associated. kube-aws doesn't try to modify an exisinting route table to include a route to the internet gateway`,
 		},
 		{
-			context: "WithNetworkTopologyExistingNATGatewayEIPs",
+			context: "WithNetworkTopologyAllManagedPublicSubnetsWithExistingRouteTableRejectingExistingIGW",
 			configYaml: mainClusterYaml + `
 vpc:
   id: vpc-1a2b3c4d
 internetGateway:
   id: igw-1a2b3c4d
 subnets:
-- name: private1
-  availabilityZone: us-west-1a
-  instanceCIDR: "10.0.1.0/24"
-  private: true
-  natGateway:
-    eipAllocationId: eipalloc-11111111
-- name: private2
-  availabilityZone: us-west-1b
-  instanceCIDR: "10.0.2.0/24"
-  private: true
-  natGateway:
-    eipAllocationId: eipalloc-22222222
 - name: public1
   availabilityZone: us-west-1a
-  instanceCIDR: "10.0.3.0/24"
-- name: public2
-  availabilityZone: us-west-1b
-  instanceCIDR: "10.0.4.0/24"
+  instanceCIDR: 10.0.1.0/24
+  routeTable:
+    id: subnet-1
+controller:
+  loadBalancer:
+    private: false
 etcd:
   subnets:
-  - name: private1
-  - name: private2
+  - name: public1
 worker:
   nodePools:
   - name: pool1
     subnets:
     - name: public1
-    - name: public2
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultExperimentalFeatures,
-				hasSpecificNumOfManagedNGWsWithUnmanagedEIPs(2),
-				hasPrivateSubnetsWithManagedNGWs(2),
-				func(c *config.Config, t *testing.T) {
-					private1 := api.NewPrivateSubnetWithPreconfiguredNATGatewayEIP("us-west-1a", "10.0.1.0/24", "eipalloc-11111111")
-					private1.Name = "private1"
-
-					private2 := api.NewPrivateSubnetWithPreconfiguredNATGatewayEIP("us-west-1b", "10.0.2.0/24", "eipalloc-22222222")
-					private2.Name = "private2"
-
-					public1 := api.NewPublicSubnet("us-west-1a", "10.0.3.0/24")
-					public1.Name = "public1"
-
-					public2 := api.NewPublicSubnet("us-west-1b", "10.0.4.0/24")
-					public2.Name = "public2"
-
-					subnets := api.Subnets{
-						private1,
-						private2,
-						public1,
-						public2,
-					}
-					publicSubnets := api.Subnets{
-						public1,
-						public2,
-					}
-					privateSubnets := api.Subnets{
-						private1,
-						private2,
-					}
-					importedPublicSubnets := api.Subnets{
-						api.NewPublicSubnetFromFn("us-west-1a", `{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-Public1"}}`),
-						api.NewPublicSubnetFromFn("us-west-1b", `{"Fn::ImportValue":{"Fn::Sub":"${NetworkStackName}-Public2"}}`),
-					}
-
-					if diff := cmp.Diff(c.AllSubnets(), subnets); diff != "" {
-						t.Errorf("Managed subnets didn't match: %s", diff)
-					}
-					p := c.NodePools[0]
-					if diff := cmp.Diff(p.Subnets, importedPublicSubnets); diff != "" {
-						t.Errorf("Worker subnets didn't match: %s", diff)
-					}
-					if diff := cmp.Diff(c.Controller.Subnets, publicSubnets); diff != "" {
-						t.Errorf("Controller subnets didn't match: %s", diff)
-					}
-					if diff := cmp.Diff(c.Controller.LoadBalancer.Subnets, publicSubnets); diff != "" {
-						t.Errorf("Controller loadbalancer subnets didn't match: %s", diff)
-					}
-					if diff := cmp.Diff(c.Etcd.Subnets, privateSubnets); diff != "" {
-						t.Errorf("Etcd subnets didn't match: %s", diff)
-					}
-				},
-			},
+			expectedErrorMessage: `internet gateway id can't be specified when all the public subnets have existing route tables associated. kube-aws doesn't try to modify an exisinting route table to include a route to the internet gateway`,
 		},
 		{
-			context: "WithNetworkTopologyVaryingPublicSubnets",
+			context: "WithNetworkTopologyAllManagedPublicSubnetsMissingExistingIGW",
 			configYaml: mainClusterYaml + `
 vpc:
-  id: vpc-1a2b3c4d
-#required only for the managed subnet "public1"
-# "public2" is assumed to have an existing route table and an igw already associated to it
-internetGateway:
-  id: igw-1a2b3c4d
+  id: vpc-1a2b3c4d
+#misses this
+#internetGateway:
+#  id: igw-1a2b3c4d
 subnets:
 - name: public1
   availabilityZone: us-west-1a
   instanceCIDR: "10.0.1.0/24"
-- name: public2
-  availabilityZone: us-west-1b
-  id: subnet-2
 controller:
   loadBalancer:
     private: false
 etcd:
   subnets:
   - name: public1
-  - name: public2
 worker:
   nodePools:
   - name: pool1
     subnets:
     - name: public1
-    - name: public2
 `,
-			assertConfig: []ConfigTester{},
+			expectedErrorMessage: `internet gateway id can't be omitted when there're one or more managed public subnets in an existing VPC`,
 		},
 		{
-			context: "WithSpotFleetEnabled",
+			context: "WithNetworkTopologyAllPreconfiguredPrivateDeprecatedAndThenRemoved",
+			configYaml: mainClusterYaml + `
+vpc:
+  id: vpc-1a2b3c4d
+# This, in combination with mapPublicIPs=false, had been implying that the route table contains a route to a preconfigured NAT gateway
+# See https://github.com/kubernetes-incubator/kube-aws/pull/284#issuecomment-276008202
+routeTableId: rtb-1a2b3c4d
+# This had been implied that all the subnets created by kube-aws should be private
+mapPublicIPs: false
+subnets:
+- availabilityZone: us-west-1a
+  instanceCIDR: "10.0.1.0/24"
+  # implies
+  # private: true
+  # routeTable
+  #   id: rtb-1a2b3c4d
+- availabilityZone: us-west-1b
+  instanceCIDR: "10.0.2.0/24"
+  # implies
+  # private: true
+  # routeTable
+  #   id: rtb-1a2b3c4d
+`,
+			expectedErrorMessage: "internet gateway id can't be omitted when there're one or more managed public subnets in an existing VPC",
+		},
+		{
+			context: "WithNetworkTopologyAllPreconfiguredPublicDeprecatedAndThenRemoved",
+			configYaml: mainClusterYaml + `
+vpc:
+  id: vpc-1a2b3c4d
+# This, in combination with mapPublicIPs=true, had been implying that the route table contains a route to a preconfigured internet gateway
+# See https://github.com/kubernetes-incubator/kube-aws/pull/284#issuecomment-276008202
+routeTableId: rtb-1a2b3c4d
+# This had been implied that all the subnets created by kube-aws should be public
+mapPublicIPs: true
+# internetGateway.id should be omitted as we assume that the route table specified by routeTableId already contain a route to one
+#internetGateway:
+#  id:
+subnets:
+- availabilityZone: us-west-1a
+  instanceCIDR: "10.0.1.0/24"
+  # #implies
+  # private: false
+  # routeTable
+  #   id: rtb-1a2b3c4d
+- availabilityZone: us-west-1b
+  instanceCIDR: "10.0.2.0/24"
+  # #implies
+  # private: false
+  # routeTable
+  #   id: rtb-1a2b3c4d
+`,
+			expectedErrorMessage: "internet gateway id can't be omitted when there're one or more managed public subnets in an existing VPC",
+		},
+		{
+			context: "WithVpcIdAndVPCCIDRSpecified",
 			configYaml: minimalValidConfigYaml + `
-worker:
-  nodePools:
-  - name: pool1
-    spotFleet:
-      targetCapacity: 10
+vpc:
+  id: vpc-1a2b3c4d
+internetGateway:
+  id: igw-1a2b3c4d
+# vpcCIDR (10.1.0.0/16) does not contain instanceCIDR (10.0.1.0/24)
+vpcCIDR: "10.1.0.0/16"
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultExperimentalFeatures,
-				hasDefaultLaunchSpecifications,
-				spotFleetBasedNodePoolHasWaitSignalDisabled,
-			},
 		},
 		{
-			context: "WithSpotFleetEnabledWithCustomIamRole",
+			context: "WithRouteTableIdSpecified",
 			configYaml: minimalValidConfigYaml + `
-worker:
-  nodePools:
-  - name: pool1
-    spotFleet:
-      targetCapacity: 10
-      iamFleetRoleArn: custom-iam-role
+# vpc.id must be specified if routeTableId is specified
+routeTableId: rtb-1a2b3c4d
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultExperimentalFeatures,
-				hasDefaultLaunchSpecifications,
-				spotFleetBasedNodePoolHasWaitSignalDisabled,
-			},
 		},
 		{
-			context: "WithSpotFleetWithCustomGp2RootVolumeSettings",
+			context: "WithWorkerSecurityGroupIds",
 			configYaml: minimalValidConfigYaml + `
 worker:
   nodePools:
   - name: pool1
-    spotFleet:
-      targetCapacity: 10
-      unitRootVolumeSize: 40
-      launchSpecifications:
-      - weightedCapacity: 1
-        instanceType: c4.large
-      - weightedCapacity: 2
-        instanceType: c4.xlarge
-        rootVolume:
-          size: 100
+    securityGroupIds:
+    - sg-12345678
+    - sg-abcdefab
+    - sg-23456789
+    - sg-bcdefabc
+    - sg-34567890
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultExperimentalFeatures,
-				spotFleetBasedNodePoolHasWaitSignalDisabled,
-				func(c *config.Config, t *testing.T) {
-					expected := []api.LaunchSpecification{
-						{
-							WeightedCapacity: 1,
-							InstanceType:     "c4.large",
-							SpotPrice:        "",
-							// RootVolumeSize was not specified in the configYaml but should default to workerRootVolumeSize * weightedCapacity
-							// RootVolumeType was not specified in the configYaml but should default to "gp2"
-							RootVolume: api.NewGp2RootVolume(40),
-						},
-						{
-							WeightedCapacity: 2,
-							InstanceType:     "c4.xlarge",
-							SpotPrice:        "",
-							RootVolume:       api.NewGp2RootVolume(100),
-						},
-					}
-					p := c.NodePools[0]
-					actual := p.WorkerNodePool.SpotFleet.LaunchSpecifications
-					if !reflect.DeepEqual(expected, actual) {
-						t.Errorf(
-							"LaunchSpecifications didn't match: expected=%v actual=%v",
-							expected,
-							actual,
-						)
-					}
-				},
-			},
+			expectedErrorMessage: "number of user provided security groups must be less than or equal to 4 but was 5",
 		},
 		{
-			context: "WithSpotFleetWithCustomInstanceTypes",
+			context: "WithWorkerAndLBSecurityGroupIds",
 			configYaml: minimalValidConfigYaml + `
 worker:
   nodePools:
   - name: pool1
-    spotFleet:
-      targetCapacity: 10
-      unitRootVolumeSize: 40
-      launchSpecifications:
-      - weightedCapacity: 1
-        instanceType: m4.large
-      - weightedCapacity: 2
-        instanceType: m4.xlarge
+    securityGroupIds:
+    - sg-12345678
+    - sg-abcdefab
+    - sg-23456789
+    loadBalancer:
+      enabled: true
+      securityGroupIds:
+        - sg-bcdefabc
+        - sg-34567890
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultExperimentalFeatures,
-				spotFleetBasedNodePoolHasWaitSignalDisabled,
-				func(c *config.Config, t *testing.T) {
-					expected := []api.LaunchSpecification{
-						{
-							WeightedCapacity: 1,
-							InstanceType:     "m4.large",
-							SpotPrice:        "",
-							// RootVolumeType was not specified in the configYaml but should default to gp2:
-							RootVolume: api.NewGp2RootVolume(40),
-						},
-						{
-							WeightedCapacity: 2,
-							InstanceType:     "m4.xlarge",
-							SpotPrice:        "",
-							RootVolume:       api.NewGp2RootVolume(80),
-						},
-					}
-					p := c.NodePools[0]
-					actual := p.WorkerNodePool.SpotFleet.LaunchSpecifications
-					if !reflect.DeepEqual(expected, actual) {
-						t.Errorf(
-							"LaunchSpecifications didn't match: expected=%v actual=%v",
-							expected,
-							actual,
-						)
-					}
-				},
-			},
+			expectedErrorMessage: "number of user provided security groups must be less than or equal to 4 but was 5",
 		},
 		{
-			context: "WithSpotFleetWithCustomIo1RootVolumeSettings",
+			context: "WithWorkerAndALBSecurityGroupIds",
 			configYaml: minimalValidConfigYaml + `
 worker:
   nodePools:
   - name: pool1
-    spotFleet:
-      targetCapacity: 10
-      rootVolumeType: io1
-      unitRootVolumeSize: 40
-      unitRootVolumeIOPS: 100
-      launchSpecifications:
-      - weightedCapacity: 1
-        instanceType: c4.large
-      - weightedCapacity: 2
-        instanceType: c4.xlarge
-        rootVolume:
-          iops: 500
-`,
-			assertConfig: []ConfigTester{
-				hasDefaultExperimentalFeatures,
-				spotFleetBasedNodePoolHasWaitSignalDisabled,
-				func(c *config.Config, t *testing.T) {
-					expected := []api.LaunchSpecification{
-						{
-							WeightedCapacity: 1,
-							InstanceType:     "c4.large",
-							SpotPrice:        "",
-							// RootVolumeSize was not specified in the configYaml but should default to workerRootVolumeSize * weightedCapacity
-							// RootVolumeIOPS was not specified in the configYaml but should default to workerRootVolumeIOPS * weightedCapacity
-							// RootVolumeType was not specified in the configYaml but should default to "io1"
-							RootVolume: api.NewIo1RootVolume(40, 100),
-						},
-						{
-							WeightedCapacity: 2,
-							InstanceType:     "c4.xlarge",
-							SpotPrice:        "",
-							// RootVolumeType was not specified in the configYaml but should default to:
-							RootVolume: api.NewIo1RootVolume(80, 500),
-						},
-					}
-					p := c.NodePools[0]
-					actual := p.WorkerNodePool.SpotFleet.LaunchSpecifications
-					if !reflect.DeepEqual(expected, actual) {
-						t.Errorf(
-							"LaunchSpecifications didn't match: expected=%v actual=%v",
-							expected,
-							actual,
-						)
-					}
-				},
-			},
+    securityGroupIds:
+    - sg-12345678
+    - sg-abcdefab
+    - sg-23456789
+    targetGroup:
+      enabled: true
+      securityGroupIds:
+        - sg-bcdefabc
+        - sg-34567890
+`,
+			expectedErrorMessage: "number of user provided security groups must be less than or equal to 4 but was 5",
 		},
 		{
-			context: "WithVpcIdSpecified",
+			context: "WithUnknownKeyInRoot",
 			configYaml: minimalValidConfigYaml + `
-vpc:
-  id: vpc-1a2b3c4d
-internetGateway:
-  id: igw-1a2b3c4d
+foo: bar
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultEtcdSettings,
-				hasDefaultExperimentalFeatures,
-				func(c *config.Config, t *testing.T) {
-					vpcId := "vpc-1a2b3c4d"
-					if c.VPC.ID != vpcId {
-						t.Errorf("vpc id didn't match: expected=%v actual=%v", vpcId, c.VPC.ID)
-					}
-					igwId := "igw-1a2b3c4d"
-					if c.InternetGateway.ID != igwId {
-						t.Errorf("internet gateway id didn't match: expected=%v actual=%v", igwId, c.InternetGateway.ID)
-					}
-				},
-			},
+			expectedErrorMessage: "unknown keys found: foo",
 		},
 		{
-			context: "WithLegacyVpcAndIGWIdSpecified",
+			context: "WithUnknownKeyInController",
 			configYaml: minimalValidConfigYaml + `
-vpcId: vpc-1a2b3c4d
-internetGatewayId: igw-1a2b3c4d
+controller:
+  foo: 1
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultEtcdSettings,
-				hasDefaultExperimentalFeatures,
-				func(c *config.Config, t *testing.T) {
-					vpcId := "vpc-1a2b3c4d"
-					if c.VPC.ID != vpcId {
-						t.Errorf("vpc id didn't match: expected=%v actual=%v", vpcId, c.VPC.ID)
-					}
-					igwId := "igw-1a2b3c4d"
-					if c.InternetGateway.ID != igwId {
-						t.Errorf("internet gateway id didn't match: expected=%v actual=%v", igwId, c.InternetGateway.ID)
-					}
-				},
-			},
+			expectedErrorMessage: "unknown keys found in controller: foo",
 		},
 		{
-			context: "WithVpcIdAndRouteTableIdSpecified",
-			configYaml: mainClusterYaml + `
-vpc:
-  id: vpc-1a2b3c4d
-subnets:
-- name: Subnet0
-  availabilityZone: ` + firstAz + `
-  instanceCIDR: "10.0.0.0/24"
-  routeTable:
-    id: rtb-1a2b3c4d
+			context: "WithUnknownKeyInControllerASG",
+			configYaml: minimalValidConfigYaml + `
+controller:
+  autoScalingGroup:
+    foo: 1
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultExperimentalFeatures,
-				func(c *config.Config, t *testing.T) {
-					subnet1 := api.NewPublicSubnetWithPreconfiguredRouteTable(firstAz, "10.0.0.0/24", "rtb-1a2b3c4d")
-					subnet1.Name = "Subnet0"
-					subnets := api.Subnets{
-						subnet1,
-					}
-					expected := api.EtcdSettings{
-						Etcd: api.Etcd{
-							EC2Instance: api.EC2Instance{
-								Count:        1,
-								InstanceType: "t2.medium",
-								RootVolume: api.RootVolume{
-									Size: 30,
-									Type: "gp2",
-									IOPS: 0,
-								},
-								Tenancy: "default",
-							},
-							DataVolume: api.DataVolume{
-								Size:      30,
-								Type:      "gp2",
-								IOPS:      0,
-								Ephemeral: false,
-							},
-							Subnets: subnets,
-							UserSuppliedArgs: api.UserSuppliedArgs{
-								QuotaBackendBytes: api.DefaultQuotaBackendBytes,
-							},
-						},
-					}
-					actual := c.EtcdSettings
-					if !reflect.DeepEqual(expected, actual) {
-						t.Errorf(
-							"EtcdSettings didn't match: expected=%v actual=%v",
-							expected,
-							actual,
-						)
-					}
-				},
-			},
+			expectedErrorMessage: "unknown keys found in controller.autoScalingGroup: foo",
 		},
 		{
-			context: "WithWorkerManagedIamRoleName",
+			context: "WithUnknownKeyInEtcd",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  foo: 1
+`,
+			expectedErrorMessage: "unknown keys found in etcd: foo",
+		},
+		{
+			context: "WithUnknownKeyInWorkerNodePool",
 			configYaml: minimalValidConfigYaml + `
 worker:
   nodePools:
   - name: pool1
-    iam:
-      role:
-        name: "myManagedRole"
+    clusterAutoscaler:
+      enabled: true
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultEtcdSettings,
-				hasDefaultExperimentalFeatures,
-				func(c *config.Config, t *testing.T) {
-					if c.NodePools[0].IAMConfig.Role.Name != "myManagedRole" {
-						t.Errorf("iam.role.name: expected=myManagedRole actual=%s", c.NodePools[0].IAMConfig.Role.Name)
-					}
-				},
-			},
+			expectedErrorMessage: "unknown keys found in worker.nodePools[0]: clusterAutoscaler",
 		},
 		{
-			context: "WithWorkerManagedPolicies",
+			context: "WithUnknownKeyInWorkerNodePoolASG",
 			configYaml: minimalValidConfigYaml + `
 worker:
   nodePools:
   - name: pool1
-    iam:
-      role:
-        managedPolicies:
-         - arn: "arn:aws:iam::aws:policy/AdministratorAccess"
-         - arn: "arn:aws:iam::000000000000:policy/myManagedPolicy"
+    autoScalingGroup:
+      foo: 1
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultEtcdSettings,
-				hasDefaultExperimentalFeatures,
-				func(c *config.Config, t *testing.T) {
-					if len(c.NodePools[0].IAMConfig.Role.ManagedPolicies) < 2 {
-						t.Errorf("iam.role.managedPolicies: incorrect number of policies expected=2 actual=%d", len(c.NodePools[0].IAMConfig.Role.ManagedPolicies))
-					}
-					if c.NodePools[0].IAMConfig.Role.ManagedPolicies[0].Arn != "arn:aws:iam::aws:policy/AdministratorAccess" {
-						t.Errorf("iam.role.managedPolicies: expected=arn:aws:iam::aws:policy/AdministratorAccess actual=%s", c.NodePools[0].IAMConfig.Role.ManagedPolicies[0].Arn)
-					}
-					if c.NodePools[0].IAMConfig.Role.ManagedPolicies[1].Arn != "arn:aws:iam::000000000000:policy/myManagedPolicy" {
-						t.Errorf("iam.role.managedPolicies: expected=arn:aws:iam::000000000000:policy/myManagedPolicy actual=%s", c.NodePools[0].IAMConfig.Role.ManagedPolicies[1].Arn)
-					}
-				},
-			},
+			expectedErrorMessage: "unknown keys found in worker.nodePools[0].autoScalingGroup: foo",
 		},
 		{
-			context: "WithWorkerExistingInstanceProfile",
+			context: "WithUnknownKeyInWorkerNodePoolSpotFleet",
 			configYaml: minimalValidConfigYaml + `
 worker:
   nodePools:
   - name: pool1
-    iam:
-      instanceProfile:
-        arn: "arn:aws:iam::000000000000:instance-profile/myInstanceProfile"
+    spotFleet:
+      bar: 1
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultEtcdSettings,
-				hasDefaultExperimentalFeatures,
-				func(c *config.Config, t *testing.T) {
-					if c.NodePools[0].IAMConfig.InstanceProfile.Arn != "arn:aws:iam::000000000000:instance-profile/myInstanceProfile" {
-						t.Errorf("existingInstanceProfile: expected=arn:aws:iam::000000000000:instance-profile/myInstanceProfile actual=%s", c.NodePools[0].IAMConfig.InstanceProfile.Arn)
-					}
-				},
-			},
+			expectedErrorMessage: "unknown keys found in worker.nodePools[0].spotFleet: bar",
 		},
 		{
-			context: "WithWorkerAndControllerExistingInstanceProfile",
+			context: "WithUnknownKeyInWorkerNodePoolCA",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    autoscaling:
+      clusterAutoscaler:
+        baz: 1
+`,
+			expectedErrorMessage: "unknown keys found in worker.nodePools[0].autoscaling.clusterAutoscaler: baz",
+		},
+		{
+			context: "WithUnknownKeyInAddons",
+			configYaml: minimalValidConfigYaml + `
+addons:
+  blah: 5
+`,
+			expectedErrorMessage: "unknown keys found in addons: blah",
+		},
+		{
+			context: "WithUnknownKeyInReschedulerAddon",
 			configYaml: minimalValidConfigYaml + `
+addons:
+  rescheduler:
+    foo: yeah
+`,
+			expectedErrorMessage: "unknown keys found in addons.rescheduler: foo",
+		},
+		{
+			context: "WithUnknownKeyInClusterAutoscalerAddon",
+			configYaml: minimalValidConfigYaml + `
+addons:
+  clusterAutoscaler:
+    foo: yeah
+`,
+			expectedErrorMessage: "unknown keys found in addons.clusterAutoscaler: foo",
+		},
+		{
+			context: "WithTooLongControllerIAMRoleName",
+			configYaml: kubeAwsSettings.withClusterName("kubeaws-it-main").withRegion("ap-northeast-1").minimumValidClusterYaml() + `
 controller:
   iam:
-    instanceProfile:
-      arn: "arn:aws:iam::000000000000:instance-profile/myControllerInstanceProfile"
+    role:
+      name: foobarba-foobarba-foobarba-foobarba-foobarba-foobarba
+`,
+			expectedErrorMessage: "IAM role name(=kubeaws-it-main-ap-northeast-1-foobarba-foobarba-foobarba-foobarba-foobarba-foobarba) will be 84 characters long. It exceeds the AWS limit of 64 characters: clusterName(=kubeaws-it-main) + region name(=ap-northeast-1) + managed iam role name(=foobarba-foobarba-foobarba-foobarba-foobarba-foobarba) should be less than or equal to 33",
+		},
+		{
+			context: "WithTooLongWorkerIAMRoleName",
+			configYaml: kubeAwsSettings.withClusterName("kubeaws-it-main").withRegion("ap-northeast-1").minimumValidClusterYaml() + `
 worker:
   nodePools:
   - name: pool1
     iam:
-      instanceProfile:
-        arn: "arn:aws:iam::000000000000:instance-profile/myInstanceProfile"
+      role:
+        name: foobarba-foobarba-foobarba-foobarba-foobarba-foobarbazzz
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultEtcdSettings,
-				hasDefaultExperimentalFeatures,
-				func(c *config.Config, t *testing.T) {
-					if c.Controller.IAMConfig.InstanceProfile.Arn != "arn:aws:iam::000000000000:instance-profile/myControllerInstanceProfile" {
-						t.Errorf("existingInstanceProfile: expected=arn:aws:iam::000000000000:instance-profile/myControllerInstanceProfile actual=%s", c.Controller.IAMConfig.InstanceProfile.Arn)
-					}
-					if c.NodePools[0].IAMConfig.InstanceProfile.Arn != "arn:aws:iam::000000000000:instance-profile/myInstanceProfile" {
-						t.Errorf("existingInstanceProfile: expected=arn:aws:iam::000000000000:instance-profile/myInstanceProfile actual=%s", c.NodePools[0].IAMConfig.InstanceProfile.Arn)
-					}
-				},
-			},
+			expectedErrorMessage: "IAM role name(=kubeaws-it-main-ap-northeast-1-foobarba-foobarba-foobarba-foobarba-foobarba-foobarbazzz) will be 87 characters long. It exceeds the AWS limit of 64 characters: clusterName(=kubeaws-it-main) + region name(=ap-northeast-1) + managed iam role name(=foobarba-foobarba-foobarba-foobarba-foobarba-foobarbazzz) should be less than or equal to 33",
+		},
+		{
+			context: "WithInvalidEtcdInstanceProfileArn",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  iam:
+    instanceProfile:
+      arn: "badArn"
+`,
+			expectedErrorMessage: "invalid etcd settings: invalid instance profile, your instance profile must match (=arn:aws:iam::YOURACCOUNTID:instance-profile/INSTANCEPROFILENAME), provided (badArn)",
+		},
+		{
+			context: "WithInvalidEtcdManagedPolicyArn",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  iam:
+    role:
+      managedPolicies:
+      - arn: "badArn"
+`,
+			expectedErrorMessage: "invalid etcd settings: invalid managed policy arn, your managed policy must match this (=arn:aws:iam::(YOURACCOUNTID|aws):policy/POLICYNAME), provided this (badArn)",
 		},
 		{
-			context: "WithWorkerSecurityGroupIds",
+			context: "WithInvalidWorkerInstanceProfileArn",
 			configYaml: minimalValidConfigYaml + `
 worker:
   nodePools:
   - name: pool1
-    securityGroupIds:
-    - sg-12345678
-    - sg-abcdefab
-    - sg-23456789
-    - sg-bcdefabc
+    iam:
+      instanceProfile:
+        arn: "badArn"
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultEtcdSettings,
-				hasDefaultExperimentalFeatures,
-				func(c *config.Config, t *testing.T) {
-					p := c.NodePools[0]
-					expectedWorkerSecurityGroupIds := []string{
-						`sg-12345678`, `sg-abcdefab`, `sg-23456789`, `sg-bcdefabc`,
-					}
-					if !reflect.DeepEqual(p.SecurityGroupIds, expectedWorkerSecurityGroupIds) {
-						t.Errorf("WorkerSecurityGroupIds didn't match: expected=%v actual=%v", expectedWorkerSecurityGroupIds, p.SecurityGroupIds)
-					}
-
-					expectedWorkerSecurityGroupRefs := []string{
-						`"sg-12345678"`, `"sg-abcdefab"`, `"sg-23456789"`, `"sg-bcdefabc"`,
-						`{"Fn::ImportValue" : {"Fn::Sub" : "${NetworkStackName}-WorkerSecurityGroup"}}`,
-					}
-					if !reflect.DeepEqual(p.SecurityGroupRefs(), expectedWorkerSecurityGroupRefs) {
-						t.Errorf("SecurityGroupRefs didn't match: expected=%v actual=%v", expectedWorkerSecurityGroupRefs, p.SecurityGroupRefs())
-					}
-				},
-			},
+			expectedErrorMessage: "invalid instance profile, your instance profile must match (=arn:aws:iam::YOURACCOUNTID:instance-profile/INSTANCEPROFILENAME), provided (badArn)",
 		},
 		{
-			context: "WithWorkerAndLBSecurityGroupIds",
+			context: "WithInvalidWorkerManagedPolicyArn",
 			configYaml: minimalValidConfigYaml + `
 worker:
   nodePools:
   - name: pool1
-    securityGroupIds:
-    - sg-12345678
-    - sg-abcdefab
-    loadBalancer:
-      enabled: true
-      securityGroupIds:
-        - sg-23456789
-        - sg-bcdefabc
+    iam:
+      role:
+        managedPolicies:
+          - arn: "badArn"
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultEtcdSettings,
-				func(c *config.Config, t *testing.T) {
-					p := c.NodePools[0]
-					expectedWorkerSecurityGroupIds := []string{
-						`sg-12345678`, `sg-abcdefab`,
-					}
-					if !reflect.DeepEqual(p.SecurityGroupIds, expectedWorkerSecurityGroupIds) {
-						t.Errorf("WorkerSecurityGroupIds didn't match: expected=%v actual=%v", expectedWorkerSecurityGroupIds, p.SecurityGroupIds)
-					}
-
-					expectedLBSecurityGroupIds := []string{
-						`sg-23456789`, `sg-bcdefabc`,
-					}
-					if !reflect.DeepEqual(p.LoadBalancer.SecurityGroupIds, expectedLBSecurityGroupIds) {
-						t.Errorf("LBSecurityGroupIds didn't match: expected=%v actual=%v", expectedLBSecurityGroupIds, p.LoadBalancer.SecurityGroupIds)
-					}
-
-					expectedWorkerSecurityGroupRefs := []string{
-						`"sg-23456789"`, `"sg-bcdefabc"`, `"sg-12345678"`, `"sg-abcdefab"`,
-						`{"Fn::ImportValue" : {"Fn::Sub" : "${NetworkStackName}-WorkerSecurityGroup"}}`,
-					}
-					if !reflect.DeepEqual(p.SecurityGroupRefs(), expectedWorkerSecurityGroupRefs) {
-						t.Errorf("SecurityGroupRefs didn't match: expected=%v actual=%v", expectedWorkerSecurityGroupRefs, p.SecurityGroupRefs())
-					}
-				},
-			},
+			expectedErrorMessage: "invalid managed policy arn, your managed policy must match this (=arn:aws:iam::(YOURACCOUNTID|aws):policy/POLICYNAME), provided this (badArn)",
 		},
 		{
-			context: "WithWorkerAndALBSecurityGroupIds",
+			context: "WithGPUEnabledWorkerButEmptyVersion",
 			configYaml: minimalValidConfigYaml + `
 worker:
   nodePools:
   - name: pool1
-    securityGroupIds:
-    - sg-12345678
-    - sg-abcdefab
-    targetGroup:
-      enabled: true
-      securityGroupIds:
-        - sg-23456789
-        - sg-bcdefabc
+    instanceType: p2.xlarge
+    gpu:
+      nvidia:
+        enabled: true
+        version: ""
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultEtcdSettings,
-				func(c *config.Config, t *testing.T) {
-					p := c.NodePools[0]
-					expectedWorkerSecurityGroupIds := []string{
-						`sg-12345678`, `sg-abcdefab`,
-					}
-					if !reflect.DeepEqual(p.SecurityGroupIds, expectedWorkerSecurityGroupIds) {
-						t.Errorf("WorkerSecurityGroupIds didn't match: expected=%v actual=%v", expectedWorkerSecurityGroupIds, p.SecurityGroupIds)
-					}
-
-					expectedALBSecurityGroupIds := []string{
-						`sg-23456789`, `sg-bcdefabc`,
-					}
-					if !reflect.DeepEqual(p.TargetGroup.SecurityGroupIds, expectedALBSecurityGroupIds) {
-						t.Errorf("LBSecurityGroupIds didn't match: expected=%v actual=%v", expectedALBSecurityGroupIds, p.TargetGroup.SecurityGroupIds)
-					}
-
-					expectedWorkerSecurityGroupRefs := []string{
-						`"sg-23456789"`, `"sg-bcdefabc"`, `"sg-12345678"`, `"sg-abcdefab"`,
-						`{"Fn::ImportValue" : {"Fn::Sub" : "${NetworkStackName}-WorkerSecurityGroup"}}`,
-					}
-					if !reflect.DeepEqual(p.SecurityGroupRefs(), expectedWorkerSecurityGroupRefs) {
-						t.Errorf("SecurityGroupRefs didn't match: expected=%v actual=%v", expectedWorkerSecurityGroupRefs, p.SecurityGroupRefs())
-					}
-				},
-			},
+			expectedErrorMessage: `gpu.nvidia.version must not be empty when gpu.nvidia is enabled.`,
 		},
 		{
-			context: "WithDedicatedInstanceTenancy",
+			context: "WithAPIServerScaleProfileWithoutWatchCache",
 			configYaml: minimalValidConfigYaml + `
-workerTenancy: dedicated
 controller:
-  tenancy: dedicated
+  apiServer:
+    scaleProfile: medium
+    defaultWatchCacheSize: 0
+`,
+			expectedErrorMessage: "`controller.apiServer.defaultWatchCacheSize` must not be 0 with the scale profile \"medium\": lists and watches from every node would hit etcd directly",
+		},
+		{
+			context: "WithKubeProxyConntrackNegativeMin",
+			configYaml: minimalValidConfigYaml + `
+kubeProxy:
+  conntrack:
+    min: -1
+`,
+			expectedErrorMessage: "`kubeProxy.conntrack.min` must be a positive integer, but was -1",
+		},
+		{
+			context: "WithStackDependingOnItself",
+			configYaml: minimalValidConfigYaml + `
+cloudformation:
+  stackDependencies:
+  - stack: etcd
+    dependsOn:
+    - etcd
+`,
+			expectedErrorMessage: "invalid `cloudformation.stackDependencies[0]`: the stack \"etcd\" can not depend on itself",
+		},
+		{
+			context: "WithEtcdMaxRequestBytesTooLarge",
+			configYaml: minimalValidConfigYaml + `
 etcd:
-  tenancy: dedicated
+  maxRequestBytes: 16777216
 `,
-			assertConfig: []ConfigTester{
-				func(c *config.Config, t *testing.T) {
-					if c.Etcd.Tenancy != "dedicated" {
-						t.Errorf("Etcd.Tenancy didn't match: expected=dedicated actual=%s", c.Etcd.Tenancy)
-					}
-					if c.WorkerTenancy != "dedicated" {
-						t.Errorf("WorkerTenancy didn't match: expected=dedicated actual=%s", c.WorkerTenancy)
-					}
-					if c.Controller.Tenancy != "dedicated" {
-						t.Errorf("Controller.Tenancy didn't match: expected=dedicated actual=%s", c.Controller.Tenancy)
-					}
-				},
-			},
+			expectedErrorMessage: "`etcd.maxRequestBytes` must be between 1 and 10485760, but was 16777216",
 		},
 		{
-			context: "WithControllerNodeLabels",
+			context: "WithEtcdGRPCKeepaliveTimeoutLongerThanInterval",
 			configYaml: minimalValidConfigYaml + `
-controller:
-  nodeLabels:
-    kube-aws.coreos.com/role: controller
+etcd:
+  grpc:
+    keepaliveInterval: 10s
+    keepaliveTimeout: 20s
 `,
-			assertConfig: []ConfigTester{
-				hasDefaultExperimentalFeatures,
-				func(c *config.Config, t *testing.T) {
-					expected := api.NodeLabels{"kube-aws.coreos.com/role": "controller"}
-					actual := c.NodeLabels()
-					if !reflect.DeepEqual(expected, actual) {
-						t.Errorf("unexpected controller node labels: expected=%v, actual=%v", expected, actual)
-					}
-				},
-			},
+			expectedErrorMessage: "`etcd.grpc.keepaliveTimeout` 20s must be shorter than `etcd.grpc.keepaliveInterval` 10s",
 		},
 		{
-			context: "WithSSHAccessAllowedSourceCIDRsSpecified",
+			context: "WithEtcdGRPCMaxConcurrentStreamsForOldEtcd",
 			configYaml: minimalValidConfigYaml + `
-sshAccessAllowedSourceCIDRs:
-- 1.2.3.255/32
+etcd:
+  grpc:
+    maxConcurrentStreams: 1000
 `,
-			assertConfig: []ConfigTester{
-				func(c *config.Config, t *testing.T) {
-					l := len(c.SSHAccessAllowedSourceCIDRs)
-					if l != 1 {
-						t.Errorf("unexpected size of sshAccessAllowedSouceCIDRs: %d", l)
-						t.FailNow()
-					}
-					actual := c.SSHAccessAllowedSourceCIDRs[0].String()
-					expected := "1.2.3.255/32"
-					if actual != expected {
-						t.Errorf("unexpected cidr in sshAccessAllowedSourecCIDRs[0]. expected = %s, actual = %s", expected, actual)
-					}
-				},
-			},
+			expectedErrorMessage: "`etcd.grpc.maxConcurrentStreams` requires etcd 3.4.20 or greater, but the etcd version was 3.2.13",
 		},
 		{
-			context:    "WithSSHAccessAllowedSourceCIDRsOmitted",
-			configYaml: minimalValidConfigYaml,
-			assertConfig: []ConfigTester{
-				func(c *config.Config, t *testing.T) {
-					l := len(c.SSHAccessAllowedSourceCIDRs)
-					if l != 1 {
-						t.Errorf("unexpected size of sshAccessAllowedSouceCIDRs: %d", l)
-						t.FailNow()
-					}
-					actual := c.SSHAccessAllowedSourceCIDRs[0].String()
-					expected := "0.0.0.0/0"
-					if actual != expected {
-						t.Errorf("unexpected cidr in sshAccessAllowedSourecCIDRs[0]. expected = %s, actual = %s", expected, actual)
-					}
-				},
-			},
+			context: "WithDedicatedControllerSubnetsSharedWithNodePool",
+			configYaml: mainClusterYaml + `
+subnets:
+- name: controller1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.1.0/24"
+  private: true
+- name: controller2
+  availabilityZone: us-west-1b
+  instanceCIDR: "10.0.2.0/24"
+  private: true
+- name: public1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.3.0/24"
+- name: public2
+  availabilityZone: us-west-1b
+  instanceCIDR: "10.0.4.0/24"
+controller:
+  count: 2
+  dedicatedSubnets: true
+  subnets:
+  - name: controller1
+  - name: controller2
+etcd:
+  subnets:
+  - name: public1
+  - name: public2
+worker:
+  nodePools:
+  - name: pool1
+    subnets:
+    - name: controller2
+`,
+			expectedErrorMessage: "`controller.subnets` must not share the subnet \"controller2\" with the node pool \"pool1\" when `controller.dedicatedSubnets` is true",
+		},
+		{
+			context: "WithDedicatedControllerSubnetsSharingRouteTableWithWorkers",
+			configYaml: mainClusterYaml + `
+vpc:
+  id: vpc-1a2b3c4d
+subnets:
+- name: controller1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.1.0/24"
+  routeTable:
+    id: rtb-shared
+- name: controller2
+  availabilityZone: us-west-1b
+  instanceCIDR: "10.0.2.0/24"
+  routeTable:
+    id: rtb-controller2
+- name: public1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.3.0/24"
+  routeTable:
+    id: rtb-shared
+- name: public2
+  availabilityZone: us-west-1b
+  instanceCIDR: "10.0.4.0/24"
+  routeTable:
+    id: rtb-public2
+controller:
+  count: 2
+  dedicatedSubnets: true
+  subnets:
+  - name: controller1
+  - name: controller2
+etcd:
+  subnets:
+  - name: public2
+worker:
+  nodePools:
+  - name: pool1
+    subnets:
+    - name: public1
+`,
+			expectedErrorMessage: "`controller.subnets` must not share the route table of the subnet \"controller1\" with the subnet \"public1\" of the node pool \"pool1\" when `controller.dedicatedSubnets` is true",
+		},
+		{
+			context: "WithDedicatedControllerSubnetsInSingleAZ",
+			configYaml: mainClusterYaml + `
+subnets:
+- name: controller1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.1.0/24"
+  private: true
+- name: controller2
+  availabilityZone: us-west-1b
+  instanceCIDR: "10.0.2.0/24"
+  private: true
+- name: public1
+  availabilityZone: us-west-1a
+  instanceCIDR: "10.0.3.0/24"
+- name: public2
+  availabilityZone: us-west-1b
+  instanceCIDR: "10.0.4.0/24"
+controller:
+  count: 2
+  dedicatedSubnets: true
+  subnets:
+  - name: controller1
+etcd:
+  subnets:
+  - name: public1
+  - name: public2
+`,
+			expectedErrorMessage: "`controller.subnets` must span at least 2 availability zones for 2 controller nodes, but spanned only us-west-1a",
 		},
 		{
-			context: "WithSSHAccessAllowedSourceCIDRsEmptied",
+			context: "WithDedicatedControllerSubnetsUnspecified",
 			configYaml: minimalValidConfigYaml + `
-sshAccessAllowedSourceCIDRs:
+controller:
+  dedicatedSubnets: true
 `,
-			assertConfig: []ConfigTester{
-				func(c *config.Config, t *testing.T) {
-					l := len(c.SSHAccessAllowedSourceCIDRs)
-					if l != 0 {
-						t.Errorf("unexpected size of sshAccessAllowedSouceCIDRs: %d", l)
-						t.FailNow()
-					}
-				},
-			},
+			expectedErrorMessage: "`controller.subnets` must be specified when `controller.dedicatedSubnets` is true",
 		},
 		{
-			context: "WithWorkerWithoutGPUSettings",
+			context: "WithLambdaNodeDrainerAndNodeDrainer",
 			configYaml: minimalValidConfigYaml + `
+experimental:
+  nodeDrainer:
+    enabled: true
 worker:
   nodePools:
   - name: pool1
+    lambdaNodeDrainer:
+      enabled: true
 `,
-			assertConfig: []ConfigTester{
-				func(c *config.Config, t *testing.T) {
-					enabled := c.NodePools[0].Gpu.Nvidia.Enabled
-					if enabled {
-						t.Errorf("unexpected enabled of gpu.nvidia: %v.  its default value should be false", enabled)
-						t.FailNow()
-					}
-				},
-			},
+			expectedErrorMessage: "`lambdaNodeDrainer` and `experimental.nodeDrainer` can't be enabled together",
 		},
 		{
-			context: "WithGPUEnabledWorker",
+			context: "WithServiceAccountIssuerNotMatchingBucket",
 			configYaml: minimalValidConfigYaml + `
-worker:
-  nodePools:
-  - name: pool1
-    instanceType: p2.xlarge
-    gpu:
-      nvidia:
-        enabled: true
-        version: "123.45"
+kubernetesVersion: v1.20.2
+controller:
+  apiServer:
+    serviceAccountIssuer:
+      url: https://another-bucket.s3.us-west-1.amazonaws.com/it
+      discovery:
+        s3Bucket: my-oidc-bucket
 `,
-			assertConfig: []ConfigTester{
-				func(c *config.Config, t *testing.T) {
-					enabled := c.NodePools[0].Gpu.Nvidia.Enabled
-					version := c.NodePools[0].Gpu.Nvidia.Version
-					if !enabled {
-						t.Errorf("unexpected enabled value of gpu.nvidia: %v.", enabled)
-						t.FailNow()
-					}
-					if version != "123.45" {
-						t.Errorf("unexpected version value of gpu.nvidia: %v.", version)
-						t.FailNow()
-					}
-				},
-			},
+			expectedErrorMessage: "must be the domain name of the bucket \"my-oidc-bucket\"",
 		},
 		{
-			context: "WithGPUDisabledWorker",
+			context: "WithServiceAccountIssuerForOldKubernetes",
 			configYaml: minimalValidConfigYaml + `
-worker:
-  nodePools:
-  - name: pool1
-    gpu:
-      nvidia:
-        enabled: false
-        version: "123.45"
+controller:
+  apiServer:
+    serviceAccountIssuer:
+      url: https://my-oidc-bucket.s3.us-west-1.amazonaws.com/it
 `,
-			assertConfig: []ConfigTester{
-				func(c *config.Config, t *testing.T) {
-					enabled := c.NodePools[0].Gpu.Nvidia.Enabled
-					version := c.NodePools[0].Gpu.Nvidia.Version
-					if enabled {
-						t.Errorf("unexpected enabled value of gpu.nvidia: %v.", enabled)
-						t.FailNow()
-					}
-					if version != "123.45" {
-						t.Errorf("unexpected version value of gpu.nvidia: %v.", version)
-						t.FailNow()
-					}
-				},
-			},
+			expectedErrorMessage: "`controller.apiServer.serviceAccountIssuer` requires kubernetesVersion 1.20 or greater",
 		},
-	}
-
-	for _, validCase := range validCases {
-		t.Run(validCase.context, func(t *testing.T) {
-			configBytes := validCase.configYaml
-			// TODO Allow including plugins in test data?
-			plugins := []*api.Plugin{}
-			providedConfig, err := config.ConfigFromBytes([]byte(configBytes), plugins)
-			if err != nil {
-				t.Errorf("failed to parse config %s: %+v", configBytes, err)
-				t.FailNow()
-			}
-
-			t.Run("AssertConfig", func(t *testing.T) {
-				for _, assertion := range validCase.assertConfig {
-					assertion(providedConfig, t)
-				}
-			})
-
-			helper.WithDummyCredentials(func(dummyAssetsDir string) {
-				var stackTemplateOptions = root.NewOptions(false, false)
-				stackTemplateOptions.AssetsDir = dummyAssetsDir
-				stackTemplateOptions.ControllerTmplFile = "../../builtin/files/userdata/cloud-config-controller"
-				stackTemplateOptions.WorkerTmplFile = "../../builtin/files/userdata/cloud-config-worker"
-				stackTemplateOptions.EtcdTmplFile = "../../builtin/files/userdata/cloud-config-etcd"
-				stackTemplateOptions.RootStackTemplateTmplFile = "../../builtin/files/stack-templates/root.json.tmpl"
-				stackTemplateOptions.NodePoolStackTemplateTmplFile = "../../builtin/files/stack-templates/node-pool.json.tmpl"
-				stackTemplateOptions.ControlPlaneStackTemplateTmplFile = "../../builtin/files/stack-templates/control-plane.json.tmpl"
-				stackTemplateOptions.NetworkStackTemplateTmplFile = "../../builtin/files/stack-templates/network.json.tmpl"
-				stackTemplateOptions.EtcdStackTemplateTmplFile = "../../builtin/files/stack-templates/etcd.json.tmpl"
-
-				cl, err := root.CompileClusterFromConfig(providedConfig, stackTemplateOptions, false)
-				if err != nil {
-					t.Errorf("failed to create cluster driver : %v", err)
-					t.FailNow()
-				}
-
-				cl.Context = &model.Context{
-					ProvidedEncryptService:  helper.DummyEncryptService{},
-					ProvidedCFInterrogator:  helper.DummyCFInterrogator{},
-					ProvidedEC2Interrogator: helper.DummyEC2Interrogator{},
-					StackTemplateGetter:     helper.DummyStackTemplateGetter{},
-				}
-
-				_, err = cl.EnsureAllAssetsGenerated()
-				if err != nil {
-					t.Errorf("%v", err)
-					t.FailNow()
-				}
-
-				t.Run("AssertCluster", func(t *testing.T) {
-					for _, assertion := range validCase.assertCluster {
-						assertion(cl, t)
-					}
-				})
-
-				t.Run("ValidateTemplates", func(t *testing.T) {
-					if err := cl.ValidateTemplates(); err != nil {
-						t.Errorf("failed to render stack template: %v", err)
-					}
-				})
-
-				if os.Getenv("KUBE_AWS_INTEGRATION_TEST") == "" {
-					t.Skipf("`export KUBE_AWS_INTEGRATION_TEST=1` is required to run integration tests. Skipping.")
-					t.SkipNow()
-				} else {
-					t.Run("ValidateStack", func(t *testing.T) {
-						if !s3URIExists {
-							t.Errorf("failed to obtain value for KUBE_AWS_S3_DIR_URI")
-							t.FailNow()
-						}
-
-						report, err := cl.ValidateStack()
-
-						if err != nil {
-							t.Errorf("failed to validate stack: %s %v", report, err)
-						}
-					})
-				}
-			})
-		})
-	}
-
-	parseErrorCases := []struct {
-		context              string
-		configYaml           string
-		expectedErrorMessage string
-	}{
 		{
-			context: "WithAPIEndpointLBAPIAccessAllowedSourceCIDRsEmptied",
-			configYaml: configYamlWithoutExernalDNSName + `
-apiEndpoints:
-- name: default
-  dnsName: k8s.example.com
-  loadBalancer:
-    apiAccessAllowedSourceCIDRs:
-    hostedZone:
-      id: a1b2c4
+			context: "WithInvalidInstanceScriptStorage",
+			configYaml: minimalValidConfigYaml + `
+instanceScript:
+  storage: ssm
 `,
-			expectedErrorMessage: `invalid cluster: invalid apiEndpoint "default" at index 0: invalid loadBalancer: either apiAccessAllowedSourceCIDRs or securityGroupIds must be present. Try not to explicitly empty apiAccessAllowedSourceCIDRs or set one or more securityGroupIDs`,
+			expectedErrorMessage: "invalid `instanceScript.storage` \"ssm\": it must be one of embedded, s3",
 		},
 		{
-			context: "WithAutoscalingEnabledButClusterAutoscalerIsDefault",
+			context: "WithNodePoolCustomResourceUsingCondition",
 			configYaml: minimalValidConfigYaml + `
 worker:
   nodePools:
   - name: pool1
-    autoscaling:
-      clusterAutoscaler:
-        enabled: true
+    customResources:
+      WorkersAlarmTopic: |
+        {"Type": "AWS::SNS::Topic", "Properties": {"TopicName": {"Fn::If": ["IsProduction", "prod", "dev"]}}}
 `,
-			expectedErrorMessage: "Autoscaling with cluster-autoscaler can't be enabled for node pools because " +
-				"you didn't enabled the cluster-autoscaler addon. Enable it by turning on `addons.clusterAutoscaler.enabled`",
+			expectedErrorMessage: "invalid custom resource \"WorkersAlarmTopic\": unsupported intrinsic function \"Fn::If\"",
 		},
 		{
-			context: "WithAutoscalingEnabledButClusterAutoscalerIsNot",
+			context: "WithContainerdImageGCThresholdOver100Percent",
 			configYaml: minimalValidConfigYaml + `
-addons:
-  clusterAutoscaler:
-    enabled: false
 worker:
   nodePools:
   - name: pool1
-    autoscaling:
-      clusterAutoscaler:
-        enabled: true
+    containerRuntime: containerd
+    containerd:
+      imageGc:
+        pruneThresholdPercent: 120
 `,
-			expectedErrorMessage: "Autoscaling with cluster-autoscaler can't be enabled for node pools because " +
-				"you didn't enabled the cluster-autoscaler addon. Enable it by turning on `addons.clusterAutoscaler.enabled`",
+			expectedErrorMessage: "`containerd.imageGc.pruneThresholdPercent` must be a percentage between 0 and 100, but was 120",
 		},
 		{
-			context: "WithClusterAutoscalerEnabledForControlPlane",
+			context: "WithInvalidAPIServerClientCA",
 			configYaml: minimalValidConfigYaml + `
 controller:
-  autoscaling:
-    clusterAutoscaler:
-      enabled: true
+  apiServer:
+    clientCAs:
+    - |
+      -----BEGIN CERTIFICATE-----
+      bm90IGEgY2VydGlmaWNhdGUK
+      -----END CERTIFICATE-----
 `,
-			expectedErrorMessage: "cluster-autoscaler can't be enabled for a control plane because " +
-				"allowing so for a group of controller nodes spreading over 2 or more availability zones " +
-				"results in unreliability while scaling nodes out.",
-		},
-		{
-			// See https://github.com/kubernetes-incubator/kube-aws/issues/365
-			context:              "WithClusterNameContainsDots",
-			configYaml:           kubeAwsSettings.withClusterName("my.cluster").minimumValidClusterYaml(),
-			expectedErrorMessage: "clusterName(=my.cluster) is malformed. It must consist only of alphanumeric characters, colons, or hyphens",
+			expectedErrorMessage: "invalid `controller.apiServer.clientCAs[0]`: failed to parse certificate #1",
 		},
 		{
-			context: "WithControllerTaint",
+			context: "WithInvalidAPIServerEventTTL",
 			configYaml: minimalValidConfigYaml + `
 controller:
-  taints:
-  - key: foo
-    value: bar
-    effect: NoSchedule
+  apiServer:
+    eventTtl: -1h
 `,
-			expectedErrorMessage: "`controller.taints` must not be specified because tainting controller nodes breaks the cluster",
+			expectedErrorMessage: "`controller.apiServer.eventTtl` must be a positive duration like 1h, but was \"-1h\"",
 		},
 		{
-			context: "WithElasticFileSystemIdInSpecificNodePoolWithManagedSubnets",
-			configYaml: mainClusterYaml + `
-subnets:
-- name: managed1
-  availabilityZone: us-west-1a
-  instanceCIDR: 10.0.1.0/24
-worker:
-  nodePools:
-  - name: pool1
-    subnets:
-    - name: managed1
-    elasticFileSystemId: efs-12345
-  - name: pool2
+			context: "WithInvalidCloudFormationTransformName",
+			configYaml: minimalValidConfigYaml + `
+cloudformation:
+  transforms:
+  - name: My Macro
 `,
-			expectedErrorMessage: "invalid node pool at index 0: elasticFileSystemId cannot be specified for a node pool in managed subnet(s), but was: efs-12345",
+			expectedErrorMessage: "invalid `cloudformation.transforms[0].name` \"My Macro\"",
 		},
 		{
-			context: "WithEtcdAutomatedDisasterRecoveryRequiresAutomatedSnapshot",
+			context: "WithAuditWebhookWithoutConfig",
 			configYaml: minimalValidConfigYaml + `
-etcd:
-  version: 3
-  snapshot:
-    automated: false
-  disasterRecovery:
-    automated: true
+experimental:
+  auditWebhook:
+    enabled: true
 `,
-			expectedErrorMessage: "`etcd.disasterRecovery.automated` is set to true but `etcd.snapshot.automated` is not - automated disaster recovery requires snapshot to be also automated",
+			expectedErrorMessage: "`experimental.auditWebhook.configBase64` must be a base64-encoded kubeconfig file",
 		},
 		{
-			context: "WithEtcdAutomatedDisasterRecoveryDoesntSupportEtcd2",
+			context: "WithServiceAccountIssuerAPIAudiencesNotIncludingIssuer",
 			configYaml: minimalValidConfigYaml + `
-etcd:
-  version: 2
-  snapshot:
-    automated: true
-  disasterRecovery:
-    automated: false
+kubernetesVersion: v1.20.2
+controller:
+  apiServer:
+    serviceAccountIssuer:
+      url: https://oidc.example.com/it
+      apiAudiences:
+      - vault.example.com
 `,
-			expectedErrorMessage: "`etcd.snapshot.automated` is set to true for enabling automated snapshot. However the feature is available only for etcd version 3",
+			expectedErrorMessage: "`controller.apiServer.serviceAccountIssuer.apiAudiences` must include the issuer url \"https://oidc.example.com/it\"",
 		},
 		{
-			context: "WithEtcdAutomatedSnapshotDoesntSupportEtcd2",
+			context: "WithBlueGreenNodePoolWithoutWaitSignal",
 			configYaml: minimalValidConfigYaml + `
-etcd:
-  version: 2
-  snapshot:
-    automated: false
-  disasterRecovery:
-    automated: true
+worker:
+  nodePools:
+  - name: pool1
+    deploymentStrategy: blueGreen
+    waitSignal:
+      enabled: false
 `,
-			expectedErrorMessage: "`etcd.disasterRecovery.automated` is set to true for enabling automated disaster recovery. However the feature is available only for etcd version 3",
+			expectedErrorMessage: "`deploymentStrategy: blueGreen` requires `waitSignal.enabled` to be true",
 		},
 		{
-			context: "WithInvalidNodeDrainTimeout",
+			context: "WithBlueGreenNodePoolWithTooLongSoakTime",
 			configYaml: minimalValidConfigYaml + `
-experimental:
-  nodeDrainer:
-    enabled: true
-    drainTimeout: 100
+worker:
+  nodePools:
+  - name: pool1
+    deploymentStrategy: blueGreen
+    blueGreen:
+      soakTime: 12h
 `,
-			expectedErrorMessage: "Drain timeout must be an integer between 1 and 60, but was 100",
+			expectedErrorMessage: "must not exceed 12 hours",
 		},
 		{
-			context: "WithInvalidTaint",
+			context: "WithAmiIdAndAmiSsmParameter",
 			configYaml: minimalValidConfigYaml + `
 worker:
   nodePools:
   - name: pool1
-    taints:
-    - key: foo
-      value: bar
-      effect: UnknownEffect
+    amiId: ami-12345678
+    amiSsmParameter: /aws/service/eks/optimized-ami/1.14/amazon-linux-2/recommended/image_id
 `,
-			expectedErrorMessage: "invalid taint effect: UnknownEffect",
+			expectedErrorMessage: "`amiId` and `amiSsmParameter` can't be specified at once",
 		},
 		{
-			context: "WithLegacyControllerSettingKeys",
+			context: "WithAPIServerResourceRequestExceedingLimit",
 			configYaml: minimalValidConfigYaml + `
-vpc:
-  id: vpc-1a2b3c4d
-internetGateway:
-  id: igw-1a2b3c4d
-routeTableId: rtb-1a2b3c4d
-controllerCount: 2
-controllerCreateTimeout: PT10M
-controllerInstanceType: t2.large
-controllerRootVolumeSize: 101
-controllerRootVolumeType: io1
-controllerRootVolumeIOPS: 102
-controllerTenancy: dedicated
+controller:
+  apiServer:
+    resources:
+      requests:
+        memory: 2Gi
+      limits:
+        memory: 1Gi
 `,
-			expectedErrorMessage: "unknown keys found: controllerCount, controllerCreateTimeout, controllerInstanceType, controllerRootVolumeIOPS, controllerRootVolumeSize, controllerRootVolumeType, controllerTenancy",
+			expectedErrorMessage: "`controller.apiServer.resources.requests.memory` must not exceed `controller.apiServer.resources.limits.memory`",
 		},
 		{
-			context: "WithLegacyEtcdSettingKeys",
+			context: "WithCustomNetworkPluginWithoutManifests",
 			configYaml: minimalValidConfigYaml + `
-vpc:
-  id: vpc-1a2b3c4d
-internetGateway:
-  id: igw-1a2b3c4d
-routeTableId: rtb-1a2b3c4d
-etcdCount: 2
-etcdTenancy: dedicated
-etcdInstanceType: t2.large
-etcdRootVolumeSize: 101
-etcdRootVolumeType: io1
-etcdRootVolumeIOPS: 102
-etcdDataVolumeSize: 103
-etcdDataVolumeType: io1
-etcdDataVolumeIOPS: 104
-etcdDataVolumeEncrypted: true
+kubernetes:
+  networking:
+    selfHosting:
+      type: custom
 `,
-			expectedErrorMessage: "unknown keys found: etcdCount, etcdDataVolumeEncrypted, etcdDataVolumeIOPS, etcdDataVolumeSize, etcdDataVolumeType, etcdInstanceType, etcdRootVolumeIOPS, etcdRootVolumeSize, etcdRootVolumeType, etcdTenancy",
+			expectedErrorMessage: "`kubernetes.networking.selfHosting.type: custom` requires at least one of `kubernetes.networking.selfHosting.custom.manifests`",
 		},
 		{
-			context: "WithAwsNodeLabelEnabledForTooLongClusterNameAndPoolName",
+			context: "WithCustomNetworkPluginWithAmazonVPC",
 			configYaml: minimalValidConfigYaml + `
-# clusterName + nodePools[].name should be less than or equal to 25 characters or the launch configuration name
-# "mykubeawsclustername-mynestedstackname-1N2C4K3LLBEDZ-WorkersLC-BC2S9P3JG2QD" exceeds the limit of 63 characters
-# See https://kubernetes.io/docs/user-guide/labels/#syntax-and-character-set
-clusterName: my-cluster1 # 11 characters
-worker:
-  nodePools:
-  - name: workernodepool1 # 15 characters
-    awsNodeLabels:
+kubernetes:
+  networking:
+    amazonVPC:
       enabled: true
+    selfHosting:
+      type: custom
+      custom:
+        manifests:
+        - name: cilium
+          url: https://example.com/cilium.yaml
 `,
-			expectedErrorMessage: "awsNodeLabels can't be enabled for node pool because the total number of characters in clusterName(=\"my-cluster1\") + node pool's name(=\"workernodepool1\") exceeds the limit of 25",
+			expectedErrorMessage: "`kubernetes.networking.selfHosting.type: custom` can't be used with `kubernetes.networking.amazonVPC.enabled`",
 		},
 		{
-			context: "WithAwsNodeLabelEnabledForTooLongClusterName",
+			context: "WithNegativeKubeletEvictionMaxPodGracePeriod",
 			configYaml: minimalValidConfigYaml + `
-# clusterName should be less than or equal to 21 characters or the launch configuration name
-# "mykubeawsclustername-mynestedstackname-1N2C4K3LLBEDZ-ControllersLC-BC2S9P3JG2QD" exceeds the limit of 63 characters
-# See https://kubernetes.io/docs/user-guide/labels/#syntax-and-character-set
-clusterName: mycluster # 9
-experimental:
-  awsNodeLabels:
-     enabled: true
+worker:
+  nodePools:
+  - name: pool1
+    kubelet:
+      evictionMaxPodGracePeriod: -1
 `,
-			expectedErrorMessage: "awsNodeLabels can't be enabled for controllers because the total number of characters in clusterName(=\"mycluster\") exceeds the limit of 8",
+			expectedErrorMessage: "`kubelet.evictionMaxPodGracePeriod` must be a non-negative integer, but was -1",
 		},
 		{
-			context: "WithMultiAPIEndpointsInvalidLB",
-			configYaml: kubeAwsSettings.mainClusterYamlWithoutAPIEndpoint() + `
-vpc:
-  id: vpc-1a2b3c4d
-internetGateway:
-  id: igw-1a2b3c4d
-
-subnets:
-- name: publicSubnet1
-  availabilityZone: us-west-1a
-  instanceCIDR: "10.0.1.0/24"
-
+			context: "WithInterPoolCommunicationToMissingNodePool",
+			configYaml: minimalValidConfigYaml + `
 worker:
-  apiEndpointName: unversionedPublic
-
-apiEndpoints:
-- name: unversionedPublic
-  dnsName: api.example.com
-  loadBalancer:
-    id: elb-internet-facing
-    private: true
-    subnets:
-    - name: publicSubnet1
-    hostedZone:
-      id: hostedzone-public
+  interPoolCommunication:
+  - from: pool1
+    to: pool2
+    ports:
+    - port: 8080
+  nodePools:
+  - name: pool1
 `,
-			expectedErrorMessage: "invalid apiEndpoint \"unversionedPublic\" at index 0: invalid loadBalancer: type, private, subnets, hostedZone must be omitted when id is specified to reuse an existing ELB",
+			expectedErrorMessage: "`worker.interPoolCommunication[0]` references the node pool \"pool2\" which doesn't exist",
 		},
 		{
-			context: "WithMultiAPIEndpointsInvalidWorkerAPIEndpointName",
-			configYaml: kubeAwsSettings.mainClusterYamlWithoutAPIEndpoint() + `
-vpc:
-  id: vpc-1a2b3c4d
-internetGateway:
-  id: igw-1a2b3c4d
-
-subnets:
-- name: publicSubnet1
-  availabilityZone: us-west-1a
-  instanceCIDR: "10.0.1.0/24"
-
-worker:
-  # no api endpoint named like that exists!
-  apiEndpointName: unknownEndpoint
-
-adminAPIEndpointName: versionedPublic
-
-apiEndpoints:
-- name: unversionedPublic
-  dnsName: api.example.com
-  loadBalancer:
-    subnets:
-    - name: publicSubnet1
-    hostedZone:
-      id: hostedzone-public
-- name: versionedPublic
-  dnsName: apiv1.example.com
-  loadBalancer:
-    subnets:
-    - name: publicSubnet1
-    hostedZone:
-      id: hostedzone-public
+			context: "WithAPIServerAnonymousHealthEndpointsAndOidc",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.32.1
+controller:
+  apiServer:
+    anonymousAuth: healthEndpoints
+experimental:
+  oidc:
+    enabled: true
+    issuerUrl: "https://accounts.google.com"
+    clientId: "kubernetes"
 `,
-			expectedErrorMessage: "invalid value for worker.apiEndpointName: no API endpoint named \"unknownEndpoint\" found",
+			expectedErrorMessage: "`controller.apiServer.anonymousAuth: healthEndpoints` can't be used with `experimental.oidc`, as the apiserver rejects the authentication config file along with the oidc flags",
 		},
 		{
-			context: "WithMultiAPIEndpointsInvalidWorkerNodePoolAPIEndpointName",
-			configYaml: kubeAwsSettings.mainClusterYamlWithoutAPIEndpoint() + `
-vpc:
-  id: vpc-1a2b3c4d
-internetGateway:
-  id: igw-1a2b3c4d
-
-subnets:
-- name: publicSubnet1
-  availabilityZone: us-west-1a
-  instanceCIDR: "10.0.1.0/24"
-
+			context: "WithOverlappingNodePoolPodCIDRRanges",
+			configYaml: minimalValidConfigYaml + `
 worker:
-  # this one is ok but...
-  apiEndpointName: versionedPublic
   nodePools:
   - name: pool1
-    # this one is ng; no api endpoint named this exists!
-    apiEndpointName: unknownEndpoint
-
-adminAPIEndpointName: versionedPublic
-
-apiEndpoints:
-- name: unversionedPublic
-  dnsName: api.example.com
-  loadBalancer:
-    subnets:
-    - name: publicSubnet1
-    hostedZone:
-      id: hostedzone-public
-- name: versionedPublic
-  dnsName: apiv1.example.com
-  loadBalancer:
-    subnets:
-    - name: publicSubnet1
-    hostedZone:
-      id: hostedzone-public
+    podCIDRRange: 10.2.128.0/18
+  - name: pool2
+    podCIDRRange: 10.2.160.0/20
 `,
-			expectedErrorMessage: "invalid node pool at index 0: failed to find an API endpoint named \"unknownEndpoint\": no API endpoint named \"unknownEndpoint\" defined under the `apiEndpoints[]`",
+			expectedErrorMessage: "podCIDRRange (10.2.160.0/20) of node pool \"pool2\" overlaps with the one (10.2.128.0/18) of node pool \"pool1\"",
 		},
 		{
-			context: "WithMultiAPIEndpointsMissingDNSName",
-			configYaml: kubeAwsSettings.mainClusterYamlWithoutAPIEndpoint() + `
-vpc:
-  id: vpc-1a2b3c4d
-internetGateway:
-  id: igw-1a2b3c4d
-
-subnets:
-- name: publicSubnet1
-  availabilityZone: us-west-1a
-  instanceCIDR: "10.0.1.0/24"
-
-apiEndpoints:
-- name: unversionedPublic
-  dnsName:
-  loadBalancer:
-    hostedZone:
-      id: hostedzone-public
+			context: "WithInvalidAWSAPIRetryMaxBackoff",
+			configYaml: minimalValidConfigYaml + `
+awsApiRetry:
+  mode: adaptive
+  maxBackoff: 10m
 `,
-			expectedErrorMessage: "invalid apiEndpoint \"unversionedPublic\" at index 0: dnsName must be set",
+			expectedErrorMessage: "invalid `awsApiRetry.maxBackoff` \"10m\": it must be between 1s and 5m0s",
 		},
 		{
-			context: "WithMultiAPIEndpointsMissingGlobalAPIEndpointName",
-			configYaml: kubeAwsSettings.mainClusterYamlWithoutAPIEndpoint() + `
-vpc:
-  id: vpc-1a2b3c4d
-internetGateway:
-  id: igw-1a2b3c4d
-
-subnets:
-- name: publicSubnet1
-  availabilityZone: us-west-1a
-  instanceCIDR: "10.0.1.0/24"
-
+			context: "WithInvalidInstanceScriptCompression",
+			configYaml: minimalValidConfigYaml + `
+instanceScript:
+  compression: xz
+`,
+			expectedErrorMessage: "invalid `instanceScript.compression` \"xz\": it must be one of none, gzip",
+		},
+		{
+			context: "WithCapacityOptimizedPrioritizedSpotAllocationWithoutInstanceTypes",
+			configYaml: minimalValidConfigYaml + `
 worker:
   nodePools:
   - name: pool1
-    # this one is ng; no api endpoint named this exists!
-    apiEndpointName: unknownEndpoint
-  - name: pool1
-    # this one is ng; missing apiEndpointName
-
-adminAPIEndpointName: versionedPublic
-
+    autoScalingGroup:
+      minSize: 1
+      maxSize: 3
+      mixedInstances:
+        enabled: true
+        spotAllocationStrategy: capacity-optimized-prioritized
+`,
+			expectedErrorMessage: "`mixedInstances.instanceTypes` must list 2 or more instance types in the order of priority for the 'capacity-optimized-prioritized' spot allocation strategy",
+		},
+		{
+			context: "WithAPIEndpointIdleTimeoutShorterThanAPIServerRequestTimeout",
+			configYaml: configYamlWithoutExernalDNSName + `
 apiEndpoints:
-- name: unversionedPublic
-  dnsName: api.example.com
+- name: default
+  dnsName: k8s.example.com
   loadBalancer:
-    subnets:
-    - name: publicSubnet1
     hostedZone:
-      id: hostedzone-public
-- name: versionedPublic
-  dnsName: apiv1.example.com
+      id: a1b2c4
+    idleTimeout: 60
+controller:
+  apiServer:
+    requestTimeout: 2m
+`,
+			expectedErrorMessage: "the idle timeout 1m0s of the load balancer of the API endpoint \"default\" must be equal to or longer than `controller.apiServer.requestTimeout` 2m0s",
+		},
+		{
+			context: "WithAPIEndpointIdleTimeoutOutOfRange",
+			configYaml: configYamlWithoutExernalDNSName + `
+apiEndpoints:
+- name: default
+  dnsName: k8s.example.com
   loadBalancer:
-    subnets:
-    - name: publicSubnet1
+    type: network
     hostedZone:
-      id: hostedzone-public
+      id: a1b2c4
+    idleTimeout: 30
 `,
-			expectedErrorMessage: "worker.apiEndpointName must not be empty when there're 2 or more API endpoints under the key `apiEndpoints` and one of worker.nodePools[] are missing apiEndpointName",
+			expectedErrorMessage: "idleTimeout (30) must be in range 60-6000 seconds for the network load balancer",
 		},
 		{
-			context: "WithMultiAPIEndpointsRecordSetImpliedBySubnetsMissingHostedZoneID",
-			configYaml: kubeAwsSettings.mainClusterYamlWithoutAPIEndpoint() + `
-vpc:
-  id: vpc-1a2b3c4d
-internetGateway:
-  id: igw-1a2b3c4d
-
-subnets:
-- name: publicSubnet1
-  availabilityZone: us-west-1a
-  instanceCIDR: "10.0.1.0/24"
-
+			context: "WithNodePoolRollbackOnFailureWithoutWaitSignal",
+			configYaml: minimalValidConfigYaml + `
 worker:
-  apiEndpointName: unversionedPublic
-
-apiEndpoints:
-- name: unversionedPublic
-  dnsName: api.example.com
-  loadBalancer:
-    # an internet-facing(which is the default) lb in the public subnet is going to be created with a corresponding record set
-    # however no hosted zone for the record set is provided!
-    subnets:
-    - name: publicSubnet1
-    # missing hosted zone id here!
+  nodePools:
+  - name: pool1
+    waitSignal:
+      enabled: false
+    update:
+      rollbackOnFailure: true
 `,
-			expectedErrorMessage: "invalid apiEndpoint \"unversionedPublic\" at index 0: invalid loadBalancer: missing hostedZone.id",
+			expectedErrorMessage: "`update.rollbackOnFailure` requires `waitSignal.enabled` to be true",
 		},
 		{
-			context: "WithMultiAPIEndpointsRecordSetImpliedByExplicitPublicMissingHostedZoneID",
-			configYaml: kubeAwsSettings.mainClusterYamlWithoutAPIEndpoint() + `
-vpc:
-  id: vpc-1a2b3c4d
-internetGateway:
-  id: igw-1a2b3c4d
-
-subnets:
-- name: publicSubnet1
-  availabilityZone: us-west-1a
-  instanceCIDR: "10.0.1.0/24"
-
+			context: "WithNodePoolRollbackOnFailureWithHealthCheckTimeoutLongerThanCreateTimeout",
+			configYaml: minimalValidConfigYaml + `
 worker:
-  apiEndpointName: unversionedPublic
-
-apiEndpoints:
-- name: unversionedPublic
-  dnsName: api.example.com
-  loadBalancer:
-    # an internet-facing lb is going to be created with a corresponding record set
-    # however no hosted zone for the record set is provided!
-    private: false
-    # missing hosted zone id here!
+  nodePools:
+  - name: pool1
+    update:
+      rollbackOnFailure: true
+      healthCheckTimeout: 15m
 `,
-			expectedErrorMessage: "invalid apiEndpoint \"unversionedPublic\" at index 0: invalid loadBalancer: missing hostedZone.id",
+			expectedErrorMessage: "`update.healthCheckTimeout` 15m0s must be shorter than `createTimeout` \"PT15M\"",
 		},
 		{
-			context: "WithMultiAPIEndpointsRecordSetImpliedByExplicitPrivateMissingHostedZoneID",
-			configYaml: kubeAwsSettings.mainClusterYamlWithoutAPIEndpoint() + `
-vpc:
-  id: vpc-1a2b3c4d
-internetGateway:
-  id: igw-1a2b3c4d
-
-subnets:
-- name: publicSubnet1
-  availabilityZone: us-west-1a
-  instanceCIDR: "10.0.1.0/24"
-- name: privateSubnet1
-  availabilityZone: us-west-1a
-  instanceCIDR: "10.0.2.0/24"
-
+			context: "WithRuntimeClassWithoutSandboxRuntime",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.27.3
+runtimeClasses:
+- name: kata
+  runtime: kata
 worker:
-  apiEndpointName: unversionedPublic
-
-apiEndpoints:
-- name: unversionedPublic
-  dnsName: api.example.com
-  loadBalancer:
-    # an internal lb is going to be created with a corresponding record set
-    # however no hosted zone for the record set is provided!
-    private: true
-    # missing hosted zone id here!
+  nodePools:
+  - name: sandboxed
+    containerRuntime: containerd
+    sandboxRuntimes:
+    - name: gvisor
 `,
-			expectedErrorMessage: "invalid apiEndpoint \"unversionedPublic\" at index 0: invalid loadBalancer: missing hostedZone.id",
+			expectedErrorMessage: "the runtime \"kata\" of `runtimeClasses[0]` \"kata\" must be installed on one or more node pools via `worker.nodePools[].sandboxRuntimes`",
 		},
 		{
-			context: "WithNetworkTopologyAllExistingPrivateSubnetsRejectingExistingIGW",
-			configYaml: mainClusterYaml + `
-vpc:
-  id: vpc-1a2b3c4d
-internetGateway:
-  id: igw-1a2b3c4d
-subnets:
-- name: private1
-  availabilityZone: us-west-1a
-  id: subnet-1
-  private: true
-controller:
-  loadBalancer:
-    private: true
-etcd:
-  subnets:
-  - name: private1
+			context: "WithSandboxRuntimeOnDocker",
+			configYaml: minimalValidConfigYaml + `
 worker:
   nodePools:
-  - name: pool1
-    subnets:
-    - name: private1
+  - name: sandboxed
+    sandboxRuntimes:
+    - name: gvisor
 `,
-			expectedErrorMessage: `internet gateway id can't be specified when all the subnets are existing private subnets`,
+			expectedErrorMessage: "invalid node pool \"sandboxed\": `sandboxRuntimes` requires the `containerd` container runtime, but the container runtime was \"docker\"",
 		},
 		{
-			context: "WithNetworkTopologyAllExistingPublicSubnetsRejectingExistingIGW",
-			configYaml: mainClusterYaml + `
-vpc:
-  id: vpc-1a2b3c4d
-internetGateway:
-  id: igw-1a2b3c4d
-subnets:
-- name: public1
-  availabilityZone: us-west-1a
-  id: subnet-1
+			context: "WithAssetPrefixNotEndingWithClusterName",
+			configYaml: minimalValidConfigYaml + `
+assetPrefix: team-a/shared
+`,
+			expectedErrorMessage: "it must end with the cluster name",
+		},
+		{
+			context: "WithAssetPrefixWithTrailingSlash",
+			configYaml: minimalValidConfigYaml + `
+assetPrefix: team-a/` + kubeAwsSettings.clusterName + `/
+`,
+			expectedErrorMessage: "invalid `assetPrefix`",
+		},
+		{
+			context: "WithBoundServiceAccountTokensWithoutServiceAccountIssuer",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.21.1
 controller:
-  loadBalancer:
-    private: false
-etcd:
-  subnets:
-  - name: public1
+  serviceAccount:
+    boundTokens: true
+`,
+			expectedErrorMessage: "`controller.serviceAccount.boundTokens` requires `controller.apiServer.serviceAccountIssuer.url`",
+		},
+		{
+			context: "WithBoundServiceAccountTokensDisabledByFeatureGate",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.20.2
+controller:
+  featureGates:
+    BoundServiceAccountTokenVolume: "false"
+  apiServer:
+    serviceAccountIssuer:
+      url: https://oidc.example.com/it
+  serviceAccount:
+    boundTokens: true
+`,
+			expectedErrorMessage: "conflicts with the feature gate `BoundServiceAccountTokenVolume=false`",
+		},
+		{
+			context: "WithAssetReplicationWithoutRegion",
+			configYaml: minimalValidConfigYaml + `
+assetReplication:
+  s3URI: s3://my-dr-bucket/mydir
+`,
+			expectedErrorMessage: "`assetReplication.region` must be set with `assetReplication.s3URI`",
+		},
+		{
+			context: "WithAssetReplicationWithSingleRegionKMSKey",
+			configYaml: minimalValidConfigYaml + `
+assetReplication:
+  s3URI: s3://my-dr-bucket/mydir
+  region: us-east-1
+`,
+			expectedErrorMessage: "`kmsKeyArn` must be the ARN of a multi-region key",
+		},
+		{
+			context: "WithAssetReplicationToTheSameRegion",
+			configYaml: minimalValidConfigYaml + `
+assetReplication:
+  s3URI: s3://my-dr-bucket/mydir
+  region: ` + kubeAwsSettings.region + `
+`,
+			expectedErrorMessage: "`assetReplication.region` must differ from the region of the cluster",
+		},
+		{
+			context: "WithNodePoolWarmPoolWithMaxPreparedCapacityGreaterThanMaxSize",
+			configYaml: minimalValidConfigYaml + `
 worker:
   nodePools:
   - name: pool1
-    subnets:
-    - name: public1
+    autoScalingGroup:
+      minSize: 1
+      maxSize: 3
+    autoscaling:
+      warmPool:
+        enabled: true
+        maxPreparedCapacity: 4
 `,
-			expectedErrorMessage: `internet gateway id can't be specified when all the public subnets have existing route tables associated. kube-aws doesn't try to modify an exisinting route table to include a route to the internet gateway`,
+			expectedErrorMessage: "`autoscaling.warmPool.maxPreparedCapacity` 4 must be equal to or less than the max size 3 of the node pool",
 		},
 		{
-			context: "WithNetworkTopologyAllManagedPublicSubnetsWithExistingRouteTableRejectingExistingIGW",
-			configYaml: mainClusterYaml + `
-vpc:
-  id: vpc-1a2b3c4d
-internetGateway:
-  id: igw-1a2b3c4d
-subnets:
-- name: public1
-  availabilityZone: us-west-1a
-  instanceCIDR: 10.0.1.0/24
-  routeTable:
-    id: subnet-1
-controller:
-  loadBalancer:
-    private: false
-etcd:
-  subnets:
-  - name: public1
+			context: "WithNodePoolWarmPoolWithInvalidPoolState",
+			configYaml: minimalValidConfigYaml + `
 worker:
   nodePools:
   - name: pool1
-    subnets:
-    - name: public1
+    autoscaling:
+      warmPool:
+        enabled: true
+        poolState: Terminated
 `,
-			expectedErrorMessage: `internet gateway id can't be specified when all the public subnets have existing route tables associated. kube-aws doesn't try to modify an exisinting route table to include a route to the internet gateway`,
+			expectedErrorMessage: "invalid `autoscaling.warmPool.poolState` \"Terminated\": it must be one of Stopped, Running, Hibernated",
 		},
 		{
-			context: "WithNetworkTopologyAllManagedPublicSubnetsMissingExistingIGW",
-			configYaml: mainClusterYaml + `
-vpc:
-  id: vpc-1a2b3c4d
-#misses this
-#internetGateway:
-#  id: igw-1a2b3c4d
-subnets:
-- name: public1
-  availabilityZone: us-west-1a
-  instanceCIDR: "10.0.1.0/24"
+			context: "WithControllerWarmPool",
+			configYaml: minimalValidConfigYaml + `
 controller:
-  loadBalancer:
-    private: false
-etcd:
-  subnets:
-  - name: public1
+  autoscaling:
+    warmPool:
+      enabled: true
+`,
+			expectedErrorMessage: "`controller.autoscaling.warmPool` is not supported",
+		},
+		{
+			context: "WithAutoSubnetsInTooSmallVPC",
+			configYaml: kubeAwsSettings.mainClusterYaml() + `
+vpcCIDR: 10.1.0.0/22
+subnets:
+  auto:
+    availabilityZones:
+    - us-west-1a
+    - us-west-1b
+    - us-west-1c
+    prefixLength: 24
+`,
+			expectedErrorMessage: "vpcCIDR 10.1.0.0/22 is too small for `subnets.auto`: it can hold only 4 subnets of /24, but 6 subnets are required",
+		},
+		{
+			context: "WithAutoSubnetsWithTopLevelAvailabilityZone",
+			configYaml: minimalValidConfigYaml + `
+subnets:
+  auto:
+    availabilityZones:
+    - us-west-1a
+    prefixLength: 24
+`,
+			expectedErrorMessage: "The top-level availabilityZone(us-west-1c) must be empty when subnets are specified",
+		},
+		{
+			context: "WithAutoSubnetsInNodePool",
+			configYaml: minimalValidConfigYaml + `
 worker:
   nodePools:
   - name: pool1
     subnets:
-    - name: public1
+      auto:
+        availabilityZones:
+        - us-west-1c
+        prefixLength: 24
+`,
+			expectedErrorMessage: "`subnets` of a node pool doesn't support `auto`",
+		},
+		{
+			context: "WithControllerKMSPluginWithoutKeyARN",
+			configYaml: minimalValidConfigYaml + `
+kubernetes:
+  encryptionAtRest:
+    enabled: true
+controller:
+  encryptionAtRest:
+    kms:
+      image:
+        repo: example.com/aws-encryption-provider
+        tag: v0.1.0
+`,
+			expectedErrorMessage: "`controller.encryptionAtRest.kms.keyArn` must be set",
+		},
+		{
+			context: "WithControllerKMSPluginWithKeyARNAndCreateKey",
+			configYaml: minimalValidConfigYaml + `
+kubernetes:
+  encryptionAtRest:
+    enabled: true
+controller:
+  encryptionAtRest:
+    kms:
+      keyArn: arn:aws:kms:us-west-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab
+      createKey: true
+      image:
+        repo: example.com/aws-encryption-provider
+        tag: v0.1.0
+`,
+			expectedErrorMessage: "`controller.encryptionAtRest.kms.keyArn` and `controller.encryptionAtRest.kms.createKey` are mutually exclusive",
+		},
+		{
+			context: "WithControllerKMSPluginWithoutImage",
+			configYaml: minimalValidConfigYaml + `
+kubernetes:
+  encryptionAtRest:
+    enabled: true
+controller:
+  encryptionAtRest:
+    kms:
+      keyArn: arn:aws:kms:us-west-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab
+`,
+			expectedErrorMessage: "`controller.encryptionAtRest.kms.image.repo` and `controller.encryptionAtRest.kms.image.tag` must be set",
+		},
+		{
+			context: "WithControllerKMSPluginWithoutEncryptionAtRest",
+			configYaml: minimalValidConfigYaml + `
+controller:
+  encryptionAtRest:
+    kms:
+      keyArn: arn:aws:kms:us-west-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab
+      image:
+        repo: example.com/aws-encryption-provider
+        tag: v0.1.0
+`,
+			expectedErrorMessage: "`controller.encryptionAtRest.kms` requires `kubernetes.encryptionAtRest.enabled` to be true",
+		},
+		{
+			context: "WithControllerKMSPluginWithKeyInAnotherRegion",
+			configYaml: minimalValidConfigYaml + `
+kubernetes:
+  encryptionAtRest:
+    enabled: true
+controller:
+  encryptionAtRest:
+    kms:
+      keyArn: arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab
+      image:
+        repo: example.com/aws-encryption-provider
+        tag: v0.1.0
+`,
+			expectedErrorMessage: "`controller.encryptionAtRest.kms.keyArn` must reference the region us-west-1 of the cluster",
+		},
+		{
+			context: "WithAPIServerCertificateReloadOnKubernetes116",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.16.8
+controller:
+  apiServer:
+    certificateReload:
+      enabled: true
+`,
+			expectedErrorMessage: "`controller.apiServer.certificateReload` requires kubernetesVersion 1.17 or greater, but was v1.16.8",
+		},
+		{
+			context: "WithAPIServerCertificateReloadWithTooShortInterval",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.20.2
+controller:
+  apiServer:
+    certificateReload:
+      enabled: true
+      interval: 30s
 `,
-			expectedErrorMessage: `internet gateway id can't be omitted when there're one or more managed public subnets in an existing VPC`,
+			expectedErrorMessage: "`controller.apiServer.certificateReload.interval` must be 1m or longer",
 		},
 		{
-			context: "WithNetworkTopologyAllPreconfiguredPrivateDeprecatedAndThenRemoved",
-			configYaml: mainClusterYaml + `
-vpc:
-  id: vpc-1a2b3c4d
-# This, in combination with mapPublicIPs=false, had been implying that the route table contains a route to a preconfigured NAT gateway
-# See https://github.com/kubernetes-incubator/kube-aws/pull/284#issuecomment-276008202
-routeTableId: rtb-1a2b3c4d
-# This had been implied that all the subnets created by kube-aws should be private
-mapPublicIPs: false
-subnets:
-- availabilityZone: us-west-1a
-  instanceCIDR: "10.0.1.0/24"
-  # implies
-  # private: true
-  # routeTable
-  #   id: rtb-1a2b3c4d
-- availabilityZone: us-west-1b
-  instanceCIDR: "10.0.2.0/24"
-  # implies
-  # private: true
-  # routeTable
-  #   id: rtb-1a2b3c4d
+			context: "WithAmazonVPCCNINetworkPluginTypeWithTypha",
+			configYaml: minimalValidConfigYaml + `
+kubernetes:
+  networking:
+    selfHosting:
+      type: amazon-vpc-cni
+      typha: true
 `,
-			expectedErrorMessage: "internet gateway id can't be omitted when there're one or more managed public subnets in an existing VPC",
+			expectedErrorMessage: "you can only enable typha when deploying type 'canal'",
 		},
 		{
-			context: "WithNetworkTopologyAllPreconfiguredPublicDeprecatedAndThenRemoved",
-			configYaml: mainClusterYaml + `
-vpc:
-  id: vpc-1a2b3c4d
-# This, in combination with mapPublicIPs=true, had been implying that the route table contains a route to a preconfigured internet gateway
-# See https://github.com/kubernetes-incubator/kube-aws/pull/284#issuecomment-276008202
-routeTableId: rtb-1a2b3c4d
-# This had been implied that all the subnets created by kube-aws should be public
-mapPublicIPs: true
-# internetGateway.id should be omitted as we assume that the route table specified by routeTableId already contain a route to one
-#internetGateway:
-#  id:
-subnets:
-- availabilityZone: us-west-1a
-  instanceCIDR: "10.0.1.0/24"
-  # #implies
-  # private: false
-  # routeTable
-  #   id: rtb-1a2b3c4d
-- availabilityZone: us-west-1b
-  instanceCIDR: "10.0.2.0/24"
-  # #implies
-  # private: false
-  # routeTable
-  #   id: rtb-1a2b3c4d
+			context: "WithUnknownNetworkPluginType",
+			configYaml: minimalValidConfigYaml + `
+kubernetes:
+  networking:
+    selfHosting:
+      type: weave
 `,
-			expectedErrorMessage: "internet gateway id can't be omitted when there're one or more managed public subnets in an existing VPC",
+			expectedErrorMessage: "style must be either 'canal', 'flannel', 'custom' or 'amazon-vpc-cni'",
 		},
 		{
-			context: "WithVpcIdAndVPCCIDRSpecified",
+			context: "WithEtcdSnapshotRetentionWithoutAutomatedSnapshot",
 			configYaml: minimalValidConfigYaml + `
-vpc:
-  id: vpc-1a2b3c4d
-internetGateway:
-  id: igw-1a2b3c4d
-# vpcCIDR (10.1.0.0/16) does not contain instanceCIDR (10.0.1.0/24)
-vpcCIDR: "10.1.0.0/16"
+etcd:
+  snapshot:
+    retentionInDays: 7
 `,
+			expectedErrorMessage: "`etcd.snapshot.interval` and `etcd.snapshot.retentionInDays` require `etcd.snapshot.automated` to be true",
 		},
 		{
-			context: "WithRouteTableIdSpecified",
+			context: "WithEtcdSnapshotTooShortInterval",
 			configYaml: minimalValidConfigYaml + `
-# vpc.id must be specified if routeTableId is specified
-routeTableId: rtb-1a2b3c4d
+etcd:
+  snapshot:
+    automated: true
+    interval: 10s
 `,
+			expectedErrorMessage: "`etcd.snapshot.interval` must be 30s or longer",
 		},
 		{
-			context: "WithWorkerSecurityGroupIds",
+			context: "WithMixedInstancesAndSpotFleet",
 			configYaml: minimalValidConfigYaml + `
 worker:
   nodePools:
   - name: pool1
-    securityGroupIds:
-    - sg-12345678
-    - sg-abcdefab
-    - sg-23456789
-    - sg-bcdefabc
-    - sg-34567890
+    autoScalingGroup:
+      mixedInstances:
+        enabled: true
+        instanceTypes:
+        - m5.large
+        - m5a.large
+    spotFleet:
+      targetCapacity: 10
 `,
-			expectedErrorMessage: "number of user provided security groups must be less than or equal to 4 but was 5",
+			expectedErrorMessage: "`autoScalingGroup.mixedInstances` and `spotFleet` are mutually exclusive",
 		},
 		{
-			context: "WithWorkerAndLBSecurityGroupIds",
+			context: "WithMixedInstancesAndSpotPrice",
 			configYaml: minimalValidConfigYaml + `
 worker:
   nodePools:
   - name: pool1
-    securityGroupIds:
-    - sg-12345678
-    - sg-abcdefab
-    - sg-23456789
-    loadBalancer:
+    spotPrice: "0.05"
+    autoScalingGroup:
+      mixedInstances:
+        enabled: true
+        instanceTypes:
+        - m5.large
+        - m5a.large
+`,
+			expectedErrorMessage: "`spotPrice` can not be specified with `autoScalingGroup.mixedInstances`",
+		},
+		{
+			context: "WithMixedInstancesSpotAndDedicatedTenancy",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    tenancy: dedicated
+    autoScalingGroup:
+      mixedInstances:
+        enabled: true
+        onDemandPercentageAboveBaseCapacity: 50
+        instanceTypes:
+        - m5.large
+        - m5a.large
+`,
+			expectedErrorMessage: "selected worker tenancy (dedicated) is incompatible with spot instances launched by `autoScalingGroup.mixedInstances`",
+		},
+		{
+			context: "WithNodeDrainerForWorkerNodePoolWithDrainSettings",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    nodeDrainer:
       enabled: true
-      securityGroupIds:
-        - sg-bcdefabc
-        - sg-34567890
+      drain:
+        timeout: 30s
 `,
-			expectedErrorMessage: "number of user provided security groups must be less than or equal to 4 but was 5",
+			expectedErrorMessage: "`worker.nodePools[].nodeDrainer.drain` can't be customized for node pool \"pool1\"",
 		},
 		{
-			context: "WithWorkerAndALBSecurityGroupIds",
+			context: "WithLambdaNodeDrainerAndNodeDrainerForWorkerNodePool",
 			configYaml: minimalValidConfigYaml + `
 worker:
   nodePools:
   - name: pool1
-    securityGroupIds:
-    - sg-12345678
-    - sg-abcdefab
-    - sg-23456789
-    targetGroup:
+    nodeDrainer:
+      enabled: true
+    lambdaNodeDrainer:
       enabled: true
-      securityGroupIds:
-        - sg-bcdefabc
-        - sg-34567890
 `,
-			expectedErrorMessage: "number of user provided security groups must be less than or equal to 4 but was 5",
+			expectedErrorMessage: "`lambdaNodeDrainer` and `nodeDrainer` can't be enabled together for a node pool",
 		},
 		{
-			context: "WithUnknownKeyInRoot",
+			context: "WithNodeDrainerForWorkerNodePoolWithTooLongDrainTimeout",
 			configYaml: minimalValidConfigYaml + `
-foo: bar
+worker:
+  nodePools:
+  - name: pool1
+    nodeDrainer:
+      enabled: true
+      drainTimeout: 61
 `,
-			expectedErrorMessage: "unknown keys found: foo",
+			expectedErrorMessage: "Drain timeout must be an integer between 1 and 60, but was 61",
 		},
 		{
-			context: "WithUnknownKeyInController",
+			context: "WithIRSAWithoutS3Bucket",
 			configYaml: minimalValidConfigYaml + `
-controller:
-  foo: 1
+kubernetesVersion: v1.20.2
+kubernetes:
+  oidc:
+    irsa:
+      enabled: true
 `,
-			expectedErrorMessage: "unknown keys found in controller: foo",
+			expectedErrorMessage: "`kubernetes.oidc.irsa.s3Bucket` must be specified",
 		},
 		{
-			context: "WithUnknownKeyInControllerASG",
+			context: "WithIRSAAndServiceAccountIssuerURL",
 			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.20.2
+kubernetes:
+  oidc:
+    irsa:
+      enabled: true
+      s3Bucket: my-oidc-bucket
 controller:
-  autoScalingGroup:
-    foo: 1
+  apiServer:
+    serviceAccountIssuer:
+      url: https://oidc.example.com
 `,
-			expectedErrorMessage: "unknown keys found in controller.autoScalingGroup: foo",
+			expectedErrorMessage: "so they can't be specified together",
 		},
 		{
-			context: "WithUnknownKeyInEtcd",
+			context: "WithIRSAForOldKubernetes",
 			configYaml: minimalValidConfigYaml + `
-etcd:
-  foo: 1
+kubernetesVersion: v1.19.5
+kubernetes:
+  oidc:
+    irsa:
+      enabled: true
+      s3Bucket: my-oidc-bucket
 `,
-			expectedErrorMessage: "unknown keys found in etcd: foo",
+			expectedErrorMessage: "requires kubernetesVersion 1.20 or greater",
 		},
 		{
-			context: "WithUnknownKeyInWorkerNodePool",
+			context: "WithBottlerocketNodePoolWithoutAWSIAMAuthenticator",
 			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.20.2
 worker:
   nodePools:
   - name: pool1
-    clusterAutoscaler:
-      enabled: true
+    os: bottlerocket
 `,
-			expectedErrorMessage: "unknown keys found in worker.nodePools[0]: clusterAutoscaler",
+			expectedErrorMessage: "invalid node pool \"pool1\": `os: bottlerocket` requires `kubeAwsPlugins.awsIamAuthenticator.enabled` to be true",
 		},
 		{
-			context: "WithUnknownKeyInWorkerNodePoolASG",
+			context: "WithBottlerocketNodePoolWithCustomFiles",
 			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.20.2
+kubeAwsPlugins:
+  awsIamAuthenticator:
+    enabled: true
 worker:
   nodePools:
   - name: pool1
-    autoScalingGroup:
-      foo: 1
+    os: bottlerocket
+    customFiles:
+    - path: /etc/foo
+      content: bar
 `,
-			expectedErrorMessage: "unknown keys found in worker.nodePools[0].autoScalingGroup: foo",
+			expectedErrorMessage: "`customFiles` isn't supported with `os: bottlerocket`",
 		},
 		{
-			context: "WithUnknownKeyInWorkerNodePoolSpotFleet",
+			context: "WithBottlerocketSettingsForFlatcarNodePool",
 			configYaml: minimalValidConfigYaml + `
 worker:
   nodePools:
   - name: pool1
-    spotFleet:
-      bar: 1
+    bottlerocket:
+      adminContainer:
+        enabled: true
 `,
-			expectedErrorMessage: "unknown keys found in worker.nodePools[0].spotFleet: bar",
+			expectedErrorMessage: "`bottlerocket` can only be specified with `os: bottlerocket`",
 		},
 		{
-			context: "WithUnknownKeyInWorkerNodePoolCA",
+			context: "WithNodePoolRollingUpdatePauseTimeShorterThanHealthCheckTimeout",
 			configYaml: minimalValidConfigYaml + `
 worker:
   nodePools:
   - name: pool1
-    autoscaling:
-      clusterAutoscaler:
-        baz: 1
+    update:
+      rollbackOnFailure: true
+    rollingUpdate:
+      pauseTime: PT5M
 `,
-			expectedErrorMessage: "unknown keys found in worker.nodePools[0].autoscaling.clusterAutoscaler: baz",
+			expectedErrorMessage: "`update.healthCheckTimeout` must be shorter than `rollingUpdate.pauseTime` \"PT5M\"",
 		},
 		{
-			context: "WithUnknownKeyInAddons",
+			context: "WithNodePoolRollingUpdateSuspendingLaunch",
 			configYaml: minimalValidConfigYaml + `
-addons:
-  blah: 5
+worker:
+  nodePools:
+  - name: pool1
+    rollingUpdate:
+      suspendProcesses:
+      - Launch
 `,
-			expectedErrorMessage: "unknown keys found in addons: blah",
+			expectedErrorMessage: "invalid process \"Launch\" in `rollingUpdate.suspendProcesses`",
 		},
 		{
-			context: "WithUnknownKeyInReschedulerAddon",
+			context: "WithUnknownClusterAutoscalerExpander",
 			configYaml: minimalValidConfigYaml + `
 addons:
-  rescheduler:
-    foo: yeah
+  clusterAutoscaler:
+    enabled: true
+    expander: price
 `,
-			expectedErrorMessage: "unknown keys found in addons.rescheduler: foo",
+			expectedErrorMessage: "invalid `addons.clusterAutoscaler.expander` \"price\": it must be one of random, most-pods, least-waste, priority",
 		},
 		{
-			context: "WithUnknownKeyInClusterAutoscalerAddon",
-			configYaml: minimalValidConfigYaml + `
-addons:
-  clusterAutoscaler:
-    foo: yeah
-`,
-			expectedErrorMessage: "unknown keys found in addons.clusterAutoscaler: foo",
+			context:              "WithGovCloudRegionWithoutAMI",
+			configYaml:           kubeAwsSettings.withRegion("us-gov-west-1").minimumValidClusterYaml(),
+			expectedErrorMessage: "`amiId` or `amiSsmParameter` must be specified for the region us-gov-west-1 in the aws-us-gov partition",
 		},
 		{
-			context: "WithTooLongControllerIAMRoleName",
-			configYaml: kubeAwsSettings.withClusterName("kubeaws-it-main").withRegion("ap-northeast-1").minimumValidClusterYaml() + `
-controller:
-  iam:
-    role:
-      name: foobarba-foobarba-foobarba-foobarba-foobarba-foobarba
+			context: "WithChinaRegionWithDefaultAmazonVPCCNIImage",
+			configYaml: kubeAwsSettings.withRegion("cn-north-1").minimumValidClusterYaml() + `
+amiId: ami-12345678
+kubernetes:
+  networking:
+    amazonVPC:
+      enabled: true
 `,
-			expectedErrorMessage: "IAM role name(=kubeaws-it-main-ap-northeast-1-foobarba-foobarba-foobarba-foobarba-foobarba-foobarba) will be 84 characters long. It exceeds the AWS limit of 64 characters: clusterName(=kubeaws-it-main) + region name(=ap-northeast-1) + managed iam role name(=foobarba-foobarba-foobarba-foobarba-foobarba-foobarba) should be less than or equal to 33",
+			expectedErrorMessage: "`kubernetes.networking.selfHosting.amazonVPCCNIImage.repo` must be specified for the region cn-north-1, as nodes in the aws-cn partition can't pull the image from 602401143452.dkr.ecr.us-west-2.amazonaws.com",
 		},
 		{
-			context: "WithTooLongWorkerIAMRoleName",
-			configYaml: kubeAwsSettings.withClusterName("kubeaws-it-main").withRegion("ap-northeast-1").minimumValidClusterYaml() + `
+			context: "WithWindowsNodePoolWithCanal",
+			configYaml: minimalValidConfigYaml + `
+kubernetesVersion: v1.16.8
 worker:
   nodePools:
   - name: pool1
-    iam:
-      role:
-        name: foobarba-foobarba-foobarba-foobarba-foobarba-foobarbazzz
-`,
-			expectedErrorMessage: "IAM role name(=kubeaws-it-main-ap-northeast-1-foobarba-foobarba-foobarba-foobarba-foobarba-foobarbazzz) will be 87 characters long. It exceeds the AWS limit of 64 characters: clusterName(=kubeaws-it-main) + region name(=ap-northeast-1) + managed iam role name(=foobarba-foobarba-foobarba-foobarba-foobarba-foobarbazzz) should be less than or equal to 33",
-		},
-		{
-			context: "WithInvalidEtcdInstanceProfileArn",
-			configYaml: minimalValidConfigYaml + `
-etcd:
-  iam:
-    instanceProfile:
-      arn: "badArn"
+    platform: windows
 `,
-			expectedErrorMessage: "invalid etcd settings: invalid instance profile, your instance profile must match (=arn:aws:iam::YOURACCOUNTID:instance-profile/INSTANCEPROFILENAME), provided (badArn)",
+			expectedErrorMessage: "`platform: windows` requires `kubernetes.networking.selfHosting.type` to be \"flannel\"",
 		},
 		{
-			context: "WithInvalidEtcdManagedPolicyArn",
+			context: "WithWindowsNodePoolWithOldKubernetes",
 			configYaml: minimalValidConfigYaml + `
-etcd:
-  iam:
-    role:
-      managedPolicies:
-      - arn: "badArn"
+kubernetesVersion: v1.13.5
+kubernetes:
+  networking:
+    selfHosting:
+      type: flannel
+worker:
+  nodePools:
+  - name: pool1
+    platform: windows
 `,
-			expectedErrorMessage: "invalid etcd settings: invalid managed policy arn, your managed policy must match this (=arn:aws:iam::(YOURACCOUNTID|aws):policy/POLICYNAME), provided this (badArn)",
+			expectedErrorMessage: "`platform: windows` requires Kubernetes 1.14 or greater",
 		},
 		{
-			context: "WithInvalidWorkerInstanceProfileArn",
+			context: "WithWindowsNodePoolWithOS",
 			configYaml: minimalValidConfigYaml + `
+kubernetes:
+  networking:
+    selfHosting:
+      type: flannel
 worker:
   nodePools:
   - name: pool1
-    iam:
-      instanceProfile:
-        arn: "badArn"
+    platform: windows
+    os: bottlerocket
 `,
-			expectedErrorMessage: "invalid instance profile, your instance profile must match (=arn:aws:iam::YOURACCOUNTID:instance-profile/INSTANCEPROFILENAME), provided (badArn)",
+			expectedErrorMessage: "`os` can't be specified with `platform: windows`",
 		},
 		{
-			context: "WithInvalidWorkerManagedPolicyArn",
+			context: "WithNodePoolPodCIDRRangeForNodePoolLabeledLikeControllers",
 			configYaml: minimalValidConfigYaml + `
 worker:
   nodePools:
-  - name: pool1
-    iam:
-      role:
-        managedPolicies:
-          - arn: "badArn"
+  - name: master
+    podCIDRRange: 10.2.128.0/18
 `,
-			expectedErrorMessage: "invalid managed policy arn, your managed policy must match this (=arn:aws:iam::(YOURACCOUNTID|aws):policy/POLICYNAME), provided this (badArn)",
+			expectedErrorMessage: "node pool \"master\" can't have podCIDRRange, as its nodes are labeled node-role.kubernetes.io/master like controller nodes",
 		},
 		{
-			context: "WithGPUEnabledWorkerButEmptyVersion",
+			context: "WithGPUDevicePluginWithoutNvidia",
 			configYaml: minimalValidConfigYaml + `
 worker:
   nodePools:
@@ -4602,10 +11042,10 @@ worker:
     instanceType: p2.xlarge
     gpu:
       nvidia:
-        enabled: true
-        version: ""
+        devicePlugin:
+          enabled: true
 `,
-			expectedErrorMessage: `gpu.nvidia.version must not be empty when gpu.nvidia is enabled.`,
+			expectedErrorMessage: "`gpu.nvidia.devicePlugin.enabled` requires `gpu.nvidia.enabled` to be true",
 		},
 		{
 			context: "WithGPUDisabledWorkerButIntallationSupportEnabled",
@@ -4641,3 +11081,62 @@ worker:
 		})
 	}
 }
+
+func TestNodePoolCustomResourceConflictingWithStackTemplate(t *testing.T) {
+	kubeAwsSettings := newKubeAwsSettingsFromEnv(t)
+	configYaml := kubeAwsSettings.minimumValidClusterYamlWithAZ("c") + `
+worker:
+  nodePools:
+  - name: pool1
+    customResources:
+      Workers:
+        Type: AWS::SNS::Topic
+`
+	providedConfig, err := config.ConfigFromBytes([]byte(configYaml), []*api.Plugin{})
+	if err != nil {
+		t.Fatalf("failed to parse config %s: %+v", configYaml, err)
+	}
+
+	helper.WithDummyCredentials(func(dummyAssetsDir string) {
+		var stackTemplateOptions = root.NewOptions(false, false)
+		stackTemplateOptions.AssetsDir = dummyAssetsDir
+		stackTemplateOptions.ControllerTmplFile = "../../builtin/files/userdata/cloud-config-controller"
+		stackTemplateOptions.WorkerTmplFile = "../../builtin/files/userdata/cloud-config-worker"
+		stackTemplateOptions.EtcdTmplFile = "../../builtin/files/userdata/cloud-config-etcd"
+		stackTemplateOptions.RootStackTemplateTmplFile = "../../builtin/files/stack-templates/root.json.tmpl"
+		stackTemplateOptions.NodePoolStackTemplateTmplFile = "../../builtin/files/stack-templates/node-pool.json.tmpl"
+		stackTemplateOptions.ControlPlaneStackTemplateTmplFile = "../../builtin/files/stack-templates/control-plane.json.tmpl"
+		stackTemplateOptions.NetworkStackTemplateTmplFile = "../../builtin/files/stack-templates/network.json.tmpl"
+		stackTemplateOptions.EtcdStackTemplateTmplFile = "../../builtin/files/stack-templates/etcd.json.tmpl"
+
+		cl, err := root.CompileClusterFromConfig(providedConfig, stackTemplateOptions, false)
+		if err != nil {
+			t.Fatalf("failed to create cluster driver : %v", err)
+		}
+		cl.Context = &model.Context{
+			ProvidedEncryptService:  helper.DummyEncryptService{},
+			ProvidedCFInterrogator:  helper.DummyCFInterrogator{},
+			ProvidedEC2Interrogator: helper.DummyEC2Interrogator{},
+			StackTemplateGetter:     helper.DummyStackTemplateGetter{},
+		}
+
+		_, err = cl.EnsureAllAssetsGenerated()
+		expected := "custom resource \"Workers\" conflicts with the one of the same name defined by the node pool stack template"
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf(`expected "%s" to be contained in the error message : %v`, expected, err)
+		}
+	})
+}
+
+// kubeletFlagsIn returns the part of the userdata which contains the command-line flags passed to kubelet
+func kubeletFlagsIn(userdata string) string {
+	start := strings.Index(userdata, "/usr/lib/coreos/kubelet-wrapper")
+	if start < 0 {
+		return ""
+	}
+	flags := userdata[start:]
+	if end := strings.Index(flags, "$KUBELET_OPTS"); end >= 0 {
+		flags = flags[:end]
+	}
+	return flags
+}
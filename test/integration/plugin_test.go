@@ -40,6 +40,11 @@ func TestPlugin(t *testing.T) {
 			clusterYaml: minimalValidConfigYaml + `
 
 
+cloudformation:
+  stackDependencies:
+  - stack: pool1
+    dependsOn:
+    - QueueFromMyPlugin
 kubeAwsPlugins:
   myPlugin:
     enabled: true
@@ -449,6 +454,9 @@ spec:
 					if !strings.Contains(rootStackTemplate, `"QueueName":"baz1"`) {
 						t.Errorf("Invalid root stack template: missing QueueName baz1: %v", rootStackTemplate)
 					}
+					if !strings.Contains(rootStackTemplate, `"DependsOn":["Controlplane","QueueFromMyPlugin"]`) {
+						t.Errorf("Invalid root stack template: missing the dependency of the node pool on QueueFromMyPlugin: %v", rootStackTemplate)
+					}
 
 					nodePoolStackTemplate, err := np.RenderStackTemplateAsString()
 					if err != nil {
@@ -495,6 +503,82 @@ spec:
 				},
 			},
 		},
+		{
+			context: "WithKubernetesSecrets",
+			clusterYaml: minimalValidConfigYaml + `
+
+kubeAwsPlugins:
+  datadog:
+    enabled: true
+`,
+			plugins: []helper.TestPlugin{
+				helper.TestPlugin{
+					Name: "datadog",
+					Yaml: `
+metadata:
+  name: datadog
+  version: 0.0.1
+spec:
+  cluster:
+    kubernetes:
+      secrets:
+      - name: datadog
+        data:
+        - key: api-key
+          ssmParameter: /datadog/api-key
+        - key: app-key
+          secretsManagerSecret: datadog
+          jsonKey: appKey
+      configMaps:
+      - name: datadog-config
+        namespace: monitoring
+        data:
+        - key: site
+          ssmParameter: datadog-site
+`,
+				},
+			},
+			assertCluster: []ClusterTester{
+				func(c *root.Cluster, t *testing.T) {
+					cp := c.ControlPlane()
+
+					{
+						e := api.CustomFile{
+							Path:        "/srv/kube-aws/plugins/datadog/injected-objects/secret-kube-system-datadog.json",
+							Permissions: 0644,
+							Content:     `{"kind":"secret","namespace":"kube-system","name":"datadog","type":"Opaque","data":[{"key":"api-key","ssmParameter":"/datadog/api-key"},{"key":"app-key","secretsManagerSecret":"datadog","jsonKey":"appKey"}]}`,
+						}
+						a := cp.Config.Controller.CustomFiles[0]
+						if !reflect.DeepEqual(e, a) {
+							t.Errorf("Unexpected controller custom file from plugin: expected=%v actual=%v", e, a)
+						}
+					}
+					{
+						e := api.IAMPolicyStatements{
+							api.IAMPolicyStatement{
+								Effect:    "Allow",
+								Actions:   []string{"ssm:GetParameter"},
+								Resources: []string{"arn:aws:ssm:us-west-1:*:parameter/datadog/api-key", "arn:aws:ssm:us-west-1:*:parameter/datadog-site"},
+							},
+							api.IAMPolicyStatement{
+								Effect:    "Allow",
+								Actions:   []string{"secretsmanager:GetSecretValue"},
+								Resources: []string{"arn:aws:secretsmanager:us-west-1:*:secret:datadog-??????"},
+							},
+						}
+						a := cp.Config.Controller.IAMConfig.Policy.Statements
+						if !reflect.DeepEqual(e, a) {
+							t.Errorf("Unexpected controller iam policy statements from plugin: expected=%v actual=%v", e, a)
+						}
+					}
+
+					controllerUserdataS3Part := cp.UserData["Controller"].Parts[api.USERDATA_S3].Asset.Content
+					if !strings.Contains(controllerUserdataS3Part, "/srv/kube-aws/plugins/kubernetes-injected-objects") {
+						t.Errorf("missing the list of injected kubernetes objects in controller userdata: %v", controllerUserdataS3Part)
+					}
+				},
+			},
+		},
 	}
 
 	for _, validCase := range validCases {
@@ -528,6 +612,8 @@ spec:
 					stackTemplateOptions.AssetsDir = dummyAssetsDir
 					stackTemplateOptions.ControllerTmplFile = "../../builtin/files/userdata/cloud-config-controller"
 					stackTemplateOptions.WorkerTmplFile = "../../builtin/files/userdata/cloud-config-worker"
+					stackTemplateOptions.BottlerocketWorkerTmplFile = "../../builtin/files/userdata/bottlerocket-worker"
+					stackTemplateOptions.WindowsWorkerTmplFile = "../../builtin/files/userdata/windows-worker"
 					stackTemplateOptions.EtcdTmplFile = "../../builtin/files/userdata/cloud-config-etcd"
 					stackTemplateOptions.RootStackTemplateTmplFile = "../../builtin/files/stack-templates/root.json.tmpl"
 					stackTemplateOptions.NodePoolStackTemplateTmplFile = "../../builtin/files/stack-templates/node-pool.json.tmpl"